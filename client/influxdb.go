@@ -150,7 +150,13 @@ func (c *Client) Write(bp BatchPoints) (*Response, error) {
 	}
 	params := req.URL.Query()
 	params.Add("db", bp.Database)
-	params.Add("rp", bp.RetentionPolicy)
+	if len(bp.RetentionPolicies) > 0 {
+		for _, rp := range bp.RetentionPolicies {
+			params.Add("rp", rp)
+		}
+	} else {
+		params.Add("rp", bp.RetentionPolicy)
+	}
 	params.Add("precision", bp.Precision)
 	params.Add("consistency", bp.WriteConsistency)
 	req.URL.RawQuery = params.Encode()
@@ -452,37 +458,42 @@ func normalizeFields(fields map[string]interface{}) map[string]interface{} {
 // BatchPoints is used to send batched data in a single write.
 // Database and Points are required
 // If no retention policy is specified, it will use the databases default retention policy.
+// If RetentionPolicies is specified, the points are written to each of those policies in a single request.
+// The writes to each policy aren't atomic: if the request fails, the error names the policies that weren't written.
 // If tags are specified, they will be "merged" with all points.  If a point already has that tag, it is ignored.
 // If time is specified, it will be applied to any point with an empty time.
 // Precision can be specified if the time is in epoch format (integer).
 // Valid values for Precision are n, u, ms, s, m, and h
 type BatchPoints struct {
-	Points           []Point           `json:"points,omitempty"`
-	Database         string            `json:"database,omitempty"`
-	RetentionPolicy  string            `json:"retentionPolicy,omitempty"`
-	Tags             map[string]string `json:"tags,omitempty"`
-	Time             time.Time         `json:"time,omitempty"`
-	Precision        string            `json:"precision,omitempty"`
-	WriteConsistency string            `json:"-"`
+	Points            []Point           `json:"points,omitempty"`
+	Database          string            `json:"database,omitempty"`
+	RetentionPolicy   string            `json:"retentionPolicy,omitempty"`
+	RetentionPolicies []string          `json:"retentionPolicies,omitempty"`
+	Tags              map[string]string `json:"tags,omitempty"`
+	Time              time.Time         `json:"time,omitempty"`
+	Precision         string            `json:"precision,omitempty"`
+	WriteConsistency  string            `json:"-"`
 }
 
 // UnmarshalJSON decodes the data into the BatchPoints struct
 func (bp *BatchPoints) UnmarshalJSON(b []byte) error {
 	var normal struct {
-		Points          []Point           `json:"points"`
-		Database        string            `json:"database"`
-		RetentionPolicy string            `json:"retentionPolicy"`
-		Tags            map[string]string `json:"tags"`
-		Time            time.Time         `json:"time"`
-		Precision       string            `json:"precision"`
+		Points            []Point           `json:"points"`
+		Database          string            `json:"database"`
+		RetentionPolicy   string            `json:"retentionPolicy"`
+		RetentionPolicies []string          `json:"retentionPolicies"`
+		Tags              map[string]string `json:"tags"`
+		Time              time.Time         `json:"time"`
+		Precision         string            `json:"precision"`
 	}
 	var epoch struct {
-		Points          []Point           `json:"points"`
-		Database        string            `json:"database"`
-		RetentionPolicy string            `json:"retentionPolicy"`
-		Tags            map[string]string `json:"tags"`
-		Time            *int64            `json:"time"`
-		Precision       string            `json:"precision"`
+		Points            []Point           `json:"points"`
+		Database          string            `json:"database"`
+		RetentionPolicy   string            `json:"retentionPolicy"`
+		RetentionPolicies []string          `json:"retentionPolicies"`
+		Tags              map[string]string `json:"tags"`
+		Time              *int64            `json:"time"`
+		Precision         string            `json:"precision"`
 	}
 
 	if err := func() error {
@@ -501,6 +512,7 @@ func (bp *BatchPoints) UnmarshalJSON(b []byte) error {
 		bp.Points = epoch.Points
 		bp.Database = epoch.Database
 		bp.RetentionPolicy = epoch.RetentionPolicy
+		bp.RetentionPolicies = epoch.RetentionPolicies
 		bp.Tags = epoch.Tags
 		bp.Time = ts
 		bp.Precision = epoch.Precision
@@ -516,6 +528,7 @@ func (bp *BatchPoints) UnmarshalJSON(b []byte) error {
 	bp.Points = normal.Points
 	bp.Database = normal.Database
 	bp.RetentionPolicy = normal.RetentionPolicy
+	bp.RetentionPolicies = normal.RetentionPolicies
 	bp.Tags = normal.Tags
 	bp.Time = normal.Time
 	bp.Precision = normal.Precision
@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// maxPooledBufferSize is the largest message buffer returned to the pool so
+// a single large message doesn't hold on to its memory.
+const maxPooledBufferSize = 4 * 1024 * 1024 // 4MB
+
+// BufferPoolStats counts the message buffers used by the service and how
+// many of them had to be allocated instead of reused.
+type BufferPoolStats struct {
+	Gets   int64
+	Allocs int64
+}
+
+// bufferPool reuses the buffers that messages are read into.
+type bufferPool struct {
+	pool   sync.Pool
+	gets   int64 // atomic
+	allocs int64 // atomic
+}
+
+// get returns a buffer with a length of n.
+func (p *bufferPool) get(n int) *[]byte {
+	atomic.AddInt64(&p.gets, 1)
+	if b, ok := p.pool.Get().(*[]byte); ok && cap(*b) >= n {
+		*b = (*b)[:n]
+		return b
+	}
+	atomic.AddInt64(&p.allocs, 1)
+	b := make([]byte, n)
+	return &b
+}
+
+// put returns a buffer to the pool. The buffer must no longer be referenced.
+func (p *bufferPool) put(b *[]byte) {
+	if cap(*b) > maxPooledBufferSize {
+		return
+	}
+	p.pool.Put(b)
+}
+
+// stats returns the pool's counters.
+func (p *bufferPool) stats() BufferPoolStats {
+	return BufferPoolStats{
+		Gets:   atomic.LoadInt64(&p.gets),
+		Allocs: atomic.LoadInt64(&p.allocs),
+	}
+}
+
+// BufferPoolStats returns the number of buffers messages have been read into
+// and how many of them were allocated.
+func (s *Service) BufferPoolStats() BufferPoolStats { return s.buffers.stats() }
+
+// StatisticsRow returns the buffer pool counters for SHOW STATS.
+func (s *Service) StatisticsRow() *influxql.Row {
+	st := s.BufferPoolStats()
+	return &influxql.Row{
+		Name:    "clusterBufferPool",
+		Columns: []string{"gets", "allocs"},
+		Values:  [][]interface{}{{st.Gets, st.Allocs}},
+	}
+}
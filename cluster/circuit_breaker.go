@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a write to a node is skipped because
+// recent writes to it have failed.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitBreaker tracks consecutive write failures for each remote node.
+// Once a node reaches the failure threshold its circuit opens and writes to
+// it are skipped for the cool-down period. After the cool-down a single
+// write is let through: success closes the circuit and failure opens it
+// for another cool-down.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	nodes     map[uint64]*circuit
+	threshold int
+	cooldown  time.Duration
+
+	// Now returns the current time. Overridden for testing.
+	Now func() time.Time
+}
+
+// circuit is the state of a single node's circuit.
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker returns a new breaker that opens after threshold
+// consecutive failures and stays open for the cool-down period.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		nodes:     make(map[uint64]*circuit),
+		threshold: threshold,
+		cooldown:  cooldown,
+		Now:       time.Now,
+	}
+}
+
+// Allow returns true if a write to the node should be attempted. Every
+// allowed write must be followed by a call to Success or Failure.
+func (b *CircuitBreaker) Allow(nodeID uint64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.nodes[nodeID]
+	if c == nil || c.failures < b.threshold {
+		return true
+	} else if b.Now().Before(c.openUntil) || c.probing {
+		return false
+	}
+
+	// The cool-down has passed so let a single write test the node.
+	c.probing = true
+	return true
+}
+
+// Success records a successful write to the node and closes its circuit.
+func (b *CircuitBreaker) Success(nodeID uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.nodes, nodeID)
+}
+
+// Failure records a failed write to the node. Returns true if the failure
+// opened the node's circuit.
+func (b *CircuitBreaker) Failure(nodeID uint64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.nodes[nodeID]
+	if c == nil {
+		c = &circuit{}
+		b.nodes[nodeID] = c
+	}
+	c.failures++
+	c.probing = false
+
+	if c.failures < b.threshold {
+		return false
+	}
+	c.openUntil = b.Now().Add(b.cooldown)
+	return true
+}
@@ -0,0 +1,61 @@
+package cluster_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+)
+
+// Ensure a node's circuit opens after consecutive failures and lets a single
+// write through once the cool-down has passed.
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := cluster.NewCircuitBreaker(2, time.Minute)
+	b.Now = func() time.Time { return now }
+
+	// A success resets the failure count.
+	if !b.Allow(1) {
+		t.Fatal("expected write to be allowed")
+	} else if b.Failure(1) {
+		t.Fatal("unexpected open circuit")
+	}
+	b.Success(1)
+	if b.Failure(1) {
+		t.Fatal("unexpected open circuit")
+	} else if !b.Failure(1) {
+		t.Fatal("expected open circuit")
+	}
+
+	// Writes are skipped during the cool-down. Other nodes are unaffected.
+	if b.Allow(1) {
+		t.Fatal("expected write to be skipped")
+	} else if !b.Allow(2) {
+		t.Fatal("expected write to other node to be allowed")
+	}
+
+	// Only one write is let through after the cool-down.
+	now = now.Add(time.Minute)
+	if !b.Allow(1) {
+		t.Fatal("expected probe write to be allowed")
+	} else if b.Allow(1) {
+		t.Fatal("expected write to be skipped during probe")
+	}
+
+	// A failed probe opens the circuit for another cool-down.
+	if !b.Failure(1) {
+		t.Fatal("expected open circuit")
+	} else if b.Allow(1) {
+		t.Fatal("expected write to be skipped")
+	}
+
+	// A successful probe closes the circuit.
+	now = now.Add(time.Minute)
+	if !b.Allow(1) {
+		t.Fatal("expected probe write to be allowed")
+	}
+	b.Success(1)
+	if !b.Allow(1) || !b.Allow(1) {
+		t.Fatal("expected writes to be allowed")
+	}
+}
@@ -3,28 +3,27 @@ package cluster
 import (
 	"net"
 	"sync"
-
-	"gopkg.in/fatih/pool.v2"
+	"time"
 )
 
 type clientPool struct {
 	mu   sync.RWMutex
-	pool map[uint64]pool.Pool
+	pool map[uint64]*connPool
 }
 
 func newClientPool() *clientPool {
 	return &clientPool{
-		pool: make(map[uint64]pool.Pool),
+		pool: make(map[uint64]*connPool),
 	}
 }
 
-func (c *clientPool) setPool(nodeID uint64, p pool.Pool) {
+func (c *clientPool) setPool(nodeID uint64, p *connPool) {
 	c.mu.Lock()
 	c.pool[nodeID] = p
 	c.mu.Unlock()
 }
 
-func (c *clientPool) getPool(nodeID uint64) (pool.Pool, bool) {
+func (c *clientPool) getPool(nodeID uint64) (*connPool, bool) {
 	c.mu.RLock()
 	p, ok := c.pool[nodeID]
 	c.mu.RUnlock()
@@ -48,6 +47,28 @@ func (c *clientPool) conn(nodeID uint64) (net.Conn, error) {
 	return conn, err
 }
 
+// reap closes expired idle connections in every pool.
+func (c *clientPool) reap(now time.Time) int {
+	c.mu.RLock()
+	var n int
+	for _, p := range c.pool {
+		n += p.reap(now)
+	}
+	c.mu.RUnlock()
+	return n
+}
+
+// stats returns the state of the pool for each node.
+func (c *clientPool) stats() map[uint64]PoolStats {
+	c.mu.RLock()
+	m := make(map[uint64]PoolStats, len(c.pool))
+	for nodeID, p := range c.pool {
+		m[nodeID] = p.Stats()
+	}
+	c.mu.RUnlock()
+	return m
+}
+
 func (c *clientPool) close() {
 	c.mu.Lock()
 	for _, p := range c.pool {
@@ -9,16 +9,66 @@ import (
 const (
 	// DefaultShardWriterTimeout is the default timeout set on shard writers.
 	DefaultShardWriterTimeout = 5 * time.Second
+
+	// DefaultShardWriterFailureThreshold is the default number of consecutive
+	// failed writes to a node before its writes go to hinted handoff.
+	DefaultShardWriterFailureThreshold = 5
+
+	// DefaultShardWriterCooldown is the default time writes to a failing node
+	// go to hinted handoff before it is tried again.
+	DefaultShardWriterCooldown = 10 * time.Second
+
+	// DefaultNodeCacheTTL is the default time node lookups are cached by
+	// shard writers.
+	DefaultNodeCacheTTL = 1 * time.Minute
+
+	// DefaultPoolMaxIdleTime is the default time a pooled connection to
+	// another node can sit idle before it is closed.
+	DefaultPoolMaxIdleTime = 1 * time.Minute
+
+	// DefaultPoolMaxLifetime is the default time a pooled connection to
+	// another node is kept open before it is recycled.
+	DefaultPoolMaxLifetime = 10 * time.Minute
 )
 
 // Config represents the configuration for the the clustering service.
 type Config struct {
 	ShardWriterTimeout toml.Duration `toml:"shard-writer-timeout"`
+
+	// Consecutive failed writes to a node before writes to it go directly to
+	// hinted handoff for the cool-down period. Zero disables this.
+	ShardWriterFailureThreshold int           `toml:"shard-writer-failure-threshold"`
+	ShardWriterCooldown         toml.Duration `toml:"shard-writer-cooldown"`
+
+	// Maximum number of open inbound connections from other nodes and the
+	// maximum number accepted per second. They apply to every connection on
+	// the shared cluster port, before it is passed to a service. Zero means
+	// no limit.
+	MaxConnections    int `toml:"max-connections"`
+	MaxConnectionRate int `toml:"max-connection-rate"`
+
+	// How long shard writers cache node lookups. Cached nodes are dropped
+	// whenever the cluster topology changes. Zero disables the cache.
+	NodeCacheTTL toml.Duration `toml:"node-cache-ttl"`
+
+	// Pooled connections to other nodes are closed after sitting idle for
+	// PoolMaxIdleTime or being open for PoolMaxLifetime. Zero disables either.
+	PoolMaxIdleTime toml.Duration `toml:"pool-max-idle-time"`
+	PoolMaxLifetime toml.Duration `toml:"pool-max-lifetime"`
+
+	// Record the fields and tag keys of written measurements in the meta
+	// store and reject writes whose field types conflict cluster-wide.
+	SchemaRegistryEnabled bool `toml:"schema-registry-enabled"`
 }
 
 // NewConfig returns an instance of Config with defaults.
 func NewConfig() Config {
 	return Config{
-		ShardWriterTimeout: toml.Duration(DefaultShardWriterTimeout),
+		ShardWriterTimeout:          toml.Duration(DefaultShardWriterTimeout),
+		ShardWriterFailureThreshold: DefaultShardWriterFailureThreshold,
+		ShardWriterCooldown:         toml.Duration(DefaultShardWriterCooldown),
+		NodeCacheTTL:                toml.Duration(DefaultNodeCacheTTL),
+		PoolMaxIdleTime:             toml.Duration(DefaultPoolMaxIdleTime),
+		PoolMaxLifetime:             toml.Duration(DefaultPoolMaxLifetime),
 	}
 }
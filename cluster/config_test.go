@@ -13,6 +13,13 @@ func TestConfig_Parse(t *testing.T) {
 	var c cluster.Config
 	if _, err := toml.Decode(`
 shard-writer-timeout = "10s"
+shard-writer-failure-threshold = 3
+shard-writer-cooldown = "30s"
+max-connections = 100
+max-connection-rate = 20
+node-cache-ttl = "2m"
+pool-max-idle-time = "30s"
+pool-max-lifetime = "5m"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -20,5 +27,19 @@ shard-writer-timeout = "10s"
 	// Validate configuration.
 	if time.Duration(c.ShardWriterTimeout) != 10*time.Second {
 		t.Fatalf("unexpected bind address: %s", c.ShardWriterTimeout)
+	} else if c.ShardWriterFailureThreshold != 3 {
+		t.Fatalf("unexpected failure threshold: %d", c.ShardWriterFailureThreshold)
+	} else if time.Duration(c.ShardWriterCooldown) != 30*time.Second {
+		t.Fatalf("unexpected cooldown: %s", c.ShardWriterCooldown)
+	} else if c.MaxConnections != 100 {
+		t.Fatalf("unexpected max connections: %d", c.MaxConnections)
+	} else if c.MaxConnectionRate != 20 {
+		t.Fatalf("unexpected max connection rate: %d", c.MaxConnectionRate)
+	} else if time.Duration(c.NodeCacheTTL) != 2*time.Minute {
+		t.Fatalf("unexpected node cache ttl: %s", c.NodeCacheTTL)
+	} else if time.Duration(c.PoolMaxIdleTime) != 30*time.Second {
+		t.Fatalf("unexpected pool max idle time: %s", c.PoolMaxIdleTime)
+	} else if time.Duration(c.PoolMaxLifetime) != 5*time.Minute {
+		t.Fatalf("unexpected pool max lifetime: %s", c.PoolMaxLifetime)
 	}
 }
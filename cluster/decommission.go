@@ -0,0 +1,154 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/influxdb/influxdb/meta"
+)
+
+// ErrNoShardTarget is returned when a shard of a decommissioned node has no
+// other node that it can be moved to.
+var ErrNoShardTarget = errors.New("no node available to take shard")
+
+// Decommissioner removes a data node from the cluster without losing data.
+//
+// The node is first marked as draining so no new shards are assigned to it.
+// Each shard it owns is then copied to the node owning the fewest shards that
+// doesn't already own it, and ownership is moved to that node in a single
+// metastore command. The shard is then copied again so the points written to
+// it between the first copy and the ownership change reach the new owner;
+// importing a copy of a shard that exists merges the points into it. The
+// node is only removed once it owns no shards.
+type Decommissioner struct {
+	MetaStore interface {
+		Node(id uint64) (*meta.NodeInfo, error)
+		Nodes() ([]meta.NodeInfo, error)
+		Databases() ([]meta.DatabaseInfo, error)
+		SetNodeDraining(id uint64, draining bool) error
+		ReassignShard(shardID, oldOwnerID, newOwnerID uint64) error
+		DeleteNode(id uint64, force bool) error
+	}
+
+	ShardCopier interface {
+		CopyShard(shardID, ownerID uint64, database, policy, sourceHost string) error
+	}
+
+	Logger *log.Logger
+}
+
+// NewDecommissioner returns a new instance of Decommissioner.
+func NewDecommissioner() *Decommissioner {
+	return &Decommissioner{
+		Logger: log.New(os.Stderr, "[decommission] ", log.LstdFlags),
+	}
+}
+
+// Decommission moves all shards off of a node and removes it from the
+// cluster. It can be run again to resume after a failure.
+func (d *Decommissioner) Decommission(nodeID uint64) error {
+	ni, err := d.MetaStore.Node(nodeID)
+	if err != nil {
+		return err
+	} else if ni == nil {
+		return meta.ErrNodeNotFound
+	}
+
+	if !ni.Draining {
+		if err := d.MetaStore.SetNodeDraining(nodeID, true); err != nil {
+			return fmt.Errorf("set draining: %s", err)
+		}
+	}
+	d.Logger.Printf("draining node %d (%s)", nodeID, ni.Host)
+
+	nodes, err := d.MetaStore.Nodes()
+	if err != nil {
+		return err
+	}
+	dis, err := d.MetaStore.Databases()
+	if err != nil {
+		return err
+	}
+
+	// Count the shards owned by each node that can take new shards.
+	counts := make(map[uint64]int)
+	for _, n := range nodes {
+		if !n.Draining && n.ID != nodeID {
+			counts[n.ID] = 0
+		}
+	}
+	for _, di := range dis {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					for _, id := range si.OwnerIDs {
+						if _, ok := counts[id]; ok {
+							counts[id]++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Move each shard owned by the node.
+	for _, di := range dis {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					if !si.OwnedBy(nodeID) {
+						continue
+					}
+
+					targetID := shardTarget(counts, si)
+					if targetID == 0 {
+						return fmt.Errorf("shard %d: %s", si.ID, ErrNoShardTarget)
+					}
+
+					if err := d.ShardCopier.CopyShard(si.ID, targetID, di.Name, rpi.Name, ni.Host); err != nil {
+						return fmt.Errorf("copy shard %d to node %d: %s", si.ID, targetID, err)
+					}
+					if err := d.MetaStore.ReassignShard(si.ID, nodeID, targetID); err != nil {
+						return fmt.Errorf("reassign shard %d to node %d: %s", si.ID, targetID, err)
+					}
+					counts[targetID]++
+
+					// Catch up with the writes made before the new owner took over.
+					if err := d.ShardCopier.CopyShard(si.ID, targetID, di.Name, rpi.Name, ni.Host); err != nil {
+						return fmt.Errorf("catch up shard %d on node %d: %s", si.ID, targetID, err)
+					}
+					d.Logger.Printf("moved shard %d from node %d to node %d", si.ID, nodeID, targetID)
+				}
+			}
+		}
+	}
+
+	if err := d.MetaStore.DeleteNode(nodeID, false); err != nil {
+		return fmt.Errorf("delete node: %s", err)
+	}
+	d.Logger.Printf("removed node %d (%s)", nodeID, ni.Host)
+	return nil
+}
+
+// shardTarget returns the node with the fewest shards that doesn't already
+// own the shard. Ties go to the lowest node id. Returns zero if there is none.
+func shardTarget(counts map[uint64]int, si meta.ShardInfo) uint64 {
+	var targetID uint64
+	for id, n := range counts {
+		if si.OwnedBy(id) {
+			continue
+		}
+		if targetID == 0 || n < counts[targetID] || (n == counts[targetID] && id < targetID) {
+			targetID = id
+		}
+	}
+	return targetID
+}
@@ -0,0 +1,141 @@
+package cluster_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/meta"
+)
+
+// Ensure a node's shards are moved to the least loaded nodes before it is removed.
+func TestDecommissioner_Decommission(t *testing.T) {
+	ms := NewDecommissionMetaStore(t, 2, 2)
+	for _, host := range []string{"host2", "host3"} {
+		if err := ms.Data.CreateNode(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var copies []string
+	d := NewDecommissioner(ms)
+	d.ShardCopier = &ShardCopier{
+		CopyShardFn: func(shardID, ownerID uint64, database, policy, sourceHost string) error {
+			copies = append(copies, fmt.Sprintf("%d:%d:%s:%s:%s", shardID, ownerID, database, policy, sourceHost))
+			return nil
+		},
+	}
+
+	if err := d.Decommission(2); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(copies, []string{"1:3:db0:rp0:host1", "1:3:db0:rp0:host1", "2:4:db0:rp0:host1", "2:4:db0:rp0:host1"}) {
+		t.Fatalf("unexpected copies: %v", copies)
+	} else if ms.Data.Node(2) != nil {
+		t.Fatal("node not removed")
+	}
+
+	rpi, _ := ms.Data.RetentionPolicy("db0", "rp0")
+	if owners := [][]uint64{rpi.ShardGroups[0].Shards[0].OwnerIDs, rpi.ShardGroups[1].Shards[0].OwnerIDs}; !reflect.DeepEqual(owners, [][]uint64{{1, 3}, {4, 1}}) {
+		t.Fatalf("unexpected owners: %v", owners)
+	}
+}
+
+// Ensure ownership isn't changed and the node isn't removed when a copy fails.
+func TestDecommissioner_Decommission_CopyError(t *testing.T) {
+	ms := NewDecommissionMetaStore(t, 2, 1)
+	if err := ms.Data.CreateNode("host2"); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecommissioner(ms)
+	d.ShardCopier = &ShardCopier{
+		CopyShardFn: func(shardID, ownerID uint64, database, policy, sourceHost string) error {
+			return errors.New("marker")
+		},
+	}
+
+	if err := d.Decommission(2); err == nil || err.Error() != "copy shard 1 to node 3: marker" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if ni := ms.Data.Node(2); ni == nil || !ni.Draining {
+		t.Fatalf("unexpected node: %#v", ni)
+	}
+
+	rpi, _ := ms.Data.RetentionPolicy("db0", "rp0")
+	if owners := rpi.ShardGroups[0].Shards[0].OwnerIDs; !reflect.DeepEqual(owners, []uint64{1, 2}) {
+		t.Fatalf("unexpected owners: %v", owners)
+	}
+}
+
+// Ensure an error is returned when there is no node to move a shard to.
+func TestDecommissioner_Decommission_ErrNoShardTarget(t *testing.T) {
+	d := NewDecommissioner(NewDecommissionMetaStore(t, 2, 1))
+	d.ShardCopier = &ShardCopier{}
+	if err := d.Decommission(2); err == nil || err.Error() != "shard 1: "+cluster.ErrNoShardTarget.Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// NewDecommissioner returns a decommissioner with logging discarded.
+func NewDecommissioner(ms *DecommissionMetaStore) *cluster.Decommissioner {
+	d := cluster.NewDecommissioner()
+	d.MetaStore = ms
+	d.Logger = log.New(ioutil.Discard, "", 0)
+	return d
+}
+
+// DecommissionMetaStore is a metastore for the decommissioner backed by meta.Data.
+type DecommissionMetaStore struct {
+	Data meta.Data
+}
+
+// NewDecommissionMetaStore returns a metastore with nodeN nodes and groupN
+// shard groups, each with a shard replicated to every node.
+func NewDecommissionMetaStore(t *testing.T, nodeN, groupN int) *DecommissionMetaStore {
+	ms := &DecommissionMetaStore{}
+	for i := 0; i < nodeN; i++ {
+		if err := ms.Data.CreateNode(fmt.Sprintf("host%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ms.Data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := ms.Data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: nodeN, Duration: time.Hour, ShardGroupDuration: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < groupN; i++ {
+		ms.Data.Index = uint64(i)
+		if err := ms.Data.CreateShardGroup("db0", "rp0", time.Unix(0, 0).Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ms
+}
+
+func (m *DecommissionMetaStore) Node(id uint64) (*meta.NodeInfo, error) { return m.Data.Node(id), nil }
+func (m *DecommissionMetaStore) Nodes() ([]meta.NodeInfo, error)        { return m.Data.Nodes, nil }
+func (m *DecommissionMetaStore) Databases() ([]meta.DatabaseInfo, error) {
+	return m.Data.Databases, nil
+}
+func (m *DecommissionMetaStore) SetNodeDraining(id uint64, draining bool) error {
+	return m.Data.SetNodeDraining(id, draining)
+}
+func (m *DecommissionMetaStore) ReassignShard(shardID, oldOwnerID, newOwnerID uint64) error {
+	return m.Data.ReassignShard(shardID, oldOwnerID, newOwnerID)
+}
+func (m *DecommissionMetaStore) DeleteNode(id uint64, force bool) error {
+	return m.Data.DeleteNode(id, force)
+}
+
+// ShardCopier is a mock implementation of Decommissioner.ShardCopier.
+type ShardCopier struct {
+	CopyShardFn func(shardID, ownerID uint64, database, policy, sourceHost string) error
+}
+
+func (c *ShardCopier) CopyShard(shardID, ownerID uint64, database, policy, sourceHost string) error {
+	return c.CopyShardFn(shardID, ownerID, database, policy, sourceHost)
+}
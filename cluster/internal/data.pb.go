@@ -14,6 +14,14 @@ It has these top-level messages:
 	Tag
 	Point
 	WriteShardResponse
+	DeleteShardRequest
+	DeleteShardResponse
+	CopyShardRequest
+	CopyShardResponse
+	ReadShardRequest
+	ReadShardResponse
+	DeleteSeriesRangeRequest
+	DeleteSeriesRangeResponse
 */
 package internal
 
@@ -27,6 +35,7 @@ var _ = math.Inf
 type WriteShardRequest struct {
 	ShardID          *uint64  `protobuf:"varint,1,req" json:"ShardID,omitempty"`
 	Points           []*Point `protobuf:"bytes,2,rep" json:"Points,omitempty"`
+	Durability       *uint32  `protobuf:"varint,3,opt" json:"Durability,omitempty"`
 	XXX_unrecognized []byte   `json:"-"`
 }
 
@@ -48,6 +57,13 @@ func (m *WriteShardRequest) GetPoints() []*Point {
 	return nil
 }
 
+func (m *WriteShardRequest) GetDurability() uint32 {
+	if m != nil && m.Durability != nil {
+		return *m.Durability
+	}
+	return 0
+}
+
 type Field struct {
 	Name             *string  `protobuf:"bytes,1,req" json:"Name,omitempty"`
 	Int32            *int32   `protobuf:"varint,2,opt" json:"Int32,omitempty"`
@@ -56,6 +72,7 @@ type Field struct {
 	Bool             *bool    `protobuf:"varint,5,opt" json:"Bool,omitempty"`
 	String_          *string  `protobuf:"bytes,6,opt" json:"String,omitempty"`
 	Bytes            []byte   `protobuf:"bytes,7,opt" json:"Bytes,omitempty"`
+	Uint64           *uint64  `protobuf:"varint,8,opt" json:"Uint64,omitempty"`
 	XXX_unrecognized []byte   `json:"-"`
 }
 
@@ -112,6 +129,13 @@ func (m *Field) GetBytes() []byte {
 	return nil
 }
 
+func (m *Field) GetUint64() uint64 {
+	if m != nil && m.Uint64 != nil {
+		return *m.Uint64
+	}
+	return 0
+}
+
 type Tag struct {
 	Key              *string `protobuf:"bytes,1,req" json:"Key,omitempty"`
 	Value            *string `protobuf:"bytes,2,req" json:"Value,omitempty"`
@@ -200,5 +224,221 @@ func (m *WriteShardResponse) GetMessage() string {
 	return ""
 }
 
+type DeleteShardRequest struct {
+	ShardID          *uint64 `protobuf:"varint,1,req" json:"ShardID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DeleteShardRequest) Reset()         { *m = DeleteShardRequest{} }
+func (m *DeleteShardRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteShardRequest) ProtoMessage()    {}
+
+func (m *DeleteShardRequest) GetShardID() uint64 {
+	if m != nil && m.ShardID != nil {
+		return *m.ShardID
+	}
+	return 0
+}
+
+type DeleteShardResponse struct {
+	Code             *int32  `protobuf:"varint,1,req" json:"Code,omitempty"`
+	Message          *string `protobuf:"bytes,2,opt" json:"Message,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DeleteShardResponse) Reset()         { *m = DeleteShardResponse{} }
+func (m *DeleteShardResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteShardResponse) ProtoMessage()    {}
+
+func (m *DeleteShardResponse) GetCode() int32 {
+	if m != nil && m.Code != nil {
+		return *m.Code
+	}
+	return 0
+}
+
+func (m *DeleteShardResponse) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+type CopyShardRequest struct {
+	ShardID          *uint64 `protobuf:"varint,1,req" json:"ShardID,omitempty"`
+	Database         *string `protobuf:"bytes,2,req" json:"Database,omitempty"`
+	Policy           *string `protobuf:"bytes,3,req" json:"Policy,omitempty"`
+	SourceHost       *string `protobuf:"bytes,4,req" json:"SourceHost,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *CopyShardRequest) Reset()         { *m = CopyShardRequest{} }
+func (m *CopyShardRequest) String() string { return proto.CompactTextString(m) }
+func (*CopyShardRequest) ProtoMessage()    {}
+
+func (m *CopyShardRequest) GetShardID() uint64 {
+	if m != nil && m.ShardID != nil {
+		return *m.ShardID
+	}
+	return 0
+}
+
+func (m *CopyShardRequest) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *CopyShardRequest) GetPolicy() string {
+	if m != nil && m.Policy != nil {
+		return *m.Policy
+	}
+	return ""
+}
+
+func (m *CopyShardRequest) GetSourceHost() string {
+	if m != nil && m.SourceHost != nil {
+		return *m.SourceHost
+	}
+	return ""
+}
+
+type CopyShardResponse struct {
+	Code             *int32  `protobuf:"varint,1,req" json:"Code,omitempty"`
+	Message          *string `protobuf:"bytes,2,opt" json:"Message,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *CopyShardResponse) Reset()         { *m = CopyShardResponse{} }
+func (m *CopyShardResponse) String() string { return proto.CompactTextString(m) }
+func (*CopyShardResponse) ProtoMessage()    {}
+
+func (m *CopyShardResponse) GetCode() int32 {
+	if m != nil && m.Code != nil {
+		return *m.Code
+	}
+	return 0
+}
+
+func (m *CopyShardResponse) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+type ReadShardRequest struct {
+	ShardID          *uint64 `protobuf:"varint,1,req" json:"ShardID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ReadShardRequest) Reset()         { *m = ReadShardRequest{} }
+func (m *ReadShardRequest) String() string { return proto.CompactTextString(m) }
+func (*ReadShardRequest) ProtoMessage()    {}
+
+func (m *ReadShardRequest) GetShardID() uint64 {
+	if m != nil && m.ShardID != nil {
+		return *m.ShardID
+	}
+	return 0
+}
+
+type ReadShardResponse struct {
+	Code             *int32  `protobuf:"varint,1,req" json:"Code,omitempty"`
+	Message          *string `protobuf:"bytes,2,opt" json:"Message,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ReadShardResponse) Reset()         { *m = ReadShardResponse{} }
+func (m *ReadShardResponse) String() string { return proto.CompactTextString(m) }
+func (*ReadShardResponse) ProtoMessage()    {}
+
+func (m *ReadShardResponse) GetCode() int32 {
+	if m != nil && m.Code != nil {
+		return *m.Code
+	}
+	return 0
+}
+
+func (m *ReadShardResponse) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+type DeleteSeriesRangeRequest struct {
+	ShardID          *uint64 `protobuf:"varint,1,req" json:"ShardID,omitempty"`
+	Measurement      *string `protobuf:"bytes,2,req" json:"Measurement,omitempty"`
+	Condition        *string `protobuf:"bytes,3,opt" json:"Condition,omitempty"`
+	MinTime          *int64  `protobuf:"varint,4,req" json:"MinTime,omitempty"`
+	MaxTime          *int64  `protobuf:"varint,5,req" json:"MaxTime,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DeleteSeriesRangeRequest) Reset()         { *m = DeleteSeriesRangeRequest{} }
+func (m *DeleteSeriesRangeRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteSeriesRangeRequest) ProtoMessage()    {}
+
+func (m *DeleteSeriesRangeRequest) GetShardID() uint64 {
+	if m != nil && m.ShardID != nil {
+		return *m.ShardID
+	}
+	return 0
+}
+
+func (m *DeleteSeriesRangeRequest) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
+func (m *DeleteSeriesRangeRequest) GetCondition() string {
+	if m != nil && m.Condition != nil {
+		return *m.Condition
+	}
+	return ""
+}
+
+func (m *DeleteSeriesRangeRequest) GetMinTime() int64 {
+	if m != nil && m.MinTime != nil {
+		return *m.MinTime
+	}
+	return 0
+}
+
+func (m *DeleteSeriesRangeRequest) GetMaxTime() int64 {
+	if m != nil && m.MaxTime != nil {
+		return *m.MaxTime
+	}
+	return 0
+}
+
+type DeleteSeriesRangeResponse struct {
+	Code             *int32  `protobuf:"varint,1,req" json:"Code,omitempty"`
+	Message          *string `protobuf:"bytes,2,opt" json:"Message,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DeleteSeriesRangeResponse) Reset()         { *m = DeleteSeriesRangeResponse{} }
+func (m *DeleteSeriesRangeResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteSeriesRangeResponse) ProtoMessage()    {}
+
+func (m *DeleteSeriesRangeResponse) GetCode() int32 {
+	if m != nil && m.Code != nil {
+		return *m.Code
+	}
+	return 0
+}
+
+func (m *DeleteSeriesRangeResponse) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
 func init() {
 }
@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/meta"
+)
+
+// NodeMetaStore looks up nodes and reports changes to the cluster topology.
+type NodeMetaStore interface {
+	Node(id uint64) (ni *meta.NodeInfo, err error)
+	Watch() <-chan struct{}
+}
+
+// nodeCache caches node lookups from the meta store. Entries expire after a
+// TTL and the whole cache is cleared when the meta store reports a change,
+// so a node that moves is picked up on the next lookup.
+type nodeCache struct {
+	mu      sync.Mutex
+	nodes   map[uint64]nodeCacheEntry
+	changed <-chan struct{}
+
+	ttl       time.Duration
+	metaStore NodeMetaStore
+}
+
+type nodeCacheEntry struct {
+	node    *meta.NodeInfo
+	expires time.Time
+}
+
+// newNodeCache returns a cache of node lookups from s. Entries are kept for ttl.
+func newNodeCache(s NodeMetaStore, ttl time.Duration) *nodeCache {
+	return &nodeCache{
+		nodes:     make(map[uint64]nodeCacheEntry),
+		changed:   s.Watch(),
+		ttl:       ttl,
+		metaStore: s,
+	}
+}
+
+// Node returns the node with the given id. Nodes that don't exist aren't cached.
+func (c *nodeCache) Node(id uint64) (*meta.NodeInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Clear the cache if the topology has changed since it was filled. The
+	// watch is renewed before the lookup so a change during it isn't missed.
+	if isClosed(c.changed) {
+		c.nodes = make(map[uint64]nodeCacheEntry)
+		c.changed = c.metaStore.Watch()
+	}
+
+	now := time.Now()
+	if e, ok := c.nodes[id]; ok && now.Before(e.expires) {
+		return e.node, nil
+	}
+
+	ni, err := c.metaStore.Node(id)
+	if err != nil {
+		return nil, err
+	} else if ni == nil {
+		delete(c.nodes, id)
+		return nil, nil
+	}
+	c.nodes[id] = nodeCacheEntry{node: ni, expires: now.Add(c.ttl)}
+	return ni, nil
+}
+
+// isClosed returns true if ch is closed.
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
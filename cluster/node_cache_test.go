@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/meta"
+)
+
+// Ensure node lookups are cached until they expire.
+func TestNodeCache_TTL(t *testing.T) {
+	ms := &nodeCacheMetaStore{host: "host0", changed: make(chan struct{})}
+	c := newNodeCache(ms, 50*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if ni, err := c.Node(1); err != nil {
+			t.Fatal(err)
+		} else if ni.Host != "host0" {
+			t.Fatalf("unexpected host: %s", ni.Host)
+		}
+	}
+	if ms.lookups != 1 {
+		t.Fatalf("unexpected lookups: %d", ms.lookups)
+	}
+
+	// Expired entries are looked up again.
+	ms.host = "host1"
+	time.Sleep(100 * time.Millisecond)
+	if ni, err := c.Node(1); err != nil {
+		t.Fatal(err)
+	} else if ni.Host != "host1" {
+		t.Fatalf("unexpected host: %s", ni.Host)
+	} else if ms.lookups != 2 {
+		t.Fatalf("unexpected lookups: %d", ms.lookups)
+	}
+}
+
+// Ensure the cache is cleared when the meta store reports a change.
+func TestNodeCache_Watch(t *testing.T) {
+	ms := &nodeCacheMetaStore{host: "host0", changed: make(chan struct{})}
+	c := newNodeCache(ms, time.Hour)
+
+	if _, err := c.Node(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Move the node and signal the change.
+	ms.host = "host1"
+	close(ms.changed)
+	ms.changed = make(chan struct{})
+
+	if ni, err := c.Node(1); err != nil {
+		t.Fatal(err)
+	} else if ni.Host != "host1" {
+		t.Fatalf("unexpected host: %s", ni.Host)
+	} else if _, err := c.Node(1); err != nil {
+		t.Fatal(err)
+	} else if ms.lookups != 2 {
+		t.Fatalf("unexpected lookups: %d", ms.lookups)
+	}
+}
+
+// Ensure missing nodes aren't cached.
+func TestNodeCache_NotFound(t *testing.T) {
+	ms := &nodeCacheMetaStore{changed: make(chan struct{})}
+	c := newNodeCache(ms, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if ni, err := c.Node(1); err != nil {
+			t.Fatal(err)
+		} else if ni != nil {
+			t.Fatalf("unexpected node: %#v", ni)
+		}
+	}
+	if ms.lookups != 2 {
+		t.Fatalf("unexpected lookups: %d", ms.lookups)
+	}
+}
+
+// nodeCacheMetaStore is a mock meta store that counts node lookups. Nodes
+// are only found if host is set.
+type nodeCacheMetaStore struct {
+	host    string
+	changed chan struct{}
+	lookups int
+}
+
+func (m *nodeCacheMetaStore) Node(id uint64) (*meta.NodeInfo, error) {
+	m.lookups++
+	if m.host == "" {
+		return nil, nil
+	}
+	return &meta.NodeInfo{ID: id, Host: m.host}, nil
+}
+
+func (m *nodeCacheMetaStore) Watch() <-chan struct{} { return m.changed }
@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// WriteObserver receives notifications about the lifecycle of shard writes.
+// Methods may be called concurrently from multiple goroutines so
+// implementations must be safe for concurrent use.
+type WriteObserver interface {
+	// OnReceive is called when a write for a shard arrives, before it is applied.
+	OnReceive(shardID uint64, points []tsdb.Point)
+
+	// OnApply is called once the points have been successfully written to the shard.
+	OnApply(shardID uint64, points []tsdb.Point)
+
+	// OnError is called when a write to a shard fails.
+	OnError(shardID uint64, err error)
+}
+
+// WriteObserverFuncs implements WriteObserver using optional function fields.
+// Nil functions are ignored.
+type WriteObserverFuncs struct {
+	OnReceiveFn func(shardID uint64, points []tsdb.Point)
+	OnApplyFn   func(shardID uint64, points []tsdb.Point)
+	OnErrorFn   func(shardID uint64, err error)
+}
+
+// OnReceive calls OnReceiveFn, if set.
+func (o *WriteObserverFuncs) OnReceive(shardID uint64, points []tsdb.Point) {
+	if o.OnReceiveFn != nil {
+		o.OnReceiveFn(shardID, points)
+	}
+}
+
+// OnApply calls OnApplyFn, if set.
+func (o *WriteObserverFuncs) OnApply(shardID uint64, points []tsdb.Point) {
+	if o.OnApplyFn != nil {
+		o.OnApplyFn(shardID, points)
+	}
+}
+
+// OnError calls OnErrorFn, if set.
+func (o *WriteObserverFuncs) OnError(shardID uint64, err error) {
+	if o.OnErrorFn != nil {
+		o.OnErrorFn(shardID, err)
+	}
+}
+
+// nopObserver is used when no observer has been set.
+type nopObserver struct{}
+
+func (nopObserver) OnReceive(shardID uint64, points []tsdb.Point) {}
+func (nopObserver) OnApply(shardID uint64, points []tsdb.Point)   {}
+func (nopObserver) OnError(shardID uint64, err error)             {}
+
+// observerOrNop returns o or a no-op observer if o is nil.
+func observerOrNop(o WriteObserver) WriteObserver {
+	if o == nil {
+		return nopObserver{}
+	}
+	return o
+}
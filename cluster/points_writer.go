@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/influxdb/influxdb"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/tsdb"
 )
@@ -34,6 +36,24 @@ const (
 	ConsistencyLevelAll
 )
 
+// DurabilityLevel represents how durable a write must be on a remote data
+// node before the node acknowledges it.
+type DurabilityLevel int
+
+const (
+	// DurabilityLevelSync requires the write to be synced to disk
+	DurabilityLevelSync DurabilityLevel = iota
+
+	// DurabilityLevelWAL requires the write to be appended to the shard's log.
+	// Shards commit writes directly to their data file so this is currently
+	// the same as DurabilityLevelSync.
+	DurabilityLevelWAL
+
+	// DurabilityLevelMemory requires the write to be queued in memory, it may
+	// be lost if the node fails before it is applied
+	DurabilityLevelMemory
+)
+
 var (
 	// ErrTimeout is returned when a write times out.
 	ErrTimeout = errors.New("timeout")
@@ -48,8 +68,50 @@ var (
 	// ErrInvalidConsistencyLevel is returned when parsing the string version
 	// of a consistency level.
 	ErrInvalidConsistencyLevel = errors.New("invalid consistency level")
+
+	// ErrInvalidDurabilityLevel is returned when parsing the string version
+	// of a durability level.
+	ErrInvalidDurabilityLevel = errors.New("invalid durability level")
 )
 
+// WriteConsistencyError is returned when a write to a shard reaches some of
+// its owners but not enough to meet the consistency level, or when the write
+// times out. Owners whose remote write failed with a retryable error have the
+// write queued for hinted handoff.
+type WriteConsistencyError struct {
+	Err     error // ErrPartialWrite or ErrTimeout
+	ShardID uint64
+
+	Required int // Acknowledgements required by the consistency level.
+	Written  int // Owners that acknowledged the write.
+	Queued   int // Owners with the write queued for hinted handoff.
+	Pending  int // Owners that hadn't responded when the write timed out.
+	Failed   int // Owners that failed without the write being queued.
+}
+
+// Error returns the underlying error.
+func (e *WriteConsistencyError) Error() string { return e.Err.Error() }
+
+// PolicyWriteError is returned when a write to one or more retention
+// policies fails. It maps each failed policy to its error. The points were
+// written to the policies that aren't in the map.
+type PolicyWriteError map[string]error
+
+// Error returns the errors for each policy, sorted by policy name.
+func (e PolicyWriteError) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	a := make([]string, len(names))
+	for i, name := range names {
+		a[i] = fmt.Sprintf("%s: %s", name, e[name])
+	}
+	return "write failed for retention policies: " + strings.Join(a, "; ")
+}
+
 func ParseConsistencyLevel(level string) (ConsistencyLevel, error) {
 	switch strings.ToLower(level) {
 	case "any":
@@ -65,6 +127,19 @@ func ParseConsistencyLevel(level string) (ConsistencyLevel, error) {
 	}
 }
 
+func ParseDurabilityLevel(level string) (DurabilityLevel, error) {
+	switch strings.ToLower(level) {
+	case "sync":
+		return DurabilityLevelSync, nil
+	case "wal":
+		return DurabilityLevelWAL, nil
+	case "memory":
+		return DurabilityLevelMemory, nil
+	default:
+		return 0, ErrInvalidDurabilityLevel
+	}
+}
+
 // PointsWriter handles writes across multiple local and remote data nodes.
 type PointsWriter struct {
 	mu      sync.RWMutex
@@ -85,12 +160,33 @@ type PointsWriter struct {
 	}
 
 	ShardWriter interface {
-		WriteShard(shardID, ownerID uint64, points []tsdb.Point) error
+		WriteShardWithDurability(shardID, ownerID uint64, points []tsdb.Point, durability DurabilityLevel) error
 	}
 
 	HintedHandoff interface {
 		WriteShard(shardID, ownerID uint64, points []tsdb.Point) error
 	}
+
+	// CircuitBreaker, if set, sends writes for nodes that keep failing
+	// straight to hinted handoff.
+	CircuitBreaker *CircuitBreaker
+
+	// Observer, if set, is notified as each shard write is started and completed.
+	Observer WriteObserver
+
+	// MaxPointsPerBatch limits the points in a single write. Larger writes
+	// are rejected. Zero means unlimited.
+	MaxPointsPerBatch int
+
+	// Precision is the precision timestamps are stored at. Points are
+	// truncated to it before they're mapped to shards. Empty is nanoseconds.
+	Precision string
+
+	// SchemaRegistry, if set, records the fields and tag keys of written
+	// measurements and rejects writes that conflict with a field's type.
+	SchemaRegistry interface {
+		UpdateSchema(database string, measurements []meta.MeasurementInfo) error
+	}
 }
 
 // NewPointsWriter returns a new instance of PointsWriter for a node.
@@ -182,6 +278,11 @@ func (w *PointsWriter) MapShards(wp *WritePointsRequest) (*ShardMapping, error)
 
 // WritePoints writes across multiple local and remote data nodes according the consistency level.
 func (w *PointsWriter) WritePoints(p *WritePointsRequest) error {
+	if err := w.checkBatchSize(p); err != nil {
+		return err
+	}
+	p.Points = tsdb.TruncatePoints(p.Points, w.Precision)
+
 	if p.RetentionPolicy == "" {
 		db, err := w.MetaStore.Database(p.Database)
 		if err != nil {
@@ -192,17 +293,158 @@ func (w *PointsWriter) WritePoints(p *WritePointsRequest) error {
 		p.RetentionPolicy = db.DefaultRetentionPolicy
 	}
 
+	if err := w.updateSchema(p); err != nil {
+		return err
+	}
+
 	shardMappings, err := w.MapShards(p)
 	if err != nil {
 		return err
 	}
+	return w.writeShardMapping(p, shardMappings)
+}
+
+// checkBatchSize returns an error if the write has more points than allowed.
+func (w *PointsWriter) checkBatchSize(p *WritePointsRequest) error {
+	if w.MaxPointsPerBatch > 0 && len(p.Points) > w.MaxPointsPerBatch {
+		return fmt.Errorf("%s: %d points, limit %d", tsdb.ErrMaxPointsPerBatchExceeded, len(p.Points), w.MaxPointsPerBatch)
+	}
+	return nil
+}
 
+// updateSchema registers the schema of the points with the schema registry.
+func (w *PointsWriter) updateSchema(p *WritePointsRequest) error {
+	if w.SchemaRegistry == nil {
+		return nil
+	}
+	return w.SchemaRegistry.UpdateSchema(p.Database, measurementSchemas(p.Points))
+}
+
+// measurementSchemas returns the fields and tag keys of each measurement in
+// points. The first type seen for a field is used.
+func measurementSchemas(points []tsdb.Point) []meta.MeasurementInfo {
+	var names []string
+	fields := make(map[string]map[string]influxql.DataType)
+	tags := make(map[string]map[string]struct{})
+	for _, p := range points {
+		name := p.Name()
+		if _, ok := fields[name]; !ok {
+			names = append(names, name)
+			fields[name] = make(map[string]influxql.DataType)
+			tags[name] = make(map[string]struct{})
+		}
+
+		for k, v := range p.Fields() {
+			if _, ok := fields[name][k]; !ok {
+				fields[name][k] = influxql.InspectDataType(v)
+			}
+		}
+		for k := range p.Tags() {
+			tags[name][k] = struct{}{}
+		}
+	}
+
+	a := make([]meta.MeasurementInfo, len(names))
+	for i, name := range names {
+		a[i].Name = name
+		for k, typ := range fields[name] {
+			a[i].Fields = append(a[i].Fields, meta.FieldInfo{Name: k, Type: typ})
+		}
+		for k := range tags[name] {
+			a[i].TagKeys = append(a[i].TagKeys, k)
+		}
+	}
+	return a
+}
+
+// WritePointsToEachPolicy writes the same points to each of the retention
+// policies in a single call. The request is checked and shards for every
+// policy are mapped before any data is written, so an invalid request or
+// policy fails without writing anything.
+//
+// The writes to each policy are independent and aren't rolled back: if any
+// of them fail then a PolicyWriteError is returned, and the points remain
+// written to the other policies. Retrying the failed policies is safe as
+// rewriting a point replaces it.
+func (w *PointsWriter) WritePointsToEachPolicy(p *WritePointsRequest, policies []string) error {
+	if err := w.checkBatchSize(p); err != nil {
+		return err
+	}
+	p.Points = tsdb.TruncatePoints(p.Points, w.Precision)
+
+	// Map the points for each policy before writing anything.
+	requests := make([]*WritePointsRequest, len(policies))
+	mappings := make([]*ShardMapping, len(policies))
+	seen := make(map[string]struct{}, len(policies))
+	for i, policy := range policies {
+		if _, ok := seen[policy]; ok {
+			return fmt.Errorf("duplicate retention policy: %s", policy)
+		}
+		seen[policy] = struct{}{}
+
+		rpi, err := w.MetaStore.RetentionPolicy(p.Database, policy)
+		if err != nil {
+			return err
+		} else if rpi == nil {
+			return fmt.Errorf("retention policy not found: %s", policy)
+		}
+
+		requests[i] = &WritePointsRequest{
+			Database:         p.Database,
+			RetentionPolicy:  policy,
+			ConsistencyLevel: p.ConsistencyLevel,
+			Durability:       p.Durability,
+			Points:           p.Points,
+		}
+		if mappings[i], err = w.MapShards(requests[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := w.updateSchema(p); err != nil {
+		return err
+	}
+
+	// Write to all policies concurrently and collect the failures.
+	errs := make(PolicyWriteError)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := range policies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := w.writeShardMapping(requests[i], mappings[i]); err != nil {
+				mu.Lock()
+				errs[requests[i].RetentionPolicy] = err
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// writeShardMapping writes the points in a shard mapping according to the
+// consistency level of the request.
+func (w *PointsWriter) writeShardMapping(p *WritePointsRequest, shardMappings *ShardMapping) error {
 	// Write each shard in it's own goroutine and return as soon
 	// as one fails.
 	ch := make(chan error, len(shardMappings.Points))
+	obs := observerOrNop(w.Observer)
 	for shardID, points := range shardMappings.Points {
 		go func(shard *meta.ShardInfo, database, retentionPolicy string, points []tsdb.Point) {
-			ch <- w.writeToShard(shard, p.Database, p.RetentionPolicy, p.ConsistencyLevel, points)
+			obs.OnReceive(shard.ID, points)
+			err := w.writeToShard(shard, p.Database, p.RetentionPolicy, p.ConsistencyLevel, p.Durability, points)
+			if err != nil {
+				obs.OnError(shard.ID, err)
+			} else {
+				obs.OnApply(shard.ID, points)
+			}
+			ch <- err
 		}(shardMappings.Shards[shardID], p.Database, p.RetentionPolicy, points)
 	}
 
@@ -220,9 +462,9 @@ func (w *PointsWriter) WritePoints(p *WritePointsRequest) error {
 }
 
 // writeToShards writes points to a shard and ensures a write consistency level has been met.  If the write
-// partially succceds, ErrPartialWrite is returned.
+// partially succceds or times out, a *WriteConsistencyError is returned.
 func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPolicy string,
-	consistency ConsistencyLevel, points []tsdb.Point) error {
+	consistency ConsistencyLevel, durability DurabilityLevel, points []tsdb.Point) error {
 	// The required number of writes to achieve the requested consistency level
 	required := len(shard.OwnerIDs)
 	switch consistency {
@@ -253,7 +495,13 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 				return
 			}
 
-			err := w.ShardWriter.WriteShard(shardID, nodeID, points)
+			var err error
+			if w.CircuitBreaker != nil && !w.CircuitBreaker.Allow(nodeID) {
+				err = ErrCircuitOpen
+			} else {
+				err = w.ShardWriter.WriteShardWithDurability(shardID, nodeID, points, durability)
+				w.recordRemoteWrite(nodeID, err)
+			}
 			if err != nil && tsdb.IsRetryable(err) {
 				// The remote write failed so queue it via hinted handoff
 				hherr := w.HintedHandoff.WriteShard(shardID, nodeID, points)
@@ -264,6 +512,9 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 				if hherr == nil && consistency == ConsistencyLevelAny {
 					ch <- nil
 					return
+				} else if hherr == nil {
+					ch <- queuedError{err}
+					return
 				}
 			}
 			ch <- err
@@ -271,17 +522,34 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 		}(shard.ID, nodeID, points)
 	}
 
-	var wrote int
+	var wrote, queued, responded int
 	timeout := time.After(DefaultWriteTimeout)
 	var writeError error
+	var partialError *tsdb.PartialWriteError
 	for _, nodeID := range shard.OwnerIDs {
 		select {
 		case <-w.closing:
 			return ErrWriteFailed
 		case <-timeout:
 			// return timeout error to caller
-			return ErrTimeout
+			return w.consistencyError(ErrTimeout, shard, required, wrote, responded, queued)
 		case err := <-ch:
+			responded++
+			if qerr, ok := err.(queuedError); ok {
+				queued++
+				err = qerr.err
+			}
+
+			// The points that weren't dropped were written.
+			if perr, ok := err.(*tsdb.PartialWriteError); ok {
+				w.Logger.Printf("write to shard %d on node %d dropped points: %v", shard.ID, nodeID, err)
+				if partialError == nil {
+					partialError = perr
+				}
+				wrote++
+				continue
+			}
+
 			// If the write returned an error, continue to the next response
 			if err != nil {
 				w.Logger.Printf("write failed for shard %d on node %d: %v", shard.ID, nodeID, err)
@@ -299,11 +567,14 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 
 	// We wrote the required consistency level
 	if wrote >= required {
+		if partialError != nil {
+			return partialError
+		}
 		return nil
 	}
 
 	if wrote > 0 {
-		return ErrPartialWrite
+		return w.consistencyError(ErrPartialWrite, shard, required, wrote, responded, queued)
 	}
 
 	if writeError != nil {
@@ -312,3 +583,38 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 
 	return ErrWriteFailed
 }
+
+// consistencyError returns the details of a shard write that didn't meet
+// its consistency level.
+func (w *PointsWriter) consistencyError(err error, shard *meta.ShardInfo, required, wrote, responded, queued int) *WriteConsistencyError {
+	return &WriteConsistencyError{
+		Err:      err,
+		ShardID:  shard.ID,
+		Required: required,
+		Written:  wrote,
+		Queued:   queued,
+		Pending:  len(shard.OwnerIDs) - responded,
+		Failed:   responded - wrote - queued,
+	}
+}
+
+// queuedError wraps the error of a remote write that was queued for hinted handoff.
+type queuedError struct {
+	err error
+}
+
+func (e queuedError) Error() string { return e.err.Error() }
+
+// recordRemoteWrite updates the circuit breaker with the result of a write to
+// a remote node. Errors that aren't retryable mean the node is reachable.
+func (w *PointsWriter) recordRemoteWrite(nodeID uint64, err error) {
+	if w.CircuitBreaker == nil {
+		return
+	}
+
+	if err == nil || !tsdb.IsRetryable(err) {
+		w.CircuitBreaker.Success(nodeID)
+	} else if w.CircuitBreaker.Failure(nodeID) {
+		w.Logger.Printf("writes to node %d failing, sending to hinted handoff for %s: %s", nodeID, w.CircuitBreaker.cooldown, err)
+	}
+}
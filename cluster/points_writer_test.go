@@ -2,12 +2,15 @@ package cluster_test
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/tsdb"
 )
@@ -111,6 +114,10 @@ func TestPointsWriter_MapShards_Multiple(t *testing.T) {
 }
 
 func TestPointsWriter_WritePoints(t *testing.T) {
+	partialWriteError := &tsdb.PartialWriteError{
+		Reason:  tsdb.ErrMaxSeriesPerDatabaseExceeded,
+		Dropped: []tsdb.Point{tsdb.NewPoint("cpu", nil, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))},
+	}
 	tests := []struct {
 		name            string
 		database        string
@@ -200,6 +207,16 @@ func TestPointsWriter_WritePoints(t *testing.T) {
 			expErr:          nil,
 		},
 
+		// Points dropped by the local shard
+		{
+			name:            "write all, points dropped locally",
+			database:        "mydb",
+			retentionPolicy: "myrp",
+			consistency:     cluster.ConsistencyLevelAll,
+			err:             []error{partialWriteError, nil, nil},
+			expErr:          partialWriteError,
+		},
+
 		// Error write error
 		{
 			name:            "no writes succeed",
@@ -305,19 +322,467 @@ func TestPointsWriter_WritePoints(t *testing.T) {
 		if err != nil && test.expErr != nil && err.Error() != test.expErr.Error() {
 			t.Errorf("PointsWriter.WritePoints(): '%s' error: got %v, exp %v", test.name, err, test.expErr)
 		}
+		if _, ok := test.expErr.(*tsdb.PartialWriteError); ok && err != test.expErr {
+			t.Errorf("PointsWriter.WritePoints(): '%s' error type: got %T, exp %T", test.name, err, test.expErr)
+		}
+	}
+}
+
+// Ensures the points writer notifies its observer of each shard write.
+func TestPointsWriter_WritePoints_Observer(t *testing.T) {
+	ms := NewMetaStore()
+	ms.NodeIDFn = func() uint64 { return 1 }
+
+	var mu sync.Mutex
+	received, applied := map[uint64]int{}, map[uint64]int{}
+	var errs []error
+
+	c := cluster.NewPointsWriter()
+	c.MetaStore = ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(shardID uint64, points []tsdb.Point) error { return nil },
+	}
+	c.ShardWriter = &fakeShardWriter{
+		ShardWriteFn: func(shardID, nodeID uint64, points []tsdb.Point) error { return fmt.Errorf("a failure") },
+	}
+	c.HintedHandoff = &fakeShardWriter{
+		ShardWriteFn: func(shardID, nodeID uint64, points []tsdb.Point) error { return nil },
+	}
+	c.Observer = &cluster.WriteObserverFuncs{
+		OnReceiveFn: func(shardID uint64, points []tsdb.Point) {
+			mu.Lock()
+			defer mu.Unlock()
+			received[shardID] += len(points)
+		},
+		OnApplyFn: func(shardID uint64, points []tsdb.Point) {
+			mu.Lock()
+			defer mu.Unlock()
+			applied[shardID] += len(points)
+		},
+		OnErrorFn: func(shardID uint64, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	}
+
+	pr := &cluster.WritePointsRequest{
+		Database:         "mydb",
+		RetentionPolicy:  "myrp",
+		ConsistencyLevel: cluster.ConsistencyLevelOne,
+	}
+	pr.AddPoint("cpu", 1.0, time.Unix(0, 0), nil)
+	pr.AddPoint("cpu", 2.0, time.Unix(0, 0).Add(time.Hour), nil)
+	pr.AddPoint("cpu", 3.0, time.Unix(0, 0).Add(time.Hour+time.Second), nil)
+
+	if err := c.WritePoints(pr); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || len(applied) != 2 {
+		t.Fatalf("unexpected shard notifications: received=%v, applied=%v", received, applied)
+	} else if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	for id, n := range received {
+		if applied[id] != n {
+			t.Fatalf("unexpected applied count for shard %d: got %d, exp %d", id, applied[id], n)
+		}
+	}
+}
+
+// Ensures writes to a failing node go straight to hinted handoff once its circuit opens.
+func TestPointsWriter_WritePoints_CircuitBreaker(t *testing.T) {
+	ms := NewMetaStore()
+	ms.NodeIDFn = func() uint64 { return 1 }
+
+	var mu sync.Mutex
+	var remote, handoff int
+
+	c := cluster.NewPointsWriter()
+	c.MetaStore = ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(shardID uint64, points []tsdb.Point) error { return nil },
+	}
+	c.ShardWriter = &fakeShardWriter{
+		ShardWriteFn: func(shardID, nodeID uint64, points []tsdb.Point) error {
+			mu.Lock()
+			defer mu.Unlock()
+			remote++
+			return fmt.Errorf("dial timeout")
+		},
+	}
+	c.HintedHandoff = &fakeShardWriter{
+		ShardWriteFn: func(shardID, nodeID uint64, points []tsdb.Point) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handoff++
+			return nil
+		},
+	}
+	c.CircuitBreaker = cluster.NewCircuitBreaker(2, time.Hour)
+
+	// Each write goes to a single shard owned by nodes 1, 2 & 3.
+	for i := 0; i < 4; i++ {
+		pr := &cluster.WritePointsRequest{
+			Database:         "mydb",
+			RetentionPolicy:  "myrp",
+			ConsistencyLevel: cluster.ConsistencyLevelAny,
+		}
+		pr.AddPoint("cpu", 1.0, time.Unix(0, 0), nil)
+		if err := c.WritePoints(pr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if remote != 4 {
+		t.Fatalf("unexpected remote write count: %d", remote)
+	} else if handoff != 8 {
+		t.Fatalf("unexpected hinted handoff count: %d", handoff)
+	}
+}
+
+// Ensures the points writer reports the details of a write that doesn't meet its consistency level.
+func TestPointsWriter_WritePoints_ConsistencyError(t *testing.T) {
+	ms := NewMetaStore()
+	ms.NodeIDFn = func() uint64 { return 1 }
+
+	c := cluster.NewPointsWriter()
+	c.MetaStore = ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(shardID uint64, points []tsdb.Point) error { return nil },
+	}
+	c.ShardWriter = &fakeShardWriter{
+		ShardWriteFn: func(shardID, nodeID uint64, points []tsdb.Point) error {
+			if nodeID == 2 {
+				return fmt.Errorf("dial timeout")
+			}
+			return fmt.Errorf("field type conflict")
+		},
+	}
+	c.HintedHandoff = &fakeShardWriter{
+		ShardWriteFn: func(shardID, nodeID uint64, points []tsdb.Point) error { return nil },
+	}
+
+	pr := &cluster.WritePointsRequest{
+		Database:         "mydb",
+		RetentionPolicy:  "myrp",
+		ConsistencyLevel: cluster.ConsistencyLevelAll,
+	}
+	pr.AddPoint("cpu", 1.0, time.Unix(0, 0), nil)
+
+	err := c.WritePoints(pr)
+	if err, ok := err.(*cluster.WriteConsistencyError); !ok {
+		t.Fatalf("unexpected error: %#v", err)
+	} else if !reflect.DeepEqual(err, &cluster.WriteConsistencyError{
+		Err:      cluster.ErrPartialWrite,
+		ShardID:  err.ShardID,
+		Required: 3,
+		Written:  1,
+		Queued:   1,
+		Failed:   1,
+	}) {
+		t.Fatalf("unexpected error: %#v", err)
+	} else if err.Error() != "partial write" {
+		t.Fatalf("unexpected error string: %s", err)
+	}
+}
+
+// Ensures the points writer sends the requested durability level with remote writes.
+func TestPointsWriter_WritePoints_Durability(t *testing.T) {
+	ms := NewMetaStore()
+	ms.NodeIDFn = func() uint64 { return 1 }
+
+	var mu sync.Mutex
+	durabilities := map[uint64]cluster.DurabilityLevel{}
+
+	c := cluster.NewPointsWriter()
+	c.MetaStore = ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(shardID uint64, points []tsdb.Point) error { return nil },
+	}
+	c.ShardWriter = &fakeShardWriter{
+		WriteShardWithDurabilityFn: func(shardID, nodeID uint64, points []tsdb.Point, durability cluster.DurabilityLevel) error {
+			mu.Lock()
+			defer mu.Unlock()
+			durabilities[nodeID] = durability
+			return nil
+		},
+	}
+
+	pr := &cluster.WritePointsRequest{
+		Database:         "mydb",
+		RetentionPolicy:  "myrp",
+		ConsistencyLevel: cluster.ConsistencyLevelAll,
+		Durability:       cluster.DurabilityLevelMemory,
+	}
+	pr.AddPoint("cpu", 1.0, time.Unix(0, 0), nil)
+	if err := c.WritePoints(pr); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(durabilities, map[uint64]cluster.DurabilityLevel{2: cluster.DurabilityLevelMemory, 3: cluster.DurabilityLevelMemory}) {
+		t.Fatalf("unexpected durabilities: %v", durabilities)
+	}
+}
+
+// Ensures the points writer writes the same points to several retention policies.
+func TestPointsWriter_WritePointsToEachPolicy(t *testing.T) {
+	rps := map[string]*meta.RetentionPolicyInfo{
+		"raw": NewRetentionPolicy("raw", time.Hour, 1),
+		"agg": NewRetentionPolicy("agg", time.Hour, 1),
+	}
+	failed := rps["agg"].ShardGroups[0].Shards[0].ID
+
+	ms := NewMetaStore()
+	ms.NodeIDFn = func() uint64 { return 1 }
+	ms.RetentionPolicyFn = func(database, name string) (*meta.RetentionPolicyInfo, error) {
+		return rps[name], nil
+	}
+	ms.CreateShardGroupIfNotExistsFn = func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+		return &rps[policy].ShardGroups[0], nil
+	}
+
+	var mu sync.Mutex
+	written := map[uint64]int{}
+
+	c := cluster.NewPointsWriter()
+	c.MetaStore = ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(shardID uint64, points []tsdb.Point) error {
+			mu.Lock()
+			defer mu.Unlock()
+			written[shardID] += len(points)
+			return nil
+		},
+	}
+
+	pr := &cluster.WritePointsRequest{
+		Database:         "mydb",
+		ConsistencyLevel: cluster.ConsistencyLevelOne,
+	}
+	pr.AddPoint("cpu", 1.0, time.Unix(0, 0), nil)
+	pr.AddPoint("cpu", 2.0, time.Unix(1, 0), nil)
+
+	// Write to both policies.
+	if err := c.WritePointsToEachPolicy(pr, []string{"raw", "agg"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, rp := range rps {
+		if n := written[rp.ShardGroups[0].Shards[0].ID]; n != 2 {
+			t.Fatalf("unexpected points written to %s: %d", name, n)
+		}
+	}
+
+	// Ensure nothing is written if any policy doesn't exist.
+	written = map[uint64]int{}
+	if err := c.WritePointsToEachPolicy(pr, []string{"raw", "foo"}); err == nil || err.Error() != "retention policy not found: foo" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(written) != 0 {
+		t.Fatalf("unexpected writes: %v", written)
+	}
+
+	// Ensure failures are reported by policy.
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(shardID uint64, points []tsdb.Point) error {
+			if shardID == failed {
+				return fmt.Errorf("marker")
+			}
+			return nil
+		},
+	}
+	err := c.WritePointsToEachPolicy(pr, []string{"raw", "agg"})
+	if e, ok := err.(cluster.PolicyWriteError); !ok || len(e) != 1 || e["agg"] == nil {
+		t.Fatalf("unexpected error: %#v", err)
+	} else if err.Error() != "write failed for retention policies: agg: write failed: marker" {
+		t.Fatalf("unexpected error message: %s", err)
+	}
+}
+
+// Ensures a write to several retention policies is checked before anything
+// is written, and that a failed policy doesn't undo the others' writes.
+func TestPointsWriter_WritePointsToEachPolicy_Partial(t *testing.T) {
+	rps := map[string]*meta.RetentionPolicyInfo{
+		"raw": NewRetentionPolicy("raw", time.Hour, 1),
+		"agg": NewRetentionPolicy("agg", time.Hour, 1),
+	}
+	raw := rps["raw"].ShardGroups[0].Shards[0].ID
+	agg := rps["agg"].ShardGroups[0].Shards[0].ID
+
+	ms := NewMetaStore()
+	ms.NodeIDFn = func() uint64 { return 1 }
+	ms.RetentionPolicyFn = func(database, name string) (*meta.RetentionPolicyInfo, error) {
+		return rps[name], nil
+	}
+	ms.CreateShardGroupIfNotExistsFn = func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+		return &rps[policy].ShardGroups[0], nil
+	}
+
+	var mu sync.Mutex
+	written := map[uint64]int{}
+
+	c := cluster.NewPointsWriter()
+	c.MetaStore = ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(shardID uint64, points []tsdb.Point) error {
+			if shardID == agg {
+				return fmt.Errorf("marker")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			written[shardID] += len(points)
+			return nil
+		},
+	}
+
+	pr := &cluster.WritePointsRequest{
+		Database:         "mydb",
+		ConsistencyLevel: cluster.ConsistencyLevelOne,
+	}
+	pr.AddPoint("cpu", 1.0, time.Unix(0, 0), nil)
+
+	// Nothing is written if a policy is repeated.
+	if err := c.WritePointsToEachPolicy(pr, []string{"raw", "raw"}); err == nil || err.Error() != "duplicate retention policy: raw" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(written) != 0 {
+		t.Fatalf("unexpected writes: %v", written)
+	}
+
+	// The points stay written to the policies that succeeded.
+	err := c.WritePointsToEachPolicy(pr, []string{"raw", "agg"})
+	if e, ok := err.(cluster.PolicyWriteError); !ok || len(e) != 1 || e["agg"] == nil {
+		t.Fatalf("unexpected error: %#v", err)
+	} else if written[raw] != 1 {
+		t.Fatalf("unexpected points written to raw: %d", written[raw])
 	}
 }
 
 var shardID uint64
 
+// Ensures the schema of written points is registered and conflicting writes are rejected.
+func TestPointsWriter_WritePoints_SchemaRegistry(t *testing.T) {
+	ms := NewMetaStore()
+	ms.NodeIDFn = func() uint64 { return 1 }
+
+	var written int64
+	c := cluster.NewPointsWriter()
+	c.MetaStore = ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(shardID uint64, points []tsdb.Point) error {
+			atomic.AddInt64(&written, int64(len(points)))
+			return nil
+		},
+	}
+	c.ShardWriter = &fakeShardWriter{
+		ShardWriteFn: func(shardID, nodeID uint64, points []tsdb.Point) error {
+			atomic.AddInt64(&written, int64(len(points)))
+			return nil
+		},
+	}
+
+	var mis []meta.MeasurementInfo
+	c.SchemaRegistry = schemaRegistryFunc(func(database string, a []meta.MeasurementInfo) error {
+		if database != "mydb" {
+			t.Fatalf("unexpected database: %s", database)
+		}
+		mis = a
+		return nil
+	})
+
+	pr := &cluster.WritePointsRequest{
+		Database:         "mydb",
+		RetentionPolicy:  "myrp",
+		ConsistencyLevel: cluster.ConsistencyLevelOne,
+	}
+	pr.AddPoint("cpu", 1.0, time.Unix(0, 0), map[string]string{"host": "serverA"})
+	pr.AddPoint("cpu", 2.0, time.Unix(0, 0).Add(time.Second), map[string]string{"host": "serverB"})
+
+	if err := c.WritePoints(pr); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(mis, []meta.MeasurementInfo{
+		{
+			Name:    "cpu",
+			Fields:  []meta.FieldInfo{{Name: "value", Type: influxql.Float}},
+			TagKeys: []string{"host"},
+		},
+	}) {
+		t.Fatalf("unexpected schema: %#v", mis)
+	}
+
+	// Verify nothing is written when the registry rejects the points.
+	atomic.StoreInt64(&written, 0)
+	c.SchemaRegistry = schemaRegistryFunc(func(database string, a []meta.MeasurementInfo) error {
+		return fmt.Errorf("field type conflict")
+	})
+	if err := c.WritePoints(pr); err == nil || err.Error() != "field type conflict" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if n := atomic.LoadInt64(&written); n != 0 {
+		t.Fatalf("unexpected points written: %d", n)
+	}
+}
+
+// Ensures the points writer rejects writes with more points than allowed.
+func TestPointsWriter_WritePoints_MaxPointsPerBatch(t *testing.T) {
+	ms := NewMetaStore()
+	ms.NodeIDFn = func() uint64 { return 1 }
+
+	var written int64
+	c := cluster.NewPointsWriter()
+	c.MetaStore = ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(shardID uint64, points []tsdb.Point) error {
+			atomic.AddInt64(&written, int64(len(points)))
+			return nil
+		},
+	}
+	c.MaxPointsPerBatch = 1
+
+	pr := &cluster.WritePointsRequest{
+		Database:         "mydb",
+		RetentionPolicy:  "myrp",
+		ConsistencyLevel: cluster.ConsistencyLevelOne,
+	}
+	pr.AddPoint("cpu", 1.0, time.Unix(0, 0), nil)
+	pr.AddPoint("cpu", 2.0, time.Unix(1, 0), nil)
+
+	if err := c.WritePoints(pr); err == nil || !influxdb.IsClientError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := c.WritePointsToEachPolicy(pr, []string{"myrp"}); err == nil || !influxdb.IsClientError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	} else if n := atomic.LoadInt64(&written); n != 0 {
+		t.Fatalf("unexpected points written: %d", n)
+	}
+}
+
+// schemaRegistryFunc is a function that implements PointsWriter.SchemaRegistry.
+type schemaRegistryFunc func(database string, measurements []meta.MeasurementInfo) error
+
+func (fn schemaRegistryFunc) UpdateSchema(database string, measurements []meta.MeasurementInfo) error {
+	return fn(database, measurements)
+}
+
 type fakeShardWriter struct {
-	ShardWriteFn func(shardID, nodeID uint64, points []tsdb.Point) error
+	ShardWriteFn               func(shardID, nodeID uint64, points []tsdb.Point) error
+	WriteShardWithDurabilityFn func(shardID, nodeID uint64, points []tsdb.Point, durability cluster.DurabilityLevel) error
 }
 
 func (f *fakeShardWriter) WriteShard(shardID, nodeID uint64, points []tsdb.Point) error {
 	return f.ShardWriteFn(shardID, nodeID, points)
 }
 
+func (f *fakeShardWriter) WriteShardWithDurability(shardID, nodeID uint64, points []tsdb.Point, durability cluster.DurabilityLevel) error {
+	if f.WriteShardWithDurabilityFn != nil {
+		return f.WriteShardWithDurabilityFn(shardID, nodeID, points, durability)
+	}
+	return f.ShardWriteFn(shardID, nodeID, points)
+}
+
 type fakeStore struct {
 	WriteFn       func(shardID uint64, points []tsdb.Point) error
 	CreateShardfn func(database, retentionPolicy string, shardID uint64) error
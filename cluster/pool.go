@@ -0,0 +1,225 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// errPoolClosed is returned when getting a connection from a closed pool.
+var errPoolClosed = errors.New("pool is closed")
+
+// PoolStats represents the state of the connection pool to a single node.
+type PoolStats struct {
+	InUse    int           // connections handed out and not yet returned
+	Idle     int           // connections waiting in the pool
+	Gets     int64         // total connections handed out
+	WaitTime time.Duration // total time spent getting connections, including dials
+	Reaped   int64         // idle or expired connections closed by the pool
+}
+
+// connPool is a pool of connections to a single node. It is based on
+// github.com/fatih/pool but also closes connections that sit idle or have
+// been open too long, since those are often dropped silently by firewalls
+// and load balancers after a network blip.
+type connPool struct {
+	mu      sync.Mutex
+	conns   []*pooledConn
+	factory func() (net.Conn, error)
+	closed  bool
+
+	maxIdle     int
+	maxIdleTime time.Duration
+	maxLifetime time.Duration
+
+	inUse    int
+	gets     int64
+	waitTime time.Duration
+	reaped   int64
+}
+
+// newConnPool returns a pool that keeps up to maxIdle connections made by
+// factory. initialCap connections are dialed up front. Zero maxIdleTime or
+// maxLifetime disables reaping on that condition.
+func newConnPool(initialCap, maxIdle int, maxIdleTime, maxLifetime time.Duration, factory func() (net.Conn, error)) (*connPool, error) {
+	if initialCap < 0 || maxIdle <= 0 || initialCap > maxIdle {
+		return nil, errors.New("invalid capacity settings")
+	}
+
+	p := &connPool{
+		factory:     factory,
+		maxIdle:     maxIdle,
+		maxIdleTime: maxIdleTime,
+		maxLifetime: maxLifetime,
+	}
+
+	now := time.Now()
+	for i := 0; i < initialCap; i++ {
+		conn, err := factory()
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("factory is not able to fill the pool: %s", err)
+		}
+		p.conns = append(p.conns, &pooledConn{Conn: conn, pool: p, createdAt: now, returnedAt: now})
+	}
+
+	return p, nil
+}
+
+// Get returns an idle connection or dials a new one. Expired idle
+// connections are closed rather than returned.
+func (p *connPool) Get() (net.Conn, error) {
+	start := time.Now()
+	conn, err := p.get(start)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.inUse++
+	p.gets++
+	p.waitTime += time.Since(start)
+	p.mu.Unlock()
+
+	return conn, nil
+}
+
+func (p *connPool) get(now time.Time) (*pooledConn, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, errPoolClosed
+		}
+
+		// Dial a new connection if there are no idle ones.
+		if len(p.conns) == 0 {
+			p.mu.Unlock()
+
+			conn, err := p.factory()
+			if err != nil {
+				return nil, err
+			}
+			return &pooledConn{Conn: conn, pool: p, createdAt: time.Now()}, nil
+		}
+
+		// Take the most recently returned connection.
+		c := p.conns[len(p.conns)-1]
+		p.conns = p.conns[:len(p.conns)-1]
+		expired := p.expired(c, now)
+		if expired {
+			p.reaped++
+		}
+		p.mu.Unlock()
+
+		if !expired {
+			c.unusable = false
+			return c, nil
+		}
+		c.Conn.Close()
+	}
+}
+
+// put returns a connection to the pool. It is closed instead if it is
+// unusable, has outlived its lifetime, or the pool is full or closed.
+func (p *connPool) put(c *pooledConn) error {
+	now := time.Now()
+
+	p.mu.Lock()
+	p.inUse--
+	if c.unusable || p.closed || len(p.conns) >= p.maxIdle || p.expired(c, now) {
+		p.mu.Unlock()
+		return c.Conn.Close()
+	}
+	c.returnedAt = now
+	p.conns = append(p.conns, c)
+	p.mu.Unlock()
+
+	return nil
+}
+
+// expired returns true if an idle connection has been idle or open too long.
+func (p *connPool) expired(c *pooledConn, now time.Time) bool {
+	if p.maxLifetime > 0 && now.Sub(c.createdAt) >= p.maxLifetime {
+		return true
+	}
+	return p.maxIdleTime > 0 && now.Sub(c.returnedAt) >= p.maxIdleTime
+}
+
+// reap closes idle connections that have expired as of now.
+func (p *connPool) reap(now time.Time) int {
+	var expired []*pooledConn
+
+	p.mu.Lock()
+	conns := p.conns[:0]
+	for _, c := range p.conns {
+		if p.expired(c, now) {
+			expired = append(expired, c)
+		} else {
+			conns = append(conns, c)
+		}
+	}
+	for i := len(conns); i < len(p.conns); i++ {
+		p.conns[i] = nil
+	}
+	p.conns = conns
+	p.reaped += int64(len(expired))
+	p.mu.Unlock()
+
+	for _, c := range expired {
+		c.Conn.Close()
+	}
+	return len(expired)
+}
+
+// Close closes all idle connections. Connections in use are closed when
+// they are returned.
+func (p *connPool) Close() {
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, c := range conns {
+		c.Conn.Close()
+	}
+}
+
+// Len returns the number of idle connections.
+func (p *connPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.conns)
+}
+
+// Stats returns the current state of the pool.
+func (p *connPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{
+		InUse:    p.inUse,
+		Idle:     len(p.conns),
+		Gets:     p.gets,
+		WaitTime: p.waitTime,
+		Reaped:   p.reaped,
+	}
+}
+
+// pooledConn is a connection from a connPool. Closing it returns it to the pool.
+type pooledConn struct {
+	net.Conn
+	pool       *connPool
+	createdAt  time.Time
+	returnedAt time.Time
+	unusable   bool
+}
+
+// Close returns the connection to the pool.
+func (c *pooledConn) Close() error { return c.pool.put(c) }
+
+// MarkUnusable marks the connection so it is closed instead of being
+// returned to the pool, e.g. after a failed read or write.
+func (c *pooledConn) MarkUnusable() { c.unusable = true }
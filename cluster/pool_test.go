@@ -0,0 +1,168 @@
+package cluster
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// Ensure connections are reused and counted in the pool stats.
+func TestConnPool_Get(t *testing.T) {
+	f := &pipeFactory{}
+	p, err := newConnPool(1, 3, 0, 0, f.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	c0, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.n != 2 {
+		t.Fatalf("unexpected dial count: %d", f.n)
+	} else if s := p.Stats(); s.InUse != 2 || s.Idle != 0 || s.Gets != 2 {
+		t.Fatalf("unexpected stats: %#v", s)
+	}
+
+	// Returned connections are reused.
+	c0.Close()
+	c1.Close()
+	if s := p.Stats(); s.InUse != 0 || s.Idle != 2 {
+		t.Fatalf("unexpected stats: %#v", s)
+	}
+	if c, err := p.Get(); err != nil {
+		t.Fatal(err)
+	} else if c != c1 {
+		t.Fatal("expected most recently returned connection")
+	} else if f.n != 2 {
+		t.Fatalf("unexpected dial count: %d", f.n)
+	}
+}
+
+// Ensure unusable connections are closed instead of returned to the pool.
+func TestConnPool_MarkUnusable(t *testing.T) {
+	f := &pipeFactory{}
+	p, err := newConnPool(0, 3, 0, 0, f.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	c, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.(*pooledConn).MarkUnusable()
+	c.Close()
+
+	if s := p.Stats(); s.InUse != 0 || s.Idle != 0 {
+		t.Fatalf("unexpected stats: %#v", s)
+	} else if !f.closed(0) {
+		t.Fatal("expected connection to be closed")
+	}
+}
+
+// Ensure connections past their lifetime are replaced on Get.
+func TestConnPool_Get_MaxLifetime(t *testing.T) {
+	f := &pipeFactory{}
+	p, err := newConnPool(1, 3, 0, 10*time.Millisecond, f.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	time.Sleep(20 * time.Millisecond)
+	if _, err := p.Get(); err != nil {
+		t.Fatal(err)
+	} else if f.n != 2 {
+		t.Fatalf("unexpected dial count: %d", f.n)
+	} else if !f.closed(0) {
+		t.Fatal("expected expired connection to be closed")
+	} else if s := p.Stats(); s.Reaped != 1 {
+		t.Fatalf("unexpected reaped count: %d", s.Reaped)
+	}
+}
+
+// Ensure idle connections are reaped once they exceed the idle time.
+func TestConnPool_Reap(t *testing.T) {
+	f := &pipeFactory{}
+	p, err := newConnPool(2, 3, time.Minute, 0, f.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// Nothing has been idle long enough yet.
+	if n := p.reap(time.Now()); n != 0 {
+		t.Fatalf("unexpected reap count: %d", n)
+	}
+
+	// Use one connection so only the other is idle past the limit.
+	c, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if n := p.reap(time.Now().Add(2 * time.Minute)); n != 1 {
+		t.Fatalf("unexpected reap count: %d", n)
+	} else if s := p.Stats(); s.InUse != 1 || s.Idle != 0 || s.Reaped != 1 {
+		t.Fatalf("unexpected stats: %#v", s)
+	} else if !f.closed(0) {
+		t.Fatal("expected idle connection to be closed")
+	}
+}
+
+// Ensure a closed pool closes returned connections and rejects gets.
+func TestConnPool_Close(t *testing.T) {
+	f := &pipeFactory{}
+	p, err := newConnPool(1, 3, 0, 0, f.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	c.Close()
+
+	if !f.closed(0) {
+		t.Fatal("expected connection to be closed")
+	} else if _, err := p.Get(); err != errPoolClosed {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// pipeFactory dials in-memory connections and tracks which are closed.
+type pipeFactory struct {
+	n     int
+	conns []*pipeConn
+}
+
+func (f *pipeFactory) dial() (net.Conn, error) {
+	client, server := net.Pipe()
+	server.Close()
+	c := &pipeConn{Conn: client}
+	f.conns = append(f.conns, c)
+	f.n++
+	return c, nil
+}
+
+func (f *pipeFactory) closed(i int) bool { return f.conns[i].closed }
+
+type pipeConn struct {
+	net.Conn
+	closed bool
+}
+
+func (c *pipeConn) Close() error {
+	c.closed = true
+	return c.Conn.Close()
+}
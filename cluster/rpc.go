@@ -15,6 +15,7 @@ type WritePointsRequest struct {
 	Database         string
 	RetentionPolicy  string
 	ConsistencyLevel ConsistencyLevel
+	Durability       DurabilityLevel
 	Points           []tsdb.Point
 }
 
@@ -38,6 +39,13 @@ type WriteShardResponse struct {
 func (w *WriteShardRequest) SetShardID(id uint64) { w.pb.ShardID = &id }
 func (w *WriteShardRequest) ShardID() uint64      { return w.pb.GetShardID() }
 
+func (w *WriteShardRequest) SetDurability(l DurabilityLevel) {
+	w.pb.Durability = proto.Uint32(uint32(l))
+}
+func (w *WriteShardRequest) Durability() DurabilityLevel {
+	return DurabilityLevel(w.pb.GetDurability())
+}
+
 func (w *WriteShardRequest) Points() []tsdb.Point { return w.unmarshalPoints() }
 
 func (w *WriteShardRequest) AddPoint(name string, value interface{}, timestamp time.Time, tags map[string]string) {
@@ -71,6 +79,8 @@ func (w *WriteShardRequest) marshalPoints(points []tsdb.Point) []*internal.Point
 				f.Int32 = proto.Int32(t)
 			case int64:
 				f.Int64 = proto.Int64(t)
+			case uint64:
+				f.Uint64 = proto.Uint64(t)
 			case float64:
 				f.Float64 = proto.Float64(t)
 			case bool:
@@ -125,6 +135,8 @@ func (w *WriteShardRequest) unmarshalPoints() []tsdb.Point {
 				pt.AddField(n, f.GetInt32())
 			} else if f.Int64 != nil {
 				pt.AddField(n, f.GetInt64())
+			} else if f.Uint64 != nil {
+				pt.AddField(n, f.GetUint64())
 			} else if f.Float64 != nil {
 				pt.AddField(n, f.GetFloat64())
 			} else if f.Bool != nil {
@@ -164,3 +176,200 @@ func (w *WriteShardResponse) UnmarshalBinary(buf []byte) error {
 	}
 	return nil
 }
+
+// DeleteShardRequest represents a request to remove a shard's data from a node.
+type DeleteShardRequest struct {
+	pb internal.DeleteShardRequest
+}
+
+func (r *DeleteShardRequest) SetShardID(id uint64) { r.pb.ShardID = &id }
+func (r *DeleteShardRequest) ShardID() uint64      { return r.pb.GetShardID() }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *DeleteShardRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates DeleteShardRequest from a binary format.
+func (r *DeleteShardRequest) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteShardResponse represents the response returned from a remote DeleteShardRequest call.
+type DeleteShardResponse struct {
+	pb internal.DeleteShardResponse
+}
+
+func (r *DeleteShardResponse) SetCode(code int)          { r.pb.Code = proto.Int32(int32(code)) }
+func (r *DeleteShardResponse) SetMessage(message string) { r.pb.Message = &message }
+
+func (r *DeleteShardResponse) Code() int       { return int(r.pb.GetCode()) }
+func (r *DeleteShardResponse) Message() string { return r.pb.GetMessage() }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *DeleteShardResponse) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates DeleteShardResponse from a binary format.
+func (r *DeleteShardResponse) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CopyShardRequest represents a request for a node to copy a shard from another node.
+type CopyShardRequest struct {
+	pb internal.CopyShardRequest
+}
+
+func (r *CopyShardRequest) SetShardID(id uint64)        { r.pb.ShardID = &id }
+func (r *CopyShardRequest) SetDatabase(database string) { r.pb.Database = &database }
+func (r *CopyShardRequest) SetPolicy(policy string)     { r.pb.Policy = &policy }
+func (r *CopyShardRequest) SetSourceHost(host string)   { r.pb.SourceHost = &host }
+func (r *CopyShardRequest) ShardID() uint64             { return r.pb.GetShardID() }
+func (r *CopyShardRequest) Database() string            { return r.pb.GetDatabase() }
+func (r *CopyShardRequest) Policy() string              { return r.pb.GetPolicy() }
+func (r *CopyShardRequest) SourceHost() string          { return r.pb.GetSourceHost() }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *CopyShardRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates CopyShardRequest from a binary format.
+func (r *CopyShardRequest) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CopyShardResponse represents the response returned from a remote CopyShardRequest call.
+type CopyShardResponse struct {
+	pb internal.CopyShardResponse
+}
+
+func (r *CopyShardResponse) SetCode(code int)          { r.pb.Code = proto.Int32(int32(code)) }
+func (r *CopyShardResponse) SetMessage(message string) { r.pb.Message = &message }
+
+func (r *CopyShardResponse) Code() int       { return int(r.pb.GetCode()) }
+func (r *CopyShardResponse) Message() string { return r.pb.GetMessage() }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *CopyShardResponse) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates CopyShardResponse from a binary format.
+func (r *CopyShardResponse) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadShardRequest represents a request for the contents of a shard's data file.
+type ReadShardRequest struct {
+	pb internal.ReadShardRequest
+}
+
+func (r *ReadShardRequest) SetShardID(id uint64) { r.pb.ShardID = &id }
+func (r *ReadShardRequest) ShardID() uint64      { return r.pb.GetShardID() }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *ReadShardRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates ReadShardRequest from a binary format.
+func (r *ReadShardRequest) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadShardResponse represents the response returned from a remote ReadShardRequest call.
+type ReadShardResponse struct {
+	pb internal.ReadShardResponse
+}
+
+func (r *ReadShardResponse) SetCode(code int)          { r.pb.Code = proto.Int32(int32(code)) }
+func (r *ReadShardResponse) SetMessage(message string) { r.pb.Message = &message }
+
+func (r *ReadShardResponse) Code() int       { return int(r.pb.GetCode()) }
+func (r *ReadShardResponse) Message() string { return r.pb.GetMessage() }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *ReadShardResponse) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates ReadShardResponse from a binary format.
+func (r *ReadShardResponse) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteSeriesRangeRequest represents a request to remove the points of a
+// measurement's series within a time range from a shard.
+type DeleteSeriesRangeRequest struct {
+	pb internal.DeleteSeriesRangeRequest
+}
+
+func (r *DeleteSeriesRangeRequest) SetShardID(id uint64)       { r.pb.ShardID = &id }
+func (r *DeleteSeriesRangeRequest) SetMeasurement(name string) { r.pb.Measurement = &name }
+func (r *DeleteSeriesRangeRequest) SetCondition(cond string)   { r.pb.Condition = &cond }
+func (r *DeleteSeriesRangeRequest) SetTimeRange(min, max int64) {
+	r.pb.MinTime, r.pb.MaxTime = &min, &max
+}
+func (r *DeleteSeriesRangeRequest) ShardID() uint64     { return r.pb.GetShardID() }
+func (r *DeleteSeriesRangeRequest) Measurement() string { return r.pb.GetMeasurement() }
+func (r *DeleteSeriesRangeRequest) Condition() string   { return r.pb.GetCondition() }
+func (r *DeleteSeriesRangeRequest) TimeRange() (min, max int64) {
+	return r.pb.GetMinTime(), r.pb.GetMaxTime()
+}
+
+// MarshalBinary encodes the object to a binary format.
+func (r *DeleteSeriesRangeRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates DeleteSeriesRangeRequest from a binary format.
+func (r *DeleteSeriesRangeRequest) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteSeriesRangeResponse represents the response returned from a remote DeleteSeriesRangeRequest call.
+type DeleteSeriesRangeResponse struct {
+	pb internal.DeleteSeriesRangeResponse
+}
+
+func (r *DeleteSeriesRangeResponse) SetCode(code int)          { r.pb.Code = proto.Int32(int32(code)) }
+func (r *DeleteSeriesRangeResponse) SetMessage(message string) { r.pb.Message = &message }
+
+func (r *DeleteSeriesRangeResponse) Code() int       { return int(r.pb.GetCode()) }
+func (r *DeleteSeriesRangeResponse) Message() string { return r.pb.GetMessage() }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *DeleteSeriesRangeResponse) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates DeleteSeriesRangeResponse from a binary format.
+func (r *DeleteSeriesRangeResponse) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
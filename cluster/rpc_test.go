@@ -13,9 +13,12 @@ func TestWriteShardRequestBinary(t *testing.T) {
 		t.Fatalf("ShardID mismatch: got %v, exp %v", sr.ShardID(), exp)
 	}
 
+	sr.SetDurability(DurabilityLevelMemory)
+
 	sr.AddPoint("cpu", 1.0, time.Unix(0, 0), map[string]string{"host": "serverA"})
 	sr.AddPoint("cpu", 2.0, time.Unix(0, 0).Add(time.Hour), nil)
 	sr.AddPoint("cpu_load", 3.0, time.Unix(0, 0).Add(time.Hour+time.Second), nil)
+	sr.AddPoint("disk", uint64(1<<63+1), time.Unix(0, 0), nil)
 
 	b, err := sr.MarshalBinary()
 	if err != nil {
@@ -34,6 +37,10 @@ func TestWriteShardRequestBinary(t *testing.T) {
 		t.Errorf("ShardID mismatch: got %v, exp %v", got.ShardID(), sr.ShardID())
 	}
 
+	if got.Durability() != DurabilityLevelMemory {
+		t.Errorf("Durability mismatch: got %v, exp %v", got.Durability(), DurabilityLevelMemory)
+	}
+
 	if len(got.Points()) != len(sr.Points()) {
 		t.Errorf("Points count mismatch: got %v, exp %v", len(got.Points()), len(sr.Points()))
 	}
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -9,7 +10,9 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/tsdb"
 )
@@ -20,6 +23,18 @@ const MaxMessageSize = 1024 * 1024 * 1024 // 1GB
 // MuxHeader is the header byte used in the TCP mux.
 const MuxHeader = 2
 
+// readShardChunkSize is the maximum size of each chunk of a shard's data
+// file sent in response to a read shard request.
+const readShardChunkSize = 1024 * 1024 // 1MB
+
+// writeQueueSize is the number of writes acknowledged from memory that can
+// be queued on a connection before new writes wait for them to be applied.
+const writeQueueSize = 64
+
+// readShardNotFoundCode is the response code returned when a read shard
+// request is made for a shard that doesn't exist on the node.
+const readShardNotFoundCode = 2
+
 // Service processes data received over raw TCP connections.
 type Service struct {
 	mu sync.RWMutex
@@ -36,8 +51,18 @@ type Service struct {
 	TSDBStore interface {
 		CreateShard(database, policy string, shardID uint64) error
 		WriteToShard(shardID uint64, points []tsdb.Point) error
+		DeleteShard(shardID uint64) error
+		ExportShard(shardID uint64, w io.Writer) error
+		ImportShard(database, policy string, shardID uint64, r io.Reader) error
+		DeleteSeriesRange(shardID uint64, measurement string, condition influxql.Expr, min, max int64) error
 	}
 
+	// Observer, if set, is notified as remote shard writes are received and applied.
+	Observer WriteObserver
+
+	// buffers holds the buffers messages are read into.
+	buffers bufferPool
+
 	Logger *log.Logger
 }
 
@@ -125,9 +150,27 @@ func (s *Service) handleConn(conn net.Conn) {
 	defer func() {
 		s.Logger.Printf("close remote write connection from %v\n", conn.RemoteAddr())
 	}()
+
+	// Writes acknowledged from memory are applied in the order received by a
+	// separate goroutine. Queued writes are applied before the handler exits.
+	queue := make(chan *WriteShardRequest, writeQueueSize)
+	applied := make(chan struct{})
+	go func() {
+		defer close(applied)
+		for req := range queue {
+			if err := s.writeShard(req); err != nil {
+				s.Logger.Printf("process queued write shard error: %s", err)
+			}
+		}
+	}()
+	defer func() {
+		close(queue)
+		<-applied
+	}()
 	for {
-		// Read type-length-value.
-		typ, buf, err := ReadTLV(conn)
+		// Read type-length-value. Requests are decoded into their own
+		// structures so the buffer is reused once the message is handled.
+		typ, b, err := readTLV(conn, &s.buffers)
 		if err != nil {
 			if strings.HasSuffix(err.Error(), "EOF") {
 				return
@@ -136,28 +179,69 @@ func (s *Service) handleConn(conn net.Conn) {
 			return
 		}
 
+		buf := *b
+
 		// Delegate message processing by type.
 		switch typ {
 		case writeShardRequestMessage:
-			err := s.processWriteShardRequest(buf)
+			err := s.processWriteShardRequest(buf, queue)
 			if err != nil {
 				s.Logger.Printf("process write shard error: %s", err)
 			}
 			s.writeShardResponse(conn, err)
+		case deleteShardRequestMessage:
+			err := s.processDeleteShardRequest(buf)
+			if err != nil {
+				s.Logger.Printf("process delete shard error: %s", err)
+			}
+			s.deleteShardResponse(conn, err)
+		case deleteSeriesRangeRequestMessage:
+			err := s.processDeleteSeriesRangeRequest(buf)
+			if err != nil {
+				s.Logger.Printf("process delete series range error: %s", err)
+			}
+			s.deleteSeriesRangeResponse(conn, err)
+		case copyShardRequestMessage:
+			err := s.processCopyShardRequest(buf)
+			if err != nil {
+				s.Logger.Printf("process copy shard error: %s", err)
+			}
+			s.copyShardResponse(conn, err)
+		case readShardRequestMessage:
+			if err := s.processReadShardRequest(conn, buf); err != nil {
+				s.Logger.Printf("process read shard error: %s", err)
+				return
+			}
 		default:
 			s.Logger.Printf("cluster service message type not found: %d", typ)
 		}
+		s.buffers.put(b)
 	}
 }
 
-func (s *Service) processWriteShardRequest(buf []byte) error {
+// processWriteShardRequest writes the points in the request to the local
+// shard. Writes with memory durability are queued and acknowledged at once.
+func (s *Service) processWriteShardRequest(buf []byte, queue chan<- *WriteShardRequest) error {
 	// Build request
 	var req WriteShardRequest
 	if err := req.UnmarshalBinary(buf); err != nil {
 		return err
 	}
 
-	err := s.TSDBStore.WriteToShard(req.ShardID(), req.Points())
+	if req.Durability() == DurabilityLevelMemory {
+		queue <- &req
+		return nil
+	}
+	return s.writeShard(&req)
+}
+
+// writeShard writes the points in a request to the local shard.
+func (s *Service) writeShard(req *WriteShardRequest) error {
+	shardID, points := req.ShardID(), req.Points()
+	obs := observerOrNop(s.Observer)
+	obs.OnReceive(shardID, points)
+
+	err := s.TSDBStore.WriteToShard(shardID, points)
 
 	// We may have received a write for a shard that we don't have locally because the
 	// sending node may have just created the shard (via the metastore) and the write
@@ -167,26 +251,34 @@ func (s *Service) processWriteShardRequest(buf []byte) error {
 	if err == tsdb.ErrShardNotFound {
 
 		// Query the metastore for the owner of this shard
-		database, retentionPolicy, sgi := s.MetaStore.ShardOwner(req.ShardID())
+		database, retentionPolicy, sgi := s.MetaStore.ShardOwner(shardID)
 		if sgi == nil {
 			// If we can't find it, then we need to drop this request
 			// as it is no longer valid.  This could happen if writes were queued via
 			// hinted handoff and delivered after a shard group was deleted.
-			s.Logger.Printf("drop write request: shard=%d", req.ShardID())
+			s.Logger.Printf("drop write request: shard=%d", shardID)
 			return nil
 		}
 
-		err = s.TSDBStore.CreateShard(database, retentionPolicy, req.ShardID())
+		err = s.TSDBStore.CreateShard(database, retentionPolicy, shardID)
+		if err == nil {
+			err = s.TSDBStore.WriteToShard(shardID, points)
+		}
 		if err != nil {
+			obs.OnError(shardID, err)
 			return err
 		}
-		return s.TSDBStore.WriteToShard(req.ShardID(), req.Points())
+		obs.OnApply(shardID, points)
+		return nil
 	}
 
 	if err != nil {
-		return fmt.Errorf("write shard %d: %s", req.ShardID(), err)
+		err = fmt.Errorf("write shard %d: %s", shardID, err)
+		obs.OnError(shardID, err)
+		return err
 	}
 
+	obs.OnApply(shardID, points)
 	return nil
 }
 
@@ -213,34 +305,311 @@ func (s *Service) writeShardResponse(w io.Writer, e error) {
 	}
 }
 
+func (s *Service) processDeleteShardRequest(buf []byte) error {
+	var req DeleteShardRequest
+	if err := req.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+
+	if err := s.TSDBStore.DeleteShard(req.ShardID()); err != nil {
+		return fmt.Errorf("delete shard %d: %s", req.ShardID(), err)
+	}
+	s.Logger.Printf("shard ID %d deleted by remote request", req.ShardID())
+	return nil
+}
+
+func (s *Service) deleteShardResponse(w io.Writer, e error) {
+	// Build response.
+	var resp DeleteShardResponse
+	if e != nil {
+		resp.SetCode(1)
+		resp.SetMessage(e.Error())
+	} else {
+		resp.SetCode(0)
+	}
+
+	// Marshal response to binary.
+	buf, err := resp.MarshalBinary()
+	if err != nil {
+		s.Logger.Printf("error marshalling delete shard response: %s", err)
+		return
+	}
+
+	// Write to connection.
+	if err := WriteTLV(w, deleteShardResponseMessage, buf); err != nil {
+		s.Logger.Printf("delete shard response error: %s", err)
+	}
+}
+
+func (s *Service) processDeleteSeriesRangeRequest(buf []byte) error {
+	var req DeleteSeriesRangeRequest
+	if err := req.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+
+	var condition influxql.Expr
+	if req.Condition() != "" {
+		expr, err := influxql.ParseExpr(req.Condition())
+		if err != nil {
+			return err
+		}
+		condition = expr
+	}
+
+	min, max := req.TimeRange()
+	if err := s.TSDBStore.DeleteSeriesRange(req.ShardID(), req.Measurement(), condition, min, max); err != nil {
+		return fmt.Errorf("delete series range from shard %d: %s", req.ShardID(), err)
+	}
+	return nil
+}
+
+func (s *Service) deleteSeriesRangeResponse(w io.Writer, e error) {
+	// Build response.
+	var resp DeleteSeriesRangeResponse
+	if e != nil {
+		resp.SetCode(1)
+		resp.SetMessage(e.Error())
+	} else {
+		resp.SetCode(0)
+	}
+
+	// Marshal response to binary.
+	buf, err := resp.MarshalBinary()
+	if err != nil {
+		s.Logger.Printf("error marshalling delete series range response: %s", err)
+		return
+	}
+
+	// Write to connection.
+	if err := WriteTLV(w, deleteSeriesRangeResponseMessage, buf); err != nil {
+		s.Logger.Printf("delete series range response error: %s", err)
+	}
+}
+
+func (s *Service) processCopyShardRequest(buf []byte) error {
+	var req CopyShardRequest
+	if err := req.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+	shardID := req.ShardID()
+
+	conn, err := net.DialTimeout("tcp", req.SourceHost(), 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Write a marker byte for cluster messages.
+	if _, err := conn.Write([]byte{MuxHeader}); err != nil {
+		return err
+	}
+
+	// Request the shard's data file from the source node.
+	var readReq ReadShardRequest
+	readReq.SetShardID(shardID)
+	if buf, err = readReq.MarshalBinary(); err != nil {
+		return err
+	} else if err := WriteTLV(conn, readShardRequestMessage, buf); err != nil {
+		return err
+	}
+
+	// A shard that was never written to on the source may not exist there.
+	// The copy is an empty shard.
+	err = s.TSDBStore.ImportShard(req.Database(), req.Policy(), shardID, &shardReader{r: conn})
+	if err == tsdb.ErrShardNotFound {
+		err = s.TSDBStore.CreateShard(req.Database(), req.Policy(), shardID)
+	}
+	if err != nil {
+		return fmt.Errorf("copy shard %d from %s: %s", shardID, req.SourceHost(), err)
+	}
+	s.Logger.Printf("shard ID %d copied from %s", shardID, req.SourceHost())
+	return nil
+}
+
+func (s *Service) copyShardResponse(w io.Writer, e error) {
+	// Build response.
+	var resp CopyShardResponse
+	if e != nil {
+		resp.SetCode(1)
+		resp.SetMessage(e.Error())
+	} else {
+		resp.SetCode(0)
+	}
+
+	// Marshal response to binary.
+	buf, err := resp.MarshalBinary()
+	if err != nil {
+		s.Logger.Printf("error marshalling copy shard response: %s", err)
+		return
+	}
+
+	// Write to connection.
+	if err := WriteTLV(w, copyShardResponseMessage, buf); err != nil {
+		s.Logger.Printf("copy shard response error: %s", err)
+	}
+}
+
+// processReadShardRequest streams a shard's data file to w as a series of
+// chunk messages followed by a response message. Returns an error if the
+// connection can no longer be used.
+func (s *Service) processReadShardRequest(w io.Writer, buf []byte) error {
+	var req ReadShardRequest
+	if err := req.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+
+	// Errors writing to the connection are reported separately from export
+	// errors as they leave the stream in an unknown state.
+	cw := &shardChunkWriter{w: w}
+	bw := bufio.NewWriterSize(cw, readShardChunkSize)
+	e := s.TSDBStore.ExportShard(req.ShardID(), bw)
+	if e == nil {
+		e = bw.Flush()
+	}
+	if cw.err != nil {
+		return cw.err
+	}
+
+	// Build response.
+	var resp ReadShardResponse
+	if e == tsdb.ErrShardNotFound {
+		resp.SetCode(readShardNotFoundCode)
+		resp.SetMessage(e.Error())
+	} else if e != nil {
+		resp.SetCode(1)
+		resp.SetMessage(e.Error())
+	} else {
+		resp.SetCode(0)
+	}
+
+	// Marshal response to binary.
+	buf, err := resp.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return WriteTLV(w, readShardResponseMessage, buf)
+}
+
+// shardChunkWriter writes each call to Write as a chunk message.
+type shardChunkWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *shardChunkWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	} else if len(p) == 0 {
+		return 0, nil
+	}
+	if w.err = WriteTLV(w.w, readShardChunkMessage, p); w.err != nil {
+		return 0, w.err
+	}
+	return len(p), nil
+}
+
+// shardReader reads the chunk messages of a read shard response as a
+// stream. Returns io.EOF once the response message is read or the error
+// reported by the remote node.
+type shardReader struct {
+	r   io.Reader
+	buf []byte
+	err error
+}
+
+func (r *shardReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		typ, buf, err := ReadTLV(r.r)
+		if err != nil {
+			r.err = err
+			continue
+		}
+
+		switch typ {
+		case readShardChunkMessage:
+			r.buf = buf
+		case readShardResponseMessage:
+			var resp ReadShardResponse
+			if err := resp.UnmarshalBinary(buf); err != nil {
+				r.err = err
+			} else if resp.Code() == readShardNotFoundCode {
+				r.err = tsdb.ErrShardNotFound
+			} else if resp.Code() != 0 {
+				r.err = fmt.Errorf("error code %d: %s", resp.Code(), resp.Message())
+			} else {
+				r.err = io.EOF
+			}
+		default:
+			r.err = fmt.Errorf("unexpected message type: %d", typ)
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
 // ReadTLV reads a type-length-value record from r.
 func ReadTLV(r io.Reader) (byte, []byte, error) {
+	typ, sz, err := readTLVHeader(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Read the value.
+	buf := make([]byte, sz)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, nil, fmt.Errorf("read message value: %s", err)
+	}
+
+	return typ, buf, nil
+}
+
+// readTLV reads a type-length-value record from r into a buffer from the
+// pool. The buffer must be returned to the pool once the value is no longer
+// referenced.
+func readTLV(r io.Reader, p *bufferPool) (byte, *[]byte, error) {
+	typ, sz, err := readTLVHeader(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Read the value.
+	buf := p.get(int(sz))
+	if _, err := io.ReadFull(r, *buf); err != nil {
+		p.put(buf)
+		return 0, nil, fmt.Errorf("read message value: %s", err)
+	}
+
+	return typ, buf, nil
+}
+
+// readTLVHeader reads the type and length of a type-length-value record from r.
+func readTLVHeader(r io.Reader) (byte, int64, error) {
 	var typ [1]byte
 	if _, err := io.ReadFull(r, typ[:]); err != nil {
-		return 0, nil, fmt.Errorf("read message type: %s", err)
+		return 0, 0, fmt.Errorf("read message type: %s", err)
 	}
 
 	// Read the size of the message.
 	var sz int64
 	if err := binary.Read(r, binary.BigEndian, &sz); err != nil {
-		return 0, nil, fmt.Errorf("read message size: %s", err)
+		return 0, 0, fmt.Errorf("read message size: %s", err)
 	}
 
 	if sz == 0 {
-		return 0, nil, fmt.Errorf("invalid message size: %d", sz)
+		return 0, 0, fmt.Errorf("invalid message size: %d", sz)
 	}
 
 	if sz >= MaxMessageSize {
-		return 0, nil, fmt.Errorf("max message size of %d exceeded: %d", MaxMessageSize, sz)
-	}
-
-	// Read the value.
-	buf := make([]byte, sz)
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return 0, nil, fmt.Errorf("read message value: %s", err)
+		return 0, 0, fmt.Errorf("max message size of %d exceeded: %d", MaxMessageSize, sz)
 	}
 
-	return typ[0], buf, nil
+	return typ[0], sz, nil
 }
 
 // WriteTLV writes a type-length-value record to w.
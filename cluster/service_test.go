@@ -2,10 +2,12 @@ package cluster_test
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"time"
 
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/tcp"
 	"github.com/influxdb/influxdb/tsdb"
@@ -22,12 +24,19 @@ func (m *metaStore) Node(nodeID uint64) (*meta.NodeInfo, error) {
 	}, nil
 }
 
+func (m *metaStore) Watch() <-chan struct{} { return nil }
+
 type testService struct {
 	nodeID          uint64
 	ln              net.Listener
 	muxln           net.Listener
 	writeShardFunc  func(shardID uint64, points []tsdb.Point) error
 	createShardFunc func(database, policy string, shardID uint64) error
+	deleteShardFunc func(shardID uint64) error
+	exportShardFunc func(shardID uint64, w io.Writer) error
+	importShardFunc func(database, policy string, shardID uint64, r io.Reader) error
+	deleteRangeFunc func(shardID uint64, measurement string, condition influxql.Expr, min, max int64) error
+	responses       chan *serviceResponse
 }
 
 func newTestService(f func(shardID uint64, points []tsdb.Point) error) testService {
@@ -44,6 +53,7 @@ func newTestService(f func(shardID uint64, points []tsdb.Point) error) testServi
 		writeShardFunc: f,
 		ln:             ln,
 		muxln:          muxln,
+		responses:      make(chan *serviceResponse, 1024),
 	}
 }
 
@@ -68,11 +78,35 @@ func (t testService) CreateShard(database, policy string, shardID uint64) error
 	return t.createShardFunc(database, policy, shardID)
 }
 
-func writeShardSuccess(shardID uint64, points []tsdb.Point) error {
-	responses <- &serviceResponse{
-		shardID: shardID,
-		points:  points,
+func (t testService) DeleteShard(shardID uint64) error {
+	return t.deleteShardFunc(shardID)
+}
+
+func (t testService) ExportShard(shardID uint64, w io.Writer) error {
+	return t.exportShardFunc(shardID, w)
+}
+
+func (t testService) ImportShard(database, policy string, shardID uint64, r io.Reader) error {
+	return t.importShardFunc(database, policy, shardID, r)
+}
+
+func (t testService) DeleteSeriesRange(shardID uint64, measurement string, condition influxql.Expr, min, max int64) error {
+	return t.deleteRangeFunc(shardID, measurement, condition, min, max)
+}
+
+// Observer returns a write observer that records applied writes as responses.
+func (ts testService) Observer() cluster.WriteObserver {
+	return &cluster.WriteObserverFuncs{
+		OnApplyFn: func(shardID uint64, points []tsdb.Point) {
+			ts.responses <- &serviceResponse{
+				shardID: shardID,
+				points:  points,
+			}
+		},
 	}
+}
+
+func writeShardSuccess(shardID uint64, points []tsdb.Point) error {
 	return nil
 }
 
@@ -80,13 +114,11 @@ func writeShardFail(shardID uint64, points []tsdb.Point) error {
 	return fmt.Errorf("failed to write")
 }
 
-var responses = make(chan *serviceResponse, 1024)
-
-func (testService) ResponseN(n int) ([]*serviceResponse, error) {
+func (ts testService) ResponseN(n int) ([]*serviceResponse, error) {
 	var a []*serviceResponse
 	for {
 		select {
-		case r := <-responses:
+		case r := <-ts.responses:
 			a = append(a, r)
 			if len(a) == n {
 				return a, nil
@@ -2,44 +2,174 @@ package cluster
 
 import (
 	"fmt"
+	"io"
+	"log"
 	"net"
+	"os"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/tsdb"
-	"gopkg.in/fatih/pool.v2"
 )
 
 const (
 	writeShardRequestMessage byte = iota + 1
 	writeShardResponseMessage
+	deleteShardRequestMessage
+	deleteShardResponseMessage
+	copyShardRequestMessage
+	copyShardResponseMessage
+	readShardRequestMessage
+	readShardChunkMessage
+	readShardResponseMessage
+	deleteSeriesRangeRequestMessage
+	deleteSeriesRangeResponseMessage
 )
 
+// DefaultMaxRequestSize is the default size of the points sent in a single
+// write request. It leaves room under MaxMessageSize for their encoding.
+const DefaultMaxRequestSize = MaxMessageSize / 2
+
 // ShardWriter writes a set of points to a shard.
 type ShardWriter struct {
 	pool    *clientPool
 	timeout time.Duration
 
-	MetaStore interface {
+	wg      sync.WaitGroup
+	closing chan struct{}
+
+	nodesOnce sync.Once
+	nodes     interface {
 		Node(id uint64) (ni *meta.NodeInfo, err error)
 	}
+
+	MetaStore NodeMetaStore
+
+	// NodeCacheTTL is how long node lookups are cached. Cached nodes are
+	// also dropped when the meta store reports a change. Zero disables the cache.
+	NodeCacheTTL time.Duration
+
+	// Pooled connections are closed once they have been idle for
+	// PoolMaxIdleTime or open for PoolMaxLifetime. Zero disables either.
+	// Changes only apply to pools created afterwards.
+	PoolMaxIdleTime time.Duration
+	PoolMaxLifetime time.Duration
+
+	// MaxPointsPerBatch splits writes into requests of at most this many
+	// points so they are accepted by nodes limiting their batch size. Zero
+	// sends every write in one request.
+	MaxPointsPerBatch int
+
+	// MaxRequestSize splits writes into requests of at most this many bytes
+	// of points so they stay under the size of a message. Zero sends every
+	// write in one request.
+	MaxRequestSize int
+
+	Logger *log.Logger
 }
 
 // NewShardWriter returns a new instance of ShardWriter.
 func NewShardWriter(timeout time.Duration) *ShardWriter {
-	return &ShardWriter{
-		pool:    newClientPool(),
-		timeout: timeout,
+	w := &ShardWriter{
+		pool:            newClientPool(),
+		timeout:         timeout,
+		closing:         make(chan struct{}),
+		NodeCacheTTL:    DefaultNodeCacheTTL,
+		PoolMaxIdleTime: DefaultPoolMaxIdleTime,
+		PoolMaxLifetime: DefaultPoolMaxLifetime,
+		MaxRequestSize:  DefaultMaxRequestSize,
+		Logger:          log.New(os.Stderr, "[shard-writer] ", log.LstdFlags),
 	}
+
+	w.wg.Add(1)
+	go w.reapConnections()
+
+	return w
 }
 
+// PoolStats returns the state of the connection pool to each node.
+func (w *ShardWriter) PoolStats() map[uint64]PoolStats {
+	return w.pool.stats()
+}
+
+// StatisticsRow returns the state of the connection pool to each node for
+// SHOW STATS.
+func (w *ShardWriter) StatisticsRow() *influxql.Row {
+	stats := w.PoolStats()
+	ids := make([]uint64, 0, len(stats))
+	for id := range stats {
+		ids = append(ids, id)
+	}
+	sort.Sort(uint64Slice(ids))
+
+	row := &influxql.Row{Name: "shardWriterPool", Columns: []string{"nodeID", "inUse", "idle", "gets", "waitNs", "reaped"}}
+	for _, id := range ids {
+		st := stats[id]
+		row.Values = append(row.Values, []interface{}{id, st.InUse, st.Idle, st.Gets, int64(st.WaitTime), st.Reaped})
+	}
+	return row
+}
+
+type uint64Slice []uint64
+
+func (a uint64Slice) Len() int           { return len(a) }
+func (a uint64Slice) Less(i, j int) bool { return a[i] < a[j] }
+func (a uint64Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// reapConnections periodically closes pooled connections that have
+// expired so dead connections don't pile up between writes.
+func (w *ShardWriter) reapConnections() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(poolReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.closing:
+			return
+		case now := <-ticker.C:
+			if n := w.pool.reap(now); n > 0 {
+				w.Logger.Printf("closed %d expired connections", n)
+			}
+		}
+	}
+}
+
+// WriteShard writes points to a shard on a remote node and waits for them to be synced.
 func (w *ShardWriter) WriteShard(shardID, ownerID uint64, points []tsdb.Point) error {
+	return w.WriteShardWithDurability(shardID, ownerID, points, DurabilityLevelSync)
+}
+
+// WriteShardWithDurability writes points to a shard on a remote node. The
+// node acknowledges the write once it reaches the durability level. Writes
+// larger than MaxRequestSize or MaxPointsPerBatch are sent in several
+// requests; if one fails the points of the earlier requests have already
+// been written.
+func (w *ShardWriter) WriteShardWithDurability(shardID, ownerID uint64, points []tsdb.Point, durability DurabilityLevel) error {
+	for _, batch := range tsdb.Points(points).Split(w.MaxRequestSize, 0) {
+		for n := w.MaxPointsPerBatch; n > 0 && len(batch) > n; batch = batch[n:] {
+			if err := w.writeShard(shardID, ownerID, batch[:n], durability); err != nil {
+				return err
+			}
+		}
+		if err := w.writeShard(shardID, ownerID, batch, durability); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeShard sends points to a shard on a remote node in a single request.
+func (w *ShardWriter) writeShard(shardID, ownerID uint64, points []tsdb.Point, durability DurabilityLevel) error {
 	c, err := w.dial(ownerID)
 	if err != nil {
 		return err
 	}
 
-	conn, ok := c.(*pool.PoolConn)
+	conn, ok := c.(*pooledConn)
 	if !ok {
 		panic("wrong connection type")
 	}
@@ -50,6 +180,7 @@ func (w *ShardWriter) WriteShard(shardID, ownerID uint64, points []tsdb.Point) e
 	// Build write request.
 	var request WriteShardRequest
 	request.SetShardID(shardID)
+	request.SetDurability(durability)
 	request.AddPoints(points)
 
 	// Marshal into protocol buffers.
@@ -86,14 +217,220 @@ func (w *ShardWriter) WriteShard(shardID, ownerID uint64, points []tsdb.Point) e
 	return nil
 }
 
+// DeleteShard asks the owner node to remove the data for a shard.
+func (w *ShardWriter) DeleteShard(shardID, ownerID uint64) error {
+	c, err := w.dial(ownerID)
+	if err != nil {
+		return err
+	}
+
+	conn, ok := c.(*pooledConn)
+	if !ok {
+		panic("wrong connection type")
+	}
+	defer conn.Close() // return to pool
+
+	// Build delete request.
+	var request DeleteShardRequest
+	request.SetShardID(shardID)
+
+	// Marshal into protocol buffers.
+	buf, err := request.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	// Write request.
+	conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	if err := WriteTLV(conn, deleteShardRequestMessage, buf); err != nil {
+		conn.MarkUnusable()
+		return err
+	}
+
+	// Read the response.
+	conn.SetReadDeadline(time.Now().Add(w.timeout))
+	_, buf, err = ReadTLV(conn)
+	if err != nil {
+		conn.MarkUnusable()
+		return err
+	}
+
+	// Unmarshal response.
+	var response DeleteShardResponse
+	if err := response.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+
+	if response.Code() != 0 {
+		return fmt.Errorf("error code %d: %s", response.Code(), response.Message())
+	}
+
+	return nil
+}
+
+// DeleteSeriesRange asks the owner node to remove the points of a shard's
+// series of a measurement matching condition between min and max.
+func (w *ShardWriter) DeleteSeriesRange(shardID, ownerID uint64, measurement string, condition influxql.Expr, min, max int64) error {
+	c, err := w.dial(ownerID)
+	if err != nil {
+		return err
+	}
+
+	conn, ok := c.(*pooledConn)
+	if !ok {
+		panic("wrong connection type")
+	}
+	defer conn.Close() // return to pool
+
+	// Build delete request.
+	var request DeleteSeriesRangeRequest
+	request.SetShardID(shardID)
+	request.SetMeasurement(measurement)
+	if condition != nil {
+		request.SetCondition(condition.String())
+	}
+	request.SetTimeRange(min, max)
+
+	// Marshal into protocol buffers.
+	buf, err := request.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	// Write request.
+	conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	if err := WriteTLV(conn, deleteSeriesRangeRequestMessage, buf); err != nil {
+		conn.MarkUnusable()
+		return err
+	}
+
+	// Read the response.
+	conn.SetReadDeadline(time.Now().Add(w.timeout))
+	_, buf, err = ReadTLV(conn)
+	if err != nil {
+		conn.MarkUnusable()
+		return err
+	}
+
+	// Unmarshal response.
+	var response DeleteSeriesRangeResponse
+	if err := response.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+
+	if response.Code() != 0 {
+		return fmt.Errorf("error code %d: %s", response.Code(), response.Message())
+	}
+
+	return nil
+}
+
+// CopyShard asks the owner node to copy a shard's data from the node at
+// sourceHost. It blocks until the copy has finished.
+func (w *ShardWriter) CopyShard(shardID, ownerID uint64, database, policy, sourceHost string) error {
+	c, err := w.dial(ownerID)
+	if err != nil {
+		return err
+	}
+
+	conn, ok := c.(*pooledConn)
+	if !ok {
+		panic("wrong connection type")
+	}
+	defer conn.Close() // return to pool
+
+	// Build copy request.
+	var request CopyShardRequest
+	request.SetShardID(shardID)
+	request.SetDatabase(database)
+	request.SetPolicy(policy)
+	request.SetSourceHost(sourceHost)
+
+	// Marshal into protocol buffers.
+	buf, err := request.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	// Write request.
+	conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	if err := WriteTLV(conn, copyShardRequestMessage, buf); err != nil {
+		conn.MarkUnusable()
+		return err
+	}
+
+	// Read the response. Copying a large shard can take a long time so
+	// there is no deadline.
+	conn.SetReadDeadline(time.Time{})
+	_, buf, err = ReadTLV(conn)
+	if err != nil {
+		conn.MarkUnusable()
+		return err
+	}
+
+	// Unmarshal response.
+	var response CopyShardResponse
+	if err := response.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+
+	if response.Code() != 0 {
+		return fmt.Errorf("error code %d: %s", response.Code(), response.Message())
+	}
+
+	return nil
+}
+
+// ReadShard copies the data file of a shard on the owner node to w.
+// Returns tsdb.ErrShardNotFound if the owner doesn't have the shard.
+func (w *ShardWriter) ReadShard(shardID, ownerID uint64, dst io.Writer) error {
+	c, err := w.dial(ownerID)
+	if err != nil {
+		return err
+	}
+
+	conn, ok := c.(*pooledConn)
+	if !ok {
+		panic("wrong connection type")
+	}
+	defer conn.Close() // return to pool
+
+	// Build read request.
+	var request ReadShardRequest
+	request.SetShardID(shardID)
+
+	// Marshal into protocol buffers.
+	buf, err := request.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	// Write request.
+	conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	if err := WriteTLV(conn, readShardRequestMessage, buf); err != nil {
+		conn.MarkUnusable()
+		return err
+	}
+
+	// Stream the data file. The stream must be read to the end for the
+	// connection to be reused so there is no deadline.
+	conn.SetReadDeadline(time.Time{})
+	if _, err := io.Copy(dst, &shardReader{r: conn}); err != nil {
+		conn.MarkUnusable()
+		return err
+	}
+
+	return nil
+}
+
 func (c *ShardWriter) dial(nodeID uint64) (net.Conn, error) {
 	// If we don't have a connection pool for that addr yet, create one
 	_, ok := c.pool.getPool(nodeID)
 	if !ok {
 		factory := &connFactory{nodeID: nodeID, clientPool: c.pool, timeout: c.timeout}
-		factory.metaStore = c.MetaStore
+		factory.metaStore = c.nodeLookup()
 
-		p, err := pool.NewChannelPool(1, 3, factory.dial)
+		p, err := newConnPool(1, 3, c.PoolMaxIdleTime, c.PoolMaxLifetime, factory.dial)
 		if err != nil {
 			return nil, err
 		}
@@ -102,10 +439,27 @@ func (c *ShardWriter) dial(nodeID uint64) (net.Conn, error) {
 	return c.pool.conn(nodeID)
 }
 
+// nodeLookup returns the source of node lookups, caching them if enabled.
+func (c *ShardWriter) nodeLookup() interface {
+	Node(id uint64) (ni *meta.NodeInfo, err error)
+} {
+	c.nodesOnce.Do(func() {
+		if c.NodeCacheTTL > 0 {
+			c.nodes = newNodeCache(c.MetaStore, c.NodeCacheTTL)
+		} else {
+			c.nodes = c.MetaStore
+		}
+	})
+	return c.nodes
+}
+
 func (w *ShardWriter) Close() error {
 	if w.pool == nil {
 		return fmt.Errorf("client already closed")
 	}
+	close(w.closing)
+	w.wg.Wait()
+
 	w.pool.close()
 	w.pool = nil
 	return nil
@@ -114,6 +468,9 @@ func (w *ShardWriter) Close() error {
 const (
 	maxConnections = 500
 	maxRetries     = 3
+
+	// poolReapInterval is how often expired pooled connections are closed.
+	poolReapInterval = 10 * time.Second
 )
 
 var errMaxConnectionsExceeded = fmt.Errorf("can not exceed max connections of %d", maxConnections)
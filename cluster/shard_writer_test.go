@@ -1,12 +1,17 @@
 package cluster_test
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/tsdb"
 )
 
@@ -16,6 +21,7 @@ func TestShardWriter_WriteShard_Success(t *testing.T) {
 	s := cluster.NewService(cluster.Config{})
 	s.Listener = ts.muxln
 	s.TSDBStore = ts
+	s.Observer = ts.Observer()
 	if err := s.Open(); err != nil {
 		t.Fatal(err)
 	}
@@ -57,12 +63,79 @@ func TestShardWriter_WriteShard_Success(t *testing.T) {
 	}
 }
 
+// Ensure the shard writer reports the state of its connection pools.
+func TestShardWriter_PoolStats(t *testing.T) {
+	ts := newTestService(writeShardSuccess)
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	s.Observer = ts.Observer()
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	points := []tsdb.Point{tsdb.NewPoint("cpu", nil, map[string]interface{}{"value": int64(100)}, time.Now())}
+	for i := 0; i < 2; i++ {
+		if err := w.WriteShard(1, 2, points); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Both writes reuse the connection dialed when the pool was created.
+	if stats := w.PoolStats(); len(stats) != 1 {
+		t.Fatalf("unexpected pool count: %d", len(stats))
+	} else if st := stats[2]; st.InUse != 0 || st.Idle != 1 || st.Gets != 2 {
+		t.Fatalf("unexpected stats: %#v", st)
+	} else if row := w.StatisticsRow(); len(row.Values) != 1 || row.Values[0][0] != uint64(2) || row.Values[0][3] != int64(2) {
+		t.Fatalf("unexpected row: %v", row.Values)
+	}
+}
+
+// Ensure the service reads each message into a pooled buffer.
+func TestService_BufferPoolStats(t *testing.T) {
+	ts := newTestService(writeShardSuccess)
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	s.Observer = ts.Observer()
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	points := []tsdb.Point{tsdb.NewPoint("cpu", nil, map[string]interface{}{"value": int64(100)}, time.Now())}
+	for i := 0; i < 3; i++ {
+		if err := w.WriteShard(1, 2, points); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The pool may drop buffers at any time so only the first read must allocate.
+	if st := s.BufferPoolStats(); st.Gets != 3 || st.Allocs < 1 || st.Allocs > 3 {
+		t.Fatalf("unexpected stats: %#v", st)
+	} else if row := s.StatisticsRow(); row.Values[0][0] != int64(3) || row.Values[0][1] != st.Allocs {
+		t.Fatalf("unexpected row: %v", row.Values)
+	}
+}
+
 // Ensure the shard writer can successful write a multiple requests.
 func TestShardWriter_WriteShard_Multiple(t *testing.T) {
 	ts := newTestService(writeShardSuccess)
 	s := cluster.NewService(cluster.Config{})
 	s.Listener = ts.muxln
 	s.TSDBStore = ts
+	s.Observer = ts.Observer()
 	if err := s.Open(); err != nil {
 		t.Fatal(err)
 	}
@@ -106,12 +179,54 @@ func TestShardWriter_WriteShard_Multiple(t *testing.T) {
 	}
 }
 
+// Ensure the shard writer splits writes larger than its batch size.
+func TestShardWriter_WriteShard_MaxPointsPerBatch(t *testing.T) {
+	ts := newTestService(writeShardSuccess)
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	s.Observer = ts.Observer()
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	w.MaxPointsPerBatch = 2
+
+	var points []tsdb.Point
+	for i := 0; i < 5; i++ {
+		points = append(points, tsdb.NewPoint("cpu", tsdb.Tags{"host": "server01"}, map[string]interface{}{"value": int64(i)}, time.Unix(int64(i), 0)))
+	}
+	if err := w.WriteShard(1, 2, points); err != nil {
+		t.Fatal(err)
+	} else if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	responses, err := ts.ResponseN(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, exp := range []int{2, 2, 1} {
+		if n := len(responses[i].points); n != exp {
+			t.Fatalf("unexpected points in request %d: %d", i, n)
+		}
+	}
+	if v := responses[2].points[0].Fields()["value"]; v != int64(4) {
+		t.Fatalf("unexpected last point: %v", v)
+	}
+}
+
 // Ensure the shard writer returns an error when the server fails to accept the write.
 func TestShardWriter_WriteShard_Error(t *testing.T) {
 	ts := newTestService(writeShardFail)
 	s := cluster.NewService(cluster.Config{})
 	s.Listener = ts.muxln
 	s.TSDBStore = ts
+	s.Observer = ts.Observer()
 	if err := s.Open(); err != nil {
 		t.Fatal(err)
 	}
@@ -134,12 +249,55 @@ func TestShardWriter_WriteShard_Error(t *testing.T) {
 	}
 }
 
+// Ensure the service notifies its observer when a remote write fails.
+func TestService_Observer_OnError(t *testing.T) {
+	ts := newTestService(writeShardFail)
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+
+	var received uint64
+	errs := make(chan error, 1)
+	s.Observer = &cluster.WriteObserverFuncs{
+		OnReceiveFn: func(shardID uint64, points []tsdb.Point) { received = shardID },
+		OnApplyFn:   func(shardID uint64, points []tsdb.Point) { t.Errorf("unexpected apply: shard=%d", shardID) },
+		OnErrorFn:   func(shardID uint64, err error) { errs <- err },
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	points := []tsdb.Point{tsdb.NewPoint(
+		"cpu", tsdb.Tags{"host": "server01"}, map[string]interface{}{"value": int64(100)}, time.Now(),
+	)}
+
+	if err := w.WriteShard(1, 2, points); err == nil {
+		t.Fatal("expected error")
+	}
+
+	select {
+	case err := <-errs:
+		if err.Error() != "write shard 1: failed to write" {
+			t.Fatalf("unexpected observed error: %s", err)
+		} else if received != 1 {
+			t.Fatalf("unexpected received shard id: %d", received)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for observer")
+	}
+}
+
 // Ensure the shard writer returns an error when dialing times out.
 func TestShardWriter_Write_ErrDialTimeout(t *testing.T) {
 	ts := newTestService(writeShardSuccess)
 	s := cluster.NewService(cluster.Config{})
 	s.Listener = ts.muxln
 	s.TSDBStore = ts
+	s.Observer = ts.Observer()
 	if err := s.Open(); err != nil {
 		t.Fatal(err)
 	}
@@ -184,3 +342,266 @@ func TestShardWriter_Write_ErrReadTimeout(t *testing.T) {
 		t.Fatalf("unexpected error: %s", err)
 	}
 }
+
+// Ensure the shard writer can delete a shard on a remote node.
+func TestShardWriter_DeleteShard(t *testing.T) {
+	var deleted uint64
+	ts := newTestService(writeShardSuccess)
+	ts.deleteShardFunc = func(shardID uint64) error {
+		deleted = shardID
+		return nil
+	}
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	if err := w.DeleteShard(10, 2); err != nil {
+		t.Fatal(err)
+	} else if deleted != 10 {
+		t.Fatalf("unexpected shard id: %d", deleted)
+	}
+}
+
+// Ensure the shard writer returns an error when the remote node fails to delete a shard.
+func TestShardWriter_DeleteShard_Error(t *testing.T) {
+	ts := newTestService(writeShardSuccess)
+	ts.deleteShardFunc = func(shardID uint64) error {
+		return fmt.Errorf("failed to delete")
+	}
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	if err := w.DeleteShard(10, 2); err == nil || err.Error() != "error code 1: delete shard 10: failed to delete" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the shard writer can remove a time range of a series from a remote node.
+func TestShardWriter_DeleteSeriesRange(t *testing.T) {
+	var req string
+	ts := newTestService(writeShardSuccess)
+	ts.deleteRangeFunc = func(shardID uint64, measurement string, condition influxql.Expr, min, max int64) error {
+		req = fmt.Sprintf("%d %s %s %d %d", shardID, measurement, condition, min, max)
+		return nil
+	}
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	cond, err := influxql.ParseExpr(`host = 'serverA'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.DeleteSeriesRange(10, 2, "cpu", cond, 100, 200); err != nil {
+		t.Fatal(err)
+	} else if req != "10 cpu host = 'serverA' 100 200" {
+		t.Fatalf("unexpected request: %s", req)
+	}
+}
+
+// Ensure the shard writer can have a remote node copy a shard from another node.
+func TestShardWriter_CopyShard(t *testing.T) {
+	// Larger than a single chunk so the data is streamed in several messages.
+	data := bytes.Repeat([]byte("0123456789"), 300000)
+
+	var imported []byte
+	ts := newTestService(writeShardSuccess)
+	ts.exportShardFunc = func(shardID uint64, w io.Writer) error {
+		if shardID != 10 {
+			t.Fatalf("unexpected shard id: %d", shardID)
+		}
+		_, err := w.Write(data)
+		return err
+	}
+	ts.importShardFunc = func(database, policy string, shardID uint64, r io.Reader) error {
+		if database != "db0" || policy != "rp0" || shardID != 10 {
+			t.Fatalf("unexpected shard: %s.%s.%d", database, policy, shardID)
+		}
+		var err error
+		imported, err = ioutil.ReadAll(r)
+		return err
+	}
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	// The service copies the shard from itself.
+	if err := w.CopyShard(10, 2, "db0", "rp0", ts.ln.Addr().String()); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(imported, data) {
+		t.Fatalf("unexpected data: %d bytes, exp %d", len(imported), len(data))
+	}
+}
+
+// Ensure an empty shard is created when the source node doesn't have the shard.
+func TestShardWriter_CopyShard_NotFound(t *testing.T) {
+	var created uint64
+	ts := newTestService(writeShardSuccess)
+	ts.exportShardFunc = func(shardID uint64, w io.Writer) error {
+		return tsdb.ErrShardNotFound
+	}
+	ts.importShardFunc = func(database, policy string, shardID uint64, r io.Reader) error {
+		_, err := ioutil.ReadAll(r)
+		return err
+	}
+	ts.createShardFunc = func(database, policy string, shardID uint64) error {
+		created = shardID
+		return nil
+	}
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	if err := w.CopyShard(10, 2, "db0", "rp0", ts.ln.Addr().String()); err != nil {
+		t.Fatal(err)
+	} else if created != 10 {
+		t.Fatalf("unexpected shard id: %d", created)
+	}
+}
+
+// Ensure the shard writer returns an error when the source node fails to export a shard.
+func TestShardWriter_CopyShard_Error(t *testing.T) {
+	ts := newTestService(writeShardSuccess)
+	ts.exportShardFunc = func(shardID uint64, w io.Writer) error {
+		return fmt.Errorf("failed to export")
+	}
+	ts.importShardFunc = func(database, policy string, shardID uint64, r io.Reader) error {
+		_, err := ioutil.ReadAll(r)
+		return err
+	}
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	host := ts.ln.Addr().String()
+	if err := w.CopyShard(10, 2, "db0", "rp0", host); err == nil || err.Error() != "error code 1: copy shard 10 from "+host+": error code 1: failed to export" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the shard writer can read a shard from a remote node.
+func TestShardWriter_ReadShard(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 300000)
+
+	ts := newTestService(writeShardSuccess)
+	ts.exportShardFunc = func(shardID uint64, w io.Writer) error {
+		if shardID == 11 {
+			return tsdb.ErrShardNotFound
+		}
+		_, err := w.Write(data)
+		return err
+	}
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	var buf bytes.Buffer
+	if err := w.ReadShard(10, 2, &buf); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("unexpected data: %d bytes, exp %d", buf.Len(), len(data))
+	}
+
+	if err := w.ReadShard(11, 2, &buf); err != tsdb.ErrShardNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a write with memory durability is acknowledged before it is applied.
+func TestShardWriter_WriteShardWithDurability_Memory(t *testing.T) {
+	release, applied := make(chan struct{}), make(chan struct{})
+	ts := newTestService(func(shardID uint64, points []tsdb.Point) error {
+		<-release
+		close(applied)
+		return nil
+	})
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	defer w.Close()
+
+	points := []tsdb.Point{tsdb.NewPoint("cpu", nil, map[string]interface{}{"value": int64(100)}, time.Now())}
+	if err := w.WriteShardWithDurability(1, 2, points, cluster.DurabilityLevelMemory); err != nil {
+		t.Fatal(err)
+	}
+
+	// The write is applied once the store is unblocked.
+	close(release)
+	select {
+	case <-applied:
+	case <-time.After(time.Second):
+		t.Fatal("write not applied")
+	}
+}
@@ -0,0 +1,207 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/snapshot"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Snapshotter creates a backup of the whole cluster as of a point in time.
+//
+// Every shard in a shard group that ended at or before the snapshot time is
+// copied from one of its owners, the local node first. The copies and the
+// metadata are written to a single snapshot file that can be restored with
+// "influxd restore", and the snapshot's manifest is recorded in the metastore.
+//
+// Each shard is copied from a consistent view of that shard but late writes
+// to shard groups that have already ended are not blocked during the copy.
+type Snapshotter struct {
+	MetaStore interface {
+		NodeID() uint64
+		Databases() ([]meta.DatabaseInfo, error)
+		MarshalBinary() ([]byte, error)
+		CreateSnapshot(si *meta.SnapshotInfo) (*meta.SnapshotInfo, error)
+	}
+
+	TSDBStore interface {
+		ExportShard(shardID uint64, w io.Writer) error
+	}
+
+	ShardReader interface {
+		ReadShard(shardID, ownerID uint64, w io.Writer) error
+	}
+
+	Logger *log.Logger
+
+	// Now returns the current time. Overridden for testing.
+	Now func() time.Time
+}
+
+// NewSnapshotter returns a new instance of Snapshotter.
+func NewSnapshotter() *Snapshotter {
+	return &Snapshotter{
+		Logger: log.New(os.Stderr, "[snapshot] ", log.LstdFlags),
+		Now:    time.Now,
+	}
+}
+
+// Snapshot writes a snapshot of the shards that ended at or before t to the
+// file at path and records its manifest in the metastore.
+func (s *Snapshotter) Snapshot(t time.Time, path string) (*meta.SnapshotInfo, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("snapshot already exists: %s", path)
+	}
+
+	// Capture the metadata before the shard list so every shard is in it.
+	buf, err := s.MetaStore.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("marshal meta: %s", err)
+	}
+	dis, err := s.MetaStore.Databases()
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy shards to a temporary directory next to the snapshot.
+	tmpdir, err := ioutil.TempDir(filepath.Dir(path), filepath.Base(path)+".shards")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	sw := snapshot.NewWriter()
+	defer sw.Close()
+	sw.Manifest.Files = append(sw.Manifest.Files, snapshot.File{Name: "meta", Size: int64(len(buf)), ModTime: s.Now()})
+	sw.FileWriters["meta"] = tsdb.NopWriteToCloser(bytes.NewReader(buf))
+
+	si := &meta.SnapshotInfo{Time: t.UTC(), Path: path}
+	for _, di := range dis {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() || sgi.EndTime.After(t) {
+					continue
+				}
+
+				for _, sh := range sgi.Shards {
+					name := filepath.Join(di.Name, rpi.Name, strconv.FormatUint(sh.ID, 10))
+					nodeID, f, err := s.copyShard(sh, filepath.Join(tmpdir, strconv.FormatUint(sh.ID, 10)))
+					if err != nil {
+						return nil, fmt.Errorf("copy shard %d: %s", sh.ID, err)
+					} else if f == nil {
+						continue // shard was never written to
+					}
+
+					fi, err := f.Stat()
+					if err != nil {
+						f.Close()
+						return nil, err
+					}
+					sw.Manifest.Files = append(sw.Manifest.Files, snapshot.File{Name: name, Size: fi.Size(), ModTime: fi.ModTime()})
+					sw.FileWriters[name] = &snapshotFile{f}
+
+					si.Shards = append(si.Shards, meta.SnapshotShardInfo{
+						ID:              sh.ID,
+						Database:        di.Name,
+						RetentionPolicy: rpi.Name,
+						NodeID:          nodeID,
+					})
+				}
+			}
+		}
+	}
+
+	// Write the snapshot file and move it into place once complete.
+	if err := writeSnapshotFile(sw, path); err != nil {
+		return nil, err
+	}
+
+	si.CreatedAt = s.Now().UTC()
+	other, err := s.MetaStore.CreateSnapshot(si)
+	if err != nil {
+		return nil, fmt.Errorf("record snapshot: %s", err)
+	}
+	s.Logger.Printf("snapshot of %d shards as of %s written to %s", len(si.Shards), t.UTC().Format(time.RFC3339), path)
+	return other, nil
+}
+
+// copyShard copies a shard from the first owner that has it to path and
+// returns the owner and the open copy. Returns a nil file if no owner has
+// the shard.
+func (s *Snapshotter) copyShard(sh meta.ShardInfo, path string) (uint64, *os.File, error) {
+	// Prefer the local copy.
+	owners := make([]uint64, 0, len(sh.OwnerIDs))
+	localID := s.MetaStore.NodeID()
+	if sh.OwnedBy(localID) {
+		owners = append(owners, localID)
+	}
+	for _, id := range sh.OwnerIDs {
+		if id != localID {
+			owners = append(owners, id)
+		}
+	}
+
+	var lastErr error
+	for _, nodeID := range owners {
+		f, err := os.Create(path)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if nodeID == localID {
+			err = s.TSDBStore.ExportShard(sh.ID, f)
+		} else {
+			err = s.ShardReader.ReadShard(sh.ID, nodeID, f)
+		}
+		if err == nil {
+			if _, err := f.Seek(0, 0); err != nil {
+				f.Close()
+				return 0, nil, err
+			}
+			return nodeID, f, nil
+		}
+		f.Close()
+
+		if err != tsdb.ErrShardNotFound {
+			s.Logger.Printf("failed to copy shard %d from node %d: %s", sh.ID, nodeID, err)
+			lastErr = err
+		}
+	}
+	return 0, nil, lastErr
+}
+
+// writeSnapshotFile writes the snapshot to a temporary file and renames it to path.
+func writeSnapshotFile(sw *snapshot.Writer, path string) error {
+	tmppath := path + ".pending"
+	f, err := os.Create(tmppath)
+	if err != nil {
+		return err
+	}
+	if _, err := sw.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(tmppath)
+		return fmt.Errorf("write snapshot: %s", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmppath)
+		return err
+	}
+	return os.Rename(tmppath, path)
+}
+
+// snapshotFile adds a copied shard to a snapshot.
+type snapshotFile struct {
+	f *os.File
+}
+
+func (sf *snapshotFile) WriteTo(w io.Writer) (int64, error) { return io.Copy(w, sf.f) }
+func (sf *snapshotFile) Close() error                       { return sf.f.Close() }
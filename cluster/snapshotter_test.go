@@ -0,0 +1,188 @@
+package cluster_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/snapshot"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Ensure the snapshotter writes ended shards from their owners to a single snapshot.
+func TestSnapshotter_Snapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influxdb-snapshotter-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Three hour long groups on two nodes with one shard each.
+	ms := NewSnapshotMetaStore(t, 3)
+	s := NewSnapshotter(ms)
+	s.TSDBStore = &SnapshotTSDBStore{
+		ExportShardFn: func(shardID uint64, w io.Writer) error {
+			if shardID == 2 {
+				return tsdb.ErrShardNotFound
+			}
+			_, err := fmt.Fprintf(w, "local%d", shardID)
+			return err
+		},
+	}
+	s.ShardReader = &ShardReader{
+		ReadShardFn: func(shardID, ownerID uint64, w io.Writer) error {
+			_, err := fmt.Fprintf(w, "remote%d:%d", shardID, ownerID)
+			return err
+		},
+	}
+
+	path := filepath.Join(dir, "snapshot")
+	si, err := s.Snapshot(time.Unix(0, 0).Add(2*time.Hour), path)
+	if err != nil {
+		t.Fatal(err)
+	} else if si.ID != 1 || !si.Time.Equal(time.Unix(0, 0).Add(2*time.Hour)) || si.Path != path {
+		t.Fatalf("unexpected snapshot: %#v", si)
+	} else if !reflect.DeepEqual(si.Shards, []meta.SnapshotShardInfo{
+		{ID: 1, Database: "db0", RetentionPolicy: "rp0", NodeID: 1},
+		{ID: 2, Database: "db0", RetentionPolicy: "rp0", NodeID: 2},
+	}) {
+		t.Fatalf("unexpected shards: %#v", si.Shards)
+	} else if len(ms.Data.Snapshots) != 1 {
+		t.Fatalf("snapshot not recorded: %d", len(ms.Data.Snapshots))
+	}
+
+	// Read back every file in the snapshot.
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	files := make(map[string]string)
+	sr := snapshot.NewReader(f)
+	for {
+		sf, err := sr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := ioutil.ReadAll(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[sf.Name] = string(buf)
+	}
+
+	if files["meta"] != "meta" {
+		t.Fatalf("unexpected meta: %q", files["meta"])
+	}
+	delete(files, "meta")
+	if !reflect.DeepEqual(files, map[string]string{
+		filepath.Join("db0", "rp0", "1"): "local1",
+		filepath.Join("db0", "rp0", "2"): "remote2:2",
+	}) {
+		t.Fatalf("unexpected files: %v", files)
+	}
+}
+
+// Ensure a shard that no owner can provide fails the snapshot.
+func TestSnapshotter_Snapshot_CopyError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influxdb-snapshotter-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ms := NewSnapshotMetaStore(t, 1)
+	s := NewSnapshotter(ms)
+	s.TSDBStore = &SnapshotTSDBStore{
+		ExportShardFn: func(shardID uint64, w io.Writer) error { return tsdb.ErrShardNotFound },
+	}
+	s.ShardReader = &ShardReader{
+		ReadShardFn: func(shardID, ownerID uint64, w io.Writer) error { return errors.New("marker") },
+	}
+
+	path := filepath.Join(dir, "snapshot")
+	if _, err := s.Snapshot(time.Unix(0, 0).Add(time.Hour), path); err == nil || err.Error() != "copy shard 1: marker" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("snapshot file exists: %v", err)
+	} else if len(ms.Data.Snapshots) != 0 {
+		t.Fatal("snapshot recorded")
+	}
+}
+
+// NewSnapshotter returns a snapshotter with logging discarded.
+func NewSnapshotter(ms *SnapshotMetaStore) *cluster.Snapshotter {
+	s := cluster.NewSnapshotter()
+	s.MetaStore = ms
+	s.Logger = log.New(ioutil.Discard, "", 0)
+	return s
+}
+
+// SnapshotMetaStore is a metastore for the snapshotter backed by meta.Data.
+type SnapshotMetaStore struct {
+	Data meta.Data
+}
+
+// NewSnapshotMetaStore returns a metastore for node 1 of a two node cluster
+// with groupN hour long shard groups. Each group has a shard on both nodes.
+func NewSnapshotMetaStore(t *testing.T, groupN int) *SnapshotMetaStore {
+	ms := &SnapshotMetaStore{}
+	for _, host := range []string{"host0", "host1"} {
+		if err := ms.Data.CreateNode(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ms.Data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := ms.Data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, Duration: time.Hour, ShardGroupDuration: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < groupN; i++ {
+		ms.Data.Index = uint64(i)
+		if err := ms.Data.CreateShardGroup("db0", "rp0", time.Unix(0, 0).Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ms
+}
+
+func (m *SnapshotMetaStore) NodeID() uint64                          { return 1 }
+func (m *SnapshotMetaStore) Databases() ([]meta.DatabaseInfo, error) { return m.Data.Databases, nil }
+func (m *SnapshotMetaStore) MarshalBinary() ([]byte, error)          { return []byte("meta"), nil }
+func (m *SnapshotMetaStore) CreateSnapshot(si *meta.SnapshotInfo) (*meta.SnapshotInfo, error) {
+	if err := m.Data.CreateSnapshot(si); err != nil {
+		return nil, err
+	}
+	other := m.Data.Snapshots[len(m.Data.Snapshots)-1]
+	return &other, nil
+}
+
+// SnapshotTSDBStore is a mock implementation of Snapshotter.TSDBStore.
+type SnapshotTSDBStore struct {
+	ExportShardFn func(shardID uint64, w io.Writer) error
+}
+
+func (s *SnapshotTSDBStore) ExportShard(shardID uint64, w io.Writer) error {
+	return s.ExportShardFn(shardID, w)
+}
+
+// ShardReader is a mock implementation of Snapshotter.ShardReader.
+type ShardReader struct {
+	ReadShardFn func(shardID, ownerID uint64, w io.Writer) error
+}
+
+func (r *ShardReader) ReadShard(shardID, ownerID uint64, w io.Writer) error {
+	return r.ReadShardFn(shardID, ownerID, w)
+}
@@ -9,7 +9,9 @@ import (
 	"log"
 	"net"
 	"os"
+	"strings"
 
+	"github.com/influxdb/influxdb/s3"
 	"github.com/influxdb/influxdb/services/snapshotter"
 	"github.com/influxdb/influxdb/snapshot"
 )
@@ -26,6 +28,21 @@ type Command struct {
 	Stderr io.Writer
 }
 
+// Options represents the command line arguments.
+type Options struct {
+	Host string
+	Path string
+
+	// If set, only the metadata is backed up.
+	MetaOnly bool
+
+	// Settings used when Path is an "s3://bucket/key" URL.
+	S3Endpoint string
+	S3Region   string
+	S3SSE      string
+	S3KMSKeyID string
+}
+
 // NewCommand returns a new instance of Command with default settings.
 func NewCommand() *Command {
 	return &Command{
@@ -40,15 +57,31 @@ func (cmd *Command) Run(args ...string) error {
 	cmd.Logger.Printf("influxdb backup")
 
 	// Parse command line arguments.
-	host, path, err := cmd.parseFlags(args)
+	opt, err := cmd.parseFlags(args)
 	if err != nil {
 		return err
 	}
+	host, path := opt.Host, opt.Path
 
-	// Retrieve snapshot from local file.
-	m, err := snapshot.ReadFileManifest(path)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("read file snapshot: %s", err)
+	// Stream directly to object storage if an S3 URL is given.
+	if strings.HasPrefix(path, "s3://") {
+		if err := cmd.backupS3(opt); err != nil {
+			return err
+		}
+		cmd.Logger.Println("backup complete")
+		return nil
+	}
+
+	// Retrieve snapshot from local file. Metadata backups are never
+	// incremental so the previous manifest isn't needed.
+	req := &snapshotter.Request{MetaOnly: opt.MetaOnly}
+	if !opt.MetaOnly {
+		m, err := snapshot.ReadFileManifest(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("read file snapshot: %s", err)
+		} else if m != nil {
+			req.Manifest = *m
+		}
 	}
 
 	// Determine temporary path to download to.
@@ -63,7 +96,7 @@ func (cmd *Command) Run(args ...string) error {
 	}
 
 	// Retrieve snapshot.
-	if err := cmd.download(host, m, tmppath); err != nil {
+	if err := cmd.download(host, req, tmppath); err != nil {
 		return fmt.Errorf("download: %s", err)
 	}
 
@@ -81,24 +114,71 @@ func (cmd *Command) Run(args ...string) error {
 }
 
 // parseFlags parses and validates the command line arguments.
-func (cmd *Command) parseFlags(args []string) (host string, path string, err error) {
+func (cmd *Command) parseFlags(args []string) (*Options, error) {
+	var opt Options
 	fs := flag.NewFlagSet("", flag.ContinueOnError)
-	fs.StringVar(&host, "host", "localhost:8088", "")
+	fs.StringVar(&opt.Host, "host", "localhost:8088", "")
+	fs.BoolVar(&opt.MetaOnly, "meta", false, "")
+	fs.StringVar(&opt.S3Endpoint, "s3-endpoint", s3.DefaultEndpoint, "")
+	fs.StringVar(&opt.S3Region, "s3-region", s3.DefaultRegion, "")
+	fs.StringVar(&opt.S3SSE, "s3-sse", "", "")
+	fs.StringVar(&opt.S3KMSKeyID, "s3-kms-key-id", "", "")
 	fs.SetOutput(cmd.Stderr)
 	fs.Usage = cmd.printUsage
 	if err := fs.Parse(args); err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	// Ensure that only one arg is specified.
 	if fs.NArg() == 0 {
-		return "", "", errors.New("snapshot path required")
+		return nil, errors.New("snapshot path required")
 	} else if fs.NArg() != 1 {
-		return "", "", errors.New("only one snapshot path allowed")
+		return nil, errors.New("only one snapshot path allowed")
 	}
-	path = fs.Arg(0)
+	opt.Path = fs.Arg(0)
 
-	return host, path, nil
+	switch opt.S3SSE {
+	case "", s3.SSES3, s3.SSEKMS:
+	default:
+		return nil, fmt.Errorf("invalid s3 server-side encryption: %s", opt.S3SSE)
+	}
+
+	return &opt, nil
+}
+
+// backupS3 streams a full snapshot from the host to an object in S3.
+// Nothing is written to local disk. The upload is aborted on failure so
+// that a partial snapshot is never left in the bucket.
+func (cmd *Command) backupS3(opt *Options) error {
+	bucket, key, err := s3.ParseURL(opt.Path)
+	if err != nil {
+		return err
+	}
+
+	c := s3.NewClient()
+	c.Endpoint = opt.S3Endpoint
+	c.Region = opt.S3Region
+	c.ServerSideEncryption = opt.S3SSE
+	c.KMSKeyID = opt.S3KMSKeyID
+	c.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	c.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	c.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+
+	w, err := c.NewWriter(bucket, key)
+	if err != nil {
+		return fmt.Errorf("start upload: %s", err)
+	}
+
+	// Incremental backups require the previous manifest so always request a
+	// full snapshot.
+	if err := cmd.snapshot(opt.Host, &snapshotter.Request{MetaOnly: opt.MetaOnly}, w); err != nil {
+		w.Abort()
+		return fmt.Errorf("download: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("upload: %s", err)
+	}
+	return nil
 }
 
 // nextPath returns the next file to write to.
@@ -122,7 +202,7 @@ func (cmd *Command) nextPath(path string) (string, error) {
 }
 
 // download downloads a snapshot from a host to a given path.
-func (cmd *Command) download(host string, m *snapshot.Manifest, path string) error {
+func (cmd *Command) download(host string, req *snapshotter.Request, path string) error {
 	// Create local file to write to.
 	f, err := os.Create(path)
 	if err != nil {
@@ -130,6 +210,11 @@ func (cmd *Command) download(host string, m *snapshot.Manifest, path string) err
 	}
 	defer f.Close()
 
+	return cmd.snapshot(host, req, f)
+}
+
+// snapshot requests a snapshot from a host and copies it to w.
+func (cmd *Command) snapshot(host string, req *snapshotter.Request, w io.Writer) error {
 	// Connect to snapshotter service.
 	conn, err := net.Dial("tcp", host)
 	if err != nil {
@@ -142,14 +227,14 @@ func (cmd *Command) download(host string, m *snapshot.Manifest, path string) err
 		return fmt.Errorf("write snapshot header byte: %s", err)
 	}
 
-	// Write the manifest we currently have.
-	if err := json.NewEncoder(conn).Encode(m); err != nil {
-		return fmt.Errorf("encode snapshot manifest: %s", err)
+	// Write the request with the manifest we currently have.
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("encode snapshot request: %s", err)
 	}
 
 	// Read snapshot from the connection.
-	if _, err := io.Copy(f, conn); err != nil {
-		return fmt.Errorf("copy snapshot to file: %s", err)
+	if _, err := io.Copy(w, conn); err != nil {
+		return fmt.Errorf("copy snapshot: %s", err)
 	}
 
 	// FIXME(benbjohnson): Verify integrity of snapshot.
@@ -163,8 +248,33 @@ func (cmd *Command) printUsage() {
 
 backup downloads a snapshot of a data node and saves it to disk.
 
+With -meta the snapshot only contains the metadata: databases, retention
+policies, users, continuous queries, shard groups and nodes. It can be
+restored onto a fresh node with "influxd restore -meta-only".
+
+If PATH is an "s3://bucket/key" URL then a full snapshot is streamed directly
+to the object using a multipart upload. Credentials are read from the
+AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN variables.
+
         -host <host:port>
                           The host to connect to snapshot.
                           Defaults to 127.0.0.1:8088.
+
+        -meta
+                          Only back up the metadata.
+
+        -s3-endpoint <url>
+                          The S3-compatible endpoint to upload to.
+                          Defaults to https://s3.amazonaws.com.
+
+        -s3-region <region>
+                          The region used to sign requests.
+                          Defaults to us-east-1.
+
+        -s3-sse <AES256|aws:kms>
+                          Server-side encryption for the uploaded object.
+
+        -s3-kms-key-id <id>
+                          The KMS key used with aws:kms encryption.
 `)
 }
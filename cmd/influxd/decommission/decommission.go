@@ -0,0 +1,136 @@
+package decommission
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// Command represents the program execution for "influxd decommission".
+type Command struct {
+	// The logger used to report progress.
+	Logger *log.Logger
+
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+}
+
+// Options represents the command line arguments.
+type Options struct {
+	Host     string
+	Username string
+	Password string
+	NodeID   uint64
+}
+
+// NewCommand returns a new instance of Command with default settings.
+func NewCommand() *Command {
+	return &Command{
+		Stderr: os.Stderr,
+	}
+}
+
+// Run excutes the program.
+func (cmd *Command) Run(args ...string) error {
+	// Set up logger.
+	cmd.Logger = log.New(cmd.Stderr, "", log.LstdFlags)
+	cmd.Logger.Printf("influxdb decommission")
+
+	// Parse command line arguments.
+	opt, err := cmd.parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	cmd.Logger.Printf("moving shards off of node %d, this may take a while", opt.NodeID)
+	if err := cmd.Decommission(opt); err != nil {
+		return err
+	}
+
+	// Notify user of completion.
+	cmd.Logger.Printf("node %d removed", opt.NodeID)
+	return nil
+}
+
+// parseFlags parses and validates the command line arguments.
+func (cmd *Command) parseFlags(args []string) (*Options, error) {
+	var opt Options
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.StringVar(&opt.Host, "host", "localhost:8086", "")
+	fs.StringVar(&opt.Username, "username", "", "")
+	fs.StringVar(&opt.Password, "password", "", "")
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Ensure that only one node is specified.
+	if fs.NArg() == 0 {
+		return nil, errors.New("node id required")
+	} else if fs.NArg() != 1 {
+		return nil, errors.New("only one node id allowed")
+	}
+	id, err := strconv.ParseUint(fs.Arg(0), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node id: %s", fs.Arg(0))
+	}
+	opt.NodeID = id
+
+	return &opt, nil
+}
+
+// Decommission asks the server to move the node's shards and remove it.
+func (cmd *Command) Decommission(opt *Options) error {
+	v := url.Values{}
+	v.Set("node", strconv.FormatUint(opt.NodeID, 10))
+	if opt.Username != "" {
+		v.Set("u", opt.Username)
+		v.Set("p", opt.Password)
+	}
+	u := url.URL{Scheme: "http", Host: opt.Host, Path: "/decommission", RawQuery: v.Encode()}
+
+	resp, err := http.Post(u.String(), "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Errors are returned as a JSON response.
+	if resp.StatusCode != http.StatusNoContent {
+		var body struct {
+			Err string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Err == "" {
+			return fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+		return errors.New(body.Err)
+	}
+	return nil
+}
+
+// printUsage prints the usage message to STDERR.
+func (cmd *Command) printUsage() {
+	fmt.Fprintf(cmd.Stderr, `usage: influxd decommission [flags] NODE-ID
+
+decommission moves every shard owned by a data node to the other nodes in
+the cluster and then removes the node. No new shards are assigned to the
+node once it starts. If it fails it can be run again to resume.
+
+        -host <host:port>
+                          The HTTP API of any node in the cluster.
+                          Defaults to localhost:8086.
+
+        -username <name>
+        -password <password>
+                          Credentials of an admin user when authentication
+                          is enabled.
+`)
+}
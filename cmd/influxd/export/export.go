@@ -0,0 +1,225 @@
+package export
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Command represents the program execution for "influxd export".
+type Command struct {
+	// The logger used to report progress.
+	Logger *log.Logger
+
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+}
+
+// Options represents the command line arguments.
+type Options struct {
+	Host            string
+	Username        string
+	Password        string
+	Database        string
+	RetentionPolicy string
+	Measurement     string
+	Start           string
+	End             string
+	Interval        string
+	Partition       string
+	Path            string
+}
+
+// NewCommand returns a new instance of Command with default settings.
+func NewCommand() *Command {
+	return &Command{
+		Stderr: os.Stderr,
+	}
+}
+
+// Run excutes the program.
+func (cmd *Command) Run(args ...string) error {
+	// Set up logger.
+	cmd.Logger = log.New(cmd.Stderr, "", log.LstdFlags)
+	cmd.Logger.Printf("influxdb export")
+
+	// Parse command line arguments.
+	opt, err := cmd.parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Export(opt); err != nil {
+		return err
+	}
+
+	// Notify user of completion.
+	cmd.Logger.Printf("export complete: %s", opt.Path)
+	return nil
+}
+
+// parseFlags parses and validates the command line arguments.
+func (cmd *Command) parseFlags(args []string) (*Options, error) {
+	var opt Options
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.StringVar(&opt.Host, "host", "localhost:8086", "")
+	fs.StringVar(&opt.Username, "username", "", "")
+	fs.StringVar(&opt.Password, "password", "", "")
+	fs.StringVar(&opt.Database, "database", "", "")
+	fs.StringVar(&opt.RetentionPolicy, "retention", "", "")
+	fs.StringVar(&opt.Measurement, "measurement", "", "")
+	fs.StringVar(&opt.Start, "start", "", "")
+	fs.StringVar(&opt.End, "end", "", "")
+	fs.StringVar(&opt.Interval, "interval", "", "")
+	fs.StringVar(&opt.Partition, "partition", "", "")
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opt.Database == "" {
+		return nil, errors.New("database required")
+	} else if opt.Measurement == "" {
+		return nil, errors.New("measurement required")
+	}
+
+	// Ensure that only one arg is specified.
+	if fs.NArg() == 0 {
+		return nil, errors.New("export path required")
+	} else if fs.NArg() != 1 {
+		return nil, errors.New("only one export path allowed")
+	}
+	opt.Path = fs.Arg(0)
+
+	return &opt, nil
+}
+
+// Export downloads the Parquet files for the options and writes them under opt.Path.
+func (cmd *Command) Export(opt *Options) error {
+	v := url.Values{}
+	v.Set("db", opt.Database)
+	v.Set("measurement", opt.Measurement)
+	for k, s := range map[string]string{
+		"u":         opt.Username,
+		"p":         opt.Password,
+		"rp":        opt.RetentionPolicy,
+		"start":     opt.Start,
+		"end":       opt.End,
+		"interval":  opt.Interval,
+		"partition": opt.Partition,
+	} {
+		if s != "" {
+			v.Set(k, s)
+		}
+	}
+	u := url.URL{Scheme: "http", Host: opt.Host, Path: "/export", RawQuery: v.Encode()}
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Errors are returned as a JSON response.
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Err string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Err == "" {
+			return fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+		return errors.New(body.Err)
+	}
+
+	return cmd.unpack(resp.Body, opt.Path)
+}
+
+// unpack extracts the files in the tar archive r to path.
+func (cmd *Command) unpack(r io.Reader, path string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read archive: %s", err)
+		}
+
+		// Don't allow entries to be written outside of the export path.
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid path in archive: %s", hdr.Name)
+		}
+		name = filepath.Join(path, name)
+
+		if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+			return err
+		}
+		if err := cmd.unpackFile(tr, name); err != nil {
+			return fmt.Errorf("unpack %s: %s", hdr.Name, err)
+		}
+		cmd.Logger.Printf("wrote %s", name)
+	}
+}
+
+// unpackFile copies the current archive entry to path.
+func (cmd *Command) unpackFile(r io.Reader, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// printUsage prints the usage message to STDERR.
+func (cmd *Command) printUsage() {
+	fmt.Fprintf(cmd.Stderr, `usage: influxd export [flags] PATH
+
+export writes the points of a measurement to Parquet files under PATH,
+partitioned by time and tag.
+
+        -host <host:port>
+                          The HTTP API of the host to export from.
+                          Defaults to localhost:8086.
+
+        -username <name>
+        -password <password>
+                          Credentials used when authentication is enabled.
+
+        -database <name>
+                          The database to export. Required.
+
+        -retention <name>
+                          The retention policy to export.
+                          Defaults to the database's default policy.
+
+        -measurement <name>
+                          The measurement to export. Required.
+
+        -start <time>
+        -end <time>
+                          The RFC3339 time range to export.
+                          Defaults to everything before now.
+
+        -interval <duration>
+                          The width of each time partition. Defaults to 1d.
+
+        -partition <tag,...>
+                          Tag keys to partition files by.
+`)
+}
@@ -0,0 +1,286 @@
+package fsck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/snapshot"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Command represents the program execution for "influxd fsck".
+type Command struct {
+	// Standard input/output, overridden for testing.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Options represents the command line arguments.
+type Options struct {
+	Host     string
+	Username string
+	Password string
+	Repair   bool
+
+	// Path is the metadata backup checked when the cluster is offline.
+	// Out is where the repaired backup is written.
+	Path string
+	Out  string
+}
+
+// NewCommand returns a new instance of Command with default settings.
+func NewCommand() *Command {
+	return &Command{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Run excutes the program.
+func (cmd *Command) Run(args ...string) error {
+	// Parse command line arguments.
+	opt, err := cmd.parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Check a backup if one is given. Otherwise check the running cluster.
+	var problems, remaining []string
+	if opt.Path != "" {
+		problems, remaining, err = cmd.CheckFile(opt)
+	} else {
+		problems, remaining, err = cmd.CheckHost(opt)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, p := range problems {
+		fmt.Fprintln(cmd.Stdout, p)
+	}
+	if !opt.Repair || len(problems) == 0 {
+		if len(problems) > 0 {
+			return fmt.Errorf("%d inconsistencies found", len(problems))
+		}
+		fmt.Fprintln(cmd.Stdout, "no inconsistencies found")
+		return nil
+	}
+
+	// Report the inconsistencies that are left after repairing.
+	if len(remaining) > 0 {
+		fmt.Fprintln(cmd.Stdout, "could not repair:")
+		for _, p := range remaining {
+			fmt.Fprintln(cmd.Stdout, p)
+		}
+		return fmt.Errorf("%d of %d inconsistencies repaired", len(problems)-len(remaining), len(problems))
+	}
+	fmt.Fprintf(cmd.Stdout, "%d inconsistencies repaired\n", len(problems))
+	return nil
+}
+
+// parseFlags parses and validates the command line arguments.
+func (cmd *Command) parseFlags(args []string) (*Options, error) {
+	var opt Options
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.StringVar(&opt.Host, "host", "localhost:8086", "")
+	fs.StringVar(&opt.Username, "username", "", "")
+	fs.StringVar(&opt.Password, "password", "", "")
+	fs.BoolVar(&opt.Repair, "repair", false, "")
+	fs.StringVar(&opt.Out, "out", "", "")
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Only one backup can be checked at a time.
+	if fs.NArg() > 1 {
+		return nil, errors.New("only one backup path allowed")
+	}
+	opt.Path = fs.Arg(0)
+
+	// Backups are never repaired in place.
+	if opt.Repair && opt.Path != "" && opt.Out == "" {
+		return nil, errors.New("-out required to repair a backup")
+	} else if opt.Out != "" && opt.Path == "" {
+		return nil, errors.New("-out only allowed with a backup path")
+	}
+
+	return &opt, nil
+}
+
+// CheckFile checks the metadata in a backup made with "influxd backup -meta"
+// and, if repair is set, writes the repaired metadata to a new backup.
+// Returns the inconsistencies found and the ones that could not be repaired.
+func (cmd *Command) CheckFile(opt *Options) (problems, remaining []string, err error) {
+	data, err := readMeta(opt.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	problems = data.Check()
+	if !opt.Repair || len(problems) == 0 {
+		return problems, nil, nil
+	}
+
+	remaining = data.Repair()
+	if err := writeMeta(opt.Out, data); err != nil {
+		return nil, nil, err
+	}
+	return problems, remaining, nil
+}
+
+// CheckHost checks the metadata of a running cluster through the HTTP API of
+// one of its nodes and repairs it if repair is set. Returns the
+// inconsistencies found and the ones that could not be repaired.
+func (cmd *Command) CheckHost(opt *Options) (problems, remaining []string, err error) {
+	problems, err = cmd.request(opt, "GET", "/meta/check")
+	if err != nil {
+		return nil, nil, err
+	} else if !opt.Repair || len(problems) == 0 {
+		return problems, nil, nil
+	}
+
+	remaining, err = cmd.request(opt, "POST", "/meta/repair")
+	if err != nil {
+		return nil, nil, err
+	}
+	return problems, remaining, nil
+}
+
+// request calls a meta check endpoint and returns the problems it reports.
+func (cmd *Command) request(opt *Options, method, path string) ([]string, error) {
+	v := url.Values{}
+	if opt.Username != "" {
+		v.Set("u", opt.Username)
+		v.Set("p", opt.Password)
+	}
+	u := url.URL{Scheme: "http", Host: opt.Host, Path: path, RawQuery: v.Encode()}
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Errors are returned as a JSON response.
+	var body struct {
+		Problems []string `json:"problems"`
+		Err      string   `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	} else if resp.StatusCode != http.StatusOK {
+		if body.Err == "" {
+			return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+		return nil, errors.New(body.Err)
+	}
+	return body.Problems, nil
+}
+
+// readMeta reads the metadata from a snapshot and its incremental backups.
+func readMeta(path string) (*meta.Data, error) {
+	mr, files, err := snapshot.OpenFileMultiReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("open multireader: %s", err)
+	}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for {
+		sf, err := mr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("snapshot has no metadata: %s", path)
+		} else if err != nil {
+			return nil, fmt.Errorf("next: entry=%s, err=%s", sf.Name, err)
+		} else if sf.Name != "meta" {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, mr, sf.Size); err != nil {
+			return nil, fmt.Errorf("copy: %s", err)
+		}
+
+		data := &meta.Data{}
+		if err := data.UnmarshalBinary(buf.Bytes()); err != nil {
+			return nil, fmt.Errorf("unmarshal: %s", err)
+		}
+		return data, nil
+	}
+}
+
+// writeMeta writes a snapshot containing only the metadata to path.
+func writeMeta(path string, data *meta.Data) error {
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("marshal meta: %s", err)
+	}
+
+	sw := snapshot.NewWriter()
+	defer sw.Close()
+	sw.Manifest.Files = []snapshot.File{{Name: "meta", Size: int64(len(buf)), ModTime: time.Now()}}
+	sw.FileWriters["meta"] = tsdb.NopWriteToCloser(bytes.NewReader(buf))
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := sw.WriteTo(f); err != nil {
+		return fmt.Errorf("write to: %s", err)
+	}
+	return f.Close()
+}
+
+// printUsage prints the usage message to STDERR.
+func (cmd *Command) printUsage() {
+	fmt.Fprintf(cmd.Stderr, `usage: influxd fsck [flags] [PATH]
+
+fsck checks the metadata of a cluster for shard groups without shards,
+shards owned by nodes that no longer exist, default retention policies that
+don't exist and other inconsistencies.
+
+The metadata of the running cluster is checked unless PATH is given, in which
+case the metadata backup at PATH made by "influxd backup -meta" is checked.
+This is used when the cluster can't be started.
+
+        -host <host:port>
+                          The HTTP API of any node in the cluster.
+                          Defaults to localhost:8086.
+
+        -username <name>
+        -password <password>
+                          Credentials of an admin user when authentication
+                          is enabled.
+
+        -repair
+                          Repair the inconsistencies. Shard groups without
+                          shards are removed and shards are given to the
+                          nodes owning the fewest shards if their owners no
+                          longer exist.
+
+        -out <path>
+                          Where to write the repaired backup when PATH is
+                          given. The backup can be restored with
+                          "influxd restore -meta-only".
+`)
+}
@@ -36,6 +36,12 @@ The commands are:
 
     backup               downloads a snapshot of a data node and saves it to disk
     config               display the default configuration
+    decommission         moves a data node's shards to other nodes and removes it
+    export               writes a measurement to Parquet files
+    fsck                 checks the cluster metadata for inconsistencies and repairs them
+    inspect              exports, imports or verifies the points of a shard
+    migrate              converts a measurement's tags into fields or fields into tags
+    node                 joins data nodes to the cluster and removes them
     restore              uses a snapshot of a data node to rebuild a cluster
     run                  run node with existing configuration
     version              displays the InfluxDB version
@@ -0,0 +1,242 @@
+package inspect
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Command represents the program execution for "influxd inspect".
+type Command struct {
+	// The logger used to report progress.
+	Logger *log.Logger
+
+	// Standard input/output, overridden for testing.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Options represents the command line arguments.
+type Options struct {
+	Command string // export, import or verify
+	Path    string // path of the shard data file
+
+	// Export time range, inclusive.
+	Start int64
+	End   int64
+
+	// File the points are exported to or imported from. Defaults to
+	// STDOUT or STDIN.
+	File string
+}
+
+// NewCommand returns a new instance of Command with default settings.
+func NewCommand() *Command {
+	return &Command{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Run excutes the program.
+func (cmd *Command) Run(args ...string) error {
+	// Set up logger. Exported points can be written to STDOUT.
+	cmd.Logger = log.New(cmd.Stderr, "", log.LstdFlags)
+
+	// Parse command line arguments.
+	opt, err := cmd.parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	var n int
+	switch opt.Command {
+	case "export":
+		n, err = cmd.Export(opt)
+	case "import":
+		n, err = cmd.Import(opt)
+	case "verify":
+		n, err = cmd.Verify(opt)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Notify user of completion.
+	cmd.Logger.Printf("%s complete: %d points", opt.Command, n)
+	return nil
+}
+
+// parseFlags parses and validates the command line arguments.
+func (cmd *Command) parseFlags(args []string) (*Options, error) {
+	if len(args) == 0 {
+		cmd.printUsage()
+		return nil, errors.New("export, import or verify required")
+	}
+
+	opt := &Options{Command: args[0]}
+	var start, end string
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	switch opt.Command {
+	case "export":
+		fs.StringVar(&start, "start", "", "")
+		fs.StringVar(&end, "end", "", "")
+		fs.StringVar(&opt.File, "out", "", "")
+	case "import":
+		fs.StringVar(&opt.File, "in", "", "")
+	case "verify":
+	default:
+		cmd.printUsage()
+		return nil, fmt.Errorf("unknown inspect command: %s", opt.Command)
+	}
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	// Parse the time range. Both ends are optional.
+	opt.Start, opt.End = math.MinInt64, math.MaxInt64
+	if start != "" {
+		t, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start time: %s", start)
+		}
+		opt.Start = t.UnixNano()
+	}
+	if end != "" {
+		t, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end time: %s", end)
+		}
+		opt.End = t.UnixNano()
+	}
+	if opt.Start > opt.End {
+		return nil, errors.New("start time after end time")
+	}
+
+	// Ensure that only one shard is specified.
+	if fs.NArg() == 0 {
+		return nil, errors.New("shard path required")
+	} else if fs.NArg() != 1 {
+		return nil, errors.New("only one shard path allowed")
+	}
+	opt.Path = fs.Arg(0)
+
+	return opt, nil
+}
+
+// Export writes the points of the shard file in the time range as line protocol.
+// The server must not be running.
+func (cmd *Command) Export(opt *Options) (int, error) {
+	// Make sure the shard exists as opening it would create it.
+	if _, err := os.Stat(opt.Path); err != nil {
+		return 0, err
+	}
+
+	w := cmd.Stdout
+	if opt.File != "" {
+		f, err := os.OpenFile(opt.File, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	n, err := tsdb.ExportShardFile(opt.Path, w, opt.Start, opt.End)
+	if err != nil {
+		return n, fmt.Errorf("export shard %s: %s", opt.Path, err)
+	}
+	return n, nil
+}
+
+// Import writes line protocol points to the shard file, creating it if it
+// doesn't exist. The server must not be running.
+func (cmd *Command) Import(opt *Options) (int, error) {
+	r := cmd.Stdin
+	if opt.File != "" {
+		f, err := os.Open(opt.File)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	n, err := tsdb.ImportShardFile(opt.Path, r)
+	if err != nil {
+		return n, fmt.Errorf("import shard %s: %s", opt.Path, err)
+	}
+	return n, nil
+}
+
+// Verify checks the shard file's pages and that its index matches its data.
+// The inconsistencies found are written to STDOUT followed by the keys of the
+// corrupt series. The server must not be running.
+func (cmd *Command) Verify(opt *Options) (int, error) {
+	// Make sure the shard exists as opening it would create it.
+	if _, err := os.Stat(opt.Path); err != nil {
+		return 0, err
+	}
+
+	v, err := tsdb.VerifyShardFile(opt.Path)
+	if err != nil {
+		return 0, fmt.Errorf("verify shard %s: %s", opt.Path, err)
+	}
+	if v.OK() {
+		return v.Points, nil
+	}
+
+	for _, p := range v.Problems {
+		fmt.Fprintln(cmd.Stdout, p)
+	}
+	if len(v.CorruptSeries) > 0 {
+		fmt.Fprintln(cmd.Stdout, "corrupt series:")
+		for _, key := range v.CorruptSeries {
+			fmt.Fprintln(cmd.Stdout, key)
+		}
+	}
+	return v.Points, fmt.Errorf("verify shard %s: %d inconsistencies found", opt.Path, len(v.Problems))
+}
+
+// printUsage prints the usage message to STDERR.
+func (cmd *Command) printUsage() {
+	fmt.Fprintf(cmd.Stderr, `usage: influxd inspect export [flags] PATH
+       influxd inspect import [flags] PATH
+       influxd inspect verify PATH
+
+inspect reads and writes the shard data file at PATH directly. The server
+must be stopped.
+
+export writes the points of the shard as line protocol with nanosecond
+timestamps. import writes line protocol points to the shard, creating it if
+it doesn't exist. Exporting from one version and importing into another
+migrates the shard's data between versions.
+
+verify checks the structure of the shard's data file and that its series
+index matches its data, then lists the series that are corrupt. A corrupt
+shard can be replaced by copying the shard from another node that owns it.
+
+        -start <time>
+        -end <time>
+                          Only export the points in the time range, given
+                          in RFC3339 format. Both ends are inclusive and
+                          default to all points.
+
+        -out <path>
+                          Export to a new file instead of STDOUT.
+
+        -in <path>
+                          Import from a file instead of STDIN.
+`)
+}
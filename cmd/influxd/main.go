@@ -10,7 +10,13 @@ import (
 	"time"
 
 	"github.com/influxdb/influxdb/cmd/influxd/backup"
+	"github.com/influxdb/influxdb/cmd/influxd/decommission"
+	"github.com/influxdb/influxdb/cmd/influxd/export"
+	"github.com/influxdb/influxdb/cmd/influxd/fsck"
 	"github.com/influxdb/influxdb/cmd/influxd/help"
+	"github.com/influxdb/influxdb/cmd/influxd/inspect"
+	"github.com/influxdb/influxdb/cmd/influxd/migrate"
+	"github.com/influxdb/influxdb/cmd/influxd/node"
 	"github.com/influxdb/influxdb/cmd/influxd/restore"
 	"github.com/influxdb/influxdb/cmd/influxd/run"
 )
@@ -79,6 +85,36 @@ func (m *Main) Run(args ...string) error {
 		if err := name.Run(args...); err != nil {
 			return fmt.Errorf("backup: %s", err)
 		}
+	case "decommission":
+		name := decommission.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("decommission: %s", err)
+		}
+	case "export":
+		name := export.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("export: %s", err)
+		}
+	case "fsck":
+		name := fsck.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("fsck: %s", err)
+		}
+	case "inspect":
+		name := inspect.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("inspect: %s", err)
+		}
+	case "migrate":
+		name := migrate.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("migrate: %s", err)
+		}
+	case "node":
+		name := node.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("node: %s", err)
+		}
 	case "restore":
 		name := restore.NewCommand()
 		if err := name.Run(args...); err != nil {
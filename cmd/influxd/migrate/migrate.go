@@ -0,0 +1,322 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Command represents the program execution for "influxd migrate".
+type Command struct {
+	// The logger used to report progress.
+	Logger *log.Logger
+
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+}
+
+// Options represents the command line arguments.
+type Options struct {
+	// Online migrations.
+	Host     string
+	Username string
+	Password string
+
+	// Offline migrations.
+	DataDir         string
+	Database        string
+	RetentionPolicy string
+
+	Measurement string
+	TagToField  string
+	FieldToTag  string
+	ShardIDs    []uint64
+}
+
+// NewCommand returns a new instance of Command with default settings.
+func NewCommand() *Command {
+	return &Command{
+		Stderr: os.Stderr,
+	}
+}
+
+// Run excutes the program.
+func (cmd *Command) Run(args ...string) error {
+	// Set up logger.
+	cmd.Logger = log.New(cmd.Stderr, "", log.LstdFlags)
+	cmd.Logger.Printf("influxdb migrate")
+
+	// Parse command line arguments.
+	opt, err := cmd.parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opt.DataDir != "" {
+		err = cmd.MigrateOffline(opt)
+	} else {
+		err = cmd.MigrateOnline(opt)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Notify user of completion.
+	cmd.Logger.Printf("migration complete")
+	return nil
+}
+
+// parseFlags parses and validates the command line arguments.
+func (cmd *Command) parseFlags(args []string) (*Options, error) {
+	var opt Options
+	var shards string
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.StringVar(&opt.Host, "host", "localhost:8086", "")
+	fs.StringVar(&opt.Username, "username", "", "")
+	fs.StringVar(&opt.Password, "password", "", "")
+	fs.StringVar(&opt.DataDir, "datadir", "", "")
+	fs.StringVar(&opt.Database, "database", "", "")
+	fs.StringVar(&opt.RetentionPolicy, "retention", "", "")
+	fs.StringVar(&opt.Measurement, "measurement", "", "")
+	fs.StringVar(&opt.TagToField, "tag-to-field", "", "")
+	fs.StringVar(&opt.FieldToTag, "field-to-tag", "", "")
+	fs.StringVar(&shards, "shards", "", "")
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opt.Measurement == "" {
+		return nil, errors.New("measurement required")
+	} else if (opt.TagToField == "") == (opt.FieldToTag == "") {
+		return nil, errors.New("exactly one of -tag-to-field or -field-to-tag required")
+	}
+
+	if shards != "" {
+		for _, s := range strings.Split(shards, ",") {
+			id, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid shard id: %s", s)
+			}
+			opt.ShardIDs = append(opt.ShardIDs, id)
+		}
+	}
+
+	// Offline migrations find the shards in the data directory. The shards
+	// of online migrations have to be listed.
+	if opt.DataDir != "" && opt.Database == "" {
+		return nil, errors.New("database required")
+	} else if opt.DataDir == "" && len(opt.ShardIDs) == 0 {
+		return nil, errors.New("shards required")
+	}
+
+	return &opt, nil
+}
+
+// migration returns the migration described by the options.
+func (opt *Options) migration() *tsdb.Migration {
+	if opt.TagToField != "" {
+		return &tsdb.Migration{Type: tsdb.TagToField, Measurement: opt.Measurement, Key: opt.TagToField}
+	}
+	return &tsdb.Migration{Type: tsdb.FieldToTag, Measurement: opt.Measurement, Key: opt.FieldToTag}
+}
+
+// MigrateOffline migrates the shard files of a database in the data directory.
+// The server must not be running.
+func (cmd *Command) MigrateOffline(opt *Options) error {
+	paths, err := cmd.shardPaths(opt)
+	if err != nil {
+		return err
+	} else if len(paths) == 0 {
+		return errors.New("no shards found")
+	}
+
+	m := opt.migration()
+	for i, path := range paths {
+		// Report progress at most once a second per shard.
+		var last time.Time
+		m.Progress = func(done, total int) {
+			if now := time.Now(); done == total || now.Sub(last) >= time.Second {
+				cmd.Logger.Printf("shard %s: %d/%d series", path, done, total)
+				last = now
+			}
+		}
+
+		n, err := tsdb.MigrateShardFile(path, m)
+		if err != nil {
+			return fmt.Errorf("migrate shard %s: %s", path, err)
+		}
+		cmd.Logger.Printf("migrated shard %s (%d/%d): %d points rewritten", path, i+1, len(paths), n)
+	}
+	return nil
+}
+
+// shardPaths returns the paths of the shard files selected by the options.
+func (cmd *Command) shardPaths(opt *Options) ([]string, error) {
+	// Use every retention policy unless one was specified.
+	dir := filepath.Join(opt.DataDir, opt.Database)
+	policies := []string{opt.RetentionPolicy}
+	if opt.RetentionPolicy == "" {
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		policies = nil
+		for _, fi := range fis {
+			if fi.IsDir() {
+				policies = append(policies, fi.Name())
+			}
+		}
+	}
+
+	var paths []string
+	for _, rp := range policies {
+		fis, err := ioutil.ReadDir(filepath.Join(dir, rp))
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range fis {
+			// Shard files are named by their id.
+			id, err := strconv.ParseUint(fi.Name(), 10, 64)
+			if err != nil || fi.IsDir() || !opt.selected(id) {
+				continue
+			}
+			paths = append(paths, filepath.Join(dir, rp, fi.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// selected returns true if a shard was selected by the options.
+func (opt *Options) selected(id uint64) bool {
+	if len(opt.ShardIDs) == 0 {
+		return true
+	}
+	for _, shardID := range opt.ShardIDs {
+		if shardID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateOnline asks a running server to migrate the selected shards it
+// stores. Shards not stored on the server are reported and skipped.
+func (cmd *Command) MigrateOnline(opt *Options) error {
+	m := opt.migration()
+	shards := make([]string, len(opt.ShardIDs))
+	for i, id := range opt.ShardIDs {
+		shards[i] = strconv.FormatUint(id, 10)
+	}
+
+	v := url.Values{}
+	v.Set("measurement", m.Measurement)
+	v.Set(m.Type.String(), m.Key)
+	v.Set("shards", strings.Join(shards, ","))
+	if opt.Username != "" {
+		v.Set("u", opt.Username)
+		v.Set("p", opt.Password)
+	}
+	u := url.URL{Scheme: "http", Host: opt.Host, Path: "/migrate", RawQuery: v.Encode()}
+
+	resp, err := http.Post(u.String(), "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Errors are returned as a JSON response.
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Err string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Err == "" {
+			return fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+		return errors.New(body.Err)
+	}
+
+	// Report each shard's result as it is streamed back.
+	dec := json.NewDecoder(resp.Body)
+	for i := 1; ; i++ {
+		var result struct {
+			Shard  uint64 `json:"shard"`
+			Points int    `json:"points"`
+			Err    string `json:"error"`
+		}
+		if err := dec.Decode(&result); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("decode result: %s", err)
+		}
+
+		if result.Err != "" {
+			cmd.Logger.Printf("shard %d (%d/%d): %s", result.Shard, i, len(opt.ShardIDs), result.Err)
+			continue
+		}
+		cmd.Logger.Printf("migrated shard %d (%d/%d): %d points rewritten", result.Shard, i, len(opt.ShardIDs), result.Points)
+	}
+}
+
+// printUsage prints the usage message to STDERR.
+func (cmd *Command) printUsage() {
+	fmt.Fprintf(cmd.Stderr, `usage: influxd migrate [flags]
+
+migrate rewrites a measurement to convert a tag into a string field of the
+same name, or a field into a tag. Series that only differed by a converted
+tag are merged. Points that only had a converted field are dropped.
+
+A running server migrates the listed shards that it stores; run it against
+each node that owns one of the shards. With -datadir the shard files are
+migrated directly and the server must be stopped.
+
+Writers should be changed to the new schema before migrating. Points
+written with the old schema during or after a migration are not converted.
+
+        -measurement <name>
+                          The measurement to migrate.
+
+        -tag-to-field <tag>
+                          Convert a tag into a field.
+
+        -field-to-tag <field>
+                          Convert a field into a tag.
+
+        -shards <id,...>
+                          The shards to migrate. Required unless -datadir is
+                          set, in which case it defaults to every shard of
+                          the database.
+
+        -host <host:port>
+                          The HTTP API of the node storing the shards.
+                          Defaults to localhost:8086.
+
+        -username <name>
+        -password <password>
+                          Credentials of an admin user when authentication
+                          is enabled.
+
+        -datadir <path>
+                          Migrate the shard files in a data directory instead
+                          of using a running server.
+
+        -database <name>
+        -retention <name>
+                          The database, and optionally the retention policy,
+                          of the shards to migrate with -datadir.
+`)
+}
@@ -0,0 +1,204 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/influxdb/influxdb/meta"
+)
+
+// Command represents the program execution for "influxd node".
+type Command struct {
+	// The logger used to report progress.
+	Logger *log.Logger
+
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+}
+
+// Options represents the command line arguments.
+type Options struct {
+	Host     string
+	Username string
+	Password string
+	Force    bool
+}
+
+// NewCommand returns a new instance of Command with default settings.
+func NewCommand() *Command {
+	return &Command{
+		Stderr: os.Stderr,
+	}
+}
+
+// Run excutes the program.
+func (cmd *Command) Run(args ...string) error {
+	// Set up logger.
+	cmd.Logger = log.New(cmd.Stderr, "", log.LstdFlags)
+
+	// Extract the subcommand.
+	if len(args) == 0 {
+		cmd.printUsage()
+		return errors.New("subcommand required")
+	} else if args[0] == "-h" {
+		cmd.printUsage()
+		return flag.ErrHelp
+	}
+	name, args := args[0], args[1:]
+
+	switch name {
+	case "join":
+		opt, host, err := cmd.parseFlags(args)
+		if err != nil {
+			return err
+		}
+		ni, err := cmd.Join(opt, host)
+		if err != nil {
+			return err
+		}
+		cmd.Logger.Printf("node %d joined: host=%s", ni.ID, ni.Host)
+	case "remove":
+		opt, arg, err := cmd.parseFlags(args)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid node id: %s", arg)
+		}
+		if err := cmd.Remove(opt, id); err != nil {
+			return err
+		}
+		cmd.Logger.Printf("node %d removed", id)
+	default:
+		return fmt.Errorf("unknown subcommand: %s", name)
+	}
+	return nil
+}
+
+// parseFlags parses the command line arguments of a subcommand and returns
+// its single positional argument.
+func (cmd *Command) parseFlags(args []string) (*Options, string, error) {
+	var opt Options
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.StringVar(&opt.Host, "host", "localhost:8086", "")
+	fs.StringVar(&opt.Username, "username", "", "")
+	fs.StringVar(&opt.Password, "password", "", "")
+	fs.BoolVar(&opt.Force, "force", false, "")
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return nil, "", err
+	}
+
+	if fs.NArg() != 1 {
+		return nil, "", errors.New("exactly one argument required")
+	}
+	return &opt, fs.Arg(0), nil
+}
+
+// Join asks the server to add a data node with the given host to the cluster.
+func (cmd *Command) Join(opt *Options, host string) (*meta.NodeInfo, error) {
+	v := url.Values{}
+	v.Set("host", host)
+
+	resp, err := cmd.do(opt, "POST", v)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var ni meta.NodeInfo
+	if err := json.NewDecoder(resp.Body).Decode(&ni); err != nil {
+		return nil, fmt.Errorf("decode node: %s", err)
+	}
+	return &ni, nil
+}
+
+// Remove asks the server to remove a data node from the cluster.
+func (cmd *Command) Remove(opt *Options, id uint64) error {
+	v := url.Values{}
+	v.Set("node", strconv.FormatUint(id, 10))
+	if opt.Force {
+		v.Set("force", "true")
+	}
+
+	resp, err := cmd.do(opt, "DELETE", v)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// do sends a request to the server's node endpoint.
+func (cmd *Command) do(opt *Options, method string, v url.Values) (*http.Response, error) {
+	if opt.Username != "" {
+		v.Set("u", opt.Username)
+		v.Set("p", opt.Password)
+	}
+	u := url.URL{Scheme: "http", Host: opt.Host, Path: "/nodes", RawQuery: v.Encode()}
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// responseError returns the error from a JSON error response.
+func responseError(resp *http.Response) error {
+	var body struct {
+		Err string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Err == "" {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return errors.New(body.Err)
+}
+
+// printUsage prints the usage message to STDERR.
+func (cmd *Command) printUsage() {
+	fmt.Fprintf(cmd.Stderr, `usage: influxd node join [flags] HOST
+       influxd node remove [flags] NODE-ID
+
+join adds a data node to the cluster by its cluster address (host:port). The
+node is assigned shards in new shard groups right away and takes over the
+node entry when it starts with the same address.
+
+remove removes a data node from the cluster. A node that owns shards can only
+be removed with -force. Use decommission to move the shards of a live node
+first.
+
+        -host <host:port>
+                          The HTTP API of any node in the cluster.
+                          Defaults to localhost:8086.
+
+        -username <name>
+        -password <password>
+                          Credentials of an admin user when authentication
+                          is enabled.
+
+        -force
+                          Remove a dead node that still owns shards. The node
+                          is dropped from the owners of its shards and shards
+                          it was the only owner of are assigned to the least
+                          loaded node. Data on the removed node is lost.
+`)
+}
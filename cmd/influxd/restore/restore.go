@@ -32,11 +32,14 @@ func NewCommand() *Command {
 
 // Run excutes the program.
 func (cmd *Command) Run(args ...string) error {
-	config, path, err := cmd.parseFlags(args)
+	config, path, metaOnly, err := cmd.parseFlags(args)
 	if err != nil {
 		return err
 	}
 
+	if metaOnly {
+		return cmd.RestoreMeta(config, path)
+	}
 	return cmd.Restore(config, path)
 }
 
@@ -65,19 +68,81 @@ func (cmd *Command) Restore(config *Config, path string) error {
 	return nil
 }
 
+// RestoreMeta rebuilds the meta store from the metadata in a snapshot. The
+// data directory is left untouched. The metadata is read and a new meta
+// store is built next to the meta directory before the directory is
+// replaced, so a bad snapshot leaves the existing metadata in place.
+func (cmd *Command) RestoreMeta(config *Config, path string) error {
+	// Open snapshot file and all incremental backups.
+	mr, files, err := snapshot.OpenFileMultiReader(path)
+	if err != nil {
+		return fmt.Errorf("open multireader: %s", err)
+	}
+	defer closeAll(files)
+
+	// Find the metadata and skip every other file.
+	var data *meta.Data
+	for data == nil {
+		sf, err := mr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("snapshot has no metadata: %s", path)
+		} else if err != nil {
+			return fmt.Errorf("next: entry=%s, err=%s", sf.Name, err)
+		} else if sf.Name != "meta" {
+			continue
+		}
+
+		fmt.Fprintf(cmd.Stdout, "unpacking: %s (%d bytes)\n", sf.Name, sf.Size)
+		if data, err = readMeta(mr, sf); err != nil {
+			return fmt.Errorf("meta: %s", err)
+		}
+	}
+
+	// Build the new meta store in a temporary directory.
+	c := config.Meta
+	c.Dir = config.Meta.Dir + ".restore"
+	if err := os.RemoveAll(c.Dir); err != nil {
+		return fmt.Errorf("remove temporary meta dir: %s", err)
+	} else if err := writeMeta(data, c); err != nil {
+		os.RemoveAll(c.Dir)
+		return fmt.Errorf("meta: %s", err)
+	}
+
+	// Swap it with the meta directory. The old directory is moved back if
+	// the new one can't be moved into place.
+	old := config.Meta.Dir + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return fmt.Errorf("remove old meta dir: %s", err)
+	} else if err := os.Rename(config.Meta.Dir, old); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("move meta dir: %s", err)
+	}
+	if err := os.Rename(c.Dir, config.Meta.Dir); err != nil {
+		os.Rename(old, config.Meta.Dir)
+		return fmt.Errorf("move restored meta dir: %s", err)
+	}
+	if err := os.RemoveAll(old); err != nil {
+		return fmt.Errorf("remove old meta dir: %s", err)
+	}
+
+	// Notify user of completion.
+	fmt.Fprintf(cmd.Stdout, "metadata restore complete using %s\n", path)
+	return nil
+}
+
 // parseFlags parses and validates the command line arguments.
-func (cmd *Command) parseFlags(args []string) (*Config, string, error) {
+func (cmd *Command) parseFlags(args []string) (*Config, string, bool, error) {
 	fs := flag.NewFlagSet("", flag.ContinueOnError)
 	configPath := fs.String("config", "", "")
+	metaOnly := fs.Bool("meta-only", false, "")
 	fs.SetOutput(cmd.Stderr)
 	fs.Usage = cmd.printUsage
 	if err := fs.Parse(args); err != nil {
-		return nil, "", err
+		return nil, "", false, err
 	}
 
 	// Parse configuration file from disk.
 	if *configPath == "" {
-		return nil, "", fmt.Errorf("config required")
+		return nil, "", false, fmt.Errorf("config required")
 	}
 
 	// Parse config.
@@ -86,16 +151,16 @@ func (cmd *Command) parseFlags(args []string) (*Config, string, error) {
 		Data: tsdb.NewConfig(),
 	}
 	if _, err := toml.DecodeFile(*configPath, &config); err != nil {
-		return nil, "", err
+		return nil, "", false, err
 	}
 
 	// Require output path.
 	path := fs.Arg(0)
 	if path == "" {
-		return nil, "", fmt.Errorf("snapshot path required")
+		return nil, "", false, fmt.Errorf("snapshot path required")
 	}
 
-	return &config, path, nil
+	return &config, path, *metaOnly, nil
 }
 
 func closeAll(a []io.Closer) {
@@ -138,33 +203,46 @@ func (cmd *Command) unpack(mr *snapshot.MultiReader, config *Config) error {
 // unpackMeta reads the metadata from the snapshot and initializes a raft
 // cluster and replaces the root metadata.
 func (cmd *Command) unpackMeta(mr *snapshot.MultiReader, sf snapshot.File, config *Config) error {
+	data, err := readMeta(mr, sf)
+	if err != nil {
+		return err
+	}
+	return writeMeta(data, config.Meta)
+}
+
+// readMeta reads and decodes the metadata file of a snapshot.
+func readMeta(mr *snapshot.MultiReader, sf snapshot.File) (*meta.Data, error) {
 	// Read meta into buffer.
 	var buf bytes.Buffer
 	if _, err := io.CopyN(&buf, mr, sf.Size); err != nil {
-		return fmt.Errorf("copy: %s", err)
+		return nil, fmt.Errorf("copy: %s", err)
 	}
 
 	// Unpack into metadata.
 	var data meta.Data
 	if err := data.UnmarshalBinary(buf.Bytes()); err != nil {
-		return fmt.Errorf("unmarshal: %s", err)
+		return nil, fmt.Errorf("unmarshal: %s", err)
 	}
+	return &data, nil
+}
 
-	// Copy meta config and remove peers so it starts in single mode.
-	c := config.Meta
-	c.Peers = nil
+// writeMeta initializes a single node meta store in the directory of the
+// meta config and replaces its metadata with data.
+func writeMeta(data *meta.Data, config meta.Config) error {
+	// Remove peers so it starts in single mode.
+	config.Peers = nil
 
 	// Initialize meta store.
-	store := meta.NewStore(config.Meta)
+	store := meta.NewStore(config)
 	store.RaftListener = newNopListener()
 	store.ExecListener = newNopListener()
 
 	// Determine advertised address.
-	_, port, err := net.SplitHostPort(config.Meta.BindAddress)
+	_, port, err := net.SplitHostPort(config.BindAddress)
 	if err != nil {
 		return fmt.Errorf("split bind address: %s", err)
 	}
-	hostport := net.JoinHostPort(config.Meta.Hostname, port)
+	hostport := net.JoinHostPort(config.Hostname, port)
 
 	// Resolve address.
 	addr, err := net.ResolveTCPAddr("tcp", hostport)
@@ -187,7 +265,7 @@ func (cmd *Command) unpackMeta(mr *snapshot.MultiReader, sf snapshot.File, confi
 	}
 
 	// Force set the full metadata.
-	if err := store.SetData(&data); err != nil {
+	if err := store.SetData(data); err != nil {
 		return fmt.Errorf("set data: %s", err)
 	}
 
@@ -224,6 +302,11 @@ restore uses a snapshot of a data node to rebuild a cluster.
 
         -config <path>
                           Set the path to the configuration file.
+
+        -meta-only
+                          Only restore the metadata from the snapshot and
+                          leave the data directory untouched. Used with
+                          snapshots from "influxd backup -meta".
 `)
 }
 
@@ -1,5 +1,93 @@
 package restore_test
 
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdb/influxdb/cmd/influxd/restore"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/snapshot"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Ensure a metadata restore replaces the meta directory only once the
+// snapshot's metadata has been read.
+func TestCommand_RestoreMeta(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influxd-restore-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &restore.Config{Meta: meta.NewConfig(), Data: tsdb.NewConfig()}
+	config.Meta.Dir = filepath.Join(dir, "meta")
+	config.Meta.Hostname = "127.0.0.1"
+	if err := os.MkdirAll(config.Meta.Dir, 0777); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(filepath.Join(config.Meta.Dir, "existing"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := restore.NewCommand()
+	cmd.Stdout, cmd.Stderr = ioutil.Discard, ioutil.Discard
+
+	// A snapshot with invalid metadata leaves the meta directory alone.
+	path := MustWriteSnapshot(filepath.Join(dir, "bad"), []byte("invalid"))
+	if err := cmd.RestoreMeta(config, path); err == nil {
+		t.Fatal("expected error")
+	} else if _, err := os.Stat(filepath.Join(config.Meta.Dir, "existing")); err != nil {
+		t.Fatalf("meta dir changed: %s", err)
+	}
+
+	// A valid snapshot replaces it.
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = MustWriteSnapshot(filepath.Join(dir, "good"), buf)
+	if err := cmd.RestoreMeta(config, path); err != nil {
+		t.Fatal(err)
+	} else if _, err := os.Stat(filepath.Join(config.Meta.Dir, "existing")); !os.IsNotExist(err) {
+		t.Fatalf("meta dir not replaced: %v", err)
+	} else if _, err := os.Stat(filepath.Join(config.Meta.Dir, "raft.db")); err != nil {
+		t.Fatalf("meta store not restored: %s", err)
+	}
+	for _, suffix := range []string{".restore", ".old"} {
+		if _, err := os.Stat(config.Meta.Dir + suffix); !os.IsNotExist(err) {
+			t.Fatalf("%s dir left behind: %v", suffix, err)
+		}
+	}
+}
+
+// MustWriteSnapshot writes a snapshot holding a meta file to path. Panic on error.
+func MustWriteSnapshot(path string, meta []byte) string {
+	sw := snapshot.NewWriter()
+	sw.Manifest.Files = []snapshot.File{{Name: "meta", Size: int64(len(meta))}}
+	sw.FileWriters["meta"] = &bufCloser{bytes.NewBuffer(meta)}
+
+	f, err := os.Create(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	if _, err := sw.WriteTo(f); err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// bufCloser adds a no-op Close to a buffer.
+type bufCloser struct{ *bytes.Buffer }
+
+func (b *bufCloser) Close() error { return nil }
+
 /*
 import (
 	"bytes"
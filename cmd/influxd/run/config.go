@@ -9,15 +9,21 @@ import (
 	"github.com/influxdb/influxdb/cluster"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/services/admin"
+	"github.com/influxdb/influxdb/services/archive"
+	"github.com/influxdb/influxdb/services/autotune"
 	"github.com/influxdb/influxdb/services/collectd"
 	"github.com/influxdb/influxdb/services/continuous_querier"
 	"github.com/influxdb/influxdb/services/graphite"
+	"github.com/influxdb/influxdb/services/grpcd"
 	"github.com/influxdb/influxdb/services/hh"
 	"github.com/influxdb/influxdb/services/httpd"
+	"github.com/influxdb/influxdb/services/loadshed"
 	"github.com/influxdb/influxdb/services/monitor"
 	"github.com/influxdb/influxdb/services/opentsdb"
 	"github.com/influxdb/influxdb/services/precreator"
+	"github.com/influxdb/influxdb/services/replication"
 	"github.com/influxdb/influxdb/services/retention"
+	"github.com/influxdb/influxdb/services/tiering"
 	"github.com/influxdb/influxdb/services/udp"
 	"github.com/influxdb/influxdb/tsdb"
 )
@@ -29,9 +35,16 @@ type Config struct {
 	Cluster    cluster.Config    `toml:"cluster"`
 	Retention  retention.Config  `toml:"retention"`
 	Precreator precreator.Config `toml:"shard-precreation"`
+	Archive    archive.Config    `toml:"archive"`
+	Tiering    tiering.Config    `toml:"tiering"`
+	Autotune   autotune.Config   `toml:"autotune"`
+	LoadShed   loadshed.Config   `toml:"load-shedding"`
+
+	Replication replication.Config `toml:"replication"`
 
 	Admin     admin.Config      `toml:"admin"`
 	HTTPD     httpd.Config      `toml:"http"`
+	GRPC      grpcd.Config      `toml:"grpc"`
 	Graphites []graphite.Config `toml:"graphite"`
 	Collectd  collectd.Config   `toml:"collectd"`
 	OpenTSDB  opentsdb.Config   `toml:"opentsdb"`
@@ -57,12 +70,18 @@ func NewConfig() *Config {
 
 	c.Admin = admin.NewConfig()
 	c.HTTPD = httpd.NewConfig()
+	c.GRPC = grpcd.NewConfig()
 	c.Collectd = collectd.NewConfig()
 	c.OpenTSDB = opentsdb.NewConfig()
 
 	c.Monitoring = monitor.NewConfig()
 	c.ContinuousQuery = continuous_querier.NewConfig()
 	c.Retention = retention.NewConfig()
+	c.Archive = archive.NewConfig()
+	c.Tiering = tiering.NewConfig()
+	c.Autotune = autotune.NewConfig()
+	c.LoadShed = loadshed.NewConfig()
+	c.Replication = replication.NewConfig()
 	c.HintedHandoff = hh.NewConfig()
 
 	return c
@@ -97,5 +116,41 @@ func (c *Config) Validate() error {
 	} else if c.HintedHandoff.Dir == "" {
 		return errors.New("HintedHandoff.Dir must be specified")
 	}
+
+	if err := c.Meta.Validate(); err != nil {
+		return err
+	}
+	if err := c.Data.Validate(); err != nil {
+		return err
+	}
+	if err := c.Retention.Validate(); err != nil {
+		return err
+	}
+	if err := c.Archive.Validate(); err != nil {
+		return err
+	}
+	if err := c.Tiering.Validate(); err != nil {
+		return err
+	} else if c.Tiering.Enabled && c.Data.ColdDir == "" {
+		return errors.New("Data.ColdDir must be specified when tiering is enabled")
+	}
+	if err := c.Autotune.Validate(); err != nil {
+		return err
+	}
+	if err := c.LoadShed.Validate(); err != nil {
+		return err
+	}
+	if err := c.Replication.Validate(); err != nil {
+		return err
+	}
+	if err := c.Precreator.Validate(); err != nil {
+		return err
+	}
+	if err := c.HTTPD.Validate(); err != nil {
+		return err
+	}
+	if err := c.GRPC.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
@@ -38,9 +38,15 @@ bind-address = ":1000"
 [opentsdb]
 bind-address = ":2000"
 
+[grpc]
+bind-address = ":8092"
+
 [udp]
 bind-address = ":4444"
 
+[load-shedding]
+inputs = ["udp"]
+
 [monitoring]
 enabled = true
 
@@ -69,8 +75,12 @@ enabled = true
 		t.Fatalf("unexpected collectd bind address: %s", c.Collectd.BindAddress)
 	} else if c.OpenTSDB.BindAddress != ":2000" {
 		t.Fatalf("unexpected opentsdb bind address: %s", c.OpenTSDB.BindAddress)
+	} else if c.GRPC.BindAddress != ":8092" {
+		t.Fatalf("unexpected grpc bind address: %s", c.GRPC.BindAddress)
 	} else if c.UDP.BindAddress != ":4444" {
 		t.Fatalf("unexpected udp bind address: %s", c.UDP.BindAddress)
+	} else if len(c.LoadShed.Inputs) != 1 || c.LoadShed.Inputs[0] != "udp" {
+		t.Fatalf("unexpected load shedding inputs: %v", c.LoadShed.Inputs)
 	} else if c.Monitoring.Enabled != true {
 		t.Fatalf("unexpected monitoring enabled: %v", c.Monitoring.Enabled)
 	} else if c.ContinuousQuery.Enabled != true {
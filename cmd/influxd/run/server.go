@@ -13,17 +13,23 @@ import (
 	"time"
 
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/services/admin"
+	"github.com/influxdb/influxdb/services/archive"
+	"github.com/influxdb/influxdb/services/autotune"
 	"github.com/influxdb/influxdb/services/collectd"
 	"github.com/influxdb/influxdb/services/continuous_querier"
 	"github.com/influxdb/influxdb/services/graphite"
 	"github.com/influxdb/influxdb/services/hh"
 	"github.com/influxdb/influxdb/services/httpd"
+	"github.com/influxdb/influxdb/services/loadshed"
 	"github.com/influxdb/influxdb/services/opentsdb"
 	"github.com/influxdb/influxdb/services/precreator"
+	"github.com/influxdb/influxdb/services/replication"
 	"github.com/influxdb/influxdb/services/retention"
 	"github.com/influxdb/influxdb/services/snapshotter"
+	"github.com/influxdb/influxdb/services/tiering"
 	"github.com/influxdb/influxdb/services/udp"
 	"github.com/influxdb/influxdb/tcp"
 	"github.com/influxdb/influxdb/tsdb"
@@ -42,6 +48,10 @@ type Server struct {
 	BindAddress string
 	Listener    net.Listener
 
+	// Limits on the connections accepted on BindAddress. Zero means no limit.
+	MaxConnections    int
+	MaxConnectionRate int
+
 	MetaStore     *meta.Store
 	TSDBStore     *tsdb.Store
 	QueryExecutor *tsdb.QueryExecutor
@@ -49,6 +59,9 @@ type Server struct {
 	ShardWriter   *cluster.ShardWriter
 	HintedHandoff *hh.Service
 
+	// LoadShedder, if set, sheds points from low-priority inputs under pressure.
+	LoadShedder *loadshed.Service
+
 	Services []Service
 
 	// These references are required for the tcp muxer.
@@ -74,20 +87,67 @@ func NewServer(c *Config, version string) (*Server, error) {
 		Hostname:    c.Meta.Hostname,
 		BindAddress: c.Meta.BindAddress,
 
+		MaxConnections:    c.Cluster.MaxConnections,
+		MaxConnectionRate: c.Cluster.MaxConnectionRate,
+
 		MetaStore: meta.NewStore(c.Meta),
 		TSDBStore: tsdb.NewStore(c.Data.Dir),
 
 		reportingDisabled: c.ReportingDisabled,
 	}
+	s.TSDBStore.ColdPath = c.Data.ColdDir
+	s.TSDBStore.MeasurementHints = c.Data.MeasurementHints
+	s.TSDBStore.FieldConflictPolicies = c.Data.FieldConflictPolicies
+	s.TSDBStore.IndexSnapshotInterval = time.Duration(c.Data.IndexSnapshotInterval)
+	s.TSDBStore.GroupCommitWindow = time.Duration(c.Data.GroupCommitWindow)
+	s.TSDBStore.HotCacheSize = c.Data.HotCacheSize
+	s.TSDBStore.ShardMmapSize = c.Data.ShardMmapSize
+	s.TSDBStore.LazyShardOpen = c.Data.LazyShardOpen
+	if c.Data.ShardPinTimeout > 0 {
+		s.TSDBStore.ShardPinTimeout = time.Duration(c.Data.ShardPinTimeout)
+	}
+	s.TSDBStore.MaxSeriesPerDatabase = c.Data.MaxSeriesPerDatabase
+	s.TSDBStore.MaxValuesPerTag = c.Data.MaxValuesPerTag
+	s.TSDBStore.SeriesKeyLimits = tsdb.SeriesKeyLimits{
+		MaxKeyLength:      c.Data.MaxSeriesKeyLength,
+		MaxTags:           c.Data.MaxTagsPerSeries,
+		MaxTagKeyLength:   c.Data.MaxTagKeyLength,
+		MaxTagValueLength: c.Data.MaxTagValueLength,
+	}
+	s.TSDBStore.Precision = c.Data.Precision
+	s.TSDBStore.MaxPointsPerBatch = c.Data.MaxPointsPerBatch
+	s.TSDBStore.MaxWriteQueueBytesPerDatabase = c.Data.MaxWriteQueueBytesPerDatabase
+	s.TSDBStore.MetaLimits = s.MetaStore
+
+	tlsConfig, err := c.Meta.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	s.MetaStore.TLSConfig = tlsConfig
 
 	// Initialize query executor.
 	s.QueryExecutor = tsdb.NewQueryExecutor(s.TSDBStore)
 	s.QueryExecutor.MetaStore = s.MetaStore
-	s.QueryExecutor.MetaStatementExecutor = &meta.StatementExecutor{Store: s.MetaStore}
+	s.QueryExecutor.MetaStatementExecutor = &meta.StatementExecutor{Store: s.MetaStore, ShardSizer: s.TSDBStore}
+	collation, err := influxql.ParseCollation(c.Data.SeriesCollation)
+	if err != nil {
+		return nil, err
+	}
+	s.QueryExecutor.Collation = collation
+	if c.Data.MaxConcurrentQueries > 0 || c.Autotune.Enabled {
+		s.QueryExecutor.QueryLimiter = tsdb.NewQueryLimiter(c.Data.MaxConcurrentQueries)
+	}
+	s.QueryExecutor.MaxQueryBytes = c.Data.MaxQueryBytes
 
 	// Set the shard writer
 	s.ShardWriter = cluster.NewShardWriter(time.Duration(c.Cluster.ShardWriterTimeout))
 	s.ShardWriter.MetaStore = s.MetaStore
+	s.ShardWriter.NodeCacheTTL = time.Duration(c.Cluster.NodeCacheTTL)
+	s.ShardWriter.PoolMaxIdleTime = time.Duration(c.Cluster.PoolMaxIdleTime)
+	s.ShardWriter.PoolMaxLifetime = time.Duration(c.Cluster.PoolMaxLifetime)
+	s.ShardWriter.MaxPointsPerBatch = c.Data.MaxPointsPerBatch
+	s.QueryExecutor.RemoteDeleter = s.ShardWriter
+	s.QueryExecutor.StatisticsSources = append(s.QueryExecutor.StatisticsSources, s.ShardWriter)
 
 	// Create the hinted handoff service
 	s.HintedHandoff = hh.NewService(c.HintedHandoff, s.ShardWriter)
@@ -98,20 +158,36 @@ func NewServer(c *Config, version string) (*Server, error) {
 	s.PointsWriter.TSDBStore = s.TSDBStore
 	s.PointsWriter.ShardWriter = s.ShardWriter
 	s.PointsWriter.HintedHandoff = s.HintedHandoff
+	s.PointsWriter.MaxPointsPerBatch = c.Data.MaxPointsPerBatch
+	s.PointsWriter.Precision = c.Data.Precision
+	if c.Cluster.ShardWriterFailureThreshold > 0 {
+		s.PointsWriter.CircuitBreaker = cluster.NewCircuitBreaker(c.Cluster.ShardWriterFailureThreshold, time.Duration(c.Cluster.ShardWriterCooldown))
+	}
+	if c.Cluster.SchemaRegistryEnabled {
+		s.PointsWriter.SchemaRegistry = s.MetaStore
+	}
 
 	// Append services.
+	s.appendLoadShedService(c.LoadShed)
 	s.appendClusterService(c.Cluster)
 	s.appendPrecreatorService(c.Precreator)
 	s.appendSnapshotterService()
 	s.appendAdminService(c.Admin)
 	s.appendContinuousQueryService(c.ContinuousQuery)
 	s.appendHTTPDService(c.HTTPD)
+	if err := s.appendGRPCService(c.GRPC); err != nil {
+		return nil, err
+	}
 	s.appendCollectdService(c.Collectd)
 	if err := s.appendOpenTSDBService(c.OpenTSDB); err != nil {
 		return nil, err
 	}
 	s.appendUDPService(c.UDP)
 	s.appendRetentionPolicyService(c.Retention)
+	s.appendArchiveService(c.Archive)
+	s.appendTieringService(c.Tiering)
+	s.appendAutotuneService(c.Autotune)
+	s.appendReplicationService(c.Replication)
 	for _, g := range c.Graphites {
 		if err := s.appendGraphiteService(g); err != nil {
 			return nil, err
@@ -121,12 +197,30 @@ func NewServer(c *Config, version string) (*Server, error) {
 	return s, nil
 }
 
+func (s *Server) appendLoadShedService(c loadshed.Config) {
+	if !c.Enabled {
+		return
+	}
+	s.LoadShedder = loadshed.NewService(c)
+	s.Services = append(s.Services, s.LoadShedder)
+	s.QueryExecutor.StatisticsSources = append(s.QueryExecutor.StatisticsSources, s.LoadShedder)
+}
+
+// inputPointsWriter returns the points writer for the named input service.
+func (s *Server) inputPointsWriter(name string) loadshed.PointsWriter {
+	if s.LoadShedder == nil {
+		return s.PointsWriter
+	}
+	return s.LoadShedder.Writer(name, s.PointsWriter)
+}
+
 func (s *Server) appendClusterService(c cluster.Config) {
 	srv := cluster.NewService(c)
 	srv.TSDBStore = s.TSDBStore
 	srv.MetaStore = s.MetaStore
 	s.Services = append(s.Services, srv)
 	s.ClusterService = srv
+	s.QueryExecutor.StatisticsSources = append(s.QueryExecutor.StatisticsSources, srv)
 }
 
 func (s *Server) appendSnapshotterService() {
@@ -144,6 +238,47 @@ func (s *Server) appendRetentionPolicyService(c retention.Config) {
 	srv := retention.NewService(c)
 	srv.MetaStore = s.MetaStore
 	srv.TSDBStore = s.TSDBStore
+	srv.ShardDeleter = s.ShardWriter
+	s.Services = append(s.Services, srv)
+}
+
+func (s *Server) appendArchiveService(c archive.Config) {
+	if !c.Enabled {
+		return
+	}
+	s.TSDBStore.Archive = archive.NewS3Archive(c)
+
+	srv := archive.NewService(c)
+	srv.MetaStore = s.MetaStore
+	srv.TSDBStore = s.TSDBStore
+	s.Services = append(s.Services, srv)
+}
+
+func (s *Server) appendTieringService(c tiering.Config) {
+	if !c.Enabled {
+		return
+	}
+	srv := tiering.NewService(c)
+	srv.MetaStore = s.MetaStore
+	srv.TSDBStore = s.TSDBStore
+	s.Services = append(s.Services, srv)
+}
+
+func (s *Server) appendReplicationService(c replication.Config) {
+	if !c.Enabled {
+		return
+	}
+	srv := replication.NewService(c)
+	srv.MetaStore = s.MetaStore
+	s.Services = append(s.Services, srv)
+}
+
+func (s *Server) appendAutotuneService(c autotune.Config) {
+	if !c.Enabled {
+		return
+	}
+	srv := autotune.NewService(c)
+	srv.QueryLimiter = s.QueryExecutor.QueryLimiter
 	s.Services = append(s.Services, srv)
 }
 
@@ -163,8 +298,26 @@ func (s *Server) appendHTTPDService(c httpd.Config) {
 	srv.Handler.MetaStore = s.MetaStore
 	srv.Handler.QueryExecutor = s.QueryExecutor
 	srv.Handler.PointsWriter = s.PointsWriter
+	srv.Handler.MaxPointsPerBatch = s.PointsWriter.MaxPointsPerBatch
+	srv.Handler.TSDBStore = s.TSDBStore
 	srv.Handler.Version = s.version
 
+	d := cluster.NewDecommissioner()
+	d.MetaStore = s.MetaStore
+	d.ShardCopier = s.ShardWriter
+	srv.Handler.Decommissioner = d
+	srv.Handler.NodeManager = s.MetaStore
+	srv.Handler.MetaChecker = s.MetaStore
+	srv.Handler.MetaReplicator = s.MetaStore
+	srv.Handler.SchemaMigrator = s.TSDBStore
+	srv.Handler.ShardCompactor = s.TSDBStore
+
+	cs := cluster.NewSnapshotter()
+	cs.MetaStore = s.MetaStore
+	cs.TSDBStore = s.TSDBStore
+	cs.ShardReader = s.ShardWriter
+	srv.Handler.ClusterSnapshotter = cs
+
 	// If a ContinuousQuerier service has been started, attach it.
 	for _, srvc := range s.Services {
 		if cqsrvc, ok := srvc.(continuous_querier.ContinuousQuerier); ok {
@@ -181,7 +334,7 @@ func (s *Server) appendCollectdService(c collectd.Config) {
 	}
 	srv := collectd.NewService(c)
 	srv.MetaStore = s.MetaStore
-	srv.PointsWriter = s.PointsWriter
+	srv.PointsWriter = s.inputPointsWriter("collectd")
 	s.Services = append(s.Services, srv)
 }
 
@@ -193,7 +346,7 @@ func (s *Server) appendOpenTSDBService(c opentsdb.Config) error {
 	if err != nil {
 		return err
 	}
-	srv.PointsWriter = s.PointsWriter
+	srv.PointsWriter = s.inputPointsWriter("opentsdb")
 	srv.MetaStore = s.MetaStore
 	s.Services = append(s.Services, srv)
 	return nil
@@ -208,7 +361,7 @@ func (s *Server) appendGraphiteService(c graphite.Config) error {
 		return err
 	}
 
-	srv.PointsWriter = s.PointsWriter
+	srv.PointsWriter = s.inputPointsWriter("graphite")
 	srv.MetaStore = s.MetaStore
 	s.Services = append(s.Services, srv)
 	return nil
@@ -233,7 +386,7 @@ func (s *Server) appendUDPService(c udp.Config) {
 		return
 	}
 	srv := udp.NewService(c)
-	srv.PointsWriter = s.PointsWriter
+	srv.PointsWriter = s.inputPointsWriter("udp")
 	s.Services = append(s.Services, srv)
 }
 
@@ -278,6 +431,8 @@ func (s *Server) Open() error {
 
 		// Multiplex listener.
 		mux := tcp.NewMux()
+		mux.MaxConnections = s.MaxConnections
+		mux.MaxConnectionRate = s.MaxConnectionRate
 		s.MetaStore.RaftListener = mux.Listen(meta.MuxRaftHeader)
 		s.MetaStore.ExecListener = mux.Listen(meta.MuxExecHeader)
 		s.ClusterService.Listener = mux.Listen(cluster.MuxHeader)
@@ -298,6 +453,9 @@ func (s *Server) Open() error {
 			return fmt.Errorf("open tsdb store: %s", err)
 		}
 
+		// Follow database renames made on other nodes.
+		go s.syncDatabaseNames()
+
 		// Open the hinted handoff service
 		if err := s.HintedHandoff.Open(); err != nil {
 			return fmt.Errorf("open hinted handoff: %s", err)
@@ -343,6 +501,9 @@ func (s *Server) Close() error {
 	for _, service := range s.Services {
 		service.Close()
 	}
+	if s.ShardWriter != nil {
+		s.ShardWriter.Close()
+	}
 	close(s.closing)
 	return nil
 }
@@ -401,6 +562,29 @@ func (s *Server) reportServer() {
 	go client.Post("http://m.influxdb.com:8086/db/reporting/series?u=reporter&p=influxdb", "application/json", data)
 }
 
+// syncDatabaseNames renames the local data of databases whenever they are
+// renamed in the metadata.
+func (s *Server) syncDatabaseNames() {
+	owner := func(shardID uint64) string {
+		database, _, _ := s.MetaStore.ShardOwner(shardID)
+		return database
+	}
+
+	for {
+		// Watch before syncing so no rename is missed.
+		changed := s.MetaStore.Watch()
+		if err := s.TSDBStore.SyncDatabaseNames(owner); err != nil {
+			log.Printf("failed to rename local databases: %s", err)
+		}
+
+		select {
+		case <-changed:
+		case <-s.closing:
+			return
+		}
+	}
+}
+
 // monitorErrorChan reads an error channel and resends it through the server.
 func (s *Server) monitorErrorChan(ch <-chan error) {
 	for {
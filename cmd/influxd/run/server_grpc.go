@@ -0,0 +1,21 @@
+//go:build grpc
+// +build grpc
+
+package run
+
+import "github.com/influxdb/influxdb/services/grpcd"
+
+func (s *Server) appendGRPCService(c grpcd.Config) error {
+	if !c.Enabled {
+		return nil
+	}
+	srv, err := grpcd.NewService(c)
+	if err != nil {
+		return err
+	}
+	srv.MetaStore = s.MetaStore
+	srv.QueryExecutor = s.QueryExecutor
+	srv.PointsWriter = s.PointsWriter
+	s.Services = append(s.Services, srv)
+	return nil
+}
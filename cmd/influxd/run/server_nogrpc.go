@@ -0,0 +1,19 @@
+//go:build !grpc
+// +build !grpc
+
+package run
+
+import (
+	"errors"
+
+	"github.com/influxdb/influxdb/services/grpcd"
+)
+
+// appendGRPCService returns an error if the gRPC service is enabled as it
+// requires building influxd with the grpc tag.
+func (s *Server) appendGRPCService(c grpcd.Config) error {
+	if !c.Enabled {
+		return nil
+	}
+	return errors.New("the gRPC service requires influxd to be built with -tags grpc")
+}
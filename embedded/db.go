@@ -0,0 +1,330 @@
+// Package embedded runs the storage engine inside another Go program. It has
+// no meta store, cluster or HTTP API: a DB keeps its shards and catalog in a
+// single directory and is only accessible from the process that opened it.
+package embedded
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// ErrDatabaseNotFound is returned when writing to a database that doesn't exist.
+var ErrDatabaseNotFound = errors.New("database not found")
+
+// ChunkSize is the maximum number of values in each row returned by a query.
+// Larger results are split across multiple rows.
+const ChunkSize = 10000
+
+// DB represents a time series database embedded in the calling program.
+type DB struct {
+	mu   sync.RWMutex
+	path string
+	data *meta.Data // databases, retention policies and shard groups
+
+	store    *tsdb.Store
+	executor *tsdb.QueryExecutor
+}
+
+// Open opens the database at path, creating it if it doesn't exist.
+func Open(path string) (*DB, error) {
+	db := &DB{path: path, data: &meta.Data{}}
+
+	if err := os.MkdirAll(path, 0777); err != nil {
+		return nil, err
+	}
+
+	// Load the catalog. Shards are always owned by a single local node.
+	if buf, err := ioutil.ReadFile(db.catalogPath()); os.IsNotExist(err) {
+		if err := db.data.CreateNode("localhost"); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if err := db.data.UnmarshalBinary(buf); err != nil {
+		return nil, fmt.Errorf("read catalog: %s", err)
+	}
+
+	db.store = tsdb.NewStore(filepath.Join(path, "data"))
+	db.store.Logger = log.New(ioutil.Discard, "", 0)
+	if err := db.store.Open(); err != nil {
+		return nil, err
+	}
+
+	db.executor = tsdb.NewQueryExecutor(db.store)
+	db.executor.MetaStore = (*metaStore)(db)
+	db.executor.MetaStatementExecutor = (*statementExecutor)(db)
+	db.executor.Logger = log.New(ioutil.Discard, "", 0)
+
+	return db, nil
+}
+
+// Close closes the database.
+func (db *DB) Close() error {
+	return db.store.Close()
+}
+
+// Path returns the path the database was opened with.
+func (db *DB) Path() string { return db.path }
+
+// catalogPath returns the path of the file that stores the catalog.
+func (db *DB) catalogPath() string { return filepath.Join(db.path, "catalog") }
+
+// saveCatalog writes the catalog to disk. The lock must be held.
+func (db *DB) saveCatalog() error {
+	buf, err := db.data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file so a partial catalog is never read.
+	tmppath := db.catalogPath() + ".tmp"
+	if err := ioutil.WriteFile(tmppath, buf, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmppath, db.catalogPath())
+}
+
+// CreateDatabaseIfNotExists creates a database with a default retention
+// policy that keeps data forever.
+func (db *DB) CreateDatabaseIfNotExists(name string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.data.Database(name) != nil {
+		return nil
+	}
+	return db.createDatabase(name)
+}
+
+// createDatabase creates a database and its default retention policy.
+// The lock must be held.
+func (db *DB) createDatabase(name string) error {
+	other := db.data.Clone()
+	if err := other.CreateDatabase(name); err != nil {
+		return err
+	}
+	rpi := meta.NewRetentionPolicyInfo(meta.AutoCreateRetentionPolicyName)
+	rpi.Duration = 0
+	if err := other.CreateRetentionPolicy(name, rpi); err != nil {
+		return err
+	} else if err := other.SetDefaultRetentionPolicy(name, rpi.Name); err != nil {
+		return err
+	}
+
+	db.data = other
+	return db.saveCatalog()
+}
+
+// WritePoints writes points to the default retention policy of a database.
+func (db *DB) WritePoints(database string, points []tsdb.Point) error {
+	// Group the points by the shard they belong to.
+	m := make(map[uint64][]tsdb.Point)
+	var policy string
+	for _, p := range points {
+		rp, shardID, err := db.shardFor(database, p.Time())
+		if err != nil {
+			return err
+		}
+		policy = rp
+		m[shardID] = append(m[shardID], p)
+	}
+
+	for shardID, a := range m {
+		if err := db.store.WriteToShard(shardID, a); err == tsdb.ErrShardNotFound {
+			// Create the shard on its first write.
+			if err := db.store.CreateShard(database, policy, shardID); err != nil {
+				return err
+			} else if err := db.store.WriteToShard(shardID, a); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shardFor returns the default retention policy of a database and the shard
+// a point at timestamp belongs to. Creates the shard group if necessary.
+func (db *DB) shardFor(database string, timestamp time.Time) (string, uint64, error) {
+	// Use the existing shard group if there is one.
+	db.mu.RLock()
+	rp, sgi, err := db.shardGroup(database, timestamp)
+	db.mu.RUnlock()
+	if err != nil {
+		return "", 0, err
+	} else if sgi != nil {
+		return rp, sgi.Shards[0].ID, nil
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// Check again in case the group was created while the lock was released.
+	if rp, sgi, err = db.shardGroup(database, timestamp); err != nil {
+		return "", 0, err
+	} else if sgi != nil {
+		return rp, sgi.Shards[0].ID, nil
+	}
+
+	other := db.data.Clone()
+	if err := other.CreateShardGroup(database, rp, timestamp); err != nil {
+		return "", 0, err
+	}
+	db.data = other
+	if err := db.saveCatalog(); err != nil {
+		return "", 0, err
+	}
+
+	_, sgi, err = db.shardGroup(database, timestamp)
+	if err != nil {
+		return "", 0, err
+	}
+	return rp, sgi.Shards[0].ID, nil
+}
+
+// shardGroup returns the default retention policy of a database and its shard
+// group containing timestamp, if any. The lock must be held.
+func (db *DB) shardGroup(database string, timestamp time.Time) (string, *meta.ShardGroupInfo, error) {
+	dbi := db.data.Database(database)
+	if dbi == nil {
+		return "", nil, ErrDatabaseNotFound
+	}
+	sgi, err := db.data.ShardGroupByTimestamp(database, dbi.DefaultRetentionPolicy, timestamp)
+	return dbi.DefaultRetentionPolicy, sgi, err
+}
+
+// Query executes an InfluxQL query against a database and returns an
+// iterator over the rows of its results. The iterator must be closed.
+func (db *DB) Query(database, command string) (*Iterator, error) {
+	q, err := influxql.ParseQuery(command)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := db.executor.ExecuteQuery(q, database, ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator{results: results}, nil
+}
+
+// Iterator iterates over the rows returned by a query.
+type Iterator struct {
+	results <-chan *influxql.Result
+	rows    []*influxql.Row
+	err     error
+	closed  bool
+}
+
+// Next returns the next row. Returns nil when there are no more rows or the
+// query failed, in which case Err returns the error.
+func (itr *Iterator) Next() *influxql.Row {
+	for len(itr.rows) == 0 {
+		if itr.closed {
+			return nil
+		}
+
+		res, ok := <-itr.results
+		if !ok {
+			itr.closed = true
+			return nil
+		} else if res.Err != nil {
+			itr.err = res.Err
+			itr.Close()
+			return nil
+		}
+		itr.rows = res.Series
+	}
+
+	row := itr.rows[0]
+	itr.rows = itr.rows[1:]
+	return row
+}
+
+// Err returns the error that stopped the iterator, if any.
+func (itr *Iterator) Err() error { return itr.err }
+
+// Close stops the iterator. The remaining results are discarded.
+func (itr *Iterator) Close() error {
+	if itr.closed {
+		return nil
+	}
+	itr.closed, itr.rows = true, nil
+	go func(results <-chan *influxql.Result) {
+		for range results {
+		}
+	}(itr.results)
+	return nil
+}
+
+// metaStore implements the meta store used by the query executor over the
+// database's catalog.
+type metaStore DB
+
+func (s *metaStore) Database(name string) (*meta.DatabaseInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.Database(name), nil
+}
+
+func (s *metaStore) Databases() ([]meta.DatabaseInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.Databases, nil
+}
+
+func (s *metaStore) RetentionPolicy(database, name string) (*meta.RetentionPolicyInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.RetentionPolicy(database, name)
+}
+
+// An embedded database has no users.
+func (s *metaStore) User(name string) (*meta.UserInfo, error) { return nil, nil }
+func (s *metaStore) AdminUserExists() (bool, error)           { return false, nil }
+func (s *metaStore) UserCount() (int, error)                  { return 0, nil }
+func (s *metaStore) ReadOnly() (bool, error)                  { return false, nil }
+func (s *metaStore) NodeID() uint64                           { return 0 }
+func (s *metaStore) Authenticate(username, password string) (*meta.UserInfo, error) {
+	return nil, meta.ErrUserNotFound
+}
+
+// statementExecutor executes the meta statements supported by an embedded database.
+type statementExecutor DB
+
+func (e *statementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.Result {
+	db := (*DB)(e)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	switch stmt := stmt.(type) {
+	case *influxql.CreateDatabaseStatement:
+		return &influxql.Result{Err: db.createDatabase(stmt.Name)}
+	case *influxql.DropDatabaseStatement:
+		other := db.data.Clone()
+		if err := other.DropDatabase(stmt.Name); err != nil {
+			return &influxql.Result{Err: err}
+		}
+		db.data = other
+		return &influxql.Result{Err: db.saveCatalog()}
+	case *influxql.ShowDatabasesStatement:
+		row := &influxql.Row{Name: "databases", Columns: []string{"name"}}
+		for _, dbi := range db.data.Databases {
+			row.Values = append(row.Values, []interface{}{dbi.Name})
+		}
+		return &influxql.Result{Series: []*influxql.Row{row}}
+	default:
+		return &influxql.Result{Err: fmt.Errorf("%s is not supported by embedded databases", stmt.String())}
+	}
+}
@@ -0,0 +1,111 @@
+package embedded_test
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/influxdb/influxdb/embedded"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Ensure points can be written, queried and read back after reopening.
+func TestDB_WritePoints(t *testing.T) {
+	path, _ := ioutil.TempDir("", "embedded-")
+	defer os.RemoveAll(path)
+
+	db := MustOpen(path)
+	if err := db.WritePoints("db0", []tsdb.Point{
+		tsdb.NewPoint("cpu", tsdb.Tags{"host": "a"}, tsdb.Fields{"value": 1.0}, time.Unix(0, 0)),
+	}); err != embedded.ErrDatabaseNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.CreateDatabaseIfNotExists("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := db.WritePoints("db0", []tsdb.Point{
+		tsdb.NewPoint("cpu", tsdb.Tags{"host": "a"}, tsdb.Fields{"value": 1.0}, time.Unix(1, 0)),
+		tsdb.NewPoint("cpu", tsdb.Tags{"host": "b"}, tsdb.Fields{"value": 2.0}, time.Unix(10, 0)),
+		tsdb.NewPoint("cpu", tsdb.Tags{"host": "a"}, tsdb.Fields{"value": 3.0}, time.Unix(30*24*60*60, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []*influxql.Row{
+		{
+			Name:    "cpu",
+			Tags:    map[string]string{},
+			Columns: []string{"time", "value"},
+			Values: [][]interface{}{
+				{time.Unix(1, 0).UTC(), 1.0},
+				{time.Unix(10, 0).UTC(), 2.0},
+				{time.Unix(30*24*60*60, 0).UTC(), 3.0},
+			},
+		},
+	}
+	if rows := MustQuery(t, db, `SELECT value FROM cpu`); !reflect.DeepEqual(rows, exp) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(rows))
+	}
+
+	// Reopen the database and ensure the data is still there.
+	db.Close()
+	db = MustOpen(path)
+	defer db.Close()
+	if rows := MustQuery(t, db, `SELECT value FROM cpu`); !reflect.DeepEqual(rows, exp) {
+		t.Fatalf("unexpected rows after reopen: %#v", rows)
+	}
+}
+
+// Ensure meta statements that are supported can be executed by a query.
+func TestDB_Query_Meta(t *testing.T) {
+	path, _ := ioutil.TempDir("", "embedded-")
+	defer os.RemoveAll(path)
+	db := MustOpen(path)
+	defer db.Close()
+
+	if rows := MustQuery(t, db, `CREATE DATABASE db0; SHOW DATABASES`); !reflect.DeepEqual(rows, []*influxql.Row{
+		{Name: "databases", Columns: []string{"name"}, Values: [][]interface{}{{"db0"}}},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(rows))
+	}
+
+	itr, err := db.Query("", `SHOW USERS`)
+	if err != nil {
+		t.Fatal(err)
+	} else if row := itr.Next(); row != nil {
+		t.Fatalf("unexpected row: %#v", row)
+	} else if itr.Err() == nil || itr.Err().Error() != `SHOW USERS is not supported by embedded databases` {
+		t.Fatalf("unexpected error: %v", itr.Err())
+	}
+}
+
+// MustOpen opens a database at path. Panic on error.
+func MustOpen(path string) *embedded.DB {
+	db, err := embedded.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// MustQuery executes a query against db0 and returns all rows.
+func MustQuery(t *testing.T, db *embedded.DB, command string) []*influxql.Row {
+	itr, err := db.Query("db0", command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+
+	var rows []*influxql.Row
+	for row := itr.Next(); row != nil; row = itr.Next() {
+		rows = append(rows, row)
+	}
+	if err := itr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
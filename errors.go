@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+
+	"github.com/influxdb/influxdb/tsdb"
 )
 
 var (
@@ -42,10 +44,52 @@ func IsClientError(err error) bool {
 	if err == ErrFieldTypeConflict {
 		return true
 	}
+	if _, ok := err.(*tsdb.PartialWriteError); ok {
+		return true
+	}
 
 	if strings.Contains(err.Error(), ErrFieldTypeConflict.Error()) {
 		return true
 	}
+	if strings.Contains(err.Error(), tsdb.ErrMaxPointsPerBatchExceeded.Error()) {
+		return true
+	}
+	if strings.Contains(err.Error(), tsdb.ErrInvalidSeriesKey.Error()) {
+		return true
+	}
+	if strings.Contains(err.Error(), tsdb.ErrMaxSeriesPerDatabaseExceeded.Error()) {
+		return true
+	}
+
+	return false
+}
+
+// IsRetryableError indicates whether a write failed because the server is
+// overloaded, so the same write can succeed if it's retried later.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Errors from remote nodes only keep their message.
+	if strings.Contains(err.Error(), tsdb.ErrWriteQueueFull.Error()) {
+		return true
+	}
+
+	return false
+}
+
+// IsNotFoundError indicates whether a write failed because its retention
+// policy doesn't exist.
+func IsNotFoundError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Errors from the meta store and remote nodes only keep their message.
+	if strings.Contains(err.Error(), "retention policy not found") {
+		return true
+	}
 
 	return false
 }
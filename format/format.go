@@ -0,0 +1,103 @@
+/*
+Package format implements version headers for on-disk data.
+
+Every file or directory written by InfluxDB records the format version it was
+written with along with the set of capabilities a reader must understand to
+interpret it. On startup the header is checked against what the running binary
+supports: older versions are migrated forward and newer or unknown versions are
+refused so that data is never misread by an older release.
+*/
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Header is the version header stored alongside on-disk data.
+type Header struct {
+	Version      int      `json:"version"`
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// MarshalBinary encodes the header to a binary format.
+func (h *Header) MarshalBinary() ([]byte, error) {
+	return json.Marshal(h)
+}
+
+// UnmarshalBinary decodes the header from a binary format.
+func (h *Header) UnmarshalBinary(buf []byte) error {
+	return json.Unmarshal(buf, h)
+}
+
+// Spec describes the format a component is able to read and write.
+type Spec struct {
+	// Name is a human readable name used in error messages, e.g. "shard".
+	Name string
+
+	// Version is the current format version. Version 0 is reserved for data
+	// written before headers existed.
+	Version int
+
+	// Capabilities lists the features understood by this release. Data that
+	// requires a capability not in this list cannot be opened.
+	Capabilities []string
+
+	// Migrate, if set, upgrades data from version from to version from+1.
+	// Steps with no on-disk changes may be left unhandled.
+	Migrate func(from int) error
+}
+
+// Header returns the header for data written in the current format.
+func (s *Spec) Header() Header {
+	return Header{Version: s.Version, Capabilities: s.Capabilities}
+}
+
+// Upgrade checks h against the spec and migrates older data to the current
+// version. Returns the header that should be stored for the data and whether
+// it differs from h.
+func (s *Spec) Upgrade(h Header) (Header, bool, error) {
+	if h.Version > s.Version {
+		return h, false, fmt.Errorf("%s format version %d is newer than supported version %d: upgrade influxdb to open this data",
+			s.Name, h.Version, s.Version)
+	}
+
+	// Refuse data that requires features this release doesn't understand.
+	if missing := s.missing(h.Capabilities); len(missing) > 0 {
+		return h, false, fmt.Errorf("%s format requires unsupported capabilities: %s",
+			s.Name, strings.Join(missing, ", "))
+	}
+
+	if h.Version == s.Version {
+		return h, false, nil
+	}
+
+	// Migrate forward one version at a time.
+	for v := h.Version; v < s.Version; v++ {
+		if s.Migrate == nil {
+			continue
+		}
+		if err := s.Migrate(v); err != nil {
+			return h, false, fmt.Errorf("migrate %s format from version %d to %d: %s", s.Name, v, v+1, err)
+		}
+	}
+	return s.Header(), true, nil
+}
+
+// missing returns the capabilities in a that aren't supported by the spec.
+func (s *Spec) missing(a []string) []string {
+	var missing []string
+loop:
+	for _, c := range a {
+		for _, other := range s.Capabilities {
+			if c == other {
+				continue loop
+			}
+		}
+		missing = append(missing, c)
+	}
+	sort.Strings(missing)
+	return missing
+}
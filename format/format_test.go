@@ -0,0 +1,81 @@
+package format_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/influxdb/influxdb/format"
+)
+
+// Ensure data in the current format is accepted unchanged.
+func TestSpec_Upgrade_Current(t *testing.T) {
+	s := format.Spec{Name: "test", Version: 2, Capabilities: []string{"a", "b"}}
+	hdr, changed, err := s.Upgrade(format.Header{Version: 2, Capabilities: []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	} else if changed {
+		t.Fatal("unexpected change")
+	} else if !reflect.DeepEqual(hdr, format.Header{Version: 2, Capabilities: []string{"a"}}) {
+		t.Fatalf("unexpected header: %#v", hdr)
+	}
+}
+
+// Ensure older data is migrated one version at a time.
+func TestSpec_Upgrade_Migrate(t *testing.T) {
+	var versions []int
+	s := format.Spec{
+		Name:         "test",
+		Version:      3,
+		Capabilities: []string{"a"},
+		Migrate: func(from int) error {
+			versions = append(versions, from)
+			return nil
+		},
+	}
+
+	hdr, changed, err := s.Upgrade(format.Header{})
+	if err != nil {
+		t.Fatal(err)
+	} else if !changed {
+		t.Fatal("expected change")
+	} else if !reflect.DeepEqual(hdr, s.Header()) {
+		t.Fatalf("unexpected header: %#v", hdr)
+	} else if !reflect.DeepEqual(versions, []int{0, 1, 2}) {
+		t.Fatalf("unexpected migrations: %v", versions)
+	}
+}
+
+// Ensure a failed migration returns an error.
+func TestSpec_Upgrade_ErrMigrate(t *testing.T) {
+	s := format.Spec{
+		Name:    "test",
+		Version: 2,
+		Migrate: func(from int) error {
+			if from == 1 {
+				return fmt.Errorf("marker")
+			}
+			return nil
+		},
+	}
+
+	if _, _, err := s.Upgrade(format.Header{}); err == nil || err.Error() != "migrate test format from version 1 to 2: marker" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure newer versions are refused.
+func TestSpec_Upgrade_ErrNewerVersion(t *testing.T) {
+	s := format.Spec{Name: "test", Version: 1}
+	if _, _, err := s.Upgrade(format.Header{Version: 2}); err == nil || err.Error() != "test format version 2 is newer than supported version 1: upgrade influxdb to open this data" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure data requiring unknown capabilities is refused.
+func TestSpec_Upgrade_ErrCapabilities(t *testing.T) {
+	s := format.Spec{Name: "test", Version: 1, Capabilities: []string{"a"}}
+	if _, _, err := s.Upgrade(format.Header{Version: 1, Capabilities: []string{"c", "a", "b"}}); err == nil || err.Error() != "test format requires unsupported capabilities: b, c" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
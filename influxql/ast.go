@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"sort"
 	"strconv"
@@ -29,6 +30,8 @@ const (
 	Time = 5
 	// Duration means the data type is a duration of time.
 	Duration = 6
+	// Unsigned means the data type is an unsigned 64-bit integer.
+	Unsigned = 7
 )
 
 // InspectDataType returns the data type of a given value.
@@ -38,6 +41,8 @@ func InspectDataType(v interface{}) DataType {
 		return Float
 	case int64, int32, int:
 		return Integer
+	case uint64:
+		return Unsigned
 	case bool:
 		return Boolean
 	case string:
@@ -65,6 +70,8 @@ func (d DataType) String() string {
 		return "time"
 	case Duration:
 		return "duration"
+	case Unsigned:
+		return "unsigned"
 	}
 	return "unknown"
 }
@@ -78,36 +85,58 @@ type Node interface {
 func (*Query) node()     {}
 func (Statements) node() {}
 
-func (*AlterRetentionPolicyStatement) node()  {}
-func (*CreateContinuousQueryStatement) node() {}
-func (*CreateDatabaseStatement) node()        {}
-func (*CreateRetentionPolicyStatement) node() {}
-func (*CreateUserStatement) node()            {}
-func (*Distinct) node()                       {}
-func (*DeleteStatement) node()                {}
-func (*DropContinuousQueryStatement) node()   {}
-func (*DropDatabaseStatement) node()          {}
-func (*DropMeasurementStatement) node()       {}
-func (*DropRetentionPolicyStatement) node()   {}
-func (*DropSeriesStatement) node()            {}
-func (*DropUserStatement) node()              {}
-func (*GrantStatement) node()                 {}
-func (*ShowContinuousQueriesStatement) node() {}
-func (*ShowGrantsForUserStatement) node()     {}
-func (*ShowServersStatement) node()           {}
-func (*ShowDatabasesStatement) node()         {}
-func (*ShowFieldKeysStatement) node()         {}
-func (*ShowRetentionPoliciesStatement) node() {}
-func (*ShowMeasurementsStatement) node()      {}
-func (*ShowSeriesStatement) node()            {}
-func (*ShowStatsStatement) node()             {}
-func (*ShowDiagnosticsStatement) node()       {}
-func (*ShowTagKeysStatement) node()           {}
-func (*ShowTagValuesStatement) node()         {}
-func (*ShowUsersStatement) node()             {}
-func (*RevokeStatement) node()                {}
-func (*SelectStatement) node()                {}
-func (*SetPasswordUserStatement) node()       {}
+func (*AlterRetentionPolicyStatement) node()       {}
+func (*CreateAPIKeyStatement) node()               {}
+func (*CreateContinuousQueryStatement) node()      {}
+func (*CreateDatabaseStatement) node()             {}
+func (*CreateRetentionPolicyStatement) node()      {}
+func (*CreateRoleStatement) node()                 {}
+func (*CreateUserStatement) node()                 {}
+func (*Distinct) node()                            {}
+func (*DeleteStatement) node()                     {}
+func (*DropAPIKeyStatement) node()                 {}
+func (*DropContinuousQueryStatement) node()        {}
+func (*DropDatabaseStatement) node()               {}
+func (*RenameDatabaseStatement) node()             {}
+func (*DropMeasurementStatement) node()            {}
+func (*DropRetentionPolicyStatement) node()        {}
+func (*DropRoleStatement) node()                   {}
+func (*DropSeriesStatement) node()                 {}
+func (*DropUserStatement) node()                   {}
+func (*ExplainStatement) node()                    {}
+func (*GrantStatement) node()                      {}
+func (*GrantRoleStatement) node()                  {}
+func (*GrantToRoleStatement) node()                {}
+func (*ShowAPIKeysStatement) node()                {}
+func (*ShowContinuousQueriesStatement) node()      {}
+func (*ShowGrantsForUserStatement) node()          {}
+func (*ShowLimitsStatement) node()                 {}
+func (*ShowServersStatement) node()                {}
+func (*ShowShardGroupsStatement) node()            {}
+func (*ShowShardsStatement) node()                 {}
+func (*ShowDatabasesStatement) node()              {}
+func (*ShowFieldKeysStatement) node()              {}
+func (*ShowRetentionPoliciesStatement) node()      {}
+func (*ShowRolesStatement) node()                  {}
+func (*ShowSchemaStatement) node()                 {}
+func (*ShowMeasurementsStatement) node()           {}
+func (*ShowMeasurementCardinalityStatement) node() {}
+func (*ShowSeriesStatement) node()                 {}
+func (*ShowSeriesCardinalityStatement) node()      {}
+func (*ShowStatsStatement) node()                  {}
+func (*ShowDiagnosticsStatement) node()            {}
+func (*ShowTagKeysStatement) node()                {}
+func (*ShowTagValuesStatement) node()              {}
+func (*ShowTagValuesCardinalityStatement) node()   {}
+func (*ShowUsersStatement) node()                  {}
+func (*RevokeStatement) node()                     {}
+func (*RevokeRoleStatement) node()                 {}
+func (*RevokeFromRoleStatement) node()             {}
+func (*SelectStatement) node()                     {}
+func (*SetLimitStatement) node()                   {}
+func (*SetReadOnlyStatement) node()                {}
+func (*SetPasswordUserStatement) node()            {}
+func (*ExpirePasswordStatement) node()             {}
 
 func (*BinaryExpr) node()      {}
 func (*BooleanLiteral) node()  {}
@@ -180,35 +209,57 @@ type ExecutionPrivilege struct {
 // ExecutionPrivileges is a list of privileges required to execute a statement.
 type ExecutionPrivileges []ExecutionPrivilege
 
-func (*AlterRetentionPolicyStatement) stmt()  {}
-func (*CreateContinuousQueryStatement) stmt() {}
-func (*CreateDatabaseStatement) stmt()        {}
-func (*CreateRetentionPolicyStatement) stmt() {}
-func (*CreateUserStatement) stmt()            {}
-func (*DeleteStatement) stmt()                {}
-func (*DropContinuousQueryStatement) stmt()   {}
-func (*DropDatabaseStatement) stmt()          {}
-func (*DropMeasurementStatement) stmt()       {}
-func (*DropRetentionPolicyStatement) stmt()   {}
-func (*DropSeriesStatement) stmt()            {}
-func (*DropUserStatement) stmt()              {}
-func (*GrantStatement) stmt()                 {}
-func (*ShowContinuousQueriesStatement) stmt() {}
-func (*ShowGrantsForUserStatement) stmt()     {}
-func (*ShowServersStatement) stmt()           {}
-func (*ShowDatabasesStatement) stmt()         {}
-func (*ShowFieldKeysStatement) stmt()         {}
-func (*ShowMeasurementsStatement) stmt()      {}
-func (*ShowRetentionPoliciesStatement) stmt() {}
-func (*ShowSeriesStatement) stmt()            {}
-func (*ShowStatsStatement) stmt()             {}
-func (*ShowDiagnosticsStatement) stmt()       {}
-func (*ShowTagKeysStatement) stmt()           {}
-func (*ShowTagValuesStatement) stmt()         {}
-func (*ShowUsersStatement) stmt()             {}
-func (*RevokeStatement) stmt()                {}
-func (*SelectStatement) stmt()                {}
-func (*SetPasswordUserStatement) stmt()       {}
+func (*AlterRetentionPolicyStatement) stmt()       {}
+func (*CreateAPIKeyStatement) stmt()               {}
+func (*CreateContinuousQueryStatement) stmt()      {}
+func (*CreateDatabaseStatement) stmt()             {}
+func (*CreateRetentionPolicyStatement) stmt()      {}
+func (*CreateRoleStatement) stmt()                 {}
+func (*CreateUserStatement) stmt()                 {}
+func (*DeleteStatement) stmt()                     {}
+func (*DropAPIKeyStatement) stmt()                 {}
+func (*DropContinuousQueryStatement) stmt()        {}
+func (*DropDatabaseStatement) stmt()               {}
+func (*RenameDatabaseStatement) stmt()             {}
+func (*DropMeasurementStatement) stmt()            {}
+func (*DropRetentionPolicyStatement) stmt()        {}
+func (*DropRoleStatement) stmt()                   {}
+func (*DropSeriesStatement) stmt()                 {}
+func (*DropUserStatement) stmt()                   {}
+func (*ExplainStatement) stmt()                    {}
+func (*GrantStatement) stmt()                      {}
+func (*GrantRoleStatement) stmt()                  {}
+func (*GrantToRoleStatement) stmt()                {}
+func (*ShowAPIKeysStatement) stmt()                {}
+func (*ShowContinuousQueriesStatement) stmt()      {}
+func (*ShowGrantsForUserStatement) stmt()          {}
+func (*ShowLimitsStatement) stmt()                 {}
+func (*ShowServersStatement) stmt()                {}
+func (*ShowShardGroupsStatement) stmt()            {}
+func (*ShowShardsStatement) stmt()                 {}
+func (*ShowDatabasesStatement) stmt()              {}
+func (*ShowFieldKeysStatement) stmt()              {}
+func (*ShowMeasurementsStatement) stmt()           {}
+func (*ShowMeasurementCardinalityStatement) stmt() {}
+func (*ShowRetentionPoliciesStatement) stmt()      {}
+func (*ShowRolesStatement) stmt()                  {}
+func (*ShowSchemaStatement) stmt()                 {}
+func (*ShowSeriesStatement) stmt()                 {}
+func (*ShowSeriesCardinalityStatement) stmt()      {}
+func (*ShowStatsStatement) stmt()                  {}
+func (*ShowDiagnosticsStatement) stmt()            {}
+func (*ShowTagKeysStatement) stmt()                {}
+func (*ShowTagValuesStatement) stmt()              {}
+func (*ShowTagValuesCardinalityStatement) stmt()   {}
+func (*ShowUsersStatement) stmt()                  {}
+func (*RevokeStatement) stmt()                     {}
+func (*RevokeRoleStatement) stmt()                 {}
+func (*RevokeFromRoleStatement) stmt()             {}
+func (*SelectStatement) stmt()                     {}
+func (*SetLimitStatement) stmt()                   {}
+func (*SetReadOnlyStatement) stmt()                {}
+func (*SetPasswordUserStatement) stmt()            {}
+func (*ExpirePasswordStatement) stmt()             {}
 
 // Expr represents an expression that can be evaluated to a value.
 type Expr interface {
@@ -324,6 +375,30 @@ func (s *DropDatabaseStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// RenameDatabaseStatement represents a command to rename a database.
+type RenameDatabaseStatement struct {
+	// Current name of the database.
+	OldName string
+
+	// Name the database is renamed to.
+	NewName string
+}
+
+// String returns a string representation of the rename database statement.
+func (s *RenameDatabaseStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("ALTER DATABASE ")
+	_, _ = buf.WriteString(QuoteIdent(s.OldName))
+	_, _ = buf.WriteString(" RENAME TO ")
+	_, _ = buf.WriteString(QuoteIdent(s.NewName))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a RenameDatabaseStatement.
+func (s *RenameDatabaseStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // DropRetentionPolicyStatement represents a command to drop a retention policy from a database.
 type DropRetentionPolicyStatement struct {
 	// Name of the policy to drop.
@@ -400,6 +475,62 @@ func (s *DropUserStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// CreateAPIKeyStatement represents a command for creating an API key.
+type CreateAPIKeyStatement struct {
+	// Name of the key to be created.
+	Name string
+
+	// Privileges granted by the key, keyed by database.
+	Privileges map[string]Privilege
+}
+
+// String returns a string representation of the create API key statement.
+func (s *CreateAPIKeyStatement) String() string {
+	databases := make([]string, 0, len(s.Privileges))
+	for database := range s.Privileges {
+		databases = append(databases, database)
+	}
+	sort.Strings(databases)
+
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("CREATE API KEY ")
+	_, _ = buf.WriteString(s.Name)
+	_, _ = buf.WriteString(" WITH ")
+	for i, database := range databases {
+		if i > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(s.Privileges[database].String())
+		_, _ = buf.WriteString(" ON ")
+		_, _ = buf.WriteString(database)
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a CreateAPIKeyStatement.
+func (s *CreateAPIKeyStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// DropAPIKeyStatement represents a command for revoking an API key.
+type DropAPIKeyStatement struct {
+	// Name of the key to drop.
+	Name string
+}
+
+// String returns a string representation of the drop API key statement.
+func (s *DropAPIKeyStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("DROP API KEY ")
+	_, _ = buf.WriteString(s.Name)
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a DropAPIKeyStatement.
+func (s *DropAPIKeyStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // Privilege is a type of action a user can be granted the right to use.
 type Privilege int
 
@@ -487,6 +618,67 @@ func (s *SetPasswordUserStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// SetLimitStatement represents a command for setting a cluster limit.
+type SetLimitStatement struct {
+	// Name of the limit.
+	Name string
+
+	// New value of the limit. Zero removes the limit.
+	Value int
+}
+
+// String returns a string representation of the set limit statement.
+func (s *SetLimitStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("SET LIMIT ")
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
+	_, _ = buf.WriteString(" = ")
+	_, _ = buf.WriteString(strconv.Itoa(s.Value))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a SetLimitStatement.
+func (s *SetLimitStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// SetReadOnlyStatement represents a command for putting the cluster into or
+// taking it out of read-only maintenance mode.
+type SetReadOnlyStatement struct {
+	ReadOnly bool
+}
+
+// String returns a string representation of the set read only statement.
+func (s *SetReadOnlyStatement) String() string {
+	return "SET READ ONLY = " + strconv.FormatBool(s.ReadOnly)
+}
+
+// RequiredPrivileges returns the privilege required to execute a SetReadOnlyStatement.
+func (s *SetReadOnlyStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ExpirePasswordStatement represents a command for forcing a user's
+// password to be changed. The user can't authenticate until it is.
+type ExpirePasswordStatement struct {
+	// Name of the user whose password expires.
+	Name string
+}
+
+// String returns a string representation of the expire password statement.
+func (s *ExpirePasswordStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("ALTER USER ")
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
+	_, _ = buf.WriteString(" EXPIRE PASSWORD")
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute an ExpirePasswordStatement.
+func (s *ExpirePasswordStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // RevokeStatement represents a command to revoke a privilege from a user.
 type RevokeStatement struct {
 	// Privilege to be revoked.
@@ -518,6 +710,152 @@ func (s *RevokeStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// CreateRoleStatement represents a command for creating a new role.
+type CreateRoleStatement struct {
+	// Name of the role to be created.
+	Name string
+}
+
+// String returns a string representation of the create role statement.
+func (s *CreateRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("CREATE ROLE ")
+	_, _ = buf.WriteString(s.Name)
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a CreateRoleStatement.
+func (s *CreateRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// DropRoleStatement represents a command for dropping a role.
+type DropRoleStatement struct {
+	// Name of the role to drop.
+	Name string
+}
+
+// String returns a string representation of the drop role statement.
+func (s *DropRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("DROP ROLE ")
+	_, _ = buf.WriteString(s.Name)
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a DropRoleStatement.
+func (s *DropRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// GrantRoleStatement represents a command for granting a role to a user.
+type GrantRoleStatement struct {
+	// Role to be granted.
+	Role string
+
+	// Who to grant the role to.
+	User string
+}
+
+// String returns a string representation of the grant role statement.
+func (s *GrantRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("GRANT ROLE ")
+	_, _ = buf.WriteString(s.Role)
+	_, _ = buf.WriteString(" TO ")
+	_, _ = buf.WriteString(s.User)
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a GrantRoleStatement.
+func (s *GrantRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// RevokeRoleStatement represents a command for revoking a role from a user.
+type RevokeRoleStatement struct {
+	// Role to be revoked.
+	Role string
+
+	// Who to revoke the role from.
+	User string
+}
+
+// String returns a string representation of the revoke role statement.
+func (s *RevokeRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("REVOKE ROLE ")
+	_, _ = buf.WriteString(s.Role)
+	_, _ = buf.WriteString(" FROM ")
+	_, _ = buf.WriteString(s.User)
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a RevokeRoleStatement.
+func (s *RevokeRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// GrantToRoleStatement represents a command for granting a privilege on a
+// database to a role.
+type GrantToRoleStatement struct {
+	// The privilege to be granted.
+	Privilege Privilege
+
+	// Database to grant the privilege on.
+	On string
+
+	// Role to grant the privilege to.
+	Role string
+}
+
+// String returns a string representation of the grant to role statement.
+func (s *GrantToRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("GRANT ")
+	_, _ = buf.WriteString(s.Privilege.String())
+	_, _ = buf.WriteString(" ON ")
+	_, _ = buf.WriteString(s.On)
+	_, _ = buf.WriteString(" TO ROLE ")
+	_, _ = buf.WriteString(s.Role)
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a GrantToRoleStatement.
+func (s *GrantToRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// RevokeFromRoleStatement represents a command for revoking a privilege on a
+// database from a role.
+type RevokeFromRoleStatement struct {
+	// Privilege to be revoked.
+	Privilege Privilege
+
+	// Database to revoke the privilege on.
+	On string
+
+	// Role to revoke the privilege from.
+	Role string
+}
+
+// String returns a string representation of the revoke from role statement.
+func (s *RevokeFromRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("REVOKE ")
+	_, _ = buf.WriteString(s.Privilege.String())
+	_, _ = buf.WriteString(" ON ")
+	_, _ = buf.WriteString(s.On)
+	_, _ = buf.WriteString(" FROM ROLE ")
+	_, _ = buf.WriteString(s.Role)
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a RevokeFromRoleStatement.
+func (s *RevokeFromRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // CreateRetentionPolicyStatement represents a command to create a retention policy.
 type CreateRetentionPolicyStatement struct {
 	// Name of policy to create.
@@ -532,6 +870,10 @@ type CreateRetentionPolicyStatement struct {
 	// Replication factor for data written to this policy.
 	Replication int
 
+	// Duration of each shard group in this policy. Zero uses a default
+	// based on the retention duration.
+	ShardGroupDuration time.Duration
+
 	// Should this policy be set as default for the database?
 	Default bool
 }
@@ -547,6 +889,10 @@ func (s *CreateRetentionPolicyStatement) String() string {
 	_, _ = buf.WriteString(FormatDuration(s.Duration))
 	_, _ = buf.WriteString(" REPLICATION ")
 	_, _ = buf.WriteString(strconv.Itoa(s.Replication))
+	if s.ShardGroupDuration > 0 {
+		_, _ = buf.WriteString(" SHARD DURATION ")
+		_, _ = buf.WriteString(FormatDuration(s.ShardGroupDuration))
+	}
 	if s.Default {
 		_, _ = buf.WriteString(" DEFAULT")
 	}
@@ -572,6 +918,9 @@ type AlterRetentionPolicyStatement struct {
 	// Replication factor for data written to this policy.
 	Replication *int
 
+	// Duration of each new shard group in this policy.
+	ShardGroupDuration *time.Duration
+
 	// Should this policy be set as defalut for the database?
 	Default bool
 }
@@ -594,6 +943,11 @@ func (s *AlterRetentionPolicyStatement) String() string {
 		_, _ = buf.WriteString(strconv.Itoa(*s.Replication))
 	}
 
+	if s.ShardGroupDuration != nil {
+		_, _ = buf.WriteString(" SHARD DURATION ")
+		_, _ = buf.WriteString(FormatDuration(*s.ShardGroupDuration))
+	}
+
 	if s.Default {
 		_, _ = buf.WriteString(" DEFAULT")
 	}
@@ -621,6 +975,9 @@ const (
 
 // SelectStatement represents a command for extracting data from the database.
 type SelectStatement struct {
+	// Directives overriding the planner's decisions.
+	Hints Hints
+
 	// Expressions returned from the selection.
 	Fields Fields
 
@@ -664,6 +1021,30 @@ type SelectStatement struct {
 	FillValue interface{}
 }
 
+// Hints are optional directives given to the planner in a "/*+ ... */"
+// comment after SELECT.
+type Hints struct {
+	// Select series by matching the tags of every series in the measurement
+	// instead of looking them up in the tag index.
+	NoIndex bool
+
+	// Maximum number of series processed at once. Series are processed one
+	// at a time if zero.
+	MaxParallel int
+}
+
+// String returns a string representation of the hints.
+func (h Hints) String() string {
+	var a []string
+	if h.NoIndex {
+		a = append(a, "NO_INDEX")
+	}
+	if h.MaxParallel > 0 {
+		a = append(a, fmt.Sprintf("MAX_PARALLEL(%d)", h.MaxParallel))
+	}
+	return "/*+ " + strings.Join(a, " ") + " */"
+}
+
 // HasDerivative returns true if one of the function calls in the statement is a
 // derivative aggregate
 func (s *SelectStatement) HasDerivative() bool {
@@ -675,6 +1056,17 @@ func (s *SelectStatement) HasDerivative() bool {
 	return false
 }
 
+// HasSeriesFunc returns true if one of the fields is a series function, such
+// as ewma() or outlier(), computed across the time buckets of the statement.
+func (s *SelectStatement) HasSeriesFunc() bool {
+	for _, f := range s.Fields {
+		if c, ok := f.Expr.(*Call); ok && isSeriesFunc(c.Name) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsSimpleDerivative return true if one of the function call is a derivative function with a
 // variable ref as the first arg
 func (s *SelectStatement) IsSimpleDerivative() bool {
@@ -692,6 +1084,7 @@ func (s *SelectStatement) IsSimpleDerivative() bool {
 // Clone returns a deep copy of the statement.
 func (s *SelectStatement) Clone() *SelectStatement {
 	clone := &SelectStatement{
+		Hints:      s.Hints,
 		Fields:     make(Fields, 0, len(s.Fields)),
 		Dimensions: make(Dimensions, 0, len(s.Dimensions)),
 		Sources:    cloneSources(s.Sources),
@@ -704,6 +1097,8 @@ func (s *SelectStatement) Clone() *SelectStatement {
 		Fill:       s.Fill,
 		FillValue:  s.FillValue,
 		IsRawQuery: s.IsRawQuery,
+
+		groupByInterval: s.groupByInterval,
 	}
 	if s.Target != nil {
 		clone.Target = &Target{
@@ -809,6 +1204,10 @@ func (s *SelectStatement) RewriteDistinct() {
 func (s *SelectStatement) String() string {
 	var buf bytes.Buffer
 	_, _ = buf.WriteString("SELECT ")
+	if s.Hints != (Hints{}) {
+		_, _ = buf.WriteString(s.Hints.String())
+		_, _ = buf.WriteString(" ")
+	}
 	_, _ = buf.WriteString(s.Fields.String())
 
 	if s.Target != nil {
@@ -941,6 +1340,10 @@ func (s *SelectStatement) validate(tr targetRequirement) error {
 		return err
 	}
 
+	if err := s.validateSeriesFuncs(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -953,7 +1356,7 @@ func (s *SelectStatement) validateAggregates(tr targetRequirement) error {
 				if min, max, got := 1, 2, len(c.Args); got > max || got < min {
 					return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", c.Name, min, max, got)
 				}
-			case "percentile":
+			case "percentile", "ewma", "outlier":
 				if exp, got := 2, len(c.Args); got != exp {
 					return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", c.Name, exp, got)
 				}
@@ -1117,6 +1520,49 @@ func (s *SelectStatement) validateDerivative() error {
 	return nil
 }
 
+// validateSeriesFuncs ensures that ewma() and outlier() are applied to an
+// aggregate with a valid numeric argument and aren't part of other expressions.
+func (s *SelectStatement) validateSeriesFuncs() error {
+	for _, f := range s.Fields {
+		for _, c := range walkFunctionCalls(f.Expr) {
+			// Series functions can't be the argument of another function.
+			if len(c.Args) > 0 {
+				if nested, ok := c.Args[0].(*Call); ok && isSeriesFunc(nested.Name) {
+					return fmt.Errorf("%s cannot be used as an argument of %s", nested.Name, c.Name)
+				}
+			}
+
+			if !isSeriesFunc(c.Name) {
+				continue
+			}
+
+			if c != f.Expr {
+				return fmt.Errorf("%s cannot be used in an expression", c.Name)
+			}
+
+			if nested, ok := c.Args[0].(*Call); !ok || strings.HasSuffix(nested.Name, "derivative") {
+				return fmt.Errorf("%s requires an aggregate argument", c.Name)
+			}
+
+			lit, ok := c.Args[1].(*NumberLiteral)
+			if !ok {
+				return fmt.Errorf("%s requires a number as the second argument", c.Name)
+			}
+			switch c.Name {
+			case "ewma":
+				if lit.Val <= 0 || lit.Val > 1 {
+					return fmt.Errorf("ewma smoothing factor must be greater than 0 and at most 1")
+				}
+			case "outlier":
+				if lit.Val <= 0 {
+					return fmt.Errorf("outlier threshold must be greater than 0")
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // GroupByIterval extracts the time interval, if specified.
 func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
 	// return if we've already pulled it out
@@ -1411,13 +1857,13 @@ type DeleteStatement struct {
 // String returns a string representation of the delete statement.
 func (s *DeleteStatement) String() string {
 	var buf bytes.Buffer
-	_, _ = buf.WriteString("DELETE ")
+	_, _ = buf.WriteString("DELETE FROM ")
 	_, _ = buf.WriteString(s.Source.String())
 	if s.Condition != nil {
 		_, _ = buf.WriteString(" WHERE ")
 		_, _ = buf.WriteString(s.Condition.String())
 	}
-	return s.String()
+	return buf.String()
 }
 
 // RequiredPrivileges returns the privilege required to execute a DeleteStatement.
@@ -1425,6 +1871,20 @@ func (s *DeleteStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: WritePrivilege}}
 }
 
+// ExplainStatement represents a command for showing how a select statement
+// would be executed without executing it.
+type ExplainStatement struct {
+	Statement *SelectStatement
+}
+
+// String returns a string representation of the explain statement.
+func (s *ExplainStatement) String() string { return "EXPLAIN " + s.Statement.String() }
+
+// RequiredPrivileges returns the privilege required to execute an ExplainStatement.
+func (s *ExplainStatement) RequiredPrivileges() ExecutionPrivileges {
+	return s.Statement.RequiredPrivileges()
+}
+
 // ShowSeriesStatement represents a command for listing series in the database.
 type ShowSeriesStatement struct {
 	// Measurement(s) the series are listed for.
@@ -1478,6 +1938,18 @@ func (s *ShowSeriesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
 }
 
+// ShowSeriesCardinalityStatement represents a command for counting the
+// number of series in the database.
+type ShowSeriesCardinalityStatement struct{}
+
+// String returns a string representation of the statement.
+func (s *ShowSeriesCardinalityStatement) String() string { return "SHOW SERIES CARDINALITY" }
+
+// RequiredPrivileges returns the privilege required to execute a ShowSeriesCardinalityStatement.
+func (s *ShowSeriesCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+}
+
 // DropSeriesStatement represents a command for removing a series from the database.
 type DropSeriesStatement struct {
 	// Data source that fields are extracted from (optional)
@@ -1551,6 +2023,30 @@ func (s *ShowServersStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// ShowShardGroupsStatement represents a command for listing all shard groups
+// and their time ranges.
+type ShowShardGroupsStatement struct{}
+
+// String returns a string representation of the show shard groups command.
+func (s *ShowShardGroupsStatement) String() string { return "SHOW SHARD GROUPS" }
+
+// RequiredPrivileges returns the privilege required to execute a ShowShardGroupsStatement
+func (s *ShowShardGroupsStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowShardsStatement represents a command for listing all shards and the
+// servers that own them.
+type ShowShardsStatement struct{}
+
+// String returns a string representation of the show shards command.
+func (s *ShowShardsStatement) String() string { return "SHOW SHARDS" }
+
+// RequiredPrivileges returns the privilege required to execute a ShowShardsStatement
+func (s *ShowShardsStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // ShowDatabasesStatement represents a command for listing all databases in the cluster.
 type ShowDatabasesStatement struct{}
 
@@ -1665,6 +2161,18 @@ func (s *ShowMeasurementsStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
 }
 
+// ShowMeasurementCardinalityStatement represents a command for counting the
+// number of measurements in the database.
+type ShowMeasurementCardinalityStatement struct{}
+
+// String returns a string representation of the statement.
+func (s *ShowMeasurementCardinalityStatement) String() string { return "SHOW MEASUREMENT CARDINALITY" }
+
+// RequiredPrivileges returns the privilege required to execute a ShowMeasurementCardinalityStatement.
+func (s *ShowMeasurementCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+}
+
 // DropMeasurementStatement represents a command to drop a measurement.
 type DropMeasurementStatement struct {
 	// Name of the measurement to be dropped.
@@ -1842,6 +2350,35 @@ func (s *ShowTagValuesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
 }
 
+// ShowTagValuesCardinalityStatement represents a command for counting the
+// number of distinct values of a tag key.
+type ShowTagValuesCardinalityStatement struct {
+	// Measurement(s) the values are counted across. All measurements if empty.
+	Sources Sources
+
+	// Tag key to count the values of.
+	TagKey string
+}
+
+// String returns a string representation of the statement.
+func (s *ShowTagValuesCardinalityStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("SHOW TAG VALUES CARDINALITY")
+
+	if s.Sources != nil {
+		_, _ = buf.WriteString(" FROM ")
+		_, _ = buf.WriteString(s.Sources.String())
+	}
+	_, _ = buf.WriteString(" WITH KEY = ")
+	_, _ = buf.WriteString(QuoteIdent(s.TagKey))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowTagValuesCardinalityStatement
+func (s *ShowTagValuesCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+}
+
 // ShowUsersStatement represents a command for listing users.
 type ShowUsersStatement struct{}
 
@@ -1855,6 +2392,69 @@ func (s *ShowUsersStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// ShowAPIKeysStatement represents a command for listing API keys.
+type ShowAPIKeysStatement struct{}
+
+// String returns a string representation of the ShowAPIKeysStatement.
+func (s *ShowAPIKeysStatement) String() string { return "SHOW API KEYS" }
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowAPIKeysStatement
+func (s *ShowAPIKeysStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowRolesStatement represents a command for listing roles and their privileges.
+type ShowRolesStatement struct{}
+
+// String returns a string representation of the ShowRolesStatement.
+func (s *ShowRolesStatement) String() string { return "SHOW ROLES" }
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowRolesStatement
+func (s *ShowRolesStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowLimitsStatement represents a command for listing the cluster limits.
+type ShowLimitsStatement struct{}
+
+// String returns a string representation of the ShowLimitsStatement.
+func (s *ShowLimitsStatement) String() string { return "SHOW LIMITS" }
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowLimitsStatement
+func (s *ShowLimitsStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowSchemaStatement represents a command for listing the measurement
+// schemas recorded in the meta store.
+type ShowSchemaStatement struct {
+	// Database to list schemas for. Defaults to the current database.
+	Database string
+
+	// Optional measurement to list the schema of.
+	Measurement string
+}
+
+// String returns a string representation of the ShowSchemaStatement.
+func (s *ShowSchemaStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("SHOW SCHEMA")
+	if s.Database != "" {
+		_, _ = buf.WriteString(" ON ")
+		_, _ = buf.WriteString(QuoteIdent(s.Database))
+	}
+	if s.Measurement != "" {
+		_, _ = buf.WriteString(" FROM ")
+		_, _ = buf.WriteString(QuoteIdent(s.Measurement))
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowSchemaStatement
+func (s *ShowSchemaStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+}
+
 // ShowFieldKeysStatement represents a command for listing field keys.
 type ShowFieldKeysStatement struct {
 	// Data sources that fields are extracted from.
@@ -2368,6 +2968,10 @@ func Walk(v Visitor, node Node) {
 			Walk(v, c)
 		}
 
+	case *DropSeriesStatement:
+		Walk(v, n.Sources)
+		Walk(v, n.Condition)
+
 	case *Field:
 		Walk(v, n.Expr)
 
@@ -2379,6 +2983,9 @@ func Walk(v Visitor, node Node) {
 	case *ParenExpr:
 		Walk(v, n.Expr)
 
+	case *ExplainStatement:
+		Walk(v, n.Statement)
+
 	case *Query:
 		Walk(v, n.Statements)
 
@@ -2404,6 +3011,9 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Condition)
 		Walk(v, n.SortFields)
 
+	case *ShowTagValuesCardinalityStatement:
+		Walk(v, n.Sources)
+
 	case *ShowFieldKeysStatement:
 		Walk(v, n.Sources)
 		Walk(v, n.SortFields)
@@ -2603,6 +3213,42 @@ func evalBinaryExpr(expr *BinaryExpr, m map[string]interface{}) interface{} {
 			}
 			return lhs / rhs
 		}
+	case uint64:
+		// number literals are parsed as float64. Negative, fractional and
+		// numbers of 2^64 or more can't be converted to a uint64 so those are
+		// compared as floats.
+		if f, ok := rhs.(float64); ok {
+			if f < 0 || f != math.Trunc(f) || f >= 1<<64 {
+				return evalBinaryExpr(&BinaryExpr{Op: expr.Op, LHS: &NumberLiteral{Val: float64(lhs)}, RHS: &NumberLiteral{Val: f}}, m)
+			}
+			rhs = uint64(f)
+		}
+		rhs, _ := rhs.(uint64)
+		switch expr.Op {
+		case EQ:
+			return lhs == rhs
+		case NEQ:
+			return lhs != rhs
+		case LT:
+			return lhs < rhs
+		case LTE:
+			return lhs <= rhs
+		case GT:
+			return lhs > rhs
+		case GTE:
+			return lhs >= rhs
+		case ADD:
+			return lhs + rhs
+		case SUB:
+			return lhs - rhs
+		case MUL:
+			return lhs * rhs
+		case DIV:
+			if rhs == 0 {
+				return uint64(0)
+			}
+			return lhs / rhs
+		}
 	case string:
 		rhs, _ := rhs.(string)
 		switch expr.Op {
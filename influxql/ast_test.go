@@ -596,6 +596,13 @@ func TestEval(t *testing.T) {
 		{in: `4 <= 4`, out: true},
 		{in: `4 AND 5`, out: nil},
 
+		// Unsigned integers.
+		{in: `foo > 9223372036854775807`, out: true, data: map[string]interface{}{"foo": uint64(18446744073709551615)}},
+		{in: `foo > -1`, out: true, data: map[string]interface{}{"foo": uint64(0)}},
+		{in: `foo < 1.5`, out: true, data: map[string]interface{}{"foo": uint64(1)}},
+		{in: `foo < 100000000000000000000`, out: true, data: map[string]interface{}{"foo": uint64(18446744073709551615)}},
+		{in: `foo + 2`, out: uint64(5), data: map[string]interface{}{"foo": uint64(3)}},
+
 		// Boolean literals.
 		{in: `true AND false`, out: false},
 		{in: `true OR false`, out: true},
@@ -0,0 +1,227 @@
+package influxql
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Collation determines the order in which measurement names, series keys and
+// tag values are returned by SHOW statements and the order of GROUP BY series
+// in SELECT results. All collations compare bytes and never depend on locale.
+type Collation int
+
+const (
+	// BinaryCollation orders strings by their byte values.
+	BinaryCollation Collation = iota
+
+	// NaturalCollation orders runs of digits by their numeric value so that
+	// "cpu2" sorts before "cpu10". All other bytes are compared by value.
+	NaturalCollation
+)
+
+// ParseCollation returns the collation with the given name. An empty name
+// returns BinaryCollation.
+func ParseCollation(s string) (Collation, error) {
+	switch s {
+	case "", "binary":
+		return BinaryCollation, nil
+	case "natural":
+		return NaturalCollation, nil
+	}
+	return BinaryCollation, fmt.Errorf("unknown collation: %s", s)
+}
+
+// String returns the name of the collation.
+func (c Collation) String() string {
+	switch c {
+	case BinaryCollation:
+		return "binary"
+	case NaturalCollation:
+		return "natural"
+	}
+	return fmt.Sprintf("Collation(%d)", int(c))
+}
+
+// Compare returns -1, 0 or 1 if a sorts before, the same as or after b.
+func (c Collation) Compare(a, b string) int {
+	if c == NaturalCollation {
+		if n := compareNatural(a, b); n != 0 {
+			return n
+		}
+	}
+
+	// Fall back to a binary comparison so that strings which are only equal
+	// numerically (e.g. "cpu01" and "cpu1") still have a stable order.
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Less returns true if a sorts before b.
+func (c Collation) Less(a, b string) bool { return c.Compare(a, b) < 0 }
+
+// SortStrings sorts a in place.
+func (c Collation) SortStrings(a []string) {
+	sort.Sort(collatedStrings{a: a, c: c})
+}
+
+// SortRows sorts rows by name and then by the values of their tags, compared
+// in tag key order.
+func (c Collation) SortRows(rows Rows) {
+	sort.Sort(collatedRows{a: rows, c: c})
+}
+
+// SortValues sorts the values of each row by the string in the first column.
+func (c Collation) SortValues(rows Rows) {
+	for _, r := range rows {
+		sort.Stable(collatedValues{a: r.Values, c: c})
+	}
+}
+
+// SortJobs sorts jobs by measurement name and then by the values of their tag set.
+func (c Collation) SortJobs(jobs []*MapReduceJob) {
+	sort.Sort(collatedJobs{a: jobs, c: c})
+}
+
+// compareTags compares two tag sets by the values of each key, in key order.
+func (c Collation) compareTags(a, b map[string]string) int {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if n := c.Compare(a[k], b[k]); n != 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// compareNatural compares a and b, treating each run of digits as a number.
+func compareNatural(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(rune(a[i])) || !isDigit(rune(b[j])) {
+			if a[i] != b[j] {
+				if a[i] < b[j] {
+					return -1
+				}
+				return 1
+			}
+			i, j = i+1, j+1
+			continue
+		}
+
+		// Read both runs of digits, ignoring leading zeros.
+		si, sj := i, j
+		for i < len(a) && isDigit(rune(a[i])) {
+			i++
+		}
+		for j < len(b) && isDigit(rune(b[j])) {
+			j++
+		}
+		x, y := trimZeros(a[si:i]), trimZeros(b[sj:j])
+
+		// A longer number is larger, otherwise compare digit by digit.
+		if len(x) != len(y) {
+			if len(x) < len(y) {
+				return -1
+			}
+			return 1
+		} else if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(a)-i < len(b)-j:
+		return -1
+	case len(a)-i > len(b)-j:
+		return 1
+	}
+	return 0
+}
+
+// trimZeros removes leading zeros from a run of digits.
+func trimZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
+
+type collatedStrings struct {
+	a []string
+	c Collation
+}
+
+func (s collatedStrings) Len() int           { return len(s.a) }
+func (s collatedStrings) Less(i, j int) bool { return s.c.Less(s.a[i], s.a[j]) }
+func (s collatedStrings) Swap(i, j int)      { s.a[i], s.a[j] = s.a[j], s.a[i] }
+
+type collatedRows struct {
+	a Rows
+	c Collation
+}
+
+func (s collatedRows) Len() int { return len(s.a) }
+func (s collatedRows) Less(i, j int) bool {
+	if n := s.c.Compare(s.a[i].Name, s.a[j].Name); n != 0 {
+		return n < 0
+	}
+	return s.c.compareTags(s.a[i].Tags, s.a[j].Tags) < 0
+}
+func (s collatedRows) Swap(i, j int) { s.a[i], s.a[j] = s.a[j], s.a[i] }
+
+type collatedValues struct {
+	a [][]interface{}
+	c Collation
+}
+
+func (s collatedValues) Len() int { return len(s.a) }
+func (s collatedValues) Less(i, j int) bool {
+	var x, y string
+	if len(s.a[i]) > 0 {
+		x, _ = s.a[i][0].(string)
+	}
+	if len(s.a[j]) > 0 {
+		y, _ = s.a[j][0].(string)
+	}
+	return s.c.Less(x, y)
+}
+func (s collatedValues) Swap(i, j int) { s.a[i], s.a[j] = s.a[j], s.a[i] }
+
+type collatedJobs struct {
+	a []*MapReduceJob
+	c Collation
+}
+
+func (s collatedJobs) Len() int { return len(s.a) }
+func (s collatedJobs) Less(i, j int) bool {
+	if n := s.c.Compare(s.a[i].MeasurementName, s.a[j].MeasurementName); n != 0 {
+		return n < 0
+	}
+	var x, y map[string]string
+	if s.a[i].TagSet != nil {
+		x = s.a[i].TagSet.Tags
+	}
+	if s.a[j].TagSet != nil {
+		y = s.a[j].TagSet.Tags
+	}
+	return s.c.compareTags(x, y) < 0
+}
+func (s collatedJobs) Swap(i, j int) { s.a[i], s.a[j] = s.a[j], s.a[i] }
@@ -0,0 +1,83 @@
+package influxql_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// Ensure collations can be parsed by name.
+func TestParseCollation(t *testing.T) {
+	for i, tt := range []struct {
+		s   string
+		c   influxql.Collation
+		err string
+	}{
+		{s: "", c: influxql.BinaryCollation},
+		{s: "binary", c: influxql.BinaryCollation},
+		{s: "natural", c: influxql.NaturalCollation},
+		{s: "en_US", err: "unknown collation: en_US"},
+	} {
+		c, err := influxql.ParseCollation(tt.s)
+		if errstring(err) != tt.err {
+			t.Errorf("%d. %q: error mismatch: exp=%s, got=%s", i, tt.s, tt.err, errstring(err))
+		} else if c != tt.c {
+			t.Errorf("%d. %q: unexpected collation: %s", i, tt.s, c)
+		}
+	}
+}
+
+// Ensure strings are sorted according to the collation.
+func TestCollation_SortStrings(t *testing.T) {
+	for i, tt := range []struct {
+		c   influxql.Collation
+		in  []string
+		exp []string
+	}{
+		{
+			c:   influxql.BinaryCollation,
+			in:  []string{"cpu10", "cpu2", "CPU1", "cpu1"},
+			exp: []string{"CPU1", "cpu1", "cpu10", "cpu2"},
+		},
+		{
+			c:   influxql.NaturalCollation,
+			in:  []string{"cpu10", "cpu2", "cpu1", "cpu", "cpu1a"},
+			exp: []string{"cpu", "cpu1", "cpu1a", "cpu2", "cpu10"},
+		},
+		{
+			c:   influxql.NaturalCollation,
+			in:  []string{"10.0.0.10", "10.0.0.9", "9.0.0.1", "010.0.0.9"},
+			exp: []string{"9.0.0.1", "010.0.0.9", "10.0.0.9", "10.0.0.10"},
+		},
+		{
+			c:   influxql.NaturalCollation,
+			in:  []string{"a18446744073709551617", "a18446744073709551616", "a2"},
+			exp: []string{"a2", "a18446744073709551616", "a18446744073709551617"},
+		},
+	} {
+		tt.c.SortStrings(tt.in)
+		if !reflect.DeepEqual(tt.in, tt.exp) {
+			t.Errorf("%d. %s: unexpected order: %v", i, tt.c, tt.in)
+		}
+	}
+}
+
+// Ensure rows are sorted by name and then by tag values.
+func TestCollation_SortRows(t *testing.T) {
+	rows := influxql.Rows{
+		{Name: "mem", Tags: map[string]string{"host": "server1"}},
+		{Name: "cpu", Tags: map[string]string{"host": "server10", "region": "east"}},
+		{Name: "cpu", Tags: map[string]string{"host": "server2", "region": "west"}},
+		{Name: "cpu", Tags: map[string]string{"host": "server2", "region": "east"}},
+	}
+	influxql.NaturalCollation.SortRows(rows)
+
+	var got []string
+	for _, r := range rows {
+		got = append(got, r.Name+","+r.Tags["host"]+","+r.Tags["region"])
+	}
+	if exp := []string{"cpu,server2,east", "cpu,server2,west", "cpu,server10,east", "mem,server1,"}; !reflect.DeepEqual(exp, got) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+}
@@ -196,6 +196,9 @@ func (m *MapReduceJob) Execute(out chan *Row, filterEmptyResults bool) {
 	// handle any fill options
 	resultValues = m.processFill(resultValues)
 
+	// apply series functions such as ewma() and outlier()
+	resultValues = m.processSeriesFuncs(resultValues)
+
 	// process derivatives
 	resultValues = m.processDerivative(resultValues)
 
@@ -269,7 +272,7 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 		}
 
 		// now empty out all the mapper outputs up to the min time
-		var values []*rawQueryMapOutput
+		runs := make([][]*rawQueryMapOutput, len(mapperOutputs))
 		for j, o := range mapperOutputs {
 			// find the index of the point up to the min
 			ind := len(o)
@@ -281,7 +284,7 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 			}
 
 			// add up to the index to the values
-			values = append(values, o[:ind]...)
+			runs[j] = o[:ind]
 
 			// clear out previously sent mapper output data
 			mapperOutputs[j] = mapperOutputs[j][ind:]
@@ -292,14 +295,13 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 			}
 		}
 
+		// merge the values by time first so we can then handle offset and limit.
 		// if we didn't pull out any values, we're done here
+		values := mergeRawOutputs(runs)
 		if values == nil {
 			break
 		}
 
-		// sort the values by time first so we can then handle offset and limit
-		sort.Sort(rawOutputs(values))
-
 		// get rid of any points that need to be offset
 		if valuesOffset < m.stmt.Offset {
 			offset := m.stmt.Offset - valuesOffset
@@ -489,6 +491,36 @@ func (m *MapReduceJob) processDerivative(results [][]interface{}) [][]interface{
 	return derivatives
 }
 
+// processSeriesFuncs replaces the values of each series function field with
+// the function applied to the nested aggregate's values across the results.
+func (m *MapReduceJob) processSeriesFuncs(results [][]interface{}) [][]interface{} {
+	for i, f := range m.stmt.Fields {
+		c, ok := f.Expr.(*Call)
+		if !ok || !isSeriesFunc(c.Name) {
+			continue
+		}
+
+		// the first column is always time
+		values := make([]interface{}, len(results))
+		for j, vals := range results {
+			values[j] = vals[i+1]
+		}
+
+		arg := c.Args[1].(*NumberLiteral).Val
+		switch c.Name {
+		case "ewma":
+			values = ExponentialMovingAverage(values, arg)
+		case "outlier":
+			values = OutlierFlags(values, arg)
+		}
+
+		for j, vals := range results {
+			vals[i+1] = values[j]
+		}
+	}
+	return results
+}
+
 // processsResults will apply any math that was specified in the select statement against the passed in results
 func (m *MapReduceJob) processResults(results [][]interface{}) [][]interface{} {
 	hasMath := false
@@ -832,6 +864,9 @@ type Planner struct {
 
 	// Returns the current time. Defaults to time.Now().
 	Now func() time.Time
+
+	// Collation determines the order in which GROUP BY series are returned.
+	Collation Collation
 }
 
 // NewPlanner returns a new instance of Planner.
@@ -867,6 +902,11 @@ func (p *Planner) Plan(stmt *SelectStatement, chunkSize int) (*Executor, error)
 		return nil, err
 	}
 
+	// Jobs are returned in binary order so only reorder them for other collations.
+	if p.Collation != BinaryCollation {
+		p.Collation.SortJobs(jobs)
+	}
+
 	// LIMIT and OFFSET the unique series
 	if stmt.SLimit > 0 || stmt.SOffset > 0 {
 		if stmt.SOffset > len(jobs) {
@@ -921,15 +961,48 @@ func (e *Executor) execute(out chan *Row) {
 	// If we have multiple tag sets we'll want to filter out the empty ones
 	filterEmptyResults := len(e.jobs) > 1
 
-	// Execute each MRJob serially
-	for _, j := range e.jobs {
-		j.Execute(out, filterEmptyResults)
+	// Execute each MRJob serially unless the statement allows more at once.
+	if n := e.stmt.Hints.MaxParallel; n > 1 && len(e.jobs) > 1 {
+		e.executeParallel(out, filterEmptyResults, n)
+	} else {
+		for _, j := range e.jobs {
+			j.Execute(out, filterEmptyResults)
+		}
 	}
 
 	// Mark the end of the output channel.
 	close(out)
 }
 
+// executeParallel runs up to n MRJobs at once. Rows are still sent to out in
+// the order of the jobs.
+func (e *Executor) executeParallel(out chan *Row, filterEmptyResults bool, n int) {
+	chs := make([]chan *Row, len(e.jobs))
+	for i := range chs {
+		chs[i] = make(chan *Row, 1)
+	}
+
+	// Start jobs in order as slots free up. The earliest unfinished job is
+	// always running so the reader below can't wait on a job without a slot.
+	go func() {
+		sem := make(chan struct{}, n)
+		for i, j := range e.jobs {
+			sem <- struct{}{}
+			go func(j *MapReduceJob, ch chan *Row) {
+				j.Execute(ch, filterEmptyResults)
+				close(ch)
+				<-sem
+			}(j, chs[i])
+		}
+	}()
+
+	for _, ch := range chs {
+		for row := range ch {
+			out <- row
+		}
+	}
+}
+
 // Row represents a single row returned from the execution of a statement.
 type Row struct {
 	Name    string            `json:"name,omitempty"`
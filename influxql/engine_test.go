@@ -3,6 +3,7 @@ package influxql
 import (
 	"fmt"
 	"math"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -422,3 +423,27 @@ func TestProcessRawQueryDerivative(t *testing.T) {
 		}
 	}
 }
+
+// TestProcessSeriesFuncs tests that series functions are applied to the
+// aggregate values of their own field.
+func TestProcessSeriesFuncs(t *testing.T) {
+	q, err := ParseQuery(`SELECT ewma(mean(value), 0.5), outlier(max(value), 1), count(value) FROM foo`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &MapReduceJob{stmt: q.Statements[0].(*SelectStatement)}
+
+	got := m.processSeriesFuncs([][]interface{}{
+		{time.Unix(0, 0), 2.0, 1.0, int64(1)},
+		{time.Unix(1, 0), 4.0, 1.0, int64(2)},
+		{time.Unix(2, 0), nil, 9.0, int64(3)},
+	})
+	exp := [][]interface{}{
+		{time.Unix(0, 0), 2.0, false, int64(1)},
+		{time.Unix(1, 0), 3.0, false, int64(2)},
+		{time.Unix(2, 0), nil, true, int64(3)},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("processSeriesFuncs mismatch:\ngot %v\nexp %v", got, exp)
+	}
+}
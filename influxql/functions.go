@@ -7,6 +7,7 @@ package influxql
 // When adding an aggregate function, define a mapper, a reducer, and add them in the switch statement in the MapReduceFuncs function
 
 import (
+	"container/heap"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -49,13 +50,18 @@ func InitializeMapFunc(c *Call) (MapFunc, error) {
 		if len(c.Args) == 0 {
 			return nil, fmt.Errorf("expected field name argument for %s()", c.Name)
 		}
+	} else if isSeriesFunc(c.Name) {
+		// series functions require a nested aggregate and a number
+		if len(c.Args) != 2 {
+			return nil, fmt.Errorf("expected two arguments for %s()", c.Name)
+		}
 	} else if len(c.Args) != 1 {
 		return nil, fmt.Errorf("expected one argument for %s()", c.Name)
 	}
 
-	// derivative can take a nested aggregate function, everything else expects
-	// a variable reference as the first arg
-	if !strings.HasSuffix(c.Name, "derivative") {
+	// derivative and series functions can take a nested aggregate function,
+	// everything else expects a variable reference as the first arg
+	if !strings.HasSuffix(c.Name, "derivative") && !isSeriesFunc(c.Name) {
 		// Ensure the argument is appropriate for the aggregate function.
 		switch fc := c.Args[0].(type) {
 		case *VarRef:
@@ -117,6 +123,13 @@ func InitializeMapFunc(c *Call) (MapFunc, error) {
 			return InitializeMapFunc(fn)
 		}
 		return MapRawQuery, nil
+	case "ewma", "outlier":
+		// Series functions are computed from the nested aggregate
+		fn, ok := c.Args[0].(*Call)
+		if !ok {
+			return nil, fmt.Errorf("expected aggregate argument in %s()", c.Name)
+		}
+		return InitializeMapFunc(fn)
 	default:
 		return nil, fmt.Errorf("function not found: %q", c.Name)
 	}
@@ -173,6 +186,12 @@ func InitializeReduceFunc(c *Call) (ReduceFunc, error) {
 			return InitializeReduceFunc(fn)
 		}
 		return nil, fmt.Errorf("expected function argument to %s", c.Name)
+	case "ewma", "outlier":
+		fn, ok := c.Args[0].(*Call)
+		if !ok {
+			return nil, fmt.Errorf("expected aggregate argument in %s()", c.Name)
+		}
+		return InitializeReduceFunc(fn)
 	default:
 		return nil, fmt.Errorf("function not found: %q", c.Name)
 	}
@@ -190,6 +209,12 @@ func InitializeUnmarshaller(c *Call) (UnmarshalFunc, error) {
 
 	// Retrieve marshal function by name
 	switch c.Name {
+	case "ewma", "outlier":
+		fn, ok := c.Args[0].(*Call)
+		if !ok {
+			return nil, fmt.Errorf("expected aggregate argument in %s()", c.Name)
+		}
+		return InitializeUnmarshaller(fn)
 	case "mean":
 		return func(b []byte) (interface{}, error) {
 			var o meanMapOutput
@@ -422,11 +447,13 @@ type NumberType int8
 const (
 	Float64Type NumberType = iota
 	Int64Type
+	Uint64Type
 )
 
 // MapSum computes the summation of values in an iterator.
 func MapSum(itr Iterator) interface{} {
 	n := float64(0)
+	var u uint64 // exact sum of unsigned values
 	count := 0
 	var resultType NumberType
 	for _, k, v := itr.Next(); k != 0; _, k, v = itr.Next() {
@@ -437,6 +464,10 @@ func MapSum(itr Iterator) interface{} {
 		case int64:
 			n += float64(n1)
 			resultType = Int64Type
+		case uint64:
+			n += float64(n1)
+			u += n1
+			resultType = Uint64Type
 		}
 	}
 	if count > 0 {
@@ -445,6 +476,8 @@ func MapSum(itr Iterator) interface{} {
 			return n
 		case Int64Type:
 			return int64(n)
+		case Uint64Type:
+			return u
 		}
 	}
 	return nil
@@ -453,6 +486,7 @@ func MapSum(itr Iterator) interface{} {
 // ReduceSum computes the sum of values for each key.
 func ReduceSum(values []interface{}) interface{} {
 	var n float64
+	var u uint64 // exact sum of unsigned values
 	count := 0
 	var resultType NumberType
 	for _, v := range values {
@@ -466,6 +500,10 @@ func ReduceSum(values []interface{}) interface{} {
 		case int64:
 			n += float64(n1)
 			resultType = Int64Type
+		case uint64:
+			n += float64(n1)
+			u += n1
+			resultType = Uint64Type
 		}
 	}
 	if count > 0 {
@@ -474,6 +512,8 @@ func ReduceSum(values []interface{}) interface{} {
 			return n
 		case Int64Type:
 			return int64(n)
+		case Uint64Type:
+			return u
 		}
 	}
 	return nil
@@ -491,6 +531,8 @@ func MapMean(itr Iterator) interface{} {
 		case int64:
 			out.Mean += (float64(n1) - out.Mean) / float64(out.Count)
 			out.ResultType = Int64Type
+		case uint64:
+			out.Mean += (float64(n1) - out.Mean) / float64(out.Count)
 		}
 	}
 
@@ -683,9 +725,25 @@ func partition(data []float64) (lows []float64, pivotValue float64, highs []floa
 
 type minMaxMapOut struct {
 	Val  float64
+	Uint uint64 // exact value if Type is Uint64Type
 	Type NumberType
 }
 
+// reduceMinMax merges the output of a mapper into out. fn picks between the
+// float values and less reports whether an unsigned value replaces the
+// current one. Unsigned values are compared exactly unless they're mixed
+// with other types.
+func reduceMinMax(out, v *minMaxMapOut, fn func(x, y float64) float64, less func(x, y uint64) bool) {
+	if out.Type == Uint64Type && v.Type == Uint64Type {
+		if less(v.Uint, out.Uint) {
+			out.Uint = v.Uint
+		}
+	} else if out.Type != v.Type && (out.Type == Uint64Type || v.Type == Uint64Type) {
+		out.Type = Float64Type
+	}
+	out.Val = fn(out.Val, v.Val)
+}
+
 // MapMin collects the values to pass to the reducer
 func MapMin(itr Iterator) interface{} {
 	min := &minMaxMapOut{}
@@ -700,6 +758,12 @@ func MapMin(itr Iterator) interface{} {
 		case int64:
 			val = float64(n)
 			min.Type = Int64Type
+		case uint64:
+			val = float64(n)
+			if !pointsYielded || n < min.Uint {
+				min.Uint = n
+			}
+			min.Type = Uint64Type
 		}
 
 		// Initialize min
@@ -732,11 +796,11 @@ func ReduceMin(values []interface{}) interface{} {
 
 		// Initialize min
 		if !pointsYielded {
-			min.Val = v.Val
-			min.Type = v.Type
+			*min = *v
 			pointsYielded = true
+			continue
 		}
-		min.Val = math.Min(min.Val, v.Val)
+		reduceMinMax(min, v, math.Min, func(x, y uint64) bool { return x < y })
 	}
 	if pointsYielded {
 		switch min.Type {
@@ -744,6 +808,8 @@ func ReduceMin(values []interface{}) interface{} {
 			return min.Val
 		case Int64Type:
 			return int64(min.Val)
+		case Uint64Type:
+			return min.Uint
 		}
 	}
 	return nil
@@ -763,6 +829,12 @@ func MapMax(itr Iterator) interface{} {
 		case int64:
 			val = float64(n)
 			max.Type = Int64Type
+		case uint64:
+			val = float64(n)
+			if !pointsYielded || n > max.Uint {
+				max.Uint = n
+			}
+			max.Type = Uint64Type
 		}
 
 		// Initialize max
@@ -795,11 +867,11 @@ func ReduceMax(values []interface{}) interface{} {
 
 		// Initialize max
 		if !pointsYielded {
-			max.Val = v.Val
-			max.Type = v.Type
+			*max = *v
 			pointsYielded = true
+			continue
 		}
-		max.Val = math.Max(max.Val, v.Val)
+		reduceMinMax(max, v, math.Max, func(x, y uint64) bool { return x > y })
 	}
 	if pointsYielded {
 		switch max.Type {
@@ -807,6 +879,8 @@ func ReduceMax(values []interface{}) interface{} {
 			return max.Val
 		case Int64Type:
 			return int64(max.Val)
+		case Uint64Type:
+			return max.Uint
 		}
 	}
 	return nil
@@ -830,6 +904,8 @@ func MapSpread(itr Iterator) interface{} {
 		case int64:
 			val = float64(n)
 			out.Type = Int64Type
+		case uint64:
+			val = float64(n)
 		}
 
 		// Initialize
@@ -888,6 +964,8 @@ func MapStddev(itr Iterator) interface{} {
 			values = append(values, n)
 		case int64:
 			values = append(values, float64(n))
+		case uint64:
+			values = append(values, float64(n))
 		}
 	}
 
@@ -1061,6 +1139,8 @@ func ReducePercentile(percentile float64) ReduceFunc {
 				switch v.(type) {
 				case int64:
 					allValues = append(allValues, float64(v.(int64)))
+				case uint64:
+					allValues = append(allValues, float64(v.(uint64)))
 				case float64:
 					allValues = append(allValues, v.(float64))
 				}
@@ -1108,8 +1188,130 @@ func (r *rawQueryMapOutput) String() string {
 	return fmt.Sprintf("{%#v %#v}", r.Time, r.Values)
 }
 
-type rawOutputs []*rawQueryMapOutput
+// mergeRawOutputs merges runs of mapper output, each sorted by time, into
+// one slice sorted by time. Values at the same time are ordered by run.
+// Returns nil if there are no values.
+func mergeRawOutputs(runs [][]*rawQueryMapOutput) []*rawQueryMapOutput {
+	h := make(rawOutputsHeap, 0, len(runs))
+	var n int
+	for i, run := range runs {
+		if len(run) > 0 {
+			h = append(h, rawOutputsRun{values: run, index: i})
+			n += len(run)
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	heap.Init(&h)
+	a := make([]*rawQueryMapOutput, 0, n)
+	for h.Len() > 0 {
+		run := &h[0]
+		a = append(a, run.values[0])
+		if run.values = run.values[1:]; len(run.values) == 0 {
+			heap.Pop(&h)
+		} else {
+			heap.Fix(&h, 0)
+		}
+	}
+	return a
+}
+
+// rawOutputsRun is the remaining output of a mapper being merged.
+type rawOutputsRun struct {
+	values []*rawQueryMapOutput
+	index  int
+}
+
+// rawOutputsHeap is a min-heap of runs by the time of their next value.
+type rawOutputsHeap []rawOutputsRun
+
+func (h rawOutputsHeap) Len() int { return len(h) }
+func (h rawOutputsHeap) Less(i, j int) bool {
+	if ti, tj := h[i].values[0].Time, h[j].values[0].Time; ti != tj {
+		return ti < tj
+	}
+	return h[i].index < h[j].index
+}
+func (h rawOutputsHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *rawOutputsHeap) Push(x interface{}) { *h = append(*h, x.(rawOutputsRun)) }
+func (h *rawOutputsHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
 
-func (a rawOutputs) Len() int           { return len(a) }
-func (a rawOutputs) Less(i, j int) bool { return a[i].Time < a[j].Time }
-func (a rawOutputs) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+// isSeriesFunc returns true if the function is applied by the executor across
+// the time buckets of a nested aggregate, e.g. ewma(mean(value), 0.5).
+func isSeriesFunc(name string) bool {
+	return name == "ewma" || name == "outlier"
+}
+
+// seriesValue returns an aggregate value as a float64.
+func seriesValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// ExponentialMovingAverage returns the exponentially weighted moving average
+// of values. Each average is alpha times the value plus 1-alpha times the
+// previous average. Empty values are left empty and don't change the average.
+func ExponentialMovingAverage(values []interface{}, alpha float64) []interface{} {
+	out := make([]interface{}, len(values))
+	var avg float64
+	var started bool
+	for i, v := range values {
+		f, ok := seriesValue(v)
+		if !ok {
+			continue
+		}
+
+		if !started {
+			avg, started = f, true
+		} else {
+			avg = alpha*f + (1-alpha)*avg
+		}
+		out[i] = avg
+	}
+	return out
+}
+
+// OutlierFlags returns true for each value whose z-score, the distance from
+// the mean of all values in standard deviations, is greater than threshold.
+// Empty values are left empty.
+func OutlierFlags(values []interface{}, threshold float64) []interface{} {
+	// Calculate the mean and standard deviation of the values.
+	var n, sum, sumsq float64
+	for _, v := range values {
+		if f, ok := seriesValue(v); ok {
+			n++
+			sum += f
+			sumsq += f * f
+		}
+	}
+
+	var mean, stddev float64
+	if n > 0 {
+		mean = sum / n
+		stddev = math.Sqrt(math.Max(sumsq/n-mean*mean, 0))
+	}
+
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		f, ok := seriesValue(v)
+		if !ok {
+			continue
+		}
+		out[i] = stddev > 0 && math.Abs(f-mean)/stddev > threshold
+	}
+	return out
+}
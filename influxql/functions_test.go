@@ -1,6 +1,7 @@
 package influxql
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -71,6 +72,25 @@ func TestMapMean(t *testing.T) {
 		}
 	}
 }
+
+// Ensure unsigned values above 2^53 are summed and compared exactly.
+func TestMapReduce_Unsigned(t *testing.T) {
+	const big = uint64(1<<60 + 1)
+	values := func() *testIterator {
+		return &testIterator{values: []point{{"0", 1, big}, {"0", 2, big + 2}, {"0", 3, uint64(1)}}}
+	}
+
+	if got := ReduceSum([]interface{}{MapSum(values()), MapSum(values())}); got != 2*(2*big+3) {
+		t.Errorf("unexpected sum: %v", got)
+	}
+	if got := ReduceMin([]interface{}{MapMin(&testIterator{values: []point{{"0", 1, big + 2}, {"0", 2, big}}}), MapMin(&testIterator{values: []point{{"0", 1, big + 1}}})}); got != big {
+		t.Errorf("unexpected min: %v", got)
+	}
+	if got := ReduceMax([]interface{}{MapMax(values()), MapMax(&testIterator{values: []point{{"0", 1, big + 1}}})}); got != big+2 {
+		t.Errorf("unexpected max: %v", got)
+	}
+}
+
 func TestInitializeMapFuncPercentile(t *testing.T) {
 	// No args
 	c := &Call{
@@ -148,6 +168,38 @@ func TestInitializeMapFuncDerivative(t *testing.T) {
 	}
 }
 
+func TestInitializeMapFuncSeriesFuncs(t *testing.T) {
+	for _, fn := range []string{"ewma", "outlier"} {
+		// A field arg should fail
+		c := &Call{
+			Name: fn,
+			Args: []Expr{
+				&VarRef{Val: "field1"},
+				&NumberLiteral{Val: 0.5},
+			},
+		}
+
+		_, err := InitializeMapFunc(c)
+		if err == nil {
+			t.Errorf("InitializeMapFunc(%v) expected error.  got nil", c)
+		}
+
+		// Nested Aggregate func should return the map func for the nested aggregate
+		c = &Call{
+			Name: fn,
+			Args: []Expr{
+				&Call{Name: "mean", Args: []Expr{&VarRef{Val: "field1"}}},
+				&NumberLiteral{Val: 0.5},
+			},
+		}
+
+		_, err = InitializeMapFunc(c)
+		if err != nil {
+			t.Errorf("InitializeMapFunc(%v) unexpected error.  got %v", c, err)
+		}
+	}
+}
+
 func TestInitializeReduceFuncPercentile(t *testing.T) {
 	// No args
 	c := &Call{
@@ -195,6 +247,26 @@ func TestReducePercentileNil(t *testing.T) {
 	}
 }
 
+func TestExponentialMovingAverage(t *testing.T) {
+	got := ExponentialMovingAverage([]interface{}{nil, 10.0, int64(20), nil, 40.0}, 0.5)
+	if exp := []interface{}{nil, 10.0, 15.0, nil, 27.5}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("ExponentialMovingAverage() mismatch. exp %v got %v", exp, got)
+	}
+}
+
+func TestOutlierFlags(t *testing.T) {
+	got := OutlierFlags([]interface{}{10.0, 11.0, nil, 9.0, 10.0, 50.0}, 1.5)
+	if exp := []interface{}{false, false, nil, false, false, true}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("OutlierFlags() mismatch. exp %v got %v", exp, got)
+	}
+
+	// Values without any deviation are never outliers
+	got = OutlierFlags([]interface{}{1.0, 1.0}, 1)
+	if exp := []interface{}{false, false}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("OutlierFlags() mismatch. exp %v got %v", exp, got)
+	}
+}
+
 func TestMapDistinct(t *testing.T) {
 	const ( // prove that we're ignoring seriesKey
 		seriesKey1 = "1"
@@ -510,6 +582,24 @@ func TestGetSortedRange(t *testing.T) {
 	}
 }
 
+func TestMergeRawOutputs(t *testing.T) {
+	a := []*rawQueryMapOutput{{Time: 1, Values: "a"}, {Time: 3, Values: "a"}, {Time: 4, Values: "a"}}
+	b := []*rawQueryMapOutput{{Time: 2, Values: "b"}, {Time: 3, Values: "b"}}
+	c := []*rawQueryMapOutput{{Time: 0, Values: "c"}}
+
+	var got []string
+	for _, o := range mergeRawOutputs([][]*rawQueryMapOutput{a, nil, b, c}) {
+		got = append(got, fmt.Sprintf("%d%s", o.Time, o.Values))
+	}
+	if exp := []string{"0c", "1a", "2b", "3a", "3b", "4a"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values: %v", got)
+	}
+
+	if values := mergeRawOutputs([][]*rawQueryMapOutput{nil, {}}); values != nil {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
+
 var benchGetSortedRangeResults []float64
 
 func BenchmarkGetSortedRangeByPivot(b *testing.B) {
@@ -79,6 +79,8 @@ func (p *Parser) ParseStatement() (Statement, error) {
 	switch tok {
 	case SELECT:
 		return p.parseSelectStatement(targetNotRequired)
+	case EXPLAIN:
+		return p.parseExplainStatement()
 	case DELETE:
 		return p.parseDeleteStatement()
 	case SHOW:
@@ -96,7 +98,7 @@ func (p *Parser) ParseStatement() (Statement, error) {
 	case SET:
 		return p.parseSetStatement()
 	default:
-		return nil, newParseError(tokstr(tok, lit), []string{"SELECT", "DELETE", "SHOW", "CREATE", "DROP", "GRANT", "REVOKE", "ALTER", "SET"}, pos)
+		return nil, newParseError(tokstr(tok, lit), []string{"SELECT", "EXPLAIN", "DELETE", "SHOW", "CREATE", "DROP", "GRANT", "REVOKE", "ALTER", "SET"}, pos)
 	}
 }
 
@@ -105,20 +107,43 @@ func (p *Parser) ParseStatement() (Statement, error) {
 func (p *Parser) parseShowStatement() (Statement, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
 	switch tok {
+	case API:
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != KEYS {
+			return nil, newParseError(tokstr(tok, lit), []string{"KEYS"}, pos)
+		}
+		return p.parseShowAPIKeysStatement()
 	case CONTINUOUS:
 		return p.parseShowContinuousQueriesStatement()
 	case GRANTS:
 		return p.parseGrantsForUserStatement()
+	case LIMITS:
+		return p.parseShowLimitsStatement()
+	case ROLES:
+		return p.parseShowRolesStatement()
+	case SCHEMA:
+		return p.parseShowSchemaStatement()
 	case DATABASES:
 		return p.parseShowDatabasesStatement()
 	case SERVERS:
 		return p.parseShowServersStatement()
+	case SHARD:
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != GROUPS {
+			return nil, newParseError(tokstr(tok, lit), []string{"GROUPS"}, pos)
+		}
+		return p.parseShowShardGroupsStatement()
+	case SHARDS:
+		return p.parseShowShardsStatement()
 	case FIELD:
 		tok, pos, lit := p.scanIgnoreWhitespace()
 		if tok == KEYS {
 			return p.parseShowFieldKeysStatement()
 		}
 		return nil, newParseError(tokstr(tok, lit), []string{"KEYS", "VALUES"}, pos)
+	case MEASUREMENT:
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != CARDINALITY {
+			return nil, newParseError(tokstr(tok, lit), []string{"CARDINALITY"}, pos)
+		}
+		return &ShowMeasurementCardinalityStatement{}, nil
 	case MEASUREMENTS:
 		return p.parseShowMeasurementsStatement()
 	case RETENTION:
@@ -128,6 +153,10 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		}
 		return nil, newParseError(tokstr(tok, lit), []string{"POLICIES"}, pos)
 	case SERIES:
+		if tok, _, _ := p.scanIgnoreWhitespace(); tok == CARDINALITY {
+			return &ShowSeriesCardinalityStatement{}, nil
+		}
+		p.unscan()
 		return p.parseShowSeriesStatement()
 	case STATS:
 		return p.parseShowStatsStatement()
@@ -138,6 +167,10 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		if tok == KEYS {
 			return p.parseShowTagKeysStatement()
 		} else if tok == VALUES {
+			if tok, _, _ := p.scanIgnoreWhitespace(); tok == CARDINALITY {
+				return p.parseShowTagValuesCardinalityStatement()
+			}
+			p.unscan()
 			return p.parseShowTagValuesStatement()
 		}
 		return nil, newParseError(tokstr(tok, lit), []string{"KEYS", "VALUES"}, pos)
@@ -145,19 +178,26 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		return p.parseShowUsersStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASES", "FIELD", "GRANTS", "MEASUREMENTS", "RETENTION", "SERIES", "SERVERS", "TAG", "USERS"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"API", "CONTINUOUS", "DATABASES", "FIELD", "GRANTS", "LIMITS", "MEASUREMENT", "MEASUREMENTS", "RETENTION", "ROLES", "SCHEMA", "SERIES", "SERVERS", "SHARD", "SHARDS", "TAG", "USERS"}, pos)
 }
 
 // parseCreateStatement parses a string and returns a create statement.
 // This function assumes the CREATE token has already been consumed.
 func (p *Parser) parseCreateStatement() (Statement, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
-	if tok == CONTINUOUS {
+	if tok == API {
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != KEY {
+			return nil, newParseError(tokstr(tok, lit), []string{"KEY"}, pos)
+		}
+		return p.parseCreateAPIKeyStatement()
+	} else if tok == CONTINUOUS {
 		return p.parseCreateContinuousQueryStatement()
 	} else if tok == DATABASE {
 		return p.parseCreateDatabaseStatement()
 	} else if tok == USER {
 		return p.parseCreateUserStatement()
+	} else if tok == ROLE {
+		return p.parseCreateRoleStatement()
 	} else if tok == RETENTION {
 		tok, pos, lit = p.scanIgnoreWhitespace()
 		if tok != POLICY {
@@ -166,7 +206,7 @@ func (p *Parser) parseCreateStatement() (Statement, error) {
 		return p.parseCreateRetentionPolicyStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASE", "USER", "RETENTION"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"API", "CONTINUOUS", "DATABASE", "USER", "ROLE", "RETENTION"}, pos)
 }
 
 // parseDropStatement parses a string and returns a drop statement.
@@ -188,6 +228,13 @@ func (p *Parser) parseDropStatement() (Statement, error) {
 		return p.parseDropRetentionPolicyStatement()
 	} else if tok == USER {
 		return p.parseDropUserStatement()
+	} else if tok == ROLE {
+		return p.parseDropRoleStatement()
+	} else if tok == API {
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != KEY {
+			return nil, newParseError(tokstr(tok, lit), []string{"KEY"}, pos)
+		}
+		return p.parseDropAPIKeyStatement()
 	}
 
 	return nil, newParseError(tokstr(tok, lit), []string{"SERIES", "CONTINUOUS", "MEASUREMENT"}, pos)
@@ -197,26 +244,141 @@ func (p *Parser) parseDropStatement() (Statement, error) {
 // This function assumes the ALTER token has already been consumed.
 func (p *Parser) parseAlterStatement() (Statement, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
-	if tok == RETENTION {
+	if tok == DATABASE {
+		return p.parseRenameDatabaseStatement()
+	} else if tok == RETENTION {
 		if tok, pos, lit = p.scanIgnoreWhitespace(); tok != POLICY {
 			return nil, newParseError(tokstr(tok, lit), []string{"POLICY"}, pos)
 		}
 		return p.parseAlterRetentionPolicyStatement()
+	} else if tok == USER {
+		return p.parseAlterUserStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"RETENTION"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"DATABASE", "RETENTION", "USER"}, pos)
 }
 
-// parseSetStatement parses a string and returns a set statement.
-// This function assumes the SET token has already been consumed.
-func (p *Parser) parseSetStatement() (*SetPasswordUserStatement, error) {
-	stmt := &SetPasswordUserStatement{}
+// parseRenameDatabaseStatement parses a string and returns a rename database statement.
+// This function assumes the ALTER DATABASE tokens have already been consumed.
+func (p *Parser) parseRenameDatabaseStatement() (*RenameDatabaseStatement, error) {
+	stmt := &RenameDatabaseStatement{}
+
+	// Parse the current name of the database.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.OldName = ident
+
+	// Consume the required RENAME TO tokens.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != RENAME {
+		return nil, newParseError(tokstr(tok, lit), []string{"RENAME"}, pos)
+	}
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != TO {
+		return nil, newParseError(tokstr(tok, lit), []string{"TO"}, pos)
+	}
+
+	// Parse the new name of the database.
+	if ident, err = p.parseIdent(); err != nil {
+		return nil, err
+	}
+	stmt.NewName = ident
+
+	return stmt, nil
+}
+
+// parseAlterUserStatement parses a string and returns an expire password statement.
+// This function assumes the ALTER USER tokens have already been consumed.
+func (p *Parser) parseAlterUserStatement() (*ExpirePasswordStatement, error) {
+	stmt := &ExpirePasswordStatement{}
 
-	// Consume the required PASSWORD token.
+	// Parse username.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Consume the required EXPIRE PASSWORD tokens.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != EXPIRE {
+		return nil, newParseError(tokstr(tok, lit), []string{"EXPIRE"}, pos)
+	}
 	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != PASSWORD {
 		return nil, newParseError(tokstr(tok, lit), []string{"PASSWORD"}, pos)
 	}
 
+	return stmt, nil
+}
+
+// parseSetStatement parses a string and returns a set statement.
+// This function assumes the SET token has already been consumed.
+func (p *Parser) parseSetStatement() (Statement, error) {
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	switch tok {
+	case LIMIT:
+		return p.parseSetLimitStatement()
+	case PASSWORD:
+		return p.parseSetPasswordUserStatement()
+	case READ:
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != ONLY {
+			return nil, newParseError(tokstr(tok, lit), []string{"ONLY"}, pos)
+		}
+		return p.parseSetReadOnlyStatement()
+	}
+
+	return nil, newParseError(tokstr(tok, lit), []string{"LIMIT", "PASSWORD", "READ"}, pos)
+}
+
+// parseSetReadOnlyStatement parses a string and returns a set read only statement.
+// This function assumes the SET READ ONLY tokens have already been consumed.
+func (p *Parser) parseSetReadOnlyStatement() (*SetReadOnlyStatement, error) {
+	// Consume the required = token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != EQ {
+		return nil, newParseError(tokstr(tok, lit), []string{"="}, pos)
+	}
+
+	switch tok, pos, lit := p.scanIgnoreWhitespace(); tok {
+	case TRUE:
+		return &SetReadOnlyStatement{ReadOnly: true}, nil
+	case FALSE:
+		return &SetReadOnlyStatement{ReadOnly: false}, nil
+	default:
+		return nil, newParseError(tokstr(tok, lit), []string{"TRUE", "FALSE"}, pos)
+	}
+}
+
+// parseSetLimitStatement parses a string and returns a set limit statement.
+// This function assumes the SET LIMIT tokens have already been consumed.
+func (p *Parser) parseSetLimitStatement() (*SetLimitStatement, error) {
+	stmt := &SetLimitStatement{}
+
+	// Parse the limit name.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Consume the required = token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != EQ {
+		return nil, newParseError(tokstr(tok, lit), []string{"="}, pos)
+	}
+
+	// Parse the limit value.
+	n, err := p.parseInt(0, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Value = n
+
+	return stmt, nil
+}
+
+// parseSetPasswordUserStatement parses a string and returns a set password statement.
+// This function assumes the SET PASSWORD tokens have already been consumed.
+func (p *Parser) parseSetPasswordUserStatement() (*SetPasswordUserStatement, error) {
+	stmt := &SetPasswordUserStatement{}
+
 	// Consume the required FOR token.
 	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FOR {
 		return nil, newParseError(tokstr(tok, lit), []string{"FOR"}, pos)
@@ -293,6 +455,17 @@ func (p *Parser) parseCreateRetentionPolicyStatement() (*CreateRetentionPolicySt
 	}
 	stmt.Replication = n
 
+	// Parse optional SHARD DURATION.
+	if tok, _, _ = p.scanIgnoreWhitespace(); tok == SHARD {
+		d, err := p.parseShardDuration()
+		if err != nil {
+			return nil, err
+		}
+		stmt.ShardGroupDuration = d
+	} else {
+		p.unscan()
+	}
+
 	// Parse optional DEFAULT token.
 	if tok, pos, lit = p.scanIgnoreWhitespace(); tok == DEFAULT {
 		stmt.Default = true
@@ -330,8 +503,8 @@ func (p *Parser) parseAlterRetentionPolicyStatement() (*AlterRetentionPolicyStat
 	}
 	stmt.Database = ident
 
-	// Loop through option tokens (DURATION, REPLICATION, DEFAULT, etc.).
-	maxNumOptions := 3
+	// Loop through option tokens (DURATION, REPLICATION, SHARD DURATION, DEFAULT, etc.).
+	maxNumOptions := 4
 Loop:
 	for i := 0; i < maxNumOptions; i++ {
 		tok, pos, lit := p.scanIgnoreWhitespace()
@@ -348,11 +521,17 @@ Loop:
 				return nil, err
 			}
 			stmt.Replication = &n
+		case SHARD:
+			d, err := p.parseShardDuration()
+			if err != nil {
+				return nil, err
+			}
+			stmt.ShardGroupDuration = &d
 		case DEFAULT:
 			stmt.Default = true
 		default:
 			if i < 1 {
-				return nil, newParseError(tokstr(tok, lit), []string{"DURATION", "RETENTION", "DEFAULT"}, pos)
+				return nil, newParseError(tokstr(tok, lit), []string{"DURATION", "RETENTION", "SHARD", "DEFAULT"}, pos)
 			}
 			p.unscan()
 			break Loop
@@ -362,6 +541,26 @@ Loop:
 	return stmt, nil
 }
 
+// parseShardDuration parses the duration of a SHARD DURATION clause.
+// This function assumes the SHARD token has already been consumed.
+func (p *Parser) parseShardDuration() (time.Duration, error) {
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != DURATION {
+		return 0, newParseError(tokstr(tok, lit), []string{"DURATION"}, pos)
+	}
+
+	// Unlike the retention duration, shard durations cannot be INF.
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != DURATION_VAL {
+		return 0, newParseError(tokstr(tok, lit), []string{"duration"}, pos)
+	}
+
+	d, err := ParseDuration(lit)
+	if err != nil {
+		return 0, &ParseError{Message: err.Error(), Pos: pos}
+	}
+	return d, nil
+}
+
 // parseInt parses a string and returns an integer literal.
 func (p *Parser) parseInt(min, max int) (int, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
@@ -526,7 +725,13 @@ func (p *Parser) parseString() (string, error) {
 
 // parseRevokeStatement parses a string and returns a revoke statement.
 // This function assumes the REVOKE token has already been consumend.
-func (p *Parser) parseRevokeStatement() (*RevokeStatement, error) {
+func (p *Parser) parseRevokeStatement() (Statement, error) {
+	// Check for a role being revoked from a user.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == ROLE {
+		return p.parseRevokeRoleStatement()
+	}
+	p.unscan()
+
 	stmt := &RevokeStatement{}
 
 	// Parse the privilege to be revoked.
@@ -558,6 +763,19 @@ func (p *Parser) parseRevokeStatement() (*RevokeStatement, error) {
 		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
 	}
 
+	// Check for a privilege being revoked from a role.
+	if tok, pos, _ := p.scanIgnoreWhitespace(); tok == ROLE {
+		if stmt.On == "" {
+			return nil, &ParseError{Message: "role privileges must be on a database", Pos: pos}
+		}
+		role, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		return &RevokeFromRoleStatement{Privilege: stmt.Privilege, On: stmt.On, Role: role}, nil
+	}
+	p.unscan()
+
 	// Parse the name of the user we're revoking the privilege from.
 	lit, err = p.parseIdent()
 	if err != nil {
@@ -570,7 +788,13 @@ func (p *Parser) parseRevokeStatement() (*RevokeStatement, error) {
 
 // parseGrantStatement parses a string and returns a grant statement.
 // This function assumes the GRANT token has already been consumed.
-func (p *Parser) parseGrantStatement() (*GrantStatement, error) {
+func (p *Parser) parseGrantStatement() (Statement, error) {
+	// Check for a role being granted to a user.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == ROLE {
+		return p.parseGrantRoleStatement()
+	}
+	p.unscan()
+
 	stmt := &GrantStatement{}
 
 	// Parse the privilege to be granted.
@@ -602,6 +826,19 @@ func (p *Parser) parseGrantStatement() (*GrantStatement, error) {
 		return nil, newParseError(tokstr(tok, lit), []string{"TO"}, pos)
 	}
 
+	// Check for a privilege being granted to a role.
+	if tok, pos, _ := p.scanIgnoreWhitespace(); tok == ROLE {
+		if stmt.On == "" {
+			return nil, &ParseError{Message: "role privileges must be on a database", Pos: pos}
+		}
+		role, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		return &GrantToRoleStatement{Privilege: stmt.Privilege, On: stmt.On, Role: role}, nil
+	}
+	p.unscan()
+
 	// Parse the name of the user we're granting the privilege to.
 	lit, err = p.parseIdent()
 	if err != nil {
@@ -612,6 +849,56 @@ func (p *Parser) parseGrantStatement() (*GrantStatement, error) {
 	return stmt, nil
 }
 
+// parseGrantRoleStatement parses a string and returns a GrantRoleStatement.
+// This function assumes the "GRANT ROLE" tokens have already been consumed.
+func (p *Parser) parseGrantRoleStatement() (*GrantRoleStatement, error) {
+	stmt := &GrantRoleStatement{}
+
+	// Parse the name of the role to be granted.
+	lit, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Role = lit
+
+	// Check for required TO token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != TO {
+		return nil, newParseError(tokstr(tok, lit), []string{"TO"}, pos)
+	}
+
+	// Parse the name of the user we're granting the role to.
+	if stmt.User, err = p.parseIdent(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// parseRevokeRoleStatement parses a string and returns a RevokeRoleStatement.
+// This function assumes the "REVOKE ROLE" tokens have already been consumed.
+func (p *Parser) parseRevokeRoleStatement() (*RevokeRoleStatement, error) {
+	stmt := &RevokeRoleStatement{}
+
+	// Parse the name of the role to be revoked.
+	lit, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Role = lit
+
+	// Check for required FROM token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FROM {
+		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
+	}
+
+	// Parse the name of the user we're revoking the role from.
+	if stmt.User, err = p.parseIdent(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
 // parsePrivilege parses a string and returns a Privilege
 func (p *Parser) parsePrivilege() (Privilege, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
@@ -637,6 +924,11 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 	stmt := &SelectStatement{}
 	var err error
 
+	// Parse hints: "/*+ HINT+ */".
+	if stmt.Hints, err = p.parseHints(); err != nil {
+		return nil, err
+	}
+
 	// Parse fields: "FIELD+".
 	if stmt.Fields, err = p.parseFields(); err != nil {
 		return nil, err
@@ -710,6 +1002,51 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 	return stmt, nil
 }
 
+// parseHints parses an optional "/*+ ... */" hint comment.
+func (p *Parser) parseHints() (Hints, error) {
+	tok, _, lit := p.scanIgnoreWhitespace()
+	if tok != HINT {
+		p.unscan()
+		return Hints{}, nil
+	}
+	return ParseHints(lit)
+}
+
+// ParseHints parses the space separated hints within a hint comment.
+func ParseHints(s string) (Hints, error) {
+	var h Hints
+	for _, f := range strings.Fields(s) {
+		name := strings.ToUpper(f)
+		switch {
+		case name == "NO_INDEX":
+			h.NoIndex = true
+		case strings.HasPrefix(name, "MAX_PARALLEL(") && strings.HasSuffix(name, ")"):
+			n, err := strconv.Atoi(name[len("MAX_PARALLEL(") : len(name)-1])
+			if err != nil || n <= 0 {
+				return Hints{}, fmt.Errorf("invalid hint: %s: expected a positive integer", f)
+			}
+			h.MaxParallel = n
+		default:
+			return Hints{}, fmt.Errorf("unknown hint: %s", f)
+		}
+	}
+	return h, nil
+}
+
+// parseExplainStatement parses a string and returns an ExplainStatement.
+// This function assumes the EXPLAIN token has already been consumed.
+func (p *Parser) parseExplainStatement() (*ExplainStatement, error) {
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != SELECT {
+		return nil, newParseError(tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	stmt, err := p.parseSelectStatement(targetNotRequired)
+	if err != nil {
+		return nil, err
+	}
+	return &ExplainStatement{Statement: stmt}, nil
+}
+
 // targetRequirement specifies whether or not a target clause is required.
 type targetRequirement int
 
@@ -938,6 +1275,32 @@ func (p *Parser) parseShowTagValuesStatement() (*ShowTagValuesStatement, error)
 	return stmt, nil
 }
 
+// parseShowTagValuesCardinalityStatement parses a string and returns a ShowTagValuesCardinalityStatement.
+// This function assumes the "SHOW TAG VALUES CARDINALITY" tokens have already been consumed.
+func (p *Parser) parseShowTagValuesCardinalityStatement() (*ShowTagValuesCardinalityStatement, error) {
+	stmt := &ShowTagValuesCardinalityStatement{}
+	var err error
+
+	// Parse optional source.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == FROM {
+		if stmt.Sources, err = p.parseSources(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	// Parse required "WITH KEY = <key>".
+	if err := p.parseTokens([]Token{WITH, KEY, EQ}); err != nil {
+		return nil, err
+	}
+	if stmt.TagKey, err = p.parseIdent(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
 // parseTagKeys parses a string and returns a list of tag keys.
 func (p *Parser) parseTagKeys() ([]string, error) {
 	var err error
@@ -1083,6 +1446,19 @@ func (p *Parser) parseShowServersStatement() (*ShowServersStatement, error) {
 	return stmt, nil
 }
 
+// parseShowShardGroupsStatement parses a string and returns a ShowShardGroupsStatement.
+// This function assumes the "SHOW SHARD GROUPS" tokens have already been consumed.
+func (p *Parser) parseShowShardGroupsStatement() (*ShowShardGroupsStatement, error) {
+	return &ShowShardGroupsStatement{}, nil
+}
+
+// parseShowShardsStatement parses a string and returns a ShowShardsStatement.
+// This function assumes the "SHOW SHARDS" tokens have already been consumed.
+func (p *Parser) parseShowShardsStatement() (*ShowShardsStatement, error) {
+	stmt := &ShowShardsStatement{}
+	return stmt, nil
+}
+
 // parseGrantsForUserStatement parses a string and returns a ShowGrantsForUserStatement.
 // This function assumes the "SHOW GRANTS" tokens have already been consumed.
 func (p *Parser) parseGrantsForUserStatement() (*ShowGrantsForUserStatement, error) {
@@ -1283,6 +1659,140 @@ func (p *Parser) parseDropUserStatement() (*DropUserStatement, error) {
 	return stmt, nil
 }
 
+// parseCreateAPIKeyStatement parses a string and returns a CreateAPIKeyStatement.
+// This function assumes the "CREATE API KEY" tokens have already been consumed.
+func (p *Parser) parseCreateAPIKeyStatement() (*CreateAPIKeyStatement, error) {
+	stmt := &CreateAPIKeyStatement{Privileges: make(map[string]Privilege)}
+
+	// Parse name of the key to be created.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Consume the required WITH token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != WITH {
+		return nil, newParseError(tokstr(tok, lit), []string{"WITH"}, pos)
+	}
+
+	// Parse one or more "<privilege> ON <database>" grants.
+	for {
+		priv, err := p.parsePrivilege()
+		if err != nil {
+			return nil, err
+		}
+
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != ON {
+			return nil, newParseError(tokstr(tok, lit), []string{"ON"}, pos)
+		}
+
+		database, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Privileges[database] = priv
+
+		// Continue while there are more grants.
+		if tok, _, _ := p.scanIgnoreWhitespace(); tok != COMMA {
+			p.unscan()
+			break
+		}
+	}
+
+	return stmt, nil
+}
+
+// parseDropAPIKeyStatement parses a string and returns a DropAPIKeyStatement.
+// This function assumes the "DROP API KEY" tokens have already been consumed.
+func (p *Parser) parseDropAPIKeyStatement() (*DropAPIKeyStatement, error) {
+	stmt := &DropAPIKeyStatement{}
+
+	// Parse the name of the key to be dropped.
+	lit, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = lit
+
+	return stmt, nil
+}
+
+// parseShowAPIKeysStatement parses a string and returns a ShowAPIKeysStatement.
+// This function assumes the "SHOW API KEYS" tokens have already been consumed.
+func (p *Parser) parseShowAPIKeysStatement() (*ShowAPIKeysStatement, error) {
+	return &ShowAPIKeysStatement{}, nil
+}
+
+// parseCreateRoleStatement parses a string and returns a CreateRoleStatement.
+// This function assumes the "CREATE ROLE" tokens have already been consumed.
+func (p *Parser) parseCreateRoleStatement() (*CreateRoleStatement, error) {
+	stmt := &CreateRoleStatement{}
+
+	// Parse name of the role to be created.
+	lit, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = lit
+
+	return stmt, nil
+}
+
+// parseDropRoleStatement parses a string and returns a DropRoleStatement.
+// This function assumes the "DROP ROLE" tokens have already been consumed.
+func (p *Parser) parseDropRoleStatement() (*DropRoleStatement, error) {
+	stmt := &DropRoleStatement{}
+
+	// Parse the name of the role to be dropped.
+	lit, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = lit
+
+	return stmt, nil
+}
+
+// parseShowRolesStatement parses a string and returns a ShowRolesStatement.
+// This function assumes the "SHOW ROLES" tokens have already been consumed.
+func (p *Parser) parseShowRolesStatement() (*ShowRolesStatement, error) {
+	return &ShowRolesStatement{}, nil
+}
+
+// parseShowSchemaStatement parses a string and returns a ShowSchemaStatement.
+// This function assumes the "SHOW SCHEMA" tokens have already been consumed.
+func (p *Parser) parseShowSchemaStatement() (*ShowSchemaStatement, error) {
+	stmt := &ShowSchemaStatement{}
+	var err error
+
+	// Parse optional database: "ON db".
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == ON {
+		if stmt.Database, err = p.parseIdent(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	// Parse optional measurement: "FROM name".
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == FROM {
+		if stmt.Measurement, err = p.parseIdent(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	return stmt, nil
+}
+
+// parseShowLimitsStatement parses a string and returns a ShowLimitsStatement.
+// This function assumes the "SHOW LIMITS" tokens have already been consumed.
+func (p *Parser) parseShowLimitsStatement() (*ShowLimitsStatement, error) {
+	return &ShowLimitsStatement{}, nil
+}
+
 // parseRetentionPolicy parses a string and returns a retention policy name.
 // This function assumes the "WITH" token has already been consumed.
 func (p *Parser) parseRetentionPolicy() (name string, dfault bool, err error) {
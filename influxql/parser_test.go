@@ -74,6 +74,30 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with hints
+		{
+			s: `SELECT /*+ no_index MAX_PARALLEL(4) */ value FROM cpu`,
+			stmt: &influxql.SelectStatement{
+				Hints:      influxql.Hints{NoIndex: true, MaxParallel: 4},
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+			},
+		},
+
+		// EXPLAIN statement
+		{
+			s: `EXPLAIN SELECT /*+ NO_INDEX */ value FROM cpu`,
+			stmt: &influxql.ExplainStatement{
+				Statement: &influxql.SelectStatement{
+					Hints:      influxql.Hints{NoIndex: true},
+					IsRawQuery: true,
+					Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+					Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				},
+			},
+		},
+
 		// SELECT statement
 		{
 			skip: true,
@@ -528,12 +552,45 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// DELETE statement with a time range
+		{
+			s: `DELETE FROM cpu WHERE time > '2000-01-01T00:00:00Z' AND time < '2000-01-02T00:00:00Z'`,
+			stmt: &influxql.DeleteStatement{
+				Source: &influxql.Measurement{Name: "cpu"},
+				Condition: &influxql.BinaryExpr{
+					Op: influxql.AND,
+					LHS: &influxql.BinaryExpr{
+						Op:  influxql.GT,
+						LHS: &influxql.VarRef{Val: "time"},
+						RHS: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00Z")},
+					},
+					RHS: &influxql.BinaryExpr{
+						Op:  influxql.LT,
+						LHS: &influxql.VarRef{Val: "time"},
+						RHS: &influxql.TimeLiteral{Val: mustParseTime("2000-01-02T00:00:00Z")},
+					},
+				},
+			},
+		},
+
 		// SHOW SERVERS
 		{
 			s:    `SHOW SERVERS`,
 			stmt: &influxql.ShowServersStatement{},
 		},
 
+		// SHOW SHARDS
+		{
+			s:    `SHOW SHARDS`,
+			stmt: &influxql.ShowShardsStatement{},
+		},
+
+		// SHOW SHARD GROUPS
+		{
+			s:    `SHOW SHARD GROUPS`,
+			stmt: &influxql.ShowShardGroupsStatement{},
+		},
+
 		// SHOW GRANTS
 		{
 			s:    `SHOW GRANTS FOR jdoe`,
@@ -552,6 +609,27 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowSeriesStatement{},
 		},
 
+		// SHOW SERIES CARDINALITY statement
+		{
+			s:    `SHOW SERIES CARDINALITY`,
+			stmt: &influxql.ShowSeriesCardinalityStatement{},
+		},
+
+		// SHOW MEASUREMENT CARDINALITY statement
+		{
+			s:    `SHOW MEASUREMENT CARDINALITY`,
+			stmt: &influxql.ShowMeasurementCardinalityStatement{},
+		},
+
+		// SHOW TAG VALUES CARDINALITY statement
+		{
+			s: `SHOW TAG VALUES CARDINALITY FROM cpu WITH KEY = host`,
+			stmt: &influxql.ShowTagValuesCardinalityStatement{
+				Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				TagKey:  "host",
+			},
+		},
+
 		// SHOW SERIES FROM
 		{
 			s: `SHOW SERIES FROM cpu`,
@@ -816,6 +894,20 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		{
+			s: `DROP SERIES FROM /cpu.*/ WHERE host = 'hosta.influxdb.org'`,
+			stmt: &influxql.DropSeriesStatement{
+				Sources: []influxql.Source{&influxql.Measurement{
+					Regex: &influxql.RegexLiteral{Val: regexp.MustCompile("cpu.*")}},
+				},
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "host"},
+					RHS: &influxql.StringLiteral{Val: "hosta.influxdb.org"},
+				},
+			},
+		},
+
 		// SHOW CONTINUOUS QUERIES statement
 		{
 			s:    `SHOW CONTINUOUS QUERIES`,
@@ -957,6 +1049,40 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SET READ ONLY
+		{
+			s:    `SET READ ONLY = true`,
+			stmt: &influxql.SetReadOnlyStatement{ReadOnly: true},
+		},
+		{
+			s:    `SET READ ONLY = false`,
+			stmt: &influxql.SetReadOnlyStatement{ReadOnly: false},
+		},
+
+		// SET LIMIT
+		{
+			s: `SET LIMIT max_databases = 100`,
+			stmt: &influxql.SetLimitStatement{
+				Name:  "max_databases",
+				Value: 100,
+			},
+		},
+
+		// ALTER DATABASE ... RENAME TO
+		{
+			s: `ALTER DATABASE db0 RENAME TO db1`,
+			stmt: &influxql.RenameDatabaseStatement{
+				OldName: "db0",
+				NewName: "db1",
+			},
+		},
+
+		// ALTER USER ... EXPIRE PASSWORD
+		{
+			s:    `ALTER USER testuser EXPIRE PASSWORD`,
+			stmt: &influxql.ExpirePasswordStatement{Name: "testuser"},
+		},
+
 		// DROP CONTINUOUS QUERY statement
 		{
 			s:    `DROP CONTINUOUS QUERY myquery ON foo`,
@@ -990,6 +1116,39 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.DropUserStatement{Name: "jdoe"},
 		},
 
+		// CREATE API KEY statement
+		{
+			s: `CREATE API KEY telegraf WITH WRITE ON db0, READ ON db1`,
+			stmt: &influxql.CreateAPIKeyStatement{
+				Name: "telegraf",
+				Privileges: map[string]influxql.Privilege{
+					"db0": influxql.WritePrivilege,
+					"db1": influxql.ReadPrivilege,
+				},
+			},
+		},
+
+		// CREATE API KEY statement with a single grant
+		{
+			s: `CREATE API KEY reporter WITH ALL PRIVILEGES ON db0`,
+			stmt: &influxql.CreateAPIKeyStatement{
+				Name:       "reporter",
+				Privileges: map[string]influxql.Privilege{"db0": influxql.AllPrivileges},
+			},
+		},
+
+		// DROP API KEY statement
+		{
+			s:    `DROP API KEY telegraf`,
+			stmt: &influxql.DropAPIKeyStatement{Name: "telegraf"},
+		},
+
+		// SHOW API KEYS statement
+		{
+			s:    `SHOW API KEYS`,
+			stmt: &influxql.ShowAPIKeysStatement{},
+		},
+
 		// GRANT READ
 		{
 			s: `GRANT READ ON testdb TO jdoe`,
@@ -1088,6 +1247,83 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE ROLE
+		{
+			s:    `CREATE ROLE analysts`,
+			stmt: &influxql.CreateRoleStatement{Name: "analysts"},
+		},
+
+		// DROP ROLE
+		{
+			s:    `DROP ROLE analysts`,
+			stmt: &influxql.DropRoleStatement{Name: "analysts"},
+		},
+
+		// SHOW ROLES
+		{
+			s:    `SHOW ROLES`,
+			stmt: &influxql.ShowRolesStatement{},
+		},
+
+		// SHOW LIMITS
+		{
+			s:    `SHOW LIMITS`,
+			stmt: &influxql.ShowLimitsStatement{},
+		},
+
+		// SHOW SCHEMA
+		{
+			s:    `SHOW SCHEMA`,
+			stmt: &influxql.ShowSchemaStatement{},
+		},
+
+		// SHOW SCHEMA ON db FROM measurement
+		{
+			s: `SHOW SCHEMA ON mydb FROM cpu`,
+			stmt: &influxql.ShowSchemaStatement{
+				Database:    "mydb",
+				Measurement: "cpu",
+			},
+		},
+
+		// GRANT ROLE
+		{
+			s: `GRANT ROLE analysts TO jdoe`,
+			stmt: &influxql.GrantRoleStatement{
+				Role: "analysts",
+				User: "jdoe",
+			},
+		},
+
+		// REVOKE ROLE
+		{
+			s: `REVOKE ROLE analysts FROM jdoe`,
+			stmt: &influxql.RevokeRoleStatement{
+				Role: "analysts",
+				User: "jdoe",
+			},
+		},
+
+		// GRANT privilege to role
+		{
+			s: `GRANT READ ON testdb TO ROLE analysts`,
+			stmt: &influxql.GrantToRoleStatement{
+				Privilege: influxql.ReadPrivilege,
+				On:        "testdb",
+				Role:      "analysts",
+			},
+		},
+
+		// REVOKE privilege from role
+		{
+			s: `REVOKE ALL PRIVILEGES ON testdb FROM ROLE analysts`,
+			stmt: &influxql.RevokeFromRoleStatement{
+				Privilege: influxql.AllPrivileges,
+				On:        "testdb",
+				Role:      "analysts",
+			},
+		},
+
 		// CREATE RETENTION POLICY
 		{
 			s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 2`,
@@ -1122,6 +1358,19 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE RETENTION POLICY ... SHARD DURATION
+		{
+			s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 520w REPLICATION 1 SHARD DURATION 4w DEFAULT`,
+			stmt: &influxql.CreateRetentionPolicyStatement{
+				Name:               "policy1",
+				Database:           "testdb",
+				Duration:           520 * 7 * 24 * time.Hour,
+				Replication:        1,
+				ShardGroupDuration: 4 * 7 * 24 * time.Hour,
+				Default:            true,
+			},
+		},
+
 		// ALTER RETENTION POLICY
 		{
 			s:    `ALTER RETENTION POLICY policy1 ON testdb DURATION 1m REPLICATION 4 DEFAULT`,
@@ -1163,6 +1412,17 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: newAlterRetentionPolicyStatement("default", "testdb", -1, 4, false),
 		},
 
+		// ALTER RETENTION POLICY ... SHARD DURATION
+		{
+			s: `ALTER RETENTION POLICY policy1 ON testdb SHARD DURATION 30d`,
+			stmt: func() *influxql.AlterRetentionPolicyStatement {
+				stmt := newAlterRetentionPolicyStatement("policy1", "testdb", -1, -1, false)
+				d := 30 * 24 * time.Hour
+				stmt.ShardGroupDuration = &d
+				return stmt
+			}(),
+		},
+
 		// SHOW STATS
 		{
 			s: `SHOW STATS`,
@@ -1190,9 +1450,12 @@ func TestParser_ParseStatement(t *testing.T) {
 		},
 
 		// Errors
-		{s: ``, err: `found EOF, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
+		{s: ``, err: `found EOF, expected SELECT, EXPLAIN, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
 		{s: `SELECT`, err: `found EOF, expected identifier, string, number, bool at line 1, char 8`},
-		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
+		{s: `blah blah`, err: `found blah, expected SELECT, EXPLAIN, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
+		{s: `SELECT /*+ FULL_SCAN */ value FROM cpu`, err: `unknown hint: FULL_SCAN`},
+		{s: `SELECT /*+ MAX_PARALLEL(0) */ value FROM cpu`, err: `invalid hint: MAX_PARALLEL(0): expected a positive integer`},
+		{s: `EXPLAIN SHOW SHARDS`, err: `found SHOW, expected SELECT at line 1, char 9`},
 		{s: `SELECT field1 X`, err: `found X, expected FROM at line 1, char 15`},
 		{s: `SELECT field1 FROM "series" WHERE X +;`, err: `found ;, expected identifier, string, number, bool at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries GROUP`, err: `found EOF, expected BY at line 1, char 35`},
@@ -1224,6 +1487,13 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `select count() from myseries`, err: `invalid number of arguments for count, expected 1, got 0`},
 		{s: `select derivative() from myseries`, err: `invalid number of arguments for derivative, expected at least 1 but no more than 2, got 0`},
 		{s: `select derivative(mean(value), 1h, 3) from myseries`, err: `invalid number of arguments for derivative, expected at least 1 but no more than 2, got 3`},
+		{s: `select ewma(mean(value)) from myseries`, err: `invalid number of arguments for ewma, expected 2, got 1`},
+		{s: `select ewma(value, 0.5) from myseries`, err: `ewma requires an aggregate argument`},
+		{s: `select ewma(mean(value), 1.5) from myseries`, err: `ewma smoothing factor must be greater than 0 and at most 1`},
+		{s: `select ewma(mean(value), 0.5) * 2 from myseries`, err: `ewma cannot be used in an expression`},
+		{s: `select outlier(mean(value), 'a') from myseries`, err: `outlier requires a number as the second argument`},
+		{s: `select outlier(mean(value), 0) from myseries`, err: `outlier threshold must be greater than 0`},
+		{s: `select derivative(ewma(mean(value), 0.5)) from myseries`, err: `ewma cannot be used as an argument of derivative`},
 		{s: `DELETE`, err: `found EOF, expected FROM at line 1, char 8`},
 		{s: `DELETE FROM`, err: `found EOF, expected identifier at line 1, char 13`},
 		{s: `DELETE FROM myseries WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
@@ -1234,7 +1504,12 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SHOW CONTINUOUS`, err: `found EOF, expected QUERIES at line 1, char 17`},
 		{s: `SHOW RETENTION`, err: `found EOF, expected POLICIES at line 1, char 16`},
 		{s: `SHOW RETENTION POLICIES`, err: `found EOF, expected identifier at line 1, char 25`},
-		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, FIELD, GRANTS, MEASUREMENTS, RETENTION, SERIES, SERVERS, TAG, USERS at line 1, char 6`},
+		{s: `SHOW SCHEMA ON`, err: `found EOF, expected identifier at line 1, char 16`},
+		{s: `SHOW SCHEMA FROM`, err: `found EOF, expected identifier at line 1, char 18`},
+		{s: `SHOW FOO`, err: `found FOO, expected API, CONTINUOUS, DATABASES, FIELD, GRANTS, LIMITS, MEASUREMENT, MEASUREMENTS, RETENTION, ROLES, SCHEMA, SERIES, SERVERS, SHARD, SHARDS, TAG, USERS at line 1, char 6`},
+		{s: `SHOW SHARD`, err: `found EOF, expected GROUPS at line 1, char 12`},
+		{s: `SHOW MEASUREMENT`, err: `found EOF, expected CARDINALITY at line 1, char 18`},
+		{s: `SHOW TAG VALUES CARDINALITY`, err: `found EOF, expected WITH at line 1, char 29`},
 		{s: `SHOW STATS ON`, err: `found EOF, expected string at line 1, char 15`},
 		{s: `SHOW GRANTS`, err: `found EOF, expected FOR at line 1, char 13`},
 		{s: `SHOW GRANTS FOR`, err: `found EOF, expected identifier at line 1, char 17`},
@@ -1251,6 +1526,24 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `DROP RETENTION POLICY "1h.cpu"`, err: `found EOF, expected ON at line 1, char 31`},
 		{s: `DROP RETENTION POLICY "1h.cpu" ON`, err: `found EOF, expected identifier at line 1, char 35`},
 		{s: `DROP USER`, err: `found EOF, expected identifier at line 1, char 11`},
+		{s: `CREATE ROLE`, err: `found EOF, expected identifier at line 1, char 13`},
+		{s: `DROP ROLE`, err: `found EOF, expected identifier at line 1, char 11`},
+		{s: `GRANT ROLE`, err: `found EOF, expected identifier at line 1, char 12`},
+		{s: `GRANT ROLE analysts`, err: `found EOF, expected TO at line 1, char 21`},
+		{s: `GRANT ROLE analysts TO`, err: `found EOF, expected identifier at line 1, char 24`},
+		{s: `GRANT READ ON testdb TO ROLE`, err: `found EOF, expected identifier at line 1, char 30`},
+		{s: `GRANT ALL PRIVILEGES TO ROLE analysts`, err: `role privileges must be on a database at line 1, char 25`},
+		{s: `REVOKE ROLE analysts`, err: `found EOF, expected FROM at line 1, char 22`},
+		{s: `REVOKE ROLE analysts FROM`, err: `found EOF, expected identifier at line 1, char 27`},
+		{s: `REVOKE ALL FROM ROLE analysts`, err: `role privileges must be on a database at line 1, char 17`},
+		{s: `CREATE API`, err: `found EOF, expected KEY at line 1, char 12`},
+		{s: `CREATE API KEY`, err: `found EOF, expected identifier at line 1, char 16`},
+		{s: `CREATE API KEY telegraf`, err: `found EOF, expected WITH at line 1, char 25`},
+		{s: `CREATE API KEY telegraf WITH`, err: `found EOF, expected READ, WRITE, ALL [PRIVILEGES] at line 1, char 30`},
+		{s: `CREATE API KEY telegraf WITH WRITE`, err: `found EOF, expected ON at line 1, char 36`},
+		{s: `CREATE API KEY telegraf WITH WRITE ON db0,`, err: `found EOF, expected READ, WRITE, ALL [PRIVILEGES] at line 1, char 43`},
+		{s: `DROP API KEY`, err: `found EOF, expected identifier at line 1, char 14`},
+		{s: `SHOW API`, err: `found EOF, expected KEYS at line 1, char 10`},
 		{s: `CREATE USER testuser`, err: `found EOF, expected WITH at line 1, char 22`},
 		{s: `CREATE USER testuser WITH`, err: `found EOF, expected PASSWORD at line 1, char 27`},
 		{s: `CREATE USER testuser WITH PASSWORD`, err: `found EOF, expected string at line 1, char 36`},
@@ -1281,18 +1574,37 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 3.14`, err: `number must be an integer at line 1, char 67`},
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 0`, err: `invalid value 0: must be 1 <= n <= 2147483647 at line 1, char 67`},
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION bad`, err: `found bad, expected number at line 1, char 67`},
-		{s: `ALTER`, err: `found EOF, expected RETENTION at line 1, char 7`},
+		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 1 SHARD`, err: `found EOF, expected DURATION at line 1, char 75`},
+		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 1 SHARD DURATION`, err: `found EOF, expected duration at line 1, char 84`},
+		{s: `ALTER`, err: `found EOF, expected DATABASE, RETENTION, USER at line 1, char 7`},
 		{s: `ALTER RETENTION`, err: `found EOF, expected POLICY at line 1, char 17`},
 		{s: `ALTER RETENTION POLICY`, err: `found EOF, expected identifier at line 1, char 24`},
 		{s: `ALTER RETENTION POLICY policy1`, err: `found EOF, expected ON at line 1, char 32`}, {s: `ALTER RETENTION POLICY policy1 ON`, err: `found EOF, expected identifier at line 1, char 35`},
-		{s: `ALTER RETENTION POLICY policy1 ON testdb`, err: `found EOF, expected DURATION, RETENTION, DEFAULT at line 1, char 42`},
-		{s: `SET`, err: `found EOF, expected PASSWORD at line 1, char 5`},
+		{s: `ALTER RETENTION POLICY policy1 ON testdb`, err: `found EOF, expected DURATION, RETENTION, SHARD, DEFAULT at line 1, char 42`},
+		{s: `ALTER RETENTION POLICY policy1 ON testdb SHARD 1d`, err: `found 1d, expected DURATION at line 1, char 48`},
+		{s: `ALTER RETENTION POLICY policy1 ON testdb SHARD DURATION INF`, err: `found INF, expected duration at line 1, char 57`},
+		{s: `SET`, err: `found EOF, expected LIMIT, PASSWORD, READ at line 1, char 5`},
+		{s: `SET READ`, err: `found EOF, expected ONLY at line 1, char 10`},
+		{s: `SET READ ONLY`, err: `found EOF, expected = at line 1, char 15`},
+		{s: `SET READ ONLY = 1`, err: `found 1, expected TRUE, FALSE at line 1, char 17`},
+		{s: `SET LIMIT`, err: `found EOF, expected identifier at line 1, char 11`},
+		{s: `SET LIMIT max_databases`, err: `found EOF, expected = at line 1, char 25`},
+		{s: `SET LIMIT max_databases = -1`, err: `invalid value -1: must be 0 <= n <= 2147483647 at line 1, char 27`},
+		{s: `SET LIMIT max_databases = 1.5`, err: `number must be an integer at line 1, char 27`},
 		{s: `SET PASSWORD`, err: `found EOF, expected FOR at line 1, char 14`},
 		{s: `SET PASSWORD something`, err: `found something, expected FOR at line 1, char 14`},
 		{s: `SET PASSWORD FOR`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `SET PASSWORD FOR dejan`, err: `found EOF, expected = at line 1, char 24`},
 		{s: `SET PASSWORD FOR dejan =`, err: `found EOF, expected string at line 1, char 25`},
 		{s: `SET PASSWORD FOR dejan = bla`, err: `found bla, expected string at line 1, char 26`},
+		{s: `ALTER FOO`, err: `found FOO, expected DATABASE, RETENTION, USER at line 1, char 7`},
+		{s: `ALTER DATABASE`, err: `found EOF, expected identifier at line 1, char 16`},
+		{s: `ALTER DATABASE db0`, err: `found EOF, expected RENAME at line 1, char 20`},
+		{s: `ALTER DATABASE db0 RENAME`, err: `found EOF, expected TO at line 1, char 27`},
+		{s: `ALTER DATABASE db0 RENAME TO`, err: `found EOF, expected identifier at line 1, char 30`},
+		{s: `ALTER USER`, err: `found EOF, expected identifier at line 1, char 12`},
+		{s: `ALTER USER dejan`, err: `found EOF, expected EXPIRE at line 1, char 18`},
+		{s: `ALTER USER dejan EXPIRE`, err: `found EOF, expected PASSWORD at line 1, char 25`},
 	}
 
 	for i, tt := range tests {
@@ -1642,6 +1954,16 @@ func TestDropSeriesStatement_String(t *testing.T) {
 				},
 			},
 		},
+		{
+			s: `CREATE API KEY telegraf WITH WRITE ON db0, READ ON db1`,
+			stmt: &influxql.CreateAPIKeyStatement{
+				Name: "telegraf",
+				Privileges: map[string]influxql.Privilege{
+					"db1": influxql.ReadPrivilege,
+					"db0": influxql.WritePrivilege,
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
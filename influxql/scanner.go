@@ -59,7 +59,7 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 	case '*':
 		return MUL, pos, ""
 	case '/':
-		return DIV, pos, ""
+		return s.scanHint()
 	case '=':
 		if ch1, _ := s.r.read(); ch1 == '~' {
 			return EQREGEX, pos, ""
@@ -100,6 +100,34 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 	return ILLEGAL, pos, string(ch0)
 }
 
+// scanHint consumes a "/*+ ... */" planner hint comment. The literal is the
+// text between the markers. Any other slash is a division operator.
+func (s *Scanner) scanHint() (tok Token, pos Pos, lit string) {
+	_, pos = s.r.curr()
+	if ch1, _ := s.r.read(); ch1 != '*' {
+		s.r.unread()
+		return DIV, pos, ""
+	} else if ch2, _ := s.r.read(); ch2 != '+' {
+		s.r.unread()
+		s.r.unread()
+		return DIV, pos, ""
+	}
+
+	var buf bytes.Buffer
+	for {
+		ch, _ := s.r.read()
+		if ch == eof {
+			return ILLEGAL, pos, "/*+" + buf.String()
+		} else if ch == '*' {
+			if ch1, _ := s.r.read(); ch1 == '/' {
+				return HINT, pos, strings.TrimSpace(buf.String())
+			}
+			s.r.unread()
+		}
+		_, _ = buf.WriteRune(ch)
+	}
+}
+
 // scanWhitespace consumes the current rune and all contiguous whitespace.
 func (s *Scanner) scanWhitespace() (tok Token, pos Pos, lit string) {
 	// Create a buffer and read the current character into it.
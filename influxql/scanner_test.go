@@ -34,6 +34,9 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `-`, tok: influxql.SUB},
 		{s: `*`, tok: influxql.MUL},
 		{s: `/`, tok: influxql.DIV},
+		{s: `/*`, tok: influxql.DIV},
+		{s: `/*+ NO_INDEX */`, tok: influxql.HINT, lit: `NO_INDEX`},
+		{s: `/*+ NO_INDEX`, tok: influxql.ILLEGAL, lit: `/*+ NO_INDEX`},
 
 		// Logical operators
 		{s: `AND`, tok: influxql.AND},
@@ -108,10 +111,12 @@ func TestScanner_Scan(t *testing.T) {
 		// Keywords
 		{s: `ALL`, tok: influxql.ALL},
 		{s: `ALTER`, tok: influxql.ALTER},
+		{s: `API`, tok: influxql.API},
 		{s: `AS`, tok: influxql.AS},
 		{s: `ASC`, tok: influxql.ASC},
 		{s: `BEGIN`, tok: influxql.BEGIN},
 		{s: `BY`, tok: influxql.BY},
+		{s: `CARDINALITY`, tok: influxql.CARDINALITY},
 		{s: `CREATE`, tok: influxql.CREATE},
 		{s: `CONTINUOUS`, tok: influxql.CONTINUOUS},
 		{s: `DATABASE`, tok: influxql.DATABASE},
@@ -123,11 +128,13 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `DURATION`, tok: influxql.DURATION},
 		{s: `END`, tok: influxql.END},
 		{s: `EXISTS`, tok: influxql.EXISTS},
+		{s: `EXPIRE`, tok: influxql.EXPIRE},
 		{s: `EXPLAIN`, tok: influxql.EXPLAIN},
 		{s: `FIELD`, tok: influxql.FIELD},
 		{s: `FROM`, tok: influxql.FROM},
 		{s: `GRANT`, tok: influxql.GRANT},
 		{s: `GROUP`, tok: influxql.GROUP},
+		{s: `GROUPS`, tok: influxql.GROUPS},
 		{s: `IF`, tok: influxql.IF},
 		{s: `INNER`, tok: influxql.INNER},
 		{s: `INSERT`, tok: influxql.INSERT},
@@ -135,11 +142,14 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `KEY`, tok: influxql.KEY},
 		{s: `KEYS`, tok: influxql.KEYS},
 		{s: `LIMIT`, tok: influxql.LIMIT},
+		{s: `LIMITS`, tok: influxql.LIMITS},
+		{s: `SHARD`, tok: influxql.SHARD},
 		{s: `SHOW`, tok: influxql.SHOW},
 		{s: `MEASUREMENT`, tok: influxql.MEASUREMENT},
 		{s: `MEASUREMENTS`, tok: influxql.MEASUREMENTS},
 		{s: `OFFSET`, tok: influxql.OFFSET},
 		{s: `ON`, tok: influxql.ON},
+		{s: `ONLY`, tok: influxql.ONLY},
 		{s: `ORDER`, tok: influxql.ORDER},
 		{s: `PASSWORD`, tok: influxql.PASSWORD},
 		{s: `POLICY`, tok: influxql.POLICY},
@@ -148,8 +158,12 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `QUERIES`, tok: influxql.QUERIES},
 		{s: `QUERY`, tok: influxql.QUERY},
 		{s: `READ`, tok: influxql.READ},
+		{s: `RENAME`, tok: influxql.RENAME},
 		{s: `RETENTION`, tok: influxql.RETENTION},
 		{s: `REVOKE`, tok: influxql.REVOKE},
+		{s: `ROLE`, tok: influxql.ROLE},
+		{s: `ROLES`, tok: influxql.ROLES},
+		{s: `SCHEMA`, tok: influxql.SCHEMA},
 		{s: `SELECT`, tok: influxql.SELECT},
 		{s: `SERIES`, tok: influxql.SERIES},
 		{s: `TAG`, tok: influxql.TAG},
@@ -25,6 +25,7 @@ const (
 	FALSE        // false
 	REGEX        // Regular expressions
 	BADREGEX     // `.*
+	HINT         // /*+ NO_INDEX */
 	literal_end
 
 	operator_beg
@@ -57,10 +58,12 @@ const (
 	// Keywords
 	ALL
 	ALTER
+	API
 	AS
 	ASC
 	BEGIN
 	BY
+	CARDINALITY
 	CREATE
 	CONTINUOUS
 	DATABASE
@@ -73,6 +76,7 @@ const (
 	DURATION
 	END
 	EXISTS
+	EXPIRE
 	EXPLAIN
 	FIELD
 	FOR
@@ -80,6 +84,7 @@ const (
 	GRANT
 	GRANTS
 	GROUP
+	GROUPS
 	IF
 	IN
 	INF
@@ -89,10 +94,12 @@ const (
 	KEY
 	KEYS
 	LIMIT
+	LIMITS
 	MEASUREMENT
 	MEASUREMENTS
 	OFFSET
 	ON
+	ONLY
 	ORDER
 	PASSWORD
 	POLICY
@@ -101,13 +108,19 @@ const (
 	QUERIES
 	QUERY
 	READ
+	RENAME
 	REPLICATION
 	RETENTION
 	REVOKE
+	ROLE
+	ROLES
+	SCHEMA
 	SELECT
 	SERIES
 	SERVERS
 	SET
+	SHARD
+	SHARDS
 	SHOW
 	SLIMIT
 	STATS
@@ -138,6 +151,7 @@ var tokens = [...]string{
 	TRUE:         "TRUE",
 	FALSE:        "FALSE",
 	REGEX:        "REGEX",
+	HINT:         "HINT",
 
 	ADD: "+",
 	SUB: "-",
@@ -164,10 +178,12 @@ var tokens = [...]string{
 
 	ALL:          "ALL",
 	ALTER:        "ALTER",
+	API:          "API",
 	AS:           "AS",
 	ASC:          "ASC",
 	BEGIN:        "BEGIN",
 	BY:           "BY",
+	CARDINALITY:  "CARDINALITY",
 	CREATE:       "CREATE",
 	CONTINUOUS:   "CONTINUOUS",
 	DATABASE:     "DATABASE",
@@ -180,6 +196,7 @@ var tokens = [...]string{
 	DURATION:     "DURATION",
 	END:          "END",
 	EXISTS:       "EXISTS",
+	EXPIRE:       "EXPIRE",
 	EXPLAIN:      "EXPLAIN",
 	FIELD:        "FIELD",
 	FOR:          "FOR",
@@ -187,6 +204,7 @@ var tokens = [...]string{
 	GRANT:        "GRANT",
 	GRANTS:       "GRANTS",
 	GROUP:        "GROUP",
+	GROUPS:       "GROUPS",
 	IF:           "IF",
 	IN:           "IN",
 	INF:          "INF",
@@ -196,10 +214,12 @@ var tokens = [...]string{
 	KEY:          "KEY",
 	KEYS:         "KEYS",
 	LIMIT:        "LIMIT",
+	LIMITS:       "LIMITS",
 	MEASUREMENT:  "MEASUREMENT",
 	MEASUREMENTS: "MEASUREMENTS",
 	OFFSET:       "OFFSET",
 	ON:           "ON",
+	ONLY:         "ONLY",
 	ORDER:        "ORDER",
 	PASSWORD:     "PASSWORD",
 	POLICY:       "POLICY",
@@ -208,13 +228,19 @@ var tokens = [...]string{
 	QUERIES:      "QUERIES",
 	QUERY:        "QUERY",
 	READ:         "READ",
+	RENAME:       "RENAME",
 	REPLICATION:  "REPLICATION",
 	RETENTION:    "RETENTION",
 	REVOKE:       "REVOKE",
+	ROLE:         "ROLE",
+	ROLES:        "ROLES",
+	SCHEMA:       "SCHEMA",
 	SELECT:       "SELECT",
 	SERIES:       "SERIES",
 	SERVERS:      "SERVERS",
 	SET:          "SET",
+	SHARD:        "SHARD",
+	SHARDS:       "SHARDS",
 	SHOW:         "SHOW",
 	SLIMIT:       "SLIMIT",
 	SOFFSET:      "SOFFSET",
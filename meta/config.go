@@ -1,9 +1,15 @@
 package meta
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"time"
 
 	"github.com/influxdb/influxdb/toml"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
@@ -22,21 +28,75 @@ const (
 	// DefaultLeaderLeaseTimeout is the default leader lease for the store.
 	DefaultLeaderLeaseTimeout = 500 * time.Millisecond
 
+	// DefaultReadLeaseTimeout is the default amount of time since the last
+	// contact with the leader that a follower serves metadata reads locally.
+	DefaultReadLeaseTimeout = 500 * time.Millisecond
+
 	// DefaultCommitTimeout is the default commit timeout for the store.
 	DefaultCommitTimeout = 50 * time.Millisecond
+
+	// DefaultSnapshotInterval is the default time between checks for whether
+	// the raft log should be snapshotted.
+	DefaultSnapshotInterval = 120 * time.Second
+
+	// DefaultSnapshotThreshold is the default number of log entries since the
+	// last snapshot before a new snapshot is taken.
+	DefaultSnapshotThreshold = 8192
+
+	// DefaultTrailingLogs is the default number of log entries kept after a
+	// snapshot so followers that are slightly behind can catch up.
+	DefaultTrailingLogs = 10240
+
+	// DefaultObserverSyncInterval is the default time between an observer
+	// fetching the metadata from its peers.
+	DefaultObserverSyncInterval = 1 * time.Second
+
+	// DefaultPasswordHashCost is the default bcrypt cost of password hashes.
+	DefaultPasswordHashCost = bcrypt.DefaultCost
 )
 
 // Config represents the meta configuration.
 type Config struct {
 	Dir                 string        `toml:"dir"`
 	Hostname            string        `toml:"hostname"`
+	Zone                string        `toml:"zone"`
+	Rack                string        `toml:"rack"`
 	BindAddress         string        `toml:"bind-address"`
 	Peers               []string      `toml:"peers"`
 	RetentionAutoCreate bool          `toml:"retention-autocreate"`
 	ElectionTimeout     toml.Duration `toml:"election-timeout"`
 	HeartbeatTimeout    toml.Duration `toml:"heartbeat-timeout"`
 	LeaderLeaseTimeout  toml.Duration `toml:"leader-lease-timeout"`
+	ReadLeaseTimeout    toml.Duration `toml:"read-lease-timeout"`
 	CommitTimeout       toml.Duration `toml:"commit-timeout"`
+	SnapshotInterval    toml.Duration `toml:"snapshot-interval"`
+	SnapshotThreshold   uint64        `toml:"snapshot-threshold"`
+	TrailingLogs        uint64        `toml:"trailing-logs"`
+
+	// An observer replicates the metadata from the meta nodes listed in peers
+	// without joining the raft cluster, so it never votes in elections or
+	// counts towards quorum.
+	Observer             bool          `toml:"observer"`
+	ObserverSyncInterval toml.Duration `toml:"observer-sync-interval"`
+
+	// The bcrypt cost of password hashes. Each increment doubles the time to
+	// hash a password. Hashes made with a different cost are replaced the
+	// next time the user authenticates.
+	PasswordHashCost int `toml:"password-hash-cost"`
+
+	// Shared secret that nodes must present on every remote exec connection
+	// to register themselves, change or fetch the metadata. Every node in the
+	// cluster must use the same token. Any node that can reach the meta nodes
+	// is trusted if it's blank.
+	JoinToken string `toml:"join-token"`
+
+	// TLS for raft and remote exec connections between meta nodes. Each node
+	// presents its certificate and requires peers to present one signed by
+	// the CA certificate.
+	TLSEnabled       bool   `toml:"tls-enabled"`
+	TLSCertificate   string `toml:"tls-certificate"`
+	TLSPrivateKey    string `toml:"tls-private-key"`
+	TLSCACertificate string `toml:"tls-ca-certificate"`
 }
 
 func NewConfig() Config {
@@ -47,6 +107,75 @@ func NewConfig() Config {
 		ElectionTimeout:     toml.Duration(DefaultElectionTimeout),
 		HeartbeatTimeout:    toml.Duration(DefaultHeartbeatTimeout),
 		LeaderLeaseTimeout:  toml.Duration(DefaultLeaderLeaseTimeout),
+		ReadLeaseTimeout:    toml.Duration(DefaultReadLeaseTimeout),
 		CommitTimeout:       toml.Duration(DefaultCommitTimeout),
+		SnapshotInterval:    toml.Duration(DefaultSnapshotInterval),
+		SnapshotThreshold:   DefaultSnapshotThreshold,
+		TrailingLogs:        DefaultTrailingLogs,
+
+		ObserverSyncInterval: toml.Duration(DefaultObserverSyncInterval),
+		PasswordHashCost:     DefaultPasswordHashCost,
 	}
 }
+
+// Validate returns an error if the raft timeouts can't be used together.
+// Clusters on high-latency links should raise the election and heartbeat
+// timeouts together, keeping the leader lease no longer than the heartbeat.
+func (c *Config) Validate() error {
+	if c.HeartbeatTimeout <= 0 {
+		return errors.New("heartbeat-timeout must be positive")
+	} else if c.ElectionTimeout <= 0 {
+		return errors.New("election-timeout must be positive")
+	} else if c.LeaderLeaseTimeout <= 0 {
+		return errors.New("leader-lease-timeout must be positive")
+	} else if c.ReadLeaseTimeout < 0 {
+		return errors.New("read-lease-timeout must not be negative")
+	} else if c.CommitTimeout <= 0 {
+		return errors.New("commit-timeout must be positive")
+	} else if c.ElectionTimeout < c.HeartbeatTimeout {
+		return errors.New("election-timeout must not be less than heartbeat-timeout")
+	} else if c.LeaderLeaseTimeout > c.HeartbeatTimeout {
+		return errors.New("leader-lease-timeout must not be greater than heartbeat-timeout")
+	} else if c.ReadLeaseTimeout > c.ElectionTimeout {
+		return errors.New("read-lease-timeout must not be greater than election-timeout")
+	} else if c.TLSEnabled && (c.TLSCertificate == "" || c.TLSPrivateKey == "" || c.TLSCACertificate == "") {
+		return errors.New("tls-certificate, tls-private-key and tls-ca-certificate are required when tls-enabled is set")
+	} else if c.Observer && len(c.Peers) == 0 {
+		return errors.New("peers are required when observer is set")
+	} else if c.Observer && c.ObserverSyncInterval <= 0 {
+		return errors.New("observer-sync-interval must be positive")
+	} else if c.PasswordHashCost < bcrypt.MinCost || c.PasswordHashCost > bcrypt.MaxCost {
+		return fmt.Errorf("password-hash-cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return nil
+}
+
+// TLSConfig returns the TLS configuration for connections between meta
+// nodes. Returns nil if TLS is not enabled.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if !c.TLSEnabled {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.TLSCertificate, c.TLSPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("load tls certificate: %s", err)
+	}
+
+	buf, err := ioutil.ReadFile(c.TLSCACertificate)
+	if err != nil {
+		return nil, fmt.Errorf("read tls ca certificate: %s", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(buf) {
+		return nil, fmt.Errorf("no certificates found in %s", c.TLSCACertificate)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/BurntSushi/toml"
 	"github.com/influxdb/influxdb/meta"
+	itoml "github.com/influxdb/influxdb/toml"
 )
 
 func TestConfig_Parse(t *testing.T) {
@@ -16,7 +17,19 @@ dir = "/tmp/foo"
 election-timeout = "10s"
 heartbeat-timeout = "20s"
 leader-lease-timeout = "30h"
+read-lease-timeout = "35s"
 commit-timeout = "40m"
+snapshot-interval = "50s"
+snapshot-threshold = 100
+trailing-logs = 200
+zone = "us-east"
+rack = "r1"
+tls-enabled = true
+tls-certificate = "/etc/ssl/meta.pem"
+tls-private-key = "/etc/ssl/meta-key.pem"
+tls-ca-certificate = "/etc/ssl/ca.pem"
+password-hash-cost = 12
+join-token = "secret"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -30,7 +43,117 @@ commit-timeout = "40m"
 		t.Fatalf("unexpected heartbeat timeout: %v", c.HeartbeatTimeout)
 	} else if time.Duration(c.LeaderLeaseTimeout) != 30*time.Hour {
 		t.Fatalf("unexpected leader lease timeout: %v", c.LeaderLeaseTimeout)
+	} else if time.Duration(c.ReadLeaseTimeout) != 35*time.Second {
+		t.Fatalf("unexpected read lease timeout: %v", c.ReadLeaseTimeout)
 	} else if time.Duration(c.CommitTimeout) != 40*time.Minute {
 		t.Fatalf("unexpected commit timeout: %v", c.CommitTimeout)
+	} else if time.Duration(c.SnapshotInterval) != 50*time.Second {
+		t.Fatalf("unexpected snapshot interval: %v", c.SnapshotInterval)
+	} else if c.SnapshotThreshold != 100 {
+		t.Fatalf("unexpected snapshot threshold: %d", c.SnapshotThreshold)
+	} else if c.TrailingLogs != 200 {
+		t.Fatalf("unexpected trailing logs: %d", c.TrailingLogs)
+	} else if c.Zone != "us-east" {
+		t.Fatalf("unexpected zone: %s", c.Zone)
+	} else if c.Rack != "r1" {
+		t.Fatalf("unexpected rack: %s", c.Rack)
+	} else if !c.TLSEnabled {
+		t.Fatal("expected tls to be enabled")
+	} else if c.TLSCertificate != "/etc/ssl/meta.pem" {
+		t.Fatalf("unexpected tls certificate: %s", c.TLSCertificate)
+	} else if c.TLSPrivateKey != "/etc/ssl/meta-key.pem" {
+		t.Fatalf("unexpected tls private key: %s", c.TLSPrivateKey)
+	} else if c.TLSCACertificate != "/etc/ssl/ca.pem" {
+		t.Fatalf("unexpected tls ca certificate: %s", c.TLSCACertificate)
+	} else if c.PasswordHashCost != 12 {
+		t.Fatalf("unexpected password hash cost: %d", c.PasswordHashCost)
+	} else if c.JoinToken != "secret" {
+		t.Fatalf("unexpected join token: %s", c.JoinToken)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := meta.NewConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Leader lease must not exceed the heartbeat timeout.
+	c.LeaderLeaseTimeout = itoml.Duration(2 * time.Second)
+	if err := c.Validate(); err == nil || err.Error() != "leader-lease-timeout must not be greater than heartbeat-timeout" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Election timeout must not be less than the heartbeat timeout.
+	c = meta.NewConfig()
+	c.HeartbeatTimeout = itoml.Duration(5 * time.Second)
+	if err := c.Validate(); err == nil || err.Error() != "election-timeout must not be less than heartbeat-timeout" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// WAN-sized timeouts are valid.
+	c = meta.NewConfig()
+	c.ElectionTimeout = itoml.Duration(10 * time.Second)
+	c.HeartbeatTimeout = itoml.Duration(5 * time.Second)
+	c.LeaderLeaseTimeout = itoml.Duration(2500 * time.Millisecond)
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c.CommitTimeout = 0
+	if err := c.Validate(); err == nil || err.Error() != "commit-timeout must be positive" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A follower must not serve reads after a new leader could be elected.
+	c = meta.NewConfig()
+	c.ReadLeaseTimeout = itoml.Duration(2 * time.Second)
+	if err := c.Validate(); err == nil || err.Error() != "read-lease-timeout must not be greater than election-timeout" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// TLS requires certificates.
+	c = meta.NewConfig()
+	c.TLSEnabled = true
+	c.TLSCertificate = "/etc/ssl/meta.pem"
+	if err := c.Validate(); err == nil || err.Error() != "tls-certificate, tls-private-key and tls-ca-certificate are required when tls-enabled is set" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Observers require peers to replicate from.
+	c = meta.NewConfig()
+	c.Observer = true
+	if err := c.Validate(); err == nil || err.Error() != "peers are required when observer is set" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.Peers = []string{"localhost:8088"}
+	c.ObserverSyncInterval = 0
+	if err := c.Validate(); err == nil || err.Error() != "observer-sync-interval must be positive" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Password hash cost must be one bcrypt supports.
+	c = meta.NewConfig()
+	c.PasswordHashCost = 2
+	if err := c.Validate(); err == nil || err.Error() != "password-hash-cost must be between 4 and 31" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure no TLS configuration is returned when TLS is disabled.
+func TestConfig_TLSConfig_Disabled(t *testing.T) {
+	c := meta.NewConfig()
+	if tc, err := c.TLSConfig(); err != nil {
+		t.Fatal(err)
+	} else if tc != nil {
+		t.Fatalf("unexpected tls config: %#v", tc)
+	}
+
+	// Missing files are reported.
+	c.TLSEnabled = true
+	c.TLSCertificate, c.TLSPrivateKey, c.TLSCACertificate = "/no/cert", "/no/key", "/no/ca"
+	if _, err := c.TLSConfig(); err == nil {
+		t.Fatal("expected error")
 	}
 }
@@ -1,11 +1,15 @@
 package meta
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta/internal"
+	"golang.org/x/crypto/bcrypt"
 )
 
 //go:generate protoc --gogo_out=. internal/meta.proto
@@ -33,6 +37,22 @@ type Data struct {
 	MaxNodeID       uint64
 	MaxShardGroupID uint64
 	MaxShardID      uint64
+
+	Snapshots     []SnapshotInfo
+	MaxSnapshotID uint64
+
+	APIKeys []APIKeyInfo
+	Roles   []RoleInfo
+
+	Limits Limits
+
+	// PasswordHashCost is the bcrypt cost of new password hashes across the
+	// cluster. Zero means each node uses its configured cost.
+	PasswordHashCost int
+
+	// ReadOnly is set while the cluster is in maintenance. Metadata changes
+	// other than those needed to write data are rejected.
+	ReadOnly bool
 }
 
 // Node returns a node by id.
@@ -72,10 +92,21 @@ func (data *Data) CreateNode(host string) error {
 	return nil
 }
 
-// DeleteNode removes a node from the metadata.
-func (data *Data) DeleteNode(id uint64) error {
+// DeleteNode removes a node from the metadata. A node cannot be removed
+// while it owns shards in shard groups that have not been deleted unless
+// force is set. Forcing the removal of a node drops it from the owners of
+// its shards; shards it was the only owner of are given to the remaining
+// node that owns the fewest shards. The data on the node is not moved.
+func (data *Data) DeleteNode(id uint64, force bool) error {
 	for i := range data.Nodes {
 		if data.Nodes[i].ID == id {
+			if data.ownsShards(id) {
+				if !force {
+					return ErrNodeOwnsShards
+				} else if err := data.disownShards(id); err != nil {
+					return err
+				}
+			}
 			data.Nodes = append(data.Nodes[:i], data.Nodes[i+1:]...)
 			return nil
 		}
@@ -83,6 +114,164 @@ func (data *Data) DeleteNode(id uint64) error {
 	return ErrNodeNotFound
 }
 
+// SetNodeDraining marks a node as draining, or no longer draining. New shards
+// are not assigned to draining nodes.
+func (data *Data) SetNodeDraining(id uint64, draining bool) error {
+	ni := data.Node(id)
+	if ni == nil {
+		return ErrNodeNotFound
+	}
+	ni.Draining = draining
+	return nil
+}
+
+// SetNodeZone sets the zone and rack of a node.
+func (data *Data) SetNodeZone(id uint64, zone, rack string) error {
+	ni := data.Node(id)
+	if ni == nil {
+		return ErrNodeNotFound
+	}
+	ni.Zone, ni.Rack = zone, rack
+	return nil
+}
+
+// ReassignShard replaces one owner of a shard with another node.
+func (data *Data) ReassignShard(shardID, oldOwnerID, newOwnerID uint64) error {
+	if data.Node(newOwnerID) == nil {
+		return ErrNodeNotFound
+	}
+
+	for i := range data.Databases {
+		dbi := &data.Databases[i]
+		for j := range dbi.RetentionPolicies {
+			rpi := &dbi.RetentionPolicies[j]
+			for k := range rpi.ShardGroups {
+				sgi := &rpi.ShardGroups[k]
+				if sgi.Deleted() {
+					continue
+				}
+				for l := range sgi.Shards {
+					if si := &sgi.Shards[l]; si.ID == shardID {
+						return si.reassign(oldOwnerID, newOwnerID)
+					}
+				}
+			}
+		}
+	}
+	return ErrShardNotFound
+}
+
+// ownsShards returns true if a node owns a shard in a shard group that has
+// not been deleted.
+func (data *Data) ownsShards(nodeID uint64) bool {
+	for _, dbi := range data.Databases {
+		for _, rpi := range dbi.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					if si.OwnedBy(nodeID) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// disownShards removes a node from the owners of every shard in a shard group
+// that has not been deleted. A shard left without owners is assigned to the
+// node owning the fewest shards, preferring nodes that are not draining.
+func (data *Data) disownShards(nodeID uint64) error {
+	// Count the shards owned by each of the other nodes.
+	counts := make(map[uint64]int)
+	for _, ni := range data.Nodes {
+		if ni.ID != nodeID {
+			counts[ni.ID] = 0
+		}
+	}
+	if len(counts) == 0 {
+		return ErrNodesRequired
+	}
+	data.walkShards(func(si *ShardInfo) {
+		for _, id := range si.OwnerIDs {
+			if _, ok := counts[id]; ok {
+				counts[id]++
+			}
+		}
+	})
+
+	data.walkShards(func(si *ShardInfo) {
+		for i, id := range si.OwnerIDs {
+			if id != nodeID {
+				continue
+			}
+
+			// Remove the owner unless it's the last one, in which case
+			// replace it with the least loaded node.
+			if len(si.OwnerIDs) > 1 {
+				si.OwnerIDs = append(si.OwnerIDs[:i], si.OwnerIDs[i+1:]...)
+			} else {
+				newOwnerID := data.leastLoadedNode(counts)
+				si.OwnerIDs[i] = newOwnerID
+				counts[newOwnerID]++
+			}
+			return
+		}
+	})
+	return nil
+}
+
+// leastLoadedNode returns the node in counts with the fewest shards. Nodes
+// that are not draining are preferred and ties go to the lowest node id.
+func (data *Data) leastLoadedNode(counts map[uint64]int) uint64 {
+	var id uint64
+	var draining bool
+	for _, ni := range data.Nodes {
+		n, ok := counts[ni.ID]
+		if !ok {
+			continue
+		} else if id == 0 || (draining && !ni.Draining) || (draining == ni.Draining && n < counts[id]) {
+			id, draining = ni.ID, ni.Draining
+		}
+	}
+	return id
+}
+
+// walkShards calls fn with every shard in a shard group that has not been deleted.
+func (data *Data) walkShards(fn func(si *ShardInfo)) {
+	for i := range data.Databases {
+		dbi := &data.Databases[i]
+		for j := range dbi.RetentionPolicies {
+			rpi := &dbi.RetentionPolicies[j]
+			for k := range rpi.ShardGroups {
+				sgi := &rpi.ShardGroups[k]
+				if sgi.Deleted() {
+					continue
+				}
+				for l := range sgi.Shards {
+					fn(&sgi.Shards[l])
+				}
+			}
+		}
+	}
+}
+
+// CreateSnapshot records the manifest of a cluster snapshot and assigns it an id.
+func (data *Data) CreateSnapshot(si *SnapshotInfo) error {
+	if si.Path == "" {
+		return ErrSnapshotPathRequired
+	}
+
+	data.MaxSnapshotID++
+	other := si.clone()
+	other.ID = data.MaxSnapshotID
+	data.Snapshots = append(data.Snapshots, other)
+	return nil
+}
+
 // Database returns a database by name.
 func (data *Data) Database(name string) *DatabaseInfo {
 	for i := range data.Databases {
@@ -100,6 +289,8 @@ func (data *Data) CreateDatabase(name string) error {
 		return ErrDatabaseNameRequired
 	} else if data.Database(name) != nil {
 		return ErrDatabaseExists
+	} else if n := data.Limits.MaxDatabases; n > 0 && len(data.Databases) >= n {
+		return ErrMaxDatabasesExceeded
 	}
 
 	// Append new node.
@@ -119,6 +310,105 @@ func (data *Data) DropDatabase(name string) error {
 	return ErrDatabaseNotFound
 }
 
+// RenameDatabase renames a database. Its retention policies, shard groups
+// and continuous queries move with it. Continuous queries reading from or
+// writing into the database and privileges on it are updated to the new name.
+func (data *Data) RenameDatabase(oldName, newName string) error {
+	di := data.Database(oldName)
+	if di == nil {
+		return ErrDatabaseNotFound
+	} else if newName == "" {
+		return ErrDatabaseNameRequired
+	} else if data.Database(newName) != nil {
+		return ErrDatabaseExists
+	}
+	di.Name = newName
+
+	for i := range data.Databases {
+		for j := range data.Databases[i].ContinuousQueries {
+			cqi := &data.Databases[i].ContinuousQueries[j]
+			cqi.Query = renameQueryDatabase(cqi.Query, oldName, newName)
+		}
+	}
+
+	for i := range data.Users {
+		renamePrivilege(data.Users[i].Privileges, oldName, newName)
+	}
+	for i := range data.Roles {
+		renamePrivilege(data.Roles[i].Privileges, oldName, newName)
+	}
+	for i := range data.APIKeys {
+		renamePrivilege(data.APIKeys[i].Privileges, oldName, newName)
+	}
+
+	return nil
+}
+
+// renameQueryDatabase rewrites the database of a continuous query and of the
+// measurements it reads and writes. Queries that don't parse are unchanged.
+func renameQueryDatabase(query, oldName, newName string) string {
+	stmt, err := influxql.NewParser(strings.NewReader(query)).ParseStatement()
+	if err != nil {
+		return query
+	}
+	cq, ok := stmt.(*influxql.CreateContinuousQueryStatement)
+	if !ok {
+		return query
+	}
+
+	if cq.Database == oldName {
+		cq.Database = newName
+	}
+	influxql.WalkFunc(cq, func(n influxql.Node) {
+		if m, ok := n.(*influxql.Measurement); ok && m.Database == oldName {
+			m.Database = newName
+		}
+	})
+	return cq.String()
+}
+
+// renamePrivilege moves a privilege on a database to its new name.
+func renamePrivilege(privileges map[string]influxql.Privilege, oldName, newName string) {
+	if p, ok := privileges[oldName]; ok {
+		delete(privileges, oldName)
+		privileges[newName] = p
+	}
+}
+
+// UpdateSchema registers the fields and tag keys written to measurements in
+// a database. Returns an error if a field is registered with another type.
+func (data *Data) UpdateSchema(database string, mis []MeasurementInfo) error {
+	di := data.Database(database)
+	if di == nil {
+		return ErrDatabaseNotFound
+	}
+
+	a, err := di.newSchema(mis)
+	if err != nil {
+		return err
+	}
+
+	for _, mi := range a {
+		if existing := di.Measurement(mi.Name); existing != nil {
+			existing.add(mi)
+			continue
+		}
+
+		other := MeasurementInfo{Name: mi.Name}
+		other.add(mi)
+		di.Measurements = append(di.Measurements, other)
+		sort.Sort(measurementInfos(di.Measurements))
+	}
+	return nil
+}
+
+// measurementInfos represents a list of measurements sortable by name.
+type measurementInfos []MeasurementInfo
+
+func (a measurementInfos) Len() int           { return len(a) }
+func (a measurementInfos) Less(i, j int) bool { return a[i].Name < a[j].Name }
+func (a measurementInfos) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
 // RetentionPolicy returns a retention policy for a database by name.
 func (data *Data) RetentionPolicy(database, name string) (*RetentionPolicyInfo, error) {
 	di := data.Database(database)
@@ -150,13 +440,23 @@ func (data *Data) CreateRetentionPolicy(database string, rpi *RetentionPolicyInf
 		return ErrDatabaseNotFound
 	} else if di.RetentionPolicy(rpi.Name) != nil {
 		return ErrRetentionPolicyExists
+	} else if n := data.Limits.MaxRetentionPolicies; n > 0 && len(di.RetentionPolicies) >= n {
+		return ErrMaxRetentionPoliciesExceeded
+	}
+
+	// Use the default shard group duration unless one was given.
+	sgDuration := rpi.ShardGroupDuration
+	if sgDuration == 0 {
+		sgDuration = shardGroupDuration(rpi.Duration)
+	} else if err := validateShardGroupDuration(sgDuration, rpi.Duration); err != nil {
+		return err
 	}
 
 	// Append new policy.
 	di.RetentionPolicies = append(di.RetentionPolicies, RetentionPolicyInfo{
 		Name:               rpi.Name,
 		Duration:           rpi.Duration,
-		ShardGroupDuration: shardGroupDuration(rpi.Duration),
+		ShardGroupDuration: sgDuration,
 		ReplicaN:           rpi.ReplicaN,
 	})
 
@@ -205,7 +505,19 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 		return ErrRetentionPolicyDurationTooLow
 	}
 
-	// Update fields.
+	// Validate a new shard group duration against the resulting policy duration.
+	if rpu.ShardGroupDuration != nil {
+		duration := rpi.Duration
+		if rpu.Duration != nil {
+			duration = *rpu.Duration
+		}
+		if err := validateShardGroupDuration(*rpu.ShardGroupDuration, duration); err != nil {
+			return err
+		}
+	}
+
+	// Update fields. A new shard group duration only applies to shard
+	// groups created after the update.
 	if rpu.Name != nil {
 		rpi.Name = *rpu.Name
 	}
@@ -215,6 +527,9 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 	if rpu.ReplicaN != nil {
 		rpi.ReplicaN = *rpu.ReplicaN
 	}
+	if rpu.ShardGroupDuration != nil {
+		rpi.ShardGroupDuration = *rpu.ShardGroupDuration
+	}
 
 	return nil
 }
@@ -269,8 +584,14 @@ func (data *Data) ShardGroupByTimestamp(database, policy string, timestamp time.
 
 // CreateShardGroup creates a shard group on a database and policy for a given timestamp.
 func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time) error {
-	// Ensure there are nodes in the metadata.
-	if len(data.Nodes) == 0 {
+	// Ensure there are nodes in the metadata that can own new shards.
+	var nodes []NodeInfo
+	for _, ni := range data.Nodes {
+		if !ni.Draining {
+			nodes = append(nodes, ni)
+		}
+	}
+	if len(nodes) == 0 {
 		return ErrNodesRequired
 	}
 
@@ -291,14 +612,14 @@ func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time)
 	replicaN := rpi.ReplicaN
 	if replicaN == 0 {
 		replicaN = 1
-	} else if replicaN > len(data.Nodes) {
-		replicaN = len(data.Nodes)
+	} else if replicaN > len(nodes) {
+		replicaN = len(nodes)
 	}
 
 	// Determine shard count by node count divided by replication factor.
 	// This will ensure nodes will get distributed across nodes evenly and
 	// replicated the correct number of times.
-	shardN := len(data.Nodes) / replicaN
+	shardN := len(nodes) / replicaN
 
 	// Create the shard group.
 	data.MaxShardGroupID++
@@ -316,13 +637,32 @@ func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time)
 
 	// Assign data nodes to shards via round robin.
 	// Start from a repeatably "random" place in the node list.
-	nodeIndex := int(data.Index % uint64(len(data.Nodes)))
+	nodeIndex := int(data.Index % uint64(len(nodes)))
+	pool := make([]NodeInfo, 0, len(nodes))
+	pool = append(pool, nodes[nodeIndex:]...)
+	pool = append(pool, nodes[:nodeIndex]...)
 	for i := range sgi.Shards {
 		si := &sgi.Shards[i]
+		zones := make(map[string]bool, replicaN)
+		racks := make(map[[2]string]bool, replicaN)
 		for j := 0; j < replicaN; j++ {
-			nodeID := data.Nodes[nodeIndex%len(data.Nodes)].ID
-			si.OwnerIDs = append(si.OwnerIDs, nodeID)
-			nodeIndex++
+			// Prefer the next node in a zone the shard isn't in yet, then
+			// the next node in a rack it isn't in yet. Racks are named
+			// within their zone.
+			k, rackFound := 0, false
+			for n := range pool {
+				if !zones[pool[n].Zone] {
+					k = n
+					break
+				} else if !rackFound && !racks[[2]string{pool[n].Zone, pool[n].Rack}] {
+					k, rackFound = n, true
+				}
+			}
+
+			si.OwnerIDs = append(si.OwnerIDs, pool[k].ID)
+			zones[pool[k].Zone] = true
+			racks[[2]string{pool[k].Zone, pool[k].Rack}] = true
+			pool = append(pool[:k], pool[k+1:]...)
 		}
 	}
 
@@ -332,6 +672,44 @@ func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time)
 	return nil
 }
 
+// PrecreateShardGroups creates the shard groups each retention policy needs
+// to cover the time up to to, following its latest shard group. Intervals
+// that end before from are skipped so gaps in the past aren't filled.
+// Policies without shard groups haven't been written to and are skipped.
+func (data *Data) PrecreateShardGroups(from, to time.Time) error {
+	for i := range data.Databases {
+		dbi := &data.Databases[i]
+		for j := range dbi.RetentionPolicies {
+			rpi := &dbi.RetentionPolicies[j]
+
+			// Find the end of the latest shard group.
+			var t time.Time
+			for _, sgi := range rpi.ShardGroups {
+				if !sgi.Deleted() && sgi.EndTime.After(t) {
+					t = sgi.EndTime
+				}
+			}
+			if t.IsZero() {
+				continue
+			} else if t.Before(from) {
+				t = from
+			}
+
+			for t.Before(to) {
+				if sgi := rpi.ShardGroupByTimestamp(t); sgi != nil {
+					t = sgi.EndTime
+					continue
+				}
+				if err := data.CreateShardGroup(dbi.Name, rpi.Name, t); err != nil {
+					return err
+				}
+				t = rpi.ShardGroups[len(rpi.ShardGroups)-1].EndTime
+			}
+		}
+	}
+	return nil
+}
+
 // DeleteShardGroup removes a shard group from a database and retention policy by id.
 func (data *Data) DeleteShardGroup(database, policy string, id uint64) error {
 	// Find retention policy.
@@ -353,6 +731,49 @@ func (data *Data) DeleteShardGroup(database, policy string, id uint64) error {
 	return ErrShardGroupNotFound
 }
 
+// PruneShardGroups removes the shard groups deleted before expiration.
+// Deleted shard groups are kept for a while so every node sees the deletion
+// and removes its shards.
+func (data *Data) PruneShardGroups(expiration time.Time) {
+	for i := range data.Databases {
+		for j := range data.Databases[i].RetentionPolicies {
+			rpi := &data.Databases[i].RetentionPolicies[j]
+
+			groups := make([]ShardGroupInfo, 0, len(rpi.ShardGroups))
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() && sgi.DeletedAt.Before(expiration) {
+					continue
+				}
+				groups = append(groups, sgi)
+			}
+			rpi.ShardGroups = groups
+		}
+	}
+}
+
+// SetMeasurementTTL sets how long the points of a measurement are kept in a
+// retention policy. A zero TTL keeps them for the policy's duration.
+func (data *Data) SetMeasurementTTL(database, policy, measurement string, ttl time.Duration) error {
+	rpi, err := data.RetentionPolicy(database, policy)
+	if err != nil {
+		return err
+	} else if ttl < 0 {
+		return ErrMeasurementTTLInvalid
+	}
+
+	ttls := make([]MeasurementTTLInfo, 0, len(rpi.MeasurementTTLs)+1)
+	for _, m := range rpi.MeasurementTTLs {
+		if m.Name != measurement {
+			ttls = append(ttls, m)
+		}
+	}
+	if ttl > 0 {
+		ttls = append(ttls, MeasurementTTLInfo{Name: measurement, TTL: ttl})
+	}
+	rpi.MeasurementTTLs = ttls
+	return nil
+}
+
 // CreateContinuousQuery adds a named continuous query to a database.
 func (data *Data) CreateContinuousQuery(database, name, query string) error {
 	di := data.Database(database)
@@ -433,16 +854,29 @@ func (data *Data) DropUser(name string) error {
 }
 
 // UpdateUser updates the password hash of an existing user.
+// An expired password is replaced by the new one.
 func (data *Data) UpdateUser(name, hash string) error {
 	for i := range data.Users {
 		if data.Users[i].Name == name {
 			data.Users[i].Hash = hash
+			data.Users[i].PasswordExpired = false
 			return nil
 		}
 	}
 	return ErrUserNotFound
 }
 
+// ExpirePassword forces a user to change their password before they can
+// authenticate again.
+func (data *Data) ExpirePassword(name string) error {
+	ui := data.User(name)
+	if ui == nil {
+		return ErrUserNotFound
+	}
+	ui.PasswordExpired = true
+	return nil
+}
+
 // SetPrivilege sets a privilege for a user on a database.
 func (data *Data) SetPrivilege(name, database string, p influxql.Privilege) error {
 	ui := data.User(name)
@@ -468,6 +902,371 @@ func (data *Data) UserPrivileges(name string) (map[string]influxql.Privilege, er
 	return ui.Privileges, nil
 }
 
+// Role returns a role by name.
+func (data *Data) Role(name string) *RoleInfo {
+	for i := range data.Roles {
+		if data.Roles[i].Name == name {
+			return &data.Roles[i]
+		}
+	}
+	return nil
+}
+
+// CreateRole creates a new role without any privileges.
+func (data *Data) CreateRole(name string) error {
+	// Ensure the role doesn't already exist.
+	if name == "" {
+		return ErrRoleNameRequired
+	} else if data.Role(name) != nil {
+		return ErrRoleExists
+	}
+
+	// Append new role.
+	data.Roles = append(data.Roles, RoleInfo{Name: name})
+
+	return nil
+}
+
+// DropRole removes an existing role by name and revokes it from all users.
+func (data *Data) DropRole(name string) error {
+	for i := range data.Roles {
+		if data.Roles[i].Name == name {
+			data.Roles = append(data.Roles[:i], data.Roles[i+1:]...)
+			for j := range data.Users {
+				data.Users[j].revokeRole(name)
+			}
+			return nil
+		}
+	}
+	return ErrRoleNotFound
+}
+
+// SetRolePrivilege sets a privilege for a role on a database.
+func (data *Data) SetRolePrivilege(name, database string, p influxql.Privilege) error {
+	ri := data.Role(name)
+	if ri == nil {
+		return ErrRoleNotFound
+	} else if database == "" {
+		return ErrDatabaseNameRequired
+	}
+
+	if ri.Privileges == nil {
+		ri.Privileges = make(map[string]influxql.Privilege)
+	}
+	ri.Privileges[database] = p
+
+	return nil
+}
+
+// GrantRole adds a role to a user. Granting a role the user already has is a no-op.
+func (data *Data) GrantRole(username, role string) error {
+	ui := data.User(username)
+	if ui == nil {
+		return ErrUserNotFound
+	} else if data.Role(role) == nil {
+		return ErrRoleNotFound
+	}
+
+	for _, name := range ui.Roles {
+		if name == role {
+			return nil
+		}
+	}
+	ui.Roles = append(ui.Roles, role)
+
+	return nil
+}
+
+// RevokeRole removes a role from a user.
+func (data *Data) RevokeRole(username, role string) error {
+	ui := data.User(username)
+	if ui == nil {
+		return ErrUserNotFound
+	} else if data.Role(role) == nil {
+		return ErrRoleNotFound
+	}
+	ui.revokeRole(role)
+	return nil
+}
+
+// RolePrivileges returns the combined privileges of a set of roles. Where
+// roles grant different privileges on a database the highest is used.
+func (data *Data) RolePrivileges(roles []string) map[string]influxql.Privilege {
+	m := make(map[string]influxql.Privilege)
+	for _, name := range roles {
+		ri := data.Role(name)
+		if ri == nil {
+			continue
+		}
+		for database, p := range ri.Privileges {
+			if p > m[database] {
+				m[database] = p
+			}
+		}
+	}
+	return m
+}
+
+// userWithRoles returns ui with the privileges of its roles attached so they
+// are used when authorizing the user. A copy is returned if ui has roles.
+func (data *Data) userWithRoles(ui *UserInfo) *UserInfo {
+	if len(ui.Roles) == 0 {
+		return ui
+	}
+	other := ui.clone()
+	other.rolePrivileges = data.RolePrivileges(ui.Roles)
+	return &other
+}
+
+// APIKey returns an API key by name.
+func (data *Data) APIKey(name string) *APIKeyInfo {
+	for i := range data.APIKeys {
+		if data.APIKeys[i].Name == name {
+			return &data.APIKeys[i]
+		}
+	}
+	return nil
+}
+
+// CreateAPIKey creates a new API key with a set of privileges.
+func (data *Data) CreateAPIKey(name, hash string, privileges map[string]influxql.Privilege) error {
+	// Ensure the key doesn't already exist and grants something.
+	if name == "" {
+		return ErrAPIKeyNameRequired
+	} else if data.APIKey(name) != nil {
+		return ErrAPIKeyExists
+	} else if len(privileges) == 0 {
+		return ErrAPIKeyPrivilegesRequired
+	}
+
+	// Append new key.
+	ak := APIKeyInfo{
+		Name:       name,
+		Hash:       hash,
+		Privileges: make(map[string]influxql.Privilege, len(privileges)),
+	}
+	for database, p := range privileges {
+		ak.Privileges[database] = p
+	}
+	data.APIKeys = append(data.APIKeys, ak)
+
+	return nil
+}
+
+// DropAPIKey removes an existing API key by name.
+func (data *Data) DropAPIKey(name string) error {
+	for i := range data.APIKeys {
+		if data.APIKeys[i].Name == name {
+			data.APIKeys = append(data.APIKeys[:i], data.APIKeys[i+1:]...)
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+// Check returns a description of every inconsistency in the metadata:
+// shard groups without shards, shards without owners or owned by nodes that
+// no longer exist, shard and shard group ids above the max ids, default
+// retention policies that don't exist and roles granted to users that don't
+// exist. Shard groups that have been deleted are only checked for their ids.
+func (data *Data) Check() []string {
+	return data.check(false)
+}
+
+// Repair fixes the inconsistencies reported by Check. Shard groups without
+// shards are removed, owners that no longer exist are removed from shards and
+// shards left without owners are assigned to the node owning the fewest
+// shards. Returns the inconsistencies that could not be repaired.
+func (data *Data) Repair() []string {
+	data.check(true)
+	return data.check(false)
+}
+
+// check finds the inconsistencies in the metadata and fixes them if repair is set.
+func (data *Data) check(repair bool) []string {
+	var a []string
+
+	// Count the shards owned by each node so shards without owners can be
+	// assigned to the least loaded node.
+	counts := make(map[uint64]int)
+	for _, ni := range data.Nodes {
+		counts[ni.ID] = 0
+	}
+	data.walkShards(func(si *ShardInfo) {
+		for _, id := range si.OwnerIDs {
+			if _, ok := counts[id]; ok {
+				counts[id]++
+			}
+		}
+	})
+
+	for i := range data.Databases {
+		dbi := &data.Databases[i]
+		if dbi.DefaultRetentionPolicy != "" && dbi.RetentionPolicy(dbi.DefaultRetentionPolicy) == nil {
+			a = append(a, fmt.Sprintf("database %s: default retention policy %s not found", dbi.Name, dbi.DefaultRetentionPolicy))
+			if repair {
+				dbi.DefaultRetentionPolicy = ""
+			}
+		}
+
+		for j := range dbi.RetentionPolicies {
+			rpi := &dbi.RetentionPolicies[j]
+			for k := 0; k < len(rpi.ShardGroups); k++ {
+				sgi := &rpi.ShardGroups[k]
+				if sgi.ID > data.MaxShardGroupID {
+					a = append(a, fmt.Sprintf("shard group %d: id greater than max shard group id %d", sgi.ID, data.MaxShardGroupID))
+					if repair {
+						data.MaxShardGroupID = sgi.ID
+					}
+				}
+				for _, si := range sgi.Shards {
+					if si.ID > data.MaxShardID {
+						a = append(a, fmt.Sprintf("shard %d: id greater than max shard id %d", si.ID, data.MaxShardID))
+						if repair {
+							data.MaxShardID = si.ID
+						}
+					}
+				}
+				if sgi.Deleted() {
+					continue
+				}
+
+				// Remove shard groups that have no shards to write to.
+				if len(sgi.Shards) == 0 {
+					a = append(a, fmt.Sprintf("database %s, retention policy %s: shard group %d has no shards", dbi.Name, rpi.Name, sgi.ID))
+					if repair {
+						rpi.ShardGroups = append(rpi.ShardGroups[:k], rpi.ShardGroups[k+1:]...)
+						k--
+					}
+					continue
+				}
+
+				for l := range sgi.Shards {
+					si := &sgi.Shards[l]
+					if len(si.OwnerIDs) == 0 {
+						a = append(a, fmt.Sprintf("shard %d: no owners", si.ID))
+					}
+
+					var owners []uint64
+					for _, id := range si.OwnerIDs {
+						if data.Node(id) == nil {
+							a = append(a, fmt.Sprintf("shard %d: owner node %d not found", si.ID, id))
+							continue
+						}
+						owners = append(owners, id)
+					}
+					if !repair || len(owners) == len(si.OwnerIDs) && len(owners) > 0 {
+						continue
+					}
+
+					// Replace the owners with the ones that exist. If none
+					// do then use the least loaded node, if there is one.
+					if len(owners) == 0 {
+						id := data.leastLoadedNode(counts)
+						if id == 0 {
+							continue
+						}
+						owners = append(owners, id)
+						counts[id]++
+					}
+					si.OwnerIDs = owners
+				}
+			}
+		}
+	}
+
+	for i := range data.Users {
+		ui := &data.Users[i]
+		for _, role := range append([]string(nil), ui.Roles...) {
+			if data.Role(role) == nil {
+				a = append(a, fmt.Sprintf("user %s: role %s not found", ui.Name, role))
+				if repair {
+					ui.revokeRole(role)
+				}
+			}
+		}
+	}
+
+	return a
+}
+
+// SetLimit sets a cluster limit by name. A zero value removes the limit.
+// Existing resources above a new limit are kept but no more can be created.
+func (data *Data) SetLimit(name string, value int) error {
+	if value < 0 {
+		return ErrLimitInvalid
+	}
+
+	switch name {
+	case LimitMaxDatabases:
+		data.Limits.MaxDatabases = value
+	case LimitMaxRetentionPolicies:
+		data.Limits.MaxRetentionPolicies = value
+	case LimitMaxSeriesPerDatabase:
+		data.Limits.MaxSeriesPerDatabase = value
+	default:
+		return ErrLimitNotFound
+	}
+	return nil
+}
+
+// SetPasswordHashCost sets the bcrypt cost of new password hashes across the
+// cluster. A zero cost removes it.
+func (data *Data) SetPasswordHashCost(cost int) error {
+	if cost != 0 && (cost < bcrypt.MinCost || cost > bcrypt.MaxCost) {
+		return ErrPasswordHashCostInvalid
+	}
+	data.PasswordHashCost = cost
+	return nil
+}
+
+// Replicate overwrites the databases, retention policies, continuous queries,
+// users, roles, API keys, limits and password hash cost with the ones in other, the metadata of
+// another cluster. Nodes and snapshots are kept, as are the shard groups of
+// retention policies that exist in both, since shards belong to the local
+// cluster. other is not copied and must not be used afterwards.
+func (data *Data) Replicate(other *Data) {
+	for i := range other.Databases {
+		di := &other.Databases[i]
+		for j := range di.RetentionPolicies {
+			rpi := &di.RetentionPolicies[j]
+			rpi.ShardGroups = nil
+			if local, _ := data.RetentionPolicy(di.Name, rpi.Name); local != nil {
+				rpi.ShardGroups = local.ShardGroups
+			}
+		}
+	}
+
+	data.Databases = other.Databases
+	data.Users = other.Users
+	data.Roles = other.Roles
+	data.APIKeys = other.APIKeys
+	data.Limits = other.Limits
+	data.PasswordHashCost = other.PasswordHashCost
+}
+
+// withoutHashes returns a copy of data without the password and API key
+// hashes, for nodes that don't authenticate users themselves. The copy
+// shares everything else with data and must not be modified.
+func (data *Data) withoutHashes() *Data {
+	other := *data
+	if data.Users != nil {
+		other.Users = make([]UserInfo, len(data.Users))
+		for i, u := range data.Users {
+			u.Hash = ""
+			other.Users[i] = u
+		}
+	}
+	if data.APIKeys != nil {
+		other.APIKeys = make([]APIKeyInfo, len(data.APIKeys))
+		for i, ak := range data.APIKeys {
+			ak.Hash = ""
+			other.APIKeys[i] = ak
+		}
+	}
+	return &other
+}
+
 // Clone returns a copy of data with a new version.
 func (data *Data) Clone() *Data {
 	other := *data
@@ -496,12 +1295,37 @@ func (data *Data) Clone() *Data {
 		}
 	}
 
+	// Copy snapshots.
+	if data.Snapshots != nil {
+		other.Snapshots = make([]SnapshotInfo, len(data.Snapshots))
+		for i := range data.Snapshots {
+			other.Snapshots[i] = data.Snapshots[i].clone()
+		}
+	}
+
+	// Copy API keys.
+	if data.APIKeys != nil {
+		other.APIKeys = make([]APIKeyInfo, len(data.APIKeys))
+		for i := range data.APIKeys {
+			other.APIKeys[i] = data.APIKeys[i].clone()
+		}
+	}
+
+	// Copy roles.
+	if data.Roles != nil {
+		other.Roles = make([]RoleInfo, len(data.Roles))
+		for i := range data.Roles {
+			other.Roles[i] = data.Roles[i].clone()
+		}
+	}
+
 	return &other
 }
 
 // marshal serializes to a protobuf representation.
 func (data *Data) marshal() *internal.Data {
 	pb := &internal.Data{
+		Version:   proto.Uint64(DataVersion),
 		Term:      proto.Uint64(data.Term),
 		Index:     proto.Uint64(data.Index),
 		ClusterID: proto.Uint64(data.ClusterID),
@@ -509,6 +1333,7 @@ func (data *Data) marshal() *internal.Data {
 		MaxNodeID:       proto.Uint64(data.MaxNodeID),
 		MaxShardGroupID: proto.Uint64(data.MaxShardGroupID),
 		MaxShardID:      proto.Uint64(data.MaxShardID),
+		MaxSnapshotID:   proto.Uint64(data.MaxSnapshotID),
 	}
 
 	pb.Nodes = make([]*internal.NodeInfo, len(data.Nodes))
@@ -526,6 +1351,31 @@ func (data *Data) marshal() *internal.Data {
 		pb.Users[i] = data.Users[i].marshal()
 	}
 
+	for i := range data.Snapshots {
+		pb.Snapshots = append(pb.Snapshots, data.Snapshots[i].marshal())
+	}
+
+	for i := range data.APIKeys {
+		pb.APIKeys = append(pb.APIKeys, data.APIKeys[i].marshal())
+	}
+
+	for i := range data.Roles {
+		pb.Roles = append(pb.Roles, data.Roles[i].marshal())
+	}
+
+	// Limits are only stored once one is set.
+	if data.Limits != (Limits{}) {
+		pb.Limits = data.Limits.marshal()
+	}
+
+	if data.PasswordHashCost != 0 {
+		pb.PasswordHashCost = proto.Int64(int64(data.PasswordHashCost))
+	}
+
+	if data.ReadOnly {
+		pb.ReadOnly = proto.Bool(true)
+	}
+
 	return pb
 }
 
@@ -538,6 +1388,7 @@ func (data *Data) unmarshal(pb *internal.Data) {
 	data.MaxNodeID = pb.GetMaxNodeID()
 	data.MaxShardGroupID = pb.GetMaxShardGroupID()
 	data.MaxShardID = pb.GetMaxShardID()
+	data.MaxSnapshotID = pb.GetMaxSnapshotID()
 
 	data.Nodes = make([]NodeInfo, len(pb.GetNodes()))
 	for i, x := range pb.GetNodes() {
@@ -553,6 +1404,29 @@ func (data *Data) unmarshal(pb *internal.Data) {
 	for i, x := range pb.GetUsers() {
 		data.Users[i].unmarshal(x)
 	}
+
+	// Snapshots were added later so they are left nil when there are none.
+	for _, x := range pb.GetSnapshots() {
+		var si SnapshotInfo
+		si.unmarshal(x)
+		data.Snapshots = append(data.Snapshots, si)
+	}
+
+	for _, x := range pb.GetAPIKeys() {
+		var ak APIKeyInfo
+		ak.unmarshal(x)
+		data.APIKeys = append(data.APIKeys, ak)
+	}
+
+	for _, x := range pb.GetRoles() {
+		var ri RoleInfo
+		ri.unmarshal(x)
+		data.Roles = append(data.Roles, ri)
+	}
+
+	data.Limits.unmarshal(pb.GetLimits())
+	data.PasswordHashCost = int(pb.GetPasswordHashCost())
+	data.ReadOnly = pb.GetReadOnly()
 }
 
 // MarshalBinary encodes the metadata to a binary format.
@@ -561,19 +1435,31 @@ func (data *Data) MarshalBinary() ([]byte, error) {
 }
 
 // UnmarshalBinary decodes the object from a binary format.
+// Metadata written by an earlier release is migrated to the current format.
 func (data *Data) UnmarshalBinary(buf []byte) error {
 	var pb internal.Data
 	if err := proto.Unmarshal(buf, &pb); err != nil {
 		return err
 	}
 	data.unmarshal(&pb)
-	return nil
+	return data.migrate(pb.GetVersion())
 }
 
 // NodeInfo represents information about a single node in the cluster.
 type NodeInfo struct {
 	ID   uint64
 	Host string
+
+	// Draining is set while the node's shards are moved to other nodes.
+	Draining bool
+
+	// Zone is the failure domain of the node, e.g. a datacenter. Replicas
+	// of a shard are placed in different zones when possible.
+	Zone string
+
+	// Rack is the failure domain of the node within its zone. Replicas
+	// in the same zone are placed in different racks when possible.
+	Rack string
 }
 
 // clone returns a deep copy of ni.
@@ -584,6 +1470,15 @@ func (ni NodeInfo) marshal() *internal.NodeInfo {
 	pb := &internal.NodeInfo{}
 	pb.ID = proto.Uint64(ni.ID)
 	pb.Host = proto.String(ni.Host)
+	if ni.Draining {
+		pb.Draining = proto.Bool(true)
+	}
+	if ni.Zone != "" {
+		pb.Zone = proto.String(ni.Zone)
+	}
+	if ni.Rack != "" {
+		pb.Rack = proto.String(ni.Rack)
+	}
 	return pb
 }
 
@@ -591,6 +1486,9 @@ func (ni NodeInfo) marshal() *internal.NodeInfo {
 func (ni *NodeInfo) unmarshal(pb *internal.NodeInfo) {
 	ni.ID = pb.GetID()
 	ni.Host = pb.GetHost()
+	ni.Draining = pb.GetDraining()
+	ni.Zone = pb.GetZone()
+	ni.Rack = pb.GetRack()
 }
 
 // DatabaseInfo represents information about a database in the system.
@@ -599,6 +1497,9 @@ type DatabaseInfo struct {
 	DefaultRetentionPolicy string
 	RetentionPolicies      []RetentionPolicyInfo
 	ContinuousQueries      []ContinuousQueryInfo
+
+	// Measurements is the schema registered by writes, sorted by name.
+	Measurements []MeasurementInfo
 }
 
 // RetentionPolicy returns a retention policy by name.
@@ -630,6 +1531,14 @@ func (di DatabaseInfo) clone() DatabaseInfo {
 		}
 	}
 
+	// Copy measurement schemas.
+	if di.Measurements != nil {
+		other.Measurements = make([]MeasurementInfo, len(di.Measurements))
+		for i := range di.Measurements {
+			other.Measurements[i] = di.Measurements[i].clone()
+		}
+	}
+
 	return other
 }
 
@@ -648,6 +1557,10 @@ func (di DatabaseInfo) marshal() *internal.DatabaseInfo {
 	for i := range di.ContinuousQueries {
 		pb.ContinuousQueries[i] = di.ContinuousQueries[i].marshal()
 	}
+
+	for i := range di.Measurements {
+		pb.Measurements = append(pb.Measurements, di.Measurements[i].marshal())
+	}
 	return pb
 }
 
@@ -665,6 +1578,54 @@ func (di *DatabaseInfo) unmarshal(pb *internal.DatabaseInfo) {
 	for i, x := range pb.GetContinuousQueries() {
 		di.ContinuousQueries[i].unmarshal(x)
 	}
+
+	for _, x := range pb.GetMeasurements() {
+		var mi MeasurementInfo
+		mi.unmarshal(x)
+		di.Measurements = append(di.Measurements, mi)
+	}
+}
+
+// Measurement returns the schema of a measurement by name.
+func (di DatabaseInfo) Measurement(name string) *MeasurementInfo {
+	i := sort.Search(len(di.Measurements), func(i int) bool { return di.Measurements[i].Name >= name })
+	if i < len(di.Measurements) && di.Measurements[i].Name == name {
+		return &di.Measurements[i]
+	}
+	return nil
+}
+
+// newSchema returns the fields and tag keys in mis that aren't registered
+// yet. Returns an error if a field is registered with a different type.
+func (di DatabaseInfo) newSchema(mis []MeasurementInfo) ([]MeasurementInfo, error) {
+	var a []MeasurementInfo
+	for _, mi := range mis {
+		existing := di.Measurement(mi.Name)
+		if existing == nil {
+			a = append(a, mi)
+			continue
+		}
+
+		other := MeasurementInfo{Name: mi.Name}
+		for _, f := range mi.Fields {
+			if fi := existing.Field(f.Name); fi == nil {
+				other.Fields = append(other.Fields, f)
+			} else if fi.Type != f.Type {
+				return nil, fmt.Errorf("field type conflict: field %q on measurement %q is type %s, already exists as type %s",
+					f.Name, mi.Name, f.Type, fi.Type)
+			}
+		}
+		for _, k := range mi.TagKeys {
+			if !existing.HasTagKey(k) {
+				other.TagKeys = append(other.TagKeys, k)
+			}
+		}
+
+		if len(other.Fields) > 0 || len(other.TagKeys) > 0 {
+			a = append(a, other)
+		}
+	}
+	return a, nil
 }
 
 // RetentionPolicyInfo represents metadata about a retention policy.
@@ -674,6 +1635,28 @@ type RetentionPolicyInfo struct {
 	Duration           time.Duration
 	ShardGroupDuration time.Duration
 	ShardGroups        []ShardGroupInfo
+
+	// MeasurementTTLs expire the points of individual measurements before
+	// the policy's duration.
+	MeasurementTTLs []MeasurementTTLInfo
+}
+
+// MeasurementTTLInfo is how long the points of a measurement are kept in a
+// retention policy.
+type MeasurementTTLInfo struct {
+	Name string
+	TTL  time.Duration
+}
+
+// MeasurementTTL returns the TTL of a measurement in the policy, or zero if
+// it's kept for the policy's duration.
+func (rpi *RetentionPolicyInfo) MeasurementTTL(name string) time.Duration {
+	for _, m := range rpi.MeasurementTTLs {
+		if m.Name == name {
+			return m.TTL
+		}
+	}
+	return 0
 }
 
 // NewRetentionPolicyInfo returns a new instance of RetentionPolicyInfo with defaults set.
@@ -734,6 +1717,14 @@ func (rpi *RetentionPolicyInfo) marshal() *internal.RetentionPolicyInfo {
 		pb.ShardGroups[i] = sgi.marshal()
 	}
 
+	pb.MeasurementTTLs = make([]*internal.MeasurementTTLInfo, len(rpi.MeasurementTTLs))
+	for i, m := range rpi.MeasurementTTLs {
+		pb.MeasurementTTLs[i] = &internal.MeasurementTTLInfo{
+			Name: proto.String(m.Name),
+			TTL:  proto.Int64(int64(m.TTL)),
+		}
+	}
+
 	return pb
 }
 
@@ -748,6 +1739,13 @@ func (rpi *RetentionPolicyInfo) unmarshal(pb *internal.RetentionPolicyInfo) {
 	for i, x := range pb.GetShardGroups() {
 		rpi.ShardGroups[i].unmarshal(x)
 	}
+
+	if len(pb.GetMeasurementTTLs()) > 0 {
+		rpi.MeasurementTTLs = make([]MeasurementTTLInfo, len(pb.GetMeasurementTTLs()))
+		for i, x := range pb.GetMeasurementTTLs() {
+			rpi.MeasurementTTLs[i] = MeasurementTTLInfo{Name: x.GetName(), TTL: time.Duration(x.GetTTL())}
+		}
+	}
 }
 
 // clone returns a deep copy of rpi.
@@ -761,6 +1759,11 @@ func (rpi RetentionPolicyInfo) clone() RetentionPolicyInfo {
 		}
 	}
 
+	if rpi.MeasurementTTLs != nil {
+		other.MeasurementTTLs = make([]MeasurementTTLInfo, len(rpi.MeasurementTTLs))
+		copy(other.MeasurementTTLs, rpi.MeasurementTTLs)
+	}
+
 	return other
 }
 
@@ -774,6 +1777,18 @@ func shardGroupDuration(d time.Duration) time.Duration {
 	return 1 * time.Hour
 }
 
+// validateShardGroupDuration returns an error if d is not a valid shard group
+// duration for a policy that retains data for duration. A zero duration
+// retains data forever so any shard group duration above the minimum is allowed.
+func validateShardGroupDuration(d, duration time.Duration) error {
+	if d < MinRetentionPolicyDuration {
+		return ErrShardGroupDurationTooLow
+	} else if duration != 0 && d > duration {
+		return ErrShardGroupDurationTooHigh
+	}
+	return nil
+}
+
 // ShardGroupInfo represents metadata about a shard group. The DeletedAt field is important
 // because it makes it clear that a ShardGroup has been marked as deleted, and allow the system
 // to be sure that a ShardGroup is not simply missing. If the DeletedAt is set, the system can
@@ -868,6 +1883,30 @@ func (si ShardInfo) clone() ShardInfo {
 	return other
 }
 
+// OwnedBy returns true if the node is an owner of the shard.
+func (si ShardInfo) OwnedBy(nodeID uint64) bool {
+	for _, id := range si.OwnerIDs {
+		if id == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// reassign replaces an owner of the shard with another node.
+func (si *ShardInfo) reassign(oldOwnerID, newOwnerID uint64) error {
+	if si.OwnedBy(newOwnerID) {
+		return ErrShardOwnerExists
+	}
+	for i, id := range si.OwnerIDs {
+		if id == oldOwnerID {
+			si.OwnerIDs[i] = newOwnerID
+			return nil
+		}
+	}
+	return ErrShardOwnerNotFound
+}
+
 // marshal serializes to a protobuf representation.
 func (si ShardInfo) marshal() *internal.ShardInfo {
 	pb := &internal.ShardInfo{
@@ -887,6 +1926,99 @@ func (si *ShardInfo) unmarshal(pb *internal.ShardInfo) {
 	copy(si.OwnerIDs, pb.GetOwnerIDs())
 }
 
+// MeasurementInfo represents the fields and tag keys written to a measurement.
+// Both are sorted by name.
+type MeasurementInfo struct {
+	Name    string
+	Fields  []FieldInfo
+	TagKeys []string
+}
+
+// Field returns a field by name.
+func (mi MeasurementInfo) Field(name string) *FieldInfo {
+	i := sort.Search(len(mi.Fields), func(i int) bool { return mi.Fields[i].Name >= name })
+	if i < len(mi.Fields) && mi.Fields[i].Name == name {
+		return &mi.Fields[i]
+	}
+	return nil
+}
+
+// HasTagKey returns true if the measurement has the tag key.
+func (mi MeasurementInfo) HasTagKey(key string) bool {
+	i := sort.SearchStrings(mi.TagKeys, key)
+	return i < len(mi.TagKeys) && mi.TagKeys[i] == key
+}
+
+// add adds fields and tag keys that aren't in the measurement yet.
+func (mi *MeasurementInfo) add(other MeasurementInfo) {
+	for _, f := range other.Fields {
+		if mi.Field(f.Name) == nil {
+			mi.Fields = append(mi.Fields, f)
+			sort.Sort(fieldInfos(mi.Fields))
+		}
+	}
+	for _, k := range other.TagKeys {
+		if !mi.HasTagKey(k) {
+			mi.TagKeys = append(mi.TagKeys, k)
+			sort.Strings(mi.TagKeys)
+		}
+	}
+}
+
+// clone returns a deep copy of mi.
+func (mi MeasurementInfo) clone() MeasurementInfo {
+	other := mi
+	if mi.Fields != nil {
+		other.Fields = make([]FieldInfo, len(mi.Fields))
+		copy(other.Fields, mi.Fields)
+	}
+	if mi.TagKeys != nil {
+		other.TagKeys = make([]string, len(mi.TagKeys))
+		copy(other.TagKeys, mi.TagKeys)
+	}
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (mi MeasurementInfo) marshal() *internal.MeasurementInfo {
+	pb := &internal.MeasurementInfo{
+		Name:    proto.String(mi.Name),
+		TagKeys: mi.TagKeys,
+	}
+	for _, f := range mi.Fields {
+		pb.Fields = append(pb.Fields, &internal.FieldInfo{
+			Name: proto.String(f.Name),
+			Type: proto.Int32(int32(f.Type)),
+		})
+	}
+	return pb
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (mi *MeasurementInfo) unmarshal(pb *internal.MeasurementInfo) {
+	mi.Name = pb.GetName()
+	mi.TagKeys = pb.GetTagKeys()
+	for _, x := range pb.GetFields() {
+		mi.Fields = append(mi.Fields, FieldInfo{
+			Name: x.GetName(),
+			Type: influxql.DataType(x.GetType()),
+		})
+	}
+}
+
+// FieldInfo represents the name and type of a field.
+type FieldInfo struct {
+	Name string
+	Type influxql.DataType
+}
+
+// fieldInfos represents a list of fields sortable by name.
+type fieldInfos []FieldInfo
+
+func (a fieldInfos) Len() int           { return len(a) }
+func (a fieldInfos) Less(i, j int) bool { return a[i].Name < a[j].Name }
+func (a fieldInfos) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
 // ContinuousQueryInfo represents metadata about a continuous query.
 type ContinuousQueryInfo struct {
 	Name  string
@@ -916,14 +2048,43 @@ type UserInfo struct {
 	Hash       string
 	Admin      bool
 	Privileges map[string]influxql.Privilege
+	Roles      []string
+
+	// PasswordExpired is set when the user must change their password
+	// before they can authenticate.
+	PasswordExpired bool
+
+	// exact is set for users derived from API keys. A privilege then only
+	// allows its own action so a write-only key cannot read.
+	exact bool
+
+	// rolePrivileges are the combined privileges of the user's roles. They
+	// are attached by the store when the user is looked up.
+	rolePrivileges map[string]influxql.Privilege
 }
 
 // Authorize returns true if the user is authorized and false if not.
 func (ui *UserInfo) Authorize(privilege influxql.Privilege, database string) bool {
 	p, ok := ui.Privileges[database]
+	if ui.exact {
+		return ok && (p == privilege || p == influxql.AllPrivileges)
+	}
+	if rp, rok := ui.rolePrivileges[database]; rok && (!ok || rp > p) {
+		p, ok = rp, true
+	}
 	return (ok && p >= privilege) || (ui.Admin)
 }
 
+// revokeRole removes a role from the user if it has it.
+func (ui *UserInfo) revokeRole(role string) {
+	for i, name := range ui.Roles {
+		if name == role {
+			ui.Roles = append(ui.Roles[:i:i], ui.Roles[i+1:]...)
+			return
+		}
+	}
+}
+
 // clone returns a deep copy of si.
 func (ui UserInfo) clone() UserInfo {
 	other := ui
@@ -935,39 +2096,242 @@ func (ui UserInfo) clone() UserInfo {
 		}
 	}
 
+	if ui.Roles != nil {
+		other.Roles = make([]string, len(ui.Roles))
+		copy(other.Roles, ui.Roles)
+	}
+
 	return other
 }
 
 // marshal serializes to a protobuf representation.
 func (ui UserInfo) marshal() *internal.UserInfo {
 	pb := &internal.UserInfo{
-		Name:  proto.String(ui.Name),
-		Hash:  proto.String(ui.Hash),
-		Admin: proto.Bool(ui.Admin),
+		Name:       proto.String(ui.Name),
+		Hash:       proto.String(ui.Hash),
+		Admin:      proto.Bool(ui.Admin),
+		Privileges: marshalPrivileges(ui.Privileges),
+		Roles:      ui.Roles,
+	}
+	if ui.PasswordExpired {
+		pb.PasswordExpired = proto.Bool(true)
+	}
+	return pb
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (ui *UserInfo) unmarshal(pb *internal.UserInfo) {
+	ui.Name = pb.GetName()
+	ui.Hash = pb.GetHash()
+	ui.Admin = pb.GetAdmin()
+	ui.Privileges = unmarshalPrivileges(pb.GetPrivileges())
+	ui.Roles = pb.GetRoles()
+	ui.PasswordExpired = pb.GetPasswordExpired()
+}
+
+// RoleInfo represents a named set of database privileges that can be
+// granted to users.
+type RoleInfo struct {
+	Name       string
+	Privileges map[string]influxql.Privilege
+}
+
+// clone returns a deep copy of ri.
+func (ri RoleInfo) clone() RoleInfo {
+	other := ri
+
+	if ri.Privileges != nil {
+		other.Privileges = make(map[string]influxql.Privilege)
+		for k, v := range ri.Privileges {
+			other.Privileges[k] = v
+		}
+	}
+
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (ri RoleInfo) marshal() *internal.RoleInfo {
+	return &internal.RoleInfo{
+		Name:       proto.String(ri.Name),
+		Privileges: marshalPrivileges(ri.Privileges),
 	}
+}
 
-	for database, privilege := range ui.Privileges {
-		pb.Privileges = append(pb.Privileges, &internal.UserPrivilege{
+// unmarshal deserializes from a protobuf representation.
+func (ri *RoleInfo) unmarshal(pb *internal.RoleInfo) {
+	ri.Name = pb.GetName()
+	ri.Privileges = unmarshalPrivileges(pb.GetPrivileges())
+}
+
+// APIKeyInfo represents an API key that grants a fixed set of privileges
+// without the credentials of a user. Only a hash of the key is stored.
+type APIKeyInfo struct {
+	Name       string
+	Hash       string
+	Privileges map[string]influxql.Privilege
+}
+
+// UserInfo returns a non-admin user carrying the privileges of the key so
+// requests made with the key go through the usual authorization checks.
+// Unlike users, a WRITE privilege on a key doesn't also grant READ.
+func (ak *APIKeyInfo) UserInfo() *UserInfo {
+	return &UserInfo{Name: ak.Name, Privileges: ak.Privileges, exact: true}
+}
+
+// clone returns a deep copy of ak.
+func (ak APIKeyInfo) clone() APIKeyInfo {
+	other := ak
+
+	if ak.Privileges != nil {
+		other.Privileges = make(map[string]influxql.Privilege)
+		for k, v := range ak.Privileges {
+			other.Privileges[k] = v
+		}
+	}
+
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (ak APIKeyInfo) marshal() *internal.APIKeyInfo {
+	return &internal.APIKeyInfo{
+		Name:       proto.String(ak.Name),
+		Hash:       proto.String(ak.Hash),
+		Privileges: marshalPrivileges(ak.Privileges),
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (ak *APIKeyInfo) unmarshal(pb *internal.APIKeyInfo) {
+	ak.Name = pb.GetName()
+	ak.Hash = pb.GetHash()
+	ak.Privileges = unmarshalPrivileges(pb.GetPrivileges())
+}
+
+// marshalPrivileges serializes a set of privileges to a protobuf representation.
+func marshalPrivileges(m map[string]influxql.Privilege) []*internal.UserPrivilege {
+	var a []*internal.UserPrivilege
+	for database, privilege := range m {
+		a = append(a, &internal.UserPrivilege{
 			Database:  proto.String(database),
 			Privilege: proto.Int32(int32(privilege)),
 		})
 	}
+	return a
+}
+
+// unmarshalPrivileges deserializes a set of privileges from a protobuf representation.
+func unmarshalPrivileges(a []*internal.UserPrivilege) map[string]influxql.Privilege {
+	m := make(map[string]influxql.Privilege, len(a))
+	for _, p := range a {
+		m[p.GetDatabase()] = influxql.Privilege(p.GetPrivilege())
+	}
+	return m
+}
+
+// SnapshotInfo represents the manifest of a cluster snapshot. It contains
+// every shard in shard groups that ended at or before Time.
+type SnapshotInfo struct {
+	ID        uint64
+	Time      time.Time
+	CreatedAt time.Time
+	Path      string
+	Shards    []SnapshotShardInfo
+}
+
+// clone returns a deep copy of si.
+func (si SnapshotInfo) clone() SnapshotInfo {
+	other := si
+
+	if si.Shards != nil {
+		other.Shards = make([]SnapshotShardInfo, len(si.Shards))
+		copy(other.Shards, si.Shards)
+	}
+
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (si SnapshotInfo) marshal() *internal.SnapshotInfo {
+	pb := &internal.SnapshotInfo{
+		ID:        proto.Uint64(si.ID),
+		Time:      proto.Int64(MarshalTime(si.Time)),
+		CreatedAt: proto.Int64(MarshalTime(si.CreatedAt)),
+		Path:      proto.String(si.Path),
+	}
+
+	pb.Shards = make([]*internal.SnapshotShardInfo, len(si.Shards))
+	for i, sh := range si.Shards {
+		pb.Shards[i] = &internal.SnapshotShardInfo{
+			ID:              proto.Uint64(sh.ID),
+			Database:        proto.String(sh.Database),
+			RetentionPolicy: proto.String(sh.RetentionPolicy),
+			NodeID:          proto.Uint64(sh.NodeID),
+		}
+	}
 
 	return pb
 }
 
 // unmarshal deserializes from a protobuf representation.
-func (ui *UserInfo) unmarshal(pb *internal.UserInfo) {
-	ui.Name = pb.GetName()
-	ui.Hash = pb.GetHash()
-	ui.Admin = pb.GetAdmin()
+func (si *SnapshotInfo) unmarshal(pb *internal.SnapshotInfo) {
+	si.ID = pb.GetID()
+	si.Time = UnmarshalTime(pb.GetTime())
+	si.CreatedAt = UnmarshalTime(pb.GetCreatedAt())
+	si.Path = pb.GetPath()
 
-	ui.Privileges = make(map[string]influxql.Privilege)
-	for _, p := range pb.GetPrivileges() {
-		ui.Privileges[p.GetDatabase()] = influxql.Privilege(p.GetPrivilege())
+	si.Shards = make([]SnapshotShardInfo, len(pb.GetShards()))
+	for i, x := range pb.GetShards() {
+		si.Shards[i] = SnapshotShardInfo{
+			ID:              x.GetID(),
+			Database:        x.GetDatabase(),
+			RetentionPolicy: x.GetRetentionPolicy(),
+			NodeID:          x.GetNodeID(),
+		}
 	}
 }
 
+// SnapshotShardInfo represents a shard in a cluster snapshot and the node
+// its data was copied from.
+type SnapshotShardInfo struct {
+	ID              uint64
+	Database        string
+	RetentionPolicy string
+	NodeID          uint64
+}
+
+// Limits represents the resources the cluster can use. A zero limit means
+// the resource is unlimited.
+type Limits struct {
+	MaxDatabases         int // databases in the cluster
+	MaxRetentionPolicies int // retention policies in each database
+	MaxSeriesPerDatabase int // series in each database on a node
+}
+
+// Limit names used by SetLimit.
+const (
+	LimitMaxDatabases         = "max_databases"
+	LimitMaxRetentionPolicies = "max_retention_policies"
+	LimitMaxSeriesPerDatabase = "max_series_per_database"
+)
+
+// marshal serializes to a protobuf representation.
+func (l Limits) marshal() *internal.Limits {
+	return &internal.Limits{
+		MaxDatabases:         proto.Int64(int64(l.MaxDatabases)),
+		MaxRetentionPolicies: proto.Int64(int64(l.MaxRetentionPolicies)),
+		MaxSeriesPerDatabase: proto.Int64(int64(l.MaxSeriesPerDatabase)),
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (l *Limits) unmarshal(pb *internal.Limits) {
+	l.MaxDatabases = int(pb.GetMaxDatabases())
+	l.MaxRetentionPolicies = int(pb.GetMaxRetentionPolicies())
+	l.MaxSeriesPerDatabase = int(pb.GetMaxSeriesPerDatabase())
+}
+
 // MarshalTime converts t to nanoseconds since epoch. A zero time returns 0.
 func MarshalTime(t time.Time) int64 {
 	if t.IsZero() {
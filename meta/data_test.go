@@ -11,6 +11,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Ensure a node can be created.
@@ -34,7 +35,7 @@ func TestData_DeleteNode(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := data.DeleteNode(1); err != nil {
+	if err := data.DeleteNode(1, false); err != nil {
 		t.Fatal(err)
 	} else if len(data.Nodes) != 2 {
 		t.Fatalf("unexpected node count: %d", len(data.Nodes))
@@ -74,6 +75,17 @@ func TestData_CreateDatabase_ErrDatabaseExists(t *testing.T) {
 	}
 }
 
+// Ensure that creating a database above the limit returns an error.
+func TestData_CreateDatabase_ErrMaxDatabasesExceeded(t *testing.T) {
+	data := meta.Data{Limits: meta.Limits{MaxDatabases: 1}}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateDatabase("db1"); err != meta.ErrMaxDatabasesExceeded {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 // Ensure a database can be removed.
 func TestData_DropDatabase(t *testing.T) {
 	var data meta.Data
@@ -90,6 +102,73 @@ func TestData_DropDatabase(t *testing.T) {
 	}
 }
 
+// Ensure a database can be renamed along with the queries and privileges
+// referring to it.
+func TestData_RenameDatabase(t *testing.T) {
+	data := meta.Data{
+		Databases: []meta.DatabaseInfo{
+			{
+				Name: "db0",
+				ContinuousQueries: []meta.ContinuousQueryInfo{
+					{Name: "cq0", Query: `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT count(value) INTO db1.rp0.cpu_count FROM db0.rp0.cpu GROUP BY time(1h) END`},
+				},
+			},
+			{
+				Name: "db1",
+				ContinuousQueries: []meta.ContinuousQueryInfo{
+					{Name: "cq1", Query: `CREATE CONTINUOUS QUERY cq1 ON db1 BEGIN SELECT count(value) INTO db0.rp0.mem_count FROM mem GROUP BY time(1h) END`},
+				},
+			},
+		},
+		Users: []meta.UserInfo{
+			{Name: "susy", Privileges: map[string]influxql.Privilege{"db0": influxql.ReadPrivilege}},
+		},
+		Roles: []meta.RoleInfo{
+			{Name: "writers", Privileges: map[string]influxql.Privilege{"db0": influxql.WritePrivilege}},
+		},
+	}
+
+	if err := data.RenameDatabase("db0", "db2"); err != nil {
+		t.Fatal(err)
+	} else if data.Database("db0") != nil || data.Database("db2") == nil {
+		t.Fatalf("unexpected databases: %#v", data.Databases)
+	}
+
+	// Continuous queries on, reading from or writing into the database are updated.
+	cq0 := influxql.MustParseStatement(data.Database("db2").ContinuousQueries[0].Query).(*influxql.CreateContinuousQueryStatement)
+	if cq0.Database != "db2" {
+		t.Fatalf("unexpected database: %s", cq0.Database)
+	} else if db := cq0.Source.Target.Measurement.Database; db != "db1" {
+		t.Fatalf("unexpected target database: %s", db)
+	} else if db := cq0.Source.Sources[0].(*influxql.Measurement).Database; db != "db2" {
+		t.Fatalf("unexpected source database: %s", db)
+	}
+	cq1 := influxql.MustParseStatement(data.Database("db1").ContinuousQueries[0].Query).(*influxql.CreateContinuousQueryStatement)
+	if cq1.Database != "db1" {
+		t.Fatalf("unexpected database: %s", cq1.Database)
+	} else if db := cq1.Source.Target.Measurement.Database; db != "db2" {
+		t.Fatalf("unexpected target database: %s", db)
+	}
+
+	if p := data.Users[0].Privileges; !reflect.DeepEqual(p, map[string]influxql.Privilege{"db2": influxql.ReadPrivilege}) {
+		t.Fatalf("unexpected user privileges: %v", p)
+	} else if p := data.Roles[0].Privileges; !reflect.DeepEqual(p, map[string]influxql.Privilege{"db2": influxql.WritePrivilege}) {
+		t.Fatalf("unexpected role privileges: %v", p)
+	}
+}
+
+// Ensure renaming a database to an existing name returns an error.
+func TestData_RenameDatabase_ErrDatabaseExists(t *testing.T) {
+	data := meta.Data{Databases: []meta.DatabaseInfo{{Name: "db0"}, {Name: "db1"}}}
+	if err := data.RenameDatabase("db0", "db1"); err != meta.ErrDatabaseExists {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := data.RenameDatabase("no_db", "db2"); err != meta.ErrDatabaseNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := data.RenameDatabase("db0", ""); err != meta.ErrDatabaseNameRequired {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 // Ensure a retention policy can be created.
 func TestData_CreateRetentionPolicy(t *testing.T) {
 	data := meta.Data{Nodes: []meta.NodeInfo{{ID: 1}, {ID: 2}}}
@@ -119,6 +198,42 @@ func TestData_CreateRetentionPolicy(t *testing.T) {
 	}
 }
 
+// Ensure that a policy can be created with its own shard group duration.
+func TestData_CreateRetentionPolicy_ShardGroupDuration(t *testing.T) {
+	data := meta.Data{Nodes: []meta.NodeInfo{{ID: 1}}}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{
+		Name:               "rp0",
+		ReplicaN:           1,
+		Duration:           365 * 24 * time.Hour,
+		ShardGroupDuration: 30 * 24 * time.Hour,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rpi, _ := data.RetentionPolicy("db0", "rp0"); rpi.ShardGroupDuration != 30*24*time.Hour {
+		t.Fatalf("unexpected shard group duration: %s", rpi.ShardGroupDuration)
+	}
+}
+
+// Ensure that creating a policy with an invalid shard group duration returns an error.
+func TestData_CreateRetentionPolicy_ErrShardGroupDuration(t *testing.T) {
+	data := meta.Data{Nodes: []meta.NodeInfo{{ID: 1}}}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Minute}); err != meta.ErrShardGroupDurationTooLow {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 2 * time.Hour, ShardGroupDuration: 3 * time.Hour}); err != meta.ErrShardGroupDurationTooHigh {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 // Ensure that creating a policy without a name returns an error.
 func TestData_CreateRetentionPolicy_ErrNameRequired(t *testing.T) {
 	data := meta.Data{Nodes: []meta.NodeInfo{{ID: 1}}}
@@ -160,6 +275,19 @@ func TestData_CreateRetentionPolicy_ErrRetentionPolicyExists(t *testing.T) {
 	}
 }
 
+// Ensure that creating a policy above the limit returns an error.
+func TestData_CreateRetentionPolicy_ErrMaxRetentionPoliciesExceeded(t *testing.T) {
+	data := meta.Data{Limits: meta.Limits{MaxRetentionPolicies: 1}}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err = data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp1"}); err != meta.ErrMaxRetentionPoliciesExceeded {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 // Ensure that a retention policy can be updated.
 func TestData_UpdateRetentionPolicy(t *testing.T) {
 	var data meta.Data
@@ -189,6 +317,77 @@ func TestData_UpdateRetentionPolicy(t *testing.T) {
 	}
 }
 
+// Ensure that a retention policy's shard group duration can be updated.
+func TestData_UpdateRetentionPolicy_ShardGroupDuration(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err = data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", Duration: 48 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new shard group duration must fit within the policy duration.
+	var rpu meta.RetentionPolicyUpdate
+	rpu.SetShardGroupDuration(72 * time.Hour)
+	if err := data.UpdateRetentionPolicy("db0", "rp0", &rpu); err != meta.ErrShardGroupDurationTooHigh {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Extending the policy duration at the same time is allowed.
+	rpu.SetDuration(0)
+	if err := data.UpdateRetentionPolicy("db0", "rp0", &rpu); err != nil {
+		t.Fatal(err)
+	} else if rpi, _ := data.RetentionPolicy("db0", "rp0"); rpi.ShardGroupDuration != 72*time.Hour {
+		t.Fatalf("unexpected shard group duration: %s", rpi.ShardGroupDuration)
+	}
+}
+
+// Ensure a measurement TTL can be set, replaced and removed on a retention
+// policy, and survives encoding.
+func TestData_SetMeasurementTTL(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err = data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.SetMeasurementTTL("db0", "rp0", "cpu", time.Hour); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetMeasurementTTL("db0", "rp0", "mem", time.Hour); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetMeasurementTTL("db0", "rp0", "cpu", 2*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify the TTLs are kept through encoding.
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if rpi, _ := other.RetentionPolicy("db0", "rp0"); rpi.MeasurementTTL("cpu") != 2*time.Hour || rpi.MeasurementTTL("mem") != time.Hour {
+		t.Fatalf("unexpected ttls: %+v", rpi.MeasurementTTLs)
+	}
+
+	// A zero TTL removes it.
+	if err := data.SetMeasurementTTL("db0", "rp0", "mem", 0); err != nil {
+		t.Fatal(err)
+	} else if rpi, _ := data.RetentionPolicy("db0", "rp0"); !reflect.DeepEqual(rpi.MeasurementTTLs, []meta.MeasurementTTLInfo{{Name: "cpu", TTL: 2 * time.Hour}}) {
+		t.Fatalf("unexpected ttls: %+v", rpi.MeasurementTTLs)
+	}
+
+	if err := data.SetMeasurementTTL("db0", "rp1", "cpu", time.Hour); err != meta.ErrRetentionPolicyNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := data.SetMeasurementTTL("db1", "rp0", "cpu", time.Hour); err != meta.ErrDatabaseNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := data.SetMeasurementTTL("db0", "rp0", "cpu", -1); err != meta.ErrMeasurementTTLInvalid {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 // Ensure a retention policy can be removed.
 func TestData_DropRetentionPolicy(t *testing.T) {
 	var data meta.Data
@@ -309,6 +508,251 @@ func TestData_CreateShardGroup(t *testing.T) {
 	}
 }
 
+// Ensure shard replicas are placed across distinct zones when possible.
+func TestData_CreateShardGroup_Zones(t *testing.T) {
+	var data meta.Data
+	createNode := func(id uint64, zone string) {
+		if err := data.CreateNode(fmt.Sprintf("node%d", id)); err != nil {
+			t.Fatal(err)
+		} else if err := data.SetNodeZone(id, zone, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The replication factor must match the cluster size when the policy is
+	// created so add the remaining nodes afterwards.
+	createNode(1, "a")
+	createNode(2, "a")
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err = data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, Duration: 1 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	createNode(3, "b")
+	createNode(4, "b")
+
+	if err := data.SetNodeZone(5, "c", ""); err != meta.ErrNodeNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	timestamp := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := data.CreateShardGroup("db0", "rp0", timestamp); err != nil {
+		t.Fatal(err)
+	}
+	if sgi, _ := data.ShardGroupByTimestamp("db0", "rp0", timestamp); !reflect.DeepEqual(sgi.Shards, []meta.ShardInfo{
+		{ID: 1, OwnerIDs: []uint64{1, 3}},
+		{ID: 2, OwnerIDs: []uint64{2, 4}},
+	}) {
+		t.Fatalf("unexpected shards: %#v", sgi.Shards)
+	}
+}
+
+// Ensure shard replicas within a zone are placed across distinct racks.
+func TestData_CreateShardGroup_Racks(t *testing.T) {
+	var data meta.Data
+	createNode := func(id uint64, zone, rack string) {
+		if err := data.CreateNode(fmt.Sprintf("node%d", id)); err != nil {
+			t.Fatal(err)
+		} else if err := data.SetNodeZone(id, zone, rack); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Zone "a" has two racks and zone "b" has one. Rack names are scoped to
+	// their zone so "r1" in each zone are different racks.
+	createNode(1, "a", "r1")
+	createNode(2, "a", "r1")
+	createNode(3, "a", "r2")
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err = data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 3, Duration: 1 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	createNode(4, "b", "r1")
+
+	timestamp := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := data.CreateShardGroup("db0", "rp0", timestamp); err != nil {
+		t.Fatal(err)
+	}
+
+	// The shard takes one node from each zone, then a node from the other
+	// rack of zone "a" instead of the next node in rack "r1".
+	if sgi, _ := data.ShardGroupByTimestamp("db0", "rp0", timestamp); !reflect.DeepEqual(sgi.Shards, []meta.ShardInfo{
+		{ID: 1, OwnerIDs: []uint64{1, 4, 3}},
+	}) {
+		t.Fatalf("unexpected shards: %#v", sgi.Shards)
+	}
+}
+
+// Ensure shards are not assigned to draining nodes.
+func TestData_CreateShardGroup_Draining(t *testing.T) {
+	var data meta.Data
+	for i := 0; i < 3; i++ {
+		if err := data.CreateNode(fmt.Sprintf("node%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err = data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 3, Duration: 1 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.SetNodeDraining(3, true); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetNodeDraining(4, true); err != meta.ErrNodeNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	timestamp := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := data.CreateShardGroup("db0", "rp0", timestamp); err != nil {
+		t.Fatal(err)
+	}
+	if sgi, _ := data.ShardGroupByTimestamp("db0", "rp0", timestamp); !reflect.DeepEqual(sgi.Shards, []meta.ShardInfo{
+		{ID: 1, OwnerIDs: []uint64{1, 2}},
+	}) {
+		t.Fatalf("unexpected shards: %#v", sgi.Shards)
+	}
+}
+
+// Ensure a shard can be moved to a new owner and that a node can only be
+// removed once it no longer owns shards.
+func TestData_ReassignShard(t *testing.T) {
+	var data meta.Data
+	for i := 0; i < 2; i++ {
+		if err := data.CreateNode(fmt.Sprintf("node%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err = data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, Duration: 1 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	timestamp := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := data.CreateShardGroup("db0", "rp0", timestamp); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateNode("node2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.DeleteNode(2, false); err != meta.ErrNodeOwnsShards {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i, tt := range []struct {
+		shardID, oldOwnerID, newOwnerID uint64
+		err                             error
+	}{
+		{shardID: 2, oldOwnerID: 2, newOwnerID: 3, err: meta.ErrShardNotFound},
+		{shardID: 1, oldOwnerID: 2, newOwnerID: 4, err: meta.ErrNodeNotFound},
+		{shardID: 1, oldOwnerID: 2, newOwnerID: 1, err: meta.ErrShardOwnerExists},
+		{shardID: 1, oldOwnerID: 3, newOwnerID: 3, err: meta.ErrShardOwnerNotFound},
+		{shardID: 1, oldOwnerID: 2, newOwnerID: 3},
+		{shardID: 1, oldOwnerID: 2, newOwnerID: 3, err: meta.ErrShardOwnerExists},
+	} {
+		if err := data.ReassignShard(tt.shardID, tt.oldOwnerID, tt.newOwnerID); err != tt.err {
+			t.Fatalf("%d. unexpected error: %v, exp %v", i, err, tt.err)
+		}
+	}
+
+	if sgi, _ := data.ShardGroupByTimestamp("db0", "rp0", timestamp); !reflect.DeepEqual(sgi.Shards, []meta.ShardInfo{
+		{ID: 1, OwnerIDs: []uint64{1, 3}},
+	}) {
+		t.Fatalf("unexpected shards: %#v", sgi.Shards)
+	} else if err := data.DeleteNode(2, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a node that owns shards can be forcibly removed.
+func TestData_DeleteNode_Force(t *testing.T) {
+	var data meta.Data
+	timestamp := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// Create a policy with one shard per node and a policy with every node
+	// owning its single shard.
+	if err := data.CreateNode("node0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err = data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 1 * time.Hour}); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateNode("node1"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateNode("node2"); err != nil {
+		t.Fatal(err)
+	} else if err = data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp1", ReplicaN: 3, Duration: 1 * time.Hour}); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateShardGroup("db0", "rp0", timestamp); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateShardGroup("db0", "rp1", timestamp); err != nil {
+		t.Fatal(err)
+	}
+
+	// Node 1 and 3 own the same number of shards but draining nodes are
+	// only used as a last resort.
+	if err := data.SetNodeDraining(1, true); err != nil {
+		t.Fatal(err)
+	} else if err := data.DeleteNode(2, true); err != nil {
+		t.Fatal(err)
+	} else if data.Node(2) != nil {
+		t.Fatal("expected node to be removed")
+	}
+
+	if sgi, _ := data.ShardGroupByTimestamp("db0", "rp0", timestamp); !reflect.DeepEqual(sgi.Shards, []meta.ShardInfo{
+		{ID: 1, OwnerIDs: []uint64{1}},
+		{ID: 2, OwnerIDs: []uint64{3}},
+		{ID: 3, OwnerIDs: []uint64{3}},
+	}) {
+		t.Fatalf("unexpected shards: %#v", sgi.Shards)
+	} else if sgi, _ := data.ShardGroupByTimestamp("db0", "rp1", timestamp); !reflect.DeepEqual(sgi.Shards, []meta.ShardInfo{
+		{ID: 4, OwnerIDs: []uint64{1, 3}},
+	}) {
+		t.Fatalf("unexpected shards: %#v", sgi.Shards)
+	}
+
+	// The last node can't be removed while it owns shards.
+	if err := data.DeleteNode(3, true); err != nil {
+		t.Fatal(err)
+	} else if err := data.DeleteNode(1, true); err != meta.ErrNodesRequired {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a snapshot manifest can be recorded and survives serialization.
+func TestData_CreateSnapshot(t *testing.T) {
+	var data meta.Data
+	si := &meta.SnapshotInfo{
+		Time:      time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt: time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC),
+		Path:      "/backups/0",
+		Shards:    []meta.SnapshotShardInfo{{ID: 1, Database: "db0", RetentionPolicy: "rp0", NodeID: 2}},
+	}
+	if err := data.CreateSnapshot(si); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateSnapshot(&meta.SnapshotInfo{}); err != meta.ErrSnapshotPathRequired {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := *si
+	exp.ID = 1
+	if !reflect.DeepEqual(data.Snapshots, []meta.SnapshotInfo{exp}) {
+		t.Fatalf("unexpected snapshots: %#v", data.Snapshots)
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other.Snapshots, data.Snapshots) || other.MaxSnapshotID != 1 {
+		t.Fatalf("unexpected snapshots: %#v", other.Snapshots)
+	}
+}
+
 // Ensure that a shard group is correctly detected as expired.
 func TestData_ShardGroupExpiredDeleted(t *testing.T) {
 	var data meta.Data
@@ -428,6 +872,30 @@ func TestData_DeleteShardGroup(t *testing.T) {
 	}
 }
 
+// Ensure shard groups deleted before the expiration are pruned.
+func TestData_PruneShardGroups(t *testing.T) {
+	data := meta.Data{
+		Databases: []meta.DatabaseInfo{{
+			Name: "db0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name: "rp0",
+				ShardGroups: []meta.ShardGroupInfo{
+					{ID: 1, DeletedAt: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)},
+					{ID: 2, DeletedAt: time.Date(2000, time.January, 3, 0, 0, 0, 0, time.UTC)},
+					{ID: 3},
+				},
+			}},
+		}},
+	}
+
+	data.PruneShardGroups(time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC))
+	if groups := data.Databases[0].RetentionPolicies[0].ShardGroups; len(groups) != 2 {
+		t.Fatalf("unexpected shard group count: %d", len(groups))
+	} else if groups[0].ID != 2 || groups[1].ID != 3 {
+		t.Fatalf("unexpected shard groups: %d, %d", groups[0].ID, groups[1].ID)
+	}
+}
+
 // Ensure a continuous query can be created.
 func TestData_CreateContinuousQuery(t *testing.T) {
 	var data meta.Data
@@ -519,6 +987,133 @@ func TestData_DropUser_ErrUserNotFound(t *testing.T) {
 	}
 }
 
+// Ensure an API key can be created.
+func TestData_CreateAPIKey(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateAPIKey("telegraf", "ABC123", map[string]influxql.Privilege{"db0": influxql.WritePrivilege}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(data.APIKeys, []meta.APIKeyInfo{
+		{Name: "telegraf", Hash: "ABC123", Privileges: map[string]influxql.Privilege{"db0": influxql.WritePrivilege}},
+	}) {
+		t.Fatalf("unexpected api keys: %#v", data.APIKeys)
+	}
+}
+
+// Ensure that creating an invalid API key returns an error.
+func TestData_CreateAPIKey_Err(t *testing.T) {
+	var data meta.Data
+	privileges := map[string]influxql.Privilege{"db0": influxql.ReadPrivilege}
+	if err := data.CreateAPIKey("", "", privileges); err != meta.ErrAPIKeyNameRequired {
+		t.Fatal(err)
+	} else if err := data.CreateAPIKey("telegraf", "", nil); err != meta.ErrAPIKeyPrivilegesRequired {
+		t.Fatal(err)
+	} else if err := data.CreateAPIKey("telegraf", "", privileges); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateAPIKey("telegraf", "", privileges); err != meta.ErrAPIKeyExists {
+		t.Fatal(err)
+	}
+}
+
+// Ensure an API key can be removed.
+func TestData_DropAPIKey(t *testing.T) {
+	var data meta.Data
+	privileges := map[string]influxql.Privilege{"db0": influxql.ReadPrivilege}
+	if err := data.CreateAPIKey("telegraf", "", privileges); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateAPIKey("grafana", "", privileges); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.DropAPIKey("telegraf"); err != nil {
+		t.Fatal(err)
+	} else if len(data.APIKeys) != 1 || data.APIKeys[0].Name != "grafana" {
+		t.Fatalf("unexpected api keys: %#v", data.APIKeys)
+	} else if err := data.DropAPIKey("telegraf"); err != meta.ErrAPIKeyNotFound {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a role can be created and granted privileges.
+func TestData_CreateRole(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateRole(""); err != meta.ErrRoleNameRequired {
+		t.Fatal(err)
+	} else if err := data.CreateRole("analysts"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateRole("analysts"); err != meta.ErrRoleExists {
+		t.Fatal(err)
+	}
+
+	if err := data.SetRolePrivilege("analysts", "db0", influxql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetRolePrivilege("analysts", "", influxql.ReadPrivilege); err != meta.ErrDatabaseNameRequired {
+		t.Fatal(err)
+	} else if err := data.SetRolePrivilege("bogus", "db0", influxql.ReadPrivilege); err != meta.ErrRoleNotFound {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(data.Roles, []meta.RoleInfo{
+		{Name: "analysts", Privileges: map[string]influxql.Privilege{"db0": influxql.ReadPrivilege}},
+	}) {
+		t.Fatalf("unexpected roles: %#v", data.Roles)
+	}
+}
+
+// Ensure roles can be granted to and revoked from users.
+func TestData_GrantRole(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateUser("susy", "", false); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateRole("analysts"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Granting is idempotent.
+	if err := data.GrantRole("susy", "analysts"); err != nil {
+		t.Fatal(err)
+	} else if err := data.GrantRole("susy", "analysts"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(data.User("susy").Roles, []string{"analysts"}) {
+		t.Fatalf("unexpected roles: %#v", data.User("susy").Roles)
+	}
+
+	if err := data.GrantRole("bob", "analysts"); err != meta.ErrUserNotFound {
+		t.Fatal(err)
+	} else if err := data.GrantRole("susy", "bogus"); err != meta.ErrRoleNotFound {
+		t.Fatal(err)
+	}
+
+	if err := data.RevokeRole("susy", "analysts"); err != nil {
+		t.Fatal(err)
+	} else if len(data.User("susy").Roles) != 0 {
+		t.Fatalf("unexpected roles: %#v", data.User("susy").Roles)
+	}
+}
+
+// Ensure dropping a role revokes it from all users.
+func TestData_DropRole(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateUser("susy", "", false); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateRole("analysts"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateRole("writers"); err != nil {
+		t.Fatal(err)
+	} else if err := data.GrantRole("susy", "analysts"); err != nil {
+		t.Fatal(err)
+	} else if err := data.GrantRole("susy", "writers"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.DropRole("analysts"); err != nil {
+		t.Fatal(err)
+	} else if len(data.Roles) != 1 || data.Roles[0].Name != "writers" {
+		t.Fatalf("unexpected roles: %#v", data.Roles)
+	} else if !reflect.DeepEqual(data.User("susy").Roles, []string{"writers"}) {
+		t.Fatalf("unexpected user roles: %#v", data.User("susy").Roles)
+	} else if err := data.DropRole("analysts"); err != meta.ErrRoleNotFound {
+		t.Fatal(err)
+	}
+}
+
 // Ensure a user can be updated.
 func TestData_UpdateUser(t *testing.T) {
 	var data meta.Data
@@ -544,6 +1139,221 @@ func TestData_UpdateUser_ErrUserNotFound(t *testing.T) {
 	}
 }
 
+// Ensure inconsistencies in the data are reported.
+// Ensure the measurement schemas of a database can be updated.
+func TestData_UpdateSchema(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.UpdateSchema("db0", []meta.MeasurementInfo{
+		{Name: "mem", Fields: []meta.FieldInfo{{Name: "used", Type: influxql.Integer}}},
+		{Name: "cpu", Fields: []meta.FieldInfo{{Name: "value", Type: influxql.Float}}, TagKeys: []string{"region"}},
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := data.UpdateSchema("db0", []meta.MeasurementInfo{
+		{Name: "cpu", Fields: []meta.FieldInfo{{Name: "idle", Type: influxql.Boolean}}, TagKeys: []string{"host", "region"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify measurements, fields and tag keys are merged and sorted.
+	if mis := data.Database("db0").Measurements; !reflect.DeepEqual(mis, []meta.MeasurementInfo{
+		{
+			Name: "cpu",
+			Fields: []meta.FieldInfo{
+				{Name: "idle", Type: influxql.Boolean},
+				{Name: "value", Type: influxql.Float},
+			},
+			TagKeys: []string{"host", "region"},
+		},
+		{Name: "mem", Fields: []meta.FieldInfo{{Name: "used", Type: influxql.Integer}}},
+	}) {
+		t.Fatalf("unexpected measurements: %#v", mis)
+	}
+
+	// Verify a field can't change type.
+	if err := data.UpdateSchema("db0", []meta.MeasurementInfo{
+		{Name: "cpu", Fields: []meta.FieldInfo{{Name: "value", Type: influxql.String}}},
+	}); err == nil || err.Error() != `field type conflict: field "value" on measurement "cpu" is type string, already exists as type float` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure updating the schema of a non-existent database returns an error.
+func TestData_UpdateSchema_ErrDatabaseNotFound(t *testing.T) {
+	var data meta.Data
+	if err := data.UpdateSchema("db0", []meta.MeasurementInfo{{Name: "cpu"}}); err != meta.ErrDatabaseNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the metadata of another cluster can be replicated without losing local shards.
+func TestData_Replicate(t *testing.T) {
+	data := meta.Data{
+		Nodes: []meta.NodeInfo{{ID: 1, Host: "standby0"}},
+		Databases: []meta.DatabaseInfo{
+			{
+				Name: "db0",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{Name: "rp0", ReplicaN: 1, ShardGroups: []meta.ShardGroupInfo{{ID: 10}}},
+				},
+			},
+			{Name: "dropped"},
+		},
+		Users: []meta.UserInfo{{Name: "old"}},
+	}
+
+	data.Replicate(&meta.Data{
+		Nodes: []meta.NodeInfo{{ID: 1, Host: "primary0"}, {ID: 2, Host: "primary1"}},
+		Databases: []meta.DatabaseInfo{
+			{
+				Name: "db0",
+				DefaultRetentionPolicy: "rp0",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{Name: "rp0", ReplicaN: 2, ShardGroups: []meta.ShardGroupInfo{{ID: 20}}},
+					{Name: "rp1", ReplicaN: 2, ShardGroups: []meta.ShardGroupInfo{{ID: 30}}},
+				},
+			},
+		},
+		Users:  []meta.UserInfo{{Name: "susy", Admin: true}},
+		Limits: meta.Limits{MaxDatabases: 5},
+	})
+
+	// Verify the local nodes and shard groups are kept.
+	if !reflect.DeepEqual(data.Nodes, []meta.NodeInfo{{ID: 1, Host: "standby0"}}) {
+		t.Fatalf("unexpected nodes: %#v", data.Nodes)
+	} else if !reflect.DeepEqual(data.Databases, []meta.DatabaseInfo{
+		{
+			Name: "db0",
+			DefaultRetentionPolicy: "rp0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{
+				{Name: "rp0", ReplicaN: 2, ShardGroups: []meta.ShardGroupInfo{{ID: 10}}},
+				{Name: "rp1", ReplicaN: 2},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected databases: %#v", data.Databases)
+	} else if !reflect.DeepEqual(data.Users, []meta.UserInfo{{Name: "susy", Admin: true}}) {
+		t.Fatalf("unexpected users: %#v", data.Users)
+	} else if data.Limits != (meta.Limits{MaxDatabases: 5}) {
+		t.Fatalf("unexpected limits: %#v", data.Limits)
+	}
+}
+
+func TestData_Check(t *testing.T) {
+	data := meta.Data{
+		Nodes: []meta.NodeInfo{{ID: 1, Host: "host0"}},
+		Databases: []meta.DatabaseInfo{
+			{
+				Name:                   "db0",
+				DefaultRetentionPolicy: "rp1",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{
+						Name: "rp0",
+						ShardGroups: []meta.ShardGroupInfo{
+							{ID: 1, Shards: []meta.ShardInfo{{ID: 1, OwnerIDs: []uint64{1, 2}}}},
+							{ID: 2},
+							{ID: 3, Shards: []meta.ShardInfo{{ID: 2}}},
+							{ID: 4, DeletedAt: time.Unix(0, 0).Add(time.Hour), Shards: []meta.ShardInfo{{ID: 3, OwnerIDs: []uint64{3}}}},
+						},
+					},
+				},
+			},
+		},
+		Users:           []meta.UserInfo{{Name: "susy", Roles: []string{"analysts"}}},
+		MaxNodeID:       1,
+		MaxShardGroupID: 4,
+		MaxShardID:      2,
+	}
+
+	if a := data.Check(); !reflect.DeepEqual(a, []string{
+		"database db0: default retention policy rp1 not found",
+		"shard 1: owner node 2 not found",
+		"database db0, retention policy rp0: shard group 2 has no shards",
+		"shard 2: no owners",
+		"shard 3: id greater than max shard id 2",
+		"user susy: role analysts not found",
+	}) {
+		t.Fatalf("unexpected problems: %#v", a)
+	}
+}
+
+// Ensure inconsistencies in the data can be repaired.
+func TestData_Repair(t *testing.T) {
+	data := meta.Data{
+		Nodes: []meta.NodeInfo{{ID: 1, Host: "host0"}, {ID: 2, Host: "host1"}},
+		Databases: []meta.DatabaseInfo{
+			{
+				Name:                   "db0",
+				DefaultRetentionPolicy: "rp1",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{
+						Name: "rp0",
+						ShardGroups: []meta.ShardGroupInfo{
+							{ID: 1, Shards: []meta.ShardInfo{{ID: 1, OwnerIDs: []uint64{1, 3}}}},
+							{ID: 2},
+							{ID: 3, Shards: []meta.ShardInfo{{ID: 2, OwnerIDs: []uint64{3}}, {ID: 3}}},
+						},
+					},
+				},
+			},
+		},
+		Users:           []meta.UserInfo{{Name: "susy", Roles: []string{"analysts"}}},
+		MaxNodeID:       2,
+		MaxShardGroupID: 2,
+		MaxShardID:      3,
+	}
+
+	if a := data.Repair(); len(a) != 0 {
+		t.Fatalf("unexpected problems: %#v", a)
+	} else if di := data.Database("db0"); di.DefaultRetentionPolicy != "" {
+		t.Fatalf("unexpected default retention policy: %s", di.DefaultRetentionPolicy)
+	} else if data.MaxShardGroupID != 3 {
+		t.Fatalf("unexpected max shard group id: %d", data.MaxShardGroupID)
+	} else if len(data.User("susy").Roles) != 0 {
+		t.Fatalf("unexpected roles: %#v", data.User("susy").Roles)
+	}
+
+	// Shards without owners are assigned to the least loaded node.
+	groups := data.Databases[0].RetentionPolicies[0].ShardGroups
+	if len(groups) != 2 || groups[0].ID != 1 || groups[1].ID != 3 {
+		t.Fatalf("unexpected shard groups: %#v", groups)
+	} else if owners := groups[0].Shards[0].OwnerIDs; !reflect.DeepEqual(owners, []uint64{1}) {
+		t.Fatalf("unexpected shard 1 owners: %v", owners)
+	} else if owners := groups[1].Shards[0].OwnerIDs; !reflect.DeepEqual(owners, []uint64{2}) {
+		t.Fatalf("unexpected shard 2 owners: %v", owners)
+	} else if owners := groups[1].Shards[1].OwnerIDs; !reflect.DeepEqual(owners, []uint64{1}) {
+		t.Fatalf("unexpected shard 3 owners: %v", owners)
+	}
+}
+
+// Ensure shards are left alone when there are no nodes to assign them to.
+func TestData_Repair_NoNodes(t *testing.T) {
+	data := meta.Data{
+		Databases: []meta.DatabaseInfo{
+			{
+				Name: "db0",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{
+						Name: "rp0",
+						ShardGroups: []meta.ShardGroupInfo{
+							{ID: 1, Shards: []meta.ShardInfo{{ID: 1, OwnerIDs: []uint64{1}}}},
+						},
+					},
+				},
+			},
+		},
+		MaxShardGroupID: 1,
+		MaxShardID:      1,
+	}
+
+	if a := data.Repair(); !reflect.DeepEqual(a, []string{"shard 1: owner node 1 not found"}) {
+		t.Fatalf("unexpected problems: %#v", a)
+	}
+}
+
 // Ensure the data can be deeply copied.
 func TestData_Clone(t *testing.T) {
 	data := meta.Data{
@@ -648,6 +1458,13 @@ func TestData_MarshalBinary(t *testing.T) {
 				ContinuousQueries: []meta.ContinuousQueryInfo{
 					{Query: "SELECT count() FROM foo"},
 				},
+				Measurements: []meta.MeasurementInfo{
+					{
+						Name:    "cpu",
+						Fields:  []meta.FieldInfo{{Name: "value", Type: influxql.Float}},
+						TagKeys: []string{"host", "region"},
+					},
+				},
 			},
 		},
 		Users: []meta.UserInfo{
@@ -656,8 +1473,24 @@ func TestData_MarshalBinary(t *testing.T) {
 				Hash:       "ABC123",
 				Admin:      true,
 				Privileges: map[string]influxql.Privilege{"db0": influxql.AllPrivileges},
+				Roles:      []string{"analysts"},
+			},
+		},
+		APIKeys: []meta.APIKeyInfo{
+			{
+				Name:       "telegraf",
+				Hash:       "DEF456",
+				Privileges: map[string]influxql.Privilege{"db0": influxql.WritePrivilege},
+			},
+		},
+		Roles: []meta.RoleInfo{
+			{
+				Name:       "analysts",
+				Privileges: map[string]influxql.Privilege{"db1": influxql.ReadPrivilege},
 			},
 		},
+		Limits:           meta.Limits{MaxDatabases: 10, MaxSeriesPerDatabase: 1000},
+		PasswordHashCost: 12,
 	}
 
 	// Marshal the data struture.
@@ -680,5 +1513,29 @@ func TestData_MarshalBinary(t *testing.T) {
 		t.Fatalf("unexpected databases: %#v", other.Databases)
 	} else if !reflect.DeepEqual(data.Users, other.Users) {
 		t.Fatalf("unexpected users: %#v", other.Users)
+	} else if !reflect.DeepEqual(data.APIKeys, other.APIKeys) {
+		t.Fatalf("unexpected api keys: %#v", other.APIKeys)
+	} else if !reflect.DeepEqual(data.Roles, other.Roles) {
+		t.Fatalf("unexpected roles: %#v", other.Roles)
+	} else if data.Limits != other.Limits {
+		t.Fatalf("unexpected limits: %#v", other.Limits)
+	} else if other.PasswordHashCost != 12 {
+		t.Fatalf("unexpected password hash cost: %d", other.PasswordHashCost)
+	}
+}
+
+// Ensure the cluster's password hash cost must be one bcrypt supports.
+func TestData_SetPasswordHashCost(t *testing.T) {
+	var data meta.Data
+	if err := data.SetPasswordHashCost(bcrypt.MinCost); err != nil {
+		t.Fatal(err)
+	} else if data.PasswordHashCost != bcrypt.MinCost {
+		t.Fatalf("unexpected password hash cost: %d", data.PasswordHashCost)
+	} else if err := data.SetPasswordHashCost(bcrypt.MaxCost + 1); err != meta.ErrPasswordHashCostInvalid {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := data.SetPasswordHashCost(0); err != nil {
+		t.Fatal(err)
+	} else if data.PasswordHashCost != 0 {
+		t.Fatalf("unexpected password hash cost: %d", data.PasswordHashCost)
 	}
 }
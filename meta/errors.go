@@ -14,6 +14,16 @@ var (
 
 	// ErrTooManyPeers is returned when more than 3 peers are used.
 	ErrTooManyPeers = errors.New("too many peers; influxdb v0.9.0 is limited to 3 nodes in a cluster")
+
+	// ErrObserverPeersRequired is returned when opening an observer without peers.
+	ErrObserverPeersRequired = errors.New("observer requires at least one peer")
+
+	// ErrObserver is returned when applying a command to an observer.
+	ErrObserver = errors.New("cannot apply commands to an observer")
+
+	// ErrReadOnly is returned when changing the metadata while the cluster
+	// is in read-only maintenance mode.
+	ErrReadOnly = errors.New("metadata is read-only for maintenance")
 )
 
 var (
@@ -26,6 +36,13 @@ var (
 	// ErrNodesRequired is returned when at least one node is required for an operation.
 	// This occurs when creating a shard group.
 	ErrNodesRequired = errors.New("at least one node required")
+
+	// ErrNodeOwnsShards is returned when removing a node that still owns shards.
+	ErrNodeOwnsShards = errors.New("node owns shards")
+
+	// ErrJoinTokenInvalid is returned when a node registers without the
+	// join token of the cluster.
+	ErrJoinTokenInvalid = errors.New("invalid join token")
 )
 
 var (
@@ -58,9 +75,22 @@ var (
 	ErrRetentionPolicyDurationTooLow = errors.New(fmt.Sprintf("retention policy duration must be at least %s",
 		RetentionPolicyMinDuration))
 
+	// ErrShardGroupDurationTooLow is returned when a retention policy's
+	// shard group duration is lower than the allowed minimum.
+	ErrShardGroupDurationTooLow = errors.New(fmt.Sprintf("shard group duration must be at least %s",
+		RetentionPolicyMinDuration))
+
+	// ErrShardGroupDurationTooHigh is returned when a retention policy's
+	// shard group duration is longer than the policy's duration.
+	ErrShardGroupDurationTooHigh = errors.New("shard group duration must not exceed retention policy duration")
+
 	// ErrReplicationFactorMismatch is returned when the replication factor
 	// does not match the number of nodes in the cluster. This is a temporary
 	// restriction until v0.9.1 is released.
+	// ErrMeasurementTTLInvalid is returned when setting a negative
+	// measurement TTL on a retention policy.
+	ErrMeasurementTTLInvalid = errors.New("measurement ttl must not be negative")
+
 	ErrReplicationFactorMismatch = errors.New("replication factor must match cluster size; this limitation will be lifted in v0.9.1")
 )
 
@@ -70,6 +100,17 @@ var (
 
 	// ErrShardGroupNotFound is returned when mutating a shard group that doesn't exist.
 	ErrShardGroupNotFound = errors.New("shard group not found")
+
+	// ErrShardNotFound is returned when mutating a shard that doesn't exist.
+	ErrShardNotFound = errors.New("shard not found")
+
+	// ErrShardOwnerExists is returned when assigning a shard to a node that
+	// already owns it.
+	ErrShardOwnerExists = errors.New("shard owner already exists")
+
+	// ErrShardOwnerNotFound is returned when reassigning a shard from a node
+	// that doesn't own it.
+	ErrShardOwnerNotFound = errors.New("shard owner not found")
 )
 
 var (
@@ -89,12 +130,77 @@ var (
 
 	// ErrUsernameRequired is returned when creating a user without a username.
 	ErrUsernameRequired = errors.New("username required")
+
+	// ErrPasswordExpired is returned when authenticating a user whose
+	// password must be changed first.
+	ErrPasswordExpired = errors.New("password expired")
+)
+
+var (
+	// ErrAPIKeyExists is returned when creating an already existing API key.
+	ErrAPIKeyExists = errors.New("api key already exists")
+
+	// ErrAPIKeyNotFound is returned when removing an API key that doesn't exist.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+
+	// ErrAPIKeyNameRequired is returned when creating an API key without a name.
+	ErrAPIKeyNameRequired = errors.New("api key name required")
+
+	// ErrAPIKeyPrivilegesRequired is returned when creating an API key
+	// without any privileges.
+	ErrAPIKeyPrivilegesRequired = errors.New("api key privileges required")
+
+	// ErrAPIKeyInvalid is returned when authenticating with an unknown API key.
+	ErrAPIKeyInvalid = errors.New("invalid api key")
+)
+
+var (
+	// ErrRoleExists is returned when creating an already existing role.
+	ErrRoleExists = errors.New("role already exists")
+
+	// ErrRoleNotFound is returned when mutating or granting a role that doesn't exist.
+	ErrRoleNotFound = errors.New("role not found")
+
+	// ErrRoleNameRequired is returned when creating a role without a name.
+	ErrRoleNameRequired = errors.New("role name required")
+)
+
+var (
+	// ErrSnapshotPathRequired is returned when recording a snapshot without a path.
+	ErrSnapshotPathRequired = errors.New("snapshot path required")
+)
+
+var (
+	// ErrLimitNotFound is returned when setting a limit that doesn't exist.
+	ErrLimitNotFound = errors.New("limit not found")
+
+	// ErrLimitInvalid is returned when setting a limit to a negative value.
+	ErrLimitInvalid = errors.New("limit must not be negative")
+
+	// ErrMaxDatabasesExceeded is returned when creating a database would
+	// exceed the max_databases limit.
+	ErrMaxDatabasesExceeded = errors.New("max databases exceeded")
+
+	// ErrMaxRetentionPoliciesExceeded is returned when creating a retention
+	// policy would exceed the max_retention_policies limit.
+	ErrMaxRetentionPoliciesExceeded = errors.New("max retention policies exceeded")
+)
+
+var (
+	// ErrPasswordHashCostInvalid is returned when setting a password hash
+	// cost outside of the range bcrypt supports.
+	ErrPasswordHashCostInvalid = errors.New("password hash cost out of range")
 )
 
 var errs = [...]error{
-	ErrStoreOpen, ErrStoreClosed,
-	ErrNodeExists, ErrNodeNotFound,
+	ErrStoreOpen, ErrStoreClosed, ErrReadOnly,
+	ErrNodeExists, ErrNodeNotFound, ErrNodeOwnsShards, ErrJoinTokenInvalid,
+	ErrShardNotFound, ErrShardOwnerExists, ErrShardOwnerNotFound,
 	ErrDatabaseExists, ErrDatabaseNotFound, ErrDatabaseNameRequired,
+	ErrLimitNotFound, ErrLimitInvalid,
+	ErrMaxDatabasesExceeded, ErrMaxRetentionPoliciesExceeded,
+	ErrPasswordHashCostInvalid,
+	ErrUserNotFound, ErrPasswordExpired, ErrAPIKeyInvalid,
 }
 
 // errLookup stores a mapping of error strings to well defined error types.
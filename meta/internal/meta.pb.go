@@ -18,6 +18,8 @@ It has these top-level messages:
 	ContinuousQueryInfo
 	UserInfo
 	UserPrivilege
+	SnapshotInfo
+	SnapshotShardInfo
 	Command
 	CreateNodeCommand
 	DeleteNodeCommand
@@ -36,6 +38,9 @@ It has these top-level messages:
 	UpdateUserCommand
 	SetPrivilegeCommand
 	SetDataCommand
+	SetNodeDrainingCommand
+	ReassignShardCommand
+	CreateSnapshotCommand
 	Response
 */
 package internal
@@ -67,6 +72,28 @@ const (
 	Command_UpdateUserCommand                Command_Type = 15
 	Command_SetPrivilegeCommand              Command_Type = 16
 	Command_SetDataCommand                   Command_Type = 17
+	Command_SetNodeDrainingCommand           Command_Type = 18
+	Command_ReassignShardCommand             Command_Type = 19
+	Command_CreateSnapshotCommand            Command_Type = 20
+	Command_SetNodeZoneCommand               Command_Type = 21
+	Command_CreateAPIKeyCommand              Command_Type = 22
+	Command_DropAPIKeyCommand                Command_Type = 23
+	Command_CreateRoleCommand                Command_Type = 24
+	Command_DropRoleCommand                  Command_Type = 25
+	Command_SetRolePrivilegeCommand          Command_Type = 26
+	Command_GrantRoleCommand                 Command_Type = 27
+	Command_RevokeRoleCommand                Command_Type = 28
+	Command_RepairCommand                    Command_Type = 29
+	Command_ExpirePasswordCommand            Command_Type = 30
+	Command_PrecreateShardGroupsCommand      Command_Type = 31
+	Command_SetLimitCommand                  Command_Type = 32
+	Command_RenameDatabaseCommand            Command_Type = 33
+	Command_UpdateSchemaCommand              Command_Type = 34
+	Command_ReplicateCommand                 Command_Type = 35
+	Command_SetReadOnlyCommand               Command_Type = 36
+	Command_PruneShardGroupsCommand          Command_Type = 37
+	Command_SetMeasurementTTLCommand         Command_Type = 38
+	Command_SetPasswordHashCostCommand       Command_Type = 39
 )
 
 var Command_Type_name = map[int32]string{
@@ -87,6 +114,28 @@ var Command_Type_name = map[int32]string{
 	15: "UpdateUserCommand",
 	16: "SetPrivilegeCommand",
 	17: "SetDataCommand",
+	18: "SetNodeDrainingCommand",
+	19: "ReassignShardCommand",
+	20: "CreateSnapshotCommand",
+	21: "SetNodeZoneCommand",
+	22: "CreateAPIKeyCommand",
+	23: "DropAPIKeyCommand",
+	24: "CreateRoleCommand",
+	25: "DropRoleCommand",
+	26: "SetRolePrivilegeCommand",
+	27: "GrantRoleCommand",
+	28: "RevokeRoleCommand",
+	29: "RepairCommand",
+	30: "ExpirePasswordCommand",
+	31: "PrecreateShardGroupsCommand",
+	32: "SetLimitCommand",
+	33: "RenameDatabaseCommand",
+	34: "UpdateSchemaCommand",
+	35: "ReplicateCommand",
+	36: "SetReadOnlyCommand",
+	37: "PruneShardGroupsCommand",
+	38: "SetMeasurementTTLCommand",
+	39: "SetPasswordHashCostCommand",
 }
 var Command_Type_value = map[string]int32{
 	"CreateNodeCommand":                1,
@@ -106,6 +155,28 @@ var Command_Type_value = map[string]int32{
 	"UpdateUserCommand":                15,
 	"SetPrivilegeCommand":              16,
 	"SetDataCommand":                   17,
+	"SetNodeDrainingCommand":           18,
+	"ReassignShardCommand":             19,
+	"CreateSnapshotCommand":            20,
+	"SetNodeZoneCommand":               21,
+	"CreateAPIKeyCommand":              22,
+	"DropAPIKeyCommand":                23,
+	"CreateRoleCommand":                24,
+	"DropRoleCommand":                  25,
+	"SetRolePrivilegeCommand":          26,
+	"GrantRoleCommand":                 27,
+	"RevokeRoleCommand":                28,
+	"RepairCommand":                    29,
+	"ExpirePasswordCommand":            30,
+	"PrecreateShardGroupsCommand":      31,
+	"SetLimitCommand":                  32,
+	"RenameDatabaseCommand":            33,
+	"UpdateSchemaCommand":              34,
+	"ReplicateCommand":                 35,
+	"SetReadOnlyCommand":               36,
+	"PruneShardGroupsCommand":          37,
+	"SetMeasurementTTLCommand":         38,
+	"SetPasswordHashCostCommand":       39,
 }
 
 func (x Command_Type) Enum() *Command_Type {
@@ -135,6 +206,14 @@ type Data struct {
 	MaxNodeID        *uint64         `protobuf:"varint,7,req" json:"MaxNodeID,omitempty"`
 	MaxShardGroupID  *uint64         `protobuf:"varint,8,req" json:"MaxShardGroupID,omitempty"`
 	MaxShardID       *uint64         `protobuf:"varint,9,req" json:"MaxShardID,omitempty"`
+	Snapshots        []*SnapshotInfo `protobuf:"bytes,10,rep" json:"Snapshots,omitempty"`
+	MaxSnapshotID    *uint64         `protobuf:"varint,11,opt" json:"MaxSnapshotID,omitempty"`
+	APIKeys          []*APIKeyInfo   `protobuf:"bytes,12,rep" json:"APIKeys,omitempty"`
+	Roles            []*RoleInfo     `protobuf:"bytes,13,rep" json:"Roles,omitempty"`
+	Limits           *Limits         `protobuf:"bytes,14,opt" json:"Limits,omitempty"`
+	Version          *uint64         `protobuf:"varint,15,opt" json:"Version,omitempty"`
+	ReadOnly         *bool           `protobuf:"varint,16,opt" json:"ReadOnly,omitempty"`
+	PasswordHashCost *int64          `protobuf:"varint,17,opt" json:"PasswordHashCost,omitempty"`
 	XXX_unrecognized []byte          `json:"-"`
 }
 
@@ -205,9 +284,100 @@ func (m *Data) GetMaxShardID() uint64 {
 	return 0
 }
 
+func (m *Data) GetSnapshots() []*SnapshotInfo {
+	if m != nil {
+		return m.Snapshots
+	}
+	return nil
+}
+
+func (m *Data) GetMaxSnapshotID() uint64 {
+	if m != nil && m.MaxSnapshotID != nil {
+		return *m.MaxSnapshotID
+	}
+	return 0
+}
+
+func (m *Data) GetAPIKeys() []*APIKeyInfo {
+	if m != nil {
+		return m.APIKeys
+	}
+	return nil
+}
+
+func (m *Data) GetRoles() []*RoleInfo {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+func (m *Data) GetLimits() *Limits {
+	if m != nil {
+		return m.Limits
+	}
+	return nil
+}
+
+func (m *Data) GetVersion() uint64 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *Data) GetReadOnly() bool {
+	if m != nil && m.ReadOnly != nil {
+		return *m.ReadOnly
+	}
+	return false
+}
+
+func (m *Data) GetPasswordHashCost() int64 {
+	if m != nil && m.PasswordHashCost != nil {
+		return *m.PasswordHashCost
+	}
+	return 0
+}
+
+type Limits struct {
+	MaxDatabases         *int64 `protobuf:"varint,1,opt" json:"MaxDatabases,omitempty"`
+	MaxRetentionPolicies *int64 `protobuf:"varint,2,opt" json:"MaxRetentionPolicies,omitempty"`
+	MaxSeriesPerDatabase *int64 `protobuf:"varint,3,opt" json:"MaxSeriesPerDatabase,omitempty"`
+	XXX_unrecognized     []byte `json:"-"`
+}
+
+func (m *Limits) Reset()         { *m = Limits{} }
+func (m *Limits) String() string { return proto.CompactTextString(m) }
+func (*Limits) ProtoMessage()    {}
+
+func (m *Limits) GetMaxDatabases() int64 {
+	if m != nil && m.MaxDatabases != nil {
+		return *m.MaxDatabases
+	}
+	return 0
+}
+
+func (m *Limits) GetMaxRetentionPolicies() int64 {
+	if m != nil && m.MaxRetentionPolicies != nil {
+		return *m.MaxRetentionPolicies
+	}
+	return 0
+}
+
+func (m *Limits) GetMaxSeriesPerDatabase() int64 {
+	if m != nil && m.MaxSeriesPerDatabase != nil {
+		return *m.MaxSeriesPerDatabase
+	}
+	return 0
+}
+
 type NodeInfo struct {
 	ID               *uint64 `protobuf:"varint,1,req" json:"ID,omitempty"`
 	Host             *string `protobuf:"bytes,2,req" json:"Host,omitempty"`
+	Draining         *bool   `protobuf:"varint,3,opt" json:"Draining,omitempty"`
+	Zone             *string `protobuf:"bytes,4,opt" json:"Zone,omitempty"`
+	Rack             *string `protobuf:"bytes,5,opt" json:"Rack,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -229,11 +399,33 @@ func (m *NodeInfo) GetHost() string {
 	return ""
 }
 
+func (m *NodeInfo) GetDraining() bool {
+	if m != nil && m.Draining != nil {
+		return *m.Draining
+	}
+	return false
+}
+
+func (m *NodeInfo) GetZone() string {
+	if m != nil && m.Zone != nil {
+		return *m.Zone
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetRack() string {
+	if m != nil && m.Rack != nil {
+		return *m.Rack
+	}
+	return ""
+}
+
 type DatabaseInfo struct {
 	Name                   *string                `protobuf:"bytes,1,req" json:"Name,omitempty"`
 	DefaultRetentionPolicy *string                `protobuf:"bytes,2,req" json:"DefaultRetentionPolicy,omitempty"`
 	RetentionPolicies      []*RetentionPolicyInfo `protobuf:"bytes,3,rep" json:"RetentionPolicies,omitempty"`
 	ContinuousQueries      []*ContinuousQueryInfo `protobuf:"bytes,4,rep" json:"ContinuousQueries,omitempty"`
+	Measurements           []*MeasurementInfo     `protobuf:"bytes,5,rep" json:"Measurements,omitempty"`
 	XXX_unrecognized       []byte                 `json:"-"`
 }
 
@@ -269,13 +461,77 @@ func (m *DatabaseInfo) GetContinuousQueries() []*ContinuousQueryInfo {
 	return nil
 }
 
+func (m *DatabaseInfo) GetMeasurements() []*MeasurementInfo {
+	if m != nil {
+		return m.Measurements
+	}
+	return nil
+}
+
+type MeasurementInfo struct {
+	Name             *string      `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Fields           []*FieldInfo `protobuf:"bytes,2,rep" json:"Fields,omitempty"`
+	TagKeys          []string     `protobuf:"bytes,3,rep" json:"TagKeys,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *MeasurementInfo) Reset()         { *m = MeasurementInfo{} }
+func (m *MeasurementInfo) String() string { return proto.CompactTextString(m) }
+func (*MeasurementInfo) ProtoMessage()    {}
+
+func (m *MeasurementInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *MeasurementInfo) GetFields() []*FieldInfo {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *MeasurementInfo) GetTagKeys() []string {
+	if m != nil {
+		return m.TagKeys
+	}
+	return nil
+}
+
+type FieldInfo struct {
+	Name             *string `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Type             *int32  `protobuf:"varint,2,req" json:"Type,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *FieldInfo) Reset()         { *m = FieldInfo{} }
+func (m *FieldInfo) String() string { return proto.CompactTextString(m) }
+func (*FieldInfo) ProtoMessage()    {}
+
+func (m *FieldInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *FieldInfo) GetType() int32 {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return 0
+}
+
 type RetentionPolicyInfo struct {
-	Name               *string           `protobuf:"bytes,1,req" json:"Name,omitempty"`
-	Duration           *int64            `protobuf:"varint,2,req" json:"Duration,omitempty"`
-	ShardGroupDuration *int64            `protobuf:"varint,3,req" json:"ShardGroupDuration,omitempty"`
-	ReplicaN           *uint32           `protobuf:"varint,4,req" json:"ReplicaN,omitempty"`
-	ShardGroups        []*ShardGroupInfo `protobuf:"bytes,5,rep" json:"ShardGroups,omitempty"`
-	XXX_unrecognized   []byte            `json:"-"`
+	Name               *string               `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Duration           *int64                `protobuf:"varint,2,req" json:"Duration,omitempty"`
+	ShardGroupDuration *int64                `protobuf:"varint,3,req" json:"ShardGroupDuration,omitempty"`
+	ReplicaN           *uint32               `protobuf:"varint,4,req" json:"ReplicaN,omitempty"`
+	ShardGroups        []*ShardGroupInfo     `protobuf:"bytes,5,rep" json:"ShardGroups,omitempty"`
+	MeasurementTTLs    []*MeasurementTTLInfo `protobuf:"bytes,6,rep" json:"MeasurementTTLs,omitempty"`
+	XXX_unrecognized   []byte                `json:"-"`
 }
 
 func (m *RetentionPolicyInfo) Reset()         { *m = RetentionPolicyInfo{} }
@@ -317,6 +573,37 @@ func (m *RetentionPolicyInfo) GetShardGroups() []*ShardGroupInfo {
 	return nil
 }
 
+func (m *RetentionPolicyInfo) GetMeasurementTTLs() []*MeasurementTTLInfo {
+	if m != nil {
+		return m.MeasurementTTLs
+	}
+	return nil
+}
+
+type MeasurementTTLInfo struct {
+	Name             *string `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	TTL              *int64  `protobuf:"varint,2,req" json:"TTL,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *MeasurementTTLInfo) Reset()         { *m = MeasurementTTLInfo{} }
+func (m *MeasurementTTLInfo) String() string { return proto.CompactTextString(m) }
+func (*MeasurementTTLInfo) ProtoMessage()    {}
+
+func (m *MeasurementTTLInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *MeasurementTTLInfo) GetTTL() int64 {
+	if m != nil && m.TTL != nil {
+		return *m.TTL
+	}
+	return 0
+}
+
 type ShardGroupInfo struct {
 	ID               *uint64      `protobuf:"varint,1,req" json:"ID,omitempty"`
 	StartTime        *int64       `protobuf:"varint,2,req" json:"StartTime,omitempty"`
@@ -418,6 +705,8 @@ type UserInfo struct {
 	Hash             *string          `protobuf:"bytes,2,req" json:"Hash,omitempty"`
 	Admin            *bool            `protobuf:"varint,3,req" json:"Admin,omitempty"`
 	Privileges       []*UserPrivilege `protobuf:"bytes,4,rep" json:"Privileges,omitempty"`
+	Roles            []string         `protobuf:"bytes,5,rep" json:"Roles,omitempty"`
+	PasswordExpired  *bool            `protobuf:"varint,6,opt" json:"PasswordExpired,omitempty"`
 	XXX_unrecognized []byte           `json:"-"`
 }
 
@@ -453,6 +742,20 @@ func (m *UserInfo) GetPrivileges() []*UserPrivilege {
 	return nil
 }
 
+func (m *UserInfo) GetRoles() []string {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+func (m *UserInfo) GetPasswordExpired() bool {
+	if m != nil && m.PasswordExpired != nil {
+		return *m.PasswordExpired
+	}
+	return false
+}
+
 type UserPrivilege struct {
 	Database         *string `protobuf:"bytes,1,req" json:"Database,omitempty"`
 	Privilege        *int32  `protobuf:"varint,2,req" json:"Privilege,omitempty"`
@@ -477,6 +780,150 @@ func (m *UserPrivilege) GetPrivilege() int32 {
 	return 0
 }
 
+type APIKeyInfo struct {
+	Name             *string          `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Hash             *string          `protobuf:"bytes,2,req" json:"Hash,omitempty"`
+	Privileges       []*UserPrivilege `protobuf:"bytes,3,rep" json:"Privileges,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
+}
+
+func (m *APIKeyInfo) Reset()         { *m = APIKeyInfo{} }
+func (m *APIKeyInfo) String() string { return proto.CompactTextString(m) }
+func (*APIKeyInfo) ProtoMessage()    {}
+
+func (m *APIKeyInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *APIKeyInfo) GetHash() string {
+	if m != nil && m.Hash != nil {
+		return *m.Hash
+	}
+	return ""
+}
+
+func (m *APIKeyInfo) GetPrivileges() []*UserPrivilege {
+	if m != nil {
+		return m.Privileges
+	}
+	return nil
+}
+
+type RoleInfo struct {
+	Name             *string          `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Privileges       []*UserPrivilege `protobuf:"bytes,2,rep" json:"Privileges,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
+}
+
+func (m *RoleInfo) Reset()         { *m = RoleInfo{} }
+func (m *RoleInfo) String() string { return proto.CompactTextString(m) }
+func (*RoleInfo) ProtoMessage()    {}
+
+func (m *RoleInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *RoleInfo) GetPrivileges() []*UserPrivilege {
+	if m != nil {
+		return m.Privileges
+	}
+	return nil
+}
+
+type SnapshotInfo struct {
+	ID               *uint64              `protobuf:"varint,1,req" json:"ID,omitempty"`
+	Time             *int64               `protobuf:"varint,2,req" json:"Time,omitempty"`
+	CreatedAt        *int64               `protobuf:"varint,3,req" json:"CreatedAt,omitempty"`
+	Path             *string              `protobuf:"bytes,4,req" json:"Path,omitempty"`
+	Shards           []*SnapshotShardInfo `protobuf:"bytes,5,rep" json:"Shards,omitempty"`
+	XXX_unrecognized []byte               `json:"-"`
+}
+
+func (m *SnapshotInfo) Reset()         { *m = SnapshotInfo{} }
+func (m *SnapshotInfo) String() string { return proto.CompactTextString(m) }
+func (*SnapshotInfo) ProtoMessage()    {}
+
+func (m *SnapshotInfo) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *SnapshotInfo) GetTime() int64 {
+	if m != nil && m.Time != nil {
+		return *m.Time
+	}
+	return 0
+}
+
+func (m *SnapshotInfo) GetCreatedAt() int64 {
+	if m != nil && m.CreatedAt != nil {
+		return *m.CreatedAt
+	}
+	return 0
+}
+
+func (m *SnapshotInfo) GetPath() string {
+	if m != nil && m.Path != nil {
+		return *m.Path
+	}
+	return ""
+}
+
+func (m *SnapshotInfo) GetShards() []*SnapshotShardInfo {
+	if m != nil {
+		return m.Shards
+	}
+	return nil
+}
+
+type SnapshotShardInfo struct {
+	ID               *uint64 `protobuf:"varint,1,req" json:"ID,omitempty"`
+	Database         *string `protobuf:"bytes,2,req" json:"Database,omitempty"`
+	RetentionPolicy  *string `protobuf:"bytes,3,req" json:"RetentionPolicy,omitempty"`
+	NodeID           *uint64 `protobuf:"varint,4,req" json:"NodeID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SnapshotShardInfo) Reset()         { *m = SnapshotShardInfo{} }
+func (m *SnapshotShardInfo) String() string { return proto.CompactTextString(m) }
+func (*SnapshotShardInfo) ProtoMessage()    {}
+
+func (m *SnapshotShardInfo) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *SnapshotShardInfo) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SnapshotShardInfo) GetRetentionPolicy() string {
+	if m != nil && m.RetentionPolicy != nil {
+		return *m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *SnapshotShardInfo) GetNodeID() uint64 {
+	if m != nil && m.NodeID != nil {
+		return *m.NodeID
+	}
+	return 0
+}
+
 type Command struct {
 	Type             *Command_Type             `protobuf:"varint,1,req,name=type,enum=internal.Command_Type" json:"type,omitempty"`
 	XXX_extensions   map[int32]proto.Extension `json:"-"`
@@ -511,6 +958,7 @@ func (m *Command) GetType() Command_Type {
 type CreateNodeCommand struct {
 	Host             *string `protobuf:"bytes,1,req" json:"Host,omitempty"`
 	Rand             *uint64 `protobuf:"varint,2,req" json:"Rand,omitempty"`
+	JoinToken        *string `protobuf:"bytes,3,opt" json:"JoinToken,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -532,6 +980,13 @@ func (m *CreateNodeCommand) GetRand() uint64 {
 	return 0
 }
 
+func (m *CreateNodeCommand) GetJoinToken() string {
+	if m != nil && m.JoinToken != nil {
+		return *m.JoinToken
+	}
+	return ""
+}
+
 var E_CreateNodeCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*CreateNodeCommand)(nil),
@@ -542,6 +997,7 @@ var E_CreateNodeCommand_Command = &proto.ExtensionDesc{
 
 type DeleteNodeCommand struct {
 	ID               *uint64 `protobuf:"varint,1,req" json:"ID,omitempty"`
+	Force            *bool   `protobuf:"varint,2,opt" json:"Force,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -556,6 +1012,13 @@ func (m *DeleteNodeCommand) GetID() uint64 {
 	return 0
 }
 
+func (m *DeleteNodeCommand) GetForce() bool {
+	if m != nil && m.Force != nil {
+		return *m.Force
+	}
+	return false
+}
+
 var E_DeleteNodeCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*DeleteNodeCommand)(nil),
@@ -709,12 +1172,13 @@ var E_SetDefaultRetentionPolicyCommand_Command = &proto.ExtensionDesc{
 }
 
 type UpdateRetentionPolicyCommand struct {
-	Database         *string `protobuf:"bytes,1,req" json:"Database,omitempty"`
-	Name             *string `protobuf:"bytes,2,req" json:"Name,omitempty"`
-	NewName          *string `protobuf:"bytes,3,opt" json:"NewName,omitempty"`
-	Duration         *int64  `protobuf:"varint,4,opt" json:"Duration,omitempty"`
-	ReplicaN         *uint32 `protobuf:"varint,5,opt" json:"ReplicaN,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	Database           *string `protobuf:"bytes,1,req" json:"Database,omitempty"`
+	Name               *string `protobuf:"bytes,2,req" json:"Name,omitempty"`
+	NewName            *string `protobuf:"bytes,3,opt" json:"NewName,omitempty"`
+	Duration           *int64  `protobuf:"varint,4,opt" json:"Duration,omitempty"`
+	ReplicaN           *uint32 `protobuf:"varint,5,opt" json:"ReplicaN,omitempty"`
+	ShardGroupDuration *int64  `protobuf:"varint,6,opt" json:"ShardGroupDuration,omitempty"`
+	XXX_unrecognized   []byte  `json:"-"`
 }
 
 func (m *UpdateRetentionPolicyCommand) Reset()         { *m = UpdateRetentionPolicyCommand{} }
@@ -756,6 +1220,13 @@ func (m *UpdateRetentionPolicyCommand) GetReplicaN() uint32 {
 	return 0
 }
 
+func (m *UpdateRetentionPolicyCommand) GetShardGroupDuration() int64 {
+	if m != nil && m.ShardGroupDuration != nil {
+		return *m.ShardGroupDuration
+	}
+	return 0
+}
+
 var E_UpdateRetentionPolicyCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*UpdateRetentionPolicyCommand)(nil),
@@ -1076,19 +1547,686 @@ var E_SetDataCommand_Command = &proto.ExtensionDesc{
 	Tag:           "bytes,117,opt,name=command",
 }
 
-type Response struct {
-	OK               *bool   `protobuf:"varint,1,req" json:"OK,omitempty"`
-	Error            *string `protobuf:"bytes,2,opt" json:"Error,omitempty"`
-	Index            *uint64 `protobuf:"varint,3,opt" json:"Index,omitempty"`
+type SetNodeDrainingCommand struct {
+	ID               *uint64 `protobuf:"varint,1,req" json:"ID,omitempty"`
+	Draining         *bool   `protobuf:"varint,2,req" json:"Draining,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
-func (m *Response) Reset()         { *m = Response{} }
-func (m *Response) String() string { return proto.CompactTextString(m) }
-func (*Response) ProtoMessage()    {}
+func (m *SetNodeDrainingCommand) Reset()         { *m = SetNodeDrainingCommand{} }
+func (m *SetNodeDrainingCommand) String() string { return proto.CompactTextString(m) }
+func (*SetNodeDrainingCommand) ProtoMessage()    {}
 
-func (m *Response) GetOK() bool {
-	if m != nil && m.OK != nil {
+func (m *SetNodeDrainingCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *SetNodeDrainingCommand) GetDraining() bool {
+	if m != nil && m.Draining != nil {
+		return *m.Draining
+	}
+	return false
+}
+
+var E_SetNodeDrainingCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetNodeDrainingCommand)(nil),
+	Field:         118,
+	Name:          "internal.SetNodeDrainingCommand.command",
+	Tag:           "bytes,118,opt,name=command",
+}
+
+type ReassignShardCommand struct {
+	ShardID          *uint64 `protobuf:"varint,1,req" json:"ShardID,omitempty"`
+	OldOwnerID       *uint64 `protobuf:"varint,2,req" json:"OldOwnerID,omitempty"`
+	NewOwnerID       *uint64 `protobuf:"varint,3,req" json:"NewOwnerID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ReassignShardCommand) Reset()         { *m = ReassignShardCommand{} }
+func (m *ReassignShardCommand) String() string { return proto.CompactTextString(m) }
+func (*ReassignShardCommand) ProtoMessage()    {}
+
+func (m *ReassignShardCommand) GetShardID() uint64 {
+	if m != nil && m.ShardID != nil {
+		return *m.ShardID
+	}
+	return 0
+}
+
+func (m *ReassignShardCommand) GetOldOwnerID() uint64 {
+	if m != nil && m.OldOwnerID != nil {
+		return *m.OldOwnerID
+	}
+	return 0
+}
+
+func (m *ReassignShardCommand) GetNewOwnerID() uint64 {
+	if m != nil && m.NewOwnerID != nil {
+		return *m.NewOwnerID
+	}
+	return 0
+}
+
+var E_ReassignShardCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*ReassignShardCommand)(nil),
+	Field:         119,
+	Name:          "internal.ReassignShardCommand.command",
+	Tag:           "bytes,119,opt,name=command",
+}
+
+type CreateSnapshotCommand struct {
+	Snapshot         *SnapshotInfo `protobuf:"bytes,1,req" json:"Snapshot,omitempty"`
+	XXX_unrecognized []byte        `json:"-"`
+}
+
+func (m *CreateSnapshotCommand) Reset()         { *m = CreateSnapshotCommand{} }
+func (m *CreateSnapshotCommand) String() string { return proto.CompactTextString(m) }
+func (*CreateSnapshotCommand) ProtoMessage()    {}
+
+func (m *CreateSnapshotCommand) GetSnapshot() *SnapshotInfo {
+	if m != nil {
+		return m.Snapshot
+	}
+	return nil
+}
+
+var E_CreateSnapshotCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateSnapshotCommand)(nil),
+	Field:         120,
+	Name:          "internal.CreateSnapshotCommand.command",
+	Tag:           "bytes,120,opt,name=command",
+}
+
+type SetNodeZoneCommand struct {
+	ID               *uint64 `protobuf:"varint,1,req" json:"ID,omitempty"`
+	Zone             *string `protobuf:"bytes,2,req" json:"Zone,omitempty"`
+	Rack             *string `protobuf:"bytes,3,opt" json:"Rack,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetNodeZoneCommand) Reset()         { *m = SetNodeZoneCommand{} }
+func (m *SetNodeZoneCommand) String() string { return proto.CompactTextString(m) }
+func (*SetNodeZoneCommand) ProtoMessage()    {}
+
+func (m *SetNodeZoneCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *SetNodeZoneCommand) GetZone() string {
+	if m != nil && m.Zone != nil {
+		return *m.Zone
+	}
+	return ""
+}
+
+func (m *SetNodeZoneCommand) GetRack() string {
+	if m != nil && m.Rack != nil {
+		return *m.Rack
+	}
+	return ""
+}
+
+var E_SetNodeZoneCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetNodeZoneCommand)(nil),
+	Field:         121,
+	Name:          "internal.SetNodeZoneCommand.command",
+	Tag:           "bytes,121,opt,name=command",
+}
+
+type CreateAPIKeyCommand struct {
+	Name             *string          `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Hash             *string          `protobuf:"bytes,2,req" json:"Hash,omitempty"`
+	Privileges       []*UserPrivilege `protobuf:"bytes,3,rep" json:"Privileges,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
+}
+
+func (m *CreateAPIKeyCommand) Reset()         { *m = CreateAPIKeyCommand{} }
+func (m *CreateAPIKeyCommand) String() string { return proto.CompactTextString(m) }
+func (*CreateAPIKeyCommand) ProtoMessage()    {}
+
+func (m *CreateAPIKeyCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *CreateAPIKeyCommand) GetHash() string {
+	if m != nil && m.Hash != nil {
+		return *m.Hash
+	}
+	return ""
+}
+
+func (m *CreateAPIKeyCommand) GetPrivileges() []*UserPrivilege {
+	if m != nil {
+		return m.Privileges
+	}
+	return nil
+}
+
+var E_CreateAPIKeyCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateAPIKeyCommand)(nil),
+	Field:         122,
+	Name:          "internal.CreateAPIKeyCommand.command",
+	Tag:           "bytes,122,opt,name=command",
+}
+
+type DropAPIKeyCommand struct {
+	Name             *string `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DropAPIKeyCommand) Reset()         { *m = DropAPIKeyCommand{} }
+func (m *DropAPIKeyCommand) String() string { return proto.CompactTextString(m) }
+func (*DropAPIKeyCommand) ProtoMessage()    {}
+
+func (m *DropAPIKeyCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_DropAPIKeyCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropAPIKeyCommand)(nil),
+	Field:         123,
+	Name:          "internal.DropAPIKeyCommand.command",
+	Tag:           "bytes,123,opt,name=command",
+}
+
+type CreateRoleCommand struct {
+	Name             *string `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *CreateRoleCommand) Reset()         { *m = CreateRoleCommand{} }
+func (m *CreateRoleCommand) String() string { return proto.CompactTextString(m) }
+func (*CreateRoleCommand) ProtoMessage()    {}
+
+func (m *CreateRoleCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_CreateRoleCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateRoleCommand)(nil),
+	Field:         124,
+	Name:          "internal.CreateRoleCommand.command",
+	Tag:           "bytes,124,opt,name=command",
+}
+
+type DropRoleCommand struct {
+	Name             *string `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DropRoleCommand) Reset()         { *m = DropRoleCommand{} }
+func (m *DropRoleCommand) String() string { return proto.CompactTextString(m) }
+func (*DropRoleCommand) ProtoMessage()    {}
+
+func (m *DropRoleCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_DropRoleCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropRoleCommand)(nil),
+	Field:         125,
+	Name:          "internal.DropRoleCommand.command",
+	Tag:           "bytes,125,opt,name=command",
+}
+
+type SetRolePrivilegeCommand struct {
+	Role             *string `protobuf:"bytes,1,req" json:"Role,omitempty"`
+	Database         *string `protobuf:"bytes,2,req" json:"Database,omitempty"`
+	Privilege        *int32  `protobuf:"varint,3,req" json:"Privilege,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetRolePrivilegeCommand) Reset()         { *m = SetRolePrivilegeCommand{} }
+func (m *SetRolePrivilegeCommand) String() string { return proto.CompactTextString(m) }
+func (*SetRolePrivilegeCommand) ProtoMessage()    {}
+
+func (m *SetRolePrivilegeCommand) GetRole() string {
+	if m != nil && m.Role != nil {
+		return *m.Role
+	}
+	return ""
+}
+
+func (m *SetRolePrivilegeCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetRolePrivilegeCommand) GetPrivilege() int32 {
+	if m != nil && m.Privilege != nil {
+		return *m.Privilege
+	}
+	return 0
+}
+
+var E_SetRolePrivilegeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetRolePrivilegeCommand)(nil),
+	Field:         126,
+	Name:          "internal.SetRolePrivilegeCommand.command",
+	Tag:           "bytes,126,opt,name=command",
+}
+
+type GrantRoleCommand struct {
+	Username         *string `protobuf:"bytes,1,req" json:"Username,omitempty"`
+	Role             *string `protobuf:"bytes,2,req" json:"Role,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *GrantRoleCommand) Reset()         { *m = GrantRoleCommand{} }
+func (m *GrantRoleCommand) String() string { return proto.CompactTextString(m) }
+func (*GrantRoleCommand) ProtoMessage()    {}
+
+func (m *GrantRoleCommand) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *GrantRoleCommand) GetRole() string {
+	if m != nil && m.Role != nil {
+		return *m.Role
+	}
+	return ""
+}
+
+var E_GrantRoleCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*GrantRoleCommand)(nil),
+	Field:         127,
+	Name:          "internal.GrantRoleCommand.command",
+	Tag:           "bytes,127,opt,name=command",
+}
+
+type RevokeRoleCommand struct {
+	Username         *string `protobuf:"bytes,1,req" json:"Username,omitempty"`
+	Role             *string `protobuf:"bytes,2,req" json:"Role,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RevokeRoleCommand) Reset()         { *m = RevokeRoleCommand{} }
+func (m *RevokeRoleCommand) String() string { return proto.CompactTextString(m) }
+func (*RevokeRoleCommand) ProtoMessage()    {}
+
+func (m *RevokeRoleCommand) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *RevokeRoleCommand) GetRole() string {
+	if m != nil && m.Role != nil {
+		return *m.Role
+	}
+	return ""
+}
+
+var E_RevokeRoleCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*RevokeRoleCommand)(nil),
+	Field:         128,
+	Name:          "internal.RevokeRoleCommand.command",
+	Tag:           "bytes,128,opt,name=command",
+}
+
+type RepairCommand struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *RepairCommand) Reset()         { *m = RepairCommand{} }
+func (m *RepairCommand) String() string { return proto.CompactTextString(m) }
+func (*RepairCommand) ProtoMessage()    {}
+
+var E_RepairCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*RepairCommand)(nil),
+	Field:         129,
+	Name:          "internal.RepairCommand.command",
+	Tag:           "bytes,129,opt,name=command",
+}
+
+type ExpirePasswordCommand struct {
+	Username         *string `protobuf:"bytes,1,req" json:"Username,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ExpirePasswordCommand) Reset()         { *m = ExpirePasswordCommand{} }
+func (m *ExpirePasswordCommand) String() string { return proto.CompactTextString(m) }
+func (*ExpirePasswordCommand) ProtoMessage()    {}
+
+func (m *ExpirePasswordCommand) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+var E_ExpirePasswordCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*ExpirePasswordCommand)(nil),
+	Field:         130,
+	Name:          "internal.ExpirePasswordCommand.command",
+	Tag:           "bytes,130,opt,name=command",
+}
+
+type PrecreateShardGroupsCommand struct {
+	From             *int64 `protobuf:"varint,1,req" json:"From,omitempty"`
+	To               *int64 `protobuf:"varint,2,req" json:"To,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *PrecreateShardGroupsCommand) Reset()         { *m = PrecreateShardGroupsCommand{} }
+func (m *PrecreateShardGroupsCommand) String() string { return proto.CompactTextString(m) }
+func (*PrecreateShardGroupsCommand) ProtoMessage()    {}
+
+func (m *PrecreateShardGroupsCommand) GetFrom() int64 {
+	if m != nil && m.From != nil {
+		return *m.From
+	}
+	return 0
+}
+
+func (m *PrecreateShardGroupsCommand) GetTo() int64 {
+	if m != nil && m.To != nil {
+		return *m.To
+	}
+	return 0
+}
+
+var E_PrecreateShardGroupsCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*PrecreateShardGroupsCommand)(nil),
+	Field:         131,
+	Name:          "internal.PrecreateShardGroupsCommand.command",
+	Tag:           "bytes,131,opt,name=command",
+}
+
+type SetLimitCommand struct {
+	Name             *string `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Value            *int64  `protobuf:"varint,2,req" json:"Value,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetLimitCommand) Reset()         { *m = SetLimitCommand{} }
+func (m *SetLimitCommand) String() string { return proto.CompactTextString(m) }
+func (*SetLimitCommand) ProtoMessage()    {}
+
+func (m *SetLimitCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *SetLimitCommand) GetValue() int64 {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return 0
+}
+
+var E_SetLimitCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetLimitCommand)(nil),
+	Field:         132,
+	Name:          "internal.SetLimitCommand.command",
+	Tag:           "bytes,132,opt,name=command",
+}
+
+type RenameDatabaseCommand struct {
+	OldName          *string `protobuf:"bytes,1,req" json:"OldName,omitempty"`
+	NewName          *string `protobuf:"bytes,2,req" json:"NewName,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RenameDatabaseCommand) Reset()         { *m = RenameDatabaseCommand{} }
+func (m *RenameDatabaseCommand) String() string { return proto.CompactTextString(m) }
+func (*RenameDatabaseCommand) ProtoMessage()    {}
+
+func (m *RenameDatabaseCommand) GetOldName() string {
+	if m != nil && m.OldName != nil {
+		return *m.OldName
+	}
+	return ""
+}
+
+func (m *RenameDatabaseCommand) GetNewName() string {
+	if m != nil && m.NewName != nil {
+		return *m.NewName
+	}
+	return ""
+}
+
+var E_RenameDatabaseCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*RenameDatabaseCommand)(nil),
+	Field:         133,
+	Name:          "internal.RenameDatabaseCommand.command",
+	Tag:           "bytes,133,opt,name=command",
+}
+
+type UpdateSchemaCommand struct {
+	Database         *string            `protobuf:"bytes,1,req" json:"Database,omitempty"`
+	Measurements     []*MeasurementInfo `protobuf:"bytes,2,rep" json:"Measurements,omitempty"`
+	XXX_unrecognized []byte             `json:"-"`
+}
+
+func (m *UpdateSchemaCommand) Reset()         { *m = UpdateSchemaCommand{} }
+func (m *UpdateSchemaCommand) String() string { return proto.CompactTextString(m) }
+func (*UpdateSchemaCommand) ProtoMessage()    {}
+
+func (m *UpdateSchemaCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *UpdateSchemaCommand) GetMeasurements() []*MeasurementInfo {
+	if m != nil {
+		return m.Measurements
+	}
+	return nil
+}
+
+var E_UpdateSchemaCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*UpdateSchemaCommand)(nil),
+	Field:         134,
+	Name:          "internal.UpdateSchemaCommand.command",
+	Tag:           "bytes,134,opt,name=command",
+}
+
+type ReplicateCommand struct {
+	Data             *Data  `protobuf:"bytes,1,req" json:"Data,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *ReplicateCommand) Reset()         { *m = ReplicateCommand{} }
+func (m *ReplicateCommand) String() string { return proto.CompactTextString(m) }
+func (*ReplicateCommand) ProtoMessage()    {}
+
+func (m *ReplicateCommand) GetData() *Data {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+var E_ReplicateCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*ReplicateCommand)(nil),
+	Field:         135,
+	Name:          "internal.ReplicateCommand.command",
+	Tag:           "bytes,135,opt,name=command",
+}
+
+type SetReadOnlyCommand struct {
+	ReadOnly         *bool  `protobuf:"varint,1,req" json:"ReadOnly,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *SetReadOnlyCommand) Reset()         { *m = SetReadOnlyCommand{} }
+func (m *SetReadOnlyCommand) String() string { return proto.CompactTextString(m) }
+func (*SetReadOnlyCommand) ProtoMessage()    {}
+
+func (m *SetReadOnlyCommand) GetReadOnly() bool {
+	if m != nil && m.ReadOnly != nil {
+		return *m.ReadOnly
+	}
+	return false
+}
+
+var E_SetReadOnlyCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetReadOnlyCommand)(nil),
+	Field:         136,
+	Name:          "internal.SetReadOnlyCommand.command",
+	Tag:           "bytes,136,opt,name=command",
+}
+
+type PruneShardGroupsCommand struct {
+	Expiration       *int64 `protobuf:"varint,1,req" json:"Expiration,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *PruneShardGroupsCommand) Reset()         { *m = PruneShardGroupsCommand{} }
+func (m *PruneShardGroupsCommand) String() string { return proto.CompactTextString(m) }
+func (*PruneShardGroupsCommand) ProtoMessage()    {}
+
+func (m *PruneShardGroupsCommand) GetExpiration() int64 {
+	if m != nil && m.Expiration != nil {
+		return *m.Expiration
+	}
+	return 0
+}
+
+var E_PruneShardGroupsCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*PruneShardGroupsCommand)(nil),
+	Field:         137,
+	Name:          "internal.PruneShardGroupsCommand.command",
+	Tag:           "bytes,137,opt,name=command",
+}
+
+type SetMeasurementTTLCommand struct {
+	Database         *string `protobuf:"bytes,1,req" json:"Database,omitempty"`
+	Policy           *string `protobuf:"bytes,2,req" json:"Policy,omitempty"`
+	Measurement      *string `protobuf:"bytes,3,req" json:"Measurement,omitempty"`
+	TTL              *int64  `protobuf:"varint,4,req" json:"TTL,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetMeasurementTTLCommand) Reset()         { *m = SetMeasurementTTLCommand{} }
+func (m *SetMeasurementTTLCommand) String() string { return proto.CompactTextString(m) }
+func (*SetMeasurementTTLCommand) ProtoMessage()    {}
+
+func (m *SetMeasurementTTLCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetMeasurementTTLCommand) GetPolicy() string {
+	if m != nil && m.Policy != nil {
+		return *m.Policy
+	}
+	return ""
+}
+
+func (m *SetMeasurementTTLCommand) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
+func (m *SetMeasurementTTLCommand) GetTTL() int64 {
+	if m != nil && m.TTL != nil {
+		return *m.TTL
+	}
+	return 0
+}
+
+var E_SetMeasurementTTLCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetMeasurementTTLCommand)(nil),
+	Field:         138,
+	Name:          "internal.SetMeasurementTTLCommand.command",
+	Tag:           "bytes,138,opt,name=command",
+}
+
+type SetPasswordHashCostCommand struct {
+	Cost             *int64 `protobuf:"varint,1,req" json:"Cost,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *SetPasswordHashCostCommand) Reset()         { *m = SetPasswordHashCostCommand{} }
+func (m *SetPasswordHashCostCommand) String() string { return proto.CompactTextString(m) }
+func (*SetPasswordHashCostCommand) ProtoMessage()    {}
+
+func (m *SetPasswordHashCostCommand) GetCost() int64 {
+	if m != nil && m.Cost != nil {
+		return *m.Cost
+	}
+	return 0
+}
+
+var E_SetPasswordHashCostCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetPasswordHashCostCommand)(nil),
+	Field:         139,
+	Name:          "internal.SetPasswordHashCostCommand.command",
+	Tag:           "bytes,139,opt,name=command",
+}
+
+type Response struct {
+	OK               *bool   `protobuf:"varint,1,req" json:"OK,omitempty"`
+	Error            *string `protobuf:"bytes,2,opt" json:"Error,omitempty"`
+	Index            *uint64 `protobuf:"varint,3,opt" json:"Index,omitempty"`
+	Data             []byte  `protobuf:"bytes,4,opt" json:"Data,omitempty"`
+	Leader           *string `protobuf:"bytes,5,opt" json:"Leader,omitempty"`
+	Name             *string `protobuf:"bytes,6,opt" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Response) Reset()         { *m = Response{} }
+func (m *Response) String() string { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()    {}
+
+func (m *Response) GetOK() bool {
+	if m != nil && m.OK != nil {
 		return *m.OK
 	}
 	return false
@@ -1108,6 +2246,27 @@ func (m *Response) GetIndex() uint64 {
 	return 0
 }
 
+func (m *Response) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Response) GetLeader() string {
+	if m != nil && m.Leader != nil {
+		return *m.Leader
+	}
+	return ""
+}
+
+func (m *Response) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterEnum("internal.Command_Type", Command_Type_name, Command_Type_value)
 	proto.RegisterExtension(E_CreateNodeCommand_Command)
@@ -1127,4 +2286,26 @@ func init() {
 	proto.RegisterExtension(E_UpdateUserCommand_Command)
 	proto.RegisterExtension(E_SetPrivilegeCommand_Command)
 	proto.RegisterExtension(E_SetDataCommand_Command)
+	proto.RegisterExtension(E_SetNodeDrainingCommand_Command)
+	proto.RegisterExtension(E_ReassignShardCommand_Command)
+	proto.RegisterExtension(E_CreateSnapshotCommand_Command)
+	proto.RegisterExtension(E_SetNodeZoneCommand_Command)
+	proto.RegisterExtension(E_CreateAPIKeyCommand_Command)
+	proto.RegisterExtension(E_DropAPIKeyCommand_Command)
+	proto.RegisterExtension(E_CreateRoleCommand_Command)
+	proto.RegisterExtension(E_DropRoleCommand_Command)
+	proto.RegisterExtension(E_SetRolePrivilegeCommand_Command)
+	proto.RegisterExtension(E_GrantRoleCommand_Command)
+	proto.RegisterExtension(E_RevokeRoleCommand_Command)
+	proto.RegisterExtension(E_RepairCommand_Command)
+	proto.RegisterExtension(E_ExpirePasswordCommand_Command)
+	proto.RegisterExtension(E_PrecreateShardGroupsCommand_Command)
+	proto.RegisterExtension(E_SetLimitCommand_Command)
+	proto.RegisterExtension(E_RenameDatabaseCommand_Command)
+	proto.RegisterExtension(E_UpdateSchemaCommand_Command)
+	proto.RegisterExtension(E_ReplicateCommand_Command)
+	proto.RegisterExtension(E_SetReadOnlyCommand_Command)
+	proto.RegisterExtension(E_PruneShardGroupsCommand_Command)
+	proto.RegisterExtension(E_SetMeasurementTTLCommand_Command)
+	proto.RegisterExtension(E_SetPasswordHashCostCommand_Command)
 }
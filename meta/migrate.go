@@ -0,0 +1,45 @@
+package meta
+
+import (
+	"fmt"
+)
+
+// DataVersion is the version of the metadata format written by this release.
+// Metadata written before the version was stored has version 0.
+const DataVersion = uint64(len(migrations))
+
+// migrations upgrade the metadata from one version to the next. The
+// migration at index i upgrades version i to version i+1. New migrations
+// are only ever appended and must leave metadata that is already in the
+// new format unchanged.
+var migrations = [...]func(data *Data) error{
+	migrateShardGroupDurations,
+}
+
+// migrate upgrades data loaded with the given version to DataVersion.
+func (data *Data) migrate(version uint64) error {
+	if version > DataVersion {
+		return fmt.Errorf("metadata version %d is newer than supported version %d", version, DataVersion)
+	}
+
+	for v := version; v < DataVersion; v++ {
+		if err := migrations[v](data); err != nil {
+			return fmt.Errorf("migrate metadata to version %d: %s", v+1, err)
+		}
+	}
+	return nil
+}
+
+// migrateShardGroupDurations sets the shard group duration of retention
+// policies created before it was stored to the default for their duration.
+func migrateShardGroupDurations(data *Data) error {
+	for i := range data.Databases {
+		for j := range data.Databases[i].RetentionPolicies {
+			rpi := &data.Databases[i].RetentionPolicies[j]
+			if rpi.ShardGroupDuration == 0 {
+				rpi.ShardGroupDuration = shardGroupDuration(rpi.Duration)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package meta_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/meta/internal"
+)
+
+// Ensure metadata written before versioning is migrated when it is loaded.
+func TestData_UnmarshalBinary_Migrate(t *testing.T) {
+	buf, err := proto.Marshal(&internal.Data{
+		Term:            proto.Uint64(1),
+		Index:           proto.Uint64(2),
+		ClusterID:       proto.Uint64(3),
+		MaxNodeID:       proto.Uint64(0),
+		MaxShardGroupID: proto.Uint64(0),
+		MaxShardID:      proto.Uint64(0),
+		Databases: []*internal.DatabaseInfo{{
+			Name:                   proto.String("db0"),
+			DefaultRetentionPolicy: proto.String("rp0"),
+			RetentionPolicies: []*internal.RetentionPolicyInfo{{
+				Name:               proto.String("rp0"),
+				Duration:           proto.Int64(int64(30 * 24 * time.Hour)),
+				ShardGroupDuration: proto.Int64(0),
+				ReplicaN:           proto.Uint32(1),
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data meta.Data
+	if err := data.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if d := data.Databases[0].RetentionPolicies[0].ShardGroupDuration; d != 24*time.Hour {
+		t.Fatalf("unexpected shard group duration: %s", d)
+	}
+
+	// The metadata is written back with the current version.
+	if buf, err := data.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	} else {
+		var pb internal.Data
+		if err := proto.Unmarshal(buf, &pb); err != nil {
+			t.Fatal(err)
+		} else if pb.GetVersion() != meta.DataVersion {
+			t.Fatalf("unexpected version: %d", pb.GetVersion())
+		}
+	}
+}
+
+// Ensure metadata written by a newer release isn't loaded.
+func TestData_UnmarshalBinary_ErrVersion(t *testing.T) {
+	buf, err := proto.Marshal(&internal.Data{
+		Term:            proto.Uint64(1),
+		Index:           proto.Uint64(2),
+		ClusterID:       proto.Uint64(3),
+		MaxNodeID:       proto.Uint64(0),
+		MaxShardGroupID: proto.Uint64(0),
+		MaxShardID:      proto.Uint64(0),
+		Version:         proto.Uint64(meta.DataVersion + 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data meta.Data
+	if err := data.UnmarshalBinary(buf); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -2,6 +2,10 @@ package meta
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/influxdb/influxdb/influxql"
 )
@@ -15,6 +19,7 @@ type StatementExecutor struct {
 		Databases() ([]DatabaseInfo, error)
 		CreateDatabase(name string) (*DatabaseInfo, error)
 		DropDatabase(name string) error
+		RenameDatabase(oldName, newName string) error
 
 		DefaultRetentionPolicy(database string) (*RetentionPolicyInfo, error)
 		CreateRetentionPolicy(database string, rpi *RetentionPolicyInfo) (*RetentionPolicyInfo, error)
@@ -25,12 +30,35 @@ type StatementExecutor struct {
 		Users() ([]UserInfo, error)
 		CreateUser(name, password string, admin bool) (*UserInfo, error)
 		UpdateUser(name, password string) error
+		ExpirePassword(name string) error
 		DropUser(name string) error
 		SetPrivilege(username, database string, p influxql.Privilege) error
 		UserPrivileges(username string) (map[string]influxql.Privilege, error)
 
+		APIKeys() ([]APIKeyInfo, error)
+		CreateAPIKey(name string, privileges map[string]influxql.Privilege) (string, error)
+		DropAPIKey(name string) error
+
+		Roles() ([]RoleInfo, error)
+		CreateRole(name string) error
+		DropRole(name string) error
+		SetRolePrivilege(role, database string, p influxql.Privilege) error
+		GrantRole(username, role string) error
+		RevokeRole(username, role string) error
+
 		CreateContinuousQuery(database, name, query string) error
 		DropContinuousQuery(database, name string) error
+
+		Limits() (Limits, error)
+		SetLimit(name string, value int) error
+
+		SetReadOnly(readOnly bool) error
+	}
+
+	// ShardSizer, if set, reports the on-disk size of shards stored on
+	// this node. Other shards are listed without a size.
+	ShardSizer interface {
+		ShardSize(shardID uint64) (int64, bool)
 	}
 }
 
@@ -41,24 +69,52 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.
 		return e.executeCreateDatabaseStatement(stmt)
 	case *influxql.DropDatabaseStatement:
 		return e.executeDropDatabaseStatement(stmt)
+	case *influxql.RenameDatabaseStatement:
+		return e.executeRenameDatabaseStatement(stmt)
 	case *influxql.ShowDatabasesStatement:
 		return e.executeShowDatabasesStatement(stmt)
 	case *influxql.ShowGrantsForUserStatement:
 		return e.executeShowGrantsForUserStatement(stmt)
 	case *influxql.ShowServersStatement:
 		return e.executeShowServersStatement(stmt)
+	case *influxql.ShowShardGroupsStatement:
+		return e.executeShowShardGroupsStatement(stmt)
+	case *influxql.ShowShardsStatement:
+		return e.executeShowShardsStatement(stmt)
 	case *influxql.CreateUserStatement:
 		return e.executeCreateUserStatement(stmt)
 	case *influxql.SetPasswordUserStatement:
 		return e.executeSetPasswordUserStatement(stmt)
+	case *influxql.ExpirePasswordStatement:
+		return e.executeExpirePasswordStatement(stmt)
 	case *influxql.DropUserStatement:
 		return e.executeDropUserStatement(stmt)
 	case *influxql.ShowUsersStatement:
 		return e.executeShowUsersStatement(stmt)
+	case *influxql.CreateAPIKeyStatement:
+		return e.executeCreateAPIKeyStatement(stmt)
+	case *influxql.DropAPIKeyStatement:
+		return e.executeDropAPIKeyStatement(stmt)
+	case *influxql.ShowAPIKeysStatement:
+		return e.executeShowAPIKeysStatement(stmt)
 	case *influxql.GrantStatement:
 		return e.executeGrantStatement(stmt)
 	case *influxql.RevokeStatement:
 		return e.executeRevokeStatement(stmt)
+	case *influxql.CreateRoleStatement:
+		return e.executeCreateRoleStatement(stmt)
+	case *influxql.DropRoleStatement:
+		return e.executeDropRoleStatement(stmt)
+	case *influxql.ShowRolesStatement:
+		return e.executeShowRolesStatement(stmt)
+	case *influxql.GrantRoleStatement:
+		return e.executeGrantRoleStatement(stmt)
+	case *influxql.RevokeRoleStatement:
+		return e.executeRevokeRoleStatement(stmt)
+	case *influxql.GrantToRoleStatement:
+		return e.executeGrantToRoleStatement(stmt)
+	case *influxql.RevokeFromRoleStatement:
+		return e.executeRevokeFromRoleStatement(stmt)
 	case *influxql.CreateRetentionPolicyStatement:
 		return e.executeCreateRetentionPolicyStatement(stmt)
 	case *influxql.AlterRetentionPolicyStatement:
@@ -73,6 +129,14 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.
 		return e.executeDropContinuousQueryStatement(stmt)
 	case *influxql.ShowContinuousQueriesStatement:
 		return e.executeShowContinuousQueriesStatement(stmt)
+	case *influxql.SetLimitStatement:
+		return e.executeSetLimitStatement(stmt)
+	case *influxql.ShowLimitsStatement:
+		return e.executeShowLimitsStatement(stmt)
+	case *influxql.SetReadOnlyStatement:
+		return e.executeSetReadOnlyStatement(stmt)
+	case *influxql.ShowSchemaStatement:
+		return e.executeShowSchemaStatement(stmt)
 	default:
 		panic(fmt.Sprintf("unsupported statement type: %T", stmt))
 	}
@@ -87,6 +151,10 @@ func (e *StatementExecutor) executeDropDatabaseStatement(q *influxql.DropDatabas
 	return &influxql.Result{Err: e.Store.DropDatabase(q.Name)}
 }
 
+func (e *StatementExecutor) executeRenameDatabaseStatement(q *influxql.RenameDatabaseStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.RenameDatabase(q.OldName, q.NewName)}
+}
+
 func (e *StatementExecutor) executeShowDatabasesStatement(q *influxql.ShowDatabasesStatement) *influxql.Result {
 	dis, err := e.Store.Databases()
 	if err != nil {
@@ -126,6 +194,88 @@ func (e *StatementExecutor) executeShowServersStatement(q *influxql.ShowServersS
 	return &influxql.Result{Series: []*influxql.Row{row}}
 }
 
+func (e *StatementExecutor) executeShowShardsStatement(q *influxql.ShowShardsStatement) *influxql.Result {
+	nis, err := e.Store.Nodes()
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+	dis, err := e.Store.Databases()
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	nodes := make(map[uint64]NodeInfo, len(nis))
+	for _, ni := range nis {
+		nodes[ni.ID] = ni
+	}
+
+	row := &influxql.Row{Columns: []string{"id", "database", "retention_policy", "shard_group", "start_time", "end_time", "owners", "zones", "racks", "size"}}
+	for _, di := range dis {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+
+				for _, si := range sgi.Shards {
+					owners := make([]string, len(si.OwnerIDs))
+					ownerZones := make([]string, len(si.OwnerIDs))
+					ownerRacks := make([]string, len(si.OwnerIDs))
+					for i, id := range si.OwnerIDs {
+						owners[i] = strconv.FormatUint(id, 10)
+						ownerZones[i] = nodes[id].Zone
+						ownerRacks[i] = nodes[id].Rack
+					}
+					// Leave the size empty for shards that aren't stored locally.
+					var size interface{}
+					if e.ShardSizer != nil {
+						if n, ok := e.ShardSizer.ShardSize(si.ID); ok {
+							size = n
+						}
+					}
+
+					row.Values = append(row.Values, []interface{}{
+						si.ID, di.Name, rpi.Name, sgi.ID,
+						formatTime(sgi.StartTime), formatTime(sgi.EndTime),
+						strings.Join(owners, ","), strings.Join(ownerZones, ","), strings.Join(ownerRacks, ","),
+						size,
+					})
+				}
+			}
+		}
+	}
+	return &influxql.Result{Series: []*influxql.Row{row}}
+}
+
+func (e *StatementExecutor) executeShowShardGroupsStatement(q *influxql.ShowShardGroupsStatement) *influxql.Result {
+	dis, err := e.Store.Databases()
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	row := &influxql.Row{Columns: []string{"id", "database", "retention_policy", "start_time", "end_time", "shards"}}
+	for _, di := range dis {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+
+				shards := make([]string, len(sgi.Shards))
+				for i, si := range sgi.Shards {
+					shards[i] = strconv.FormatUint(si.ID, 10)
+				}
+				row.Values = append(row.Values, []interface{}{
+					sgi.ID, di.Name, rpi.Name,
+					formatTime(sgi.StartTime), formatTime(sgi.EndTime),
+					strings.Join(shards, ","),
+				})
+			}
+		}
+	}
+	return &influxql.Result{Series: []*influxql.Row{row}}
+}
+
 func (e *StatementExecutor) executeCreateUserStatement(q *influxql.CreateUserStatement) *influxql.Result {
 	admin := false
 	if q.Privilege != nil {
@@ -140,6 +290,10 @@ func (e *StatementExecutor) executeSetPasswordUserStatement(q *influxql.SetPassw
 	return &influxql.Result{Err: e.Store.UpdateUser(q.Name, q.Password)}
 }
 
+func (e *StatementExecutor) executeExpirePasswordStatement(q *influxql.ExpirePasswordStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.ExpirePassword(q.Name)}
+}
+
 func (e *StatementExecutor) executeDropUserStatement(q *influxql.DropUserStatement) *influxql.Result {
 	return &influxql.Result{Err: e.Store.DropUser(q.Name)}
 }
@@ -157,6 +311,43 @@ func (e *StatementExecutor) executeShowUsersStatement(q *influxql.ShowUsersState
 	return &influxql.Result{Series: []*influxql.Row{row}}
 }
 
+func (e *StatementExecutor) executeCreateAPIKeyStatement(q *influxql.CreateAPIKeyStatement) *influxql.Result {
+	key, err := e.Store.CreateAPIKey(q.Name, q.Privileges)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	// The key is only returned here since the store keeps just its hash.
+	row := &influxql.Row{Columns: []string{"name", "key"}}
+	row.Values = append(row.Values, []interface{}{q.Name, key})
+	return &influxql.Result{Series: []*influxql.Row{row}}
+}
+
+func (e *StatementExecutor) executeDropAPIKeyStatement(q *influxql.DropAPIKeyStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.DropAPIKey(q.Name)}
+}
+
+func (e *StatementExecutor) executeShowAPIKeysStatement(q *influxql.ShowAPIKeysStatement) *influxql.Result {
+	aks, err := e.Store.APIKeys()
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	row := &influxql.Row{Columns: []string{"name", "database", "privilege"}}
+	for _, ak := range aks {
+		databases := make([]string, 0, len(ak.Privileges))
+		for d := range ak.Privileges {
+			databases = append(databases, d)
+		}
+		sort.Strings(databases)
+
+		for _, d := range databases {
+			row.Values = append(row.Values, []interface{}{ak.Name, d, ak.Privileges[d].String()})
+		}
+	}
+	return &influxql.Result{Series: []*influxql.Row{row}}
+}
+
 func (e *StatementExecutor) executeGrantStatement(stmt *influxql.GrantStatement) *influxql.Result {
 	return &influxql.Result{Err: e.Store.SetPrivilege(stmt.User, stmt.On, stmt.Privilege)}
 }
@@ -165,10 +356,60 @@ func (e *StatementExecutor) executeRevokeStatement(stmt *influxql.RevokeStatemen
 	return &influxql.Result{Err: e.Store.SetPrivilege(stmt.User, stmt.On, influxql.NoPrivileges)}
 }
 
+func (e *StatementExecutor) executeCreateRoleStatement(q *influxql.CreateRoleStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.CreateRole(q.Name)}
+}
+
+func (e *StatementExecutor) executeDropRoleStatement(q *influxql.DropRoleStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.DropRole(q.Name)}
+}
+
+func (e *StatementExecutor) executeShowRolesStatement(q *influxql.ShowRolesStatement) *influxql.Result {
+	ris, err := e.Store.Roles()
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	row := &influxql.Row{Columns: []string{"name", "database", "privilege"}}
+	for _, ri := range ris {
+		databases := make([]string, 0, len(ri.Privileges))
+		for d := range ri.Privileges {
+			databases = append(databases, d)
+		}
+		sort.Strings(databases)
+
+		// List roles without privileges too so they can be found and dropped.
+		if len(databases) == 0 {
+			row.Values = append(row.Values, []interface{}{ri.Name, nil, nil})
+		}
+		for _, d := range databases {
+			row.Values = append(row.Values, []interface{}{ri.Name, d, ri.Privileges[d].String()})
+		}
+	}
+	return &influxql.Result{Series: []*influxql.Row{row}}
+}
+
+func (e *StatementExecutor) executeGrantRoleStatement(stmt *influxql.GrantRoleStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.GrantRole(stmt.User, stmt.Role)}
+}
+
+func (e *StatementExecutor) executeRevokeRoleStatement(stmt *influxql.RevokeRoleStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.RevokeRole(stmt.User, stmt.Role)}
+}
+
+func (e *StatementExecutor) executeGrantToRoleStatement(stmt *influxql.GrantToRoleStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.SetRolePrivilege(stmt.Role, stmt.On, stmt.Privilege)}
+}
+
+func (e *StatementExecutor) executeRevokeFromRoleStatement(stmt *influxql.RevokeFromRoleStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.SetRolePrivilege(stmt.Role, stmt.On, influxql.NoPrivileges)}
+}
+
 func (e *StatementExecutor) executeCreateRetentionPolicyStatement(stmt *influxql.CreateRetentionPolicyStatement) *influxql.Result {
 	rpi := NewRetentionPolicyInfo(stmt.Name)
 	rpi.Duration = stmt.Duration
 	rpi.ReplicaN = stmt.Replication
+	rpi.ShardGroupDuration = stmt.ShardGroupDuration
 
 	// Create new retention policy.
 	_, err := e.Store.CreateRetentionPolicy(stmt.Database, rpi)
@@ -186,8 +427,9 @@ func (e *StatementExecutor) executeCreateRetentionPolicyStatement(stmt *influxql
 
 func (e *StatementExecutor) executeAlterRetentionPolicyStatement(stmt *influxql.AlterRetentionPolicyStatement) *influxql.Result {
 	rpu := &RetentionPolicyUpdate{
-		Duration: stmt.Duration,
-		ReplicaN: stmt.Replication,
+		Duration:           stmt.Duration,
+		ReplicaN:           stmt.Replication,
+		ShardGroupDuration: stmt.ShardGroupDuration,
 	}
 
 	// Update the retention policy.
@@ -251,3 +493,59 @@ func (e *StatementExecutor) executeShowContinuousQueriesStatement(stmt *influxql
 	}
 	return &influxql.Result{Series: rows}
 }
+
+// formatTime returns t as an RFC3339 string in UTC.
+func formatTime(t time.Time) string { return t.UTC().Format(time.RFC3339Nano) }
+
+func (e *StatementExecutor) executeSetLimitStatement(stmt *influxql.SetLimitStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.SetLimit(stmt.Name, stmt.Value)}
+}
+
+func (e *StatementExecutor) executeShowLimitsStatement(stmt *influxql.ShowLimitsStatement) *influxql.Result {
+	l, err := e.Store.Limits()
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	row := &influxql.Row{Columns: []string{"name", "value"}}
+	row.Values = [][]interface{}{
+		{LimitMaxDatabases, l.MaxDatabases},
+		{LimitMaxRetentionPolicies, l.MaxRetentionPolicies},
+		{LimitMaxSeriesPerDatabase, l.MaxSeriesPerDatabase},
+	}
+	return &influxql.Result{Series: []*influxql.Row{row}}
+}
+
+func (e *StatementExecutor) executeSetReadOnlyStatement(stmt *influxql.SetReadOnlyStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.SetReadOnly(stmt.ReadOnly)}
+}
+
+func (e *StatementExecutor) executeShowSchemaStatement(q *influxql.ShowSchemaStatement) *influxql.Result {
+	if q.Database == "" {
+		return &influxql.Result{Err: ErrDatabaseNameRequired}
+	}
+
+	di, err := e.Store.Database(q.Database)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	} else if di == nil {
+		return &influxql.Result{Err: ErrDatabaseNotFound}
+	}
+
+	rows := []*influxql.Row{}
+	for _, mi := range di.Measurements {
+		if q.Measurement != "" && q.Measurement != mi.Name {
+			continue
+		}
+
+		row := &influxql.Row{Name: mi.Name, Columns: []string{"key", "kind", "type"}}
+		for _, k := range mi.TagKeys {
+			row.Values = append(row.Values, []interface{}{k, "tag", influxql.DataType(influxql.String).String()})
+		}
+		for _, fi := range mi.Fields {
+			row.Values = append(row.Values, []interface{}{fi.Name, "field", fi.Type.String()})
+		}
+		rows = append(rows, row)
+	}
+	return &influxql.Result{Series: rows}
+}
@@ -45,6 +45,25 @@ func TestStatementExecutor_ExecuteStatement_DropDatabase(t *testing.T) {
 	}
 }
 
+// Ensure an ALTER DATABASE ... RENAME TO statement can be executed.
+func TestStatementExecutor_ExecuteStatement_RenameDatabase(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.RenameDatabaseFn = func(oldName, newName string) error {
+		if oldName != "foo" {
+			t.Fatalf("unexpected old name: %s", oldName)
+		} else if newName != "bar" {
+			t.Fatalf("unexpected new name: %s", newName)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`ALTER DATABASE foo RENAME TO bar`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
 // Ensure a SHOW DATABASES statement can be executed.
 func TestStatementExecutor_ExecuteStatement_ShowDatabases(t *testing.T) {
 	e := NewStatementExecutor()
@@ -149,6 +168,97 @@ func TestStatementExecutor_ExecuteStatement_ShowServers_Err(t *testing.T) {
 	}
 }
 
+// Ensure a SHOW SHARDS statement returns the owners of each shard and their locations.
+func TestStatementExecutor_ExecuteStatement_ShowShards(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.NodesFn = func() ([]meta.NodeInfo, error) {
+		return []meta.NodeInfo{
+			{ID: 1, Host: "node0", Zone: "us-east", Rack: "r1"},
+			{ID: 2, Host: "node1", Zone: "us-west", Rack: "r2"},
+		}, nil
+	}
+	e.Store.DatabasesFn = func() ([]meta.DatabaseInfo, error) {
+		return []meta.DatabaseInfo{
+			{
+				Name: "db0",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{
+						Name: "rp0",
+						ShardGroups: []meta.ShardGroupInfo{
+							{
+								ID:        1,
+								StartTime: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+								EndTime:   time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC),
+								Shards: []meta.ShardInfo{
+									{ID: 1, OwnerIDs: []uint64{1, 2}},
+									{ID: 3, OwnerIDs: []uint64{2}},
+								},
+							},
+							{ID: 2, Shards: []meta.ShardInfo{{ID: 2, OwnerIDs: []uint64{1}}}, DeletedAt: time.Unix(0, 0)},
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	e.StatementExecutor.ShardSizer = shardSizerFunc(func(shardID uint64) (int64, bool) {
+		return 1024, shardID == 1
+	})
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW SHARDS`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, influxql.Rows{
+		{
+			Columns: []string{"id", "database", "retention_policy", "shard_group", "start_time", "end_time", "owners", "zones", "racks", "size"},
+			Values: [][]interface{}{
+				{uint64(1), "db0", "rp0", uint64(1), "2000-01-01T00:00:00Z", "2000-01-02T00:00:00Z", "1,2", "us-east,us-west", "r1,r2", int64(1024)},
+				{uint64(3), "db0", "rp0", uint64(1), "2000-01-01T00:00:00Z", "2000-01-02T00:00:00Z", "2", "us-west", "r2", nil},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
+// Ensure a SHOW SHARD GROUPS statement returns the time range and shards of each group.
+func TestStatementExecutor_ExecuteStatement_ShowShardGroups(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.DatabasesFn = func() ([]meta.DatabaseInfo, error) {
+		return []meta.DatabaseInfo{
+			{
+				Name: "db0",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{
+						Name: "rp0",
+						ShardGroups: []meta.ShardGroupInfo{
+							{
+								ID:        1,
+								StartTime: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+								EndTime:   time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC),
+								Shards:    []meta.ShardInfo{{ID: 1}, {ID: 3}},
+							},
+							{ID: 2, Shards: []meta.ShardInfo{{ID: 2}}, DeletedAt: time.Unix(0, 0)},
+						},
+					},
+				},
+			},
+		}, nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW SHARD GROUPS`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, influxql.Rows{
+		{
+			Columns: []string{"id", "database", "retention_policy", "start_time", "end_time", "shards"},
+			Values: [][]interface{}{
+				{uint64(1), "db0", "rp0", "2000-01-01T00:00:00Z", "2000-01-02T00:00:00Z", "1,3"},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
 // Ensure a CREATE USER statement can be executed.
 func TestStatementExecutor_ExecuteStatement_CreateUser(t *testing.T) {
 	e := NewStatementExecutor()
@@ -213,6 +323,23 @@ func TestStatementExecutor_ExecuteStatement_SetPassword_Err(t *testing.T) {
 	}
 }
 
+// Ensure an ALTER USER ... EXPIRE PASSWORD statement can be executed.
+func TestStatementExecutor_ExecuteStatement_ExpirePassword(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.ExpirePasswordFn = func(name string) error {
+		if name != "susy" {
+			t.Fatalf("unexpected name: %s", name)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`ALTER USER susy EXPIRE PASSWORD`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
 // Ensure a DROP USER statement can be executed.
 func TestStatementExecutor_ExecuteStatement_DropUser(t *testing.T) {
 	e := NewStatementExecutor()
@@ -279,6 +406,194 @@ func TestStatementExecutor_ExecuteStatement_ShowUsers_Err(t *testing.T) {
 	}
 }
 
+// Ensure a CREATE API KEY statement returns the generated key.
+func TestStatementExecutor_ExecuteStatement_CreateAPIKey(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.CreateAPIKeyFn = func(name string, privileges map[string]influxql.Privilege) (string, error) {
+		if name != "telegraf" {
+			t.Fatalf("unexpected name: %s", name)
+		} else if !reflect.DeepEqual(privileges, map[string]influxql.Privilege{"db0": influxql.WritePrivilege, "db1": influxql.ReadPrivilege}) {
+			t.Fatalf("unexpected privileges: %#v", privileges)
+		}
+		return "secret", nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`CREATE API KEY telegraf WITH WRITE ON db0, READ ON db1`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, influxql.Rows{
+		{
+			Columns: []string{"name", "key"},
+			Values:  [][]interface{}{{"telegraf", "secret"}},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
+// Ensure a CREATE API KEY statement returns errors from the store.
+func TestStatementExecutor_ExecuteStatement_CreateAPIKey_Err(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.CreateAPIKeyFn = func(name string, privileges map[string]influxql.Privilege) (string, error) {
+		return "", errors.New("marker")
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`CREATE API KEY telegraf WITH WRITE ON db0`)); res.Err == nil || res.Err.Error() != "marker" {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
+// Ensure a DROP API KEY statement can be executed.
+func TestStatementExecutor_ExecuteStatement_DropAPIKey(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.DropAPIKeyFn = func(name string) error {
+		if name != "telegraf" {
+			t.Fatalf("unexpected name: %s", name)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`DROP API KEY telegraf`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
+// Ensure a SHOW API KEYS statement lists each privilege of each key.
+func TestStatementExecutor_ExecuteStatement_ShowAPIKeys(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.APIKeysFn = func() ([]meta.APIKeyInfo, error) {
+		return []meta.APIKeyInfo{
+			{Name: "telegraf", Hash: "xxx", Privileges: map[string]influxql.Privilege{"db1": influxql.ReadPrivilege, "db0": influxql.WritePrivilege}},
+			{Name: "grafana", Hash: "yyy", Privileges: map[string]influxql.Privilege{"db1": influxql.ReadPrivilege}},
+		}, nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW API KEYS`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, influxql.Rows{
+		{
+			Columns: []string{"name", "database", "privilege"},
+			Values: [][]interface{}{
+				{"telegraf", "db0", "WRITE"},
+				{"telegraf", "db1", "READ"},
+				{"grafana", "db1", "READ"},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
+// Ensure a CREATE ROLE statement can be executed.
+func TestStatementExecutor_ExecuteStatement_CreateRole(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.CreateRoleFn = func(name string) error {
+		if name != "analysts" {
+			t.Fatalf("unexpected name: %s", name)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`CREATE ROLE analysts`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
+// Ensure a DROP ROLE statement can be executed.
+func TestStatementExecutor_ExecuteStatement_DropRole(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.DropRoleFn = func(name string) error {
+		if name != "analysts" {
+			t.Fatalf("unexpected name: %s", name)
+		}
+		return meta.ErrRoleNotFound
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`DROP ROLE analysts`)); res.Err != meta.ErrRoleNotFound {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
+// Ensure a SHOW ROLES statement lists each privilege of each role.
+func TestStatementExecutor_ExecuteStatement_ShowRoles(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.RolesFn = func() ([]meta.RoleInfo, error) {
+		return []meta.RoleInfo{
+			{Name: "analysts", Privileges: map[string]influxql.Privilege{"db1": influxql.ReadPrivilege, "db0": influxql.AllPrivileges}},
+			{Name: "empty"},
+		}, nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW ROLES`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, influxql.Rows{
+		{
+			Columns: []string{"name", "database", "privilege"},
+			Values: [][]interface{}{
+				{"analysts", "db0", "ALL PRIVILEGES"},
+				{"analysts", "db1", "READ"},
+				{"empty", nil, nil},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
+// Ensure GRANT ROLE and REVOKE ROLE statements can be executed.
+func TestStatementExecutor_ExecuteStatement_GrantRevokeRole(t *testing.T) {
+	var granted, revoked bool
+	e := NewStatementExecutor()
+	e.Store.GrantRoleFn = func(username, role string) error {
+		if username != "susy" || role != "analysts" {
+			t.Fatalf("unexpected grant: %s %s", username, role)
+		}
+		granted = true
+		return nil
+	}
+	e.Store.RevokeRoleFn = func(username, role string) error {
+		if username != "susy" || role != "analysts" {
+			t.Fatalf("unexpected revoke: %s %s", username, role)
+		}
+		revoked = true
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`GRANT ROLE analysts TO susy`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res := e.ExecuteStatement(influxql.MustParseStatement(`REVOKE ROLE analysts FROM susy`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !granted || !revoked {
+		t.Fatalf("unexpected calls: granted=%v, revoked=%v", granted, revoked)
+	}
+}
+
+// Ensure privileges can be granted to and revoked from a role.
+func TestStatementExecutor_ExecuteStatement_GrantRevokeRolePrivilege(t *testing.T) {
+	var privs []influxql.Privilege
+	e := NewStatementExecutor()
+	e.Store.SetRolePrivilegeFn = func(role, database string, p influxql.Privilege) error {
+		if role != "analysts" {
+			t.Fatalf("unexpected role: %s", role)
+		} else if database != "foo" {
+			t.Fatalf("unexpected database: %s", database)
+		}
+		privs = append(privs, p)
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`GRANT WRITE ON foo TO ROLE analysts`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res := e.ExecuteStatement(influxql.MustParseStatement(`REVOKE WRITE ON foo FROM ROLE analysts`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(privs, []influxql.Privilege{influxql.WritePrivilege, influxql.NoPrivileges}) {
+		t.Fatalf("unexpected privileges: %v", privs)
+	}
+}
+
 // Ensure a GRANT statement can be executed.
 func TestStatementExecutor_ExecuteStatement_Grant(t *testing.T) {
 	e := NewStatementExecutor()
@@ -357,6 +672,8 @@ func TestStatementExecutor_ExecuteStatement_CreateRetentionPolicy(t *testing.T)
 			t.Fatalf("unexpected duration: %v", rpi.Duration)
 		} else if rpi.ReplicaN != 3 {
 			t.Fatalf("unexpected replication factor: %v", rpi.ReplicaN)
+		} else if rpi.ShardGroupDuration != time.Hour {
+			t.Fatalf("unexpected shard group duration: %v", rpi.ShardGroupDuration)
 		}
 		return nil, nil
 	}
@@ -369,7 +686,7 @@ func TestStatementExecutor_ExecuteStatement_CreateRetentionPolicy(t *testing.T)
 		return nil
 	}
 
-	if res := e.ExecuteStatement(influxql.MustParseStatement(`CREATE RETENTION POLICY rp0 ON foo DURATION 2h REPLICATION 3 DEFAULT`)); res.Err != nil {
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`CREATE RETENTION POLICY rp0 ON foo DURATION 2h REPLICATION 3 SHARD DURATION 1h DEFAULT`)); res.Err != nil {
 		t.Fatal(res.Err)
 	} else if res.Series != nil {
 		t.Fatalf("unexpected rows: %#v", res.Series)
@@ -400,6 +717,8 @@ func TestStatementExecutor_ExecuteStatement_AlterRetentionPolicy(t *testing.T) {
 			t.Fatalf("unexpected duration: %v", *rpu.Duration)
 		} else if rpu.ReplicaN != nil && *rpu.ReplicaN != 2 {
 			t.Fatalf("unexpected replication factor: %v", *rpu.ReplicaN)
+		} else if rpu.ShardGroupDuration != nil && *rpu.ShardGroupDuration != 24*time.Hour {
+			t.Fatalf("unexpected shard group duration: %v", *rpu.ShardGroupDuration)
 		}
 		return nil
 	}
@@ -426,6 +745,11 @@ func TestStatementExecutor_ExecuteStatement_AlterRetentionPolicy(t *testing.T) {
 	if res := e.ExecuteStatement(stmt); res.Err != nil {
 		t.Fatalf("unexpected error: %s", res.Err)
 	}
+
+	stmt = influxql.MustParseStatement(`ALTER RETENTION POLICY rp0 ON foo SHARD DURATION 1d`)
+	if res := e.ExecuteStatement(stmt); res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
 }
 
 // Ensure a ALTER RETENTION POLICY statement returns errors from the store.
@@ -678,6 +1002,125 @@ func TestStatementExecutor_ExecuteStatement_ShowContinuousQueries_Err(t *testing
 	}
 }
 
+// Ensure a SET LIMIT statement can be executed.
+func TestStatementExecutor_ExecuteStatement_SetLimit(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.SetLimitFn = func(name string, value int) error {
+		if name != "max_databases" {
+			t.Fatalf("unexpected name: %s", name)
+		} else if value != 10 {
+			t.Fatalf("unexpected value: %d", value)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SET LIMIT max_databases = 10`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
+// Ensure a SET READ ONLY statement can be executed.
+func TestStatementExecutor_ExecuteStatement_SetReadOnly(t *testing.T) {
+	e := NewStatementExecutor()
+	var readOnly bool
+	e.Store.SetReadOnlyFn = func(v bool) error {
+		readOnly = v
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SET READ ONLY = true`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !readOnly {
+		t.Fatal("expected read-only")
+	}
+}
+
+// Ensure a SHOW LIMITS statement returns every limit.
+func TestStatementExecutor_ExecuteStatement_ShowLimits(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.LimitsFn = func() (meta.Limits, error) {
+		return meta.Limits{MaxDatabases: 10, MaxSeriesPerDatabase: 1000}, nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW LIMITS`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, influxql.Rows{
+		{
+			Columns: []string{"name", "value"},
+			Values: [][]interface{}{
+				{"max_databases", 10},
+				{"max_retention_policies", 0},
+				{"max_series_per_database", 1000},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
+// Ensure a SHOW SCHEMA statement lists the tag keys and fields of measurements.
+func TestStatementExecutor_ExecuteStatement_ShowSchema(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		if name != "db0" {
+			t.Fatalf("unexpected name: %s", name)
+		}
+		return &meta.DatabaseInfo{
+			Name: name,
+			Measurements: []meta.MeasurementInfo{
+				{
+					Name:    "cpu",
+					Fields:  []meta.FieldInfo{{Name: "value", Type: influxql.Float}},
+					TagKeys: []string{"host"},
+				},
+				{
+					Name:   "mem",
+					Fields: []meta.FieldInfo{{Name: "used", Type: influxql.Integer}},
+				},
+			},
+		}, nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW SCHEMA ON db0`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, influxql.Rows{
+		{
+			Name:    "cpu",
+			Columns: []string{"key", "kind", "type"},
+			Values: [][]interface{}{
+				{"host", "tag", "string"},
+				{"value", "field", "float"},
+			},
+		},
+		{
+			Name:    "mem",
+			Columns: []string{"key", "kind", "type"},
+			Values: [][]interface{}{
+				{"used", "field", "integer"},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+
+	// Verify the schema of a single measurement can be shown.
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW SCHEMA ON db0 FROM mem`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if len(res.Series) != 1 || res.Series[0].Name != "mem" {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
+// Ensure a SHOW SCHEMA statement requires a database.
+func TestStatementExecutor_ExecuteStatement_ShowSchema_ErrDatabaseNameRequired(t *testing.T) {
+	e := NewStatementExecutor()
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW SCHEMA`)); res.Err != meta.ErrDatabaseNameRequired {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
 // Ensure that executing an unsupported statement will panic.
 func TestStatementExecutor_ExecuteStatement_Unsupported(t *testing.T) {
 	var panicked bool
@@ -713,6 +1156,11 @@ func NewStatementExecutor() *StatementExecutor {
 	return e
 }
 
+// shardSizerFunc is a function that implements StatementExecutor.ShardSizer.
+type shardSizerFunc func(shardID uint64) (int64, bool)
+
+func (fn shardSizerFunc) ShardSize(shardID uint64) (int64, bool) { return fn(shardID) }
+
 // StatementExecutorStore represents a mock implementation of StatementExecutor.Store.
 type StatementExecutorStore struct {
 	NodesFn                     func() ([]meta.NodeInfo, error)
@@ -720,6 +1168,7 @@ type StatementExecutorStore struct {
 	DatabasesFn                 func() ([]meta.DatabaseInfo, error)
 	CreateDatabaseFn            func(name string) (*meta.DatabaseInfo, error)
 	DropDatabaseFn              func(name string) error
+	RenameDatabaseFn            func(oldName, newName string) error
 	DefaultRetentionPolicyFn    func(database string) (*meta.RetentionPolicyInfo, error)
 	CreateRetentionPolicyFn     func(database string, rpi *meta.RetentionPolicyInfo) (*meta.RetentionPolicyInfo, error)
 	UpdateRetentionPolicyFn     func(database, name string, rpu *meta.RetentionPolicyUpdate) error
@@ -728,12 +1177,25 @@ type StatementExecutorStore struct {
 	UsersFn                     func() ([]meta.UserInfo, error)
 	CreateUserFn                func(name, password string, admin bool) (*meta.UserInfo, error)
 	UpdateUserFn                func(name, password string) error
+	ExpirePasswordFn            func(name string) error
 	DropUserFn                  func(name string) error
 	SetPrivilegeFn              func(username, database string, p influxql.Privilege) error
 	UserPrivilegesFn            func(username string) (map[string]influxql.Privilege, error)
+	APIKeysFn                   func() ([]meta.APIKeyInfo, error)
+	CreateAPIKeyFn              func(name string, privileges map[string]influxql.Privilege) (string, error)
+	DropAPIKeyFn                func(name string) error
+	RolesFn                     func() ([]meta.RoleInfo, error)
+	CreateRoleFn                func(name string) error
+	DropRoleFn                  func(name string) error
+	SetRolePrivilegeFn          func(role, database string, p influxql.Privilege) error
+	GrantRoleFn                 func(username, role string) error
+	RevokeRoleFn                func(username, role string) error
 	ContinuousQueriesFn         func() ([]meta.ContinuousQueryInfo, error)
 	CreateContinuousQueryFn     func(database, name, query string) error
 	DropContinuousQueryFn       func(database, name string) error
+	LimitsFn                    func() (meta.Limits, error)
+	SetLimitFn                  func(name string, value int) error
+	SetReadOnlyFn               func(readOnly bool) error
 }
 
 func (s *StatementExecutorStore) Nodes() ([]meta.NodeInfo, error) {
@@ -756,6 +1218,10 @@ func (s *StatementExecutorStore) DropDatabase(name string) error {
 	return s.DropDatabaseFn(name)
 }
 
+func (s *StatementExecutorStore) RenameDatabase(oldName, newName string) error {
+	return s.RenameDatabaseFn(oldName, newName)
+}
+
 func (s *StatementExecutorStore) DefaultRetentionPolicy(database string) (*meta.RetentionPolicyInfo, error) {
 	return s.DefaultRetentionPolicyFn(database)
 }
@@ -788,6 +1254,10 @@ func (s *StatementExecutorStore) UpdateUser(name, password string) error {
 	return s.UpdateUserFn(name, password)
 }
 
+func (s *StatementExecutorStore) ExpirePassword(name string) error {
+	return s.ExpirePasswordFn(name)
+}
+
 func (s *StatementExecutorStore) DropUser(name string) error {
 	return s.DropUserFn(name)
 }
@@ -800,6 +1270,42 @@ func (s *StatementExecutorStore) UserPrivileges(username string) (map[string]inf
 	return s.UserPrivilegesFn(username)
 }
 
+func (s *StatementExecutorStore) APIKeys() ([]meta.APIKeyInfo, error) {
+	return s.APIKeysFn()
+}
+
+func (s *StatementExecutorStore) CreateAPIKey(name string, privileges map[string]influxql.Privilege) (string, error) {
+	return s.CreateAPIKeyFn(name, privileges)
+}
+
+func (s *StatementExecutorStore) DropAPIKey(name string) error {
+	return s.DropAPIKeyFn(name)
+}
+
+func (s *StatementExecutorStore) Roles() ([]meta.RoleInfo, error) {
+	return s.RolesFn()
+}
+
+func (s *StatementExecutorStore) CreateRole(name string) error {
+	return s.CreateRoleFn(name)
+}
+
+func (s *StatementExecutorStore) DropRole(name string) error {
+	return s.DropRoleFn(name)
+}
+
+func (s *StatementExecutorStore) SetRolePrivilege(role, database string, p influxql.Privilege) error {
+	return s.SetRolePrivilegeFn(role, database, p)
+}
+
+func (s *StatementExecutorStore) GrantRole(username, role string) error {
+	return s.GrantRoleFn(username, role)
+}
+
+func (s *StatementExecutorStore) RevokeRole(username, role string) error {
+	return s.RevokeRoleFn(username, role)
+}
+
 func (s *StatementExecutorStore) ContinuousQueries() ([]meta.ContinuousQueryInfo, error) {
 	return s.ContinuousQueriesFn()
 }
@@ -811,3 +1317,15 @@ func (s *StatementExecutorStore) CreateContinuousQuery(database, name, query str
 func (s *StatementExecutorStore) DropContinuousQuery(database, name string) error {
 	return s.DropContinuousQueryFn(database, name)
 }
+
+func (s *StatementExecutorStore) Limits() (meta.Limits, error) {
+	return s.LimitsFn()
+}
+
+func (s *StatementExecutorStore) SetLimit(name string, value int) error {
+	return s.SetLimitFn(name, value)
+}
+
+func (s *StatementExecutorStore) SetReadOnly(readOnly bool) error {
+	return s.SetReadOnlyFn(readOnly)
+}
@@ -1,12 +1,18 @@
 package meta
 
 import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"math"
 	"math/rand"
 	"net"
 	"os"
@@ -19,11 +25,19 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/raft-boltdb"
+	"github.com/influxdb/influxdb/format"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta/internal"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// StoreFormat is the on-disk format of the meta directory written by this release.
+var StoreFormat = format.Spec{
+	Name:         "meta",
+	Version:      1,
+	Capabilities: []string{"raft-boltdb", "raft-snapshots"},
+}
+
 // tcp.Mux header bytes.
 const (
 	MuxRaftHeader = 0
@@ -34,6 +48,22 @@ const (
 // that it is coming from a remote exec client connection.
 const ExecMagic = "EXEC"
 
+// FetchDataMagic is the first 4 bytes sent on an exec connection by an
+// observer requesting a copy of the metadata.
+const FetchDataMagic = "DATA"
+
+// AuthenticateMagic is the first 4 bytes sent on an exec connection by an
+// observer authenticating a user or API key, as its copy of the metadata
+// has no password or API key hashes.
+const AuthenticateMagic = "AUTH"
+
+// maxJoinTokenSize is the largest join token accepted on an exec connection.
+const maxJoinTokenSize = 1024
+
+// maxCredentialSize is the largest username, password or API key accepted
+// on an exec connection.
+const maxCredentialSize = 1024
+
 // Retention policy settings.
 const (
 	AutoCreateRetentionPolicyName   = "default"
@@ -49,6 +79,10 @@ const (
 	raftTransportTimeout  = 10 * time.Second
 )
 
+// The number of logins waiting to have their passwords rehashed. Logins past
+// the limit are rehashed on a later login instead.
+const rehashQueueSize = 64
+
 // Store represents a raft-backed metastore.
 type Store struct {
 	mu     sync.RWMutex
@@ -62,6 +96,9 @@ type Store struct {
 
 	data *Data
 
+	// The last leader reported by a peer. Only used by observers.
+	observerLeader string
+
 	remoteAddr net.Addr
 	raft       *raft.Raft
 	raftLayer  *raftLayer
@@ -72,17 +109,33 @@ type Store struct {
 	ready   chan struct{}
 	err     chan error
 	closing chan struct{}
+	changed chan struct{} // closed and replaced when the metadata changes
 	wg      sync.WaitGroup
 
+	rehashes chan rehashRequest // logins to rehash off the request path
+
 	retentionAutoCreate bool
 
 	// The listeners to accept raft and remote exec connections from.
 	RaftListener net.Listener
 	ExecListener net.Listener
 
+	// The TLS configuration for raft and remote exec connections.
+	// Connections are unencrypted if nil.
+	TLSConfig *tls.Config
+
 	// The advertised hostname of the store.
 	Addr net.Addr
 
+	// The zone and rack of the local node, if any.
+	Zone string
+	Rack string
+
+	// If set, the store replicates the metadata from its peers every
+	// ObserverSyncInterval instead of joining the raft cluster.
+	Observer             bool
+	ObserverSyncInterval time.Duration
+
 	// The amount of time before a follower starts a new election.
 	HeartbeatTimeout time.Duration
 
@@ -93,9 +146,28 @@ type Store struct {
 	// leader steps down to a follower state.
 	LeaderLeaseTimeout time.Duration
 
+	// The amount of time since the last contact with the leader that a
+	// follower treats its local metadata as current. Zero disables the lease.
+	ReadLeaseTimeout time.Duration
+
 	// The amount of time without an apply before sending a heartbeat.
 	CommitTimeout time.Duration
 
+	// How often to check if a snapshot should be taken, the number of log
+	// entries since the last snapshot before taking one, and the number of
+	// entries kept after the log is truncated by a snapshot.
+	SnapshotInterval  time.Duration
+	SnapshotThreshold uint64
+	TrailingLogs      uint64
+
+	// The bcrypt cost used to hash passwords. It's stored in the metadata
+	// on open so every node in the cluster hashes with the same cost.
+	PasswordHashCost int
+
+	// The shared secret nodes present to register themselves. Nodes can
+	// register without it if blank.
+	JoinToken string
+
 	Logger *log.Logger
 }
 
@@ -109,14 +181,27 @@ func NewStore(c Config) *Store {
 		ready:   make(chan struct{}),
 		err:     make(chan error),
 		closing: make(chan struct{}),
+		changed: make(chan struct{}),
+
+		rehashes: make(chan rehashRequest, rehashQueueSize),
 
 		retentionAutoCreate: c.RetentionAutoCreate,
 
-		HeartbeatTimeout:   time.Duration(c.HeartbeatTimeout),
-		ElectionTimeout:    time.Duration(c.ElectionTimeout),
-		LeaderLeaseTimeout: time.Duration(c.LeaderLeaseTimeout),
-		CommitTimeout:      time.Duration(c.CommitTimeout),
-		Logger:             log.New(os.Stderr, "", log.LstdFlags),
+		Zone:                 c.Zone,
+		Rack:                 c.Rack,
+		Observer:             c.Observer,
+		ObserverSyncInterval: time.Duration(c.ObserverSyncInterval),
+		HeartbeatTimeout:     time.Duration(c.HeartbeatTimeout),
+		ElectionTimeout:      time.Duration(c.ElectionTimeout),
+		LeaderLeaseTimeout:   time.Duration(c.LeaderLeaseTimeout),
+		ReadLeaseTimeout:     time.Duration(c.ReadLeaseTimeout),
+		CommitTimeout:        time.Duration(c.CommitTimeout),
+		SnapshotInterval:     time.Duration(c.SnapshotInterval),
+		SnapshotThreshold:    c.SnapshotThreshold,
+		TrailingLogs:         c.TrailingLogs,
+		PasswordHashCost:     c.PasswordHashCost,
+		JoinToken:            c.JoinToken,
+		Logger:               log.New(os.Stderr, "", log.LstdFlags),
 	}
 }
 
@@ -127,11 +212,17 @@ func (s *Store) Path() string { return s.path }
 // IDPath returns the path to the local node ID file.
 func (s *Store) IDPath() string { return filepath.Join(s.path, "id") }
 
+// FormatPath returns the path to the format header file.
+func (s *Store) FormatPath() string { return filepath.Join(s.path, "format") }
+
 // Open opens and initializes the raft store.
 func (s *Store) Open() error {
-	// Verify that no more than 3 peers.
+	// Verify that no more than 3 peers. Observers don't join the raft
+	// cluster so they can replicate from any number of peers.
 	// https://github.com/influxdb/influxdb/issues/2750
-	if len(s.peers) > 3 {
+	if s.Observer && len(s.peers) == 0 {
+		return ErrObserverPeersRequired
+	} else if !s.Observer && len(s.peers) > 3 {
 		return ErrTooManyPeers
 	}
 
@@ -157,14 +248,21 @@ func (s *Store) Open() error {
 			return fmt.Errorf("mkdir all: %s", err)
 		}
 
-		// Open the raft store.
-		if err := s.openRaft(); err != nil {
-			return fmt.Errorf("raft: %s", err)
+		// Refuse to open data written in an unknown format.
+		if err := s.upgradeFormat(); err != nil {
+			return fmt.Errorf("format: %s", err)
 		}
 
-		// Initialize the store, if necessary.
-		if err := s.initialize(); err != nil {
-			return fmt.Errorf("initialize raft: %s", err)
+		// Open the raft store. Observers only replicate from their peers.
+		if !s.Observer {
+			if err := s.openRaft(); err != nil {
+				return fmt.Errorf("raft: %s", err)
+			}
+
+			// Initialize the store, if necessary.
+			if err := s.initialize(); err != nil {
+				return fmt.Errorf("initialize raft: %s", err)
+			}
 		}
 
 		// Load existing ID, if exists.
@@ -182,14 +280,42 @@ func (s *Store) Open() error {
 	s.wg.Add(1)
 	go s.serveExecListener()
 
+	// Rehash passwords queued by Authenticate.
+	s.wg.Add(1)
+	go s.processRehashes()
+
+	// Observers discard raft connections and start once they have
+	// replicated the metadata.
+	if s.Observer {
+		s.wg.Add(2)
+		go s.serveRaftListener()
+		go s.observe()
+		return nil
+	}
+	s.start()
+
+	return nil
+}
+
+// start creates the local node if it doesn't exist yet.
+// Otherwise the ready channel is closed immediately.
+func (s *Store) start() {
 	// If the ID doesn't exist then create a new node.
 	if s.id == 0 {
 		go s.init()
 	} else {
 		close(s.ready)
+
+		// Update the zone in case it changed since the node was created.
+		if s.Zone != "" || s.Rack != "" {
+			go s.updateLocalZone()
+		}
 	}
 
-	return nil
+	// Share the configured password hash cost with the rest of the cluster.
+	if s.PasswordHashCost != 0 {
+		go s.updatePasswordHashCost(s.PasswordHashCost)
+	}
 }
 
 // openRaft initializes the raft store.
@@ -201,12 +327,21 @@ func (s *Store) openRaft() error {
 	config.ElectionTimeout = s.ElectionTimeout
 	config.LeaderLeaseTimeout = s.LeaderLeaseTimeout
 	config.CommitTimeout = s.CommitTimeout
+	if s.SnapshotInterval > 0 {
+		config.SnapshotInterval = s.SnapshotInterval
+	}
+	if s.SnapshotThreshold > 0 {
+		config.SnapshotThreshold = s.SnapshotThreshold
+	}
+	if s.TrailingLogs > 0 {
+		config.TrailingLogs = s.TrailingLogs
+	}
 
 	// If no peers are set in the config then start as a single server.
 	config.EnableSingleNode = (len(s.peers) == 0)
 
 	// Build raft layer to multiplex listener.
-	s.raftLayer = newRaftLayer(s.RaftListener, s.Addr)
+	s.raftLayer = newRaftLayer(s.listener(s.RaftListener), s.Addr, s.TLSConfig)
 
 	// Create a transport layer
 	s.transport = raft.NewNetworkTransport(s.raftLayer, 3, 10*time.Second, os.Stderr)
@@ -291,6 +426,36 @@ func (s *Store) close() error {
 	return nil
 }
 
+// upgradeFormat checks the format header of the store directory and stamps it
+// with the current version. Directories written before headers existed are
+// treated as version 0.
+func (s *Store) upgradeFormat() error {
+	var hdr format.Header
+	if b, err := ioutil.ReadFile(s.FormatPath()); err == nil {
+		if err := hdr.UnmarshalBinary(b); err != nil {
+			return fmt.Errorf("read file: %s", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("read file: %s", err)
+	}
+
+	hdr, changed, err := StoreFormat.Upgrade(hdr)
+	if err != nil {
+		return err
+	} else if !changed {
+		return nil
+	}
+
+	b, err := hdr.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(s.FormatPath(), b, 0666); err != nil {
+		return fmt.Errorf("write file: %s", err)
+	}
+	return nil
+}
+
 // readID reads the local node ID from the ID file.
 func (s *Store) readID() error {
 	b, err := ioutil.ReadFile(s.IDPath())
@@ -334,10 +499,16 @@ func (s *Store) createLocalNode() error {
 		return fmt.Errorf("wait for leader: %s", err)
 	}
 
-	// Create new node.
-	ni, err := s.CreateNode(s.Addr.String())
-	if err != nil {
-		return fmt.Errorf("create node: %s", err)
+	// Use the node if it was already joined to the cluster by its host.
+	// Otherwise create a new node.
+	s.mu.RLock()
+	ni := s.data.NodeByHost(s.Addr.String())
+	s.mu.RUnlock()
+	if ni == nil {
+		var err error
+		if ni, err = s.CreateNode(s.Addr.String()); err != nil {
+			return fmt.Errorf("create node: %s", err)
+		}
 	}
 
 	// Write node id to file.
@@ -350,12 +521,64 @@ func (s *Store) createLocalNode() error {
 
 	s.Logger.Printf("created local node: id=%d, host=%s", s.id, s.Addr.String())
 
+	// Set the zone before any shards are assigned to the node.
+	if s.Zone != "" || s.Rack != "" {
+		if err := s.SetNodeZone(ni.ID, s.Zone, s.Rack); err != nil {
+			return fmt.Errorf("set node zone: %s", err)
+		}
+	}
+
 	return nil
 }
 
+// updateLocalZone sets the zone and rack of an existing local node if they
+// don't match the configuration.
+func (s *Store) updateLocalZone() {
+	if err := s.WaitForLeader(30 * time.Second); err != nil {
+		s.Logger.Printf("wait for leader to update zone: %s", err)
+		return
+	}
+
+	ni, err := s.Node(s.id)
+	if err != nil {
+		s.Logger.Printf("read local node to update zone: %s", err)
+		return
+	} else if ni == nil || (ni.Zone == s.Zone && ni.Rack == s.Rack) {
+		return
+	}
+
+	if err := s.SetNodeZone(s.id, s.Zone, s.Rack); err != nil {
+		s.Logger.Printf("update zone: %s", err)
+		return
+	}
+	s.Logger.Printf("updated local node zone: id=%d, zone=%s, rack=%s", s.id, s.Zone, s.Rack)
+}
+
+// updatePasswordHashCost sets the cluster's password hash cost if it doesn't
+// match the configuration.
+func (s *Store) updatePasswordHashCost(cost int) {
+	if err := s.WaitForLeader(30 * time.Second); err != nil {
+		s.Logger.Printf("wait for leader to update password hash cost: %s", err)
+		return
+	}
+
+	s.mu.RLock()
+	current := s.data.PasswordHashCost
+	s.mu.RUnlock()
+	if current == cost {
+		return
+	}
+
+	if err := s.SetPasswordHashCost(cost); err != nil {
+		s.Logger.Printf("update password hash cost: %s", err)
+		return
+	}
+	s.Logger.Printf("updated password hash cost: %d", cost)
+}
+
 // WaitForLeader sleeps until a leader is found or a timeout occurs.
 func (s *Store) WaitForLeader(timeout time.Duration) error {
-	if s.raft.Leader() != "" {
+	if s.leader() != "" {
 		return nil
 	}
 
@@ -373,13 +596,29 @@ func (s *Store) WaitForLeader(timeout time.Duration) error {
 		case <-timer.C:
 			return errors.New("timeout")
 		case <-ticker.C:
-			if s.raft.Leader() != "" {
+			if s.leader() != "" {
 				return nil
 			}
 		}
 	}
 }
 
+// Watch returns a channel that is closed the next time the metadata changes,
+// such as when a database, retention policy or shard group is created or
+// removed. Callers should call Watch again before re-reading the metadata
+// so that no change is missed.
+func (s *Store) Watch() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.changed
+}
+
+// notifyChanged wakes up the watchers of the metadata. The lock must be held.
+func (s *Store) notifyChanged() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
 // Ready returns a channel that is closed once the store is initialized.
 func (s *Store) Ready() <-chan struct{} { return s.ready }
 
@@ -401,23 +640,47 @@ func (s *Store) IsLeader() bool {
 func (s *Store) Leader() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	if s.raft == nil {
+	if s.Observer {
+		return s.observerLeader
+	} else if s.raft == nil {
 		return ""
 	}
 	return s.raft.Leader()
 }
 
+// leader returns the current leader without holding the lock.
+func (s *Store) leader() string {
+	if s.Observer {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.observerLeader
+	}
+	return s.raft.Leader()
+}
+
 // LeaderCh returns a channel that notifies on leadership change.
+// Observers never become leader so nothing is sent on their channel.
 // Panics when the store has not been opened yet.
 func (s *Store) LeaderCh() <-chan bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	if s.Observer {
+		return make(chan bool)
+	}
 	assert(s.raft != nil, "cannot retrieve leadership channel when closed")
 	return s.raft.LeaderCh()
 }
 
 // SetPeers sets a list of peers in the cluster.
+// Observers replicate from the new peers instead.
 func (s *Store) SetPeers(addrs []string) error {
+	if s.Observer {
+		s.mu.Lock()
+		s.peers = addrs
+		s.mu.Unlock()
+		return nil
+	}
+
 	a := make([]string, len(addrs))
 	for i, s := range addrs {
 		addr, err := net.ResolveTCPAddr("tcp", s)
@@ -434,9 +697,10 @@ func (s *Store) SetPeers(addrs []string) error {
 func (s *Store) serveExecListener() {
 	defer s.wg.Done()
 
+	ln := s.listener(s.ExecListener)
 	for {
 		// Accept next TCP connection.
-		conn, err := s.ExecListener.Accept()
+		conn, err := ln.Accept()
 		if err != nil {
 			if strings.Contains(err.Error(), "connection closed") {
 				return
@@ -462,10 +726,19 @@ func (s *Store) handleExecConn(conn net.Conn) {
 		b := make([]byte, 4)
 		if _, err := io.ReadFull(conn, b); err != nil {
 			return fmt.Errorf("read magic: %s", err)
-		} else if string(b) != ExecMagic {
+		} else if string(b) != ExecMagic && string(b) != FetchDataMagic && string(b) != AuthenticateMagic {
 			return fmt.Errorf("invalid exec magic: %q", string(b))
 		}
 
+		// Authenticate the peer before reading its request.
+		if err := s.readJoinToken(conn); err != nil {
+			return err
+		} else if string(b) == FetchDataMagic {
+			return s.handleFetchData(conn)
+		} else if string(b) == AuthenticateMagic {
+			return s.handleAuthenticate(conn)
+		}
+
 		// Read command size.
 		var sz uint64
 		if err := binary.Read(conn, binary.BigEndian, &sz); err != nil {
@@ -478,9 +751,10 @@ func (s *Store) handleExecConn(conn net.Conn) {
 			return fmt.Errorf("read command: %s", err)
 		}
 
-		// Ensure command can be deserialized before applying.
-		if err := proto.Unmarshal(buf, &internal.Command{}); err != nil {
-			return fmt.Errorf("unable to unmarshal command: %s", err)
+		// Ensure command can be deserialized and is authorized before applying.
+		buf, err := s.authorizeCommand(buf)
+		if err != nil {
+			return err
 		}
 
 		// Apply against the raft log.
@@ -490,166 +764,544 @@ func (s *Store) handleExecConn(conn net.Conn) {
 		return nil
 	}()
 
+	// The fetch data handler writes its own response.
+	if err == errResponseWritten {
+		conn.Close()
+		return
+	}
+
 	// Build response message.
 	var resp internal.Response
 	resp.OK = proto.Bool(err == nil)
-	resp.Index = proto.Uint64(s.raft.LastIndex())
+	resp.Index = proto.Uint64(s.lastIndex())
 	if err != nil {
 		resp.Error = proto.String(err.Error())
 	}
-
-	// Encode response back to connection.
-	if b, err := proto.Marshal(&resp); err != nil {
-		panic(err)
-	} else if err = binary.Write(conn, binary.BigEndian, uint64(len(b))); err != nil {
-		s.Logger.Printf("unable to write exec response size: %s", err)
-	} else if _, err = conn.Write(b); err != nil {
-		s.Logger.Printf("unable to write exec response: %s", err)
-	}
+	s.writeResponse(conn, &resp)
 	conn.Close()
 }
 
-// MarshalBinary encodes the store's data to a binary protobuf format.
-func (s *Store) MarshalBinary() ([]byte, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.data.MarshalBinary()
-}
-
-// ClusterID returns the unique identifier for the cluster.
-// This is generated once a node has been created.
-func (s *Store) ClusterID() (id uint64, err error) {
-	err = s.read(func(data *Data) error {
-		id = data.ClusterID
-		return nil
-	})
-	return
-}
+// readJoinToken reads the join token sent by the peer and returns an error if
+// it doesn't match the store's token.
+func (s *Store) readJoinToken(conn net.Conn) error {
+	var sz uint64
+	if err := binary.Read(conn, binary.BigEndian, &sz); err != nil {
+		return fmt.Errorf("read join token size: %s", err)
+	} else if sz > maxJoinTokenSize {
+		return ErrJoinTokenInvalid
+	}
 
-// NodeID returns the identifier for the local node.
-// Panics if the node has not joined the cluster.
-func (s *Store) NodeID() uint64 { return s.id }
+	token := make([]byte, sz)
+	if _, err := io.ReadFull(conn, token); err != nil {
+		return fmt.Errorf("read join token: %s", err)
+	}
 
-// Node returns a node by id.
-func (s *Store) Node(id uint64) (ni *NodeInfo, err error) {
-	err = s.read(func(data *Data) error {
-		ni = data.Node(id)
-		if ni == nil {
-			return errInvalidate
-		}
-		return nil
-	})
-	return
+	if s.JoinToken != "" && subtle.ConstantTimeCompare(token, []byte(s.JoinToken)) != 1 {
+		s.Logger.Printf("rejected exec connection without a valid join token: addr=%s", conn.RemoteAddr())
+		return ErrJoinTokenInvalid
+	}
+	return nil
 }
 
-// NodeByHost returns a node by hostname.
-func (s *Store) NodeByHost(host string) (ni *NodeInfo, err error) {
-	err = s.read(func(data *Data) error {
-		ni = data.NodeByHost(host)
-		if ni == nil {
-			return errInvalidate
-		}
-		return nil
-	})
-	return
+// writeJoinToken sends the store's join token to authenticate an exec
+// connection.
+func (s *Store) writeJoinToken(conn net.Conn) error {
+	if err := binary.Write(conn, binary.BigEndian, uint64(len(s.JoinToken))); err != nil {
+		return fmt.Errorf("write join token size: %s", err)
+	} else if _, err := conn.Write([]byte(s.JoinToken)); err != nil {
+		return fmt.Errorf("write join token: %s", err)
+	}
+	return nil
 }
 
-// Nodes returns a list of all nodes.
-func (s *Store) Nodes() (a []NodeInfo, err error) {
-	err = s.read(func(data *Data) error {
-		a = data.Nodes
-		return nil
-	})
-	return
-}
+// errResponseWritten is returned to handleExecConn() when the response has
+// already been written to the connection.
+var errResponseWritten = errors.New("response written")
 
-// CreateNode creates a new node in the store.
-func (s *Store) CreateNode(host string) (*NodeInfo, error) {
-	if err := s.exec(internal.Command_CreateNodeCommand, internal.E_CreateNodeCommand_Command,
-		&internal.CreateNodeCommand{
-			Host: proto.String(host),
-			Rand: proto.Uint64(uint64(rand.Int63())),
-		},
-	); err != nil {
-		return nil, err
+// handleFetchData reads the metadata index known by an observer and responds
+// with the current leader and, if it is newer, a copy of the metadata without
+// password or API key hashes. Followers send the maximum index to request
+// only the leader's index.
+func (s *Store) handleFetchData(conn net.Conn) error {
+	var index uint64
+	if err := binary.Read(conn, binary.BigEndian, &index); err != nil {
+		return fmt.Errorf("read index: %s", err)
 	}
-	return s.NodeByHost(host)
-}
 
-// DeleteNode removes a node from the metastore by id.
-func (s *Store) DeleteNode(id uint64) error {
-	return s.exec(internal.Command_DeleteNodeCommand, internal.E_DeleteNodeCommand_Command,
-		&internal.DeleteNodeCommand{
-			ID: proto.Uint64(id),
-		},
-	)
-}
+	s.mu.RLock()
+	data := s.data
+	s.mu.RUnlock()
 
-// Database returns a database by name.
-func (s *Store) Database(name string) (di *DatabaseInfo, err error) {
-	err = s.read(func(data *Data) error {
-		di = data.Database(name)
-		if di == nil {
-			return errInvalidate
+	var resp internal.Response
+	resp.OK = proto.Bool(true)
+	resp.Index = proto.Uint64(data.Index)
+	resp.Leader = proto.String(s.leader())
+	if data.Index > index {
+		b, err := data.withoutHashes().MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("marshal data: %s", err)
 		}
-		return nil
-	})
-	return
-}
+		resp.Data = b
+	}
+	s.writeResponse(conn, &resp)
 
-// Databases returns a list of all databases.
-func (s *Store) Databases() (dis []DatabaseInfo, err error) {
-	err = s.read(func(data *Data) error {
-		dis = data.Databases
-		return nil
-	})
-	return
+	return errResponseWritten
 }
 
-// CreateDatabase creates a new database in the store.
-func (s *Store) CreateDatabase(name string) (*DatabaseInfo, error) {
-	if err := s.exec(internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command,
-		&internal.CreateDatabaseCommand{
-			Name: proto.String(name),
-		},
-	); err != nil {
-		return nil, err
+// handleAuthenticate reads a username and password from an observer and
+// responds with the name of the authenticated user. A blank username
+// authenticates an API key instead and responds with the key's name.
+func (s *Store) handleAuthenticate(conn net.Conn) error {
+	username, err := readCredential(conn)
+	if err != nil {
+		return fmt.Errorf("read username: %s", err)
+	}
+	secret, err := readCredential(conn)
+	if err != nil {
+		return fmt.Errorf("read password: %s", err)
 	}
 
-	if s.retentionAutoCreate {
-		// Read node count.
-		// Retention policies must be fully replicated.
-		var nodeN int
-		if err := s.read(func(data *Data) error {
-			nodeN = len(data.Nodes)
-			return nil
-		}); err != nil {
-			return nil, fmt.Errorf("read: %s", err)
-		}
+	var ui *UserInfo
+	if username != "" {
+		ui, err = s.Authenticate(username, secret)
+	} else {
+		ui, err = s.AuthenticateAPIKey(secret)
+	}
+	if err != nil {
+		return err
+	}
 
-		// Create a retention policy.
-		rpi := NewRetentionPolicyInfo(AutoCreateRetentionPolicyName)
-		rpi.ReplicaN = nodeN
-		rpi.Duration = AutoCreateRetentionPolicyPeriod
-		if _, err := s.CreateRetentionPolicy(name, rpi); err != nil {
-			return nil, err
-		}
+	var resp internal.Response
+	resp.OK = proto.Bool(true)
+	resp.Index = proto.Uint64(s.lastIndex())
+	resp.Name = proto.String(ui.Name)
+	s.writeResponse(conn, &resp)
 
-		// Set it as the default retention policy.
-		if err := s.SetDefaultRetentionPolicy(name, AutoCreateRetentionPolicyName); err != nil {
-			return nil, err
+	return errResponseWritten
+}
+
+// remoteAuthenticate authenticates a user, or an API key if username is
+// blank, at the first peer that can be reached and returns the name of the
+// user or key.
+func (s *Store) remoteAuthenticate(username, secret string) (string, error) {
+	s.mu.RLock()
+	peers := s.peers
+	s.mu.RUnlock()
+
+	var err error
+	for _, peer := range peers {
+		var resp *internal.Response
+		if resp, err = s.remoteAuthenticateAt(peer, username, secret); err != nil {
+			continue
+		} else if !resp.GetOK() {
+			return "", lookupError(errors.New(resp.GetError()))
 		}
+		return resp.GetName(), nil
 	}
-
-	return s.Database(name)
+	return "", err
 }
 
-// CreateDatabaseIfNotExists creates a new database in the store if it doesn't already exist.
-func (s *Store) CreateDatabaseIfNotExists(name string) (*DatabaseInfo, error) {
-	// Try to find database locally first.
-	if di, err := s.Database(name); err != nil {
+// remoteAuthenticateAt sends credentials to a peer and returns its response.
+func (s *Store) remoteAuthenticateAt(addr, username, secret string) (*internal.Response, error) {
+	conn, err := dial(addr, MuxExecHeader, 10*time.Second, s.TLSConfig)
+	if err != nil {
 		return nil, err
-	} else if di != nil {
+	}
+	defer conn.Close()
+
+	// Write a marker message, the join token and the credentials.
+	if _, err := conn.Write([]byte(AuthenticateMagic)); err != nil {
+		return nil, err
+	} else if err := s.writeJoinToken(conn); err != nil {
+		return nil, err
+	} else if err := writeCredential(conn, username); err != nil {
+		return nil, fmt.Errorf("write username: %s", err)
+	} else if err := writeCredential(conn, secret); err != nil {
+		return nil, fmt.Errorf("write password: %s", err)
+	}
+
+	// Read response.
+	var sz uint64
+	if err := binary.Read(conn, binary.BigEndian, &sz); err != nil {
+		return nil, fmt.Errorf("read response size: %s", err)
+	}
+	buf := make([]byte, sz)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, fmt.Errorf("read response: %s", err)
+	}
+
+	var resp internal.Response
+	if err := proto.Unmarshal(buf, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %s", err)
+	}
+	return &resp, nil
+}
+
+// readCredential reads a size prefixed username, password or API key.
+func readCredential(conn net.Conn) (string, error) {
+	var sz uint64
+	if err := binary.Read(conn, binary.BigEndian, &sz); err != nil {
+		return "", err
+	} else if sz > maxCredentialSize {
+		return "", fmt.Errorf("credential too large: %d bytes", sz)
+	}
+
+	buf := make([]byte, sz)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// writeCredential writes a size prefixed username, password or API key.
+func writeCredential(conn net.Conn, v string) error {
+	if err := binary.Write(conn, binary.BigEndian, uint64(len(v))); err != nil {
+		return err
+	}
+	_, err := conn.Write([]byte(v))
+	return err
+}
+
+// writeResponse encodes a response to an exec connection.
+func (s *Store) writeResponse(conn net.Conn, resp *internal.Response) {
+	if b, err := proto.Marshal(resp); err != nil {
+		panic(err)
+	} else if err = binary.Write(conn, binary.BigEndian, uint64(len(b))); err != nil {
+		s.Logger.Printf("unable to write exec response size: %s", err)
+	} else if _, err = conn.Write(b); err != nil {
+		s.Logger.Printf("unable to write exec response: %s", err)
+	}
+}
+
+// lastIndex returns the index of the last raft log entry. Observers return
+// the index of the metadata replicated from their peers.
+func (s *Store) lastIndex() uint64 {
+	if s.Observer {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.data.Index
+	}
+	return s.raft.LastIndex()
+}
+
+// serveRaftListener closes raft connections to an observer.
+// This function runs in a separate goroutine.
+func (s *Store) serveRaftListener() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.RaftListener.Accept()
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}
+}
+
+// observe replicates the metadata from the peers until the store is closed.
+// The store is started once a peer reports the leader.
+// This function runs in a separate goroutine.
+func (s *Store) observe() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.ObserverSyncInterval)
+	defer ticker.Stop()
+
+	started := false
+	for {
+		if err := s.fetchData(); err != nil {
+			s.Logger.Printf("observer fetch data: %s", err)
+		} else if !started && s.leader() != "" {
+			s.start()
+			started = true
+		}
+
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchData replaces the metadata with a newer copy from the first peer
+// that responds.
+func (s *Store) fetchData() error {
+	s.mu.RLock()
+	peers, index := s.peers, s.data.Index
+	s.mu.RUnlock()
+
+	var err error
+	for _, peer := range peers {
+		var resp *internal.Response
+		if resp, err = s.fetchDataFrom(peer, index); err != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.observerLeader = resp.GetLeader()
+		if resp.Data != nil && resp.GetIndex() > s.data.Index {
+			data := &Data{}
+			if err := data.UnmarshalBinary(resp.Data); err != nil {
+				return fmt.Errorf("unmarshal data: %s", err)
+			}
+			s.data = data
+			s.notifyChanged()
+		}
+		return nil
+	}
+	return err
+}
+
+// fetchDataFrom requests the metadata from a peer if it is newer than index.
+func (s *Store) fetchDataFrom(addr string, index uint64) (*internal.Response, error) {
+	conn, err := dial(addr, MuxExecHeader, 10*time.Second, s.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Write a marker message, the join token and the index already replicated.
+	if _, err := conn.Write([]byte(FetchDataMagic)); err != nil {
+		return nil, err
+	} else if err := s.writeJoinToken(conn); err != nil {
+		return nil, err
+	} else if err := binary.Write(conn, binary.BigEndian, index); err != nil {
+		return nil, fmt.Errorf("write index: %s", err)
+	}
+
+	// Read response.
+	var sz uint64
+	if err := binary.Read(conn, binary.BigEndian, &sz); err != nil {
+		return nil, fmt.Errorf("read response size: %s", err)
+	}
+	buf := make([]byte, sz)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, fmt.Errorf("read response: %s", err)
+	}
+
+	var resp internal.Response
+	if err := proto.Unmarshal(buf, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %s", err)
+	} else if !resp.GetOK() {
+		return nil, fmt.Errorf("fetch data failed: %s", resp.GetError())
+	}
+	return &resp, nil
+}
+
+// MarshalBinary encodes the store's data to a binary protobuf format.
+func (s *Store) MarshalBinary() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.MarshalBinary()
+}
+
+// ClusterID returns the unique identifier for the cluster.
+// This is generated once a node has been created.
+func (s *Store) ClusterID() (id uint64, err error) {
+	err = s.read(func(data *Data) error {
+		id = data.ClusterID
+		return nil
+	})
+	return
+}
+
+// NodeID returns the identifier for the local node.
+// Panics if the node has not joined the cluster.
+func (s *Store) NodeID() uint64 { return s.id }
+
+// Node returns a node by id.
+func (s *Store) Node(id uint64) (ni *NodeInfo, err error) {
+	err = s.read(func(data *Data) error {
+		ni = data.Node(id)
+		if ni == nil {
+			return errInvalidate
+		}
+		return nil
+	})
+	return
+}
+
+// NodeByHost returns a node by hostname.
+func (s *Store) NodeByHost(host string) (ni *NodeInfo, err error) {
+	err = s.read(func(data *Data) error {
+		ni = data.NodeByHost(host)
+		if ni == nil {
+			return errInvalidate
+		}
+		return nil
+	})
+	return
+}
+
+// Nodes returns a list of all nodes.
+func (s *Store) Nodes() (a []NodeInfo, err error) {
+	err = s.read(func(data *Data) error {
+		a = data.Nodes
+		return nil
+	})
+	return
+}
+
+// CreateNode creates a new node in the store.
+func (s *Store) CreateNode(host string) (*NodeInfo, error) {
+	cmd := &internal.CreateNodeCommand{
+		Host: proto.String(host),
+		Rand: proto.Uint64(uint64(rand.Int63())),
+	}
+	if s.JoinToken != "" {
+		cmd.JoinToken = proto.String(s.JoinToken)
+	}
+	if err := s.exec(internal.Command_CreateNodeCommand, internal.E_CreateNodeCommand_Command, cmd); err != nil {
+		return nil, err
+	}
+	return s.NodeByHost(host)
+}
+
+// DeleteNode removes a node from the metastore by id. If force is set then
+// a node that still owns shards is removed from their owners first.
+func (s *Store) DeleteNode(id uint64, force bool) error {
+	return s.exec(internal.Command_DeleteNodeCommand, internal.E_DeleteNodeCommand_Command,
+		&internal.DeleteNodeCommand{
+			ID:    proto.Uint64(id),
+			Force: proto.Bool(force),
+		},
+	)
+}
+
+// SetNodeDraining marks a node as draining, or no longer draining.
+func (s *Store) SetNodeDraining(id uint64, draining bool) error {
+	return s.exec(internal.Command_SetNodeDrainingCommand, internal.E_SetNodeDrainingCommand_Command,
+		&internal.SetNodeDrainingCommand{
+			ID:       proto.Uint64(id),
+			Draining: proto.Bool(draining),
+		},
+	)
+}
+
+// SetNodeZone sets the zone and rack of a node.
+func (s *Store) SetNodeZone(id uint64, zone, rack string) error {
+	return s.exec(internal.Command_SetNodeZoneCommand, internal.E_SetNodeZoneCommand_Command,
+		&internal.SetNodeZoneCommand{
+			ID:   proto.Uint64(id),
+			Zone: proto.String(zone),
+			Rack: proto.String(rack),
+		},
+	)
+}
+
+// ReassignShard replaces an owner of a shard with another node.
+func (s *Store) ReassignShard(shardID, oldOwnerID, newOwnerID uint64) error {
+	return s.exec(internal.Command_ReassignShardCommand, internal.E_ReassignShardCommand_Command,
+		&internal.ReassignShardCommand{
+			ShardID:    proto.Uint64(shardID),
+			OldOwnerID: proto.Uint64(oldOwnerID),
+			NewOwnerID: proto.Uint64(newOwnerID),
+		},
+	)
+}
+
+// CreateSnapshot records the manifest of a cluster snapshot and returns it
+// with its assigned id.
+func (s *Store) CreateSnapshot(si *SnapshotInfo) (*SnapshotInfo, error) {
+	if err := s.exec(internal.Command_CreateSnapshotCommand, internal.E_CreateSnapshotCommand_Command,
+		&internal.CreateSnapshotCommand{
+			Snapshot: si.marshal(),
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	// Find the newest snapshot matching the one just created.
+	var other *SnapshotInfo
+	err := s.read(func(data *Data) error {
+		for i := len(data.Snapshots) - 1; i >= 0; i-- {
+			if data.Snapshots[i].CreatedAt.Equal(si.CreatedAt) && data.Snapshots[i].Path == si.Path {
+				v := data.Snapshots[i].clone()
+				other = &v
+				return nil
+			}
+		}
+		return errInvalidate
+	})
+	return other, err
+}
+
+// Snapshots returns the manifests of all cluster snapshots.
+func (s *Store) Snapshots() (a []SnapshotInfo, err error) {
+	err = s.read(func(data *Data) error {
+		a = data.Snapshots
+		return nil
+	})
+	return
+}
+
+// Database returns a database by name.
+func (s *Store) Database(name string) (di *DatabaseInfo, err error) {
+	err = s.read(func(data *Data) error {
+		di = data.Database(name)
+		if di == nil {
+			return errInvalidate
+		}
+		return nil
+	})
+	return
+}
+
+// Databases returns a list of all databases.
+func (s *Store) Databases() (dis []DatabaseInfo, err error) {
+	err = s.read(func(data *Data) error {
+		dis = data.Databases
+		return nil
+	})
+	return
+}
+
+// CreateDatabase creates a new database in the store.
+func (s *Store) CreateDatabase(name string) (*DatabaseInfo, error) {
+	if err := s.exec(internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command,
+		&internal.CreateDatabaseCommand{
+			Name: proto.String(name),
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	if s.retentionAutoCreate {
+		// Read node count.
+		// Retention policies must be fully replicated.
+		var nodeN int
+		if err := s.read(func(data *Data) error {
+			nodeN = len(data.Nodes)
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("read: %s", err)
+		}
+
+		// Create a retention policy.
+		rpi := NewRetentionPolicyInfo(AutoCreateRetentionPolicyName)
+		rpi.ReplicaN = nodeN
+		rpi.Duration = AutoCreateRetentionPolicyPeriod
+		if _, err := s.CreateRetentionPolicy(name, rpi); err != nil {
+			return nil, err
+		}
+
+		// Set it as the default retention policy.
+		if err := s.SetDefaultRetentionPolicy(name, AutoCreateRetentionPolicyName); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.Database(name)
+}
+
+// CreateDatabaseIfNotExists creates a new database in the store if it doesn't already exist.
+func (s *Store) CreateDatabaseIfNotExists(name string) (*DatabaseInfo, error) {
+	// Try to find database locally first.
+	if di, err := s.Database(name); err != nil {
+		return nil, err
+	} else if di != nil {
 		return di, nil
 	}
 
@@ -670,6 +1322,44 @@ func (s *Store) DropDatabase(name string) error {
 	)
 }
 
+// RenameDatabase renames a database along with its retention policies,
+// continuous queries and the privileges on it.
+func (s *Store) RenameDatabase(oldName, newName string) error {
+	return s.exec(internal.Command_RenameDatabaseCommand, internal.E_RenameDatabaseCommand_Command,
+		&internal.RenameDatabaseCommand{
+			OldName: proto.String(oldName),
+			NewName: proto.String(newName),
+		},
+	)
+}
+
+// UpdateSchema registers the fields and tag keys written to measurements in
+// a database. Writes that only use registered fields and tag keys don't go
+// through raft. Returns an error if a field is registered with another type.
+func (s *Store) UpdateSchema(database string, mis []MeasurementInfo) error {
+	var a []MeasurementInfo
+	if err := s.read(func(data *Data) error {
+		di := data.Database(database)
+		if di == nil {
+			return ErrDatabaseNotFound
+		}
+
+		var err error
+		a, err = di.newSchema(mis)
+		return err
+	}); err != nil {
+		return err
+	} else if len(a) == 0 {
+		return nil
+	}
+
+	cmd := &internal.UpdateSchemaCommand{Database: proto.String(database)}
+	for i := range a {
+		cmd.Measurements = append(cmd.Measurements, a[i].marshal())
+	}
+	return s.exec(internal.Command_UpdateSchemaCommand, internal.E_UpdateSchemaCommand_Command, cmd)
+}
+
 // RetentionPolicy returns a retention policy for a database by name.
 func (s *Store) RetentionPolicy(database, name string) (rpi *RetentionPolicyInfo, err error) {
 	err = s.read(func(data *Data) error {
@@ -779,13 +1469,20 @@ func (s *Store) UpdateRetentionPolicy(database, name string, rpu *RetentionPolic
 		replicaN = &value
 	}
 
+	var shardGroupDuration *int64
+	if rpu.ShardGroupDuration != nil {
+		value := int64(*rpu.ShardGroupDuration)
+		shardGroupDuration = &value
+	}
+
 	return s.exec(internal.Command_UpdateRetentionPolicyCommand, internal.E_UpdateRetentionPolicyCommand_Command,
 		&internal.UpdateRetentionPolicyCommand{
-			Database: proto.String(database),
-			Name:     proto.String(name),
-			NewName:  newName,
-			Duration: duration,
-			ReplicaN: replicaN,
+			Database:           proto.String(database),
+			Name:               proto.String(name),
+			NewName:            newName,
+			Duration:           duration,
+			ReplicaN:           replicaN,
+			ShardGroupDuration: shardGroupDuration,
 		},
 	)
 }
@@ -845,6 +1542,28 @@ func (s *Store) DeleteShardGroup(database, policy string, id uint64) error {
 	)
 }
 
+// PruneShardGroups removes the shard groups deleted before expiration.
+func (s *Store) PruneShardGroups(expiration time.Time) error {
+	return s.exec(internal.Command_PruneShardGroupsCommand, internal.E_PruneShardGroupsCommand_Command,
+		&internal.PruneShardGroupsCommand{
+			Expiration: proto.Int64(MarshalTime(expiration)),
+		},
+	)
+}
+
+// SetMeasurementTTL sets how long the points of a measurement are kept in a
+// retention policy. A zero TTL removes it.
+func (s *Store) SetMeasurementTTL(database, policy, measurement string, ttl time.Duration) error {
+	return s.exec(internal.Command_SetMeasurementTTLCommand, internal.E_SetMeasurementTTLCommand_Command,
+		&internal.SetMeasurementTTLCommand{
+			Database:    proto.String(database),
+			Policy:      proto.String(policy),
+			Measurement: proto.String(measurement),
+			TTL:         proto.Int64(int64(ttl)),
+		},
+	)
+}
+
 // ShardGroups returns a list of all shard groups for a policy by timestamp.
 func (s *Store) ShardGroups(database, policy string) (a []ShardGroupInfo, err error) {
 	err = s.read(func(data *Data) error {
@@ -937,6 +1656,7 @@ func (s *Store) User(name string) (ui *UserInfo, err error) {
 		if ui == nil {
 			return errInvalidate
 		}
+		ui = data.userWithRoles(ui)
 		return nil
 	})
 	return
@@ -966,7 +1686,29 @@ func (s *Store) AdminUserExists() (exists bool, err error) {
 }
 
 // Authenticate retrieves a user with a matching username and password.
+// The password is queued to be rehashed if its hash was made with a different
+// cost than the cluster's.
 func (s *Store) Authenticate(username, password string) (ui *UserInfo, err error) {
+	// Observers don't have the password hashes so a peer checks the password.
+	if s.Observer {
+		name, err := s.remoteAuthenticate(username, password)
+		if err != nil {
+			return nil, err
+		}
+		err = s.read(func(data *Data) error {
+			u := data.User(name)
+			if u == nil {
+				return ErrUserNotFound
+			}
+			ui = data.userWithRoles(u)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return ui, nil
+	}
+
 	err = s.read(func(data *Data) error {
 		// Find user.
 		u := data.User(username)
@@ -977,18 +1719,61 @@ func (s *Store) Authenticate(username, password string) (ui *UserInfo, err error
 		// Compare password with user hash.
 		if err := bcrypt.CompareHashAndPassword([]byte(u.Hash), []byte(password)); err != nil {
 			return err
+		} else if u.PasswordExpired {
+			return ErrPasswordExpired
 		}
 
-		ui = u
+		ui = data.userWithRoles(u)
 		return nil
 	})
-	return
+	if err != nil {
+		return nil, err
+	}
+
+	// Rehashing writes through raft so it's left to processRehashes rather
+	// than holding up the login.
+	if cost, err := bcrypt.Cost([]byte(ui.Hash)); err == nil && cost != s.passwordHashCost() {
+		select {
+		case s.rehashes <- rehashRequest{username: username, password: password, hash: ui.Hash}:
+		default:
+		}
+	}
+	return ui, nil
+}
+
+// rehashRequest is a login whose password hash was made with a stale cost.
+type rehashRequest struct {
+	username string
+	password string
+	hash     string // the hash the password was checked against
+}
+
+// processRehashes rehashes the passwords of queued logins until the store is
+// closed. A failed rehash is retried on a later login.
+func (s *Store) processRehashes() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case r := <-s.rehashes:
+			// Skip users whose hash changed since the login was queued,
+			// such as when the same user logged in twice in a row.
+			if ui, err := s.User(r.username); err != nil || ui == nil || ui.Hash != r.hash {
+				continue
+			}
+
+			if err := s.UpdateUser(r.username, r.password); err != nil {
+				s.Logger.Printf("rehash password for %s: %s", r.username, err)
+			}
+		}
+	}
 }
 
 // CreateUser creates a new user in the store.
 func (s *Store) CreateUser(name, password string, admin bool) (*UserInfo, error) {
 	// Hash the password before serializing it.
-	hash, err := HashPassword(password)
+	hash, err := HashPassword(password, s.passwordHashCost())
 	if err != nil {
 		return nil, err
 	}
@@ -1018,7 +1803,7 @@ func (s *Store) DropUser(name string) error {
 // UpdateUser updates an existing user in the store.
 func (s *Store) UpdateUser(name, password string) error {
 	// Hash the password before serializing it.
-	hash, err := HashPassword(password)
+	hash, err := HashPassword(password, s.passwordHashCost())
 	if err != nil {
 		return err
 	}
@@ -1032,6 +1817,41 @@ func (s *Store) UpdateUser(name, password string) error {
 	)
 }
 
+// ExpirePassword forces a user to change their password. The user can't
+// authenticate until a new password is set with UpdateUser.
+func (s *Store) ExpirePassword(name string) error {
+	return s.exec(internal.Command_ExpirePasswordCommand, internal.E_ExpirePasswordCommand_Command,
+		&internal.ExpirePasswordCommand{
+			Username: proto.String(name),
+		},
+	)
+}
+
+// SetPasswordHashCost sets the bcrypt cost of new password hashes across the
+// cluster. A zero cost falls back to each node's configured cost.
+func (s *Store) SetPasswordHashCost(cost int) error {
+	return s.exec(internal.Command_SetPasswordHashCostCommand, internal.E_SetPasswordHashCostCommand_Command,
+		&internal.SetPasswordHashCostCommand{
+			Cost: proto.Int64(int64(cost)),
+		},
+	)
+}
+
+// passwordHashCost returns the bcrypt cost for new password hashes. The
+// cluster's cost takes precedence over the configured one.
+func (s *Store) passwordHashCost() int {
+	s.mu.RLock()
+	cost := s.data.PasswordHashCost
+	s.mu.RUnlock()
+
+	if cost != 0 {
+		return cost
+	} else if s.PasswordHashCost != 0 {
+		return s.PasswordHashCost
+	}
+	return DefaultPasswordHashCost
+}
+
 // SetPrivilege sets a privilege for a user on a database.
 func (s *Store) SetPrivilege(username, database string, p influxql.Privilege) error {
 	return s.exec(internal.Command_SetPrivilegeCommand, internal.E_SetPrivilegeCommand_Command,
@@ -1043,71 +1863,269 @@ func (s *Store) SetPrivilege(username, database string, p influxql.Privilege) er
 	)
 }
 
-// UserPrivileges returns a list of all databases.
-func (s *Store) UserPrivileges(username string) (p map[string]influxql.Privilege, err error) {
-	err = s.read(func(data *Data) error {
-		p, err = data.UserPrivileges(username)
-		return err
-	})
-	return
+// UserPrivileges returns a list of all databases.
+func (s *Store) UserPrivileges(username string) (p map[string]influxql.Privilege, err error) {
+	err = s.read(func(data *Data) error {
+		p, err = data.UserPrivileges(username)
+		return err
+	})
+	return
+}
+
+// UserCount returns the number of users defined in the cluster.
+func (s *Store) UserCount() (count int, err error) {
+	err = s.read(func(data *Data) error {
+		count = len(data.Users)
+		return nil
+	})
+	return
+}
+
+// Roles returns a list of all roles.
+func (s *Store) Roles() (a []RoleInfo, err error) {
+	err = s.read(func(data *Data) error {
+		a = data.Roles
+		return nil
+	})
+	return
+}
+
+// CreateRole creates a new role without any privileges.
+func (s *Store) CreateRole(name string) error {
+	return s.exec(internal.Command_CreateRoleCommand, internal.E_CreateRoleCommand_Command,
+		&internal.CreateRoleCommand{
+			Name: proto.String(name),
+		},
+	)
+}
+
+// DropRole removes a role by name and revokes it from all users.
+func (s *Store) DropRole(name string) error {
+	return s.exec(internal.Command_DropRoleCommand, internal.E_DropRoleCommand_Command,
+		&internal.DropRoleCommand{
+			Name: proto.String(name),
+		},
+	)
+}
+
+// SetRolePrivilege sets a privilege for a role on a database.
+func (s *Store) SetRolePrivilege(role, database string, p influxql.Privilege) error {
+	return s.exec(internal.Command_SetRolePrivilegeCommand, internal.E_SetRolePrivilegeCommand_Command,
+		&internal.SetRolePrivilegeCommand{
+			Role:      proto.String(role),
+			Database:  proto.String(database),
+			Privilege: proto.Int32(int32(p)),
+		},
+	)
+}
+
+// GrantRole grants a role to a user.
+func (s *Store) GrantRole(username, role string) error {
+	return s.exec(internal.Command_GrantRoleCommand, internal.E_GrantRoleCommand_Command,
+		&internal.GrantRoleCommand{
+			Username: proto.String(username),
+			Role:     proto.String(role),
+		},
+	)
+}
+
+// RevokeRole revokes a role from a user.
+func (s *Store) RevokeRole(username, role string) error {
+	return s.exec(internal.Command_RevokeRoleCommand, internal.E_RevokeRoleCommand_Command,
+		&internal.RevokeRoleCommand{
+			Username: proto.String(username),
+			Role:     proto.String(role),
+		},
+	)
+}
+
+// APIKeys returns a list of all API keys.
+func (s *Store) APIKeys() (a []APIKeyInfo, err error) {
+	err = s.read(func(data *Data) error {
+		a = data.APIKeys
+		return nil
+	})
+	return
+}
+
+// CreateAPIKey creates an API key granting privileges on a set of databases.
+// Returns the generated key. Only its hash is stored so it cannot be
+// retrieved again.
+func (s *Store) CreateAPIKey(name string, privileges map[string]influxql.Privilege) (string, error) {
+	key, err := GenerateAPIKey()
+	if err != nil {
+		return "", err
+	}
+
+	// Serialize command and send it to the leader.
+	if err := s.exec(internal.Command_CreateAPIKeyCommand, internal.E_CreateAPIKeyCommand_Command,
+		&internal.CreateAPIKeyCommand{
+			Name:       proto.String(name),
+			Hash:       proto.String(HashAPIKey(key)),
+			Privileges: marshalPrivileges(privileges),
+		},
+	); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// DropAPIKey removes an API key by name.
+func (s *Store) DropAPIKey(name string) error {
+	return s.exec(internal.Command_DropAPIKeyCommand, internal.E_DropAPIKeyCommand_Command,
+		&internal.DropAPIKeyCommand{
+			Name: proto.String(name),
+		},
+	)
+}
+
+// AuthenticateAPIKey returns a non-admin user carrying the privileges of
+// the API key matching key.
+func (s *Store) AuthenticateAPIKey(key string) (ui *UserInfo, err error) {
+	// Observers don't have the API key hashes so a peer checks the key.
+	if s.Observer {
+		name, err := s.remoteAuthenticate("", key)
+		if err != nil {
+			return nil, err
+		}
+		err = s.read(func(data *Data) error {
+			ak := data.APIKey(name)
+			if ak == nil {
+				return ErrAPIKeyInvalid
+			}
+			ui = ak.UserInfo()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return ui, nil
+	}
+
+	hash := []byte(HashAPIKey(key))
+	err = s.read(func(data *Data) error {
+		for i := range data.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(data.APIKeys[i].Hash), hash) == 1 {
+				ui = data.APIKeys[i].UserInfo()
+				return nil
+			}
+		}
+		return ErrAPIKeyInvalid
+	})
+	return
+}
+
+// PrecreateShardGroups creates the shard groups each retention policy needs
+// up to the time to, following its latest shard group, so writes don't wait
+// for a raft round trip when a new interval starts. Intervals that end before
+// from are skipped. All of the groups are created in a single command.
+func (s *Store) PrecreateShardGroups(from, to time.Time) error {
+	// Only go through raft if there are groups to create.
+	var n uint64
+	if err := s.read(func(data *Data) error {
+		other := data.Clone()
+		if err := other.PrecreateShardGroups(from, to); err != nil {
+			return err
+		}
+		n = other.MaxShardGroupID - data.MaxShardGroupID
+		return nil
+	}); err != nil {
+		return err
+	} else if n == 0 {
+		return nil
+	}
+
+	if err := s.exec(internal.Command_PrecreateShardGroupsCommand, internal.E_PrecreateShardGroupsCommand_Command,
+		&internal.PrecreateShardGroupsCommand{
+			From: proto.Int64(from.UnixNano()),
+			To:   proto.Int64(to.UnixNano()),
+		},
+	); err != nil {
+		return err
+	}
+	s.Logger.Printf("precreated %d shard groups up to %s", n, to.UTC())
+	return nil
+}
+
+// Limits returns the cluster limits.
+func (s *Store) Limits() (l Limits, err error) {
+	err = s.read(func(data *Data) error {
+		l = data.Limits
+		return nil
+	})
+	return
+}
+
+// SetLimit sets a cluster limit by name. A zero value removes the limit.
+func (s *Store) SetLimit(name string, value int) error {
+	return s.exec(internal.Command_SetLimitCommand, internal.E_SetLimitCommand_Command,
+		&internal.SetLimitCommand{
+			Name:  proto.String(name),
+			Value: proto.Int64(int64(value)),
+		},
+	)
+}
+
+// SetData force overwrites the root data.
+// This should only be used when restoring a snapshot.
+func (s *Store) SetData(data *Data) error {
+	return s.exec(internal.Command_SetDataCommand, internal.E_SetDataCommand_Command,
+		&internal.SetDataCommand{
+			Data: data.marshal(),
+		},
+	)
+}
+
+// Replicate overwrites the databases, users, roles, API keys and limits with
+// the metadata of another cluster. This is used to keep a standby cluster in
+// sync with its primary. Local nodes and shard groups are kept.
+func (s *Store) Replicate(data *Data) error {
+	return s.exec(internal.Command_ReplicateCommand, internal.E_ReplicateCommand_Command,
+		&internal.ReplicateCommand{
+			Data: data.marshal(),
+		},
+	)
 }
 
-// UserCount returns the number of users defined in the cluster.
-func (s *Store) UserCount() (count int, err error) {
+// ReadOnly returns true if the metadata is read-only for maintenance.
+func (s *Store) ReadOnly() (readOnly bool, err error) {
 	err = s.read(func(data *Data) error {
-		count = len(data.Users)
+		readOnly = data.ReadOnly
 		return nil
 	})
 	return
 }
 
-// PrecreateShardGroups creates shard groups whose endtime is before the cutoff time passed in. This
-// avoid the need for these shards to be created when data for the corresponding time range arrives.
-// Shard creation involves Raft consensus, and precreation avoids taking the hit at write-time.
-func (s *Store) PrecreateShardGroups(cutoff time.Time) error {
-	s.read(func(data *Data) error {
-		for _, di := range data.Databases {
-			for _, rp := range di.RetentionPolicies {
-				for _, g := range rp.ShardGroups {
-					// Check to see if it is not deleted and going to end before our interval
-					if !g.Deleted() && g.EndTime.Before(cutoff) {
-						nextShardGroupTime := g.EndTime.Add(1 * time.Nanosecond)
-
-						// Check if successive shard group exists.
-						if sgi, err := s.ShardGroupByTimestamp(di.Name, rp.Name, nextShardGroupTime); err != nil {
-							s.Logger.Printf("failed to check if successive shard group for group exists %d: %s",
-								g.ID, err.Error())
-							continue
-						} else if sgi != nil && !sgi.Deleted() {
-							continue
-						}
-
-						// It doesn't. Create it.
-						if newGroup, err := s.CreateShardGroupIfNotExists(di.Name, rp.Name, nextShardGroupTime); err != nil {
-							s.Logger.Printf("failed to create successive shard group for group %d: %s",
-								g.ID, err.Error())
-						} else {
-							s.Logger.Printf("new shard group %d successfully created for database %s, retention policy %s",
-								newGroup.ID, di.Name, rp.Name)
-						}
-					}
-				}
+// SetReadOnly puts the cluster into or takes it out of read-only maintenance
+// mode. While read-only, databases, retention policies, users and nodes can't
+// be changed but data can still be written and backed up.
+func (s *Store) SetReadOnly(readOnly bool) error {
+	return s.exec(internal.Command_SetReadOnlyCommand, internal.E_SetReadOnlyCommand_Command,
+		&internal.SetReadOnlyCommand{
+			ReadOnly: proto.Bool(readOnly),
+		},
+	)
+}
 
-			}
-		}
+// Check returns a description of every inconsistency in the metadata.
+func (s *Store) Check() (a []string, err error) {
+	err = s.read(func(data *Data) error {
+		a = data.Check()
 		return nil
 	})
-	return nil
+	return
 }
 
-// SetData force overwrites the root data.
-// This should only be used when restoring a snapshot.
-func (s *Store) SetData(data *Data) error {
-	return s.exec(internal.Command_SetDataCommand, internal.E_SetDataCommand_Command,
-		&internal.SetDataCommand{
-			Data: data.marshal(),
-		},
-	)
+// Repair fixes the inconsistencies in the metadata through the raft log so
+// every meta node applies the same fixes. Returns the inconsistencies that
+// could not be repaired.
+func (s *Store) Repair() ([]string, error) {
+	if err := s.exec(internal.Command_RepairCommand, internal.E_RepairCommand_Command,
+		&internal.RepairCommand{},
+	); err != nil {
+		return nil, err
+	}
+	return s.Check()
 }
 
 // read executes a function with the current metadata.
@@ -1150,9 +2168,58 @@ func (s *Store) read(fn func(*Data) error) error {
 // but an error should not be passed through to the caller.
 var errInvalidate = errors.New("invalidate cache")
 
+// invalidate waits for the local metadata to catch up with the leader.
+//
+// While the store holds a read lease the local commit index is current so
+// the metadata only has to catch up with it. Otherwise the leader is asked
+// for its index first. Observers fetch a new copy of the metadata instead.
 func (s *Store) invalidate() error {
-	time.Sleep(1 * time.Second)
-	return nil // FIXME(benbjohnson): Reload cache from the leader.
+	if s.Observer {
+		return s.fetchData()
+	}
+
+	// Apply the entries committed before the read.
+	if s.hasReadLease() {
+		index := s.raftIndex("commit_index")
+		return poll(func() bool { return s.raftIndex("applied_index") >= index }, 5*time.Second)
+	}
+
+	leader := s.leader()
+	if leader == "" {
+		return errors.New("no leader")
+	}
+
+	// Request the index only; the data is replicated through raft.
+	resp, err := s.fetchDataFrom(leader, math.MaxUint64)
+	if err != nil {
+		return fmt.Errorf("fetch leader index: %s", err)
+	}
+	return s.sync(resp.GetIndex(), 5*time.Second)
+}
+
+// hasReadLease returns true if the store is the leader or is a follower that
+// has heard from the leader within the read lease timeout.
+func (s *Store) hasReadLease() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.raft == nil {
+		return false
+	} else if s.raft.State() == raft.Leader {
+		return true
+	}
+	return s.ReadLeaseTimeout > 0 && time.Since(s.raft.LastContact()) < s.ReadLeaseTimeout
+}
+
+// raftIndex returns an index reported by the raft stats, such as the
+// commit_index or applied_index.
+func (s *Store) raftIndex(name string) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.raft == nil {
+		return 0
+	}
+	index, _ := strconv.ParseUint(s.raft.Stats()[name], 10, 64)
+	return index
 }
 
 func (s *Store) exec(typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) error {
@@ -1167,15 +2234,51 @@ func (s *Store) exec(typ internal.Command_Type, desc *proto.ExtensionDesc, value
 
 	// Apply the command if this is the leader.
 	// Otherwise remotely execute the command against the current leader.
-	if s.raft.State() == raft.Leader {
+	if !s.Observer && s.raft.State() == raft.Leader {
+		if b, err = s.authorizeCommand(b); err != nil {
+			return err
+		}
 		return s.apply(b)
 	} else {
 		return s.remoteExec(b)
 	}
 }
 
+// authorizeCommand returns an error if a serialized command registers a node
+// without the join token. The token is removed from the returned command so
+// it isn't written to the raft log.
+func (s *Store) authorizeCommand(b []byte) ([]byte, error) {
+	var cmd internal.Command
+	if err := proto.Unmarshal(b, &cmd); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal command: %s", err)
+	} else if cmd.GetType() != internal.Command_CreateNodeCommand {
+		return b, nil
+	}
+
+	ext, err := proto.GetExtension(&cmd, internal.E_CreateNodeCommand_Command)
+	if err != nil {
+		return nil, fmt.Errorf("get extension: %s", err)
+	}
+	v := ext.(*internal.CreateNodeCommand)
+
+	if s.JoinToken != "" && subtle.ConstantTimeCompare([]byte(v.GetJoinToken()), []byte(s.JoinToken)) != 1 {
+		s.Logger.Printf("rejected node without a valid join token: host=%s", v.GetHost())
+		return nil, ErrJoinTokenInvalid
+	}
+
+	v.JoinToken = nil
+	if err := proto.SetExtension(&cmd, internal.E_CreateNodeCommand_Command, v); err != nil {
+		return nil, fmt.Errorf("set extension: %s", err)
+	}
+	return proto.Marshal(&cmd)
+}
+
 // apply applies a serialized command to the raft log.
 func (s *Store) apply(b []byte) error {
+	if s.Observer {
+		return ErrObserver
+	}
+
 	// Apply to raft log.
 	f := s.raft.Apply(b, 0)
 	if err := f.Error(); err != nil {
@@ -1196,28 +2299,24 @@ func (s *Store) apply(b []byte) error {
 // remoteExec sends an encoded command to the remote leader.
 func (s *Store) remoteExec(b []byte) error {
 	// Retrieve the current known leader.
-	leader := s.raft.Leader()
+	leader := s.leader()
 	if leader == "" {
 		return errors.New("no leader")
 	}
 
 	// Create a connection to the leader.
-	conn, err := net.DialTimeout("tcp", leader, 10*time.Second)
+	conn, err := dial(leader, MuxExecHeader, 10*time.Second, s.TLSConfig)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	// Write a marker byte for exec messages.
-	_, err = conn.Write([]byte{MuxExecHeader})
-	if err != nil {
-		return err
-	}
-
-	// Write a marker message.
+	// Write a marker message and the join token.
 	_, err = conn.Write([]byte(ExecMagic))
 	if err != nil {
 		return err
+	} else if err := s.writeJoinToken(conn); err != nil {
+		return err
 	}
 
 	// Write command size & bytes.
@@ -1245,6 +2344,13 @@ func (s *Store) remoteExec(b []byte) error {
 		return fmt.Errorf("exec failed: %s", resp.GetError())
 	}
 
+	// Observers fetch the change instead of waiting for the next sync.
+	if s.Observer {
+		if err := s.fetchData(); err != nil {
+			return fmt.Errorf("fetch data: %s", err)
+		}
+	}
+
 	// Wait for local FSM to sync to index.
 	if err := s.sync(resp.GetIndex(), 5*time.Second); err != nil {
 		return fmt.Errorf("sync: %s", err)
@@ -1255,6 +2361,15 @@ func (s *Store) remoteExec(b []byte) error {
 
 // sync polls the state machine until it reaches a given index.
 func (s *Store) sync(index uint64, timeout time.Duration) error {
+	return poll(func() bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.data.Index >= index
+	}, timeout)
+}
+
+// poll checks fn until it returns true or a timeout occurs.
+func poll(fn func() bool, timeout time.Duration) error {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -1262,113 +2377,327 @@ func (s *Store) sync(index uint64, timeout time.Duration) error {
 	defer timer.Stop()
 
 	for {
+		if fn() {
+			return nil
+		}
+
 		// Wait for next tick or timeout.
 		select {
 		case <-ticker.C:
 		case <-timer.C:
 			return errors.New("timeout")
 		}
-
-		// Compare index against current metadata.
-		s.mu.Lock()
-		ok := (s.data.Index >= index)
-		s.mu.Unlock()
-
-		// Exit if we are at least at the given index.
-		if ok {
-			return nil
-		}
 	}
 }
 
 // storeFSM represents the finite state machine used by Store to interact with Raft.
 type storeFSM Store
 
+// readOnlyCommands are the commands applied while the metadata is read-only.
+// Writes create shard groups and register schemas, backups record
+// snapshots and deleted shard groups are still pruned.
+var readOnlyCommands = map[internal.Command_Type]bool{
+	internal.Command_SetReadOnlyCommand:          true,
+	internal.Command_CreateShardGroupCommand:     true,
+	internal.Command_PrecreateShardGroupsCommand: true,
+	internal.Command_UpdateSchemaCommand:         true,
+	internal.Command_CreateSnapshotCommand:       true,
+	internal.Command_PruneShardGroupsCommand:     true,
+}
+
 func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 	var cmd internal.Command
 	if err := proto.Unmarshal(l.Data, &cmd); err != nil {
 		panic(fmt.Errorf("cannot marshal command: %x", l.Data))
 	}
 
-	// Lock the store.
-	s := (*Store)(fsm)
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	// Lock the store.
+	s := (*Store)(fsm)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := func() interface{} {
+		// Reject changes that aren't needed to write data during maintenance.
+		if fsm.data.ReadOnly && !readOnlyCommands[cmd.GetType()] {
+			return ErrReadOnly
+		}
+
+		switch cmd.GetType() {
+		case internal.Command_CreateNodeCommand:
+			return fsm.applyCreateNodeCommand(&cmd)
+		case internal.Command_DeleteNodeCommand:
+			return fsm.applyDeleteNodeCommand(&cmd)
+		case internal.Command_CreateDatabaseCommand:
+			return fsm.applyCreateDatabaseCommand(&cmd)
+		case internal.Command_DropDatabaseCommand:
+			return fsm.applyDropDatabaseCommand(&cmd)
+		case internal.Command_RenameDatabaseCommand:
+			return fsm.applyRenameDatabaseCommand(&cmd)
+		case internal.Command_UpdateSchemaCommand:
+			return fsm.applyUpdateSchemaCommand(&cmd)
+		case internal.Command_ReplicateCommand:
+			return fsm.applyReplicateCommand(&cmd)
+		case internal.Command_SetReadOnlyCommand:
+			return fsm.applySetReadOnlyCommand(&cmd)
+		case internal.Command_CreateRetentionPolicyCommand:
+			return fsm.applyCreateRetentionPolicyCommand(&cmd)
+		case internal.Command_DropRetentionPolicyCommand:
+			return fsm.applyDropRetentionPolicyCommand(&cmd)
+		case internal.Command_SetDefaultRetentionPolicyCommand:
+			return fsm.applySetDefaultRetentionPolicyCommand(&cmd)
+		case internal.Command_UpdateRetentionPolicyCommand:
+			return fsm.applyUpdateRetentionPolicyCommand(&cmd)
+		case internal.Command_CreateShardGroupCommand:
+			return fsm.applyCreateShardGroupCommand(&cmd)
+		case internal.Command_DeleteShardGroupCommand:
+			return fsm.applyDeleteShardGroupCommand(&cmd)
+		case internal.Command_PruneShardGroupsCommand:
+			return fsm.applyPruneShardGroupsCommand(&cmd)
+		case internal.Command_SetMeasurementTTLCommand:
+			return fsm.applySetMeasurementTTLCommand(&cmd)
+		case internal.Command_CreateContinuousQueryCommand:
+			return fsm.applyCreateContinuousQueryCommand(&cmd)
+		case internal.Command_DropContinuousQueryCommand:
+			return fsm.applyDropContinuousQueryCommand(&cmd)
+		case internal.Command_CreateUserCommand:
+			return fsm.applyCreateUserCommand(&cmd)
+		case internal.Command_DropUserCommand:
+			return fsm.applyDropUserCommand(&cmd)
+		case internal.Command_UpdateUserCommand:
+			return fsm.applyUpdateUserCommand(&cmd)
+		case internal.Command_SetPrivilegeCommand:
+			return fsm.applySetPrivilegeCommand(&cmd)
+		case internal.Command_SetNodeDrainingCommand:
+			return fsm.applySetNodeDrainingCommand(&cmd)
+		case internal.Command_SetNodeZoneCommand:
+			return fsm.applySetNodeZoneCommand(&cmd)
+		case internal.Command_CreateAPIKeyCommand:
+			return fsm.applyCreateAPIKeyCommand(&cmd)
+		case internal.Command_DropAPIKeyCommand:
+			return fsm.applyDropAPIKeyCommand(&cmd)
+		case internal.Command_CreateRoleCommand:
+			return fsm.applyCreateRoleCommand(&cmd)
+		case internal.Command_DropRoleCommand:
+			return fsm.applyDropRoleCommand(&cmd)
+		case internal.Command_SetRolePrivilegeCommand:
+			return fsm.applySetRolePrivilegeCommand(&cmd)
+		case internal.Command_GrantRoleCommand:
+			return fsm.applyGrantRoleCommand(&cmd)
+		case internal.Command_RevokeRoleCommand:
+			return fsm.applyRevokeRoleCommand(&cmd)
+		case internal.Command_ReassignShardCommand:
+			return fsm.applyReassignShardCommand(&cmd)
+		case internal.Command_CreateSnapshotCommand:
+			return fsm.applyCreateSnapshotCommand(&cmd)
+		case internal.Command_SetDataCommand:
+			return fsm.applySetDataCommand(&cmd)
+		case internal.Command_RepairCommand:
+			return fsm.applyRepairCommand(&cmd)
+		case internal.Command_ExpirePasswordCommand:
+			return fsm.applyExpirePasswordCommand(&cmd)
+		case internal.Command_SetPasswordHashCostCommand:
+			return fsm.applySetPasswordHashCostCommand(&cmd)
+		case internal.Command_PrecreateShardGroupsCommand:
+			return fsm.applyPrecreateShardGroupsCommand(&cmd)
+		case internal.Command_SetLimitCommand:
+			return fsm.applySetLimitCommand(&cmd)
+		default:
+			panic(fmt.Errorf("cannot apply command: %x", l.Data))
+		}
+	}()
+
+	// Copy term and index to new metadata.
+	fsm.data.Term = l.Term
+	fsm.data.Index = l.Index
+
+	s.notifyChanged()
+
+	return err
+}
+
+func (fsm *storeFSM) applyCreateNodeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateNodeCommand_Command)
+	v := ext.(*internal.CreateNodeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateNode(v.GetHost()); err != nil {
+		return err
+	}
+
+	// If the cluster ID hasn't been set then use the command's random number.
+	if other.ClusterID == 0 {
+		other.ClusterID = uint64(v.GetRand())
+	}
+
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyDeleteNodeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DeleteNodeCommand_Command)
+	v := ext.(*internal.DeleteNodeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DeleteNode(v.GetID(), v.GetForce()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetNodeDrainingCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetNodeDrainingCommand_Command)
+	v := ext.(*internal.SetNodeDrainingCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetNodeDraining(v.GetID(), v.GetDraining()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetNodeZoneCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetNodeZoneCommand_Command)
+	v := ext.(*internal.SetNodeZoneCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetNodeZone(v.GetID(), v.GetZone(), v.GetRack()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyCreateAPIKeyCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateAPIKeyCommand_Command)
+	v := ext.(*internal.CreateAPIKeyCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateAPIKey(v.GetName(), v.GetHash(), unmarshalPrivileges(v.GetPrivileges())); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyDropAPIKeyCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropAPIKeyCommand_Command)
+	v := ext.(*internal.DropAPIKeyCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DropAPIKey(v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyCreateRoleCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateRoleCommand_Command)
+	v := ext.(*internal.CreateRoleCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateRole(v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyDropRoleCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropRoleCommand_Command)
+	v := ext.(*internal.DropRoleCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DropRole(v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetRolePrivilegeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetRolePrivilegeCommand_Command)
+	v := ext.(*internal.SetRolePrivilegeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetRolePrivilege(v.GetRole(), v.GetDatabase(), influxql.Privilege(v.GetPrivilege())); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
 
-	err := func() interface{} {
-		switch cmd.GetType() {
-		case internal.Command_CreateNodeCommand:
-			return fsm.applyCreateNodeCommand(&cmd)
-		case internal.Command_DeleteNodeCommand:
-			return fsm.applyDeleteNodeCommand(&cmd)
-		case internal.Command_CreateDatabaseCommand:
-			return fsm.applyCreateDatabaseCommand(&cmd)
-		case internal.Command_DropDatabaseCommand:
-			return fsm.applyDropDatabaseCommand(&cmd)
-		case internal.Command_CreateRetentionPolicyCommand:
-			return fsm.applyCreateRetentionPolicyCommand(&cmd)
-		case internal.Command_DropRetentionPolicyCommand:
-			return fsm.applyDropRetentionPolicyCommand(&cmd)
-		case internal.Command_SetDefaultRetentionPolicyCommand:
-			return fsm.applySetDefaultRetentionPolicyCommand(&cmd)
-		case internal.Command_UpdateRetentionPolicyCommand:
-			return fsm.applyUpdateRetentionPolicyCommand(&cmd)
-		case internal.Command_CreateShardGroupCommand:
-			return fsm.applyCreateShardGroupCommand(&cmd)
-		case internal.Command_DeleteShardGroupCommand:
-			return fsm.applyDeleteShardGroupCommand(&cmd)
-		case internal.Command_CreateContinuousQueryCommand:
-			return fsm.applyCreateContinuousQueryCommand(&cmd)
-		case internal.Command_DropContinuousQueryCommand:
-			return fsm.applyDropContinuousQueryCommand(&cmd)
-		case internal.Command_CreateUserCommand:
-			return fsm.applyCreateUserCommand(&cmd)
-		case internal.Command_DropUserCommand:
-			return fsm.applyDropUserCommand(&cmd)
-		case internal.Command_UpdateUserCommand:
-			return fsm.applyUpdateUserCommand(&cmd)
-		case internal.Command_SetPrivilegeCommand:
-			return fsm.applySetPrivilegeCommand(&cmd)
-		case internal.Command_SetDataCommand:
-			return fsm.applySetDataCommand(&cmd)
-		default:
-			panic(fmt.Errorf("cannot apply command: %x", l.Data))
-		}
-	}()
+func (fsm *storeFSM) applyGrantRoleCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_GrantRoleCommand_Command)
+	v := ext.(*internal.GrantRoleCommand)
 
-	// Copy term and index to new metadata.
-	fsm.data.Term = l.Term
-	fsm.data.Index = l.Index
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.GrantRole(v.GetUsername(), v.GetRole()); err != nil {
+		return err
+	}
+	fsm.data = other
 
-	return err
+	return nil
 }
 
-func (fsm *storeFSM) applyCreateNodeCommand(cmd *internal.Command) interface{} {
-	ext, _ := proto.GetExtension(cmd, internal.E_CreateNodeCommand_Command)
-	v := ext.(*internal.CreateNodeCommand)
+func (fsm *storeFSM) applyRevokeRoleCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_RevokeRoleCommand_Command)
+	v := ext.(*internal.RevokeRoleCommand)
 
 	// Copy data and update.
 	other := fsm.data.Clone()
-	if err := other.CreateNode(v.GetHost()); err != nil {
+	if err := other.RevokeRole(v.GetUsername(), v.GetRole()); err != nil {
 		return err
 	}
+	fsm.data = other
 
-	// If the cluster ID hasn't been set then use the command's random number.
-	if other.ClusterID == 0 {
-		other.ClusterID = uint64(v.GetRand())
-	}
+	return nil
+}
+
+func (fsm *storeFSM) applyReassignShardCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_ReassignShardCommand_Command)
+	v := ext.(*internal.ReassignShardCommand)
 
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.ReassignShard(v.GetShardID(), v.GetOldOwnerID(), v.GetNewOwnerID()); err != nil {
+		return err
+	}
 	fsm.data = other
+
 	return nil
 }
 
-func (fsm *storeFSM) applyDeleteNodeCommand(cmd *internal.Command) interface{} {
-	ext, _ := proto.GetExtension(cmd, internal.E_DeleteNodeCommand_Command)
-	v := ext.(*internal.DeleteNodeCommand)
+func (fsm *storeFSM) applyCreateSnapshotCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateSnapshotCommand_Command)
+	v := ext.(*internal.CreateSnapshotCommand)
+
+	var si SnapshotInfo
+	si.unmarshal(v.GetSnapshot())
 
 	// Copy data and update.
 	other := fsm.data.Clone()
-	if err := other.DeleteNode(v.GetID()); err != nil {
+	if err := other.CreateSnapshot(&si); err != nil {
 		return err
 	}
 	fsm.data = other
@@ -1404,6 +2733,39 @@ func (fsm *storeFSM) applyDropDatabaseCommand(cmd *internal.Command) interface{}
 	return nil
 }
 
+func (fsm *storeFSM) applyRenameDatabaseCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_RenameDatabaseCommand_Command)
+	v := ext.(*internal.RenameDatabaseCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.RenameDatabase(v.GetOldName(), v.GetNewName()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyUpdateSchemaCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_UpdateSchemaCommand_Command)
+	v := ext.(*internal.UpdateSchemaCommand)
+
+	mis := make([]MeasurementInfo, len(v.GetMeasurements()))
+	for i, x := range v.GetMeasurements() {
+		mis[i].unmarshal(x)
+	}
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.UpdateSchema(v.GetDatabase(), mis); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyCreateRetentionPolicyCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_CreateRetentionPolicyCommand_Command)
 	v := ext.(*internal.CreateRetentionPolicyCommand)
@@ -1467,6 +2829,10 @@ func (fsm *storeFSM) applyUpdateRetentionPolicyCommand(cmd *internal.Command) in
 		value := int(v.GetReplicaN())
 		rpu.ReplicaN = &value
 	}
+	if v.ShardGroupDuration != nil {
+		value := time.Duration(v.GetShardGroupDuration())
+		rpu.ShardGroupDuration = &value
+	}
 
 	// Copy data and update.
 	other := fsm.data.Clone()
@@ -1506,6 +2872,32 @@ func (fsm *storeFSM) applyDeleteShardGroupCommand(cmd *internal.Command) interfa
 	return nil
 }
 
+func (fsm *storeFSM) applyPruneShardGroupsCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_PruneShardGroupsCommand_Command)
+	v := ext.(*internal.PruneShardGroupsCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	other.PruneShardGroups(UnmarshalTime(v.GetExpiration()))
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetMeasurementTTLCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetMeasurementTTLCommand_Command)
+	v := ext.(*internal.SetMeasurementTTLCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetMeasurementTTL(v.GetDatabase(), v.GetPolicy(), v.GetMeasurement(), time.Duration(v.GetTTL())); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyCreateContinuousQueryCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_CreateContinuousQueryCommand_Command)
 	v := ext.(*internal.CreateContinuousQueryCommand)
@@ -1591,30 +2983,148 @@ func (fsm *storeFSM) applySetDataCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_SetDataCommand_Command)
 	v := ext.(*internal.SetDataCommand)
 
-	// Overwrite data.
-	fsm.data = &Data{}
-	fsm.data.unmarshal(v.GetData())
+	// Overwrite data, migrating it if it was written by an earlier release.
+	other := &Data{}
+	other.unmarshal(v.GetData())
+	if err := other.migrate(v.GetData().GetVersion()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyReplicateCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_ReplicateCommand_Command)
+	v := ext.(*internal.ReplicateCommand)
+
+	// The primary may run an earlier release so migrate its metadata first.
+	primary := &Data{}
+	primary.unmarshal(v.GetData())
+	if err := primary.migrate(v.GetData().GetVersion()); err != nil {
+		return err
+	}
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	other.Replicate(primary)
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetReadOnlyCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetReadOnlyCommand_Command)
+	v := ext.(*internal.SetReadOnlyCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	other.ReadOnly = v.GetReadOnly()
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyPrecreateShardGroupsCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_PrecreateShardGroupsCommand_Command)
+	v := ext.(*internal.PrecreateShardGroupsCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.PrecreateShardGroups(time.Unix(0, v.GetFrom()), time.Unix(0, v.GetTo())); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetLimitCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetLimitCommand_Command)
+	v := ext.(*internal.SetLimitCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetLimit(v.GetName(), int(v.GetValue())); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyExpirePasswordCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_ExpirePasswordCommand_Command)
+	v := ext.(*internal.ExpirePasswordCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.ExpirePassword(v.GetUsername()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetPasswordHashCostCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetPasswordHashCostCommand_Command)
+	v := ext.(*internal.SetPasswordHashCostCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetPasswordHashCost(int(v.GetCost())); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyRepairCommand(cmd *internal.Command) interface{} {
+	// Copy data and repair.
+	other := fsm.data.Clone()
+	other.Repair()
+	fsm.data = other
 
 	return nil
 }
 
+// Snapshot returns a copy of the current metadata so raft can write it to a
+// snapshot and truncate the log entries it replaces.
 func (fsm *storeFSM) Snapshot() (raft.FSMSnapshot, error) {
 	s := (*Store)(fsm)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return &storeFSMSnapshot{Data: (*Store)(fsm).data}, nil
+	return &storeFSMSnapshot{Data: fsm.data.Clone()}, nil
 }
 
+// Restore replaces the metadata with the contents of a snapshot.
 func (fsm *storeFSM) Restore(r io.ReadCloser) error {
+	defer r.Close()
+
 	// Read all bytes.
-	// b, err := ioutil.ReadAll(r)
-	// if err != nil {
-	// 	return err
-	// }
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
 
-	// TODO: Decode metadata.
-	// TODO: Set metadata on store.
+	// Decode metadata.
+	data := &Data{}
+	if err := data.UnmarshalBinary(b); err != nil {
+		return err
+	}
+
+	// Set metadata on store. The snapshot is restored by raft.NewRaft while
+	// Open holds the lock so only lock when restoring into a running store.
+	s := (*Store)(fsm)
+	if s.raft != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	fsm.data = data
+	s.notifyChanged()
 
 	return nil
 }
@@ -1623,31 +3133,119 @@ type storeFSMSnapshot struct {
 	Data *Data
 }
 
+// Persist writes the snapshot's metadata to the sink.
 func (s *storeFSMSnapshot) Persist(sink raft.SnapshotSink) error {
-	// TODO: Encode data.
-	// TODO: sink.Write(p)
-	// TODO: sink.Close()
-	panic("not implemented yet")
+	err := func() error {
+		// Encode data.
+		p, err := s.Data.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		// Write data to sink.
+		if _, err := sink.Write(p); err != nil {
+			return err
+		}
+
+		// Close the sink.
+		return sink.Close()
+	}()
+
+	if err != nil {
+		sink.Cancel()
+		return err
+	}
+	return nil
 }
 
 // Release is invoked when we are finished with the snapshot
 func (s *storeFSMSnapshot) Release() {}
 
+// listener returns ln, accepting TLS connections if TLS is configured.
+func (s *Store) listener(ln net.Listener) net.Listener {
+	if s.TLSConfig == nil {
+		return ln
+	}
+	return tls.NewListener(ln, s.TLSConfig)
+}
+
+// dial connects to a meta node and writes the mux header byte. The rest of
+// the connection uses TLS if config is set.
+func dial(addr string, header byte, timeout time.Duration, config *tls.Config) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write a marker byte for the mux.
+	if _, err := conn.Write([]byte{header}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if config == nil {
+		return conn, nil
+	}
+
+	// Verify the peer against its host unless a server name is configured.
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		config = clientTLSConfig(config, host)
+	}
+
+	tlsConn := tls.Client(conn, config)
+	tlsConn.SetDeadline(time.Now().Add(timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// clientTLSConfig returns a copy of the client settings in config that
+// verifies the peer against serverName. The fields are copied by hand because
+// tls.Config can't be copied by value and has no Clone method before Go 1.8.
+func clientTLSConfig(config *tls.Config, serverName string) *tls.Config {
+	return &tls.Config{
+		Rand:                   config.Rand,
+		Time:                   config.Time,
+		Certificates:           config.Certificates,
+		NameToCertificate:      config.NameToCertificate,
+		RootCAs:                config.RootCAs,
+		NextProtos:             config.NextProtos,
+		ServerName:             serverName,
+		InsecureSkipVerify:     config.InsecureSkipVerify,
+		CipherSuites:           config.CipherSuites,
+		SessionTicketsDisabled: config.SessionTicketsDisabled,
+		ClientSessionCache:     config.ClientSessionCache,
+		MinVersion:             config.MinVersion,
+		MaxVersion:             config.MaxVersion,
+		CurvePreferences:       config.CurvePreferences,
+	}
+}
+
 // raftLayer wraps the connection so it can be re-used for forwarding.
 type raftLayer struct {
-	ln     net.Listener
-	addr   net.Addr
-	conn   chan net.Conn
-	closed chan struct{}
+	ln        net.Listener
+	addr      net.Addr
+	tlsConfig *tls.Config
+	conn      chan net.Conn
+	closed    chan struct{}
 }
 
 // newRaftLayer returns a new instance of raftLayer.
-func newRaftLayer(ln net.Listener, addr net.Addr) *raftLayer {
+func newRaftLayer(ln net.Listener, addr net.Addr, tlsConfig *tls.Config) *raftLayer {
 	return &raftLayer{
-		ln:     ln,
-		addr:   addr,
-		conn:   make(chan net.Conn),
-		closed: make(chan struct{}),
+		ln:        ln,
+		addr:      addr,
+		tlsConfig: tlsConfig,
+		conn:      make(chan net.Conn),
+		closed:    make(chan struct{}),
 	}
 }
 
@@ -1656,18 +3254,7 @@ func (l *raftLayer) Addr() net.Addr { return l.addr }
 
 // Dial creates a new network connection.
 func (l *raftLayer) Dial(addr string, timeout time.Duration) (net.Conn, error) {
-	conn, err := net.DialTimeout("tcp", addr, timeout)
-	if err != nil {
-		return nil, err
-	}
-
-	// Write a marker byte for raft messages.
-	_, err = conn.Write([]byte{MuxRaftHeader})
-	if err != nil {
-		conn.Close()
-		return nil, err
-	}
-	return conn, err
+	return dial(addr, MuxRaftHeader, timeout, l.tlsConfig)
 }
 
 // Accept waits for the next connection.
@@ -1678,25 +3265,40 @@ func (l *raftLayer) Close() error { return l.ln.Close() }
 
 // RetentionPolicyUpdate represents retention policy fields to be updated.
 type RetentionPolicyUpdate struct {
-	Name     *string
-	Duration *time.Duration
-	ReplicaN *int
+	Name               *string
+	Duration           *time.Duration
+	ReplicaN           *int
+	ShardGroupDuration *time.Duration
 }
 
-func (rpu *RetentionPolicyUpdate) SetName(v string)            { rpu.Name = &v }
-func (rpu *RetentionPolicyUpdate) SetDuration(v time.Duration) { rpu.Duration = &v }
-func (rpu *RetentionPolicyUpdate) SetReplicaN(v int)           { rpu.ReplicaN = &v }
-
-// BcryptCost is the cost associated with generating password with Bcrypt.
-// This setting is lowered during testing to improve test suite performance.
-var BcryptCost = 10
+func (rpu *RetentionPolicyUpdate) SetName(v string)                      { rpu.Name = &v }
+func (rpu *RetentionPolicyUpdate) SetDuration(v time.Duration)           { rpu.Duration = &v }
+func (rpu *RetentionPolicyUpdate) SetReplicaN(v int)                     { rpu.ReplicaN = &v }
+func (rpu *RetentionPolicyUpdate) SetShardGroupDuration(v time.Duration) { rpu.ShardGroupDuration = &v }
 
 // HashPassword generates a cryptographically secure hash for password.
 // Returns an error if the password is invalid or a hash cannot be generated.
-var HashPassword = func(password string) ([]byte, error) {
-	// The second arg is the cost of the hashing, higher is slower but makes
-	// it harder to brute force, since it will be really slow and impractical
-	return bcrypt.GenerateFromPassword([]byte(password), BcryptCost)
+// This is replaced during testing to improve test suite performance.
+var HashPassword = func(password string, cost int) ([]byte, error) {
+	// The cost of the hashing, higher is slower but makes it harder to
+	// brute force, since it will be really slow and impractical
+	return bcrypt.GenerateFromPassword([]byte(password), cost)
+}
+
+// GenerateAPIKey returns a new random API key.
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashAPIKey returns the hash of key that is stored in the meta store.
+// Keys are random so a fast hash is sufficient, unlike passwords.
+func HashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
 }
 
 // assert will panic with a given formatted message if the given condition is false.
@@ -2,9 +2,19 @@ package meta_test
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"math/big"
 	"net"
 	"os"
 	"path/filepath"
@@ -13,14 +23,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/influxdb/influxdb/format"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/meta/internal"
 	"github.com/influxdb/influxdb/tcp"
 	"github.com/influxdb/influxdb/toml"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func init() {
 	// Disable password hashing to speed up testing.
-	meta.HashPassword = func(password string) ([]byte, error) {
+	meta.HashPassword = func(password string, cost int) ([]byte, error) {
 		return []byte(password), nil
 	}
 }
@@ -47,6 +62,90 @@ func TestStore_Open_ErrTooManyPeers(t *testing.T) {
 	}
 }
 
+// Ensure the store stamps a new directory with the current format version.
+func TestStore_Open_Format(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	var hdr format.Header
+	if b, err := ioutil.ReadFile(s.FormatPath()); err != nil {
+		t.Fatal(err)
+	} else if err := hdr.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(hdr, meta.StoreFormat.Header()) {
+		t.Fatalf("unexpected header: %#v", hdr)
+	}
+}
+
+// Ensure that opening a store written by a newer release returns an error.
+func TestStore_Open_NewerFormat(t *testing.T) {
+	t.Parallel()
+	path := MustTempFile()
+	defer os.RemoveAll(path)
+	if err := os.MkdirAll(path, 0777); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(filepath.Join(path, "format"), []byte(`{"version":100}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStore(NewConfig(path))
+	err := s.Open()
+	s.Listener.Close()
+	if err == nil || err.Error() != "format: meta format version 100 is newer than supported version 1: upgrade influxdb to open this data" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the store snapshots its log and restores the snapshot on reopen.
+func TestStore_Snapshot_Reopen(t *testing.T) {
+	t.Parallel()
+	c := NewConfig(MustTempFile())
+	c.SnapshotThreshold = 4
+	c.TrailingLogs = 1
+	defer os.RemoveAll(c.Dir)
+
+	s := NewStore(c)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	<-s.Ready()
+
+	// Create enough databases to trigger a snapshot.
+	for i := 0; i < 10; i++ {
+		if _, err := s.CreateDatabase(fmt.Sprintf("db%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Listener.Close()
+	if err := s.Store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure a snapshot was written.
+	if fis, err := ioutil.ReadDir(filepath.Join(c.Dir, "snapshots")); err != nil {
+		t.Fatal(err)
+	} else if len(fis) == 0 {
+		t.Fatal("expected snapshot")
+	}
+
+	// Reopen the store and ensure the databases were restored.
+	s = NewStore(c)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	<-s.Ready()
+
+	for i := 0; i < 10; i++ {
+		if di, err := s.Database(fmt.Sprintf("db%d", i)); err != nil {
+			t.Fatal(err)
+		} else if di == nil {
+			t.Fatalf("expected database: db%d", i)
+		}
+	}
+}
+
 // Ensure the store can create a new node.
 func TestStore_CreateNode(t *testing.T) {
 	t.Parallel()
@@ -156,7 +255,7 @@ func TestStore_DeleteNode(t *testing.T) {
 	}
 
 	// Remove second node.
-	if err := s.DeleteNode(3); err != nil {
+	if err := s.DeleteNode(3, false); err != nil {
 		t.Fatal(err)
 	}
 
@@ -178,11 +277,37 @@ func TestStore_DeleteNode_ErrNodeNotFound(t *testing.T) {
 	s := MustOpenStore()
 	defer s.Close()
 
-	if err := s.DeleteNode(2); err != meta.ErrNodeNotFound {
+	if err := s.DeleteNode(2, false); err != meta.ErrNodeNotFound {
 		t.Fatalf("unexpected error: %s", err)
 	}
 }
 
+// Ensure the store can forcibly remove a node that still owns shards.
+func TestStore_DeleteNode_Force(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	// Create node, database, policy, & group.
+	if _, err := s.CreateNode("host0"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if _, err = s.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, Duration: 1 * time.Hour}); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateShardGroup("db0", "rp0", time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteNode(2, false); err != meta.ErrNodeOwnsShards {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.DeleteNode(2, true); err != nil {
+		t.Fatal(err)
+	} else if _, _, sgi := s.ShardOwner(1); !reflect.DeepEqual(sgi.Shards[0].OwnerIDs, []uint64{1}) {
+		t.Fatalf("unexpected owners: %v", sgi.Shards[0].OwnerIDs)
+	}
+}
+
 // Ensure the store can create a new database.
 func TestStore_CreateDatabase(t *testing.T) {
 	t.Parallel()
@@ -204,6 +329,198 @@ func TestStore_CreateDatabase(t *testing.T) {
 	}
 }
 
+// Ensure limits can be set and are enforced when creating databases.
+func TestStore_SetLimit(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	if err := s.SetLimit("max_databases", 1); err != nil {
+		t.Fatal(err)
+	} else if l, err := s.Limits(); err != nil {
+		t.Fatal(err)
+	} else if l != (meta.Limits{MaxDatabases: 1}) {
+		t.Fatalf("unexpected limits: %#v", l)
+	}
+
+	if _, err := s.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateDatabase("db1"); err != meta.ErrMaxDatabasesExceeded {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Unknown limits and negative values are rejected.
+	if err := s.SetLimit("no_such_limit", 1); err != meta.ErrLimitNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := s.SetLimit("max_databases", -1); err != meta.ErrLimitInvalid {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure metadata changes are rejected while the store is read-only.
+func TestStore_SetReadOnly(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	if _, err := s.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 1 * time.Hour}); err != nil {
+		t.Fatal(err)
+	} else if err := s.SetReadOnly(true); err != nil {
+		t.Fatal(err)
+	} else if readOnly, err := s.ReadOnly(); err != nil {
+		t.Fatal(err)
+	} else if !readOnly {
+		t.Fatal("expected read-only")
+	}
+
+	// Metadata changes and node joins are rejected.
+	if _, err := s.CreateDatabase("db1"); err != meta.ErrReadOnly {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.DropDatabase("db0"); err != meta.ErrReadOnly {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := s.CreateNode("host1:8088"); err != meta.ErrReadOnly {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Shard groups can still be created for writes.
+	if _, err := s.CreateShardGroupIfNotExists("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes are accepted once maintenance is over.
+	if err := s.SetReadOnly(false); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure the store can rename a database.
+func TestStore_RenameDatabase(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	if _, err := s.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := s.RenameDatabase("db0", "db1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if di, err := s.Database("db0"); err != nil {
+		t.Fatal(err)
+	} else if di != nil {
+		t.Fatalf("unexpected database: %#v", di)
+	}
+	if di, err := s.Database("db1"); err != nil {
+		t.Fatal(err)
+	} else if di == nil {
+		t.Fatal("renamed database not found")
+	}
+}
+
+// Ensure the store can replicate the metadata of another cluster.
+func TestStore_Replicate(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	if _, err := s.CreateDatabase("local"); err != nil {
+		t.Fatal(err)
+	} else if err := s.Replicate(&meta.Data{
+		Databases: []meta.DatabaseInfo{{Name: "db0"}},
+		Users:     []meta.UserInfo{{Name: "susy", Hash: "ABC123", Admin: true}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if dis, err := s.Databases(); err != nil {
+		t.Fatal(err)
+	} else if len(dis) != 1 || dis[0].Name != "db0" {
+		t.Fatalf("unexpected databases: %#v", dis)
+	} else if ui, err := s.User("susy"); err != nil {
+		t.Fatal(err)
+	} else if ui == nil || !ui.Admin {
+		t.Fatalf("unexpected user: %#v", ui)
+	}
+
+	// Verify the local node is kept.
+	if ni, err := s.Node(1); err != nil {
+		t.Fatal(err)
+	} else if ni == nil {
+		t.Fatal("local node not found")
+	}
+}
+
+// Ensure the store can record measurement schemas.
+func TestStore_UpdateSchema(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	if _, err := s.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := s.UpdateSchema("db0", []meta.MeasurementInfo{
+		{Name: "cpu", Fields: []meta.FieldInfo{{Name: "value", Type: influxql.Float}}, TagKeys: []string{"host"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Registered schemas are a no-op and conflicting types are rejected.
+	if err := s.UpdateSchema("db0", []meta.MeasurementInfo{
+		{Name: "cpu", Fields: []meta.FieldInfo{{Name: "value", Type: influxql.Float}}},
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := s.UpdateSchema("db0", []meta.MeasurementInfo{
+		{Name: "cpu", Fields: []meta.FieldInfo{{Name: "value", Type: influxql.Integer}}},
+	}); err == nil {
+		t.Fatal("expected field type conflict")
+	}
+
+	if di, err := s.Database("db0"); err != nil {
+		t.Fatal(err)
+	} else if mi := di.Measurement("cpu"); mi == nil {
+		t.Fatal("measurement not found")
+	} else if !reflect.DeepEqual(mi.Fields, []meta.FieldInfo{{Name: "value", Type: influxql.Float}}) {
+		t.Fatalf("unexpected fields: %#v", mi.Fields)
+	} else if !reflect.DeepEqual(mi.TagKeys, []string{"host"}) {
+		t.Fatalf("unexpected tag keys: %#v", mi.TagKeys)
+	}
+}
+
+// Ensure watchers are notified when the metadata changes.
+func TestStore_Watch(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	// Watch for the next change.
+	ch := s.Watch()
+	select {
+	case <-ch:
+		t.Fatal("unexpected notification")
+	default:
+	}
+
+	if _, err := s.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The channel should be closed and replaced by a new one.
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for notification")
+	}
+	select {
+	case <-s.Watch():
+		t.Fatal("unexpected notification")
+	default:
+	}
+}
+
 // Ensure the store can delete an existing database.
 func TestStore_DropDatabase(t *testing.T) {
 	t.Parallel()
@@ -245,6 +562,22 @@ func TestStore_DropDatabase_ErrDatabaseNotFound(t *testing.T) {
 	}
 }
 
+// Ensure the leader answers a lookup for a missing database from its local copy.
+func TestStore_Database_NotFound(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	now := time.Now()
+	if di, err := s.Database("no_such_database"); err != nil {
+		t.Fatal(err)
+	} else if di != nil {
+		t.Fatalf("unexpected database: %#v", di)
+	} else if d := time.Since(now); d > 500*time.Millisecond {
+		t.Fatalf("lookup too slow: %s", d)
+	}
+}
+
 // Ensure the store can create a retention policy on a database.
 func TestStore_CreateRetentionPolicy(t *testing.T) {
 	t.Parallel()
@@ -368,6 +701,35 @@ func TestStore_UpdateRetentionPolicy(t *testing.T) {
 	}
 }
 
+// Ensure the store can change the shard group duration of a retention policy
+// and that new shard groups use it.
+func TestStore_UpdateRetentionPolicy_ShardGroupDuration(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	// Create database and policy.
+	if _, err := s.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Update the shard group duration.
+	var rpu meta.RetentionPolicyUpdate
+	rpu.SetShardGroupDuration(30 * 24 * time.Hour)
+	if err := s.UpdateRetentionPolicy("db0", "rp0", &rpu); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure a new shard group spans the new duration.
+	if sgi, err := s.CreateShardGroup("db0", "rp0", time.Date(2000, time.January, 10, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	} else if d := sgi.EndTime.Sub(sgi.StartTime); d != 30*24*time.Hour {
+		t.Fatalf("unexpected shard group duration: %s", d)
+	}
+}
+
 // Ensure the store can create a shard group on a retention policy.
 func TestStore_CreateShardGroup(t *testing.T) {
 	t.Parallel()
@@ -391,6 +753,69 @@ func TestStore_CreateShardGroup(t *testing.T) {
 	}
 }
 
+// Ensure the store can move the shards of a draining node so it can be removed.
+func TestStore_ReassignShard(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	// Create node, database, policy, & group.
+	if _, err := s.CreateNode("host0"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if _, err = s.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, Duration: 1 * time.Hour}); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateShardGroup("db0", "rp0", time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateNode("host1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mark the node as draining.
+	if err := s.SetNodeDraining(2, true); err != nil {
+		t.Fatal(err)
+	} else if ni, _ := s.Node(2); !ni.Draining {
+		t.Fatal("expected node to be draining")
+	}
+
+	// The node can only be removed once its shard has moved.
+	if err := s.DeleteNode(2, false); err != meta.ErrNodeOwnsShards {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.ReassignShard(1, 2, 3); err != nil {
+		t.Fatal(err)
+	} else if _, _, sgi := s.ShardOwner(1); !sgi.Shards[0].OwnedBy(3) || sgi.Shards[0].OwnedBy(2) {
+		t.Fatalf("unexpected owners: %v", sgi.Shards[0].OwnerIDs)
+	} else if err := s.DeleteNode(2, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure the store can record a snapshot manifest.
+func TestStore_CreateSnapshot(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	si := &meta.SnapshotInfo{
+		Time:      time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt: time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC),
+		Path:      "/backups/0",
+		Shards:    []meta.SnapshotShardInfo{{ID: 1, Database: "db0", RetentionPolicy: "rp0", NodeID: 1}},
+	}
+	if other, err := s.CreateSnapshot(si); err != nil {
+		t.Fatal(err)
+	} else if other.ID != 1 || other.Path != "/backups/0" || len(other.Shards) != 1 {
+		t.Fatalf("unexpected snapshot: %#v", other)
+	}
+
+	if a, err := s.Snapshots(); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || a[0].ID != 1 {
+		t.Fatalf("unexpected snapshots: %#v", a)
+	}
+}
+
 // Ensure the store can delete an existing shard group.
 func TestStore_DeleteShardGroup(t *testing.T) {
 	t.Parallel()
@@ -429,7 +854,19 @@ func TestStore_PrecreateShardGroup(t *testing.T) {
 		t.Fatal(err)
 	} else if _, err := s.CreateShardGroup("db0", "rp0", time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)); err != nil {
 		t.Fatal(err)
-	} else if err := s.PrecreateShardGroups(time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+	}
+
+	// Every group starting before the horizon is created.
+	from := time.Date(2000, time.January, 1, 0, 30, 0, 0, time.UTC)
+	if err := s.PrecreateShardGroups(from, from.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if err := s.PrecreateShardGroups(from, from.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Groups that would have ended in the past are not created.
+	from = time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC)
+	if err := s.PrecreateShardGroups(from, from.Add(30*time.Minute)); err != nil {
 		t.Fatal(err)
 	}
 
@@ -437,12 +874,17 @@ func TestStore_PrecreateShardGroup(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(groups) != 2 {
-		t.Fatalf("shard group precreation failed to create new shard group")
+	var a []time.Time
+	for _, g := range groups {
+		a = append(a, g.StartTime)
 	}
-	if groups[1].StartTime != time.Date(2000, time.January, 1, 1, 0, 0, 0, time.UTC) {
-		t.Fatalf("precreated shard group has wrong start time, exp %s, got %s",
-			time.Date(2000, time.January, 1, 1, 0, 0, 0, time.UTC), groups[1].StartTime)
+	if !reflect.DeepEqual(a, []time.Time{
+		time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2000, time.January, 1, 1, 0, 0, 0, time.UTC),
+		time.Date(2000, time.January, 1, 2, 0, 0, 0, time.UTC),
+		time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC),
+	}) {
+		t.Fatalf("unexpected shard group start times: %v", a)
 	}
 }
 
@@ -586,25 +1028,265 @@ func TestStore_UpdateUser(t *testing.T) {
 	}
 }
 
-// Ensure the store can return the count of users in it.
-func TestStore_UserCount(t *testing.T) {
+// Ensure passwords hashed with a different cost than the cluster's are
+// rehashed after login.
+func TestStore_Authenticate_Rehash(t *testing.T) {
 	t.Parallel()
 	s := MustOpenStore()
 	defer s.Close()
 
-	if count, err := s.UserCount(); count != 0 && err != nil {
-		t.Fatalf("expected user count to be 0 but was %d", count)
+	// The cluster's cost takes precedence over the configured one.
+	s.PasswordHashCost = bcrypt.MinCost
+	if err := s.SetPasswordHashCost(bcrypt.MinCost + 1); err != nil {
+		t.Fatal(err)
 	}
 
-	// Create users.
-	if _, err := s.CreateUser("susy", "pass", true); err != nil {
-		t.Fatal(err)
-	} else if _, err := s.CreateUser("bob", "pass", true); err != nil {
+	// Store a user whose password was hashed with the minimum cost.
+	hash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	if err != nil {
 		t.Fatal(err)
 	}
+	MustSetUsers(s, meta.UserInfo{Name: "susy", Hash: string(hash)})
 
-	if count, err := s.UserCount(); count != 2 && err != nil {
-		t.Fatalf("expected user count to be 2 but was %d", count)
+	// Authenticating queues the hash to be replaced in the background.
+	// Test hashes aren't hashed at all.
+	ch := s.Watch()
+	if _, err := s.Authenticate("susy", "pass"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for rehash")
+	}
+	if ui, _ := s.User("susy"); ui.Hash != "pass" {
+		t.Fatalf("unexpected hash: %s", ui.Hash)
+	}
+}
+
+// Ensure a user can't authenticate once their password expires.
+func TestStore_ExpirePassword(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+	s.PasswordHashCost = bcrypt.MinCost
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	MustSetUsers(s, meta.UserInfo{Name: "susy", Hash: string(hash)})
+
+	if err := s.ExpirePassword("susy"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Authenticate("susy", "pass"); err != meta.ErrPasswordExpired {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.ExpirePassword("bob"); err != meta.ErrUserNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Setting a new password clears the expiration.
+	if err := s.UpdateUser("susy", "pass2"); err != nil {
+		t.Fatal(err)
+	} else if ui, _ := s.User("susy"); ui.PasswordExpired {
+		t.Fatal("expected password to not be expired")
+	}
+}
+
+// Ensure the store can return the count of users in it.
+func TestStore_UserCount(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	if count, err := s.UserCount(); count != 0 && err != nil {
+		t.Fatalf("expected user count to be 0 but was %d", count)
+	}
+
+	// Create users.
+	if _, err := s.CreateUser("susy", "pass", true); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.CreateUser("bob", "pass", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := s.UserCount(); count != 2 && err != nil {
+		t.Fatalf("expected user count to be 2 but was %d", count)
+	}
+}
+
+// Ensure the store can create an API key and authenticate with it.
+func TestStore_AuthenticateAPIKey(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	key, err := s.CreateAPIKey("telegraf", map[string]influxql.Privilege{
+		"db0": influxql.WritePrivilege,
+		"db1": influxql.ReadPrivilege,
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if key == "" {
+		t.Fatal("expected key")
+	}
+
+	// Only the hash of the key is stored.
+	if a, err := s.APIKeys(); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || a[0].Hash != meta.HashAPIKey(key) {
+		t.Fatalf("unexpected api keys: %#v", a)
+	}
+
+	// Authenticate with the key and verify its privileges.
+	ui, err := s.AuthenticateAPIKey(key)
+	if err != nil {
+		t.Fatal(err)
+	} else if ui.Admin {
+		t.Fatal("expected non-admin user")
+	} else if !ui.Authorize(influxql.WritePrivilege, "db0") {
+		t.Fatal("expected write on db0")
+	} else if ui.Authorize(influxql.ReadPrivilege, "db0") {
+		t.Fatal("unexpected read on db0")
+	} else if !ui.Authorize(influxql.ReadPrivilege, "db1") {
+		t.Fatal("expected read on db1")
+	} else if ui.Authorize(influxql.WritePrivilege, "db1") {
+		t.Fatal("unexpected write on db1")
+	}
+
+	// Drop the key and verify it no longer authenticates.
+	if err := s.DropAPIKey("telegraf"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.AuthenticateAPIKey(key); err != meta.ErrAPIKeyInvalid {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure users are authorized by the privileges of their roles.
+func TestStore_GrantRole(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	if _, err := s.CreateUser("susy", "pass", false); err != nil {
+		t.Fatal(err)
+	} else if err := s.SetPrivilege("susy", "db0", influxql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	} else if err := s.CreateRole("writers"); err != nil {
+		t.Fatal(err)
+	} else if err := s.SetRolePrivilege("writers", "db0", influxql.WritePrivilege); err != nil {
+		t.Fatal(err)
+	} else if err := s.SetRolePrivilege("writers", "db1", influxql.WritePrivilege); err != nil {
+		t.Fatal(err)
+	} else if err := s.GrantRole("susy", "writers"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The role adds to the user's own privileges.
+	ui, err := s.User("susy")
+	if err != nil {
+		t.Fatal(err)
+	} else if !ui.Authorize(influxql.WritePrivilege, "db0") {
+		t.Fatal("expected write on db0")
+	} else if !ui.Authorize(influxql.WritePrivilege, "db1") {
+		t.Fatal("expected write on db1")
+	} else if ui.Authorize(influxql.ReadPrivilege, "db2") {
+		t.Fatal("unexpected read on db2")
+	}
+
+	// Role privileges aren't reported as the user's own grants.
+	if p, err := s.UserPrivileges("susy"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(p, map[string]influxql.Privilege{"db0": influxql.ReadPrivilege}) {
+		t.Fatalf("unexpected privileges: %#v", p)
+	}
+
+	// Revoking the role's privilege or the role takes effect on lookup.
+	if err := s.SetRolePrivilege("writers", "db1", influxql.NoPrivileges); err != nil {
+		t.Fatal(err)
+	} else if ui, err := s.User("susy"); err != nil {
+		t.Fatal(err)
+	} else if ui.Authorize(influxql.WritePrivilege, "db1") {
+		t.Fatal("unexpected write on db1")
+	} else if !ui.Authorize(influxql.WritePrivilege, "db0") {
+		t.Fatal("expected write on db0")
+	}
+
+	if err := s.RevokeRole("susy", "writers"); err != nil {
+		t.Fatal(err)
+	} else if ui, err := s.User("susy"); err != nil {
+		t.Fatal(err)
+	} else if ui.Authorize(influxql.WritePrivilege, "db0") {
+		t.Fatal("unexpected write on db0")
+	} else if !ui.Authorize(influxql.ReadPrivilege, "db0") {
+		t.Fatal("expected read on db0")
+	}
+
+	if a, err := s.Roles(); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || a[0].Name != "writers" {
+		t.Fatalf("unexpected roles: %#v", a)
+	} else if err := s.DropRole("writers"); err != nil {
+		t.Fatal(err)
+	} else if a, err := s.Roles(); err != nil || len(a) != 0 {
+		t.Fatalf("unexpected roles: %#v (%v)", a, err)
+	}
+}
+
+// Ensure the store can find and repair inconsistencies in the metadata.
+func TestStore_Repair(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	// Replace the metadata with a database whose shard is owned by a node
+	// that doesn't exist.
+	ni, err := s.Node(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetData(&meta.Data{
+		Nodes: []meta.NodeInfo{*ni},
+		Databases: []meta.DatabaseInfo{
+			{
+				Name:                   "db0",
+				DefaultRetentionPolicy: "rp1",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{
+						Name: "rp0",
+						ShardGroups: []meta.ShardGroupInfo{
+							{ID: 1, Shards: []meta.ShardInfo{{ID: 1, OwnerIDs: []uint64{2}}}},
+							{ID: 2},
+						},
+					},
+				},
+			},
+		},
+		MaxNodeID:       1,
+		MaxShardGroupID: 2,
+		MaxShardID:      1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if a, err := s.Check(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(a, []string{
+		"database db0: default retention policy rp1 not found",
+		"shard 1: owner node 2 not found",
+		"database db0, retention policy rp0: shard group 2 has no shards",
+	}) {
+		t.Fatalf("unexpected problems: %#v", a)
+	}
+
+	if a, err := s.Repair(); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 0 {
+		t.Fatalf("unexpected problems: %#v", a)
+	} else if _, _, sgi := s.ShardOwner(1); !reflect.DeepEqual(sgi.Shards[0].OwnerIDs, []uint64{1}) {
+		t.Fatalf("unexpected owners: %v", sgi.Shards[0].OwnerIDs)
+	} else if a, err := s.ShardGroups("db0", "rp0"); err != nil || len(a) != 1 {
+		t.Fatalf("unexpected shard groups: %#v (%v)", a, err)
 	}
 }
 
@@ -639,6 +1321,272 @@ func TestCluster_Open(t *testing.T) {
 	}
 }
 
+// Ensure followers see a database created on the leader with and without a read lease.
+func TestCluster_ReadLease(t *testing.T) {
+	c := MustOpenCluster(3)
+	defer c.Close()
+
+	leader := c.Leader()
+	if leader == nil {
+		t.Fatal("no leader found")
+	}
+
+	for i, lease := range []time.Duration{0, 500 * time.Millisecond} {
+		name := fmt.Sprintf("db%d", i)
+		if _, err := leader.CreateDatabase(name); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, s := range c.Stores {
+			s.ReadLeaseTimeout = lease
+			if di, err := s.Database(name); err != nil {
+				t.Fatalf("lease=%s: %s", lease, err)
+			} else if di == nil {
+				t.Fatalf("lease=%s: expected database", lease)
+			}
+		}
+	}
+}
+
+// Ensure an observer replicates the metadata and forwards commands to the leader.
+func TestStore_Observer(t *testing.T) {
+	t.Parallel()
+	leader := MustOpenStore()
+	defer leader.Close()
+
+	config := NewConfig(MustTempFile())
+	config.Peers = []string{leader.Addr.String()}
+	config.Observer = true
+	config.ObserverSyncInterval = toml.Duration(10 * time.Millisecond)
+	o := NewStore(config)
+	if err := o.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	select {
+	case err := <-o.Err():
+		t.Fatal(err)
+	case <-o.Ready():
+	}
+
+	// The observer knows the leader but never becomes it.
+	if o.IsLeader() {
+		t.Fatal("observer is leader")
+	} else if o.Leader() != leader.Addr.String() {
+		t.Fatalf("unexpected leader: %s", o.Leader())
+	}
+
+	// Changes on the leader are replicated to the observer.
+	watch := o.Watch()
+	if _, err := leader.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-watch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for replication")
+	}
+	if di, err := o.Database("db0"); err != nil {
+		t.Fatal(err)
+	} else if di == nil {
+		t.Fatal("expected database")
+	}
+
+	// Commands on the observer are applied by the leader.
+	if _, err := o.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	}
+	if di, err := o.Database("db1"); err != nil {
+		t.Fatal(err)
+	} else if di == nil {
+		t.Fatal("expected database on observer")
+	}
+	if di, err := leader.Database("db1"); err != nil {
+		t.Fatal(err)
+	} else if di == nil {
+		t.Fatal("expected database on leader")
+	}
+
+	// The observer's metadata has no hashes so users and API keys are
+	// authenticated by a peer.
+	hash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	MustSetUsers(leader, meta.UserInfo{Name: "susy", Hash: string(hash)})
+	key, err := leader.CreateAPIKey("key0", map[string]influxql.Privilege{"db0": influxql.ReadPrivilege})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ui, err := o.User("susy"); err != nil {
+		t.Fatal(err)
+	} else if ui.Hash != "" {
+		t.Fatalf("unexpected password hash on observer: %s", ui.Hash)
+	}
+	if ui, err := o.Authenticate("susy", "pass"); err != nil {
+		t.Fatal(err)
+	} else if ui.Name != "susy" {
+		t.Fatalf("unexpected user: %s", ui.Name)
+	} else if _, err := o.Authenticate("susy", "wrong"); err == nil {
+		t.Fatal("expected error for wrong password")
+	}
+	if ui, err := o.AuthenticateAPIKey(key); err != nil {
+		t.Fatal(err)
+	} else if ui.Name != "key0" || !ui.Authorize(influxql.ReadPrivilege, "db0") {
+		t.Fatalf("unexpected user: %#v", ui)
+	} else if _, err := o.AuthenticateAPIKey("invalid"); err != meta.ErrAPIKeyInvalid {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure exec connections to a store use TLS when configured.
+func TestStore_Open_TLS(t *testing.T) {
+	path := MustTempFile()
+	config := MustWriteTLSFiles(path + "-tls")
+	defer os.RemoveAll(path + "-tls")
+	tlsConfig, err := config.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStore(NewConfig(path))
+	s.TLSConfig = tlsConfig
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	select {
+	case err := <-s.Err():
+		t.Fatal(err)
+	case <-s.Ready():
+	}
+
+	// Peers presenting a trusted certificate can connect.
+	clientConfig := &tls.Config{
+		Certificates: tlsConfig.Certificates,
+		RootCAs:      tlsConfig.RootCAs,
+		ServerName:   "127.0.0.1",
+	}
+	tlsConn := tls.Client(MustDialHeader(s.Addr.String(), meta.MuxExecHeader), clientConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		t.Fatalf("handshake: %s", err)
+	}
+	tlsConn.Close()
+
+	// Unencrypted exec messages are rejected.
+	conn := MustDialHeader(s.Addr.String(), meta.MuxExecHeader)
+	defer conn.Close()
+	if _, err := conn.Write([]byte(meta.ExecMagic)); err != nil {
+		t.Fatal(err)
+	} else if err := binary.Write(conn, binary.BigEndian, uint64(0)); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expected unencrypted connection to be rejected")
+	} else if e, ok := err.(net.Error); ok && e.Timeout() {
+		t.Fatal("unencrypted connection not closed")
+	}
+}
+
+// Ensure nodes can only register with the join token.
+func TestStore_JoinToken(t *testing.T) {
+	t.Parallel()
+	config := NewConfig(MustTempFile())
+	config.JoinToken = "secret"
+	s := NewStore(config)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	select {
+	case err := <-s.Err():
+		t.Fatal(err)
+	case <-s.Ready():
+	}
+
+	// Nodes without the token are rejected.
+	for _, token := range []string{"", "wrong"} {
+		if resp := MustExecCreateNode(s.Addr.String(), "host1:8088", token); resp.GetOK() {
+			t.Fatalf("token=%q: expected node to be rejected", token)
+		} else if resp.GetError() != meta.ErrJoinTokenInvalid.Error() {
+			t.Fatalf("token=%q: unexpected error: %s", token, resp.GetError())
+		}
+	}
+	if ni, err := s.NodeByHost("host1:8088"); err != nil {
+		t.Fatal(err)
+	} else if ni != nil {
+		t.Fatalf("unexpected node: %#v", ni)
+	}
+
+	// Nodes with the token can register.
+	if resp := MustExecCreateNode(s.Addr.String(), "host1:8088", "secret"); !resp.GetOK() {
+		t.Fatalf("unexpected error: %s", resp.GetError())
+	} else if ni, err := s.NodeByHost("host1:8088"); err != nil {
+		t.Fatal(err)
+	} else if ni == nil {
+		t.Fatal("expected node")
+	}
+}
+
+// Ensure exec connections without the join token can't change or read the
+// metadata.
+func TestStore_JoinToken_ExecConn(t *testing.T) {
+	t.Parallel()
+	config := NewConfig(MustTempFile())
+	config.JoinToken = "secret"
+	s := NewStore(config)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	select {
+	case err := <-s.Err():
+		t.Fatal(err)
+	case <-s.Ready():
+	}
+
+	other := &meta.Data{}
+	if err := other.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	var pb internal.Data
+	if b, err := other.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	} else if err := proto.Unmarshal(b, &pb); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		// Commands other than node registration are rejected.
+		if resp := MustExecCommand(s.Addr.String(), token, internal.Command_SetDataCommand, internal.E_SetDataCommand_Command,
+			&internal.SetDataCommand{Data: &pb}); resp.GetOK() {
+			t.Fatalf("token=%q: expected command to be rejected", token)
+		} else if resp.GetError() != meta.ErrJoinTokenInvalid.Error() {
+			t.Fatalf("token=%q: unexpected error: %s", token, resp.GetError())
+		}
+
+		// The metadata can't be fetched.
+		if resp := MustFetchData(s.Addr.String(), token); resp.GetOK() {
+			t.Fatalf("token=%q: expected fetch to be rejected", token)
+		} else if resp.Data != nil {
+			t.Fatalf("token=%q: unexpected data", token)
+		}
+	}
+	if di, err := s.Database("db0"); err != nil {
+		t.Fatal(err)
+	} else if di != nil {
+		t.Fatalf("unexpected database: %#v", di)
+	}
+
+	// Peers with the token can fetch the metadata.
+	if resp := MustFetchData(s.Addr.String(), "secret"); !resp.GetOK() {
+		t.Fatalf("unexpected error: %s", resp.GetError())
+	} else if resp.Data == nil {
+		t.Fatal("expected data")
+	}
+}
+
 // Store is a test wrapper for meta.Store.
 type Store struct {
 	*meta.Store
@@ -672,6 +1620,22 @@ func MustOpenStore() *Store {
 	return s
 }
 
+// MustSetUsers replaces the users in the store's metadata.
+func MustSetUsers(s *Store, users ...meta.UserInfo) {
+	buf, err := s.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	var data meta.Data
+	if err := data.UnmarshalBinary(buf); err != nil {
+		panic(err)
+	}
+	data.Users = users
+	if err := s.SetData(&data); err != nil {
+		panic(err)
+	}
+}
+
 // Open opens the store on a random TCP port.
 func (s *Store) Open() error {
 	// Open a TCP port.
@@ -807,6 +1771,168 @@ func (c *Cluster) Leader() *Store {
 	return nil
 }
 
+// MustWriteTLSFiles writes a CA and a certificate for 127.0.0.1 signed by it
+// to dir and returns a config that uses them. Panic on error.
+func MustWriteTLSFiles(dir string) meta.Config {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		panic(err)
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "influxdb-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		panic(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		panic(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}, ca, &key.PublicKey, caKey)
+	if err != nil {
+		panic(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+
+	c := meta.Config{
+		TLSEnabled:       true,
+		TLSCertificate:   filepath.Join(dir, "cert.pem"),
+		TLSPrivateKey:    filepath.Join(dir, "key.pem"),
+		TLSCACertificate: filepath.Join(dir, "ca.pem"),
+	}
+	MustWritePEM(c.TLSCACertificate, "CERTIFICATE", caDER)
+	MustWritePEM(c.TLSCertificate, "CERTIFICATE", der)
+	MustWritePEM(c.TLSPrivateKey, "EC PRIVATE KEY", keyDER)
+	return c
+}
+
+// MustDialHeader connects to addr and writes a mux header byte. Panic on error.
+func MustDialHeader(addr string, header byte) net.Conn {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		panic(err)
+	} else if _, err := conn.Write([]byte{header}); err != nil {
+		panic(err)
+	}
+	return conn
+}
+
+// MustExecCreateNode sends a command registering host to the exec listener
+// at addr and returns the response. Panic on error.
+func MustExecCreateNode(addr, host, token string) *internal.Response {
+	return MustExecCommand(addr, token, internal.Command_CreateNodeCommand, internal.E_CreateNodeCommand_Command,
+		&internal.CreateNodeCommand{
+			Host:      proto.String(host),
+			Rand:      proto.Uint64(1),
+			JoinToken: proto.String(token),
+		},
+	)
+}
+
+// MustExecCommand sends a command to the exec listener at addr on a
+// connection authenticated with token and returns the response.
+// Panic on error.
+func MustExecCommand(addr, token string, typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) *internal.Response {
+	cmd := &internal.Command{Type: &typ}
+	if err := proto.SetExtension(cmd, desc, value); err != nil {
+		panic(err)
+	}
+	b, err := proto.Marshal(cmd)
+	if err != nil {
+		panic(err)
+	}
+
+	conn := MustDialHeader(addr, meta.MuxExecHeader)
+	defer conn.Close()
+	if _, err := conn.Write([]byte(meta.ExecMagic)); err != nil {
+		panic(err)
+	}
+	MustWriteJoinToken(conn, token)
+	if err := binary.Write(conn, binary.BigEndian, uint64(len(b))); err != nil {
+		panic(err)
+	} else if _, err := conn.Write(b); err != nil {
+		panic(err)
+	}
+	return MustReadResponse(conn)
+}
+
+// MustFetchData requests the metadata from the exec listener at addr on a
+// connection authenticated with token and returns the response.
+// Panic on error.
+func MustFetchData(addr, token string) *internal.Response {
+	conn := MustDialHeader(addr, meta.MuxExecHeader)
+	defer conn.Close()
+	if _, err := conn.Write([]byte(meta.FetchDataMagic)); err != nil {
+		panic(err)
+	}
+	MustWriteJoinToken(conn, token)
+	if err := binary.Write(conn, binary.BigEndian, uint64(0)); err != nil {
+		panic(err)
+	}
+	return MustReadResponse(conn)
+}
+
+// MustWriteJoinToken writes the join token frame to an exec connection.
+// Panic on error.
+func MustWriteJoinToken(conn net.Conn, token string) {
+	if err := binary.Write(conn, binary.BigEndian, uint64(len(token))); err != nil {
+		panic(err)
+	} else if _, err := conn.Write([]byte(token)); err != nil {
+		panic(err)
+	}
+}
+
+// MustReadResponse reads a response from an exec connection. Panic on error.
+func MustReadResponse(conn net.Conn) *internal.Response {
+	var sz uint64
+	if err := binary.Read(conn, binary.BigEndian, &sz); err != nil {
+		panic(err)
+	}
+	buf := make([]byte, sz)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		panic(err)
+	}
+	var resp internal.Response
+	if err := proto.Unmarshal(buf, &resp); err != nil {
+		panic(err)
+	}
+	return &resp
+}
+
+// MustWritePEM writes a PEM block to path. Panic on error.
+func MustWritePEM(path, typ string, b []byte) {
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600); err != nil {
+		panic(err)
+	}
+}
+
 // MustTempFile returns the path to a non-existent temporary file.
 func MustTempFile() string {
 	f, _ := ioutil.TempFile("", "influxdb-meta-")
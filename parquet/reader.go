@@ -0,0 +1,491 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Read decodes a whole file and returns its columns and rows. Values have
+// the same types that Writer accepts, with nil for missing values.
+//
+// Only files in the form written by Writer can be read: a flat schema with
+// uncompressed, PLAIN encoded data pages.
+func Read(b []byte) ([]Column, [][]interface{}, error) {
+	if len(b) < 12 || string(b[:4]) != string(magic) || string(b[len(b)-4:]) != string(magic) {
+		return nil, nil, ErrInvalidFile
+	}
+	n := int64(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	if n > int64(len(b)-12) {
+		return nil, nil, ErrInvalidFile
+	}
+
+	d := &decoder{b: b[int64(len(b)-8)-n : len(b)-8]}
+	meta := d.fileMetaData()
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+
+	var rows [][]interface{}
+	for _, rg := range meta.rowGroups {
+		if len(rg.chunks) != len(meta.columns) {
+			return nil, nil, ErrInvalidFile
+		}
+
+		// Read each column chunk and then transpose the values into rows.
+		values := make([][]interface{}, len(meta.columns))
+		for i, c := range meta.columns {
+			chunk := rg.chunks[i]
+			if chunk.codec != codecUncompressed {
+				return nil, nil, fmt.Errorf("parquet column %q: unsupported codec %d", c.Name, chunk.codec)
+			} else if chunk.offset < 4 || chunk.offset >= int64(len(b)) || chunk.numValues != rg.numRows {
+				return nil, nil, ErrInvalidFile
+			}
+
+			a, err := readColumnChunk(b[chunk.offset:], c, chunk.numValues)
+			if err != nil {
+				return nil, nil, err
+			}
+			values[i] = a
+		}
+
+		for j := int64(0); j < rg.numRows; j++ {
+			row := make([]interface{}, len(meta.columns))
+			for i := range meta.columns {
+				row[i] = values[i][j]
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return meta.columns, rows, nil
+}
+
+// readColumnChunk decodes the data pages of a column chunk starting at b.
+func readColumnChunk(b []byte, c Column, numValues int64) ([]interface{}, error) {
+	values := make([]interface{}, 0, numValues)
+	for int64(len(values)) < numValues {
+		d := &decoder{b: b}
+		hdr := d.pageHeader()
+		if d.err != nil {
+			return nil, d.err
+		} else if hdr.size < 0 || hdr.size > len(d.b) {
+			return nil, ErrInvalidFile
+		} else if hdr.typ != pageTypeData || hdr.encoding != encodingPlain {
+			return nil, fmt.Errorf("parquet column %q: unsupported page", c.Name)
+		} else if hdr.numValues <= 0 || int64(hdr.numValues) > numValues-int64(len(values)) {
+			return nil, ErrInvalidFile
+		}
+
+		a, err := decodePage(d.b[:hdr.size], c, hdr.numValues)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, a...)
+		b = d.b[hdr.size:]
+	}
+	return values, nil
+}
+
+// decodePage decodes n values from the data of a PLAIN encoded page.
+func decodePage(data []byte, c Column, n int) ([]interface{}, error) {
+	// Optional columns are prefixed with their definition levels.
+	levels := make([]bool, n)
+	if c.Required {
+		for i := range levels {
+			levels[i] = true
+		}
+	} else {
+		if len(data) < 4 {
+			return nil, ErrInvalidFile
+		}
+		size := binary.LittleEndian.Uint32(data)
+		if uint64(size) > uint64(len(data)-4) {
+			return nil, ErrInvalidFile
+		} else if err := decodeLevels(data[4:4+size], levels); err != nil {
+			return nil, err
+		}
+		data = data[4+size:]
+	}
+
+	values := make([]interface{}, n)
+	var nbits uint
+	for i := range values {
+		if !levels[i] {
+			continue
+		}
+
+		switch c.Type {
+		case Boolean:
+			if len(data) == 0 {
+				return nil, ErrInvalidFile
+			}
+			values[i] = data[0]&(1<<nbits) != 0
+			if nbits++; nbits == 8 {
+				data, nbits = data[1:], 0
+			}
+			continue
+		case String:
+			if len(data) < 4 {
+				return nil, ErrInvalidFile
+			}
+			size := binary.LittleEndian.Uint32(data)
+			if uint64(size) > uint64(len(data)-4) {
+				return nil, ErrInvalidFile
+			}
+			values[i], data = string(data[4:4+size]), data[4+size:]
+			continue
+		}
+
+		if len(data) < 8 {
+			return nil, ErrInvalidFile
+		}
+		v := binary.LittleEndian.Uint64(data)
+		data = data[8:]
+		switch c.Type {
+		case Int64:
+			values[i] = int64(v)
+		case Double:
+			values[i] = math.Float64frombits(v)
+		case Timestamp:
+			values[i] = time.Unix(0, int64(v)).UTC()
+		}
+	}
+	return values, nil
+}
+
+// decodeLevels decodes definition levels with a bit width of 1 into levels.
+func decodeLevels(b []byte, levels []bool) error {
+	for i := 0; i < len(levels); {
+		hdr, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrInvalidFile
+		}
+		b = b[n:]
+
+		// An RLE run repeats one value. Otherwise hdr>>1 groups of 8 values
+		// are bit-packed.
+		if hdr&1 == 0 {
+			if len(b) == 0 || hdr>>1 > uint64(len(levels)-i) {
+				return ErrInvalidFile
+			}
+			for j := uint64(0); j < hdr>>1; j++ {
+				levels[i] = b[0] == 1
+				i++
+			}
+			b = b[1:]
+		} else {
+			groups := hdr >> 1
+			if groups > uint64(len(b)) {
+				return ErrInvalidFile
+			}
+			for j := uint64(0); j < groups*8 && i < len(levels); j++ {
+				levels[i] = b[j/8]&(1<<(j%8)) != 0
+				i++
+			}
+			b = b[groups:]
+		}
+	}
+	return nil
+}
+
+// fileMetaData holds the parts of the file footer needed to read the rows.
+type fileMetaData struct {
+	columns   []Column
+	rowGroups []rowGroupMetaData
+}
+
+type rowGroupMetaData struct {
+	chunks  []columnChunkMetaData
+	numRows int64
+}
+
+type columnChunkMetaData struct {
+	offset    int64
+	numValues int64
+	codec     int32
+}
+
+// pageHeader holds the parts of a page header needed to read its values.
+type pageHeader struct {
+	typ       int32
+	size      int
+	numValues int
+	encoding  int32
+}
+
+// decoder decodes Thrift structures using the compact protocol. The first
+// error is kept and later reads return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+// fileMetaData decodes the file footer.
+func (d *decoder) fileMetaData() fileMetaData {
+	var m fileMetaData
+	d.fields(func(id int16, typ byte) {
+		switch {
+		case id == 2 && typ == typeList:
+			_, n := d.list()
+			for i := 0; i < n && d.err == nil; i++ {
+				name, c, children := d.schemaElement()
+				if i == 0 {
+					continue // root
+				} else if children > 0 {
+					d.fail(fmt.Errorf("parquet column %q: nested schemas are not supported", name))
+				}
+				m.columns = append(m.columns, c)
+			}
+		case id == 4 && typ == typeList:
+			_, n := d.list()
+			for i := 0; i < n && d.err == nil; i++ {
+				m.rowGroups = append(m.rowGroups, d.rowGroup())
+			}
+		default:
+			d.skip(typ)
+		}
+	})
+	return m
+}
+
+// schemaElement decodes a column in the schema and its number of children.
+func (d *decoder) schemaElement() (name string, c Column, children int32) {
+	var physical int32
+	var timestamp bool
+	d.fields(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == typeI32:
+			physical = d.i32()
+		case id == 3 && typ == typeI32:
+			c.Required = d.i32() == repetitionRequired
+		case id == 4 && typ == typeBinary:
+			c.Name = string(d.binary())
+		case id == 5 && typ == typeI32:
+			children = d.i32()
+		case id == 10 && typ == typeStruct:
+			d.fields(func(id int16, typ byte) {
+				timestamp = timestamp || id == 8
+				d.skip(typ)
+			})
+		default:
+			d.skip(typ)
+		}
+	})
+
+	switch physical {
+	case physicalBoolean:
+		c.Type = Boolean
+	case physicalInt64:
+		c.Type = Int64
+		if timestamp {
+			c.Type = Timestamp
+		}
+	case physicalDouble:
+		c.Type = Double
+	case physicalByteArray:
+		c.Type = String
+	default:
+		if children == 0 {
+			d.fail(fmt.Errorf("parquet column %q: unsupported type %d", c.Name, physical))
+		}
+	}
+	return c.Name, c, children
+}
+
+// rowGroup decodes the location of a row group's column chunks.
+func (d *decoder) rowGroup() rowGroupMetaData {
+	var rg rowGroupMetaData
+	d.fields(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == typeList:
+			_, n := d.list()
+			for i := 0; i < n && d.err == nil; i++ {
+				rg.chunks = append(rg.chunks, d.columnChunk())
+			}
+		case id == 3 && typ == typeI64:
+			rg.numRows = d.i64()
+		default:
+			d.skip(typ)
+		}
+	})
+	return rg
+}
+
+// columnChunk decodes the metadata of a column chunk.
+func (d *decoder) columnChunk() columnChunkMetaData {
+	var chunk columnChunkMetaData
+	d.fields(func(id int16, typ byte) {
+		if id != 3 || typ != typeStruct {
+			d.skip(typ)
+			return
+		}
+		d.fields(func(id int16, typ byte) {
+			switch {
+			case id == 4 && typ == typeI32:
+				chunk.codec = d.i32()
+			case id == 5 && typ == typeI64:
+				chunk.numValues = d.i64()
+			case id == 9 && typ == typeI64:
+				chunk.offset = d.i64()
+			default:
+				d.skip(typ)
+			}
+		})
+	})
+	return chunk
+}
+
+// pageHeader decodes a page header.
+func (d *decoder) pageHeader() pageHeader {
+	var hdr pageHeader
+	d.fields(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == typeI32:
+			hdr.typ = d.i32()
+		case id == 3 && typ == typeI32:
+			hdr.size = int(d.i32())
+		case id == 5 && typ == typeStruct:
+			d.fields(func(id int16, typ byte) {
+				switch {
+				case id == 1 && typ == typeI32:
+					hdr.numValues = int(d.i32())
+				case id == 2 && typ == typeI32:
+					hdr.encoding = d.i32()
+				default:
+					d.skip(typ)
+				}
+			})
+		default:
+			d.skip(typ)
+		}
+	})
+	return hdr
+}
+
+// fields calls fn with the id and type of each field of a struct until the
+// stop field. fn must read or skip the field's value.
+func (d *decoder) fields(fn func(id int16, typ byte)) {
+	var last int16
+	for d.err == nil {
+		h := d.byte()
+		if h == 0 {
+			return
+		}
+
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(d.varint())
+		}
+		last = id
+		fn(id, h&0x0F)
+	}
+}
+
+// skip reads and discards a field value of type typ.
+func (d *decoder) skip(typ byte) {
+	switch typ {
+	case typeBoolTrue, typeBoolFalse:
+		// The value is stored in the field header.
+	case typeByte:
+		d.byte()
+	case typeI16, typeI32, typeI64:
+		d.uvarint()
+	case typeDouble:
+		d.next(8)
+	case typeBinary:
+		d.binary()
+	case typeList, typeSet:
+		elem, n := d.list()
+		for i := 0; i < n && d.err == nil; i++ {
+			if elem == typeBoolTrue || elem == typeBoolFalse {
+				d.byte() // list elements store booleans in a byte
+			} else {
+				d.skip(elem)
+			}
+		}
+	case typeMap:
+		n := int(d.uvarint())
+		if n == 0 {
+			return
+		}
+		types := d.byte()
+		for i := 0; i < n && d.err == nil; i++ {
+			d.skip(types >> 4)
+			d.skip(types & 0x0F)
+		}
+	case typeStruct:
+		d.fields(func(_ int16, typ byte) { d.skip(typ) })
+	default:
+		d.fail(ErrInvalidFile)
+	}
+}
+
+// list decodes a list header and returns the element type and count.
+func (d *decoder) list() (elem byte, n int) {
+	h := d.byte()
+	n = int(h >> 4)
+	if n == 15 {
+		n = int(d.uvarint())
+	}
+
+	// Every element takes at least a byte.
+	if n < 0 || n > len(d.b) {
+		d.fail(ErrInvalidFile)
+		return 0, 0
+	}
+	return h & 0x0F, n
+}
+
+func (d *decoder) i32() int32 { return int32(d.varint()) }
+
+func (d *decoder) i64() int64 { return d.varint() }
+
+func (d *decoder) binary() []byte { return d.next(int(d.uvarint())) }
+
+// varint decodes a zigzag encoded varint.
+func (d *decoder) varint() int64 {
+	v := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.fail(ErrInvalidFile)
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) byte() byte {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+// next returns the next n bytes.
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	} else if n < 0 || n > len(d.b) {
+		d.fail(ErrInvalidFile)
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+// fail records err if no error has been recorded yet.
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
@@ -0,0 +1,101 @@
+package parquet_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/parquet"
+)
+
+// Ensure rows written to a file are read back unchanged.
+func TestRead(t *testing.T) {
+	columns := []parquet.Column{
+		{Name: "time", Type: parquet.Timestamp, Required: true},
+		{Name: "host", Type: parquet.String},
+		{Name: "count", Type: parquet.Int64},
+		{Name: "value", Type: parquet.Double},
+		{Name: "up", Type: parquet.Boolean},
+	}
+
+	// Write enough rows for several row groups and booleans spanning bytes.
+	var rows [][]interface{}
+	for i := 0; i < 25; i++ {
+		row := []interface{}{time.Unix(0, int64(i)).UTC(), fmt.Sprintf("server%d", i%3), int64(i), float64(i) / 2, i%2 == 0}
+		if i%4 == 1 {
+			row[1] = nil
+		}
+		if i%5 == 2 {
+			row[2], row[4] = nil, nil
+		}
+		rows = append(rows, row)
+	}
+
+	var buf bytes.Buffer
+	w := parquet.NewWriter(&buf, columns)
+	w.RowGroupSize = 10
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cols, a, err := parquet.Read(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cols, columns) {
+		t.Fatalf("unexpected columns: %#v", cols)
+	} else if !reflect.DeepEqual(a, rows) {
+		t.Fatalf("unexpected rows:\n\nexp=%v\n\ngot=%v", rows, a)
+	}
+}
+
+// Ensure a file without rows is read back with its columns.
+func TestRead_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	columns := []parquet.Column{{Name: "value", Type: parquet.Int64, Required: true}}
+	if err := parquet.NewWriter(&buf, columns).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cols, rows, err := parquet.Read(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cols, columns) || len(rows) != 0 {
+		t.Fatalf("unexpected file: %v, %v", cols, rows)
+	}
+}
+
+// Ensure truncated and corrupt files are rejected.
+func TestRead_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	w := parquet.NewWriter(&buf, []parquet.Column{{Name: "value", Type: parquet.String}})
+	if err := w.Write([]interface{}{"foo"}); err != nil {
+		t.Fatal(err)
+	} else if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+
+	// Overwrite the footer with invalid field types.
+	corrupt := append([]byte{}, b...)
+	n := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	for i := len(b) - 8 - n; i < len(b)-8; i++ {
+		corrupt[i] = 0xFF
+	}
+
+	for i, tt := range [][]byte{
+		nil,
+		b[:len(b)-1],
+		append([]byte("PAR1"), b[len(b)-8:]...),
+		corrupt,
+	} {
+		if _, _, err := parquet.Read(tt); err != parquet.ErrInvalidFile {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
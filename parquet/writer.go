@@ -0,0 +1,517 @@
+/*
+Package parquet implements a minimal writer for Apache Parquet files.
+
+Only flat schemas are supported. Every column is written as a single
+uncompressed, PLAIN encoded data page per row group, which is enough for
+analysis tools such as Spark and DuckDB to read the data back. Read decodes
+files in the same form.
+*/
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// DefaultRowGroupSize is the default number of rows buffered before a row group is written.
+const DefaultRowGroupSize = 65536
+
+// magic is written at the start and end of every Parquet file.
+var magic = []byte("PAR1")
+
+var (
+	// ErrWriterClosed is returned when writing to a closed writer.
+	ErrWriterClosed = errors.New("parquet writer closed")
+
+	// ErrColumnCount is returned when a row doesn't have a value for every column.
+	ErrColumnCount = errors.New("parquet row column count mismatch")
+
+	// ErrInvalidFile is returned when reading a file that is truncated or corrupt.
+	ErrInvalidFile = errors.New("invalid parquet file")
+)
+
+// Type is the type of the values in a column.
+type Type int
+
+const (
+	// Boolean columns hold bool values.
+	Boolean Type = iota
+
+	// Int64 columns hold int64 values.
+	Int64
+
+	// Double columns hold float64 values.
+	Double
+
+	// String columns hold UTF-8 string values.
+	String
+
+	// Timestamp columns hold time.Time values stored as nanoseconds since the epoch.
+	Timestamp
+)
+
+// Physical types, encodings and other enumerations from the Parquet format.
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8 = 0
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+
+	pageTypeData = 0
+)
+
+// physical returns the Parquet physical type for t.
+func (t Type) physical() int32 {
+	switch t {
+	case Boolean:
+		return physicalBoolean
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	}
+	return physicalInt64
+}
+
+// Column describes a column in a file.
+type Column struct {
+	Name string
+	Type Type
+
+	// Required columns must have a value in every row. Other columns may be nil.
+	Required bool
+}
+
+// Writer writes rows to a Parquet file.
+type Writer struct {
+	w       io.Writer
+	n       int64 // bytes written to w
+	columns []Column
+	closed  bool
+
+	// Row groups written so far and the rows in the current row group.
+	rowGroups []rowGroup
+	rows      [][]interface{}
+	numRows   int64
+
+	// RowGroupSize is the number of rows buffered before a row group is written.
+	RowGroupSize int
+
+	// CreatedBy is stored in the file footer to identify the writer.
+	CreatedBy string
+}
+
+// rowGroup holds the location of a row group written to the file.
+type rowGroup struct {
+	columns  []columnChunk
+	numRows  int64
+	byteSize int64
+}
+
+// columnChunk holds the location of a column within a row group.
+type columnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// NewWriter returns a new writer that writes rows with the given columns to w.
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	return &Writer{
+		w:            w,
+		columns:      columns,
+		RowGroupSize: DefaultRowGroupSize,
+		CreatedBy:    "influxdb",
+	}
+}
+
+// Write adds a row to the file. Values must be in column order.
+func (w *Writer) Write(values []interface{}) error {
+	if w.closed {
+		return ErrWriterClosed
+	} else if len(values) != len(w.columns) {
+		return ErrColumnCount
+	}
+
+	for i, c := range w.columns {
+		if err := c.validate(values[i]); err != nil {
+			return err
+		}
+	}
+
+	w.rows = append(w.rows, values)
+	if len(w.rows) >= w.RowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close writes any buffered rows and the file footer.
+// It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	// The magic header is written lazily so that an empty file is still valid.
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	// Write the file metadata followed by its length and the magic footer.
+	buf := w.fileMetaData()
+	if err := w.write(buf); err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(buf)))
+	if err := w.write(size[:]); err != nil {
+		return err
+	}
+	return w.write(magic)
+}
+
+// validate returns an error if v cannot be stored in the column.
+func (c *Column) validate(v interface{}) error {
+	if v == nil {
+		if c.Required {
+			return fmt.Errorf("parquet column %q requires a value", c.Name)
+		}
+		return nil
+	}
+
+	var ok bool
+	switch c.Type {
+	case Boolean:
+		_, ok = v.(bool)
+	case Int64:
+		_, ok = v.(int64)
+	case Double:
+		_, ok = v.(float64)
+	case String:
+		_, ok = v.(string)
+	case Timestamp:
+		_, ok = v.(time.Time)
+	}
+	if !ok {
+		return fmt.Errorf("parquet column %q cannot store %T", c.Name, v)
+	}
+	return nil
+}
+
+// writeHeader writes the magic bytes at the start of the file.
+func (w *Writer) writeHeader() error {
+	if w.n > 0 {
+		return nil
+	}
+	return w.write(magic)
+}
+
+// flush writes the buffered rows as a row group.
+func (w *Writer) flush() error {
+	if len(w.rows) == 0 {
+		return nil
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	rg := rowGroup{numRows: int64(len(w.rows))}
+	for i, c := range w.columns {
+		page := w.encodePage(i, c)
+
+		chunk := columnChunk{offset: w.n, numValues: int64(len(w.rows))}
+		if err := w.write(page); err != nil {
+			return err
+		}
+		chunk.size = w.n - chunk.offset
+		rg.byteSize += chunk.size
+		rg.columns = append(rg.columns, chunk)
+	}
+
+	w.rowGroups = append(w.rowGroups, rg)
+	w.numRows += rg.numRows
+	w.rows = w.rows[:0]
+	return nil
+}
+
+// encodePage returns the page header and data for a column of the buffered rows.
+func (w *Writer) encodePage(i int, c Column) []byte {
+	var data bytes.Buffer
+
+	// Optional columns are prefixed with their definition levels.
+	if !c.Required {
+		levels := make([]bool, len(w.rows))
+		for j, row := range w.rows {
+			levels[j] = row[i] != nil
+		}
+		buf := encodeLevels(levels)
+		binary.Write(&data, binary.LittleEndian, uint32(len(buf)))
+		data.Write(buf)
+	}
+
+	// Write the non-null values using PLAIN encoding.
+	var bits, nbits byte
+	for _, row := range w.rows {
+		switch v := row[i].(type) {
+		case bool:
+			if v {
+				bits |= 1 << nbits
+			}
+			if nbits++; nbits == 8 {
+				data.WriteByte(bits)
+				bits, nbits = 0, 0
+			}
+		case int64:
+			binary.Write(&data, binary.LittleEndian, v)
+		case float64:
+			binary.Write(&data, binary.LittleEndian, math.Float64bits(v))
+		case string:
+			binary.Write(&data, binary.LittleEndian, uint32(len(v)))
+			data.WriteString(v)
+		case time.Time:
+			binary.Write(&data, binary.LittleEndian, v.UnixNano())
+		}
+	}
+	if nbits > 0 {
+		data.WriteByte(bits)
+	}
+
+	// Prepend the page header.
+	var e encoder
+	e.beginStruct()
+	e.i32(1, pageTypeData)
+	e.i32(2, int32(data.Len()))
+	e.i32(3, int32(data.Len()))
+	e.fieldStruct(5)
+	e.i32(1, int32(len(w.rows)))
+	e.i32(2, encodingPlain)
+	e.i32(3, encodingRLE)
+	e.i32(4, encodingRLE)
+	e.endStruct()
+	e.endStruct()
+
+	return append(e.buf.Bytes(), data.Bytes()...)
+}
+
+// encodeLevels encodes definition levels with a bit width of 1 as RLE runs.
+func encodeLevels(levels []bool) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		writeUvarint(&buf, uint64(j-i)<<1)
+		if levels[i] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
+
+// fileMetaData returns the encoded footer describing the schema and row groups.
+func (w *Writer) fileMetaData() []byte {
+	var e encoder
+	e.beginStruct()
+	e.i32(1, 1)
+
+	// The schema is a root element followed by one element per column.
+	e.fieldList(2, typeStruct, len(w.columns)+1)
+	e.beginStruct()
+	e.binary(4, []byte("schema"))
+	e.i32(5, int32(len(w.columns)))
+	e.endStruct()
+	for _, c := range w.columns {
+		e.beginStruct()
+		e.i32(1, c.Type.physical())
+		if c.Required {
+			e.i32(3, repetitionRequired)
+		} else {
+			e.i32(3, repetitionOptional)
+		}
+		e.binary(4, []byte(c.Name))
+		if c.Type == String {
+			e.i32(6, convertedUTF8)
+		}
+		if c.Type == Timestamp {
+			// LogicalType.TIMESTAMP{isAdjustedToUTC: true, unit: NANOS}
+			e.fieldStruct(10)
+			e.fieldStruct(8)
+			e.bool(1, true)
+			e.fieldStruct(2)
+			e.fieldStruct(3)
+			e.endStruct()
+			e.endStruct()
+			e.endStruct()
+			e.endStruct()
+		}
+		e.endStruct()
+	}
+
+	e.i64(3, w.numRows)
+
+	e.fieldList(4, typeStruct, len(w.rowGroups))
+	for _, rg := range w.rowGroups {
+		e.beginStruct()
+		e.fieldList(1, typeStruct, len(rg.columns))
+		for i, chunk := range rg.columns {
+			c := w.columns[i]
+
+			e.beginStruct()
+			e.i64(2, chunk.offset)
+			e.fieldStruct(3)
+			e.i32(1, c.Type.physical())
+			e.fieldList(2, typeI32, 2)
+			e.listI32(encodingPlain)
+			e.listI32(encodingRLE)
+			e.fieldList(3, typeBinary, 1)
+			e.listBinary([]byte(c.Name))
+			e.i32(4, codecUncompressed)
+			e.i64(5, chunk.numValues)
+			e.i64(6, chunk.size)
+			e.i64(7, chunk.size)
+			e.i64(9, chunk.offset)
+			e.endStruct()
+			e.endStruct()
+		}
+		e.i64(2, rg.byteSize)
+		e.i64(3, rg.numRows)
+		e.endStruct()
+	}
+
+	e.binary(6, []byte(w.CreatedBy))
+	e.endStruct()
+	return e.buf.Bytes()
+}
+
+// write writes b to the underlying writer and tracks the file offset.
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	return err
+}
+
+// Thrift compact protocol field types.
+const (
+	typeBoolTrue  = 1
+	typeBoolFalse = 2
+	typeByte      = 3
+	typeI16       = 4
+	typeI32       = 5
+	typeI64       = 6
+	typeDouble    = 7
+	typeBinary    = 8
+	typeList      = 9
+	typeSet       = 10
+	typeMap       = 11
+	typeStruct    = 12
+)
+
+// encoder encodes Thrift structures using the compact protocol.
+type encoder struct {
+	buf  bytes.Buffer
+	last []int16 // last field id written in each open struct
+}
+
+// beginStruct starts a struct that is not the value of a field,
+// such as the top level struct or a list element.
+func (e *encoder) beginStruct() { e.last = append(e.last, 0) }
+
+// endStruct writes the stop field and closes the current struct.
+func (e *encoder) endStruct() {
+	e.buf.WriteByte(0)
+	e.last = e.last[:len(e.last)-1]
+}
+
+// fieldStruct starts a struct that is the value of field id.
+func (e *encoder) fieldStruct(id int16) {
+	e.fieldHeader(id, typeStruct)
+	e.beginStruct()
+}
+
+// fieldList writes the header for a list of n elements of type typ in field id.
+func (e *encoder) fieldList(id int16, typ byte, n int) {
+	e.fieldHeader(id, typeList)
+	if n < 15 {
+		e.buf.WriteByte(byte(n)<<4 | typ)
+	} else {
+		e.buf.WriteByte(0xF0 | typ)
+		writeUvarint(&e.buf, uint64(n))
+	}
+}
+
+func (e *encoder) bool(id int16, v bool) {
+	if v {
+		e.fieldHeader(id, typeBoolTrue)
+	} else {
+		e.fieldHeader(id, typeBoolFalse)
+	}
+}
+
+func (e *encoder) i32(id int16, v int32) {
+	e.fieldHeader(id, typeI32)
+	e.listI32(v)
+}
+
+func (e *encoder) i64(id int16, v int64) {
+	e.fieldHeader(id, typeI64)
+	writeUvarint(&e.buf, uint64((v<<1)^(v>>63)))
+}
+
+func (e *encoder) binary(id int16, b []byte) {
+	e.fieldHeader(id, typeBinary)
+	e.listBinary(b)
+}
+
+// listI32 writes an i32 without a field header.
+func (e *encoder) listI32(v int32) {
+	writeUvarint(&e.buf, uint64(uint32((v<<1)^(v>>31))))
+}
+
+// listBinary writes a binary value without a field header.
+func (e *encoder) listBinary(b []byte) {
+	writeUvarint(&e.buf, uint64(len(b)))
+	e.buf.Write(b)
+}
+
+// fieldHeader writes the header for field id, using a delta from the previous field when possible.
+func (e *encoder) fieldHeader(id int16, typ byte) {
+	last := &e.last[len(e.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		e.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		e.buf.WriteByte(typ)
+		writeUvarint(&e.buf, uint64(uint16((id<<1)^(id>>15))))
+	}
+	*last = id
+}
+
+// writeUvarint writes v to buf as an unsigned varint.
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	buf.Write(b[:n])
+}
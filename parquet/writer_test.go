@@ -0,0 +1,93 @@
+package parquet_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/parquet"
+)
+
+// Ensure the writer produces a file with a header, data pages and a footer.
+func TestWriter_Write(t *testing.T) {
+	var buf bytes.Buffer
+	w := parquet.NewWriter(&buf, []parquet.Column{
+		{Name: "time", Type: parquet.Timestamp, Required: true},
+		{Name: "host", Type: parquet.String},
+		{Name: "value", Type: parquet.Double},
+	})
+	w.RowGroupSize = 2
+
+	for _, values := range [][]interface{}{
+		{time.Unix(0, 1), "serverA", 1.5},
+		{time.Unix(0, 2), nil, 2.5},
+		{time.Unix(0, 3), "serverB", nil},
+	} {
+		if err := w.Write(values); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+	if !bytes.HasPrefix(b, []byte("PAR1")) || !bytes.HasSuffix(b, []byte("PAR1")) {
+		t.Fatal("missing magic bytes")
+	}
+
+	// The footer length should cover the metadata which names every column.
+	n := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	if n <= 0 || n > len(b)-12 {
+		t.Fatalf("invalid footer length: %d", n)
+	}
+	footer := b[len(b)-8-n : len(b)-8]
+	for _, name := range []string{"schema", "time", "host", "value", "influxdb"} {
+		if !bytes.Contains(footer, []byte(name)) {
+			t.Fatalf("footer missing %q", name)
+		}
+	}
+
+	// The first value written should be the time of the first row.
+	if !bytes.Contains(b, []byte{1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0}) {
+		t.Fatal("time values not found")
+	}
+}
+
+// Ensure a file without rows is still valid.
+func TestWriter_Close_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	w := parquet.NewWriter(&buf, []parquet.Column{{Name: "value", Type: parquet.Int64}})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+	if !bytes.HasPrefix(b, []byte("PAR1")) || !bytes.HasSuffix(b, []byte("PAR1")) {
+		t.Fatal("missing magic bytes")
+	} else if err := w.Write([]interface{}{int64(1)}); err != parquet.ErrWriterClosed {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure invalid rows are rejected.
+func TestWriter_Write_Invalid(t *testing.T) {
+	w := parquet.NewWriter(&bytes.Buffer{}, []parquet.Column{
+		{Name: "time", Type: parquet.Timestamp, Required: true},
+		{Name: "value", Type: parquet.Int64},
+	})
+
+	for i, tt := range []struct {
+		values []interface{}
+		err    string
+	}{
+		{values: []interface{}{time.Unix(0, 0)}, err: parquet.ErrColumnCount.Error()},
+		{values: []interface{}{nil, int64(1)}, err: `parquet column "time" requires a value`},
+		{values: []interface{}{time.Unix(0, 0), 1.5}, err: `parquet column "value" cannot store float64`},
+	} {
+		if err := w.Write(tt.values); err == nil || err.Error() != tt.err {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
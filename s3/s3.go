@@ -0,0 +1,503 @@
+/*
+Package s3 implements a minimal client for S3-compatible object storage.
+
+Only the requests needed to stream large objects are supported: objects are
+written with multipart uploads so that data never needs to be staged on local
+disk. Requests are signed with AWS Signature Version 4.
+*/
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultEndpoint is the default S3 endpoint.
+	DefaultEndpoint = "https://s3.amazonaws.com"
+
+	// DefaultRegion is the default region used to sign requests.
+	DefaultRegion = "us-east-1"
+
+	// DefaultPartSize is the default size of each part of a multipart upload.
+	DefaultPartSize = 16 * 1024 * 1024
+
+	// MinPartSize is the smallest part size accepted by S3, except for the last part.
+	MinPartSize = 5 * 1024 * 1024
+
+	// MaxParts is the most parts S3 accepts in a multipart upload.
+	MaxParts = 10000
+
+	// DefaultMaxRetries is the default number of times a failed request is retried.
+	DefaultMaxRetries = 3
+)
+
+// Server-side encryption modes.
+const (
+	// SSES3 encrypts objects with keys managed by S3.
+	SSES3 = "AES256"
+
+	// SSEKMS encrypts objects with a key managed by KMS.
+	SSEKMS = "aws:kms"
+)
+
+// ErrTooManyParts is returned when uploading more than MaxParts parts.
+var ErrTooManyParts = errors.New("s3: too many parts")
+
+// emptyHash is the SHA256 hash of an empty payload.
+const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Client represents a client for an S3-compatible endpoint.
+type Client struct {
+	// Endpoint is the base URL of the service. Buckets are addressed by path.
+	Endpoint string
+
+	// Region is the region used to sign requests.
+	Region string
+
+	// Credentials used to sign requests. SessionToken is only required for
+	// temporary credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// ServerSideEncryption is either empty, SSES3 or SSEKMS. KMSKeyID
+	// optionally selects the KMS key when using SSEKMS.
+	ServerSideEncryption string
+	KMSKeyID             string
+
+	// MaxRetries is the number of times a request that fails with a network
+	// error or a server error is retried.
+	MaxRetries int
+
+	HTTPClient *http.Client
+
+	// Returns the current time. Defaults to time.Now().
+	Now func() time.Time
+}
+
+// NewClient returns a new client for the default endpoint.
+func NewClient() *Client {
+	return &Client{
+		Endpoint:   DefaultEndpoint,
+		Region:     DefaultRegion,
+		MaxRetries: DefaultMaxRetries,
+		HTTPClient: http.DefaultClient,
+		Now:        time.Now,
+	}
+}
+
+// ParseURL splits an "s3://bucket/key" URL into its bucket and key.
+func ParseURL(s string) (bucket, key string, err error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", "", err
+	} else if u.Scheme != "s3" {
+		return "", "", fmt.Errorf("invalid s3 url: %s", s)
+	}
+
+	bucket, key = u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("s3 url requires a bucket and key: %s", s)
+	}
+	return bucket, key, nil
+}
+
+// Get returns the contents of an object. The caller must close the reader.
+func (c *Client) Get(bucket, key string) (io.ReadCloser, error) {
+	resp, err := c.do("GET", bucket, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes an object.
+func (c *Client) Delete(bucket, key string) error {
+	resp, err := c.do("DELETE", bucket, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Error is an error response returned by the service.
+type Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+// Error returns the string representation of the error.
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: unexpected status: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+// Upload represents an in-progress multipart upload.
+type Upload struct {
+	client *Client
+	Bucket string
+	Key    string
+	ID     string
+	parts  []completedPart
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// NewUpload starts a multipart upload to a key in a bucket.
+func (c *Client) NewUpload(bucket, key string) (*Upload, error) {
+	header := make(http.Header)
+	if c.ServerSideEncryption != "" {
+		header.Set("X-Amz-Server-Side-Encryption", c.ServerSideEncryption)
+		if c.ServerSideEncryption == SSEKMS && c.KMSKeyID != "" {
+			header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", c.KMSKeyID)
+		}
+	}
+
+	resp, err := c.do("POST", bucket, key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("s3: decode upload: %s", err)
+	} else if result.UploadID == "" {
+		return nil, errors.New("s3: missing upload id")
+	}
+	return &Upload{client: c, Bucket: bucket, Key: key, ID: result.UploadID}, nil
+}
+
+// UploadPart uploads the next part of the object.
+func (u *Upload) UploadPart(b []byte) error {
+	n := len(u.parts) + 1
+	if n > MaxParts {
+		return ErrTooManyParts
+	}
+	q := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {u.ID}}
+
+	resp, err := u.client.do("PUT", u.Bucket, u.Key, q, nil, b)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	u.parts = append(u.parts, completedPart{PartNumber: n, ETag: resp.Header.Get("ETag")})
+	return nil
+}
+
+// Complete assembles the uploaded parts into the object.
+func (u *Upload) Complete() error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: u.parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := u.client.do("POST", u.Bucket, u.Key, url.Values{"uploadId": {u.ID}}, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The service can report a failure after it has returned a 200 status.
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return decodeError(resp.StatusCode, b)
+}
+
+// Abort cancels the upload and discards any uploaded parts.
+func (u *Upload) Abort() error {
+	resp, err := u.client.do("DELETE", u.Bucket, u.Key, url.Values{"uploadId": {u.ID}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Writer streams data to an object using a multipart upload.
+// Data is buffered in memory one part at a time.
+type Writer struct {
+	upload *Upload
+	buf    []byte
+	err    error
+
+	// PartSize is the size of each uploaded part. Must be at least MinPartSize.
+	PartSize int
+}
+
+// PartSize returns the part size to upload an object of size bytes in at
+// most MaxParts parts. It is DefaultPartSize unless the object is larger
+// than MaxParts parts of that size, in which case it is rounded up to a
+// whole megabyte.
+func PartSize(size int64) int {
+	if size <= MaxParts*DefaultPartSize {
+		return DefaultPartSize
+	}
+	const mb = 1024 * 1024
+	n := (size + MaxParts - 1) / MaxParts
+	return int((n + mb - 1) / mb * mb)
+}
+
+// NewWriter starts a multipart upload and returns a writer for it.
+func (c *Client) NewWriter(bucket, key string) (*Writer, error) {
+	u, err := c.NewUpload(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{upload: u, PartSize: DefaultPartSize}, nil
+}
+
+// Write buffers p and uploads a part whenever a full part is buffered.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		i := w.PartSize - len(w.buf)
+		if i > len(p) {
+			i = len(p)
+		}
+		w.buf = append(w.buf, p[:i]...)
+		p = p[i:]
+
+		if len(w.buf) >= w.PartSize {
+			if err := w.flush(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close uploads the remaining data and completes the upload.
+// The upload is aborted if any part failed to upload.
+func (w *Writer) Close() error {
+	if w.err == nil && (len(w.buf) > 0 || len(w.upload.parts) == 0) {
+		w.flush()
+	}
+	if w.err != nil {
+		w.upload.Abort()
+		return w.err
+	}
+	if err := w.upload.Complete(); err != nil {
+		w.upload.Abort()
+		return err
+	}
+	return nil
+}
+
+// Abort cancels the upload.
+func (w *Writer) Abort() error {
+	if w.err == nil {
+		w.err = errors.New("s3: upload aborted")
+	}
+	return w.upload.Abort()
+}
+
+// flush uploads the buffered data as a part.
+func (w *Writer) flush() error {
+	if err := w.upload.UploadPart(w.buf); err != nil {
+		w.err = err
+		return err
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// do sends a signed request and retries it on network and server errors.
+// Returns an error if the response has a non-2xx status.
+func (c *Client) do(method, bucket, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	var err error
+	for i := 0; i <= c.MaxRetries; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(1<<uint(i-1)) * 100 * time.Millisecond)
+		}
+
+		var resp *http.Response
+		resp, err = c.send(method, bucket, key, query, header, body)
+		if err != nil {
+			continue
+		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		err = decodeError(resp.StatusCode, b)
+		if resp.StatusCode < 500 {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// send sends a single signed request.
+func (c *Client) send(method, bucket, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u, err := url.Parse(strings.TrimSuffix(c.Endpoint, "/") + "/" + bucket + "/" + key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = encodeQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+	c.sign(req, body)
+
+	return c.HTTPClient.Do(req)
+}
+
+// sign adds an AWS Signature Version 4 authorization header to req.
+// The host and every header already set on req are signed.
+func (c *Client) sign(req *http.Request, body []byte) {
+	t := c.Now().UTC()
+	date := t.Format("20060102")
+
+	payloadHash := emptyHash
+	if len(body) > 0 {
+		h := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(h[:])
+	}
+	req.Header.Set("X-Amz-Date", t.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Build the sorted list of signed headers.
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders bytes.Buffer
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		encodePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.Region + "/s3/aws4_request"
+	h := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + t.Format("20060102T150405Z") + "\n" + scope + "\n" + hex.EncodeToString(h[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), date)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// decodeError returns an error for an error response body.
+// Returns nil if the status is 2xx and the body doesn't contain an error.
+func decodeError(status int, b []byte) error {
+	var e Error
+	if err := xml.Unmarshal(b, &e); err != nil || e.Code == "" {
+		if status >= 200 && status < 300 {
+			return nil
+		}
+	}
+	e.StatusCode = status
+	return &e
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+// encodeQuery encodes query parameters sorted by key as required for signing.
+func encodeQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var a []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			a = append(a, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(a, "&")
+}
+
+// encodePath encodes each segment of a path.
+func encodePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return uriEncode(path, false)
+}
+
+// uriEncode percent-encodes every byte of s except unreserved characters.
+// Slashes are also left unencoded unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
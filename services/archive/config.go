@@ -0,0 +1,84 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdb/influxdb/s3"
+	"github.com/influxdb/influxdb/toml"
+)
+
+const (
+	// DefaultCheckInterval is the default time between checks for shards to archive.
+	DefaultCheckInterval = 10 * time.Minute
+
+	// DefaultCacheTTL is the default time an archived shard is kept locally
+	// after it was last fetched.
+	DefaultCacheTTL = time.Hour
+)
+
+// Config represents the configuration for the shard archival service.
+type Config struct {
+	Enabled       bool          `toml:"enabled"`
+	CheckInterval toml.Duration `toml:"check-interval"`
+	CacheTTL      toml.Duration `toml:"cache-ttl"`
+
+	// Object storage settings.
+	Endpoint             string `toml:"endpoint"`
+	Region               string `toml:"region"`
+	Bucket               string `toml:"bucket"`
+	Prefix               string `toml:"prefix"`
+	AccessKeyID          string `toml:"access-key-id"`
+	SecretAccessKey      string `toml:"secret-access-key"`
+	ServerSideEncryption string `toml:"server-side-encryption"`
+	KMSKeyID             string `toml:"kms-key-id"`
+
+	Policies []Policy `toml:"policy"`
+}
+
+// Policy controls when the shards of a retention policy are archived.
+type Policy struct {
+	Database string `toml:"database"`
+
+	// The retention policy to archive. Applies to every retention
+	// policy in the database if blank.
+	RetentionPolicy string `toml:"retention-policy"`
+
+	// How long after a shard group's end time its shards are archived.
+	After toml.Duration `toml:"after"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		CheckInterval: toml.Duration(DefaultCheckInterval),
+		CacheTTL:      toml.Duration(DefaultCacheTTL),
+		Endpoint:      s3.DefaultEndpoint,
+		Region:        s3.DefaultRegion,
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Bucket == "" {
+		return errors.New("archive bucket must be specified")
+	}
+	switch c.ServerSideEncryption {
+	case "", s3.SSES3, s3.SSEKMS:
+	default:
+		return fmt.Errorf("invalid archive server-side encryption: %s", c.ServerSideEncryption)
+	}
+	for _, p := range c.Policies {
+		if p.Database == "" {
+			return errors.New("archive policy database must be specified")
+		} else if p.After <= 0 {
+			return fmt.Errorf("archive policy for %s must have a positive after duration", p.Database)
+		}
+	}
+	return nil
+}
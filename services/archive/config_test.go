@@ -0,0 +1,62 @@
+package archive_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdb/influxdb/services/archive"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c archive.Config
+	if _, err := toml.Decode(`
+enabled = true
+check-interval = "1s"
+cache-ttl = "2h"
+bucket = "shards"
+server-side-encryption = "AES256"
+
+[[policy]]
+database = "db0"
+retention-policy = "rp0"
+after = "168h"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if c.Enabled != true {
+		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if time.Duration(c.CheckInterval) != time.Second {
+		t.Fatalf("unexpected check interval: %v", c.CheckInterval)
+	} else if time.Duration(c.CacheTTL) != 2*time.Hour {
+		t.Fatalf("unexpected cache ttl: %v", c.CacheTTL)
+	} else if c.Bucket != "shards" {
+		t.Fatalf("unexpected bucket: %s", c.Bucket)
+	} else if len(c.Policies) != 1 || c.Policies[0].Database != "db0" || c.Policies[0].RetentionPolicy != "rp0" || time.Duration(c.Policies[0].After) != 7*24*time.Hour {
+		t.Fatalf("unexpected policies: %+v", c.Policies)
+	} else if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure invalid configurations are rejected.
+func TestConfig_Validate(t *testing.T) {
+	for i, tt := range []struct {
+		c   archive.Config
+		err string
+	}{
+		{c: archive.Config{}},
+		{c: archive.Config{Enabled: true}, err: "archive bucket must be specified"},
+		{c: archive.Config{Enabled: true, Bucket: "b", ServerSideEncryption: "foo"}, err: "invalid archive server-side encryption: foo"},
+		{c: archive.Config{Enabled: true, Bucket: "b", Policies: []archive.Policy{{After: 1}}}, err: "archive policy database must be specified"},
+		{c: archive.Config{Enabled: true, Bucket: "b", Policies: []archive.Policy{{Database: "db0"}}}, err: "archive policy for db0 must have a positive after duration"},
+	} {
+		err := tt.c.Validate()
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"io"
+	"path"
+
+	"github.com/influxdb/influxdb/s3"
+)
+
+// S3Archive stores archived shards in an S3-compatible bucket.
+// It implements tsdb.ShardArchive.
+type S3Archive struct {
+	Client *s3.Client
+	Bucket string
+	Prefix string
+}
+
+// NewS3Archive returns an archive for the bucket in the config.
+func NewS3Archive(c Config) *S3Archive {
+	client := s3.NewClient()
+	client.Endpoint = c.Endpoint
+	client.Region = c.Region
+	client.AccessKeyID = c.AccessKeyID
+	client.SecretAccessKey = c.SecretAccessKey
+	client.ServerSideEncryption = c.ServerSideEncryption
+	client.KMSKeyID = c.KMSKeyID
+
+	return &S3Archive{
+		Client: client,
+		Bucket: c.Bucket,
+		Prefix: c.Prefix,
+	}
+}
+
+// Put streams r to the object for key using a multipart upload. The parts
+// are sized so that size bytes fit in the number of parts S3 allows.
+func (a *S3Archive) Put(key string, r io.Reader, size int64) error {
+	w, err := a.Client.NewWriter(a.Bucket, a.key(key))
+	if err != nil {
+		return err
+	}
+	w.PartSize = s3.PartSize(size)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
+
+// Get returns the contents of the object for key.
+func (a *S3Archive) Get(key string) (io.ReadCloser, error) {
+	return a.Client.Get(a.Bucket, a.key(key))
+}
+
+// Delete removes the object for key.
+func (a *S3Archive) Delete(key string) error {
+	return a.Client.Delete(a.Bucket, a.key(key))
+}
+
+// key returns the object key for a shard key.
+func (a *S3Archive) key(key string) string {
+	if a.Prefix == "" {
+		return key
+	}
+	return path.Join(a.Prefix, key)
+}
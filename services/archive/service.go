@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/meta"
+)
+
+// Service moves the shards of cold shard groups to object storage and removes
+// the local copies of archived shards once they are no longer being queried.
+type Service struct {
+	MetaStore interface {
+		VisitRetentionPolicies(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo))
+	}
+	TSDBStore interface {
+		ShardIDs() []uint64
+		ShardArchived(shardID uint64) bool
+		ArchiveShard(shardID uint64) error
+		EvictArchivedShards(idle time.Duration) (int, error)
+	}
+
+	checkInterval time.Duration
+	cacheTTL      time.Duration
+	policies      []Policy
+	wg            sync.WaitGroup
+	done          chan struct{}
+
+	logger *log.Logger
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		checkInterval: time.Duration(c.CheckInterval),
+		cacheTTL:      time.Duration(c.CacheTTL),
+		policies:      c.Policies,
+		done:          make(chan struct{}),
+		logger:        log.New(os.Stderr, "[archive] ", log.LstdFlags),
+	}
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Close stops the service.
+func (s *Service) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return nil
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.logger = l
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			s.logger.Println("shard archival terminating")
+			return
+
+		case <-ticker.C:
+			s.archiveShards(time.Now().UTC())
+
+			if n, err := s.TSDBStore.EvictArchivedShards(s.cacheTTL); err != nil {
+				s.logger.Printf("failed to evict archived shards: %s", err)
+			} else if n > 0 {
+				s.logger.Printf("evicted %d cached archived shards", n)
+			}
+		}
+	}
+}
+
+// archiveShards archives the local shards of every shard group that has
+// passed the archive age of its retention policy.
+func (s *Service) archiveShards(now time.Time) {
+	cold := make(map[uint64]struct{})
+	s.MetaStore.VisitRetentionPolicies(func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo) {
+		after, ok := s.archiveAfter(d.Name, r.Name)
+		if !ok {
+			return
+		}
+		for _, g := range r.ShardGroups {
+			if g.Deleted() || g.EndTime.Add(after).After(now) {
+				continue
+			}
+			for _, sh := range g.Shards {
+				cold[sh.ID] = struct{}{}
+			}
+		}
+	})
+
+	for _, id := range s.TSDBStore.ShardIDs() {
+		if _, ok := cold[id]; !ok || s.TSDBStore.ShardArchived(id) {
+			continue
+		}
+		if err := s.TSDBStore.ArchiveShard(id); err != nil {
+			s.logger.Printf("failed to archive shard ID %d: %s", id, err)
+			continue
+		}
+		s.logger.Printf("shard ID %d archived", id)
+	}
+}
+
+// archiveAfter returns the archive age for a retention policy. A policy for
+// the specific retention policy takes precedence over one for the database.
+func (s *Service) archiveAfter(database, policy string) (time.Duration, bool) {
+	var after time.Duration
+	var ok bool
+	for _, p := range s.policies {
+		if p.Database != database {
+			continue
+		} else if p.RetentionPolicy == policy {
+			return time.Duration(p.After), true
+		} else if p.RetentionPolicy == "" {
+			after, ok = time.Duration(p.After), true
+		}
+	}
+	return after, ok
+}
@@ -0,0 +1,114 @@
+package archive_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/services/archive"
+	"github.com/influxdb/influxdb/toml"
+)
+
+// Ensure the shards of cold shard groups are archived according to their policy.
+func TestService_ArchiveShards(t *testing.T) {
+	s := archive.NewService(archive.Config{
+		CheckInterval: toml.Duration(10 * time.Millisecond),
+		CacheTTL:      toml.Duration(time.Hour),
+		Policies: []archive.Policy{
+			{Database: "db0", After: toml.Duration(24 * time.Hour)},
+			{Database: "db0", RetentionPolicy: "rp1", After: toml.Duration(48 * time.Hour)},
+		},
+	})
+
+	now := time.Now().UTC()
+	var ms MetaStore
+	ms.VisitRetentionPoliciesFn = func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {
+		f(meta.DatabaseInfo{Name: "db0"}, meta.RetentionPolicyInfo{
+			Name: "rp0",
+			ShardGroups: []meta.ShardGroupInfo{
+				{ID: 1, EndTime: now.Add(-36 * time.Hour), Shards: []meta.ShardInfo{{ID: 10}}},
+				{ID: 2, EndTime: now.Add(-time.Hour), Shards: []meta.ShardInfo{{ID: 20}}},
+				{ID: 3, EndTime: now.Add(-36 * time.Hour), Shards: []meta.ShardInfo{{ID: 30}}, DeletedAt: now},
+			},
+		})
+		f(meta.DatabaseInfo{Name: "db0"}, meta.RetentionPolicyInfo{
+			Name: "rp1",
+			ShardGroups: []meta.ShardGroupInfo{
+				{ID: 4, EndTime: now.Add(-36 * time.Hour), Shards: []meta.ShardInfo{{ID: 40}}},
+				{ID: 5, EndTime: now.Add(-72 * time.Hour), Shards: []meta.ShardInfo{{ID: 50}, {ID: 51}}},
+			},
+		})
+		f(meta.DatabaseInfo{Name: "db1"}, meta.RetentionPolicyInfo{
+			Name: "rp0",
+			ShardGroups: []meta.ShardGroupInfo{
+				{ID: 6, EndTime: now.Add(-72 * time.Hour), Shards: []meta.ShardInfo{{ID: 60}}},
+			},
+		})
+	}
+	s.MetaStore = &ms
+
+	// Shard 51 isn't stored locally and shard 50 is already archived.
+	var mu sync.Mutex
+	archived := make(map[uint64]struct{})
+	done := make(chan struct{})
+	var once sync.Once
+	var ts TSDBStore
+	ts.ShardIDsFn = func() []uint64 { return []uint64{10, 20, 30, 40, 50, 60} }
+	ts.ShardArchivedFn = func(shardID uint64) bool { return shardID == 50 }
+	ts.ArchiveShardFn = func(shardID uint64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		archived[shardID] = struct{}{}
+		return nil
+	}
+	ts.EvictArchivedShardsFn = func(idle time.Duration) (int, error) {
+		if idle != time.Hour {
+			t.Errorf("unexpected idle duration: %s", idle)
+		}
+		once.Do(func() { close(done) })
+		return 0, nil
+	}
+	s.TSDBStore = &ts
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for archive check")
+	}
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(archived, map[uint64]struct{}{10: {}}) {
+		t.Fatalf("unexpected archived shards: %v", archived)
+	}
+}
+
+// MetaStore is a mockable implementation of archive.Service.MetaStore.
+type MetaStore struct {
+	VisitRetentionPoliciesFn func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo))
+}
+
+func (ms *MetaStore) VisitRetentionPolicies(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {
+	ms.VisitRetentionPoliciesFn(f)
+}
+
+// TSDBStore is a mockable implementation of archive.Service.TSDBStore.
+type TSDBStore struct {
+	ShardIDsFn            func() []uint64
+	ShardArchivedFn       func(shardID uint64) bool
+	ArchiveShardFn        func(shardID uint64) error
+	EvictArchivedShardsFn func(idle time.Duration) (int, error)
+}
+
+func (s *TSDBStore) ShardIDs() []uint64                { return s.ShardIDsFn() }
+func (s *TSDBStore) ShardArchived(shardID uint64) bool { return s.ShardArchivedFn(shardID) }
+func (s *TSDBStore) ArchiveShard(shardID uint64) error { return s.ArchiveShardFn(shardID) }
+func (s *TSDBStore) EvictArchivedShards(idle time.Duration) (int, error) {
+	return s.EvictArchivedShardsFn(idle)
+}
@@ -0,0 +1,74 @@
+package autotune
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influxdb/influxdb/toml"
+)
+
+const (
+	// DefaultCheckInterval is the default time between load samples.
+	DefaultCheckInterval = 10 * time.Second
+
+	// DefaultMinConcurrentQueries is the default lower bound on the query limit.
+	DefaultMinConcurrentQueries = 1
+
+	// DefaultMaxConcurrentQueries is the default upper bound on the query limit.
+	DefaultMaxConcurrentQueries = 32
+
+	// DefaultCPUThreshold is the default 1 minute load average per CPU above
+	// which the query limit is lowered.
+	DefaultCPUThreshold = 0.9
+
+	// DefaultMemoryThreshold is the default fraction of system memory in use
+	// above which the query limit is lowered.
+	DefaultMemoryThreshold = 0.9
+
+	// DefaultIOThreshold is the default fraction of time tasks are stalled on
+	// I/O above which the query limit is lowered.
+	DefaultIOThreshold = 0.3
+)
+
+// Config represents the configuration for the query concurrency auto-tuner.
+type Config struct {
+	Enabled       bool          `toml:"enabled"`
+	CheckInterval toml.Duration `toml:"check-interval"`
+
+	// Bounds of the concurrent query limit.
+	MinConcurrentQueries int `toml:"min-concurrent-queries"`
+	MaxConcurrentQueries int `toml:"max-concurrent-queries"`
+
+	// Pressure thresholds, each as a fraction.
+	CPUThreshold    float64 `toml:"cpu-threshold"`
+	MemoryThreshold float64 `toml:"memory-threshold"`
+	IOThreshold     float64 `toml:"io-threshold"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		CheckInterval:        toml.Duration(DefaultCheckInterval),
+		MinConcurrentQueries: DefaultMinConcurrentQueries,
+		MaxConcurrentQueries: DefaultMaxConcurrentQueries,
+		CPUThreshold:         DefaultCPUThreshold,
+		MemoryThreshold:      DefaultMemoryThreshold,
+		IOThreshold:          DefaultIOThreshold,
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.MinConcurrentQueries < 1 {
+		return errors.New("autotune min-concurrent-queries must be at least 1")
+	} else if c.MaxConcurrentQueries < c.MinConcurrentQueries {
+		return errors.New("autotune max-concurrent-queries must not be less than min-concurrent-queries")
+	} else if c.CPUThreshold <= 0 || c.MemoryThreshold <= 0 || c.IOThreshold <= 0 {
+		return errors.New("autotune thresholds must be greater than zero")
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package autotune
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Load represents the pressure on a system's resources. Each value is a
+// fraction where higher values mean more pressure.
+type Load struct {
+	// The 1 minute load average divided by the number of CPUs.
+	CPU float64
+
+	// The fraction of system memory that is not available.
+	Memory float64
+
+	// The fraction of time some tasks were stalled waiting on I/O.
+	IO float64
+}
+
+// ProcSampler samples the system load from the Linux /proc filesystem.
+// Values that are unavailable, such as I/O pressure on kernels without
+// pressure stall information, are reported as zero.
+type ProcSampler struct {
+	Root string
+}
+
+// NewProcSampler returns a new sampler for /proc.
+func NewProcSampler() *ProcSampler {
+	return &ProcSampler{Root: "/proc"}
+}
+
+// Sample returns the current system load.
+func (s *ProcSampler) Sample() (Load, error) {
+	var l Load
+	var err error
+
+	if l.CPU, err = s.cpu(); err != nil {
+		return Load{}, fmt.Errorf("cpu: %s", err)
+	}
+	if l.Memory, err = s.memory(); err != nil {
+		return Load{}, fmt.Errorf("memory: %s", err)
+	}
+	if l.IO, err = s.io(); err != nil {
+		return Load{}, fmt.Errorf("io: %s", err)
+	}
+	return l, nil
+}
+
+// cpu returns the 1 minute load average per CPU.
+func (s *ProcSampler) cpu() (float64, error) {
+	buf, err := ioutil.ReadFile(s.Root + "/loadavg")
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(string(buf))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("invalid loadavg: %q", buf)
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	return v / float64(runtime.NumCPU()), nil
+}
+
+// memory returns the fraction of memory that isn't available.
+func (s *ProcSampler) memory() (float64, error) {
+	f, err := os.Open(s.Root + "/meminfo")
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var total, available float64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = v
+		case "MemAvailable:":
+			available = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	} else if total == 0 {
+		return 0, nil
+	}
+	return 1 - available/total, nil
+}
+
+// io returns the 10 second average of the "some" I/O stall percentage as a fraction.
+func (s *ProcSampler) io() (float64, error) {
+	buf, err := ioutil.ReadFile(s.Root + "/pressure/io")
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(string(buf), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "some" {
+			continue
+		}
+		for _, f := range fields[1:] {
+			if strings.HasPrefix(f, "avg10=") {
+				v, err := strconv.ParseFloat(strings.TrimPrefix(f, "avg10="), 64)
+				if err != nil {
+					return 0, err
+				}
+				return v / 100, nil
+			}
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,118 @@
+package autotune
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// lowWatermark is the fraction of each threshold that load must drop below
+// before the query limit is raised. Load between the watermark and the
+// threshold holds the limit steady so it doesn't oscillate.
+const lowWatermark = 0.8
+
+// Service adjusts the concurrent query limit based on system load. The limit
+// is halved when any resource is under pressure and raised by one while all
+// resources are idle, staying within the configured bounds.
+type Service struct {
+	QueryLimiter interface {
+		Limit() int
+		SetLimit(limit int)
+	}
+
+	// Sampler returns the current system load.
+	Sampler interface {
+		Sample() (Load, error)
+	}
+
+	config Config
+	wg     sync.WaitGroup
+	done   chan struct{}
+
+	logger *log.Logger
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		Sampler: NewProcSampler(),
+		config:  c,
+		done:    make(chan struct{}),
+		logger:  log.New(os.Stderr, "[autotune] ", log.LstdFlags),
+	}
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	s.logger.Printf("starting query concurrency auto-tuning, limit %d-%d",
+		s.config.MinConcurrentQueries, s.config.MaxConcurrentQueries)
+
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Close stops the service.
+func (s *Service) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return nil
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.logger = l
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.CheckInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			load, err := s.Sampler.Sample()
+			if err != nil {
+				s.logger.Printf("failed to sample system load: %s", err)
+				continue
+			}
+			s.adjust(load)
+		}
+	}
+}
+
+// adjust updates the query limit for the load.
+func (s *Service) adjust(load Load) {
+	c := s.config
+	prev := s.QueryLimiter.Limit()
+
+	// Start from the upper bound if the limiter is unlimited or out of bounds.
+	limit := prev
+	if limit <= 0 || limit > c.MaxConcurrentQueries {
+		limit = c.MaxConcurrentQueries
+	} else if limit < c.MinConcurrentQueries {
+		limit = c.MinConcurrentQueries
+	}
+
+	switch {
+	case load.CPU > c.CPUThreshold || load.Memory > c.MemoryThreshold || load.IO > c.IOThreshold:
+		limit /= 2
+		if limit < c.MinConcurrentQueries {
+			limit = c.MinConcurrentQueries
+		}
+	case load.CPU < c.CPUThreshold*lowWatermark && load.Memory < c.MemoryThreshold*lowWatermark && load.IO < c.IOThreshold*lowWatermark:
+		if limit < c.MaxConcurrentQueries {
+			limit++
+		}
+	}
+
+	if limit != prev {
+		s.QueryLimiter.SetLimit(limit)
+		s.logger.Printf("concurrent query limit changed from %d to %d (cpu=%.2f, memory=%.2f, io=%.2f)",
+			prev, limit, load.CPU, load.Memory, load.IO)
+	}
+}
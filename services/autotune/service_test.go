@@ -0,0 +1,90 @@
+package autotune
+
+import (
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Ensure the query limit is lowered under pressure and raised when idle.
+func TestService_Adjust(t *testing.T) {
+	s := NewService(Config{
+		MinConcurrentQueries: 2,
+		MaxConcurrentQueries: 8,
+		CPUThreshold:         0.9,
+		MemoryThreshold:      0.9,
+		IOThreshold:          0.3,
+	})
+	s.SetLogger(log.New(ioutil.Discard, "", 0))
+	limiter := &QueryLimiter{}
+	s.QueryLimiter = limiter
+
+	for i, tt := range []struct {
+		load  Load
+		limit int
+	}{
+		{load: Load{}, limit: 8},                  // unlimited starts at the upper bound
+		{load: Load{CPU: 1.5}, limit: 4},          // cpu pressure halves the limit
+		{load: Load{IO: 0.5}, limit: 2},           // io pressure halves the limit
+		{load: Load{Memory: 0.95}, limit: 2},      // limit never drops below the lower bound
+		{load: Load{CPU: 0.8}, limit: 2},          // load near the threshold holds the limit
+		{load: Load{CPU: 0.1, IO: 0.1}, limit: 3}, // idle raises the limit by one
+		{load: Load{}, limit: 4},
+	} {
+		s.adjust(tt.load)
+		if limiter.limit != tt.limit {
+			t.Fatalf("%d. unexpected limit: %d, exp %d", i, limiter.limit, tt.limit)
+		}
+	}
+}
+
+// Ensure system load can be read from the /proc filesystem.
+func TestProcSampler_Sample(t *testing.T) {
+	root, err := ioutil.TempDir("", "autotune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	mustWriteFile(t, filepath.Join(root, "loadavg"), "0.00 1.50 2.00 1/100 1234\n")
+	mustWriteFile(t, filepath.Join(root, "meminfo"), "MemTotal:       1000 kB\nMemFree:         100 kB\nMemAvailable:    250 kB\n")
+	if err := os.Mkdir(filepath.Join(root, "pressure"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	mustWriteFile(t, filepath.Join(root, "pressure", "io"), "some avg10=12.50 avg60=1.00 avg300=0.00 total=100\nfull avg10=5.00 avg60=0.00 avg300=0.00 total=50\n")
+
+	l, err := (&ProcSampler{Root: root}).Sample()
+	if err != nil {
+		t.Fatal(err)
+	} else if l.CPU != 0 {
+		t.Fatalf("unexpected cpu: %v", l.CPU)
+	} else if math.Abs(l.Memory-0.75) > 1e-9 {
+		t.Fatalf("unexpected memory: %v", l.Memory)
+	} else if math.Abs(l.IO-0.125) > 1e-9 {
+		t.Fatalf("unexpected io: %v", l.IO)
+	}
+
+	// Missing files report no pressure.
+	if l, err := (&ProcSampler{Root: filepath.Join(root, "missing")}).Sample(); err != nil {
+		t.Fatal(err)
+	} else if l != (Load{}) {
+		t.Fatalf("unexpected load: %+v", l)
+	}
+}
+
+// QueryLimiter is a mock implementation of Service.QueryLimiter.
+type QueryLimiter struct {
+	limit int
+}
+
+func (l *QueryLimiter) Limit() int         { return l.limit }
+func (l *QueryLimiter) SetLimit(limit int) { l.limit = limit }
+
+func mustWriteFile(t *testing.T, path, s string) {
+	if err := ioutil.WriteFile(path, []byte(s), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -25,6 +25,7 @@ type pointsWriter interface {
 type metaStore interface {
 	WaitForLeader(d time.Duration) error
 	CreateDatabaseIfNotExists(name string) (*meta.DatabaseInfo, error)
+	Watch() <-chan struct{}
 }
 
 // Service represents a UDP server which receives metrics in collectd's binary
@@ -70,6 +71,7 @@ func (s *Service) Open() error {
 		return err
 	}
 
+	changed := s.MetaStore.Watch()
 	if _, err := s.MetaStore.CreateDatabaseIfNotExists(s.Config.Database); err != nil {
 		s.Logger.Printf("failed to ensure target database %s exists: %s", s.Config.Database, err.Error())
 		return err
@@ -104,11 +106,12 @@ func (s *Service) Open() error {
 
 	// Create channel and wait group for signalling goroutines to stop.
 	s.stop = make(chan struct{})
-	s.wg.Add(2)
+	s.wg.Add(3)
 
 	// Start goroutines that process collectd packets.
 	go s.serve()
 	go s.writePoints()
+	go s.watchDatabase(changed)
 
 	s.Logger.Println("collectd UDP started")
 
@@ -131,6 +134,24 @@ func (s *Service) Close() error {
 	return nil
 }
 
+// watchDatabase recreates the target database if it is dropped while the
+// service is running. Otherwise writes would fail until a restart.
+func (s *Service) watchDatabase(changed <-chan struct{}) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-changed:
+		}
+
+		changed = s.MetaStore.Watch()
+		if _, err := s.MetaStore.CreateDatabaseIfNotExists(s.Config.Database); err != nil {
+			s.Logger.Printf("failed to ensure target database %s exists: %s", s.Config.Database, err.Error())
+		}
+	}
+}
+
 // SetLogger sets the internal logger to the logger passed in.
 func (s *Service) SetLogger(l *log.Logger) {
 	s.Logger = l
@@ -229,6 +229,8 @@ func (ms *testMetaStore) WaitForLeader(d time.Duration) error {
 	return nil
 }
 
+func (ms *testMetaStore) Watch() <-chan struct{} { return nil }
+
 func wait(c chan struct{}, d time.Duration) (err error) {
 	select {
 	case <-c:
@@ -229,6 +229,28 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 		startTime = startTime.Add(-interval)
 	}
 
+	recomputeNoOlderThan := time.Duration(s.Config.RecomputeNoOlderThan)
+
+	// Series functions such as outlier() are computed across the intervals
+	// of a query, so the current interval and the ones being recomputed are
+	// queried together rather than one at a time.
+	if cq.q.HasSeriesFunc() {
+		windowStart := startTime
+		for i := 0; i < s.Config.RecomputePreviousN && now.Sub(windowStart) <= recomputeNoOlderThan; i++ {
+			windowStart = windowStart.Add(-interval)
+		}
+
+		if err := cq.q.SetTimeRange(windowStart, startTime.Add(interval)); err != nil {
+			s.Logger.Printf("error setting time range: %s\n", err)
+			return err
+		}
+		if err := s.runContinuousQueryAndWriteResult(cq); err != nil {
+			s.Logger.Printf("error: %s. running: %s\n", err, cq.q.String())
+			return err
+		}
+		return nil
+	}
+
 	if err := cq.q.SetTimeRange(startTime, startTime.Add(interval)); err != nil {
 		s.Logger.Printf("error setting time range: %s\n", err)
 	}
@@ -239,8 +261,6 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 		return err
 	}
 
-	recomputeNoOlderThan := time.Duration(s.Config.RecomputeNoOlderThan)
-
 	for i := 0; i < s.Config.RecomputePreviousN; i++ {
 		// if we're already more time past the previous window than we're going to look back, stop
 		if now.Sub(startTime) > recomputeNoOlderThan {
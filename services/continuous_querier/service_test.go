@@ -61,6 +61,32 @@ func TestExecuteContinuousQuery_HappyPath(t *testing.T) {
 	}
 }
 
+// Ensure a CQ with a series function queries the current and recomputed
+// intervals at once so the function is computed across them.
+func TestExecuteContinuousQuery_SeriesFunc(t *testing.T) {
+	s := NewTestService(t)
+	s.MetaStore.(*MetaStore).CreateContinuousQuery("db", "cq_outlier", `SELECT outlier(mean(value), 2) INTO cpu_outlier FROM cpu WHERE time > now() - 1h GROUP BY time(1m)`)
+	dbis, _ := s.MetaStore.Databases()
+	dbi := dbis[0]
+	cqi := dbi.ContinuousQueries[1]
+
+	var ranges []time.Duration
+	qe := s.QueryExecutor.(*QueryExecutor)
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int) (<-chan *influxql.Result, error) {
+		min, max := influxql.TimeRange(query.Statements[0].(*influxql.SelectStatement).Condition)
+		ranges = append(ranges, max.Sub(min))
+		return nil, nil
+	}
+
+	if err := s.ExecuteContinuousQuery(&dbi, &cqi); err != nil {
+		t.Fatal(err)
+	} else if len(ranges) != 1 {
+		t.Fatalf("unexpected query count: %d", len(ranges))
+	} else if exp := time.Duration(s.Config.RecomputePreviousN+1) * time.Minute; ranges[0] <= exp-time.Second || ranges[0] > exp {
+		t.Fatalf("unexpected time range: exp=%s, got=%s", exp, ranges[0])
+	}
+}
+
 // Test the service happy path.
 func TestService_HappyPath(t *testing.T) {
 	s := NewTestService(t)
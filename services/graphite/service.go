@@ -46,6 +46,7 @@ type Service struct {
 	MetaStore interface {
 		WaitForLeader(d time.Duration) error
 		CreateDatabaseIfNotExists(name string) (*meta.DatabaseInfo, error)
+		Watch() <-chan struct{}
 	}
 }
 
@@ -85,6 +86,7 @@ func (s *Service) Open() error {
 		return err
 	}
 
+	changed := s.MetaStore.Watch()
 	if _, err := s.MetaStore.CreateDatabaseIfNotExists(s.database); err != nil {
 		s.logger.Printf("failed to ensure target database %s exists: %s", s.database, err.Error())
 		return err
@@ -103,18 +105,39 @@ func (s *Service) Open() error {
 		return err
 	}
 
+	s.wg.Add(1)
+	go s.watchDatabase(changed)
+
 	s.logger.Printf("%s Graphite input opened on %s", s.protocol, s.addr.String())
 	return nil
 }
 
+// watchDatabase recreates the target database if it is dropped while the
+// service is running. Otherwise writes would fail until a restart.
+func (s *Service) watchDatabase(changed <-chan struct{}) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-changed:
+		}
+
+		changed = s.MetaStore.Watch()
+		if _, err := s.MetaStore.CreateDatabaseIfNotExists(s.database); err != nil {
+			s.logger.Printf("failed to ensure target database %s exists: %s", s.database, err.Error())
+		}
+	}
+}
+
 // Close stops all data processing on the Graphite input.
 func (s *Service) Close() error {
 	close(s.done)
-	s.wg.Wait()
-	s.done = nil
 	if s.ln != nil {
 		s.ln.Close()
 	}
+	s.wg.Wait()
+	s.done = nil
 	return nil
 }
 
@@ -292,8 +315,10 @@ func (p *Parser) Parse(line string) (tsdb.Point, error) {
 		return nil, fmt.Errorf("field \"%s\" time: %s", fields[0], err)
 	}
 
-	// Check if we have fractional seconds
-	timestamp := time.Unix(int64(unixTime), int64((unixTime-math.Floor(unixTime))*float64(time.Second)))
+	// Fractional seconds are rounded to the microsecond so float error
+	// doesn't put a point sent at the same time by two writers a nanosecond
+	// apart.
+	timestamp := time.Unix(0, int64(math.Floor(unixTime*1e6+0.5))*int64(time.Microsecond))
 
 	point := tsdb.NewPoint(name, tags, fieldValues, timestamp)
 
@@ -2,6 +2,8 @@ package graphite_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"log"
 	"net"
 	"reflect"
 	"strconv"
@@ -230,6 +232,16 @@ func Test_DecodeMetric(t *testing.T) {
 	}
 }
 
+// Ensure fractional seconds are parsed without float error.
+func Test_DecodeMetric_FractionalTime(t *testing.T) {
+	point, err := graphite.NewParser().Parse(`cpu 50 1435077219.123`)
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := time.Unix(1435077219, 123000000); !point.Time().Equal(exp) {
+		t.Fatalf("time value mismatch.  expected %v, got %v", exp.UnixNano(), point.Time().UnixNano())
+	}
+}
+
 func Test_ServerGraphiteTCP(t *testing.T) {
 	t.Parallel()
 
@@ -375,6 +387,60 @@ func Test_ServerGraphiteUDP(t *testing.T) {
 	conn.Close()
 }
 
+// Ensure the target database is recreated when it is dropped.
+func Test_ServerGraphite_RecreateDatabase(t *testing.T) {
+	t.Parallel()
+
+	config := graphite.NewConfig()
+	config.Database = "graphitedb"
+	config.BindAddress = "127.0.0.1:0"
+	config.Protocol = "tcp"
+
+	service, err := graphite.NewService(config)
+	if err != nil {
+		t.Fatalf("failed to create Graphite service: %s", err.Error())
+	}
+	service.SetLogger(log.New(ioutil.Discard, "", 0))
+
+	var mu sync.Mutex
+	changed := make(chan struct{})
+	created := make(chan string, 2)
+	service.MetaStore = &MetaStore{
+		CreateDatabaseIfNotExistsFn: func(name string) (*meta.DatabaseInfo, error) {
+			created <- name
+			return nil, nil
+		},
+		WatchFn: func() <-chan struct{} {
+			mu.Lock()
+			defer mu.Unlock()
+			return changed
+		},
+	}
+
+	if err := service.Open(); err != nil {
+		t.Fatalf("failed to open Graphite service: %s", err.Error())
+	}
+	defer service.Close()
+	if name := <-created; name != "graphitedb" {
+		t.Fatalf("unexpected database created: %s", name)
+	}
+
+	// Notify the service that the metadata changed.
+	mu.Lock()
+	close(changed)
+	changed = make(chan struct{})
+	mu.Unlock()
+
+	select {
+	case name := <-created:
+		if name != "graphitedb" {
+			t.Fatalf("unexpected database created: %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for database to be recreated")
+	}
+}
+
 // PointsWriter represents a mock impl of PointsWriter.
 type PointsWriter struct {
 	WritePointsFn func(*cluster.WritePointsRequest) error
@@ -397,6 +463,22 @@ func (d *DatabaseCreator) WaitForLeader(t time.Duration) error {
 	return nil
 }
 
+func (d *DatabaseCreator) Watch() <-chan struct{} { return nil }
+
+// MetaStore represents a mock impl of the service's MetaStore.
+type MetaStore struct {
+	CreateDatabaseIfNotExistsFn func(name string) (*meta.DatabaseInfo, error)
+	WatchFn                     func() <-chan struct{}
+}
+
+func (ms *MetaStore) WaitForLeader(d time.Duration) error { return nil }
+
+func (ms *MetaStore) CreateDatabaseIfNotExists(name string) (*meta.DatabaseInfo, error) {
+	return ms.CreateDatabaseIfNotExistsFn(name)
+}
+
+func (ms *MetaStore) Watch() <-chan struct{} { return ms.WatchFn() }
+
 // Test Helpers
 func errstr(err error) string {
 	if err != nil {
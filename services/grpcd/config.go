@@ -0,0 +1,61 @@
+package grpcd
+
+//go:generate protoc --gogo_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. grpcpb/grpcpb.proto
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+)
+
+const (
+	// DefaultBindAddress is the default address that the service binds to.
+	DefaultBindAddress = ":8082"
+)
+
+type Config struct {
+	Enabled     bool   `toml:"enabled"`
+	BindAddress string `toml:"bind-address"`
+	AuthEnabled bool   `toml:"auth-enabled"`
+
+	// TLS for connections from clients. Credentials are sent in each
+	// request so TLS is required when auth-enabled is set.
+	TLSEnabled     bool   `toml:"tls-enabled"`
+	TLSCertificate string `toml:"tls-certificate"`
+	TLSPrivateKey  string `toml:"tls-private-key"`
+}
+
+func NewConfig() Config {
+	return Config{
+		BindAddress: DefaultBindAddress,
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	} else if c.AuthEnabled && !c.TLSEnabled {
+		return errors.New("tls-enabled is required when auth-enabled is set")
+	} else if c.TLSEnabled && (c.TLSCertificate == "" || c.TLSPrivateKey == "") {
+		return errors.New("tls-certificate and tls-private-key are required when tls-enabled is set")
+	}
+	return nil
+}
+
+// TLSConfig returns the TLS configuration for connections from clients.
+// Returns nil if TLS is not enabled.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if !c.TLSEnabled {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.TLSCertificate, c.TLSPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("load tls certificate: %s", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package grpcd_test
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdb/influxdb/services/grpcd"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c grpcd.Config
+	if _, err := toml.Decode(`
+enabled = true
+bind-address = ":9000"
+auth-enabled = true
+tls-enabled = true
+tls-certificate = "/etc/ssl/grpc.pem"
+tls-private-key = "/etc/ssl/grpc-key.pem"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if c.Enabled != true {
+		t.Fatalf("unexpected enabled: %v", c.Enabled)
+	} else if c.BindAddress != ":9000" {
+		t.Fatalf("unexpected bind address: %s", c.BindAddress)
+	} else if c.AuthEnabled != true {
+		t.Fatalf("unexpected auth enabled: %v", c.AuthEnabled)
+	} else if !c.TLSEnabled {
+		t.Fatalf("unexpected tls enabled: %v", c.TLSEnabled)
+	} else if c.TLSCertificate != "/etc/ssl/grpc.pem" {
+		t.Fatalf("unexpected tls certificate: %s", c.TLSCertificate)
+	} else if c.TLSPrivateKey != "/etc/ssl/grpc-key.pem" {
+		t.Fatalf("unexpected tls private key: %s", c.TLSPrivateKey)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := grpcd.NewConfig()
+	c.Enabled = true
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Credentials are only accepted over TLS.
+	c.AuthEnabled = true
+	if err := c.Validate(); err == nil || err.Error() != "tls-enabled is required when auth-enabled is set" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// TLS requires a certificate and key.
+	c.TLSEnabled = true
+	if err := c.Validate(); err == nil || err.Error() != "tls-certificate and tls-private-key are required when tls-enabled is set" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.TLSCertificate = "/etc/ssl/grpc.pem"
+	c.TLSPrivateKey = "/etc/ssl/grpc-key.pem"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,392 @@
+// Code generated by protoc-gen-gogo.
+// source: grpcpb.proto
+// DO NOT EDIT!
+
+/*
+Package grpcpb is a generated protocol buffer package.
+
+It is generated from these files:
+
+	grpcpb.proto
+
+It has these top-level messages:
+
+	WritePointsRequest
+	WritePointsResponse
+	Point
+	Tag
+	Field
+	QueryRequest
+	QueryResponse
+	Row
+	Values
+	Value
+*/
+package grpcpb
+
+import proto "github.com/gogo/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type WritePointsRequest struct {
+	Database         *string  `protobuf:"bytes,1,req" json:"Database,omitempty"`
+	RetentionPolicy  *string  `protobuf:"bytes,2,opt" json:"RetentionPolicy,omitempty"`
+	ConsistencyLevel *string  `protobuf:"bytes,3,opt" json:"ConsistencyLevel,omitempty"`
+	Points           []*Point `protobuf:"bytes,4,rep" json:"Points,omitempty"`
+	Username         *string  `protobuf:"bytes,5,opt" json:"Username,omitempty"`
+	Password         *string  `protobuf:"bytes,6,opt" json:"Password,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *WritePointsRequest) Reset()         { *m = WritePointsRequest{} }
+func (m *WritePointsRequest) String() string { return proto.CompactTextString(m) }
+func (*WritePointsRequest) ProtoMessage()    {}
+
+func (m *WritePointsRequest) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *WritePointsRequest) GetRetentionPolicy() string {
+	if m != nil && m.RetentionPolicy != nil {
+		return *m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *WritePointsRequest) GetConsistencyLevel() string {
+	if m != nil && m.ConsistencyLevel != nil {
+		return *m.ConsistencyLevel
+	}
+	return ""
+}
+
+func (m *WritePointsRequest) GetPoints() []*Point {
+	if m != nil {
+		return m.Points
+	}
+	return nil
+}
+
+func (m *WritePointsRequest) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *WritePointsRequest) GetPassword() string {
+	if m != nil && m.Password != nil {
+		return *m.Password
+	}
+	return ""
+}
+
+type WritePointsResponse struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *WritePointsResponse) Reset()         { *m = WritePointsResponse{} }
+func (m *WritePointsResponse) String() string { return proto.CompactTextString(m) }
+func (*WritePointsResponse) ProtoMessage()    {}
+
+type Point struct {
+	Name             *string  `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Time             *int64   `protobuf:"varint,2,opt" json:"Time,omitempty"`
+	Tags             []*Tag   `protobuf:"bytes,3,rep" json:"Tags,omitempty"`
+	Fields           []*Field `protobuf:"bytes,4,rep" json:"Fields,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *Point) Reset()         { *m = Point{} }
+func (m *Point) String() string { return proto.CompactTextString(m) }
+func (*Point) ProtoMessage()    {}
+
+func (m *Point) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *Point) GetTime() int64 {
+	if m != nil && m.Time != nil {
+		return *m.Time
+	}
+	return 0
+}
+
+func (m *Point) GetTags() []*Tag {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Point) GetFields() []*Field {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+type Tag struct {
+	Key              *string `protobuf:"bytes,1,req" json:"Key,omitempty"`
+	Value            *string `protobuf:"bytes,2,req" json:"Value,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Tag) Reset()         { *m = Tag{} }
+func (m *Tag) String() string { return proto.CompactTextString(m) }
+func (*Tag) ProtoMessage()    {}
+
+func (m *Tag) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *Tag) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
+
+type Field struct {
+	Name             *string  `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Int64            *int64   `protobuf:"varint,2,opt" json:"Int64,omitempty"`
+	Float64          *float64 `protobuf:"fixed64,3,opt" json:"Float64,omitempty"`
+	Bool             *bool    `protobuf:"varint,4,opt" json:"Bool,omitempty"`
+	String_          *string  `protobuf:"bytes,5,opt" json:"String,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *Field) Reset()         { *m = Field{} }
+func (m *Field) String() string { return proto.CompactTextString(m) }
+func (*Field) ProtoMessage()    {}
+
+func (m *Field) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *Field) GetInt64() int64 {
+	if m != nil && m.Int64 != nil {
+		return *m.Int64
+	}
+	return 0
+}
+
+func (m *Field) GetFloat64() float64 {
+	if m != nil && m.Float64 != nil {
+		return *m.Float64
+	}
+	return 0
+}
+
+func (m *Field) GetBool() bool {
+	if m != nil && m.Bool != nil {
+		return *m.Bool
+	}
+	return false
+}
+
+func (m *Field) GetString_() string {
+	if m != nil && m.String_ != nil {
+		return *m.String_
+	}
+	return ""
+}
+
+type QueryRequest struct {
+	Query            *string `protobuf:"bytes,1,req" json:"Query,omitempty"`
+	Database         *string `protobuf:"bytes,2,opt" json:"Database,omitempty"`
+	ChunkSize        *uint32 `protobuf:"varint,3,opt" json:"ChunkSize,omitempty"`
+	Username         *string `protobuf:"bytes,4,opt" json:"Username,omitempty"`
+	Password         *string `protobuf:"bytes,5,opt" json:"Password,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *QueryRequest) Reset()         { *m = QueryRequest{} }
+func (m *QueryRequest) String() string { return proto.CompactTextString(m) }
+func (*QueryRequest) ProtoMessage()    {}
+
+func (m *QueryRequest) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
+	}
+	return ""
+}
+
+func (m *QueryRequest) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *QueryRequest) GetChunkSize() uint32 {
+	if m != nil && m.ChunkSize != nil {
+		return *m.ChunkSize
+	}
+	return 0
+}
+
+func (m *QueryRequest) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *QueryRequest) GetPassword() string {
+	if m != nil && m.Password != nil {
+		return *m.Password
+	}
+	return ""
+}
+
+type QueryResponse struct {
+	StatementID      *uint32 `protobuf:"varint,1,req" json:"StatementID,omitempty"`
+	Series           []*Row  `protobuf:"bytes,2,rep" json:"Series,omitempty"`
+	Error            *string `protobuf:"bytes,3,opt" json:"Error,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *QueryResponse) Reset()         { *m = QueryResponse{} }
+func (m *QueryResponse) String() string { return proto.CompactTextString(m) }
+func (*QueryResponse) ProtoMessage()    {}
+
+func (m *QueryResponse) GetStatementID() uint32 {
+	if m != nil && m.StatementID != nil {
+		return *m.StatementID
+	}
+	return 0
+}
+
+func (m *QueryResponse) GetSeries() []*Row {
+	if m != nil {
+		return m.Series
+	}
+	return nil
+}
+
+func (m *QueryResponse) GetError() string {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ""
+}
+
+type Row struct {
+	Name             *string   `protobuf:"bytes,1,opt" json:"Name,omitempty"`
+	Tags             []*Tag    `protobuf:"bytes,2,rep" json:"Tags,omitempty"`
+	Columns          []string  `protobuf:"bytes,3,rep" json:"Columns,omitempty"`
+	Values           []*Values `protobuf:"bytes,4,rep" json:"Values,omitempty"`
+	XXX_unrecognized []byte    `json:"-"`
+}
+
+func (m *Row) Reset()         { *m = Row{} }
+func (m *Row) String() string { return proto.CompactTextString(m) }
+func (*Row) ProtoMessage()    {}
+
+func (m *Row) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *Row) GetTags() []*Tag {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Row) GetColumns() []string {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+func (m *Row) GetValues() []*Values {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type Values struct {
+	Values           []*Value `protobuf:"bytes,1,rep" json:"Values,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *Values) Reset()         { *m = Values{} }
+func (m *Values) String() string { return proto.CompactTextString(m) }
+func (*Values) ProtoMessage()    {}
+
+func (m *Values) GetValues() []*Value {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type Value struct {
+	Int64            *int64   `protobuf:"varint,1,opt" json:"Int64,omitempty"`
+	Float64          *float64 `protobuf:"fixed64,2,opt" json:"Float64,omitempty"`
+	Bool             *bool    `protobuf:"varint,3,opt" json:"Bool,omitempty"`
+	String_          *string  `protobuf:"bytes,4,opt" json:"String,omitempty"`
+	Time             *int64   `protobuf:"varint,5,opt" json:"Time,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *Value) Reset()         { *m = Value{} }
+func (m *Value) String() string { return proto.CompactTextString(m) }
+func (*Value) ProtoMessage()    {}
+
+func (m *Value) GetInt64() int64 {
+	if m != nil && m.Int64 != nil {
+		return *m.Int64
+	}
+	return 0
+}
+
+func (m *Value) GetFloat64() float64 {
+	if m != nil && m.Float64 != nil {
+		return *m.Float64
+	}
+	return 0
+}
+
+func (m *Value) GetBool() bool {
+	if m != nil && m.Bool != nil {
+		return *m.Bool
+	}
+	return false
+}
+
+func (m *Value) GetString_() string {
+	if m != nil && m.String_ != nil {
+		return *m.String_
+	}
+	return ""
+}
+
+func (m *Value) GetTime() int64 {
+	if m != nil && m.Time != nil {
+		return *m.Time
+	}
+	return 0
+}
@@ -0,0 +1,367 @@
+//go:build grpc
+// +build grpc
+
+package grpcd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/influxdb/influxdb"
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/services/grpcd/grpcpb"
+	"github.com/influxdb/influxdb/tsdb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultChunkSize is the maximum number of values in each row returned by
+// a query when the request doesn't set a chunk size.
+const DefaultChunkSize = 10000
+
+// Service serves writes and queries over gRPC. The protocol is described
+// by grpcpb/grpcpb.proto.
+type Service struct {
+	grpcpb.UnimplementedInfluxDBServer
+
+	ln     net.Listener
+	addr   string
+	err    chan error
+	server *grpc.Server
+	tls    *tls.Config
+
+	requireAuthentication bool
+
+	MetaStore interface {
+		Database(name string) (*meta.DatabaseInfo, error)
+		Authenticate(username, password string) (*meta.UserInfo, error)
+		Users() ([]meta.UserInfo, error)
+	}
+
+	QueryExecutor interface {
+		Authorize(u *meta.UserInfo, q *influxql.Query, db string) error
+		ExecuteQuery(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error)
+	}
+
+	PointsWriter interface {
+		WritePoints(p *cluster.WritePointsRequest) error
+	}
+
+	Logger *log.Logger
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) (*Service, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		addr: c.BindAddress,
+		err:  make(chan error),
+		tls:  tlsConfig,
+
+		requireAuthentication: c.AuthEnabled,
+
+		Logger: log.New(os.Stderr, "[grpc] ", log.LstdFlags),
+	}, nil
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	// Open listener.
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+
+	var opts []grpc.ServerOption
+	if s.tls != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tls)))
+	}
+	s.server = grpc.NewServer(opts...)
+	grpcpb.RegisterInfluxDBServer(s.server, s)
+
+	s.Logger.Println("listening on gRPC:", ln.Addr().String())
+
+	// Begin serving requests in a separate goroutine.
+	go s.serve()
+	return nil
+}
+
+// Close stops the server and closes the underlying listener.
+func (s *Service) Close() error {
+	if s.server != nil {
+		s.server.Stop()
+	}
+	return nil
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.Logger = l
+}
+
+// Err returns a channel for fatal errors that occur on the listener.
+func (s *Service) Err() <-chan error { return s.err }
+
+// Addr returns the listener's address. Returns nil if listener is closed.
+func (s *Service) Addr() net.Addr {
+	if s.ln != nil {
+		return s.ln.Addr()
+	}
+	return nil
+}
+
+// serve serves requests from the listener.
+func (s *Service) serve() {
+	err := s.server.Serve(s.ln)
+	if err != nil && !strings.Contains(err.Error(), "closed") {
+		s.err <- fmt.Errorf("listener failed: addr=%s, err=%s", s.Addr(), err)
+	}
+}
+
+// WritePoints writes a batch of points to a database.
+func (s *Service) WritePoints(ctx context.Context, req *grpcpb.WritePointsRequest) (*grpcpb.WritePointsResponse, error) {
+	user, err := s.authenticate(req.GetUsername(), req.GetPassword())
+	if err != nil {
+		return nil, err
+	}
+
+	database := req.GetDatabase()
+	if database == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "database is required")
+	}
+
+	if di, err := s.MetaStore.Database(database); err != nil {
+		return nil, status.Errorf(codes.Internal, "metastore database error: %s", err)
+	} else if di == nil {
+		return nil, status.Errorf(codes.NotFound, "database not found: %q", database)
+	}
+
+	if s.requireAuthentication && user == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user is required to write to database %q", database)
+	} else if s.requireAuthentication && !user.Authorize(influxql.WritePrivilege, database) {
+		return nil, status.Errorf(codes.PermissionDenied, "%q user is not authorized to write to database %q", user.Name, database)
+	}
+
+	consistency := cluster.ConsistencyLevelOne
+	if req.ConsistencyLevel != nil {
+		l, err := cluster.ParseConsistencyLevel(req.GetConsistencyLevel())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+		}
+		consistency = l
+	}
+
+	points := make([]tsdb.Point, len(req.GetPoints()))
+	now := time.Now().UTC()
+	for i, p := range req.GetPoints() {
+		pt, err := decodePoint(p, now)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+		}
+		points[i] = pt
+	}
+
+	if err := s.PointsWriter.WritePoints(&cluster.WritePointsRequest{
+		Database:         database,
+		RetentionPolicy:  req.GetRetentionPolicy(),
+		ConsistencyLevel: consistency,
+		Points:           points,
+	}); influxdb.IsClientError(err) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "%s", err)
+	}
+
+	return &grpcpb.WritePointsResponse{}, nil
+}
+
+// ExecuteQuery executes a query and streams back its results as they're
+// produced. Statement errors are returned in the results; the call only
+// fails if the query can't be run at all.
+func (s *Service) ExecuteQuery(req *grpcpb.QueryRequest, stream grpcpb.InfluxDB_ExecuteQueryServer) error {
+	user, err := s.authenticate(req.GetUsername(), req.GetPassword())
+	if err != nil {
+		return err
+	}
+
+	q, err := influxql.ParseQuery(req.GetQuery())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "error parsing query: %s", err)
+	}
+
+	if s.requireAuthentication {
+		if err := s.QueryExecutor.Authorize(user, q, req.GetDatabase()); err != nil {
+			return status.Errorf(codes.PermissionDenied, "%s", err)
+		}
+	}
+
+	chunkSize := DefaultChunkSize
+	if n := req.GetChunkSize(); n > 0 {
+		chunkSize = int(n)
+	}
+
+	results, err := s.QueryExecutor.ExecuteQuery(q, req.GetDatabase(), chunkSize)
+	if _, ok := err.(meta.AuthError); ok {
+		return status.Errorf(codes.PermissionDenied, "%s", err)
+	} else if err != nil {
+		return status.Errorf(codes.Internal, "%s", err)
+	}
+
+	for r := range results {
+		// Ignore nil results.
+		if r == nil {
+			continue
+		}
+
+		if err := stream.Send(encodeResult(r)); err != nil {
+			// Drain the remaining results so the executor can finish.
+			go func() {
+				for range results {
+				}
+			}()
+			return err
+		}
+	}
+	return nil
+}
+
+// authenticate returns the user with the given credentials. Returns a nil
+// user if authentication is disabled or no users exist yet.
+func (s *Service) authenticate(username, password string) (*meta.UserInfo, error) {
+	if !s.requireAuthentication {
+		return nil, nil
+	}
+
+	uis, err := s.MetaStore.Users()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%s", err)
+	} else if len(uis) == 0 {
+		return nil, nil
+	}
+
+	if username == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "username required")
+	}
+	ui, err := s.MetaStore.Authenticate(username, password)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+	}
+	return ui, nil
+}
+
+// decodePoint converts a protobuf point into a point. Points without a
+// time are given now.
+func decodePoint(p *grpcpb.Point, now time.Time) (tsdb.Point, error) {
+	if p.GetName() == "" {
+		return nil, fmt.Errorf("point name is required")
+	}
+
+	tags := make(tsdb.Tags, len(p.GetTags()))
+	for _, t := range p.GetTags() {
+		tags[t.GetKey()] = t.GetValue()
+	}
+
+	fields := make(tsdb.Fields, len(p.GetFields()))
+	for _, f := range p.GetFields() {
+		switch {
+		case f.Int64 != nil:
+			fields[f.GetName()] = f.GetInt64()
+		case f.Float64 != nil:
+			fields[f.GetName()] = f.GetFloat64()
+		case f.Bool != nil:
+			fields[f.GetName()] = f.GetBool()
+		case f.String_ != nil:
+			fields[f.GetName()] = f.GetString_()
+		default:
+			return nil, fmt.Errorf("field %q of %q has no value", f.GetName(), p.GetName())
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("point %q has no fields", p.GetName())
+	}
+
+	t := now
+	if p.Time != nil {
+		t = time.Unix(0, p.GetTime()).UTC()
+	}
+	return tsdb.NewPoint(p.GetName(), tags, fields, t), nil
+}
+
+// encodeResult converts a query result into its protobuf representation.
+func encodeResult(r *influxql.Result) *grpcpb.QueryResponse {
+	resp := &grpcpb.QueryResponse{StatementID: proto.Uint32(uint32(r.StatementID))}
+	if r.Err != nil {
+		resp.Error = proto.String(r.Err.Error())
+	}
+
+	for _, row := range r.Series {
+		pr := &grpcpb.Row{Columns: row.Columns}
+		if row.Name != "" {
+			pr.Name = proto.String(row.Name)
+		}
+		for _, k := range sortedKeys(row.Tags) {
+			pr.Tags = append(pr.Tags, &grpcpb.Tag{Key: proto.String(k), Value: proto.String(row.Tags[k])})
+		}
+		for _, values := range row.Values {
+			pv := &grpcpb.Values{Values: make([]*grpcpb.Value, len(values))}
+			for i, v := range values {
+				pv.Values[i] = encodeValue(v)
+			}
+			pr.Values = append(pr.Values, pv)
+		}
+		resp.Series = append(resp.Series, pr)
+	}
+	return resp
+}
+
+// encodeValue converts a value of a row into its protobuf representation.
+// Types without a protobuf equivalent are encoded as strings.
+func encodeValue(v interface{}) *grpcpb.Value {
+	switch v := v.(type) {
+	case nil:
+		return &grpcpb.Value{}
+	case float64:
+		return &grpcpb.Value{Float64: proto.Float64(v)}
+	case int64:
+		return &grpcpb.Value{Int64: proto.Int64(v)}
+	case int:
+		return &grpcpb.Value{Int64: proto.Int64(int64(v))}
+	case uint64:
+		return &grpcpb.Value{Int64: proto.Int64(int64(v))}
+	case bool:
+		return &grpcpb.Value{Bool: proto.Bool(v)}
+	case string:
+		return &grpcpb.Value{String_: proto.String(v)}
+	case time.Time:
+		return &grpcpb.Value{Time: proto.Int64(v.UnixNano())}
+	default:
+		return &grpcpb.Value{String_: proto.String(fmt.Sprint(v))}
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys(m map[string]string) []string {
+	a := make([]string, 0, len(m))
+	for k := range m {
+		a = append(a, k)
+	}
+	sort.Strings(a)
+	return a
+}
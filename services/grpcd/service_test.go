@@ -0,0 +1,387 @@
+//go:build grpc
+// +build grpc
+
+package grpcd_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gogo/protobuf/proto"
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/services/grpcd"
+	"github.com/influxdb/influxdb/services/grpcd/grpcpb"
+	"github.com/influxdb/influxdb/tsdb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// Ensure points can be written.
+func TestService_WritePoints(t *testing.T) {
+	s := NewService(false)
+	s.PointsWriter.WritePointsFn = func(req *cluster.WritePointsRequest) error {
+		if req.Database != "db0" {
+			t.Fatalf("unexpected database: %s", req.Database)
+		} else if req.RetentionPolicy != "rp0" {
+			t.Fatalf("unexpected retention policy: %s", req.RetentionPolicy)
+		} else if req.ConsistencyLevel != cluster.ConsistencyLevelAll {
+			t.Fatalf("unexpected consistency level: %d", req.ConsistencyLevel)
+		} else if !reflect.DeepEqual(req.Points, []tsdb.Point{
+			tsdb.NewPoint("cpu", tsdb.Tags{"host": "server01"}, tsdb.Fields{"value": 1.5, "count": int64(2)}, time.Unix(0, 100).UTC()),
+		}) {
+			t.Fatalf("unexpected points: %s", spew.Sdump(req.Points))
+		}
+		return nil
+	}
+
+	if _, err := s.WritePoints(context.Background(), &grpcpb.WritePointsRequest{
+		Database:         proto.String("db0"),
+		RetentionPolicy:  proto.String("rp0"),
+		ConsistencyLevel: proto.String("all"),
+		Points: []*grpcpb.Point{{
+			Name: proto.String("cpu"),
+			Time: proto.Int64(100),
+			Tags: []*grpcpb.Tag{{Key: proto.String("host"), Value: proto.String("server01")}},
+			Fields: []*grpcpb.Field{
+				{Name: proto.String("value"), Float64: proto.Float64(1.5)},
+				{Name: proto.String("count"), Int64: proto.Int64(2)},
+			},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure writing to a database that doesn't exist returns NotFound.
+func TestService_WritePoints_DatabaseNotFound(t *testing.T) {
+	s := NewService(false)
+	s.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) { return nil, nil }
+
+	if _, err := s.WritePoints(context.Background(), &grpcpb.WritePointsRequest{
+		Database: proto.String("db0"),
+	}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a point without fields is rejected.
+func TestService_WritePoints_NoFields(t *testing.T) {
+	s := NewService(false)
+
+	if _, err := s.WritePoints(context.Background(), &grpcpb.WritePointsRequest{
+		Database: proto.String("db0"),
+		Points:   []*grpcpb.Point{{Name: proto.String("cpu")}},
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure writes require a user with write privileges when authentication is enabled.
+func TestService_WritePoints_Auth(t *testing.T) {
+	s := NewService(true)
+	s.MetaStore.UsersFn = func() ([]meta.UserInfo, error) { return []meta.UserInfo{{Name: "susy"}}, nil }
+	s.MetaStore.AuthenticateFn = func(username, password string) (*meta.UserInfo, error) {
+		if username != "susy" || password != "pass" {
+			return nil, errors.New("authentication failed")
+		}
+		return &meta.UserInfo{Name: "susy", Privileges: map[string]influxql.Privilege{"db0": influxql.ReadPrivilege}}, nil
+	}
+
+	req := &grpcpb.WritePointsRequest{Database: proto.String("db0")}
+	if _, err := s.WritePoints(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req.Username, req.Password = proto.String("susy"), proto.String("pass")
+	if _, err := s.WritePoints(context.Background(), req); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure query results are streamed back.
+func TestService_ExecuteQuery(t *testing.T) {
+	s := NewService(false)
+	s.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+		if q.String() != "SELECT value FROM cpu;\nSELECT value FROM mem" {
+			t.Fatalf("unexpected query: %s", q.String())
+		} else if db != "db0" {
+			t.Fatalf("unexpected database: %s", db)
+		} else if chunkSize != grpcd.DefaultChunkSize {
+			t.Fatalf("unexpected chunk size: %d", chunkSize)
+		}
+
+		ch := make(chan *influxql.Result, 2)
+		ch <- &influxql.Result{StatementID: 0, Series: influxql.Rows{{
+			Name:    "cpu",
+			Tags:    map[string]string{"region": "us", "host": "a"},
+			Columns: []string{"time", "value"},
+			Values:  [][]interface{}{{time.Unix(0, 10), 1.5}, {time.Unix(0, 20), nil}},
+		}}}
+		ch <- &influxql.Result{StatementID: 1, Err: errors.New("marker")}
+		close(ch)
+		return ch, nil
+	}
+
+	var stream QueryStream
+	if err := s.ExecuteQuery(&grpcpb.QueryRequest{
+		Query:    proto.String(`SELECT value FROM cpu; SELECT value FROM mem`),
+		Database: proto.String("db0"),
+	}, &stream); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(stream.Responses, []*grpcpb.QueryResponse{
+		{
+			StatementID: proto.Uint32(0),
+			Series: []*grpcpb.Row{{
+				Name: proto.String("cpu"),
+				Tags: []*grpcpb.Tag{
+					{Key: proto.String("host"), Value: proto.String("a")},
+					{Key: proto.String("region"), Value: proto.String("us")},
+				},
+				Columns: []string{"time", "value"},
+				Values: []*grpcpb.Values{
+					{Values: []*grpcpb.Value{{Time: proto.Int64(10)}, {Float64: proto.Float64(1.5)}}},
+					{Values: []*grpcpb.Value{{Time: proto.Int64(20)}, {}}},
+				},
+			}},
+		},
+		{StatementID: proto.Uint32(1), Error: proto.String("marker")},
+	}) {
+		t.Fatalf("unexpected responses: %s", spew.Sdump(stream.Responses))
+	}
+}
+
+// Ensure an invalid query returns InvalidArgument.
+func TestService_ExecuteQuery_ParseError(t *testing.T) {
+	s := NewService(false)
+
+	var stream QueryStream
+	if err := s.ExecuteQuery(&grpcpb.QueryRequest{Query: proto.String(`SELECTZ`)}, &stream); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure points can be written over TLS and credentials aren't accepted
+// over a plaintext connection.
+func TestService_TLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grpcd_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := MustWriteTLSFiles(dir)
+	c.BindAddress = "127.0.0.1:0"
+	c.AuthEnabled = true
+	s := NewServiceWithConfig(c)
+	s.MetaStore.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{{Name: "susy", Admin: true}}, nil
+	}
+	s.MetaStore.AuthenticateFn = func(username, password string) (*meta.UserInfo, error) {
+		if username != "susy" || password != "pass" {
+			t.Fatalf("unexpected credentials: %s/%s", username, password)
+		}
+		return &meta.UserInfo{Name: "susy", Admin: true}, nil
+	}
+	s.PointsWriter.WritePointsFn = func(req *cluster.WritePointsRequest) error { return nil }
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	req := &grpcpb.WritePointsRequest{
+		Database: proto.String("db0"),
+		Username: proto.String("susy"),
+		Password: proto.String("pass"),
+		Points: []*grpcpb.Point{{
+			Name:   proto.String("cpu"),
+			Fields: []*grpcpb.Field{{Name: proto.String("value"), Float64: proto.Float64(1)}},
+		}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Write over TLS.
+	buf, err := ioutil.ReadFile(c.TLSCertificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(buf)
+	conn, err := grpc.Dial(s.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := grpcpb.NewInfluxDBClient(conn).WritePoints(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The server doesn't accept plaintext connections.
+	conn, err = grpc.Dial(s.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := grpcpb.NewInfluxDBClient(conn).WritePoints(ctx, req); status.Code(err) != codes.Unavailable {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Service is a test wrapper for grpcd.Service.
+type Service struct {
+	*grpcd.Service
+	MetaStore     ServiceMetaStore
+	QueryExecutor ServiceQueryExecutor
+	PointsWriter  ServicePointsWriter
+}
+
+// NewService returns a new instance of Service. Database db0 exists.
+func NewService(authEnabled bool) *Service {
+	c := grpcd.NewConfig()
+	c.AuthEnabled = authEnabled
+	return NewServiceWithConfig(c)
+}
+
+// NewServiceWithConfig returns a new instance of Service with a config.
+// Database db0 exists.
+func NewServiceWithConfig(c grpcd.Config) *Service {
+	srv, err := grpcd.NewService(c)
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Service{Service: srv}
+	s.Service.MetaStore = &s.MetaStore
+	s.Service.QueryExecutor = &s.QueryExecutor
+	s.Service.PointsWriter = &s.PointsWriter
+	s.Service.SetLogger(log.New(ioutil.Discard, "", 0))
+
+	s.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		if name != "db0" {
+			return nil, nil
+		}
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	return s
+}
+
+// ServiceMetaStore is a mockable implementation of grpcd.Service.MetaStore.
+type ServiceMetaStore struct {
+	DatabaseFn     func(name string) (*meta.DatabaseInfo, error)
+	AuthenticateFn func(username, password string) (*meta.UserInfo, error)
+	UsersFn        func() ([]meta.UserInfo, error)
+}
+
+func (s *ServiceMetaStore) Database(name string) (*meta.DatabaseInfo, error) {
+	return s.DatabaseFn(name)
+}
+
+func (s *ServiceMetaStore) Authenticate(username, password string) (*meta.UserInfo, error) {
+	return s.AuthenticateFn(username, password)
+}
+
+func (s *ServiceMetaStore) Users() ([]meta.UserInfo, error) {
+	return s.UsersFn()
+}
+
+// ServiceQueryExecutor is a mockable implementation of grpcd.Service.QueryExecutor.
+type ServiceQueryExecutor struct {
+	AuthorizeFn    func(u *meta.UserInfo, q *influxql.Query, db string) error
+	ExecuteQueryFn func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error)
+}
+
+func (e *ServiceQueryExecutor) Authorize(u *meta.UserInfo, q *influxql.Query, db string) error {
+	return e.AuthorizeFn(u, q, db)
+}
+
+func (e *ServiceQueryExecutor) ExecuteQuery(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+	return e.ExecuteQueryFn(q, db, chunkSize)
+}
+
+// ServicePointsWriter is a mockable implementation of grpcd.Service.PointsWriter.
+type ServicePointsWriter struct {
+	WritePointsFn func(p *cluster.WritePointsRequest) error
+}
+
+func (w *ServicePointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
+	return w.WritePointsFn(p)
+}
+
+// QueryStream records the responses sent by ExecuteQuery.
+type QueryStream struct {
+	grpc.ServerStream
+	Responses []*grpcpb.QueryResponse
+}
+
+func (s *QueryStream) Send(resp *grpcpb.QueryResponse) error {
+	s.Responses = append(s.Responses, resp)
+	return nil
+}
+
+// MustWriteTLSFiles writes a self-signed certificate for 127.0.0.1 to dir and
+// returns a config that uses it. Panic on error.
+func MustWriteTLSFiles(dir string) grpcd.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+
+	c := grpcd.NewConfig()
+	c.Enabled = true
+	c.TLSEnabled = true
+	c.TLSCertificate = filepath.Join(dir, "cert.pem")
+	c.TLSPrivateKey = filepath.Join(dir, "key.pem")
+	MustWritePEM(c.TLSCertificate, "CERTIFICATE", der)
+	MustWritePEM(c.TLSPrivateKey, "EC PRIVATE KEY", keyDER)
+	return c
+}
+
+// MustWritePEM writes a PEM block to path. Panic on error.
+func MustWritePEM(path, typ string, der []byte) {
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600); err != nil {
+		panic(err)
+	}
+}
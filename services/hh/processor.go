@@ -99,10 +99,32 @@ func (p *Processor) addQueue(nodeID uint64) (*queue, error) {
 	if err := queue.Open(); err != nil {
 		return nil, err
 	}
+	for _, r := range queue.repairs {
+		p.logRepair(nodeID, r)
+	}
 	p.queues[nodeID] = queue
 	return queue, nil
 }
 
+// logRepair logs the writes to a node that were dropped when a corrupt
+// segment of its queue was truncated.
+func (p *Processor) logRepair(nodeID uint64, r *segmentRepair) {
+	p.Logger.Printf("truncated corrupt queue segment %s for node %d at offset %d", r.path, nodeID, r.offset)
+	if r.unreadable > 0 {
+		p.Logger.Printf("dropped %d unreadable queued writes for node %d", r.unreadable, nodeID)
+	}
+	for _, b := range r.lost {
+		shardID, points, err := p.unmarshalWrite(b)
+		if err != nil {
+			p.Logger.Printf("dropped unreadable queued write for node %d: %s", nodeID, err)
+			continue
+		}
+		for _, pt := range points {
+			p.Logger.Printf("dropped queued point for node %d, shard %d: %s", nodeID, shardID, pt.String())
+		}
+	}
+}
+
 func (p *Processor) WriteShard(shardID, ownerID uint64, points []tsdb.Point) error {
 	queue, ok := p.queues[ownerID]
 	if !ok {
@@ -197,6 +219,9 @@ func (p *Processor) marshalWrite(shardID uint64, points []tsdb.Point) []byte {
 }
 
 func (p *Processor) unmarshalWrite(b []byte) (uint64, []tsdb.Point, error) {
+	if len(b) < 8 {
+		return 0, nil, fmt.Errorf("queued write too short: %d bytes", len(b))
+	}
 	ownerID := binary.BigEndian.Uint64(b[:8])
 	points, err := tsdb.ParsePoints(b[8:])
 	return ownerID, points, err
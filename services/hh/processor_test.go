@@ -2,6 +2,7 @@ package hh
 
 import (
 	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
@@ -78,3 +79,45 @@ func TestProcessorProcess(t *testing.T) {
 	}
 
 }
+
+func TestProcessorProcessAfterReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "processor_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pt := tsdb.NewPoint("cpu", tsdb.Tags{"foo": "bar"}, tsdb.Fields{"value": 1.0}, time.Unix(0, 0))
+
+	// Queue a write while the owner is down.
+	p, err := NewProcessor(dir, &fakeShardWriter{}, ProcessorOptions{MaxSize: 1024})
+	if err != nil {
+		t.Fatalf("Process() failed to create processor: %v", err)
+	}
+	if err := p.WriteShard(100, 200, []tsdb.Point{pt}); err != nil {
+		t.Fatalf("Process() failed to write points: %v", err)
+	}
+
+	// A new processor on the same directory should replay the queued write.
+	var count int
+	sh := &fakeShardWriter{
+		ShardWriteFn: func(shardID, nodeID uint64, points []tsdb.Point) error {
+			count++
+			if shardID != 100 || nodeID != 200 || len(points) != 1 || points[0].String() != pt.String() {
+				t.Fatalf("Process() write mismatch: shardID=%d, nodeID=%d, points=%v", shardID, nodeID, points)
+			}
+			return nil
+		},
+	}
+	p, err = NewProcessor(dir, sh, ProcessorOptions{MaxSize: 1024})
+	if err != nil {
+		t.Fatalf("Process() failed to reopen processor: %v", err)
+	}
+	if err := p.Process(); err != nil {
+		t.Fatalf("Process() failed to write points: %v", err)
+	}
+
+	if exp := 1; count != exp {
+		t.Fatalf("Process() write count mismatch: got %v, exp %v", count, exp)
+	}
+}
@@ -3,6 +3,7 @@ package hh
 import (
 	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"os"
@@ -16,11 +17,19 @@ var (
 	ErrNotOpen     = fmt.Errorf("queue not open")
 	ErrQueueFull   = fmt.Errorf("queue is full")
 	ErrSegmentFull = fmt.Errorf("segment is full")
+
+	// ErrBlockCorrupt is returned when a block's checksum doesn't match its body.
+	ErrBlockCorrupt = fmt.Errorf("block checksum mismatch")
 )
 
 const (
 	defaultSegmentSize = 10 * 1024 * 1024
 	footerSize         = 8
+
+	// checksumFlag is set in the length of blocks followed by a CRC-32 of
+	// their body. Blocks written before checksums were added don't have one.
+	checksumFlag = 1 << 63
+	checksumSize = 4
 )
 
 // queue is a bounded, disk-backed, append-only type that combines queue and
@@ -71,6 +80,9 @@ type queue struct {
 
 	// The segments that exist on disk
 	segments segments
+
+	// Repairs made to corrupt segments when the queue was opened.
+	repairs []*segmentRepair
 }
 
 type segments []*segment
@@ -97,6 +109,13 @@ func (l *queue) Open() error {
 	}
 	l.segments = segments
 
+	l.repairs = nil
+	for _, s := range segments {
+		if s.repair != nil {
+			l.repairs = append(l.repairs, s.repair)
+		}
+	}
+
 	if len(l.segments) == 0 {
 		_, err := l.addSegment()
 		if err != nil {
@@ -350,6 +369,10 @@ func (l *queue) trimHead() error {
 // the segment to update the head pointer).  Reads must seek to the end then back into the
 // segment offset stored in the footer.
 //
+// Blocks are followed by a 4 byte CRC-32 of their body, marked by the high bit of their
+// length. A segment is verified when it is opened and truncated at its first corrupt
+// block so a partial write doesn't prevent the queue from opening.
+//
 // Segments store arbitrary byte slices and leave the serialization to the caller.  Segments
 // are created with a max size and will block writes when the segment is full.
 type segment struct {
@@ -360,8 +383,24 @@ type segment struct {
 	path string
 
 	pos         int64
-	currentSize int64
+	currentSize int64 // bytes after the current block's length
 	maxSize     int64
+
+	// repair is set if the segment was truncated at a corrupt block when opened.
+	repair *segmentRepair
+}
+
+// segmentRepair describes the blocks dropped from a segment that was
+// truncated at its first corrupt block.
+type segmentRepair struct {
+	path   string
+	offset int64 // offset the segment was truncated at
+
+	// lost holds the bodies of the unsent blocks that were intact but came
+	// after the corrupt block. unreadable is the number of unsent blocks
+	// whose body couldn't be read.
+	lost       [][]byte
+	unreadable int
 }
 
 func newSegment(path string, maxSize int64) (*segment, error) {
@@ -406,6 +445,11 @@ func (l *segment) open() error {
 		return nil
 	}
 
+	// Truncate the segment at its first corrupt block, if any.
+	if err := l.verify(); err != nil {
+		return err
+	}
+
 	// Existing segment so read the current position and the size of the current block
 	if err := l.seekEnd(-footerSize); err != nil {
 		return err
@@ -424,16 +468,133 @@ func (l *segment) open() error {
 	// If we're at the end of the segment, don't read the current block size,
 	// it's 0.
 	if l.pos < l.size-footerSize {
-		currentSize, err := l.readUint64()
+		sz, err := l.readUint64()
 		if err != nil {
 			return err
 		}
-		l.currentSize = int64(currentSize)
+		l.currentSize = blockSize(sz)
 	}
 
 	return nil
 }
 
+// verify checks that every block in the segment fits within it and matches
+// its checksum. If one doesn't, the segment is truncated at that block and
+// the repair is recorded. The head is kept if it is before the truncated
+// block, otherwise it is moved to the end. A head that isn't at a block
+// boundary was lost to a partial write so it is reset to the start of the
+// segment; sent blocks are then sent again, which is safe as writing a
+// point twice has no effect.
+func (l *segment) verify() error {
+	if l.size < footerSize {
+		return l.truncate(0, 0)
+	}
+	end := l.size - footerSize
+
+	// Read the head position.
+	if err := l.seekEnd(-footerSize); err != nil {
+		return err
+	}
+	pos, err := l.readUint64()
+	if err != nil {
+		return err
+	}
+	head := int64(pos)
+
+	// Scan the blocks from the start of the segment.
+	var repair *segmentRepair
+	headValid := head == end
+	for off := int64(0); off < end; {
+		if off == head {
+			headValid = true
+		}
+
+		body, next, err := l.readBlockAt(off, end)
+		if err != nil && err != ErrBlockCorrupt {
+			// The block's length is invalid so the rest of the segment can't be read.
+			if repair == nil {
+				repair = &segmentRepair{path: l.path, offset: off}
+			}
+			if off >= head {
+				repair.unreadable++
+			}
+			break
+		}
+
+		if err == ErrBlockCorrupt && repair == nil {
+			repair = &segmentRepair{path: l.path, offset: off}
+		}
+		if repair != nil && off >= head {
+			if err == ErrBlockCorrupt {
+				repair.unreadable++
+			} else {
+				repair.lost = append(repair.lost, body)
+			}
+		}
+		off = next
+	}
+	if repair == nil && headValid {
+		return nil
+	} else if repair == nil {
+		repair = &segmentRepair{path: l.path, offset: end}
+	}
+
+	// Only unsent blocks are lost, and they are only known with a valid head.
+	if !headValid {
+		head = 0
+		repair.lost, repair.unreadable = nil, 0
+	} else if head > repair.offset {
+		head = repair.offset
+	}
+	l.repair = repair
+	return l.truncate(repair.offset, head)
+}
+
+// readBlockAt reads the body of the block at off, returning the offset of the
+// next block. Returns ErrBlockCorrupt with the next offset if the block's
+// checksum doesn't match.
+func (l *segment) readBlockAt(off, end int64) ([]byte, int64, error) {
+	if off+8 > end {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	if err := l.seek(off); err != nil {
+		return nil, 0, err
+	}
+	sz, err := l.readUint64()
+	if err != nil {
+		return nil, 0, err
+	}
+	n := blockSize(sz)
+	if n < 0 || n > end-off-8 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	b := make([]byte, n)
+	if err := l.readBytes(b); err != nil {
+		return nil, 0, err
+	}
+	body, err := blockBody(sz, b)
+	return body, off + 8 + n, err
+}
+
+// truncate cuts the segment at off and writes a footer pointing to head.
+func (l *segment) truncate(off, head int64) error {
+	if err := l.file.Truncate(off); err != nil {
+		return err
+	}
+	if err := l.seek(off); err != nil {
+		return err
+	}
+	if err := l.writeUint64(uint64(head)); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.size = off + footerSize
+	return nil
+}
+
 // append adds byte slice to the end of segment
 func (l *segment) append(b []byte) error {
 	l.mu.Lock()
@@ -451,7 +612,7 @@ func (l *segment) append(b []byte) error {
 		return err
 	}
 
-	if err := l.writeUint64(uint64(len(b))); err != nil {
+	if err := l.writeUint64(uint64(len(b)) | checksumFlag); err != nil {
 		return err
 	}
 
@@ -459,6 +620,10 @@ func (l *segment) append(b []byte) error {
 		return err
 	}
 
+	if err := l.writeBytes(u32tob(crc32.ChecksumIEEE(b))); err != nil {
+		return err
+	}
+
 	if err := l.writeUint64(uint64(l.pos)); err != nil {
 		return err
 	}
@@ -468,10 +633,10 @@ func (l *segment) append(b []byte) error {
 	}
 
 	if l.currentSize == 0 {
-		l.currentSize = int64(len(b))
+		l.currentSize = int64(len(b)) + checksumSize
 	}
 
-	l.size += int64(len(b)) + 8 // uint64 for slice length
+	l.size += int64(len(b)) + 8 + checksumSize // uint64 for slice length
 
 	return nil
 }
@@ -494,14 +659,14 @@ func (l *segment) current() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	l.currentSize = int64(sz)
+	l.currentSize = blockSize(sz)
 
-	b := make([]byte, sz)
+	b := make([]byte, l.currentSize)
 	if err := l.readBytes(b); err != nil {
 		return nil, err
 	}
 
-	return b, nil
+	return blockBody(sz, b)
 }
 
 // advance advances the current value pointer
@@ -541,7 +706,7 @@ func (l *segment) advance() error {
 	if err != nil {
 		return err
 	}
-	l.currentSize = int64(sz)
+	l.currentSize = blockSize(sz)
 
 	if int64(l.pos) == l.size-footerSize {
 		l.currentSize = 0
@@ -651,6 +816,33 @@ func (l *segment) readBytes(b []byte) error {
 	return nil
 }
 
+// blockSize returns the number of bytes following a block's length.
+func blockSize(sz uint64) int64 {
+	if sz&checksumFlag != 0 {
+		return int64(sz&^checksumFlag) + checksumSize
+	}
+	return int64(sz)
+}
+
+// blockBody returns the body of a block from the bytes following its length,
+// verifying its checksum if it has one.
+func blockBody(sz uint64, b []byte) ([]byte, error) {
+	if sz&checksumFlag == 0 {
+		return b, nil
+	}
+	body, sum := b[:len(b)-checksumSize], b[len(b)-checksumSize:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(sum) {
+		return nil, ErrBlockCorrupt
+	}
+	return body, nil
+}
+
+func u32tob(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
 func u64tob(v uint64) []byte {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b, v)
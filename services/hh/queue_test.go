@@ -60,8 +60,8 @@ func TestQueueAppendOne(t *testing.T) {
 		t.Fatalf("Queue.Append file not exists. exp %v to exist", exp)
 	}
 
-	// 8 byte header ptr + 8 byte record len + record len
-	if exp := int64(8 + 8 + 4); stats.Size() != exp {
+	// 8 byte header ptr + 8 byte record len + record len + 4 byte checksum
+	if exp := int64(8 + 8 + 4 + 4); stats.Size() != exp {
 		t.Fatalf("Queue.Append file size mismatch. got %v, exp %v", stats.Size(), exp)
 	}
 
@@ -279,6 +279,124 @@ func TestQueueReopen(t *testing.T) {
 	}
 }
 
+// Ensure a segment is truncated at a corrupt block when the queue is opened
+// and the unsent blocks after it are reported.
+func TestQueueOpen_CorruptBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hh_queue")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := newQueue(dir, 1024)
+	if err != nil {
+		t.Fatalf("failed to create queue: %v", err)
+	}
+	if err := q.Open(); err != nil {
+		t.Fatalf("failed to open queue: %v", err)
+	}
+	for _, b := range []string{"one", "two", "three"} {
+		if err := q.Append([]byte(b)); err != nil {
+			t.Fatalf("Queue.Append failed: %v", err)
+		}
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Queue.Close failed: %v", err)
+	}
+
+	// Flip a byte in the body of the second block.
+	path := filepath.Join(dir, "1")
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf[8+3+4+8] ^= 0xff
+	if err := ioutil.WriteFile(path, buf, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Open(); err != nil {
+		t.Fatalf("failed to re-open queue: %v", err)
+	}
+	if len(q.repairs) != 1 {
+		t.Fatalf("unexpected repairs: %d", len(q.repairs))
+	} else if r := q.repairs[0]; r.offset != 8+3+4 || r.unreadable != 1 || len(r.lost) != 1 || string(r.lost[0]) != "three" {
+		t.Fatalf("unexpected repair: %+v", r)
+	}
+
+	// Only the block before the corrupt one is left.
+	if cur, err := q.Current(); err != nil {
+		t.Fatalf("Queue.Current failed: %v", err)
+	} else if string(cur) != "one" {
+		t.Errorf("Queue.Current mismatch: got %v, exp %v", string(cur), "one")
+	}
+	if err := q.Advance(); err != nil {
+		t.Fatalf("Queue.Advance failed: %v", err)
+	} else if _, err := q.Current(); err != io.EOF {
+		t.Fatalf("Queue.Current error mismatch: got %v, exp %v", err, io.EOF)
+	}
+}
+
+// Ensure a segment left with a partial block by a failed append can be
+// opened and its blocks are read again from the start.
+func TestQueueOpen_PartialWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hh_queue")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := newQueue(dir, 1024)
+	if err != nil {
+		t.Fatalf("failed to create queue: %v", err)
+	}
+	if err := q.Open(); err != nil {
+		t.Fatalf("failed to open queue: %v", err)
+	}
+	for _, b := range []string{"one", "two"} {
+		if err := q.Append([]byte(b)); err != nil {
+			t.Fatalf("Queue.Append failed: %v", err)
+		}
+	}
+	if err := q.Advance(); err != nil {
+		t.Fatalf("Queue.Advance failed: %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Queue.Close failed: %v", err)
+	}
+
+	// Overwrite the footer with the start of a block, as an append would.
+	f, err := os.OpenFile(filepath.Join(dir, "1"), os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(-footerSize, os.SEEK_END); err != nil {
+		t.Fatal(err)
+	} else if _, err := f.Write(append(u64tob(100|checksumFlag), "three"...)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := q.Open(); err != nil {
+		t.Fatalf("failed to re-open queue: %v", err)
+	} else if len(q.repairs) != 1 || q.repairs[0].offset != 2*(8+3+4) {
+		t.Fatalf("unexpected repairs: %+v", q.repairs)
+	}
+
+	// The head was lost so both blocks are read again.
+	for _, exp := range []string{"one", "two"} {
+		cur, err := q.Current()
+		if err != nil {
+			t.Fatalf("Queue.Current failed: %v", err)
+		} else if string(cur) != exp {
+			t.Errorf("Queue.Current mismatch: got %v, exp %v", string(cur), exp)
+		}
+		if err := q.Advance(); err != nil {
+			t.Fatalf("Queue.Advance failed: %v", err)
+		}
+	}
+}
+
 func TestPurgeQueue(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping purge queue")
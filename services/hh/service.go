@@ -90,22 +90,32 @@ func (s *Service) WriteShard(shardID, ownerID uint64, points []tsdb.Point) error
 	return s.HintedHandoff.WriteShard(shardID, ownerID, points)
 }
 
+// retryWrites replays queued writes once on startup and then on every retry interval.
 func (s *Service) retryWrites() {
 	defer s.wg.Done()
 	ticker := time.NewTicker(time.Duration(s.cfg.RetryInterval))
 	defer ticker.Stop()
+
+	// Writes queued before a restart are replayed immediately.
+	s.process()
+
 	for {
 		select {
 		case <-s.closing:
 			return
 		case <-ticker.C:
-			if err := s.HintedHandoff.Process(); err != nil && err != io.EOF {
-				s.Logger.Printf("retried write failed: %v", err)
-			}
+			s.process()
 		}
 	}
 }
 
+// process retries all queued writes.
+func (s *Service) process() {
+	if err := s.HintedHandoff.Process(); err != nil && err != io.EOF {
+		s.Logger.Printf("retried write failed: %v", err)
+	}
+}
+
 // expireWrites will cause the handoff queues to remove writes that are older
 // than the configured threshold
 func (s *Service) expireWrites() {
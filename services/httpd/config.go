@@ -1,5 +1,21 @@
 package httpd
 
+import "fmt"
+
+const (
+	// WriteConsistencyFailureError returns an error to the client when a
+	// write doesn't meet its consistency level.
+	WriteConsistencyFailureError = "error"
+
+	// WriteConsistencyFailurePartial returns a partial success with the
+	// details of each shard when some of its owners were written to.
+	WriteConsistencyFailurePartial = "partial"
+
+	// WriteConsistencyFailureHandoff returns success when every owner that
+	// wasn't written to has the write queued for hinted handoff.
+	WriteConsistencyFailureHandoff = "handoff"
+)
+
 type Config struct {
 	Enabled      bool   `toml:"enabled"`
 	BindAddress  string `toml:"bind-address"`
@@ -7,6 +23,18 @@ type Config struct {
 	LogEnabled   bool   `toml:"log-enabled"`
 	WriteTracing bool   `toml:"write-tracing"`
 	PprofEnabled bool   `toml:"pprof-enabled"`
+
+	// WriteConsistencyFailure is the response to a write that doesn't meet
+	// its consistency level: "error", "partial" or "handoff".
+	WriteConsistencyFailure string `toml:"write-consistency-failure"`
+
+	// MaxWriteBatchSize splits writes into batches of at most this many
+	// bytes of points. Zero writes every request as one batch.
+	MaxWriteBatchSize int `toml:"max-write-batch-size"`
+
+	// SnapshotDir is the directory /snapshot writes cluster snapshots to.
+	// Blank disables snapshots over HTTP.
+	SnapshotDir string `toml:"snapshot-dir"`
 }
 
 func NewConfig() Config {
@@ -14,5 +42,20 @@ func NewConfig() Config {
 		Enabled:     true,
 		BindAddress: ":8086",
 		LogEnabled:  true,
+
+		WriteConsistencyFailure: WriteConsistencyFailureError,
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	switch c.WriteConsistencyFailure {
+	case "", WriteConsistencyFailureError, WriteConsistencyFailurePartial, WriteConsistencyFailureHandoff:
+	default:
+		return fmt.Errorf("invalid write-consistency-failure: %s", c.WriteConsistencyFailure)
+	}
+	if c.MaxWriteBatchSize < 0 {
+		return fmt.Errorf("max-write-batch-size must not be negative")
 	}
+	return nil
 }
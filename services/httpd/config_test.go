@@ -17,6 +17,7 @@ auth-enabled = true
 log-enabled = true
 write-tracing = true
 pprof-enabled = true
+write-consistency-failure = "partial"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -34,6 +35,8 @@ pprof-enabled = true
 		t.Fatalf("unexpected write tracing: %v", c.WriteTracing)
 	} else if c.PprofEnabled != true {
 		t.Fatalf("unexpected pprof enabled: %v", c.PprofEnabled)
+	} else if c.WriteConsistencyFailure != httpd.WriteConsistencyFailurePartial {
+		t.Fatalf("unexpected write consistency failure: %s", c.WriteConsistencyFailure)
 	}
 }
 
@@ -44,3 +47,15 @@ func TestConfig_WriteTracing(t *testing.T) {
 		t.Fatalf("write tracing was not set")
 	}
 }
+
+func TestConfig_Validate(t *testing.T) {
+	c := httpd.NewConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c.WriteConsistencyFailure = "retry"
+	if err := c.Validate(); err == nil || err.Error() != "invalid write-consistency-failure: retry" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,436 @@
+package httpd
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/parquet"
+)
+
+// DefaultExportInterval is the default width of the time partitions written by /export.
+const DefaultExportInterval = 24 * time.Hour
+
+// exportPartition holds the points written to a single exported file.
+type exportPartition struct {
+	path   string
+	points []exportPoint
+}
+
+// exportPoint is a single point read for export.
+type exportPoint struct {
+	time   time.Time
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+// exportColumn is a column of an exported file and the tag or field it holds.
+type exportColumn struct {
+	parquet.Column
+	key string
+	tag bool
+}
+
+// exportWindow is a range of time read and written to the archive at once.
+type exportWindow struct {
+	start, end time.Time
+}
+
+// serveExport returns the points of a measurement as a tar archive of Parquet
+// files. Files are partitioned by time and by the tag keys listed in the
+// "partition" parameter using Hive-style paths with escaped values, e.g.:
+//
+//	cpu/time=2015-07-01T00%3A00%3A00Z/host=serverA/data.parquet
+//
+// The time range is read and written in windows of whole time partitions at
+// least as wide as a shard group, so only one window is held in memory.
+// Errors in the first window are returned as an HTTP error; errors after the
+// archive has started are logged and the archive is cut off. A field with the
+// same name as a tag or partition key is written as name_1.
+func (h *Handler) serveExport(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	q := r.URL.Query()
+	pretty := q.Get("pretty") == "true"
+
+	db, rp, measurement := q.Get("db"), q.Get("rp"), q.Get("measurement")
+	if db == "" {
+		httpError(w, `missing required parameter "db"`, pretty, http.StatusBadRequest)
+		return
+	} else if measurement == "" {
+		httpError(w, `missing required parameter "measurement"`, pretty, http.StatusBadRequest)
+		return
+	}
+
+	if h.requireAuthentication && !user.Authorize(influxql.ReadPrivilege, db) {
+		httpError(w, fmt.Sprintf("%q user is not authorized to read from database %q", user.Name, db), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the time range and partitioning.
+	start, end := time.Unix(0, 0).UTC(), time.Now().UTC()
+	if s := q.Get("start"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			httpError(w, "error parsing start time: "+err.Error(), pretty, http.StatusBadRequest)
+			return
+		}
+		start = t
+	}
+	if s := q.Get("end"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			httpError(w, "error parsing end time: "+err.Error(), pretty, http.StatusBadRequest)
+			return
+		}
+		end = t
+	}
+
+	interval := DefaultExportInterval
+	if s := q.Get("interval"); s != "" {
+		d, err := influxql.ParseDuration(s)
+		if err != nil {
+			httpError(w, "error parsing interval: "+err.Error(), pretty, http.StatusBadRequest)
+			return
+		} else if d <= 0 {
+			httpError(w, "interval must be greater than zero", pretty, http.StatusBadRequest)
+			return
+		}
+		interval = d
+	}
+
+	var partitionBy []string
+	if s := q.Get("partition"); s != "" {
+		partitionBy = strings.Split(s, ",")
+	}
+
+	// Find the shard groups of the retention policy to split the range on.
+	di, err := h.MetaStore.Database(db)
+	if err != nil {
+		httpError(w, "error with export: "+err.Error(), pretty, http.StatusInternalServerError)
+		return
+	} else if di == nil {
+		httpError(w, "database not found: "+db, pretty, http.StatusNotFound)
+		return
+	}
+	if rp == "" {
+		rp = di.DefaultRetentionPolicy
+	}
+	rpi := di.RetentionPolicy(rp)
+	if rpi == nil {
+		httpError(w, "retention policy not found: "+rp, pretty, http.StatusNotFound)
+		return
+	}
+
+	var tw *tar.Writer
+	for _, window := range exportWindows(rpi, start, end, interval) {
+		partitions, err := h.readExport(db, rp, measurement, window, interval, partitionBy)
+		if err != nil {
+			if tw == nil {
+				httpError(w, "error with export: "+err.Error(), pretty, http.StatusInternalServerError)
+			} else {
+				h.Logger.Printf("export %s: %s", measurement, err)
+			}
+			return
+		}
+
+		// Write partitions in a consistent order.
+		paths := make([]string, 0, len(partitions))
+		for path := range partitions {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			part := partitions[path]
+
+			var buf bytes.Buffer
+			columns, err := part.columns(partitionBy)
+			if err == nil {
+				err = part.encode(&buf, columns)
+			}
+			if err != nil {
+				if tw == nil {
+					httpError(w, "error with export: "+err.Error(), pretty, http.StatusInternalServerError)
+				} else {
+					h.Logger.Printf("export %s: %s", part.path, err)
+				}
+				return
+			}
+
+			if tw == nil {
+				w.Header().Add("content-type", "application/x-tar")
+				tw = tar.NewWriter(w)
+			}
+			if err := tw.WriteHeader(&tar.Header{
+				Name:    part.path,
+				Mode:    0644,
+				Size:    int64(buf.Len()),
+				ModTime: time.Now(),
+			}); err != nil {
+				return
+			}
+			if _, err := tw.Write(buf.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+
+	if tw == nil {
+		w.Header().Add("content-type", "application/x-tar")
+		tw = tar.NewWriter(w)
+	}
+	tw.Close()
+}
+
+// exportWindows splits the time range into windows of whole time partitions
+// that are at least as wide as the policy's shard groups. Windows without a
+// shard group are skipped as they hold no data.
+func exportWindows(rpi *meta.RetentionPolicyInfo, start, end time.Time, interval time.Duration) []exportWindow {
+	width := interval
+	if d := rpi.ShardGroupDuration; d > interval {
+		width = interval * ((d + interval - 1) / interval)
+	}
+
+	seen := make(map[time.Time]struct{})
+	var windows []exportWindow
+	for i := range rpi.ShardGroups {
+		sg := &rpi.ShardGroups[i]
+		if sg.Deleted() {
+			continue
+		}
+
+		min, max := sg.StartTime, sg.EndTime
+		if min.Before(start) {
+			min = start
+		}
+		if max.After(end) {
+			max = end
+		}
+		for t := min.Truncate(width); t.Before(max); t = t.Add(width) {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+
+			w := exportWindow{start: t, end: t.Add(width)}
+			if w.start.Before(start) {
+				w.start = start
+			}
+			if w.end.After(end) {
+				w.end = end
+			}
+			windows = append(windows, w)
+		}
+	}
+	sort.Sort(exportWindowSlice(windows))
+	return windows
+}
+
+// readExport reads the points of a window and groups them into partitions
+// keyed by path.
+func (h *Handler) readExport(db, rp, measurement string, window exportWindow, interval time.Duration, partitionBy []string) (map[string]*exportPartition, error) {
+	stmt := &influxql.SelectStatement{
+		Fields:     influxql.Fields{{Expr: &influxql.Wildcard{}}},
+		Sources:    influxql.Sources{&influxql.Measurement{Database: db, RetentionPolicy: rp, Name: measurement}},
+		Dimensions: influxql.Dimensions{{Expr: &influxql.Wildcard{}}},
+		Condition: &influxql.BinaryExpr{
+			Op:  influxql.AND,
+			LHS: &influxql.BinaryExpr{Op: influxql.GTE, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.TimeLiteral{Val: window.start}},
+			RHS: &influxql.BinaryExpr{Op: influxql.LT, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.TimeLiteral{Val: window.end}},
+		},
+		IsRawQuery: true,
+	}
+	results, err := h.QueryExecutor.ExecuteQuery(&influxql.Query{Statements: influxql.Statements{stmt}}, db, DefaultChunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make(map[string]*exportPartition)
+	for result := range results {
+		// Keep reading so the executor isn't blocked, but only report the first error.
+		if err != nil {
+			continue
+		} else if result.Err != nil {
+			err = result.Err
+			continue
+		}
+
+		for _, row := range result.Series {
+			for _, values := range row.Values {
+				p := exportPoint{tags: make(map[string]string), fields: make(map[string]interface{})}
+				for i, v := range values {
+					if row.Columns[i] == "time" {
+						p.time, _ = v.(time.Time)
+					} else if v != nil {
+						p.fields[row.Columns[i]] = v
+					}
+				}
+
+				// Build the partition path from the time and partition tags.
+				path := url.QueryEscape(measurement) + "/time=" + url.QueryEscape(p.time.Truncate(interval).UTC().Format(time.RFC3339))
+				for _, k := range partitionBy {
+					path += "/" + url.QueryEscape(k) + "=" + url.QueryEscape(row.Tags[k])
+				}
+				for k, v := range row.Tags {
+					if !contains(partitionBy, k) {
+						p.tags[k] = v
+					}
+				}
+
+				part := partitions[path]
+				if part == nil {
+					part = &exportPartition{path: path + "/data.parquet"}
+					partitions[path] = part
+				}
+				part.points = append(part.points, p)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return partitions, nil
+}
+
+// columns returns the schema for the partition: the time followed by the
+// remaining tags and then the fields, each in sorted order. Fields named
+// like a tag, a partition key or "time" are suffixed with _1, or the first
+// free number, as in query results.
+func (p *exportPartition) columns(partitionBy []string) ([]exportColumn, error) {
+	tags := make(map[string]struct{})
+	fields := make(map[string]parquet.Type)
+	for _, pt := range p.points {
+		for k := range pt.tags {
+			tags[k] = struct{}{}
+		}
+		for k, v := range pt.fields {
+			typ, err := parquetType(v)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %s", k, err)
+			}
+
+			// Integers and floats can be stored together as floats.
+			prev, ok := fields[k]
+			switch {
+			case !ok || prev == typ:
+				fields[k] = typ
+			case prev == parquet.Int64 && typ == parquet.Double, prev == parquet.Double && typ == parquet.Int64:
+				fields[k] = parquet.Double
+			default:
+				return nil, fmt.Errorf("field type conflict: %s", k)
+			}
+		}
+	}
+
+	taken := map[string]struct{}{"time": {}}
+	for _, k := range partitionBy {
+		taken[k] = struct{}{}
+	}
+	for k := range tags {
+		taken[k] = struct{}{}
+	}
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		taken[k] = struct{}{}
+	}
+
+	columns := []exportColumn{{Column: parquet.Column{Name: "time", Type: parquet.Timestamp, Required: true}}}
+	for _, k := range sortedKeys(tags) {
+		columns = append(columns, exportColumn{Column: parquet.Column{Name: k, Type: parquet.String}, key: k, tag: true})
+	}
+	for _, k := range names {
+		name := k
+		if _, ok := tags[k]; ok || k == "time" || contains(partitionBy, k) {
+			for i := 1; ; i++ {
+				name = fmt.Sprintf("%s_%d", k, i)
+				if _, ok := taken[name]; !ok {
+					break
+				}
+			}
+			taken[name] = struct{}{}
+		}
+		columns = append(columns, exportColumn{Column: parquet.Column{Name: name, Type: fields[k]}, key: k})
+	}
+	return columns, nil
+}
+
+// encode writes the partition's points to buf as a Parquet file.
+func (p *exportPartition) encode(buf *bytes.Buffer, columns []exportColumn) error {
+	schema := make([]parquet.Column, len(columns))
+	for i, c := range columns {
+		schema[i] = c.Column
+	}
+
+	pw := parquet.NewWriter(buf, schema)
+	for _, pt := range p.points {
+		values := make([]interface{}, len(columns))
+		values[0] = pt.time
+		for i, c := range columns[1:] {
+			if c.tag {
+				if v, ok := pt.tags[c.key]; ok {
+					values[i+1] = v
+				}
+			} else if v, ok := pt.fields[c.key]; ok {
+				if n, ok := v.(int64); ok && c.Type == parquet.Double {
+					v = float64(n)
+				}
+				values[i+1] = v
+			}
+		}
+		if err := pw.Write(values); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+type exportWindowSlice []exportWindow
+
+func (a exportWindowSlice) Len() int           { return len(a) }
+func (a exportWindowSlice) Less(i, j int) bool { return a[i].start.Before(a[j].start) }
+func (a exportWindowSlice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// parquetType returns the column type used to store a field value.
+func parquetType(v interface{}) (parquet.Type, error) {
+	switch v.(type) {
+	case float64:
+		return parquet.Double, nil
+	case int64:
+		return parquet.Int64, nil
+	case bool:
+		return parquet.Boolean, nil
+	case string:
+		return parquet.String, nil
+	}
+	return 0, fmt.Errorf("unsupported type: %T", v)
+}
+
+// sortedKeys returns the keys of a set in sorted order.
+func sortedKeys(m map[string]struct{}) []string {
+	a := make([]string, 0, len(m))
+	for k := range m {
+		a = append(a, k)
+	}
+	sort.Strings(a)
+	return a
+}
+
+// contains returns true if a contains s.
+func contains(a []string, s string) bool {
+	for _, other := range a {
+		if other == s {
+			return true
+		}
+	}
+	return false
+}
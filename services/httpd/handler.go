@@ -12,6 +12,8 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +33,10 @@ const (
 	// With raw data queries, mappers will read up to this amount before sending results back to the engine.
 	// This is the default size in the number of values returned in a raw query. Could be many more bytes depending on fields returned.
 	DefaultChunkSize = 10000
+
+	// WriteRetryAfter is the number of seconds clients are asked to wait
+	// before retrying a write rejected because the server is overloaded.
+	WriteRetryAfter = 1
 )
 
 // TODO: Standard response headers (see: HeaderHandler)
@@ -56,7 +62,10 @@ type Handler struct {
 	MetaStore interface {
 		Database(name string) (*meta.DatabaseInfo, error)
 		Authenticate(username, password string) (ui *meta.UserInfo, err error)
+		AuthenticateAPIKey(key string) (ui *meta.UserInfo, err error)
 		Users() ([]meta.UserInfo, error)
+		Nodes() ([]meta.NodeInfo, error)
+		Databases() ([]meta.DatabaseInfo, error)
 	}
 
 	QueryExecutor interface {
@@ -65,13 +74,75 @@ type Handler struct {
 
 	PointsWriter interface {
 		WritePoints(p *cluster.WritePointsRequest) error
+		WritePointsToEachPolicy(p *cluster.WritePointsRequest, policies []string) error
 	}
 
 	ContinuousQuerier continuous_querier.ContinuousQuerier
 
+	TSDBStore interface {
+		LastPoints(database, retentionPolicy, measurement string) ([]tsdb.Point, error)
+	}
+
+	// Decommissioner, if set, moves the shards off of a node and removes it.
+	Decommissioner interface {
+		Decommission(nodeID uint64) error
+	}
+
+	// NodeManager, if set, joins data nodes to the cluster and removes them.
+	NodeManager interface {
+		CreateNode(host string) (*meta.NodeInfo, error)
+		DeleteNode(id uint64, force bool) error
+	}
+
+	// MetaChecker, if set, finds and repairs inconsistencies in the metadata.
+	MetaChecker interface {
+		Check() ([]string, error)
+		Repair() ([]string, error)
+	}
+
+	// MetaReplicator, if set, accepts the metadata of a primary cluster so
+	// this cluster can act as its standby.
+	MetaReplicator interface {
+		Replicate(data *meta.Data) error
+	}
+
+	// SchemaMigrator, if set, converts tags into fields and fields into tags
+	// in the shards stored on this node.
+	SchemaMigrator interface {
+		MigrateShard(shardID uint64, m *tsdb.Migration) (int, error)
+	}
+
+	// ShardCompactor, if set, rewrites the data files of shards stored on
+	// this node to reclaim their free space.
+	ShardCompactor interface {
+		CompactShard(shardID uint64) (int64, error)
+	}
+
+	// ClusterSnapshotter, if set, writes a backup of the cluster as of a time.
+	ClusterSnapshotter interface {
+		Snapshot(t time.Time, path string) (*meta.SnapshotInfo, error)
+	}
+
 	Logger         *log.Logger
 	loggingEnabled bool // Log every HTTP access.
 	WriteTrace     bool // Detailed logging of write path
+
+	// WriteConsistencyFailure is the response to a write that doesn't meet
+	// its consistency level. Defaults to returning an error.
+	WriteConsistencyFailure string
+
+	// MaxWriteBatchSize splits writes into batches of at most this many
+	// bytes of points, written one after another. Zero writes every request
+	// as one batch.
+	MaxWriteBatchSize int
+
+	// MaxPointsPerBatch rejects writes with more points while they're
+	// parsed, before the rest of the request is. Zero is unlimited.
+	MaxPointsPerBatch int
+
+	// SnapshotDir is the directory cluster snapshots are written to.
+	// Snapshots are disabled if it is blank.
+	SnapshotDir string
 }
 
 // NewHandler returns a new instance of handler with routes.
@@ -97,6 +168,14 @@ func NewHandler(requireAuthentication, loggingEnabled, writeTrace bool) *Handler
 			"write", // Data-ingest route.
 			"POST", "/write", true, true, h.serveWrite,
 		},
+		route{
+			"last", // Latest point for each series.
+			"GET", "/last", true, true, h.serveLast,
+		},
+		route{
+			"export", // Points in a time range as Parquet files.
+			"GET", "/export", false, true, h.serveExport,
+		},
 		route{ // Ping
 			"ping",
 			"GET", "/ping", true, true, h.servePing,
@@ -105,6 +184,50 @@ func NewHandler(requireAuthentication, loggingEnabled, writeTrace bool) *Handler
 			"ping-head",
 			"HEAD", "/ping", true, true, h.servePing,
 		},
+		route{ // Move a data node's shards to other nodes and remove it
+			"decommission",
+			"POST", "/decommission", false, true, h.serveDecommission,
+		},
+		route{ // Join a data node to the cluster
+			"join-node",
+			"POST", "/nodes", false, true, h.serveJoinNode,
+		},
+		route{ // Remove a data node from the cluster
+			"remove-node",
+			"DELETE", "/nodes", false, true, h.serveRemoveNode,
+		},
+		route{ // Find inconsistencies in the metadata
+			"meta-check",
+			"GET", "/meta/check", true, true, h.serveMetaCheck,
+		},
+		route{ // Repair inconsistencies in the metadata
+			"meta-repair",
+			"POST", "/meta/repair", false, true, h.serveMetaRepair,
+		},
+		route{ // Replace the metadata with the metadata of a primary cluster
+			"meta-replicate",
+			"POST", "/meta/replicate", false, true, h.serveMetaReplicate,
+		},
+		route{ // Convert a tag into a field, or a field into a tag
+			"migrate",
+			"POST", "/migrate", false, true, h.serveMigrate,
+		},
+		route{ // Reclaim the free space in shard data files
+			"compact",
+			"POST", "/compact", false, true, h.serveCompact,
+		},
+		route{ // Back up every shard that ended before a time
+			"snapshot",
+			"POST", "/snapshot", false, true, h.serveSnapshot,
+		},
+		route{ // List the data nodes in the cluster
+			"cluster-nodes",
+			"GET", "/cluster/nodes", true, true, h.serveClusterNodes,
+		},
+		route{ // List the shards in the cluster and the nodes that own them
+			"cluster-shards",
+			"GET", "/cluster/shards", true, true, h.serveClusterShards,
+		},
 		route{ // Tell data node to run CQs that should be run
 			"process_continuous_queries",
 			"POST", "/data/process_continuous_queries", false, false, h.serveProcessContinuousQueries,
@@ -189,6 +312,508 @@ func (h *Handler) serveProcessContinuousQueries(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// serveDecommission moves all shards off of a data node and removes it from
+// the cluster. The request blocks until the node has been removed.
+func (h *Handler) serveDecommission(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	q := r.URL.Query()
+	pretty := q.Get("pretty") == "true"
+
+	if h.Decommissioner == nil {
+		httpError(w, "decommissioning not enabled", pretty, http.StatusNotImplemented)
+		return
+	}
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to decommission nodes", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	nodeID, err := strconv.ParseUint(q.Get("node"), 10, 64)
+	if err != nil {
+		httpError(w, `invalid or missing parameter "node"`, pretty, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Decommissioner.Decommission(nodeID); err == meta.ErrNodeNotFound {
+		httpError(w, err.Error(), pretty, http.StatusNotFound)
+		return
+	} else if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveJoinNode adds a data node to the cluster by its host and returns the
+// new node. New shard groups are assigned to the node immediately.
+func (h *Handler) serveJoinNode(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	q := r.URL.Query()
+	pretty := q.Get("pretty") == "true"
+
+	if h.NodeManager == nil {
+		httpError(w, "node management not enabled", pretty, http.StatusNotImplemented)
+		return
+	}
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to join nodes", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	host := q.Get("host")
+	if host == "" {
+		httpError(w, `missing required parameter "host"`, pretty, http.StatusBadRequest)
+		return
+	}
+
+	ni, err := h.NodeManager.CreateNode(host)
+	if err == meta.ErrNodeExists {
+		httpError(w, err.Error(), pretty, http.StatusConflict)
+		return
+	} else if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.Write(MarshalJSON(ni, pretty))
+}
+
+// serveRemoveNode removes a data node from the cluster. Nodes that still own
+// shards are only removed if "force" is set, which is meant for nodes that
+// are dead and can't be decommissioned.
+func (h *Handler) serveRemoveNode(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	q := r.URL.Query()
+	pretty := q.Get("pretty") == "true"
+
+	if h.NodeManager == nil {
+		httpError(w, "node management not enabled", pretty, http.StatusNotImplemented)
+		return
+	}
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to remove nodes", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	nodeID, err := strconv.ParseUint(q.Get("node"), 10, 64)
+	if err != nil {
+		httpError(w, `invalid or missing parameter "node"`, pretty, http.StatusBadRequest)
+		return
+	}
+	force := q.Get("force") == "true"
+
+	if err := h.NodeManager.DeleteNode(nodeID, force); err == meta.ErrNodeNotFound {
+		httpError(w, err.Error(), pretty, http.StatusNotFound)
+		return
+	} else if err == meta.ErrNodeOwnsShards {
+		httpError(w, err.Error(), pretty, http.StatusConflict)
+		return
+	} else if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// metaProblems is returned by the /meta/check and /meta/repair endpoints.
+type metaProblems struct {
+	Problems []string `json:"problems"`
+}
+
+// serveMetaCheck returns the inconsistencies found in the metadata.
+func (h *Handler) serveMetaCheck(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	h.serveMetaChecker(w, r, user, false)
+}
+
+// serveMetaRepair repairs the inconsistencies in the metadata and returns
+// the ones that could not be repaired.
+func (h *Handler) serveMetaRepair(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	h.serveMetaChecker(w, r, user, true)
+}
+
+func (h *Handler) serveMetaChecker(w http.ResponseWriter, r *http.Request, user *meta.UserInfo, repair bool) {
+	pretty := r.URL.Query().Get("pretty") == "true"
+
+	if h.MetaChecker == nil {
+		httpError(w, "meta checks not enabled", pretty, http.StatusNotImplemented)
+		return
+	}
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to check metadata", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	var a []string
+	var err error
+	if repair {
+		a, err = h.MetaChecker.Repair()
+	} else {
+		a, err = h.MetaChecker.Check()
+	}
+	if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+	if a == nil {
+		a = []string{}
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.Write(MarshalJSON(metaProblems{Problems: a}, pretty))
+}
+
+// serveMetaReplicate replaces the databases, users and roles of this cluster
+// with the metadata of a primary cluster sent in the request body.
+func (h *Handler) serveMetaReplicate(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	pretty := r.URL.Query().Get("pretty") == "true"
+
+	if h.MetaReplicator == nil {
+		httpError(w, "meta replication not enabled", pretty, http.StatusNotImplemented)
+		return
+	}
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to replicate metadata", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusBadRequest)
+		return
+	}
+
+	data := &meta.Data{}
+	if err := data.UnmarshalBinary(buf); err != nil {
+		httpError(w, "invalid metadata: "+err.Error(), pretty, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.MetaReplicator.Replicate(data); err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// migrateResult is written for each shard migrated by serveMigrate.
+type migrateResult struct {
+	Shard  uint64 `json:"shard"`
+	Points int    `json:"points"`
+	Err    string `json:"error,omitempty"`
+}
+
+// serveMigrate converts a tag of a measurement into a field, or a field into
+// a tag, in each of the requested shards stored on this node. A result is
+// streamed as each shard completes.
+func (h *Handler) serveMigrate(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	q := r.URL.Query()
+	pretty := q.Get("pretty") == "true"
+
+	if h.SchemaMigrator == nil {
+		httpError(w, "schema migrations not enabled", pretty, http.StatusNotImplemented)
+		return
+	}
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to migrate shards", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	m := &tsdb.Migration{Measurement: q.Get("measurement")}
+	if m.Measurement == "" {
+		httpError(w, `missing required parameter "measurement"`, pretty, http.StatusBadRequest)
+		return
+	}
+	if tag, field := q.Get("tag-to-field"), q.Get("field-to-tag"); (tag == "") == (field == "") {
+		httpError(w, `exactly one of "tag-to-field" or "field-to-tag" required`, pretty, http.StatusBadRequest)
+		return
+	} else if tag != "" {
+		m.Type, m.Key = tsdb.TagToField, tag
+	} else {
+		m.Type, m.Key = tsdb.FieldToTag, field
+	}
+
+	var shardIDs []uint64
+	for _, s := range strings.Split(q.Get("shards"), ",") {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			httpError(w, `invalid or missing parameter "shards"`, pretty, http.StatusBadRequest)
+			return
+		}
+		shardIDs = append(shardIDs, id)
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	for _, id := range shardIDs {
+		result := migrateResult{Shard: id}
+		if n, err := h.SchemaMigrator.MigrateShard(id, m); err != nil {
+			result.Err = err.Error()
+		} else {
+			result.Points = n
+		}
+
+		w.Write(MarshalJSON(result, pretty))
+		w.Write([]byte("\n"))
+		w.(http.Flusher).Flush()
+	}
+}
+
+// compactResult is written for each shard compacted by serveCompact.
+type compactResult struct {
+	Shard     uint64 `json:"shard"`
+	Reclaimed int64  `json:"reclaimed"`
+	Err       string `json:"error,omitempty"`
+}
+
+// serveCompact rewrites the data file of each of the requested shards stored
+// on this node, returning the space freed by deleted data to the filesystem.
+// A result is streamed as each shard completes.
+func (h *Handler) serveCompact(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	q := r.URL.Query()
+	pretty := q.Get("pretty") == "true"
+
+	if h.ShardCompactor == nil {
+		httpError(w, "shard compaction not enabled", pretty, http.StatusNotImplemented)
+		return
+	}
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to compact shards", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	var shardIDs []uint64
+	for _, s := range strings.Split(q.Get("shards"), ",") {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			httpError(w, `invalid or missing parameter "shards"`, pretty, http.StatusBadRequest)
+			return
+		}
+		shardIDs = append(shardIDs, id)
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	for _, id := range shardIDs {
+		result := compactResult{Shard: id}
+		if n, err := h.ShardCompactor.CompactShard(id); err != nil {
+			result.Err = err.Error()
+		} else {
+			result.Reclaimed = n
+		}
+
+		w.Write(MarshalJSON(result, pretty))
+		w.Write([]byte("\n"))
+		w.(http.Flusher).Flush()
+	}
+}
+
+// serveSnapshot writes a snapshot of every shard that ended at or before
+// the "before" time to the file called "name" in the snapshot directory on
+// this node and returns its manifest.
+func (h *Handler) serveSnapshot(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	q := r.URL.Query()
+	pretty := q.Get("pretty") == "true"
+
+	if h.ClusterSnapshotter == nil || h.SnapshotDir == "" {
+		httpError(w, "cluster snapshots not enabled", pretty, http.StatusNotImplemented)
+		return
+	}
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to snapshot the cluster", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	before, err := time.Parse(time.RFC3339, q.Get("before"))
+	if err != nil {
+		httpError(w, `invalid or missing parameter "before"`, pretty, http.StatusBadRequest)
+		return
+	}
+	// The name can't leave the snapshot directory.
+	name := q.Get("name")
+	if name == "" {
+		httpError(w, `missing required parameter "name"`, pretty, http.StatusBadRequest)
+		return
+	} else if name != filepath.Base(name) || name == "." || name == ".." {
+		httpError(w, `invalid parameter "name"`, pretty, http.StatusBadRequest)
+		return
+	}
+
+	si, err := h.ClusterSnapshotter.Snapshot(before, filepath.Join(h.SnapshotDir, name))
+	if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.Write(MarshalJSON(si, pretty))
+}
+
+// clusterNode is a data node returned by the /cluster/nodes endpoint.
+type clusterNode struct {
+	ID     uint64 `json:"id"`
+	Host   string `json:"host"`
+	Status string `json:"status"`
+	Zone   string `json:"zone,omitempty"`
+	Rack   string `json:"rack,omitempty"`
+	Shards int    `json:"shards"`
+}
+
+// Statuses of a node returned by the /cluster/nodes endpoint.
+const (
+	nodeStatusActive   = "active"
+	nodeStatusDraining = "draining"
+)
+
+// clusterShard is a shard returned by the /cluster/shards endpoint.
+type clusterShard struct {
+	ID              uint64    `json:"id"`
+	Database        string    `json:"database"`
+	RetentionPolicy string    `json:"retentionPolicy"`
+	ShardGroup      uint64    `json:"shardGroup"`
+	StartTime       time.Time `json:"startTime"`
+	EndTime         time.Time `json:"endTime"`
+	Owners          []uint64  `json:"owners"`
+}
+
+// serveClusterNodes returns the data nodes in the cluster along with their
+// status and the number of shards each one owns.
+func (h *Handler) serveClusterNodes(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	pretty := r.URL.Query().Get("pretty") == "true"
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to view cluster nodes", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	nis, err := h.MetaStore.Nodes()
+	if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+	shards, err := h.clusterShards("")
+	if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+
+	// Count the shards owned by each node.
+	counts := make(map[uint64]int)
+	for _, sh := range shards {
+		for _, id := range sh.Owners {
+			counts[id]++
+		}
+	}
+
+	nodes := make([]clusterNode, 0, len(nis))
+	for _, ni := range nis {
+		status := nodeStatusActive
+		if ni.Draining {
+			status = nodeStatusDraining
+		}
+		nodes = append(nodes, clusterNode{
+			ID:     ni.ID,
+			Host:   ni.Host,
+			Status: status,
+			Zone:   ni.Zone,
+			Rack:   ni.Rack,
+			Shards: counts[ni.ID],
+		})
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.Write(MarshalJSON(nodes, pretty))
+}
+
+// serveClusterShards returns the shards in the cluster and the nodes that own
+// them. The shards can be limited to a database with "db" and to the shards
+// owned by a node with "node".
+func (h *Handler) serveClusterShards(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	q := r.URL.Query()
+	pretty := q.Get("pretty") == "true"
+
+	if h.requireAuthentication && !user.Admin {
+		httpError(w, fmt.Sprintf("%q user is not authorized to view cluster shards", user.Name), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	var nodeID uint64
+	if s := q.Get("node"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			httpError(w, `invalid parameter "node"`, pretty, http.StatusBadRequest)
+			return
+		}
+		nodeID = id
+	}
+
+	shards, err := h.clusterShards(q.Get("db"))
+	if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+
+	// Only keep the shards owned by the node, if one was given.
+	if nodeID != 0 {
+		other := shards[:0]
+		for _, sh := range shards {
+			for _, id := range sh.Owners {
+				if id == nodeID {
+					other = append(other, sh)
+					break
+				}
+			}
+		}
+		shards = other
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.Write(MarshalJSON(shards, pretty))
+}
+
+// clusterShards returns the shards of every shard group that hasn't been
+// deleted. Only shards in database are returned unless it is blank.
+func (h *Handler) clusterShards(database string) ([]clusterShard, error) {
+	dis, err := h.MetaStore.Databases()
+	if err != nil {
+		return nil, err
+	}
+
+	shards := []clusterShard{}
+	for _, di := range dis {
+		if database != "" && di.Name != database {
+			continue
+		}
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					shards = append(shards, clusterShard{
+						ID:              si.ID,
+						Database:        di.Name,
+						RetentionPolicy: rpi.Name,
+						ShardGroup:      sgi.ID,
+						StartTime:       sgi.StartTime,
+						EndTime:         sgi.EndTime,
+						Owners:          si.OwnerIDs,
+					})
+				}
+			}
+		}
+	}
+	return shards, nil
+}
+
 // serveQuery parses an incoming query and, if valid, executes the query.
 func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
 	q := r.URL.Query()
@@ -365,6 +990,12 @@ func (h *Handler) serveWriteJSON(w http.ResponseWriter, r *http.Request, body []
 		return
 	}
 
+	if h.MaxPointsPerBatch > 0 && len(bp.Points) > h.MaxPointsPerBatch {
+		err := fmt.Errorf("%s: %d points, limit %d", tsdb.ErrMaxPointsPerBatchExceeded, len(bp.Points), h.MaxPointsPerBatch)
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
+	}
+
 	points, err := NormalizeBatchPoints(bp)
 	if err != nil {
 		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
@@ -372,12 +1003,21 @@ func (h *Handler) serveWriteJSON(w http.ResponseWriter, r *http.Request, body []
 	}
 
 	// Convert the json batch struct to a points writer struct
-	if err := h.PointsWriter.WritePoints(&cluster.WritePointsRequest{
+	if err := h.writePoints(&cluster.WritePointsRequest{
 		Database:         bp.Database,
 		RetentionPolicy:  bp.RetentionPolicy,
 		ConsistencyLevel: cluster.ConsistencyLevelOne,
 		Points:           points,
-	}); influxdb.IsClientError(err) {
+	}, bp.RetentionPolicies); h.writeConsistencyFailure(w, err) {
+		return
+	} else if influxdb.IsRetryableError(err) {
+		w.Header().Set("Retry-After", strconv.Itoa(WriteRetryAfter))
+		resultError(w, influxql.Result{Err: err}, http.StatusServiceUnavailable)
+		return
+	} else if influxdb.IsNotFoundError(err) {
+		resultError(w, influxql.Result{Err: err}, http.StatusNotFound)
+		return
+	} else if influxdb.IsClientError(err) {
 		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
 		return
 	} else if err != nil {
@@ -418,9 +1058,12 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 	precision := r.FormValue("precision")
 	if precision == "" {
 		precision = "n"
+	} else if err := tsdb.ValidatePrecision(precision); err != nil {
+		h.writeError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
 	}
 
-	points, err := tsdb.ParsePointsWithPrecision(body, time.Now().UTC(), precision)
+	points, err := tsdb.ParsePointsWithLimit(body, time.Now().UTC(), precision, h.MaxPointsPerBatch)
 	if err != nil {
 		if err.Error() == "EOF" {
 			w.WriteHeader(http.StatusOK)
@@ -467,13 +1110,34 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 		consistency = cluster.ConsistencyLevelQuorum
 	}
 
+	// Determine how durable remote writes must be before they're acknowledged.
+	var durability cluster.DurabilityLevel
+	if s := r.Form.Get("durability"); s != "" {
+		l, err := cluster.ParseDurabilityLevel(s)
+		if err != nil {
+			h.writeError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+			return
+		}
+		durability = l
+	}
+
 	// Write points.
-	if err := h.PointsWriter.WritePoints(&cluster.WritePointsRequest{
+	if err := h.writePoints(&cluster.WritePointsRequest{
 		Database:         database,
 		RetentionPolicy:  r.FormValue("rp"),
 		ConsistencyLevel: consistency,
+		Durability:       durability,
 		Points:           points,
-	}); influxdb.IsClientError(err) {
+	}, r.Form["rp"]); h.writeConsistencyFailure(w, err) {
+		return
+	} else if influxdb.IsRetryableError(err) {
+		w.Header().Set("Retry-After", strconv.Itoa(WriteRetryAfter))
+		h.writeError(w, influxql.Result{Err: err}, http.StatusServiceUnavailable)
+		return
+	} else if influxdb.IsNotFoundError(err) {
+		h.writeError(w, influxql.Result{Err: err}, http.StatusNotFound)
+		return
+	} else if influxdb.IsClientError(err) {
 		h.writeError(w, influxql.Result{Err: err}, http.StatusBadRequest)
 		return
 	} else if err != nil {
@@ -484,11 +1148,233 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// writePoints writes the request to each retention policy in policies. If
+// fewer than two policies are given then only the request's policy is written.
+// Writes to several policies aren't atomic: if the write to one fails the
+// others have still been written. Requests larger than MaxWriteBatchSize are
+// written in several batches; if one fails the earlier batches have already
+// been written.
+func (h *Handler) writePoints(req *cluster.WritePointsRequest, policies []string) error {
+	batches := tsdb.Points(req.Points).Split(h.MaxWriteBatchSize, h.shardGroupDuration(req, policies))
+	if len(batches) == 1 {
+		return h.writeBatch(req, policies)
+	}
+
+	for _, batch := range batches {
+		other := *req
+		other.Points = batch
+		if err := h.writeBatch(&other, policies); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shardGroupDuration returns the shortest shard group duration of the
+// retention policies a request is written to, or zero if it's unknown.
+func (h *Handler) shardGroupDuration(req *cluster.WritePointsRequest, policies []string) time.Duration {
+	di, err := h.MetaStore.Database(req.Database)
+	if err != nil || di == nil {
+		return 0
+	}
+
+	if len(policies) == 0 {
+		name := req.RetentionPolicy
+		if name == "" {
+			name = di.DefaultRetentionPolicy
+		}
+		policies = []string{name}
+	}
+
+	var d time.Duration
+	for _, name := range policies {
+		rpi := di.RetentionPolicy(name)
+		if rpi == nil {
+			continue
+		} else if d == 0 || rpi.ShardGroupDuration < d {
+			d = rpi.ShardGroupDuration
+		}
+	}
+	return d
+}
+
+// writeBatch writes a single batch of points to each retention policy.
+func (h *Handler) writeBatch(req *cluster.WritePointsRequest, policies []string) error {
+	if len(policies) > 1 {
+		return h.PointsWriter.WritePointsToEachPolicy(req, policies)
+	}
+	return h.PointsWriter.WritePoints(req)
+}
+
+// writeConsistencyFailure writes the response to a write that didn't meet
+// its consistency level when the handler is configured to report partial
+// success or hinted handoff. Returns false if err should be returned to the
+// client as an error instead.
+func (h *Handler) writeConsistencyFailure(w http.ResponseWriter, err error) bool {
+	mode := h.WriteConsistencyFailure
+	if mode != WriteConsistencyFailurePartial && mode != WriteConsistencyFailureHandoff {
+		return false
+	}
+
+	errs := consistencyErrors(err)
+	if len(errs) == 0 {
+		return false
+	}
+
+	var resp writeConsistencyResponse
+	for _, e := range errs {
+		// A partial success requires a write to at least one owner and a
+		// handoff requires every owner to be written or queued.
+		if mode == WriteConsistencyFailurePartial && e.Written == 0 {
+			return false
+		} else if mode == WriteConsistencyFailureHandoff && e.Failed > 0 {
+			return false
+		}
+
+		resp.Shards = append(resp.Shards, shardWriteStatus{
+			ID:       e.ShardID,
+			Err:      e.Error(),
+			Required: e.Required,
+			Written:  e.Written,
+			Queued:   e.Queued,
+			Pending:  e.Pending,
+			Failed:   e.Failed,
+		})
+	}
+
+	w.Header().Add("content-type", "application/json")
+	if mode == WriteConsistencyFailurePartial {
+		resp.Partial = true
+		w.WriteHeader(http.StatusOK)
+	} else {
+		resp.Handoff = true
+		w.WriteHeader(http.StatusAccepted)
+	}
+	w.Write(MarshalJSON(resp, false))
+	return true
+}
+
+// consistencyErrors returns the consistency errors that caused a write to
+// fail. Returns nil if any part of the write failed for another reason.
+func consistencyErrors(err error) []*cluster.WriteConsistencyError {
+	switch err := err.(type) {
+	case *cluster.WriteConsistencyError:
+		return []*cluster.WriteConsistencyError{err}
+	case cluster.PolicyWriteError:
+		names := make([]string, 0, len(err))
+		for name := range err {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var a []*cluster.WriteConsistencyError
+		for _, name := range names {
+			e, ok := err[name].(*cluster.WriteConsistencyError)
+			if !ok {
+				return nil
+			}
+			a = append(a, e)
+		}
+		return a
+	}
+	return nil
+}
+
+// writeConsistencyResponse is the body of a response to a write that was
+// accepted without meeting its consistency level.
+type writeConsistencyResponse struct {
+	Partial bool               `json:"partial,omitempty"`
+	Handoff bool               `json:"handoff,omitempty"`
+	Shards  []shardWriteStatus `json:"shards"`
+}
+
+// shardWriteStatus is the outcome of a write to a single shard.
+type shardWriteStatus struct {
+	ID       uint64 `json:"id"`
+	Err      string `json:"error"`
+	Required int    `json:"required"`
+	Written  int    `json:"written"`
+	Queued   int    `json:"queued"`
+	Pending  int    `json:"pending"`
+	Failed   int    `json:"failed"`
+}
+
 // serveOptions returns an empty response to comply with OPTIONS pre-flight requests
 func (h *Handler) serveOptions(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// serveLast returns the most recent point written to each series of a measurement.
+func (h *Handler) serveLast(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	q := r.URL.Query()
+	pretty := q.Get("pretty") == "true"
+
+	db, measurement := q.Get("db"), q.Get("measurement")
+	if db == "" {
+		httpError(w, `missing required parameter "db"`, pretty, http.StatusBadRequest)
+		return
+	} else if measurement == "" {
+		httpError(w, `missing required parameter "measurement"`, pretty, http.StatusBadRequest)
+		return
+	}
+
+	if h.requireAuthentication && !user.Authorize(influxql.ReadPrivilege, db) {
+		httpError(w, fmt.Sprintf("%q user is not authorized to read from database %q", user.Name, db), pretty, http.StatusUnauthorized)
+		return
+	}
+
+	// Points are read from the database's default retention policy unless
+	// another is given, like a query.
+	rp := q.Get("rp")
+	if rp == "" {
+		di, err := h.MetaStore.Database(db)
+		if err != nil {
+			httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+			return
+		} else if di == nil {
+			httpError(w, fmt.Sprintf("database not found: %q", db), pretty, http.StatusNotFound)
+			return
+		}
+		rp = di.DefaultRetentionPolicy
+	}
+
+	points, err := h.TSDBStore.LastPoints(db, rp, measurement)
+	if err != nil {
+		httpError(w, err.Error(), pretty, http.StatusInternalServerError)
+		return
+	}
+
+	// Build a row for each series.
+	result := &influxql.Result{}
+	for _, p := range points {
+		fields := p.Fields()
+		names := make([]string, 0, len(fields))
+		for k := range fields {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		values := []interface{}{p.Time()}
+		for _, k := range names {
+			values = append(values, fields[k])
+		}
+
+		result.Series = append(result.Series, &influxql.Row{
+			Name:    p.Name(),
+			Tags:    p.Tags(),
+			Columns: append([]string{"time"}, names...),
+			Values:  [][]interface{}{values},
+		})
+	}
+
+	if epoch := strings.TrimSpace(q.Get("epoch")); epoch != "" {
+		convertToEpoch(result, epoch)
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.Write(MarshalJSON(Response{Results: []*influxql.Result{result}}, pretty))
+}
+
 // servePing returns a simple response to let the client know the server is running.
 func (h *Handler) servePing(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
@@ -590,6 +1476,20 @@ func parseCredentials(r *http.Request) (string, string, error) {
 	}
 }
 
+// parseAPIKey returns the API key encoded in a request, if any. The key may
+// be present as a URL query param or as a Token Authorization header.
+// as param: http://127.0.0.1/write?api_key=key
+// as header: Authorization: Token key
+func parseAPIKey(r *http.Request) string {
+	if key := r.URL.Query().Get("api_key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Token ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Token "))
+	}
+	return ""
+}
+
 // authenticate wraps a handler and ensures that if user credentials are passed in
 // an attempt is made to authenticate that user. If authentication fails, an error is returned.
 //
@@ -613,6 +1513,17 @@ func authenticate(inner func(http.ResponseWriter, *http.Request, *meta.UserInfo)
 
 		// TODO corylanou: never allow this in the future without users
 		if requireAuthentication && len(uis) > 0 {
+			// API keys are used in place of user credentials.
+			if key := parseAPIKey(r); key != "" {
+				user, err = h.MetaStore.AuthenticateAPIKey(key)
+				if err != nil {
+					httpError(w, err.Error(), false, http.StatusUnauthorized)
+					return
+				}
+				inner(w, r, user)
+				return
+			}
+
 			username, password, err := parseCredentials(r)
 			if err != nil {
 				httpError(w, err.Error(), false, http.StatusUnauthorized)
@@ -1,20 +1,27 @@
 package httpd_test
 
 import (
+	"archive/tar"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/influxdb/influxdb/client"
+	"github.com/influxdb/influxdb/cluster"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/parquet"
 	"github.com/influxdb/influxdb/services/httpd"
 	"github.com/influxdb/influxdb/tsdb"
 )
@@ -155,6 +162,830 @@ func TestHandler_Query(t *testing.T) {
 	}
 }
 
+// Ensure the handler returns the last point for each series.
+func TestHandler_Last(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name, DefaultRetentionPolicy: "rp0"}, nil
+	}
+	h.TSDBStore.LastPointsFn = func(database, retentionPolicy, measurement string) ([]tsdb.Point, error) {
+		if database != "foo" {
+			t.Fatalf("unexpected db: %s", database)
+		} else if retentionPolicy != "rp0" {
+			t.Fatalf("unexpected rp: %s", retentionPolicy)
+		} else if measurement != "cpu" {
+			t.Fatalf("unexpected measurement: %s", measurement)
+		}
+		return []tsdb.Point{
+			tsdb.NewPoint("cpu", tsdb.Tags{"host": "serverA"}, tsdb.Fields{"value": 1.5, "idle": 10.0}, time.Unix(0, 10)),
+			tsdb.NewPoint("cpu", tsdb.Tags{"host": "serverB"}, tsdb.Fields{"value": 2.5}, time.Unix(0, 20)),
+		}, nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/last?db=foo&measurement=cpu&epoch=n", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"results":[{"series":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","idle","value"],"values":[[10,10,1.5]]},{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[[20,2.5]]}]}]}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler reads the last points of a given retention policy.
+func TestHandler_Last_RetentionPolicy(t *testing.T) {
+	h := NewHandler(false)
+	h.TSDBStore.LastPointsFn = func(database, retentionPolicy, measurement string) ([]tsdb.Point, error) {
+		if retentionPolicy != "rp1" {
+			t.Fatalf("unexpected rp: %s", retentionPolicy)
+		}
+		return nil, nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/last?db=foo&rp=rp1&measurement=cpu", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler returns an error if the database doesn't exist.
+func TestHandler_Last_DatabaseNotFound(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return nil, nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/last?db=foo&measurement=cpu", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler returns an error if the measurement is missing.
+func TestHandler_Last_ErrMeasurementRequired(t *testing.T) {
+	h := NewHandler(false)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/last?db=foo", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"missing required parameter \"measurement\""}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler can decommission a node.
+func TestHandler_Decommission(t *testing.T) {
+	h := NewHandler(false)
+	var nodeID uint64
+	h.Handler.Decommissioner = &HandlerDecommissioner{
+		DecommissionFn: func(id uint64) error {
+			nodeID = id
+			return nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/decommission?node=2", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if nodeID != 2 {
+		t.Fatalf("unexpected node id: %d", nodeID)
+	}
+}
+
+// Ensure the handler returns an error if the node is invalid or doesn't exist.
+func TestHandler_Decommission_Err(t *testing.T) {
+	h := NewHandler(false)
+	h.Handler.Decommissioner = &HandlerDecommissioner{
+		DecommissionFn: func(id uint64) error { return meta.ErrNodeNotFound },
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/decommission?node=x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"invalid or missing parameter \"node\""}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/decommission?node=2", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"node not found"}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler returns the inconsistencies in the metadata and repairs them.
+func TestHandler_MetaCheck(t *testing.T) {
+	h := NewHandler(false)
+	var repaired bool
+	h.Handler.MetaChecker = &HandlerMetaChecker{
+		CheckFn: func() ([]string, error) {
+			if repaired {
+				return nil, nil
+			}
+			return []string{"shard 1: no owners"}, nil
+		},
+		RepairFn: func() ([]string, error) {
+			repaired = true
+			return nil, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/meta/check", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"problems":["shard 1: no owners"]}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/meta/repair", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"problems":[]}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	} else if !repaired {
+		t.Fatal("expected repair")
+	}
+}
+
+// Ensure the handler replicates the metadata of a primary cluster.
+func TestHandler_MetaReplicate(t *testing.T) {
+	h := NewHandler(false)
+	var replicated *meta.Data
+	h.Handler.MetaReplicator = &HandlerMetaReplicator{
+		ReplicateFn: func(data *meta.Data) error {
+			replicated = data
+			return nil
+		},
+	}
+
+	buf, err := (&meta.Data{Databases: []meta.DatabaseInfo{{Name: "db0"}}}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/meta/replicate", bytes.NewReader(buf)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if replicated == nil || len(replicated.Databases) != 1 || replicated.Databases[0].Name != "db0" {
+		t.Fatalf("unexpected metadata: %#v", replicated)
+	}
+
+	// Verify invalid metadata is rejected.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/meta/replicate", strings.NewReader("garbage")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler can join a node to the cluster.
+func TestHandler_JoinNode(t *testing.T) {
+	h := NewHandler(false)
+	h.Handler.NodeManager = &HandlerNodeManager{
+		CreateNodeFn: func(host string) (*meta.NodeInfo, error) {
+			if host != "host1:8088" {
+				t.Fatalf("unexpected host: %s", host)
+			}
+			return &meta.NodeInfo{ID: 2, Host: host}, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/nodes?host=host1:8088", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"ID":2,"Host":"host1:8088","Draining":false,"Zone":"","Rack":""}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/nodes", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler returns the nodes in the cluster with their shard counts.
+func TestHandler_ClusterNodes(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.NodesFn = func() ([]meta.NodeInfo, error) {
+		return []meta.NodeInfo{{ID: 1, Host: "host0:8088", Zone: "us-east"}, {ID: 2, Host: "host1:8088", Draining: true}}, nil
+	}
+	h.MetaStore.DatabasesFn = newClusterDatabases
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/cluster/nodes", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `[{"id":1,"host":"host0:8088","status":"active","zone":"us-east","shards":2},{"id":2,"host":"host1:8088","status":"draining","shards":1}]` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler returns the shards in the cluster, filtered by database and node.
+func TestHandler_ClusterShards(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabasesFn = newClusterDatabases
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/cluster/shards?db=db0&node=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `[{"id":10,"database":"db0","retentionPolicy":"rp0","shardGroup":1,"startTime":"2000-01-01T00:00:00Z","endTime":"2000-01-02T00:00:00Z","owners":[1,2]}]` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/cluster/shards?node=x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// newClusterDatabases returns databases with two live shards and one deleted shard group.
+func newClusterDatabases() ([]meta.DatabaseInfo, error) {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return []meta.DatabaseInfo{
+		{
+			Name: "db0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name: "rp0",
+				ShardGroups: []meta.ShardGroupInfo{
+					{ID: 1, StartTime: start, EndTime: start.Add(24 * time.Hour), Shards: []meta.ShardInfo{{ID: 10, OwnerIDs: []uint64{1, 2}}}},
+					{ID: 2, StartTime: start, EndTime: start.Add(24 * time.Hour), DeletedAt: start, Shards: []meta.ShardInfo{{ID: 11, OwnerIDs: []uint64{1}}}},
+				},
+			}},
+		},
+		{
+			Name: "db1",
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name: "rp0",
+				ShardGroups: []meta.ShardGroupInfo{
+					{ID: 3, StartTime: start, EndTime: start.Add(24 * time.Hour), Shards: []meta.ShardInfo{{ID: 12, OwnerIDs: []uint64{1}}}},
+				},
+			}},
+		},
+	}, nil
+}
+
+// Ensure the handler only removes a node that owns shards when forced.
+func TestHandler_RemoveNode(t *testing.T) {
+	h := NewHandler(false)
+	h.Handler.NodeManager = &HandlerNodeManager{
+		DeleteNodeFn: func(id uint64, force bool) error {
+			if id != 2 {
+				return meta.ErrNodeNotFound
+			} else if !force {
+				return meta.ErrNodeOwnsShards
+			}
+			return nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("DELETE", "/nodes?node=2", nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"node owns shards"}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("DELETE", "/nodes?node=3&force=true", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("DELETE", "/nodes?node=2&force=true", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler migrates each requested shard and streams the results.
+func TestHandler_Migrate(t *testing.T) {
+	h := NewHandler(false)
+	h.Handler.SchemaMigrator = &HandlerSchemaMigrator{
+		MigrateShardFn: func(shardID uint64, m *tsdb.Migration) (int, error) {
+			if m.Type != tsdb.TagToField || m.Measurement != "cpu" || m.Key != "region" {
+				t.Fatalf("unexpected migration: %#v", m)
+			} else if shardID == 2 {
+				return 0, tsdb.ErrShardNotFound
+			}
+			return 10, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/migrate?measurement=cpu&tag-to-field=region&shards=1,2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"shard":1,"points":10}`+"\n"+`{"shard":2,"points":0,"error":"shard not found"}`+"\n" {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/migrate?measurement=cpu&tag-to-field=region&field-to-tag=value&shards=1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/migrate?measurement=cpu&field-to-tag=value", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+func TestHandler_Compact(t *testing.T) {
+	h := NewHandler(false)
+	h.Handler.ShardCompactor = &HandlerShardCompactor{
+		CompactShardFn: func(shardID uint64) (int64, error) {
+			if shardID == 2 {
+				return 0, tsdb.ErrShardPinned
+			}
+			return 4096, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/compact?shards=1,2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"shard":1,"reclaimed":4096}`+"\n"+`{"shard":2,"reclaimed":0,"error":"shard pinned"}`+"\n" {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/compact", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler passes the requested durability level to the points writer.
+func TestHandler_Write_Durability(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	var durability cluster.DurabilityLevel
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		durability = p.Durability
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0&durability=memory", bytes.NewBufferString("cpu value=1")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if durability != cluster.DurabilityLevelMemory {
+		t.Fatalf("unexpected durability: %d", durability)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0&durability=eventually", bytes.NewBufferString("cpu value=1")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != "invalid durability level\n" {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler writes large requests in batches in time order.
+func TestHandler_Write_MaxWriteBatchSize(t *testing.T) {
+	h := NewHandler(false)
+	h.MaxWriteBatchSize = 40
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	var batches [][]int64
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		var a []int64
+		for _, pt := range p.Points {
+			a = append(a, pt.UnixNano())
+		}
+		batches = append(batches, a)
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0", bytes.NewBufferString("cpu value=1 3\ncpu value=1 1\ncpu value=1 2")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if !reflect.DeepEqual(batches, [][]int64{{1, 2}, {3}}) {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+}
+
+// Ensure the handler doesn't split a batch across shard groups.
+func TestHandler_Write_MaxWriteBatchSize_ShardGroups(t *testing.T) {
+	h := NewHandler(false)
+	h.MaxWriteBatchSize = 40
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{
+			Name:                   name,
+			DefaultRetentionPolicy: "rp0",
+			RetentionPolicies:      []meta.RetentionPolicyInfo{{Name: "rp0", ShardGroupDuration: 2}},
+		}, nil
+	}
+	var batches [][]int64
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		var a []int64
+		for _, pt := range p.Points {
+			a = append(a, pt.UnixNano())
+		}
+		batches = append(batches, a)
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0", bytes.NewBufferString("cpu value=1 3\ncpu value=1 1\ncpu value=1 2")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if !reflect.DeepEqual(batches, [][]int64{{1}, {2, 3}}) {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+}
+
+// Ensure the handler rejects writes with more points than a batch allows.
+func TestHandler_Write_MaxPointsPerBatch(t *testing.T) {
+	h := NewHandler(false)
+	h.MaxPointsPerBatch = 2
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		t.Fatal("unexpected write")
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0", bytes.NewBufferString("cpu value=1 1\ncpu value=1 2\ncpu value=1 3")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if !strings.Contains(w.Body.String(), tsdb.ErrMaxPointsPerBatchExceeded.Error()) {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler authenticates writes with an API key.
+func TestHandler_Write_APIKey(t *testing.T) {
+	h := NewHandler(true)
+	h.MetaStore.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{{Name: "admin", Admin: true}}, nil
+	}
+	h.MetaStore.AuthenticateAPIKeyFn = func(key string) (*meta.UserInfo, error) {
+		if key != "secret" {
+			return nil, meta.ErrAPIKeyInvalid
+		}
+		ak := meta.APIKeyInfo{Name: "telegraf", Privileges: map[string]influxql.Privilege{
+			"db0": influxql.WritePrivilege,
+			"db1": influxql.ReadPrivilege,
+		}}
+		return ak.UserInfo(), nil
+	}
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error { return nil }
+
+	// Write with the key in the Authorization header.
+	w := httptest.NewRecorder()
+	r := MustNewRequest("POST", "/write?db=db0", bytes.NewBufferString("cpu value=1"))
+	r.Header.Set("Authorization", "Token secret")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	// Write with the key as a query param.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0&api_key=secret", bytes.NewBufferString("cpu value=1")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	// The key is read-only on db1.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db1&api_key=secret", bytes.NewBufferString("cpu value=1")))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	// Unknown keys are rejected.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0&api_key=bad", bytes.NewBufferString("cpu value=1")))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if !strings.Contains(w.Body.String(), meta.ErrAPIKeyInvalid.Error()) {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure writes that dropped points are reported as client errors.
+func TestHandler_Write_PartialWriteError(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		return &tsdb.PartialWriteError{Reason: tsdb.ErrMaxSeriesPerDatabaseExceeded, Dropped: p.Points}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0", bytes.NewBufferString("cpu value=1")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if !strings.Contains(w.Body.String(), tsdb.ErrMaxSeriesPerDatabaseExceeded.Error()) {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler asks clients to retry writes rejected by a full write queue.
+func TestHandler_Write_WriteQueueFull(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		return fmt.Errorf("write shard 1: %s: database db0 has 10 bytes queued, limit 10", tsdb.ErrWriteQueueFull)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0", bytes.NewBufferString("cpu value=1")))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if s := w.Header().Get("Retry-After"); s != "1" {
+		t.Fatalf("unexpected Retry-After: %q", s)
+	}
+}
+
+// Ensure the handler returns a 404 for writes to a retention policy that doesn't exist.
+func TestHandler_Write_RetentionPolicyNotFound(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		return meta.ErrRetentionPolicyNotFound
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0&rp=rp1", bytes.NewBufferString("cpu value=1")))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := w.Body.String(); !strings.Contains(body, "retention policy not found") {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+// Ensure the handler returns the configured response to writes that don't meet their consistency level.
+func TestHandler_Write_ConsistencyFailure(t *testing.T) {
+	for i, tt := range []struct {
+		mode   string
+		err    error
+		status int
+		body   string
+	}{
+		{
+			mode:   httpd.WriteConsistencyFailureError,
+			err:    &cluster.WriteConsistencyError{Err: cluster.ErrPartialWrite, ShardID: 1, Required: 2, Written: 1, Queued: 1},
+			status: http.StatusInternalServerError,
+			body:   "partial write\n",
+		},
+		{
+			mode:   httpd.WriteConsistencyFailurePartial,
+			err:    &cluster.WriteConsistencyError{Err: cluster.ErrPartialWrite, ShardID: 1, Required: 2, Written: 1, Failed: 1},
+			status: http.StatusOK,
+			body:   `{"partial":true,"shards":[{"id":1,"error":"partial write","required":2,"written":1,"queued":0,"pending":0,"failed":1}]}`,
+		},
+		{
+			mode:   httpd.WriteConsistencyFailurePartial,
+			err:    &cluster.WriteConsistencyError{Err: cluster.ErrTimeout, ShardID: 1, Required: 1, Pending: 2},
+			status: http.StatusInternalServerError,
+			body:   "timeout\n",
+		},
+		{
+			mode:   httpd.WriteConsistencyFailureHandoff,
+			err:    &cluster.WriteConsistencyError{Err: cluster.ErrTimeout, ShardID: 1, Required: 3, Written: 1, Queued: 1, Pending: 1},
+			status: http.StatusAccepted,
+			body:   `{"handoff":true,"shards":[{"id":1,"error":"timeout","required":3,"written":1,"queued":1,"pending":1,"failed":0}]}`,
+		},
+		{
+			mode:   httpd.WriteConsistencyFailureHandoff,
+			err:    &cluster.WriteConsistencyError{Err: cluster.ErrPartialWrite, ShardID: 1, Required: 2, Written: 1, Failed: 1},
+			status: http.StatusInternalServerError,
+			body:   "partial write\n",
+		},
+	} {
+		h := NewHandler(false)
+		h.Handler.WriteConsistencyFailure = tt.mode
+		h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+			return &meta.DatabaseInfo{Name: name}, nil
+		}
+		h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error { return tt.err }
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0", bytes.NewBufferString("cpu value=1")))
+		if w.Code != tt.status {
+			t.Errorf("%d. unexpected status: %d", i, w.Code)
+		} else if w.Body.String() != tt.body {
+			t.Errorf("%d. unexpected body: %s", i, w.Body.String())
+		}
+	}
+}
+
+// Ensure the handler can snapshot the cluster.
+func TestHandler_Snapshot(t *testing.T) {
+	h := NewHandler(false)
+	h.Handler.SnapshotDir = "/tmp"
+	h.Handler.ClusterSnapshotter = &HandlerClusterSnapshotter{
+		SnapshotFn: func(before time.Time, path string) (*meta.SnapshotInfo, error) {
+			if !before.Equal(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("unexpected time: %s", before)
+			} else if path != "/tmp/snapshot" {
+				t.Fatalf("unexpected path: %s", path)
+			}
+			return &meta.SnapshotInfo{ID: 1, Time: before, Path: path}, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/snapshot?before=2000-01-01T00:00:00Z&name=snapshot", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"ID":1,"Time":"2000-01-01T00:00:00Z","CreatedAt":"0001-01-01T00:00:00Z","Path":"/tmp/snapshot","Shards":null}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler returns an error if the snapshot time is invalid.
+func TestHandler_Snapshot_ErrInvalidTime(t *testing.T) {
+	h := NewHandler(false)
+	h.Handler.SnapshotDir = "/tmp"
+	h.Handler.ClusterSnapshotter = &HandlerClusterSnapshotter{}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/snapshot?before=yesterday&name=snapshot", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"invalid or missing parameter \"before\""}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler only writes snapshots inside the snapshot directory.
+func TestHandler_Snapshot_ErrInvalidName(t *testing.T) {
+	h := NewHandler(false)
+	h.Handler.SnapshotDir = "/tmp"
+	h.Handler.ClusterSnapshotter = &HandlerClusterSnapshotter{}
+
+	for _, name := range []string{"/etc/snapshot", "../snapshot", "a/b", ".."} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewRequest("POST", "/snapshot?before=2000-01-01T00:00:00Z&name="+url.QueryEscape(name), nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: unexpected status: %d", name, w.Code)
+		} else if w.Body.String() != `{"error":"invalid parameter \"name\""}` {
+			t.Fatalf("%s: unexpected body: %s", name, w.Body.String())
+		}
+	}
+
+	// Snapshots are disabled without a directory.
+	h.Handler.SnapshotDir = ""
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/snapshot?before=2000-01-01T00:00:00Z&name=snapshot", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler exports points as Parquet files partitioned by time and tag.
+func TestHandler_Export(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{
+			Name: name,
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name:               "bar",
+				ShardGroupDuration: 24 * time.Hour,
+				ShardGroups: []meta.ShardGroupInfo{
+					{ID: 1, StartTime: time.Unix(0, 0), EndTime: time.Unix(86400, 0)},
+					{ID: 2, StartTime: time.Unix(86400, 0), EndTime: time.Unix(2*86400, 0)},
+				},
+			}},
+		}, nil
+	}
+
+	// Each day is read on its own.
+	var queries []string
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+		if db != "foo" {
+			t.Fatalf("unexpected db: %s", db)
+		}
+		queries = append(queries, q.String())
+
+		switch q.String() {
+		case `SELECT * FROM "foo"."bar".cpu WHERE time >= '1970-01-01 00:00:00' AND time < '1970-01-02 00:00:00' GROUP BY *`:
+			return NewResultChan(&influxql.Result{Series: influxql.Rows{
+				{
+					Name:    "cpu",
+					Tags:    map[string]string{"host": "serverA", "region": "west"},
+					Columns: []string{"time", "value"},
+					Values:  [][]interface{}{{time.Unix(0, 0).UTC(), 1.5}},
+				},
+				{
+					Name:    "cpu",
+					Tags:    map[string]string{"host": "serverB", "region": "west"},
+					Columns: []string{"time", "host", "value"},
+					Values:  [][]interface{}{{time.Unix(10, 0).UTC(), "other", int64(3)}},
+				},
+			}}), nil
+		default:
+			return NewResultChan(&influxql.Result{Series: influxql.Rows{
+				{
+					Name:    "cpu",
+					Tags:    map[string]string{"host": "serverA", "region": "west"},
+					Columns: []string{"time", "value"},
+					Values:  [][]interface{}{{time.Unix(86400, 0).UTC(), 2.5}},
+				},
+			}}), nil
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/export?db=foo&rp=bar&measurement=cpu&start=1970-01-01T00:00:00Z&end=1970-01-03T00:00:00Z&partition=region", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if exp := []string{
+		`SELECT * FROM "foo"."bar".cpu WHERE time >= '1970-01-01 00:00:00' AND time < '1970-01-02 00:00:00' GROUP BY *`,
+		`SELECT * FROM "foo"."bar".cpu WHERE time >= '1970-01-02 00:00:00' AND time < '1970-01-03 00:00:00' GROUP BY *`,
+	}; !reflect.DeepEqual(exp, queries) {
+		t.Fatalf("unexpected queries: %v", queries)
+	}
+
+	// Read each Parquet file back. The schema includes the non-partition tags
+	// and the field named like the host tag is renamed. Integers stored with
+	// floats are converted.
+	type file struct {
+		columns []parquet.Column
+		rows    [][]interface{}
+	}
+	files := make(map[string]file)
+	tr := tar.NewReader(w.Body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		buf, _ := ioutil.ReadAll(tr)
+		columns, rows, err := parquet.Read(buf)
+		if err != nil {
+			t.Fatalf("%s: %s", hdr.Name, err)
+		}
+		files[hdr.Name] = file{columns: columns, rows: rows}
+	}
+
+	if exp := map[string]file{
+		"cpu/time=1970-01-01T00%3A00%3A00Z/region=west/data.parquet": {
+			columns: []parquet.Column{
+				{Name: "time", Type: parquet.Timestamp, Required: true},
+				{Name: "host", Type: parquet.String},
+				{Name: "host_1", Type: parquet.String},
+				{Name: "value", Type: parquet.Double},
+			},
+			rows: [][]interface{}{
+				{time.Unix(0, 0).UTC(), "serverA", nil, 1.5},
+				{time.Unix(10, 0).UTC(), "serverB", "other", float64(3)},
+			},
+		},
+		"cpu/time=1970-01-02T00%3A00%3A00Z/region=west/data.parquet": {
+			columns: []parquet.Column{
+				{Name: "time", Type: parquet.Timestamp, Required: true},
+				{Name: "host", Type: parquet.String},
+				{Name: "value", Type: parquet.Double},
+			},
+			rows: [][]interface{}{
+				{time.Unix(86400, 0).UTC(), "serverA", 2.5},
+			},
+		},
+	}; !reflect.DeepEqual(exp, files) {
+		t.Fatalf("unexpected files: %#v", files)
+	}
+}
+
 // Ensure the handler merges results from the same statement.
 func TestHandler_Query_MergeResults(t *testing.T) {
 	h := NewHandler(false)
@@ -376,6 +1207,8 @@ type Handler struct {
 	*httpd.Handler
 	MetaStore     HandlerMetaStore
 	QueryExecutor HandlerQueryExecutor
+	PointsWriter  HandlerPointsWriter
+	TSDBStore     HandlerTSDBStore
 }
 
 // NewHandler returns a new instance of Handler.
@@ -385,6 +1218,8 @@ func NewHandler(requireAuthentication bool) *Handler {
 	}
 	h.Handler.MetaStore = &h.MetaStore
 	h.Handler.QueryExecutor = &h.QueryExecutor
+	h.Handler.PointsWriter = &h.PointsWriter
+	h.Handler.TSDBStore = &h.TSDBStore
 	h.Handler.Version = "0.0.0"
 	return h
 }
@@ -394,6 +1229,10 @@ type HandlerMetaStore struct {
 	DatabaseFn     func(name string) (*meta.DatabaseInfo, error)
 	AuthenticateFn func(username, password string) (ui *meta.UserInfo, err error)
 	UsersFn        func() ([]meta.UserInfo, error)
+	NodesFn        func() ([]meta.NodeInfo, error)
+	DatabasesFn    func() ([]meta.DatabaseInfo, error)
+
+	AuthenticateAPIKeyFn func(key string) (ui *meta.UserInfo, err error)
 }
 
 func (s *HandlerMetaStore) Database(name string) (*meta.DatabaseInfo, error) {
@@ -404,10 +1243,22 @@ func (s *HandlerMetaStore) Authenticate(username, password string) (ui *meta.Use
 	return s.AuthenticateFn(username, password)
 }
 
+func (s *HandlerMetaStore) AuthenticateAPIKey(key string) (ui *meta.UserInfo, err error) {
+	return s.AuthenticateAPIKeyFn(key)
+}
+
 func (s *HandlerMetaStore) Users() ([]meta.UserInfo, error) {
 	return s.UsersFn()
 }
 
+func (s *HandlerMetaStore) Nodes() ([]meta.NodeInfo, error) {
+	return s.NodesFn()
+}
+
+func (s *HandlerMetaStore) Databases() ([]meta.DatabaseInfo, error) {
+	return s.DatabasesFn()
+}
+
 // HandlerQueryExecutor is a mock implementation of Handler.QueryExecutor.
 type HandlerQueryExecutor struct {
 	ExecuteQueryFn func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error)
@@ -417,6 +1268,95 @@ func (e *HandlerQueryExecutor) ExecuteQuery(q *influxql.Query, db string, chunkS
 	return e.ExecuteQueryFn(q, db, chunkSize)
 }
 
+// HandlerPointsWriter is a mock implementation of Handler.PointsWriter.
+type HandlerPointsWriter struct {
+	WritePointsFn             func(p *cluster.WritePointsRequest) error
+	WritePointsToEachPolicyFn func(p *cluster.WritePointsRequest, policies []string) error
+}
+
+func (w *HandlerPointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
+	return w.WritePointsFn(p)
+}
+
+func (w *HandlerPointsWriter) WritePointsToEachPolicy(p *cluster.WritePointsRequest, policies []string) error {
+	return w.WritePointsToEachPolicyFn(p, policies)
+}
+
+// HandlerTSDBStore is a mock implementation of Handler.TSDBStore.
+type HandlerTSDBStore struct {
+	LastPointsFn func(database, retentionPolicy, measurement string) ([]tsdb.Point, error)
+}
+
+func (s *HandlerTSDBStore) LastPoints(database, retentionPolicy, measurement string) ([]tsdb.Point, error) {
+	return s.LastPointsFn(database, retentionPolicy, measurement)
+}
+
+// HandlerDecommissioner is a mock implementation of Handler.Decommissioner.
+type HandlerDecommissioner struct {
+	DecommissionFn func(nodeID uint64) error
+}
+
+func (d *HandlerDecommissioner) Decommission(nodeID uint64) error {
+	return d.DecommissionFn(nodeID)
+}
+
+// HandlerMetaChecker is a mock implementation of Handler.MetaChecker.
+type HandlerMetaChecker struct {
+	CheckFn  func() ([]string, error)
+	RepairFn func() ([]string, error)
+}
+
+func (c *HandlerMetaChecker) Check() ([]string, error)  { return c.CheckFn() }
+func (c *HandlerMetaChecker) Repair() ([]string, error) { return c.RepairFn() }
+
+// HandlerMetaReplicator is a mock implementation of Handler.MetaReplicator.
+type HandlerMetaReplicator struct {
+	ReplicateFn func(data *meta.Data) error
+}
+
+func (r *HandlerMetaReplicator) Replicate(data *meta.Data) error { return r.ReplicateFn(data) }
+
+// HandlerNodeManager is a mock implementation of Handler.NodeManager.
+type HandlerNodeManager struct {
+	CreateNodeFn func(host string) (*meta.NodeInfo, error)
+	DeleteNodeFn func(id uint64, force bool) error
+}
+
+func (m *HandlerNodeManager) CreateNode(host string) (*meta.NodeInfo, error) {
+	return m.CreateNodeFn(host)
+}
+
+func (m *HandlerNodeManager) DeleteNode(id uint64, force bool) error {
+	return m.DeleteNodeFn(id, force)
+}
+
+// HandlerSchemaMigrator is a mock implementation of Handler.SchemaMigrator.
+type HandlerSchemaMigrator struct {
+	MigrateShardFn func(shardID uint64, m *tsdb.Migration) (int, error)
+}
+
+func (m *HandlerSchemaMigrator) MigrateShard(shardID uint64, mig *tsdb.Migration) (int, error) {
+	return m.MigrateShardFn(shardID, mig)
+}
+
+// HandlerShardCompactor is a mock implementation of Handler.ShardCompactor.
+type HandlerShardCompactor struct {
+	CompactShardFn func(shardID uint64) (int64, error)
+}
+
+func (c *HandlerShardCompactor) CompactShard(shardID uint64) (int64, error) {
+	return c.CompactShardFn(shardID)
+}
+
+// HandlerClusterSnapshotter is a mock implementation of Handler.ClusterSnapshotter.
+type HandlerClusterSnapshotter struct {
+	SnapshotFn func(before time.Time, path string) (*meta.SnapshotInfo, error)
+}
+
+func (s *HandlerClusterSnapshotter) Snapshot(before time.Time, path string) (*meta.SnapshotInfo, error) {
+	return s.SnapshotFn(before, path)
+}
+
 // MustNewRequest returns a new HTTP request. Panic on error.
 func MustNewRequest(method, urlStr string, body io.Reader) *http.Request {
 	r, err := http.NewRequest(method, urlStr, body)
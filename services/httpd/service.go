@@ -33,6 +33,9 @@ func NewService(c Config) *Service {
 		Logger: log.New(os.Stderr, "[httpd] ", log.LstdFlags),
 	}
 	s.Handler.Logger = s.Logger
+	s.Handler.WriteConsistencyFailure = c.WriteConsistencyFailure
+	s.Handler.MaxWriteBatchSize = c.MaxWriteBatchSize
+	s.Handler.SnapshotDir = c.SnapshotDir
 	return s
 }
 
@@ -0,0 +1,62 @@
+package loadshed
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influxdb/influxdb/toml"
+)
+
+const (
+	// DefaultCheckInterval is the default time between heap samples.
+	DefaultCheckInterval = 1 * time.Second
+
+	// DefaultMaxPendingPoints is the default number of points from shedding
+	// inputs that may be waiting on the coordinator before points are shed.
+	DefaultMaxPendingPoints = 100000
+)
+
+// DefaultInputs are the input services that shed points by default.
+var DefaultInputs = []string{"graphite", "udp"}
+
+// Config represents the configuration for shedding points from low-priority
+// input services while the server is under pressure.
+type Config struct {
+	Enabled       bool          `toml:"enabled"`
+	CheckInterval toml.Duration `toml:"check-interval"`
+
+	// Names of the input services that may shed points.
+	Inputs []string `toml:"inputs"`
+
+	// Pressure thresholds. Zero disables a threshold.
+	MaxHeapSize      toml.Size `toml:"max-heap-size"`
+	MaxPendingPoints int       `toml:"max-pending-points"`
+
+	// Fraction of points kept while shedding. Zero drops every point.
+	SampleRate float64 `toml:"sample-rate"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		CheckInterval:    toml.Duration(DefaultCheckInterval),
+		Inputs:           DefaultInputs,
+		MaxPendingPoints: DefaultMaxPendingPoints,
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.CheckInterval <= 0 {
+		return errors.New("load-shedding check-interval must be positive")
+	} else if c.MaxHeapSize < 0 || c.MaxPendingPoints < 0 {
+		return errors.New("load-shedding thresholds must not be negative")
+	} else if c.SampleRate < 0 || c.SampleRate > 1 {
+		return errors.New("load-shedding sample-rate must be between 0 and 1")
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package loadshed
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// PointsWriter writes points to the coordinator.
+type PointsWriter interface {
+	WritePoints(p *cluster.WritePointsRequest) error
+}
+
+// Service sheds points from low-priority input services, such as UDP and
+// graphite, while the server is under pressure so that HTTP writes and
+// queries keep their share of memory and coordinator capacity.
+//
+// The server is under pressure while the sampled heap is above the heap
+// threshold or while more points from shedding inputs are waiting on the
+// coordinator than the pending threshold allows.
+type Service struct {
+	// Sampler returns the number of heap bytes in use.
+	Sampler interface {
+		HeapInUse() (uint64, error)
+	}
+
+	config Config
+	inputs map[string]bool
+
+	heapInUse uint64 // last sampled heap size, atomic
+	pending   int64  // points waiting on the coordinator, atomic
+	shedding  bool   // last observed state, only used by run()
+
+	mu    sync.Mutex
+	stats map[string]*input
+
+	wg   sync.WaitGroup
+	done chan struct{}
+
+	logger *log.Logger
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	s := &Service{
+		Sampler: RuntimeSampler{},
+		config:  c,
+		inputs:  make(map[string]bool),
+		stats:   make(map[string]*input),
+		done:    make(chan struct{}),
+		logger:  log.New(os.Stderr, "[loadshed] ", log.LstdFlags),
+	}
+	for _, name := range c.Inputs {
+		s.inputs[name] = true
+	}
+	return s
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	s.logger.Printf("starting load shedding for inputs %v", s.config.Inputs)
+
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Close stops the service.
+func (s *Service) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return nil
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.logger = l
+}
+
+// Writer returns a points writer for the named input service that sheds
+// points under pressure before passing them on to w. Returns w unchanged if
+// the input isn't configured to shed.
+func (s *Service) Writer(name string, w PointsWriter) PointsWriter {
+	if !s.inputs[name] {
+		return w
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Inputs with the same name, such as multiple graphite listeners, share
+	// their statistics.
+	in := s.stats[name]
+	if in == nil {
+		in = &input{}
+		s.stats[name] = in
+	}
+	return &Writer{PointsWriter: w, service: s, input: in}
+}
+
+// Stats returns the statistics of each shedding input service, keyed by name.
+func (s *Service) Stats() map[string]*Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := make(map[string]*Stats, len(s.stats))
+	for name, in := range s.stats {
+		m[name] = &Stats{
+			PointsReceived: atomic.LoadUint64(&in.stats.PointsReceived),
+			PointsShed:     atomic.LoadUint64(&in.stats.PointsShed),
+		}
+	}
+	return m
+}
+
+// Shedding returns true if the server is under pressure.
+func (s *Service) Shedding() bool {
+	if n := s.config.MaxHeapSize; n > 0 && atomic.LoadUint64(&s.heapInUse) > uint64(n) {
+		return true
+	}
+	if n := s.config.MaxPendingPoints; n > 0 && atomic.LoadInt64(&s.pending) > int64(n) {
+		return true
+	}
+	return false
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.CheckInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.check()
+		}
+	}
+}
+
+// check samples the heap and logs when shedding starts or stops.
+func (s *Service) check() {
+	if s.config.MaxHeapSize > 0 {
+		n, err := s.Sampler.HeapInUse()
+		if err != nil {
+			s.logger.Printf("failed to sample heap: %s", err)
+		} else {
+			atomic.StoreUint64(&s.heapInUse, n)
+		}
+	}
+
+	shedding := s.Shedding()
+	if shedding == s.shedding {
+		return
+	}
+	s.shedding = shedding
+
+	if shedding {
+		s.logger.Printf("shedding points from inputs (heap=%d, pending=%d)",
+			atomic.LoadUint64(&s.heapInUse), atomic.LoadInt64(&s.pending))
+	} else {
+		for name, st := range s.Stats() {
+			s.logger.Printf("stopped shedding points, %s shed %d of %d points", name, st.PointsShed, st.PointsReceived)
+		}
+	}
+}
+
+// StatisticsRow returns the statistics of each shedding input service for
+// SHOW STATS.
+func (s *Service) StatisticsRow() *influxql.Row {
+	stats := s.Stats()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	row := &influxql.Row{Name: "loadshed", Columns: []string{"input", "pointsReceived", "pointsShed"}}
+	for _, name := range names {
+		st := stats[name]
+		row.Values = append(row.Values, []interface{}{name, st.PointsReceived, st.PointsShed})
+	}
+	return row
+}
+
+// Stats are the statistics each shedding input service tracks.
+type Stats struct {
+	PointsReceived uint64 // Total count of points received from the input.
+	PointsShed     uint64 // Number of points dropped under pressure.
+}
+
+// input tracks the points received from an input service.
+type input struct {
+	stats   Stats
+	sampled uint64 // points seen while shedding
+}
+
+// Writer sheds points from an input service under pressure and writes the
+// rest to the underlying points writer.
+type Writer struct {
+	PointsWriter PointsWriter
+
+	service *Service
+	input   *input
+}
+
+// WritePoints writes the points that aren't shed.
+func (w *Writer) WritePoints(p *cluster.WritePointsRequest) error {
+	points := w.shed(p.Points)
+	if len(points) == 0 {
+		return nil
+	}
+
+	pending := &w.service.pending
+	atomic.AddInt64(pending, int64(len(points)))
+	defer atomic.AddInt64(pending, -int64(len(points)))
+
+	if len(points) == len(p.Points) {
+		return w.PointsWriter.WritePoints(p)
+	}
+	other := *p
+	other.Points = points
+	return w.PointsWriter.WritePoints(&other)
+}
+
+// shed returns the points that are kept. While shedding, a fraction of the
+// points equal to the sample rate is kept, spread evenly across writes.
+func (w *Writer) shed(points []tsdb.Point) []tsdb.Point {
+	in := w.input
+	atomic.AddUint64(&in.stats.PointsReceived, uint64(len(points)))
+	if !w.service.Shedding() {
+		return points
+	}
+
+	rate := w.service.config.SampleRate
+	kept := make([]tsdb.Point, 0, int(float64(len(points))*rate)+1)
+	for _, p := range points {
+		n := atomic.AddUint64(&in.sampled, 1)
+		if uint64(float64(n)*rate) > uint64(float64(n-1)*rate) {
+			kept = append(kept, p)
+		}
+	}
+	atomic.AddUint64(&in.stats.PointsShed, uint64(len(points)-len(kept)))
+	return kept
+}
+
+// RuntimeSampler samples the heap from the Go runtime.
+type RuntimeSampler struct{}
+
+// HeapInUse returns the number of bytes in in-use heap spans.
+func (RuntimeSampler) HeapInUse() (uint64, error) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse, nil
+}
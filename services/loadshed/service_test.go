@@ -0,0 +1,149 @@
+package loadshed_test
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/services/loadshed"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Ensure inputs that aren't configured to shed get the writer unchanged.
+func TestService_Writer_NotShedding(t *testing.T) {
+	s := NewService(loadshed.Config{Inputs: []string{"udp"}})
+	var pw PointsWriter
+	if w := s.Writer("opentsdb", &pw); w != &pw {
+		t.Fatalf("unexpected writer: %#v", w)
+	}
+}
+
+// Ensure points are shed once too many are waiting on the coordinator.
+func TestService_Writer_MaxPendingPoints(t *testing.T) {
+	s := NewService(loadshed.Config{Inputs: []string{"udp"}, MaxPendingPoints: 2})
+
+	// Block writes until released so points stay pending.
+	release := make(chan struct{})
+	written := make(chan int, 10)
+	pw := &PointsWriter{WritePointsFn: func(p *cluster.WritePointsRequest) error {
+		<-release
+		written <- len(p.Points)
+		return nil
+	}}
+	w := s.Writer("udp", pw)
+
+	// Three pending points exceed the threshold.
+	errs := make(chan error)
+	go func() { errs <- w.WritePoints(&cluster.WritePointsRequest{Points: NewPoints(3)}) }()
+	for !s.Shedding() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// New points are dropped while shedding.
+	if err := w.WritePoints(&cluster.WritePointsRequest{Points: NewPoints(5)}); err != nil {
+		t.Fatal(err)
+	}
+
+	close(release)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	} else if n := <-written; n != 3 {
+		t.Fatalf("unexpected points written: %d", n)
+	} else if s.Shedding() {
+		t.Fatal("expected shedding to stop")
+	}
+
+	if st := s.Stats()["udp"]; st.PointsReceived != 8 || st.PointsShed != 5 {
+		t.Fatalf("unexpected stats: %+v", st)
+	} else if row := s.StatisticsRow(); !reflect.DeepEqual(row.Values, [][]interface{}{{"udp", uint64(8), uint64(5)}}) {
+		t.Fatalf("unexpected row: %v", row.Values)
+	}
+}
+
+// Ensure a fraction of points is kept while shedding under heap pressure.
+func TestService_Writer_SampleRate(t *testing.T) {
+	s := NewService(loadshed.Config{
+		Inputs:        []string{"graphite"},
+		MaxHeapSize:   1000,
+		SampleRate:    0.25,
+		CheckInterval: 1,
+	})
+	s.Sampler = &Sampler{heap: 2000}
+
+	var n int
+	w := s.Writer("graphite", &PointsWriter{WritePointsFn: func(p *cluster.WritePointsRequest) error {
+		if p.Database != "db0" {
+			t.Fatalf("unexpected database: %s", p.Database)
+		}
+		n += len(p.Points)
+		return nil
+	}})
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for !s.Shedding() {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := w.WritePoints(&cluster.WritePointsRequest{Database: "db0", Points: NewPoints(10)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n != 10 {
+		t.Fatalf("unexpected points written: %d", n)
+	} else if st := s.Stats()["graphite"]; st.PointsReceived != 40 || st.PointsShed != 30 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+// Ensure the config is validated.
+func TestConfig_Validate(t *testing.T) {
+	c := loadshed.NewConfig()
+	c.Enabled = true
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c.SampleRate = 1.5
+	if err := c.Validate(); err == nil || err.Error() != "load-shedding sample-rate must be between 0 and 1" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// NewService returns a new service with logging discarded.
+func NewService(c loadshed.Config) *loadshed.Service {
+	s := loadshed.NewService(c)
+	s.SetLogger(log.New(ioutil.Discard, "", 0))
+	return s
+}
+
+// NewPoints returns n points.
+func NewPoints(n int) []tsdb.Point {
+	points := make([]tsdb.Point, n)
+	for i := range points {
+		points[i] = tsdb.NewPoint("cpu", nil, tsdb.Fields{"value": float64(i)}, time.Unix(int64(i+1), 0))
+	}
+	return points
+}
+
+// PointsWriter is a mock implementation of loadshed.PointsWriter.
+type PointsWriter struct {
+	WritePointsFn func(p *cluster.WritePointsRequest) error
+}
+
+func (w *PointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
+	return w.WritePointsFn(p)
+}
+
+// Sampler is a mock implementation of Service.Sampler.
+type Sampler struct {
+	heap uint64
+}
+
+func (s *Sampler) HeapInUse() (uint64, error) { return s.heap, nil }
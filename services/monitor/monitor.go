@@ -1,13 +1,53 @@
 package monitor
 
+import (
+	"strconv"
+	"time"
+
+	"github.com/influxdb/influxdb/tsdb"
+)
+
 // Monitor represents a TSDB monitoring service.
 type Monitor struct {
-	Store interface{}
+	// Store, if set, provides the statistics of the local shards.
+	Store interface {
+		Statistics() []tsdb.ShardStatistics
+	}
 }
 
 func (m *Monitor) Open() error  { return nil }
 func (m *Monitor) Close() error { return nil }
 
+// ShardPoints returns a point with the statistics of each local shard,
+// tagged with the shard's ID and database, to be written at time now.
+func (m *Monitor) ShardPoints(now time.Time) []tsdb.Point {
+	if m.Store == nil {
+		return nil
+	}
+
+	var points []tsdb.Point
+	for _, st := range m.Store.Statistics() {
+		points = append(points, tsdb.NewPoint(
+			"shard",
+			tsdb.Tags{"id": strconv.FormatUint(st.ID, 10), "database": st.Database},
+			tsdb.Fields{
+				"diskBytes":     st.DiskBytes,
+				"seriesN":       int64(st.SeriesN),
+				"hotCacheBytes": int64(st.HotCacheBytes),
+				"pendingWrites": int64(st.PendingWrites),
+				"writeN":        st.WriteN,
+				"writeNs":       int64(st.WriteDuration),
+				"queryN":        st.QueryN,
+				"queryNs":       int64(st.QueryDuration),
+				"fieldsCoerced": st.FieldsCoerced,
+				"fieldsDropped": st.FieldsDropped,
+			},
+			now,
+		))
+	}
+	return points
+}
+
 // StartSelfMonitoring starts a goroutine which monitors the InfluxDB server
 // itself and stores the results in the specified database at a given interval.
 /*
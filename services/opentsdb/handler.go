@@ -101,12 +101,12 @@ func (h *Handler) servePut(w http.ResponseWriter, r *http.Request) {
 		p := dps[i]
 
 		// Convert timestamp to Go time.
-		// If time value is over a billion then it's microseconds.
+		// If time value is over ten billion then it's milliseconds.
 		var ts time.Time
 		if p.Time < 10000000000 {
 			ts = time.Unix(p.Time, 0)
 		} else {
-			ts = time.Unix(p.Time/1000, (p.Time%1000)*1000)
+			ts = time.Unix(p.Time/1000, (p.Time%1000)*int64(time.Millisecond))
 		}
 
 		points = append(points, tsdb.NewPoint(p.Metric, p.Tags, map[string]interface{}{"value": p.Value}, ts))
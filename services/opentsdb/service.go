@@ -26,8 +26,9 @@ type Service struct {
 	ln     net.Listener  // main listener
 	httpln *chanListener // http channel-based listener
 
-	wg  sync.WaitGroup
-	err chan error
+	wg   sync.WaitGroup
+	err  chan error
+	done chan struct{}
 
 	BindAddress      string
 	Database         string
@@ -40,6 +41,7 @@ type Service struct {
 	MetaStore interface {
 		WaitForLeader(d time.Duration) error
 		CreateDatabaseIfNotExists(name string) (*meta.DatabaseInfo, error)
+		Watch() <-chan struct{}
 	}
 
 	Logger *log.Logger
@@ -70,6 +72,7 @@ func (s *Service) Open() error {
 		return err
 	}
 
+	changed := s.MetaStore.Watch()
 	if _, err := s.MetaStore.CreateDatabaseIfNotExists(s.Database); err != nil {
 		s.Logger.Printf("failed to ensure target database %s exists: %s", s.Database, err.Error())
 		return err
@@ -87,15 +90,22 @@ func (s *Service) Open() error {
 	s.Logger.Println("listening on:", ln.Addr().String())
 
 	// Begin listening for connections.
-	s.wg.Add(2)
+	s.done = make(chan struct{})
+	s.wg.Add(3)
 	go s.serveHTTP()
 	go s.serve()
+	go s.watchDatabase(s.done, changed)
 
 	return nil
 }
 
 // Close closes the underlying listener.
 func (s *Service) Close() error {
+	if s.done != nil {
+		close(s.done)
+		s.done = nil
+	}
+
 	if s.ln != nil {
 		return s.ln.Close()
 	}
@@ -104,6 +114,25 @@ func (s *Service) Close() error {
 	return nil
 }
 
+// watchDatabase recreates the target database if it is dropped while the
+// service is running. Otherwise writes would fail until a restart. Returns
+// when done is closed.
+func (s *Service) watchDatabase(done, changed <-chan struct{}) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-done:
+			return
+		case <-changed:
+		}
+
+		changed = s.MetaStore.Watch()
+		if _, err := s.MetaStore.CreateDatabaseIfNotExists(s.Database); err != nil {
+			s.Logger.Printf("failed to ensure target database %s exists: %s", s.Database, err.Error())
+		}
+	}
+}
+
 // SetLogger sets the internal logger to the logger passed in.
 func (s *Service) SetLogger(l *log.Logger) { s.Logger = l }
 
@@ -206,7 +235,7 @@ func (s *Service) handleTelnetConn(conn net.Conn) {
 			t = time.Unix(ts, 0)
 			break
 		case 13:
-			t = time.Unix(ts/1000, (ts%1000)*1000)
+			t = time.Unix(ts/1000, (ts%1000)*int64(time.Millisecond))
 			break
 		default:
 			s.Logger.Println("TSDBServer: time must be 10 or 13 chars, skipping: ", tsStr)
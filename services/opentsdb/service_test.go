@@ -165,3 +165,5 @@ func (d *DatabaseCreator) CreateDatabaseIfNotExists(name string) (*meta.Database
 func (d *DatabaseCreator) WaitForLeader(t time.Duration) error {
 	return nil
 }
+
+func (d *DatabaseCreator) Watch() <-chan struct{} { return nil }
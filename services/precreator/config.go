@@ -1,6 +1,7 @@
 package precreator
 
 import (
+	"errors"
 	"time"
 
 	"github.com/influxdb/influxdb/toml"
@@ -10,8 +11,8 @@ const (
 	// DefaultCheckInterval is the shard precreation check time if none is specified.
 	DefaultCheckInterval = 10 * time.Minute
 
-	// DefaultAdvancePeriod is the default period ahead of the endtime of a shard group
-	// that its successor group is created.
+	// DefaultAdvancePeriod is the default period ahead of the start time of a
+	// shard group that it is created.
 	DefaultAdvancePeriod = 30 * time.Minute
 )
 
@@ -30,3 +31,17 @@ func NewConfig() Config {
 		AdvancePeriod: toml.Duration(DefaultAdvancePeriod),
 	}
 }
+
+// Validate returns an error if groups could start before they are precreated.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.CheckInterval <= 0 {
+		return errors.New("shard-precreation check-interval must be positive")
+	} else if c.AdvancePeriod <= c.CheckInterval {
+		return errors.New("shard-precreation advance-period must be greater than check-interval")
+	}
+	return nil
+}
@@ -29,3 +29,22 @@ advance-period = "10m"
 		t.Fatalf("unexpected advance period: %s", c.AdvancePeriod)
 	}
 }
+
+func TestConfig_Validate(t *testing.T) {
+	c := precreator.NewConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Groups must be created before they start.
+	c.AdvancePeriod = c.CheckInterval
+	if err := c.Validate(); err == nil || err.Error() != "shard-precreation advance-period must be greater than check-interval" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Disabled services aren't validated.
+	c.Enabled = false
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -18,7 +18,7 @@ type Service struct {
 
 	MetaStore interface {
 		IsLeader() bool
-		PrecreateShardGroups(cutoff time.Time) error
+		PrecreateShardGroups(from, to time.Time) error
 	}
 }
 
@@ -87,10 +87,9 @@ func (s *Service) runPrecreation() {
 	}
 }
 
-// precreate performs actual resource precreation.
+// precreate creates the shard groups that start within the advance period of t.
 func (s *Service) precreate(t time.Time) error {
-	cutoff := t.Add(s.advancePeriod).UTC()
-	if err := s.MetaStore.PrecreateShardGroups(cutoff); err != nil {
+	if err := s.MetaStore.PrecreateShardGroups(t, t.Add(s.advancePeriod).UTC()); err != nil {
 		return err
 	}
 	return nil
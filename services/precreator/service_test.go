@@ -18,10 +18,12 @@ func Test_ShardPrecreation(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 	ms := metaStore{
-		PrecreateShardGroupsFn: func(u time.Time) error {
+		PrecreateShardGroupsFn: func(from, to time.Time) error {
 			wg.Done()
-			if u != now.Add(advancePeriod) {
-				t.Fatalf("precreation called with wrong time, got %s, exp %s", u, now)
+			if from != now {
+				t.Fatalf("precreation called with wrong start time, got %s, exp %s", from, now)
+			} else if to != now.Add(advancePeriod) {
+				t.Fatalf("precreation called with wrong end time, got %s, exp %s", to, now.Add(advancePeriod))
 			}
 			return nil
 		},
@@ -47,13 +49,13 @@ func Test_ShardPrecreation(t *testing.T) {
 
 // PointsWriter represents a mock impl of PointsWriter.
 type metaStore struct {
-	PrecreateShardGroupsFn func(cutoff time.Time) error
+	PrecreateShardGroupsFn func(from, to time.Time) error
 }
 
 func (m metaStore) IsLeader() bool {
 	return true
 }
 
-func (m metaStore) PrecreateShardGroups(timestamp time.Time) error {
-	return m.PrecreateShardGroupsFn(timestamp)
+func (m metaStore) PrecreateShardGroups(from, to time.Time) error {
+	return m.PrecreateShardGroupsFn(from, to)
 }
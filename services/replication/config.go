@@ -0,0 +1,58 @@
+package replication
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/influxdb/influxdb/toml"
+)
+
+const (
+	// DefaultInterval is the default time between full resyncs of the
+	// metadata to the standby cluster.
+	DefaultInterval = 1 * time.Minute
+
+	// DefaultTimeout is the default timeout for requests to the standby cluster.
+	DefaultTimeout = 30 * time.Second
+)
+
+// Config represents the configuration for replicating metadata to a standby cluster.
+type Config struct {
+	Enabled bool `toml:"enabled"`
+
+	// HTTP API of any node in the standby cluster and the credentials of an
+	// admin user on it.
+	URL      string `toml:"url"`
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+
+	// The metadata is sent whenever it changes and again every interval, so
+	// the standby catches up after an outage.
+	Interval toml.Duration `toml:"interval"`
+	Timeout  toml.Duration `toml:"timeout"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		Interval: toml.Duration(DefaultInterval),
+		Timeout:  toml.Duration(DefaultTimeout),
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.URL == "" {
+		return errors.New("replication url must be specified")
+	} else if u, err := url.Parse(c.URL); err != nil || u.Host == "" {
+		return errors.New("replication url must be an absolute URL")
+	} else if c.Interval <= 0 {
+		return errors.New("replication interval must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package replication_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdb/influxdb/services/replication"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c replication.Config
+	if _, err := toml.Decode(`
+enabled = true
+url = "http://standby:8086"
+username = "admin"
+password = "secret"
+interval = "30s"
+timeout = "5s"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if c.Enabled != true {
+		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if c.URL != "http://standby:8086" {
+		t.Fatalf("unexpected url: %s", c.URL)
+	} else if c.Username != "admin" || c.Password != "secret" {
+		t.Fatalf("unexpected credentials: %s/%s", c.Username, c.Password)
+	} else if time.Duration(c.Interval) != 30*time.Second {
+		t.Fatalf("unexpected interval: %v", c.Interval)
+	} else if time.Duration(c.Timeout) != 5*time.Second {
+		t.Fatalf("unexpected timeout: %v", c.Timeout)
+	} else if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure invalid configurations are rejected.
+func TestConfig_Validate(t *testing.T) {
+	for i, tt := range []struct {
+		c   replication.Config
+		err string
+	}{
+		{c: replication.Config{}},
+		{c: replication.Config{Enabled: true, Interval: 1}, err: "replication url must be specified"},
+		{c: replication.Config{Enabled: true, URL: "standby", Interval: 1}, err: "replication url must be an absolute URL"},
+		{c: replication.Config{Enabled: true, URL: "http://standby:8086"}, err: "replication interval must be positive"},
+	} {
+		err := tt.c.Validate()
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
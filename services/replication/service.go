@@ -0,0 +1,131 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Service ships the metadata of this cluster to a standby cluster so a
+// disaster recovery site keeps the same databases, retention policies, users
+// and roles. Only the raft leader sends the metadata. Shard data isn't
+// replicated.
+type Service struct {
+	MetaStore interface {
+		IsLeader() bool
+		Watch() <-chan struct{}
+		MarshalBinary() ([]byte, error)
+	}
+
+	url      string
+	username string
+	password string
+	interval time.Duration
+	client   *http.Client
+
+	wg   sync.WaitGroup
+	done chan struct{}
+
+	logger *log.Logger
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		url:      strings.TrimSuffix(c.URL, "/"),
+		username: c.Username,
+		password: c.Password,
+		interval: time.Duration(c.Interval),
+		client:   &http.Client{Timeout: time.Duration(c.Timeout)},
+		done:     make(chan struct{}),
+		logger:   log.New(os.Stderr, "[replication] ", log.LstdFlags),
+	}
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Close stops the service.
+func (s *Service) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return nil
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.logger = l
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		// Watch before reading so no change is missed while sending.
+		changed := s.MetaStore.Watch()
+		if s.MetaStore.IsLeader() {
+			if err := s.Replicate(); err != nil {
+				s.logger.Printf("failed to replicate metadata to %s: %s", s.url, err)
+			}
+		}
+
+		select {
+		case <-s.done:
+			s.logger.Println("metadata replication terminating")
+			return
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Replicate sends the current metadata to the standby cluster.
+func (s *Service) Replicate() error {
+	buf, err := s.MetaStore.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("marshal meta: %s", err)
+	}
+
+	v := url.Values{}
+	if s.username != "" {
+		v.Set("u", s.username)
+		v.Set("p", s.password)
+	}
+	u := s.url + "/meta/replicate"
+	if len(v) > 0 {
+		u += "?" + v.Encode()
+	}
+
+	resp, err := s.client.Post(u, "application/octet-stream", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	// Errors are returned as a JSON response.
+	var body struct {
+		Err string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Err == "" {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return errors.New(body.Err)
+}
@@ -0,0 +1,69 @@
+package replication_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdb/influxdb/services/replication"
+	"github.com/influxdb/influxdb/toml"
+)
+
+// Ensure the metadata is posted to the standby cluster.
+func TestService_Replicate(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/meta/replicate" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		} else if u, p := r.URL.Query().Get("u"), r.URL.Query().Get("p"); u != "admin" || p != "secret" {
+			t.Fatalf("unexpected credentials: %s/%s", u, p)
+		}
+		buf, _ := ioutil.ReadAll(r.Body)
+		body = string(buf)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	s := NewService(ts.URL + "/")
+	if err := s.Replicate(); err != nil {
+		t.Fatal(err)
+	} else if body != "META" {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+// Ensure an error from the standby cluster is returned.
+func TestService_Replicate_Err(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"authorization failed"}`))
+	}))
+	defer ts.Close()
+
+	if err := NewService(ts.URL).Replicate(); err == nil || err.Error() != "authorization failed" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// NewService returns a service that replicates to url with test credentials.
+func NewService(url string) *replication.Service {
+	s := replication.NewService(replication.Config{
+		URL:      url,
+		Username: "admin",
+		Password: "secret",
+		Interval: toml.Duration(replication.DefaultInterval),
+		Timeout:  toml.Duration(replication.DefaultTimeout),
+	})
+	s.SetLogger(log.New(ioutil.Discard, "", 0))
+	s.MetaStore = &MetaStore{}
+	return s
+}
+
+// MetaStore is a mock implementation of Service.MetaStore.
+type MetaStore struct{}
+
+func (m *MetaStore) IsLeader() bool                 { return true }
+func (m *MetaStore) Watch() <-chan struct{}         { return make(chan struct{}) }
+func (m *MetaStore) MarshalBinary() ([]byte, error) { return []byte("META"), nil }
@@ -1,6 +1,8 @@
 package retention
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/influxdb/influxdb/toml"
@@ -9,8 +11,48 @@ import (
 type Config struct {
 	Enabled       bool          `toml:"enabled"`
 	CheckInterval toml.Duration `toml:"check-interval"`
+
+	// TombstoneExpiration is how long deleted shard groups are kept in the
+	// metadata before they are pruned. Zero keeps them forever.
+	TombstoneExpiration toml.Duration `toml:"tombstone-expiration"`
+
+	// MeasurementTTLs expire the points of individual measurements before
+	// their retention policy's duration.
+	MeasurementTTLs []MeasurementTTL `toml:"measurement-ttl"`
+}
+
+// MeasurementTTL removes the points of a measurement once they are older than
+// the TTL, instead of when their shard group expires. The leader stores it on
+// the retention policy in the metadata; a zero TTL removes it.
+type MeasurementTTL struct {
+	Database string `toml:"database"`
+
+	// The retention policy holding the measurement. Applies to every
+	// retention policy in the database if blank.
+	RetentionPolicy string `toml:"retention-policy"`
+
+	Measurement string        `toml:"measurement"`
+	TTL         toml.Duration `toml:"ttl"`
 }
 
 func NewConfig() Config {
-	return Config{Enabled: true, CheckInterval: toml.Duration(10 * time.Minute)}
+	return Config{
+		Enabled:             true,
+		CheckInterval:       toml.Duration(10 * time.Minute),
+		TombstoneExpiration: toml.Duration(24 * time.Hour),
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	for _, m := range c.MeasurementTTLs {
+		if m.Database == "" {
+			return errors.New("measurement ttl database must be specified")
+		} else if m.Measurement == "" {
+			return errors.New("measurement ttl measurement must be specified")
+		} else if m.TTL < 0 {
+			return fmt.Errorf("measurement ttl for %s must not be negative", m.Measurement)
+		}
+	}
+	return nil
 }
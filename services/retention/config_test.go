@@ -14,6 +14,13 @@ func TestConfig_Parse(t *testing.T) {
 	if _, err := toml.Decode(`
 enabled = true
 check-interval = "1s"
+tombstone-expiration = "2h"
+
+[[measurement-ttl]]
+database = "db0"
+retention-policy = "rp0"
+measurement = "cpu"
+ttl = "168h"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -23,5 +30,31 @@ check-interval = "1s"
 		t.Fatalf("unexpected enabled state: %v", c.Enabled)
 	} else if time.Duration(c.CheckInterval) != time.Second {
 		t.Fatalf("unexpected check interval: %v", c.CheckInterval)
+	} else if time.Duration(c.TombstoneExpiration) != 2*time.Hour {
+		t.Fatalf("unexpected tombstone expiration: %v", c.TombstoneExpiration)
+	} else if len(c.MeasurementTTLs) != 1 {
+		t.Fatalf("unexpected measurement ttls: %+v", c.MeasurementTTLs)
+	} else if m := c.MeasurementTTLs[0]; m.Database != "db0" || m.RetentionPolicy != "rp0" || m.Measurement != "cpu" || time.Duration(m.TTL) != 7*24*time.Hour {
+		t.Fatalf("unexpected measurement ttls: %+v", c.MeasurementTTLs)
+	} else if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure invalid configurations are rejected.
+func TestConfig_Validate(t *testing.T) {
+	for i, tt := range []struct {
+		c   retention.Config
+		err string
+	}{
+		{c: retention.Config{}},
+		{c: retention.Config{MeasurementTTLs: []retention.MeasurementTTL{{Measurement: "cpu", TTL: 1}}}, err: "measurement ttl database must be specified"},
+		{c: retention.Config{MeasurementTTLs: []retention.MeasurementTTL{{Database: "db0", TTL: 1}}}, err: "measurement ttl measurement must be specified"},
+		{c: retention.Config{MeasurementTTLs: []retention.MeasurementTTL{{Database: "db0", Measurement: "cpu", TTL: -1}}}, err: "measurement ttl for cpu must not be negative"},
+	} {
+		err := tt.c.Validate()
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		}
 	}
 }
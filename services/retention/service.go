@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 )
 
@@ -15,16 +16,30 @@ type Service struct {
 		IsLeader() bool
 		VisitRetentionPolicies(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo))
 		DeleteShardGroup(database, policy string, id uint64) error
+		PruneShardGroups(expiration time.Time) error
+		SetMeasurementTTL(database, policy, measurement string, ttl time.Duration) error
+		NodeID() uint64
+		Watch() <-chan struct{}
 	}
 	TSDBStore interface {
 		ShardIDs() []uint64
+		ShardArchived(shardID uint64) bool
 		DeleteShard(shardID uint64) error
+		DeleteSeriesRange(shardID uint64, measurement string, condition influxql.Expr, min, max int64) error
 	}
 
-	enabled       bool
-	checkInterval time.Duration
-	wg            sync.WaitGroup
-	done          chan struct{}
+	// ShardDeleter, if set, is used to tell remote owners of expired shards
+	// to remove their data.
+	ShardDeleter interface {
+		DeleteShard(shardID, ownerID uint64) error
+	}
+
+	enabled             bool
+	checkInterval       time.Duration
+	tombstoneExpiration time.Duration
+	measurementTTLs     []MeasurementTTL
+	wg                  sync.WaitGroup
+	done                chan struct{}
 
 	logger *log.Logger
 }
@@ -32,9 +47,11 @@ type Service struct {
 // NewService returns a configure retention policy enforcement service.
 func NewService(c Config) *Service {
 	return &Service{
-		checkInterval: time.Duration(c.CheckInterval),
-		done:          make(chan struct{}),
-		logger:        log.New(os.Stderr, "[retention] ", log.LstdFlags),
+		checkInterval:       time.Duration(c.CheckInterval),
+		tombstoneExpiration: time.Duration(c.TombstoneExpiration),
+		measurementTTLs:     c.MeasurementTTLs,
+		done:                make(chan struct{}),
+		logger:              log.New(os.Stderr, "[retention] ", log.LstdFlags),
 	}
 }
 
@@ -77,6 +94,9 @@ func (s *Service) deleteShardGroups() {
 			}
 			s.logger.Println("retention policy enforcement check commencing")
 
+			s.setMeasurementTTLs()
+
+			var deleted []meta.ShardInfo
 			s.MetaStore.VisitRetentionPolicies(func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo) {
 				for _, g := range r.ExpiredShardGroups(time.Now().UTC()) {
 					if err := s.MetaStore.DeleteShardGroup(d.Name, r.Name, g.ID); err != nil {
@@ -85,9 +105,79 @@ func (s *Service) deleteShardGroups() {
 					} else {
 						s.logger.Printf("deleted shard group %d from database %s, retention policy %s",
 							g.ID, d.Name, r.Name)
+						deleted = append(deleted, g.Shards...)
 					}
 				}
 			})
+
+			s.deleteRemoteShards(deleted)
+			s.pruneShardGroups()
+		}
+	}
+}
+
+// setMeasurementTTLs stores the configured measurement TTLs on their
+// retention policies so every node expires the same points.
+func (s *Service) setMeasurementTTLs() {
+	if len(s.measurementTTLs) == 0 {
+		return
+	}
+
+	type update struct {
+		database, policy, measurement string
+		ttl                           time.Duration
+	}
+	var updates []update
+	s.MetaStore.VisitRetentionPolicies(func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo) {
+		for _, m := range s.measurementTTLs {
+			if m.Database != d.Name || (m.RetentionPolicy != "" && m.RetentionPolicy != r.Name) {
+				continue
+			}
+			if ttl := time.Duration(m.TTL); r.MeasurementTTL(m.Measurement) != ttl {
+				updates = append(updates, update{d.Name, r.Name, m.Measurement, ttl})
+			}
+		}
+	})
+
+	for _, u := range updates {
+		if err := s.MetaStore.SetMeasurementTTL(u.database, u.policy, u.measurement, u.ttl); err != nil {
+			s.logger.Printf("failed to set ttl of measurement %s in database %s, retention policy %s: %s",
+				u.measurement, u.database, u.policy, err.Error())
+		}
+	}
+}
+
+// pruneShardGroups removes the shard groups deleted longer ago than the
+// tombstone expiration from the metadata. Every node has had time to remove
+// their shards by then.
+func (s *Service) pruneShardGroups() {
+	if s.tombstoneExpiration == 0 {
+		return
+	}
+
+	if err := s.MetaStore.PruneShardGroups(time.Now().UTC().Add(-s.tombstoneExpiration)); err != nil {
+		s.logger.Printf("failed to prune deleted shard groups: %s", err.Error())
+	}
+}
+
+// deleteRemoteShards asks the remote owners of each shard to remove its data.
+// Local shards are removed by deleteShards.
+func (s *Service) deleteRemoteShards(shards []meta.ShardInfo) {
+	if s.ShardDeleter == nil {
+		return
+	}
+
+	nodeID := s.MetaStore.NodeID()
+	for _, sh := range shards {
+		for _, ownerID := range sh.OwnerIDs {
+			if ownerID == nodeID {
+				continue
+			}
+			if err := s.ShardDeleter.DeleteShard(sh.ID, ownerID); err != nil {
+				s.logger.Printf("failed to delete shard ID %d on node %d: %s", sh.ID, ownerID, err.Error())
+				continue
+			}
+			s.logger.Printf("shard ID %d deleted on node %d", sh.ID, ownerID)
 		}
 	}
 }
@@ -98,32 +188,79 @@ func (s *Service) deleteShards() {
 	ticker := time.NewTicker(s.checkInterval)
 	defer ticker.Stop()
 	for {
+		// Also check when the metadata changes so the shards of deleted
+		// shard groups are removed without waiting for the next tick. The
+		// watch is set before checking so a change during the check isn't missed.
+		changed := s.MetaStore.Watch()
+		s.deleteDeletedShards()
+
 		select {
 		case <-s.done:
 			s.logger.Println("retention policy enforcement terminating")
 			return
-
 		case <-ticker.C:
 			s.logger.Println("retention policy shard deletion check commencing")
+			s.deleteExpiredMeasurements(time.Now().UTC())
+		case <-changed:
+		}
+	}
+}
 
-			deletedShardIDs := make(map[uint64]struct{}, 0)
-			s.MetaStore.VisitRetentionPolicies(func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo) {
-				for _, g := range r.DeletedShardGroups() {
-					for _, sh := range g.Shards {
-						deletedShardIDs[sh.ID] = struct{}{}
-					}
+// deleteDeletedShards removes the local shards of deleted shard groups.
+func (s *Service) deleteDeletedShards() {
+	deletedShardIDs := make(map[uint64]struct{}, 0)
+	s.MetaStore.VisitRetentionPolicies(func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo) {
+		for _, g := range r.DeletedShardGroups() {
+			for _, sh := range g.Shards {
+				deletedShardIDs[sh.ID] = struct{}{}
+			}
+		}
+	})
+
+	for _, id := range s.TSDBStore.ShardIDs() {
+		if _, ok := deletedShardIDs[id]; ok {
+			if err := s.TSDBStore.DeleteShard(id); err != nil {
+				s.logger.Printf("failed to delete shard ID %d: %s", id, err.Error())
+				continue
+			}
+			s.logger.Printf("shard ID %d deleted", id)
+		}
+	}
+}
+
+// deleteExpiredMeasurements removes the points older than their TTL of each
+// measurement with a TTL in the metadata from the local shards. Archived
+// shards are skipped rather than fetched back; their groups expire with the
+// retention policy.
+func (s *Service) deleteExpiredMeasurements(now time.Time) {
+	local := make(map[uint64]struct{})
+	for _, id := range s.TSDBStore.ShardIDs() {
+		local[id] = struct{}{}
+	}
+
+	s.MetaStore.VisitRetentionPolicies(func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo) {
+		for _, m := range r.MeasurementTTLs {
+			// Only shard groups starting before the cutoff hold expired
+			// points, and only within the group's own time range.
+			cutoff := now.Add(-m.TTL)
+			for _, g := range r.ShardGroups {
+				if g.Deleted() || !g.StartTime.Before(cutoff) {
+					continue
+				}
+				max := cutoff
+				if g.EndTime.Before(max) {
+					max = g.EndTime
 				}
-			})
 
-			for _, id := range s.TSDBStore.ShardIDs() {
-				if _, ok := deletedShardIDs[id]; ok {
-					if err := s.TSDBStore.DeleteShard(id); err != nil {
-						s.logger.Printf("failed to delete shard ID %d: %s", id, err.Error())
+				for _, sh := range g.Shards {
+					if _, ok := local[sh.ID]; !ok || s.TSDBStore.ShardArchived(sh.ID) {
 						continue
 					}
-					s.logger.Printf("shard ID %d deleted", id)
+					if err := s.TSDBStore.DeleteSeriesRange(sh.ID, m.Name, nil, g.StartTime.UnixNano(), max.UnixNano()-1); err != nil {
+						s.logger.Printf("failed to delete expired %s points from shard ID %d: %s", m.Name, sh.ID, err.Error())
+					}
 				}
 			}
 		}
-	}
+	})
 }
@@ -0,0 +1,357 @@
+package retention_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/services/retention"
+	"github.com/influxdb/influxdb/toml"
+)
+
+// Ensure expired shards are deleted from remote owners.
+func TestService_DeleteShardGroups_Remote(t *testing.T) {
+	s := retention.NewService(retention.Config{CheckInterval: toml.Duration(10 * time.Millisecond)})
+
+	var ms MetaStore
+	ms.VisitRetentionPoliciesFn = func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {
+		f(meta.DatabaseInfo{Name: "db0"}, meta.RetentionPolicyInfo{
+			Name:     "rp0",
+			Duration: time.Hour,
+			ShardGroups: []meta.ShardGroupInfo{{
+				ID:      1,
+				EndTime: time.Now().Add(-2 * time.Hour),
+				Shards:  []meta.ShardInfo{{ID: 10, OwnerIDs: []uint64{1, 2, 3}}},
+			}},
+		})
+	}
+	s.MetaStore = &ms
+	s.TSDBStore = &TSDBStore{}
+
+	// Record remote deletions.
+	var mu sync.Mutex
+	var once sync.Once
+	deleted := make(map[uint64]uint64)
+	done := make(chan struct{})
+	s.ShardDeleter = ShardDeleterFunc(func(shardID, ownerID uint64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deleted[ownerID] = shardID
+		if len(deleted) == 2 {
+			once.Do(func() { close(done) })
+		}
+		return nil
+	})
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for remote deletes")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := deleted[1]; ok {
+		t.Fatal("local shard deleted remotely")
+	} else if deleted[2] != 10 || deleted[3] != 10 {
+		t.Fatalf("unexpected deletes: %v", deleted)
+	}
+}
+
+// Ensure local shards of deleted shard groups are removed as soon as the
+// metadata changes.
+func TestService_DeleteShards_Watch(t *testing.T) {
+	s := retention.NewService(retention.Config{CheckInterval: toml.Duration(time.Hour)})
+
+	// The shard group is deleted after the service starts.
+	var mu sync.Mutex
+	var groupDeleted bool
+	changed := make(chan struct{})
+	var ms MetaStore
+	ms.VisitRetentionPoliciesFn = func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {
+		mu.Lock()
+		defer mu.Unlock()
+		sgi := meta.ShardGroupInfo{ID: 1, Shards: []meta.ShardInfo{{ID: 10, OwnerIDs: []uint64{1}}}}
+		if groupDeleted {
+			sgi.DeletedAt = time.Now()
+		}
+		f(meta.DatabaseInfo{Name: "db0"}, meta.RetentionPolicyInfo{Name: "rp0", ShardGroups: []meta.ShardGroupInfo{sgi}})
+	}
+	ms.WatchFn = func() <-chan struct{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return changed
+	}
+	s.MetaStore = &ms
+
+	done := make(chan uint64)
+	s.TSDBStore = &TSDBStore{
+		ShardIDsFn:    func() []uint64 { return []uint64{10} },
+		DeleteShardFn: func(shardID uint64) error { done <- shardID; return nil },
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Delete the group and notify watchers.
+	mu.Lock()
+	groupDeleted = true
+	close(changed)
+	changed = make(chan struct{})
+	mu.Unlock()
+
+	select {
+	case id := <-done:
+		if id != 10 {
+			t.Fatalf("unexpected shard deleted: %d", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for shard delete")
+	}
+}
+
+// Ensure shard groups deleted longer ago than the tombstone expiration are pruned.
+func TestService_PruneShardGroups(t *testing.T) {
+	s := retention.NewService(retention.Config{
+		CheckInterval:       toml.Duration(10 * time.Millisecond),
+		TombstoneExpiration: toml.Duration(time.Hour),
+	})
+
+	var ms MetaStore
+	ms.VisitRetentionPoliciesFn = func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {}
+	done := make(chan time.Time, 1)
+	ms.PruneShardGroupsFn = func(expiration time.Time) error {
+		select {
+		case done <- expiration:
+		default:
+		}
+		return nil
+	}
+	s.MetaStore = &ms
+	s.TSDBStore = &TSDBStore{}
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	select {
+	case expiration := <-done:
+		if d := time.Now().Sub(expiration); d < time.Hour || d > time.Hour+time.Minute {
+			t.Fatalf("unexpected expiration: %s", expiration)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for prune")
+	}
+}
+
+// Ensure the points of measurements older than their TTL in the metadata are
+// removed from local, unarchived shards within the shard group's time range.
+func TestService_DeleteExpiredMeasurements(t *testing.T) {
+	s := retention.NewService(retention.Config{CheckInterval: toml.Duration(10 * time.Millisecond)})
+
+	now := time.Now().UTC()
+	start := now.Add(-48 * time.Hour)
+	var ms MetaStore
+	ms.VisitRetentionPoliciesFn = func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {
+		f(meta.DatabaseInfo{Name: "db0"}, meta.RetentionPolicyInfo{
+			Name:            "rp0",
+			MeasurementTTLs: []meta.MeasurementTTLInfo{{Name: "cpu", TTL: 24 * time.Hour}},
+			ShardGroups: []meta.ShardGroupInfo{
+				{ID: 1, StartTime: start, EndTime: start.Add(12 * time.Hour), Shards: []meta.ShardInfo{{ID: 10}, {ID: 11}, {ID: 12}}},
+				{ID: 2, StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour), Shards: []meta.ShardInfo{{ID: 20}}},
+				{ID: 3, StartTime: start, EndTime: start.Add(12 * time.Hour), Shards: []meta.ShardInfo{{ID: 30}}, DeletedAt: now},
+			},
+		})
+		f(meta.DatabaseInfo{Name: "db1"}, meta.RetentionPolicyInfo{
+			Name: "rp0",
+			ShardGroups: []meta.ShardGroupInfo{
+				{ID: 4, StartTime: start, EndTime: start.Add(12 * time.Hour), Shards: []meta.ShardInfo{{ID: 40}}},
+			},
+		})
+	}
+	s.MetaStore = &ms
+
+	// Shard 11 isn't stored locally and shard 12 is archived.
+	type call struct {
+		shardID     uint64
+		measurement string
+		min, max    int64
+	}
+	calls := make(chan call, 10)
+	s.TSDBStore = &TSDBStore{
+		ShardIDsFn:      func() []uint64 { return []uint64{10, 12, 20, 30, 40} },
+		ShardArchivedFn: func(shardID uint64) bool { return shardID == 12 },
+		DeleteSeriesRangeFn: func(shardID uint64, measurement string, condition influxql.Expr, min, max int64) error {
+			if condition != nil {
+				t.Errorf("unexpected condition: %s", condition)
+			}
+			select {
+			case calls <- call{shardID, measurement, min, max}:
+			default:
+			}
+			return nil
+		},
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// The range is clamped to the shard group, which ends before the cutoff.
+	select {
+	case c := <-calls:
+		if exp := (call{10, "cpu", start.UnixNano(), start.Add(12*time.Hour).UnixNano() - 1}); c != exp {
+			t.Fatalf("unexpected delete: %+v, exp %+v", c, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for delete")
+	}
+}
+
+// Ensure the leader stores the configured measurement TTLs on the matching
+// retention policies.
+func TestService_SetMeasurementTTLs(t *testing.T) {
+	s := retention.NewService(retention.Config{
+		CheckInterval: toml.Duration(10 * time.Millisecond),
+		MeasurementTTLs: []retention.MeasurementTTL{
+			{Database: "db0", Measurement: "cpu", TTL: toml.Duration(24 * time.Hour)},
+			{Database: "db0", RetentionPolicy: "rp1", Measurement: "mem", TTL: toml.Duration(time.Hour)},
+		},
+	})
+
+	// rp1 already has the cpu TTL.
+	var ms MetaStore
+	ms.VisitRetentionPoliciesFn = func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {
+		f(meta.DatabaseInfo{Name: "db0"}, meta.RetentionPolicyInfo{Name: "rp0"})
+		f(meta.DatabaseInfo{Name: "db0"}, meta.RetentionPolicyInfo{
+			Name:            "rp1",
+			MeasurementTTLs: []meta.MeasurementTTLInfo{{Name: "cpu", TTL: 24 * time.Hour}},
+		})
+		f(meta.DatabaseInfo{Name: "db1"}, meta.RetentionPolicyInfo{Name: "rp0"})
+	}
+	type call struct {
+		database, policy, measurement string
+		ttl                           time.Duration
+	}
+	calls := make(chan call, 10)
+	ms.SetMeasurementTTLFn = func(database, policy, measurement string, ttl time.Duration) error {
+		select {
+		case calls <- call{database, policy, measurement, ttl}:
+		default:
+		}
+		return nil
+	}
+	s.MetaStore = &ms
+	s.TSDBStore = &TSDBStore{}
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, exp := range []call{
+		{"db0", "rp0", "cpu", 24 * time.Hour},
+		{"db0", "rp1", "mem", time.Hour},
+	} {
+		select {
+		case c := <-calls:
+			if c != exp {
+				t.Fatalf("unexpected ttl: %+v, exp %+v", c, exp)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for ttl")
+		}
+	}
+}
+
+// MetaStore is a mockable implementation of retention.Service.MetaStore.
+type MetaStore struct {
+	VisitRetentionPoliciesFn func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo))
+	WatchFn                  func() <-chan struct{}
+	PruneShardGroupsFn       func(expiration time.Time) error
+	SetMeasurementTTLFn      func(database, policy, measurement string, ttl time.Duration) error
+}
+
+func (ms *MetaStore) IsLeader() bool { return true }
+func (ms *MetaStore) NodeID() uint64 { return 1 }
+
+func (ms *MetaStore) VisitRetentionPolicies(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {
+	ms.VisitRetentionPoliciesFn(f)
+}
+
+func (ms *MetaStore) DeleteShardGroup(database, policy string, id uint64) error { return nil }
+
+func (ms *MetaStore) PruneShardGroups(expiration time.Time) error {
+	if ms.PruneShardGroupsFn == nil {
+		return nil
+	}
+	return ms.PruneShardGroupsFn(expiration)
+}
+
+func (ms *MetaStore) SetMeasurementTTL(database, policy, measurement string, ttl time.Duration) error {
+	if ms.SetMeasurementTTLFn == nil {
+		return nil
+	}
+	return ms.SetMeasurementTTLFn(database, policy, measurement, ttl)
+}
+
+// Watch returns a channel that is never closed unless WatchFn is set.
+func (ms *MetaStore) Watch() <-chan struct{} {
+	if ms.WatchFn == nil {
+		return nil
+	}
+	return ms.WatchFn()
+}
+
+// TSDBStore is a mockable implementation of retention.Service.TSDBStore.
+// It has no shards unless the functions are set.
+type TSDBStore struct {
+	ShardIDsFn          func() []uint64
+	ShardArchivedFn     func(shardID uint64) bool
+	DeleteShardFn       func(shardID uint64) error
+	DeleteSeriesRangeFn func(shardID uint64, measurement string, condition influxql.Expr, min, max int64) error
+}
+
+func (s *TSDBStore) ShardIDs() []uint64 {
+	if s.ShardIDsFn == nil {
+		return nil
+	}
+	return s.ShardIDsFn()
+}
+
+func (s *TSDBStore) ShardArchived(shardID uint64) bool {
+	if s.ShardArchivedFn == nil {
+		return false
+	}
+	return s.ShardArchivedFn(shardID)
+}
+
+func (s *TSDBStore) DeleteShard(shardID uint64) error {
+	if s.DeleteShardFn == nil {
+		return nil
+	}
+	return s.DeleteShardFn(shardID)
+}
+
+func (s *TSDBStore) DeleteSeriesRange(shardID uint64, measurement string, condition influxql.Expr, min, max int64) error {
+	if s.DeleteSeriesRangeFn == nil {
+		return nil
+	}
+	return s.DeleteSeriesRangeFn(shardID, measurement, condition, min, max)
+}
+
+// ShardDeleterFunc is a function that implements retention.Service.ShardDeleter.
+type ShardDeleterFunc func(shardID, ownerID uint64) error
+
+func (fn ShardDeleterFunc) DeleteShard(shardID, ownerID uint64) error { return fn(shardID, ownerID) }
@@ -1,6 +1,7 @@
 package snapshotter
 
 import (
+	"bytes"
 	"encoding"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdb/influxdb/snapshot"
 	"github.com/influxdb/influxdb/tsdb"
@@ -17,6 +19,16 @@ import (
 // MuxHeader is the header byte used for the TCP muxer.
 const MuxHeader = 3
 
+// Request represents a request for a snapshot. Older clients send only the
+// manifest so the other fields are optional.
+type Request struct {
+	// The files the client already has. Only newer files are sent.
+	snapshot.Manifest
+
+	// If set, the snapshot only contains the metadata.
+	MetaOnly bool `json:"metaOnly,omitempty"`
+}
+
 // Service manages the listener for the snapshot endpoint.
 type Service struct {
 	wg  sync.WaitGroup
@@ -88,28 +100,53 @@ func (s *Service) serve() {
 
 // handleConn processes conn. This is run in a separate goroutine.
 func (s *Service) handleConn(conn net.Conn) error {
-	// Read manifest from connection.
-	m, err := s.readManifest(conn)
+	// Read request from connection.
+	req, err := s.readRequest(conn)
 	if err != nil {
-		return fmt.Errorf("read manifest: %s", err)
+		return fmt.Errorf("read request: %s", err)
+	}
+
+	// Write metadata only snapshot to connection.
+	if req.MetaOnly {
+		if err := s.writeMetaSnapshot(conn); err != nil {
+			return fmt.Errorf("write meta snapshot: %s", err)
+		}
+		return nil
 	}
 
 	// Write snapshot to connection.
-	if err := s.writeSnapshot(conn, m); err != nil {
+	if err := s.writeSnapshot(conn, req.Manifest); err != nil {
 		return fmt.Errorf("write snapshot: %s", err)
 	}
 
 	return nil
 }
 
-// readManifest reads the manifest size and contents from conn.
-// Unmarshals the bytes and returns a manifest object.
-func (s *Service) readManifest(conn net.Conn) (snapshot.Manifest, error) {
-	var m snapshot.Manifest
-	if err := json.NewDecoder(conn).Decode(&m); err != nil {
-		return m, err
+// readRequest reads the request from conn.
+func (s *Service) readRequest(conn net.Conn) (Request, error) {
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// writeMetaSnapshot writes a snapshot containing only the metadata to conn.
+func (s *Service) writeMetaSnapshot(conn net.Conn) error {
+	buf, err := s.MetaStore.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("marshal meta: %s", err)
 	}
-	return m, nil
+
+	sw := snapshot.NewWriter()
+	defer sw.Close()
+	sw.Manifest.Files = []snapshot.File{{Name: "meta", Size: int64(len(buf)), ModTime: time.Now()}}
+	sw.FileWriters["meta"] = tsdb.NopWriteToCloser(bytes.NewReader(buf))
+
+	if _, err := sw.WriteTo(conn); err != nil {
+		return fmt.Errorf("write to: %s", err)
+	}
+	return nil
 }
 
 // writeSnapshot creates a snapshot writer, trims the manifest, and writes to conn.
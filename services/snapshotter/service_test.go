@@ -1 +1,91 @@
 package snapshotter_test
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"testing"
+
+	"github.com/influxdb/influxdb/services/snapshotter"
+	"github.com/influxdb/influxdb/snapshot"
+	"github.com/influxdb/influxdb/tcp"
+)
+
+// Ensure a metadata only snapshot contains just the metadata.
+func TestService_MetaOnly(t *testing.T) {
+	s := NewService()
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte{snapshotter.MuxHeader}); err != nil {
+		t.Fatal(err)
+	} else if err := json.NewEncoder(conn).Encode(&snapshotter.Request{MetaOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read every file in the snapshot.
+	files := make(map[string]string)
+	sr := snapshot.NewReader(conn)
+	for {
+		sf, err := sr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := ioutil.ReadAll(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[sf.Name] = string(buf)
+	}
+
+	if len(files) != 1 || files["meta"] != "meta" {
+		t.Fatalf("unexpected files: %v", files)
+	}
+}
+
+// Service is a test wrapper for snapshotter.Service.
+type Service struct {
+	*snapshotter.Service
+	Addr net.Addr
+	ln   net.Listener
+}
+
+// NewService returns a new service listening on a random port.
+func NewService() *Service {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	// Wrap listener in a muxer.
+	mux := tcp.NewMux()
+	s := &Service{Service: snapshotter.NewService(), Addr: ln.Addr(), ln: ln}
+	s.MetaStore = &MetaStore{}
+	s.Listener = mux.Listen(snapshotter.MuxHeader)
+	s.Logger = log.New(ioutil.Discard, "", 0)
+	go mux.Serve(ln)
+	return s
+}
+
+// Close closes the listener and the service.
+func (s *Service) Close() error {
+	s.ln.Close()
+	return s.Service.Close()
+}
+
+// MetaStore is a mock implementation of Service.MetaStore.
+type MetaStore struct{}
+
+func (m *MetaStore) MarshalBinary() ([]byte, error) { return []byte("meta"), nil }
@@ -0,0 +1,50 @@
+package tiering
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influxdb/influxdb/toml"
+)
+
+const (
+	// DefaultCheckInterval is the default time between checks for shards to
+	// move to the cold tier.
+	DefaultCheckInterval = 10 * time.Minute
+
+	// DefaultAfter is the default time after a shard group's end time that
+	// its shards are moved to the cold tier.
+	DefaultAfter = 30 * 24 * time.Hour
+)
+
+// Config represents the configuration for the shard tiering service. The
+// cold tier's directory is set by the data config's cold-dir.
+type Config struct {
+	Enabled       bool          `toml:"enabled"`
+	CheckInterval toml.Duration `toml:"check-interval"`
+
+	// How long after a shard group's end time its shards are moved.
+	After toml.Duration `toml:"after"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		CheckInterval: toml.Duration(DefaultCheckInterval),
+		After:         toml.Duration(DefaultAfter),
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.CheckInterval <= 0 {
+		return errors.New("tiering check-interval must be positive")
+	} else if c.After <= 0 {
+		return errors.New("tiering after must be positive")
+	}
+	return nil
+}
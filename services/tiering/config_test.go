@@ -0,0 +1,49 @@
+package tiering_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdb/influxdb/services/tiering"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c tiering.Config
+	if _, err := toml.Decode(`
+enabled = true
+check-interval = "1s"
+after = "168h"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if c.Enabled != true {
+		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if time.Duration(c.CheckInterval) != time.Second {
+		t.Fatalf("unexpected check interval: %v", c.CheckInterval)
+	} else if time.Duration(c.After) != 7*24*time.Hour {
+		t.Fatalf("unexpected after: %v", c.After)
+	} else if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure invalid configurations are rejected.
+func TestConfig_Validate(t *testing.T) {
+	for i, tt := range []struct {
+		c   tiering.Config
+		err string
+	}{
+		{c: tiering.Config{}},
+		{c: tiering.Config{Enabled: true, After: 1}, err: "tiering check-interval must be positive"},
+		{c: tiering.Config{Enabled: true, CheckInterval: 1}, err: "tiering after must be positive"},
+	} {
+		err := tt.c.Validate()
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package tiering
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/meta"
+)
+
+// Service moves the local shards of old shard groups to the cold tier.
+type Service struct {
+	MetaStore interface {
+		VisitRetentionPolicies(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo))
+	}
+	TSDBStore interface {
+		ShardIDs() []uint64
+		ShardCold(shardID uint64) bool
+		ShardArchived(shardID uint64) bool
+		MoveShardToColdTier(shardID uint64) error
+	}
+
+	checkInterval time.Duration
+	after         time.Duration
+	wg            sync.WaitGroup
+	done          chan struct{}
+
+	logger *log.Logger
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		checkInterval: time.Duration(c.CheckInterval),
+		after:         time.Duration(c.After),
+		done:          make(chan struct{}),
+		logger:        log.New(os.Stderr, "[tiering] ", log.LstdFlags),
+	}
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Close stops the service.
+func (s *Service) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return nil
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.logger = l
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			s.logger.Println("shard tiering terminating")
+			return
+
+		case <-ticker.C:
+			s.moveShards(time.Now().UTC())
+		}
+	}
+}
+
+// moveShards moves the local shards of every shard group that ended longer
+// ago than the after duration to the cold tier.
+func (s *Service) moveShards(now time.Time) {
+	cold := make(map[uint64]struct{})
+	s.MetaStore.VisitRetentionPolicies(func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo) {
+		for _, g := range r.ShardGroups {
+			if g.Deleted() || g.EndTime.Add(s.after).After(now) {
+				continue
+			}
+			for _, sh := range g.Shards {
+				cold[sh.ID] = struct{}{}
+			}
+		}
+	})
+
+	for _, id := range s.TSDBStore.ShardIDs() {
+		if _, ok := cold[id]; !ok || s.TSDBStore.ShardCold(id) || s.TSDBStore.ShardArchived(id) {
+			continue
+		}
+		if err := s.TSDBStore.MoveShardToColdTier(id); err != nil {
+			s.logger.Printf("failed to move shard ID %d to the cold tier: %s", id, err)
+			continue
+		}
+		s.logger.Printf("shard ID %d moved to the cold tier", id)
+	}
+}
@@ -0,0 +1,99 @@
+package tiering_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/services/tiering"
+	"github.com/influxdb/influxdb/toml"
+)
+
+// Ensure the local shards of old shard groups are moved to the cold tier.
+func TestService_MoveShards(t *testing.T) {
+	s := tiering.NewService(tiering.Config{
+		CheckInterval: toml.Duration(10 * time.Millisecond),
+		After:         toml.Duration(24 * time.Hour),
+	})
+
+	now := time.Now().UTC()
+	var ms MetaStore
+	ms.VisitRetentionPoliciesFn = func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {
+		f(meta.DatabaseInfo{Name: "db0"}, meta.RetentionPolicyInfo{
+			Name: "rp0",
+			ShardGroups: []meta.ShardGroupInfo{
+				{ID: 1, EndTime: now.Add(-36 * time.Hour), Shards: []meta.ShardInfo{{ID: 10}}},
+				{ID: 2, EndTime: now.Add(-time.Hour), Shards: []meta.ShardInfo{{ID: 20}}},
+				{ID: 3, EndTime: now.Add(-36 * time.Hour), Shards: []meta.ShardInfo{{ID: 30}}, DeletedAt: now},
+				{ID: 4, EndTime: now.Add(-72 * time.Hour), Shards: []meta.ShardInfo{{ID: 40}, {ID: 41}, {ID: 42}}},
+			},
+		})
+	}
+	s.MetaStore = &ms
+
+	// Shard 40 is already cold, shard 41 is archived and shard 42 isn't
+	// stored locally.
+	var mu sync.Mutex
+	moved := make(map[uint64]struct{})
+	done := make(chan struct{})
+	var ts TSDBStore
+	var checks int
+	ts.ShardIDsFn = func() []uint64 {
+		// The first check has finished once the second one starts.
+		if checks++; checks == 2 {
+			close(done)
+		}
+		return []uint64{10, 20, 30, 40, 41}
+	}
+	ts.ShardColdFn = func(shardID uint64) bool { return shardID == 40 }
+	ts.ShardArchivedFn = func(shardID uint64) bool { return shardID == 41 }
+	ts.MoveShardToColdTierFn = func(shardID uint64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		moved[shardID] = struct{}{}
+		return nil
+	}
+	s.TSDBStore = &ts
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for tiering check")
+	}
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(moved, map[uint64]struct{}{10: {}}) {
+		t.Fatalf("unexpected moved shards: %v", moved)
+	}
+}
+
+// MetaStore is a mockable implementation of tiering.Service.MetaStore.
+type MetaStore struct {
+	VisitRetentionPoliciesFn func(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo))
+}
+
+func (ms *MetaStore) VisitRetentionPolicies(f func(d meta.DatabaseInfo, r meta.RetentionPolicyInfo)) {
+	ms.VisitRetentionPoliciesFn(f)
+}
+
+// TSDBStore is a mockable implementation of tiering.Service.TSDBStore.
+type TSDBStore struct {
+	ShardIDsFn            func() []uint64
+	ShardColdFn           func(shardID uint64) bool
+	ShardArchivedFn       func(shardID uint64) bool
+	MoveShardToColdTierFn func(shardID uint64) error
+}
+
+func (s *TSDBStore) ShardIDs() []uint64                { return s.ShardIDsFn() }
+func (s *TSDBStore) ShardCold(shardID uint64) bool     { return s.ShardColdFn(shardID) }
+func (s *TSDBStore) ShardArchived(shardID uint64) bool { return s.ShardArchivedFn(shardID) }
+func (s *TSDBStore) MoveShardToColdTier(shardID uint64) error {
+	return s.MoveShardToColdTierFn(shardID)
+}
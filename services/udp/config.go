@@ -9,4 +9,8 @@ type Config struct {
 	Database     string        `toml:"database"`
 	BatchSize    int           `toml:"batch-size"`
 	BatchTimeout toml.Duration `toml:"batch-timeout"`
+
+	// Precision of the timestamps of points written, one of n, u, ms, s, m
+	// or h. Empty is nanoseconds.
+	Precision string `toml:"precision"`
 }
@@ -17,6 +17,7 @@ bind-address = ":4444"
 database = "awesomedb"
 batch-size = 100
 batch-timeout = "10ms"
+precision = "s"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -32,5 +33,7 @@ batch-timeout = "10ms"
 		t.Fatalf("unexpected batch size: %d", c.BatchSize)
 	} else if time.Duration(c.BatchTimeout) != (10 * time.Millisecond) {
 		t.Fatalf("unexpected batch timeout: %v", c.BatchTimeout)
+	} else if c.Precision != "s" {
+		t.Fatalf("unexpected precision: %s", c.Precision)
 	}
 }
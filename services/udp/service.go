@@ -119,7 +119,7 @@ func (s *Service) serve() {
 			continue
 		}
 
-		points, err := tsdb.ParsePoints(buf[:n])
+		points, err := tsdb.ParsePointsWithPrecision(buf[:n], time.Now().UTC(), s.config.Precision)
 		if err != nil {
 			s.Logger.Printf("Failed to parse points: %s", err)
 			continue
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -17,12 +18,20 @@ const (
 
 // Mux multiplexes a network connection.
 type Mux struct {
-	ln net.Listener
-	m  map[byte]*listener
+	wg   sync.WaitGroup
+	done chan struct{} // closed when the underlying listener stops
+	ln   net.Listener
+	m    map[byte]*listener
 
 	// The amount of time to wait for the first header byte.
 	Timeout time.Duration
 
+	// Limits on accepted connections: the most open at once, including
+	// those not yet passed to a listener, and the most accepted per second.
+	// Connections over MaxConnections are closed. Zero means no limit.
+	MaxConnections    int
+	MaxConnectionRate int
+
 	// Out-of-band error logger
 	Logger *log.Logger
 }
@@ -31,6 +40,7 @@ type Mux struct {
 func NewMux() *Mux {
 	return &Mux{
 		m:       make(map[byte]*listener),
+		done:    make(chan struct{}),
 		Timeout: DefaultTimeout,
 		Logger:  log.New(os.Stderr, "", log.LstdFlags),
 	}
@@ -38,7 +48,22 @@ func NewMux() *Mux {
 
 // Serve handles connections from ln and multiplexes then across registered listener.
 func (mux *Mux) Serve(ln net.Listener) error {
+	var conns chan struct{}
+	if mux.MaxConnections > 0 {
+		conns = make(chan struct{}, mux.MaxConnections)
+	}
+	var acceptInterval time.Duration
+	if mux.MaxConnectionRate > 0 {
+		acceptInterval = time.Second / time.Duration(mux.MaxConnectionRate)
+	}
+
+	var lastAccept time.Time
 	for {
+		// Space out accepts to stay under the connection rate.
+		if d := acceptInterval - time.Since(lastAccept); acceptInterval > 0 && d > 0 {
+			time.Sleep(d)
+		}
+
 		// Wait for the next connection.
 		// If it returns a temporary error then simply retry.
 		// If it returns any other error then exit immediately.
@@ -49,44 +74,76 @@ func (mux *Mux) Serve(ln net.Listener) error {
 			continue
 		}
 		if err != nil {
+			// Wait for all connections to be demuxed before closing listeners.
+			close(mux.done)
+			mux.wg.Wait()
 			for _, ln := range mux.m {
 				close(ln.c)
 			}
 			return err
 		}
-
-		// Set a read deadline so connections with no data don't timeout.
-		if err := conn.SetReadDeadline(time.Now().Add(mux.Timeout)); err != nil {
-			conn.Close()
-			mux.Logger.Printf("tcp.Mux: cannot set read deadline: %s", err)
-			continue
+		lastAccept = time.Now()
+
+		// Reject the connection if too many are already open. The slot is
+		// freed when the connection is closed, by the mux or its listener.
+		if conns != nil {
+			select {
+			case conns <- struct{}{}:
+				conn = &limitedConn{Conn: conn, release: func() { <-conns }}
+			default:
+				mux.Logger.Printf("tcp.Mux: rejecting connection from %v: %d connections open", conn.RemoteAddr(), cap(conns))
+				conn.Close()
+				continue
+			}
 		}
 
-		// Read first byte from connection to determine handler.
-		var typ [1]byte
-		if _, err := io.ReadFull(conn, typ[:]); err != nil {
-			conn.Close()
-			mux.Logger.Printf("tcp.Mux: cannot read header byte: %s", err)
-			continue
-		}
+		// Demux each connection in its own goroutine so that a slow client or
+		// a busy listener can't hold up connections for other listeners, e.g.
+		// raft heartbeats queued behind a burst of shard write connections.
+		mux.wg.Add(1)
+		go mux.handleConn(conn)
+	}
+}
 
-		// Reset read deadline and let the listener handle that.
-		if err := conn.SetReadDeadline(time.Time{}); err != nil {
-			conn.Close()
-			mux.Logger.Printf("tcp.Mux: cannot reset set read deadline: %s", err)
-			continue
-		}
+// handleConn reads the header byte from conn and passes it to the matching listener.
+func (mux *Mux) handleConn(conn net.Conn) {
+	defer mux.wg.Done()
 
-		// Retrieve handler based on first byte.
-		handler := mux.m[typ[0]]
-		if handler == nil {
-			conn.Close()
-			mux.Logger.Printf("tcp.Mux: handler not registered: %d", typ[0])
-			continue
-		}
+	// Set a read deadline so connections with no data don't timeout.
+	if err := conn.SetReadDeadline(time.Now().Add(mux.Timeout)); err != nil {
+		conn.Close()
+		mux.Logger.Printf("tcp.Mux: cannot set read deadline: %s", err)
+		return
+	}
+
+	// Read first byte from connection to determine handler.
+	var typ [1]byte
+	if _, err := io.ReadFull(conn, typ[:]); err != nil {
+		conn.Close()
+		mux.Logger.Printf("tcp.Mux: cannot read header byte: %s", err)
+		return
+	}
+
+	// Reset read deadline and let the listener handle that.
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		conn.Close()
+		mux.Logger.Printf("tcp.Mux: cannot reset set read deadline: %s", err)
+		return
+	}
+
+	// Retrieve handler based on first byte.
+	handler := mux.m[typ[0]]
+	if handler == nil {
+		conn.Close()
+		mux.Logger.Printf("tcp.Mux: handler not registered: %d", typ[0])
+		return
+	}
 
-		// Send connection to handler.
-		handler.c <- conn
+	// Send connection to handler unless the mux is shutting down.
+	select {
+	case handler.c <- conn:
+	case <-mux.done:
+		conn.Close()
 	}
 }
 
@@ -107,6 +164,20 @@ func (mux *Mux) Listen(header byte) net.Listener {
 	return ln
 }
 
+// limitedConn is a connection counted against the mux's MaxConnections.
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+// Close closes the connection and frees its slot.
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
 // listener is a receiver for connections received by Mux.
 type listener struct {
 	c chan net.Conn
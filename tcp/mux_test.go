@@ -135,3 +135,113 @@ func TestMux_Listen_ErrAlreadyRegistered(t *testing.T) {
 	mux.Listen(5)
 	mux.Listen(5)
 }
+
+// Ensure a connection that hasn't sent its header doesn't block other connections.
+func TestMux_SlowConnection(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpListener.Close()
+
+	mux := tcp.NewMux()
+	mux.Timeout = 10 * time.Second
+	mux.Logger = log.New(ioutil.Discard, "", 0)
+	ln := mux.Listen(5)
+	go mux.Serve(tcpListener)
+
+	// Open a connection that never sends a header byte.
+	slow, err := net.Dial("tcp", tcpListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slow.Close()
+
+	// A second connection should still be handed to its listener.
+	conn, err := net.Dial("tcp", tcpListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte{5}); err != nil {
+		t.Fatal(err)
+	}
+
+	accepted := make(chan net.Conn)
+	go func() {
+		c, _ := ln.Accept()
+		accepted <- c
+	}()
+
+	select {
+	case c := <-accepted:
+		if c == nil {
+			t.Fatal("expected connection")
+		}
+		c.Close()
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for connection")
+	}
+}
+
+// Ensure the mux rejects connections over its limit, including those that
+// haven't sent a header, until one is closed.
+func TestMux_MaxConnections(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpListener.Close()
+
+	mux := tcp.NewMux()
+	mux.MaxConnections = 1
+	mux.Logger = log.New(ioutil.Discard, "", 0)
+	ln := mux.Listen(5)
+	go mux.Serve(tcpListener)
+
+	// Open a connection that never sends a header byte.
+	idle, err := net.Dial("tcp", tcpListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+
+	// A second connection is closed by the mux.
+	rejected, err := net.Dial("tcp", tcpListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rejected.Close()
+	rejected.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := rejected.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("expected connection to be closed: %v", err)
+	}
+
+	// Closing the first connection frees its slot.
+	idle.Close()
+	accepted := make(chan net.Conn)
+	go func() {
+		c, _ := ln.Accept()
+		accepted <- c
+	}()
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", tcpListener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte{5}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case c := <-accepted:
+			c.Close()
+			return
+		case <-time.After(50 * time.Millisecond):
+			if i == 20 {
+				t.Fatal("timeout waiting for connection")
+			}
+		}
+	}
+}
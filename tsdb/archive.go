@@ -0,0 +1,431 @@
+package tsdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// ArchiveExt is the extension of the manifest file kept locally for a shard
+// whose data has been moved to a ShardArchive.
+const ArchiveExt = ".archive"
+
+var (
+	// ErrShardArchiveNotSet is returned when archiving or fetching a shard
+	// without a ShardArchive set on the store.
+	ErrShardArchiveNotSet = errors.New("shard archive not set")
+
+	// ErrShardModified is returned when a shard is written to while it is
+	// being archived.
+	ErrShardModified = errors.New("shard modified during archive")
+)
+
+// ShardArchive represents object storage that holds the data files of cold shards.
+// Put is given the number of bytes that will be read from r.
+type ShardArchive interface {
+	Put(key string, r io.Reader, size int64) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// archiveManifest holds the metadata of an archived shard so that the index
+// and series filter can be built without fetching the shard's data.
+type archiveManifest struct {
+	Key    string            `json:"key"`
+	Fields map[string][]byte `json:"fields"`
+	Series map[string][]byte `json:"series"`
+	Filter []byte            `json:"filter,omitempty"`
+}
+
+// ArchiveShard uploads a shard's data file to the store's archive and removes
+// the local copy. The shard's series and fields stay in the index and its data
+// is fetched again the next time it is queried.
+func (s *Store) ArchiveShard(shardID uint64) error {
+	s.mu.RLock()
+	sh, a := s.shards[shardID], s.Archive
+	s.mu.RUnlock()
+	if sh == nil {
+		return ErrShardNotFound
+	} else if a == nil {
+		return ErrShardArchiveNotSet
+	}
+
+	// Shards that are already archived only need their cached copy removed.
+	if sh.ArchiveKey() != "" {
+		return sh.evict()
+	} else if err := sh.openDeferred(); err != nil {
+		return err
+	}
+
+	rel, err := s.shardRelPath(sh)
+	if err != nil {
+		return err
+	}
+	key := filepath.ToSlash(rel)
+
+	// Upload a consistent copy of the shard while writes are still allowed.
+	m, txID, err := sh.upload(a, key)
+	if err != nil {
+		return fmt.Errorf("upload: %s", err)
+	}
+
+	// Block writes while the local copy is removed. Give up if the shard was
+	// written to during the upload so the archived copy is never stale.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shards[shardID] != sh {
+		return ErrShardNotFound
+	}
+	sh.mu.RLock()
+	other, err := sh.txID()
+	sh.mu.RUnlock()
+	if err != nil {
+		return err
+	} else if other != txID {
+		return ErrShardModified
+	}
+
+	if err := writeArchiveManifest(sh.path+ArchiveExt, m); err != nil {
+		return err
+	}
+	sh.mu.Lock()
+	sh.archiveKey = key
+	sh.mu.Unlock()
+	return sh.evict()
+}
+
+// ShardArchived returns true if the shard's data has been moved to the archive.
+func (s *Store) ShardArchived(shardID uint64) bool {
+	s.mu.RLock()
+	sh := s.shards[shardID]
+	s.mu.RUnlock()
+	return sh != nil && sh.ArchiveKey() != ""
+}
+
+// FetchShard returns a shard, fetching its data from the archive if it isn't
+// available locally. Returns nil if the shard doesn't exist.
+func (s *Store) FetchShard(shardID uint64) (*Shard, error) {
+	s.mu.RLock()
+	sh, a := s.shards[shardID], s.Archive
+	s.mu.RUnlock()
+	if sh == nil {
+		return nil, nil
+	}
+
+	if err := sh.fetch(a); err != nil {
+		return nil, fmt.Errorf("fetch shard %d: %s", shardID, err)
+	}
+	return sh, nil
+}
+
+// EvictArchivedShards removes the local copies of archived shards that have
+// not been fetched within the idle duration. Returns the number of shards evicted.
+func (s *Store) EvictArchivedShards(idle time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	now := time.Now()
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		cached := sh.archiveKey != "" && sh.db != nil && sh.pins == 0 && now.Sub(sh.lastAccess) >= idle
+		sh.mu.RUnlock()
+		if !cached {
+			continue
+		}
+
+		if err := sh.evict(); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// restoreShard returns an archived shard to local storage so it can be
+// modified, and opens the data file of a deferred shard. The archived copy
+// is removed as it would no longer be current. The shard is downloaded
+// without the store lock, so callers should restore it before taking the
+// lock and check the shard wasn't removed meanwhile.
+func (s *Store) restoreShard(sh *Shard) error {
+	if err := sh.openDeferred(); err != nil {
+		return err
+	}
+
+	sh.fetchMu.Lock()
+	defer sh.fetchMu.Unlock()
+
+	key, err := sh.download(s.Archive, true)
+	if err != nil {
+		return fmt.Errorf("fetch shard: %s", err)
+	} else if key == "" {
+		return nil
+	}
+
+	if err := os.Remove(sh.path + ArchiveExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := s.Archive.Delete(key); err != nil {
+		s.Logger.Printf("failed to delete archived shard %s: %s", key, err)
+	}
+	return nil
+}
+
+// openShard opens a shard. If the shard has been archived then its index is
+// loaded from the manifest unless a cached copy of the data exists.
+func openShard(sh *Shard) error {
+	m, err := readArchiveManifest(sh.path + ArchiveExt)
+	if os.IsNotExist(err) {
+		return sh.Open()
+	} else if err != nil {
+		return err
+	}
+
+	// Open the cached copy if there is one.
+	if _, err := os.Stat(sh.path); err == nil {
+		if err := sh.Open(); err != nil {
+			return err
+		}
+		sh.archiveKey = m.Key
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return sh.loadArchiveManifest(m)
+}
+
+// ArchiveKey returns the key of the shard in the archive or a blank string
+// if the shard has not been archived.
+func (s *Shard) ArchiveKey() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.archiveKey
+}
+
+// upload writes a copy of the shard to the archive and returns its manifest
+// and the ID of the transaction that was copied.
+func (s *Shard) upload(a ShardArchive, key string) (*archiveManifest, int, error) {
+	m := &archiveManifest{
+		Key:    key,
+		Fields: make(map[string][]byte),
+		Series: make(map[string][]byte),
+	}
+
+	var txID int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		txID = tx.ID()
+
+		// Values are only valid for the life of the transaction so copy them.
+		if err := tx.Bucket([]byte("fields")).ForEach(func(k, v []byte) error {
+			m.Fields[string(k)] = append([]byte(nil), v...)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte("series")).ForEach(func(k, v []byte) error {
+			m.Series[string(k)] = append([]byte(nil), v...)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		// Build the series filter from the same transaction as the series
+		// so it holds every series in the archived copy.
+		f := newSeriesFilter(len(m.Series))
+		for k := range m.Series {
+			f.insert([]byte(k))
+		}
+		var err error
+		if m.Filter, err = f.MarshalBinary(); err != nil {
+			return err
+		}
+
+		// Stream the transaction to the archive. The transaction must remain
+		// open until the copy has finished.
+		pr, pw := io.Pipe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := tx.WriteTo(pw)
+			pw.CloseWithError(err)
+		}()
+
+		err = a.Put(key, pr, tx.Size())
+		pr.Close()
+		<-done
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return m, txID, nil
+}
+
+// fetch copies the shard's data from the archive if it isn't available
+// locally, and opens the data file of a deferred shard. It is otherwise a
+// no-op for shards that have not been archived.
+func (s *Shard) fetch(a ShardArchive) error {
+	if err := s.openDeferred(); err != nil {
+		return err
+	}
+
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+	_, err := s.download(a, false)
+	return err
+}
+
+// download copies an archived shard's data from the archive unless a cached
+// copy is open, and returns its archive key. If restore is set the shard is
+// no longer marked as archived. Returns a blank key if the shard isn't
+// archived. The shard lock isn't held while downloading so reads of the
+// index continue; the fetch lock must be held instead.
+func (s *Shard) download(a ShardArchive, restore bool) (string, error) {
+	s.mu.Lock()
+	key := s.archiveKey
+	if key == "" {
+		s.mu.Unlock()
+		return "", nil
+	}
+	s.lastAccess = time.Now()
+	if s.db != nil {
+		if restore {
+			s.archiveKey = ""
+		}
+		s.mu.Unlock()
+		return key, nil
+	}
+	s.mu.Unlock()
+
+	if a == nil {
+		return "", ErrShardArchiveNotSet
+	}
+
+	rc, err := a.Get(key)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	// Download to a temporary file so a partial copy is never opened.
+	tmppath := s.path + ".fetch"
+	f, err := os.Create(tmppath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		os.Remove(tmppath)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmppath)
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Rename(tmppath, s.path); err != nil {
+		return "", err
+	}
+	db, err := s.openDB(s.path)
+	if err != nil {
+		return "", err
+	}
+	s.db = db
+	if restore {
+		s.archiveKey = ""
+	}
+	return key, nil
+}
+
+// evict closes the shard's store and removes its local data file. Returns
+// ErrShardPinned if the shard is pinned.
+func (s *Shard) evict() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pins > 0 {
+		return ErrShardPinned
+	}
+
+	if s.db != nil {
+		if err := s.db.Close(); err != nil {
+			return err
+		}
+		s.db = nil
+	}
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// loadArchiveManifest loads the shard's metadata into the index and its series
+// filter from a manifest. The filter is rebuilt from the series if the
+// manifest doesn't have a valid one.
+func (s *Shard) loadArchiveManifest(m *archiveManifest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+
+	for k, v := range m.Fields {
+		if err := s.indexMeasurementFields(k, v); err != nil {
+			return err
+		}
+	}
+	for k, v := range m.Series {
+		if err := s.indexSeries(k, v, nil); err != nil {
+			return err
+		}
+	}
+
+	f := &bloomFilter{}
+	if err := f.UnmarshalBinary(m.Filter); err != nil {
+		f = newSeriesFilter(len(m.Series))
+		for k := range m.Series {
+			f.insert([]byte(k))
+		}
+	}
+	s.seriesFilter = f
+	s.archiveKey = m.Key
+	return nil
+}
+
+// readArchiveManifest reads a manifest from path.
+func readArchiveManifest(path string) (*archiveManifest, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &archiveManifest{}
+	if err := json.Unmarshal(buf, m); err != nil {
+		return nil, fmt.Errorf("read archive manifest: %s", err)
+	}
+	return m, nil
+}
+
+// writeArchiveManifest writes a manifest to path. The file is written to a
+// temporary path first so that a partial manifest is never read.
+func writeArchiveManifest(path string, m *archiveManifest) error {
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	tmppath := path + ".tmp"
+	if err := ioutil.WriteFile(tmppath, buf, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmppath, path)
+}
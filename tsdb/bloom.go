@@ -0,0 +1,200 @@
+package tsdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	// minSeriesFilterCapacity is the smallest number of series a shard's
+	// series filter is sized for.
+	minSeriesFilterCapacity = 1 << 14
+
+	// seriesFilterFalsePositiveRate is the rate the series filter is sized
+	// for. The actual rate is lower until the filter reaches its capacity.
+	seriesFilterFalsePositiveRate = 0.01
+)
+
+// errBloomFilterInvalid is returned when an encoded bloom filter can't be decoded.
+var errBloomFilterInvalid = errors.New("invalid bloom filter")
+
+// bloomFilter is a probabilistic set of keys. contains never returns false
+// for an inserted key but may return true for a key that wasn't inserted.
+type bloomFilter struct {
+	bits     []uint64
+	m        uint64 // number of bits
+	k        uint64 // number of hashes per key
+	n        int    // number of keys inserted, less any false positives
+	capacity int    // number of keys the filter was sized for
+}
+
+// newBloomFilter returns a filter sized for n keys with a false positive rate of p.
+func newBloomFilter(n int, p float64) *bloomFilter {
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Ceil(math.Ln2 * float64(m) / float64(n)))
+	return &bloomFilter{
+		bits:     make([]uint64, (m+63)/64),
+		m:        m,
+		k:        k,
+		capacity: n,
+	}
+}
+
+// insert adds a key to the filter.
+func (f *bloomFilter) insert(key []byte) {
+	if f.contains(key) {
+		return
+	}
+	h1, h2 := bloomHash(key)
+	for i := uint64(0); i < f.k; i++ {
+		loc := (h1 + i*h2) % f.m
+		f.bits[loc/64] |= 1 << (loc % 64)
+	}
+	f.n++
+}
+
+// contains returns false if the key was definitely never inserted.
+func (f *bloomFilter) contains(key []byte) bool {
+	h1, h2 := bloomHash(key)
+	for i := uint64(0); i < f.k; i++ {
+		loc := (h1 + i*h2) % f.m
+		if f.bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// full returns true once more keys have been inserted than the filter was sized for.
+func (f *bloomFilter) full() bool { return f.n > f.capacity }
+
+// MarshalBinary encodes the filter's size followed by its bits.
+func (f *bloomFilter) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 32+8*len(f.bits))
+	binary.BigEndian.PutUint64(buf[0:8], f.m)
+	binary.BigEndian.PutUint64(buf[8:16], f.k)
+	binary.BigEndian.PutUint64(buf[16:24], uint64(f.n))
+	binary.BigEndian.PutUint64(buf[24:32], uint64(f.capacity))
+	for i, w := range f.bits {
+		binary.BigEndian.PutUint64(buf[32+8*i:], w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (f *bloomFilter) UnmarshalBinary(buf []byte) error {
+	if len(buf) < 32 {
+		return errBloomFilterInvalid
+	}
+	m, k := binary.BigEndian.Uint64(buf[0:8]), binary.BigEndian.Uint64(buf[8:16])
+	if m == 0 || k == 0 || uint64(len(buf)-32) != 8*((m+63)/64) {
+		return errBloomFilterInvalid
+	}
+
+	f.m, f.k = m, k
+	f.n = int(binary.BigEndian.Uint64(buf[16:24]))
+	f.capacity = int(binary.BigEndian.Uint64(buf[24:32]))
+	f.bits = make([]uint64, (m+63)/64)
+	for i := range f.bits {
+		f.bits[i] = binary.BigEndian.Uint64(buf[32+8*i:])
+	}
+	return nil
+}
+
+// bloomHash returns the two hashes of a key combined to derive its bit locations.
+func bloomHash(key []byte) (uint64, uint64) {
+	h1 := fnv.New64a()
+	h1.Write(key)
+	h2 := fnv.New64()
+	h2.Write(key)
+	return h1.Sum64(), h2.Sum64() | 1
+}
+
+// newSeriesFilter returns an empty series filter for a shard with n series.
+// It's sized for twice as many so it can grow before being rebuilt.
+func newSeriesFilter(n int) *bloomFilter {
+	if n *= 2; n < minSeriesFilterCapacity {
+		n = minSeriesFilterCapacity
+	}
+	return newBloomFilter(n, seriesFilterFalsePositiveRate)
+}
+
+// loadSeriesFilter builds the shard's series filter from the series buckets
+// in its data file. The shard lock must be held.
+func (s *Shard) loadSeriesFilter() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		var n int
+		if err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if !isShardMetaBucket(name) {
+				n++
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		f := newSeriesFilter(n)
+		if err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if !isShardMetaBucket(name) {
+				f.insert(name)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		s.seriesFilter = f
+		return nil
+	})
+}
+
+// addToSeriesFilter adds series new to the shard to its series filter,
+// rebuilding the filter if it has outgrown its size. Writes to existing
+// series don't take the shard lock.
+func (s *Shard) addToSeriesFilter(keys map[string]struct{}) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seriesFilter == nil {
+		return nil
+	}
+	for k := range keys {
+		s.seriesFilter.insert([]byte(k))
+	}
+	if s.seriesFilter.full() {
+		return s.loadSeriesFilter()
+	}
+	return nil
+}
+
+// mayContainSeries returns false if none of the series have been written to
+// the shard. It can return true for series that haven't been written.
+func (s *Shard) mayContainSeries(keys []string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.seriesFilter == nil {
+		return true
+	}
+	for _, k := range keys {
+		if s.seriesFilter.contains([]byte(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isShardMetaBucket returns true if a top level bucket of a shard's data
+// file holds metadata instead of the points of a series.
+func isShardMetaBucket(name []byte) bool {
+	switch string(name) {
+	case "series", "fields", "seriesfields", shardFormatBucket:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package tsdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Ensure a bloom filter contains every inserted key and few others.
+func TestBloomFilter(t *testing.T) {
+	f := newBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.insert([]byte(fmt.Sprintf("cpu,host=server%d", i)))
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.contains([]byte(fmt.Sprintf("cpu,host=server%d", i))) {
+			t.Fatalf("key %d not found", i)
+		}
+	}
+
+	var fp int
+	for i := 1000; i < 11000; i++ {
+		if f.contains([]byte(fmt.Sprintf("cpu,host=server%d", i))) {
+			fp++
+		}
+	}
+	if fp > 300 {
+		t.Fatalf("too many false positives: %d/10000", fp)
+	}
+
+	if f.full() {
+		t.Fatal("filter full before capacity")
+	}
+
+	// Keys that are false positives when inserted aren't counted, so the
+	// filter fills a little after its capacity.
+	for i := 0; i < 100; i++ {
+		f.insert([]byte(fmt.Sprintf("mem,host=server%d", i)))
+	}
+	if !f.full() {
+		t.Fatal("filter not full after capacity")
+	}
+}
+
+// Ensure a bloom filter can be encoded and decoded.
+func TestBloomFilter_MarshalBinary(t *testing.T) {
+	f := newBloomFilter(1000, 0.01)
+	for i := 0; i < 100; i++ {
+		f.insert([]byte(fmt.Sprintf("cpu,host=server%d", i)))
+	}
+	buf, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var other bloomFilter
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(f, &other) {
+		t.Fatal("decoded filter doesn't match")
+	} else if err := other.UnmarshalBinary(buf[:len(buf)-8]); err != errBloomFilterInvalid {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a shard's series filter is kept up to date by writes and rebuilt on open.
+func TestShard_MayContainSeries(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if sh.mayContainSeries([]string{"cpu,host=a"}) {
+		t.Fatal("empty shard may contain series")
+	}
+
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	validate := func() {
+		if !sh.mayContainSeries([]string{"cpu,host=b", "cpu,host=a"}) {
+			t.Fatal("written series not found")
+		} else if sh.mayContainSeries([]string{"cpu,host=b"}) {
+			t.Fatal("unwritten series found")
+		}
+	}
+	validate()
+
+	sh.Close()
+	sh = NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+	validate()
+}
@@ -0,0 +1,157 @@
+package tsdb
+
+import (
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// compactBatchSize is the number of key and value bytes copied in each
+// transaction when compacting a shard.
+const compactBatchSize = 16 * 1024 * 1024
+
+// CompactShard rewrites a shard's data file without its free pages so the
+// space left by deleted data is returned to the filesystem. The shard stays
+// open and writes continue while the copy is made. Returns the number of
+// bytes reclaimed.
+func (s *Store) CompactShard(shardID uint64) (int64, error) {
+	sh := s.Shard(shardID)
+	if sh == nil {
+		return 0, ErrShardNotFound
+	} else if sh.ArchiveKey() != "" {
+		return 0, ErrShardArchived
+	} else if err := sh.openDeferred(); err != nil {
+		return 0, err
+	}
+
+	fi, err := os.Stat(sh.Path())
+	if err != nil {
+		return 0, err
+	}
+	tmppath := sh.Path() + ".compact"
+	os.Remove(tmppath)
+	txID, err := sh.compactTo(tmppath)
+	if err != nil {
+		os.Remove(tmppath)
+		return 0, err
+	}
+	defer os.Remove(tmppath)
+	compacted, err := os.Stat(tmppath)
+	if err != nil {
+		return 0, err
+	}
+
+	// Block writes while the shard is switched over.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shards[shardID] != sh {
+		return 0, ErrShardNotFound
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	// Give up if the shard was written to during the copy so no writes are
+	// lost.
+	if other, err := sh.txID(); err != nil {
+		return 0, err
+	} else if other != txID {
+		return 0, ErrShardModified
+	}
+
+	// Reads holding a pin would block closing the shard's store.
+	if sh.pins > 0 {
+		return 0, ErrShardPinned
+	}
+
+	if err := sh.db.Close(); err != nil {
+		return 0, err
+	}
+
+	// Reopen the original file if the compacted one can't replace it.
+	renameErr := os.Rename(tmppath, sh.path)
+	db, err := sh.openDB(sh.path)
+	if err != nil {
+		sh.db = nil
+		return 0, err
+	}
+	sh.db = db
+	if renameErr != nil {
+		return 0, renameErr
+	}
+
+	return fi.Size() - compacted.Size(), nil
+}
+
+// compactTo writes the shard's buckets to a new data file at path. Unlike
+// copyTo only the pages in use are written. Returns the ID of the
+// transaction that was copied.
+func (s *Shard) compactTo(path string) (int, error) {
+	dst, err := bolt.Open(path, 0666, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+
+	var txID int
+	err = s.db.View(func(tx *bolt.Tx) error {
+		txID = tx.ID()
+
+		// Copy the top level buckets in batches so the whole shard isn't
+		// held in memory and every bucket doesn't cost its own commit.
+		dtx, err := dst.Begin(true)
+		if err != nil {
+			return err
+		}
+		defer func() { dtx.Rollback() }()
+
+		var n int
+		if err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			fill := bolt.DefaultFillPercent
+			if !isShardMetaBucket(name) {
+				m := string(unescape([]byte(measurementFromSeriesKey(string(name)))))
+				fill = fillPercent(s.index.patterns[m])
+			}
+
+			bkt, err := dtx.CreateBucket(name)
+			if err != nil {
+				return err
+			} else if err := copyBucket(bkt, b, fill, &n); err != nil {
+				return err
+			}
+
+			// Commit once the batch is large enough and start the next one.
+			if n < compactBatchSize {
+				return nil
+			} else if err := dtx.Commit(); err != nil {
+				return err
+			}
+			n = 0
+			dtx, err = dst.Begin(true)
+			return err
+		}); err != nil {
+			return err
+		}
+		return dtx.Commit()
+	})
+	return txID, err
+}
+
+// copyBucket copies the keys and nested buckets of src to dst and adds the
+// number of bytes copied to n.
+func copyBucket(dst, src *bolt.Bucket, fill float64, n *int) error {
+	dst.FillPercent = fill
+	return src.ForEach(func(k, v []byte) error {
+		*n += len(k) + len(v)
+		if v != nil {
+			return dst.Put(k, v)
+		}
+
+		bkt, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucket(bkt, src.Bucket(k), fill, n)
+	})
+}
@@ -1,8 +1,11 @@
 package tsdb
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/toml"
 )
 
@@ -19,6 +22,51 @@ const (
 
 	// DefaultRetentionCheckPeriod is the period of time between retention policy checks are run
 	DefaultRetentionCheckPeriod = 10 * time.Minute
+
+	// DefaultSeriesCollation is the default order of series keys and tag values in query results.
+	DefaultSeriesCollation = "binary"
+
+	// DefaultMaxConcurrentQueries is the default limit of concurrently executing queries.
+	// Zero means unlimited.
+	DefaultMaxConcurrentQueries = 0
+
+	// DefaultMaxQueryBytes is the default limit of bytes a single query may read.
+	// Zero means unlimited.
+	DefaultMaxQueryBytes = 0
+
+	// DefaultMaxSeriesPerDatabase is the default limit of series in each
+	// database on a node. Zero means unlimited.
+	DefaultMaxSeriesPerDatabase = 0
+
+	// DefaultMaxValuesPerTag is the default limit of values of each tag key
+	// in a measurement. Zero means unlimited.
+	DefaultMaxValuesPerTag = 0
+
+	// DefaultMaxPointsPerBatch is the default limit of points in a single
+	// write. Zero means unlimited.
+	DefaultMaxPointsPerBatch = 0
+
+	// DefaultMaxSeriesKeyLength is the default limit of the length of a new
+	// series key, in bytes.
+	DefaultMaxSeriesKeyLength = MaxSeriesKeyLength
+
+	// DefaultMaxTagsPerSeries is the default limit of tags of a new series.
+	DefaultMaxTagsPerSeries = 256
+
+	// DefaultMaxTagKeyLength is the default limit of the length of a tag key
+	// of a new series, in bytes.
+	DefaultMaxTagKeyLength = 256
+
+	// DefaultMaxTagValueLength is the default limit of the length of a tag
+	// value of a new series, in bytes.
+	DefaultMaxTagValueLength = 4096
+
+	// DefaultPrecision is the default precision timestamps are stored at.
+	DefaultPrecision = "n"
+
+	// DefaultLazyShardOpen is the default for deferring opening shard data
+	// files until they're accessed.
+	DefaultLazyShardOpen = false
 )
 
 type Config struct {
@@ -27,15 +75,169 @@ type Config struct {
 	RetentionCheckEnabled bool          `toml:"retention-check-enabled"`
 	RetentionCheckPeriod  toml.Duration `toml:"retention-check-period"`
 	RetentionCreatePeriod toml.Duration `toml:"retention-create-period"`
+
+	// ColdDir is a second data directory, typically on slower disk, holding
+	// the shards moved to the cold tier. Empty disables the cold tier.
+	ColdDir string `toml:"cold-dir"`
+
+	// SeriesCollation is either "binary" or "natural".
+	SeriesCollation string `toml:"series-collation"`
+
+	// Precision is the precision timestamps are stored at, one of n, u, ms,
+	// s, m or h. Written points are truncated to it so writers sending
+	// different precisions don't create duplicate points.
+	Precision string `toml:"precision"`
+
+	// MaxConcurrentQueries limits the number of SELECT statements that execute
+	// at the same time. Zero means unlimited.
+	MaxConcurrentQueries int `toml:"max-concurrent-queries"`
+
+	// MaxQueryBytes limits the bytes a single query may read across all of
+	// the shards it hits. Zero means unlimited.
+	MaxQueryBytes int64 `toml:"max-query-bytes"`
+
+	// MaxSeriesPerDatabase limits the series in each database on this node.
+	// Points creating series over the limit are dropped. Zero means unlimited.
+	MaxSeriesPerDatabase int `toml:"max-series-per-database"`
+
+	// MaxValuesPerTag limits the values of each tag key in a measurement.
+	// Writes creating more are rejected. Zero means unlimited.
+	MaxValuesPerTag int `toml:"max-values-per-tag"`
+
+	// MaxPointsPerBatch limits the points in a single write. Larger writes
+	// are rejected, and writes to other nodes are split into batches of at
+	// most this many points. Zero means unlimited.
+	MaxPointsPerBatch int `toml:"max-points-per-batch"`
+
+	// Limits of the keys of new series, in bytes of the escaped key. Writes
+	// creating series over them are rejected. Zero means unlimited, although
+	// series keys can't be longer than MaxSeriesKeyLength.
+	MaxSeriesKeyLength int `toml:"max-series-key-length"`
+	MaxTagsPerSeries   int `toml:"max-tags-per-series"`
+	MaxTagKeyLength    int `toml:"max-tag-key-length"`
+	MaxTagValueLength  int `toml:"max-tag-value-length"`
+
+	// MaxWriteQueueBytesPerDatabase limits the bytes of writes to each
+	// database waiting to be committed. Writes over the limit are rejected
+	// so one database can't stall writes to the others. Zero means unlimited.
+	MaxWriteQueueBytesPerDatabase int `toml:"max-write-queue-bytes-per-database"`
+
+	// MeasurementHints describe how measurements are written so their
+	// storage can be tuned.
+	MeasurementHints []MeasurementHint `toml:"measurement-hint"`
+
+	// FieldConflictPolicies set how fields written with a different type
+	// than the existing field are handled in each database.
+	FieldConflictPolicies []FieldConflictPolicy `toml:"field-conflict-policy"`
+
+	// ShardPinTimeout is the longest a shard's data is held for an export
+	// or backup before its pin is released.
+	ShardPinTimeout toml.Duration `toml:"shard-pin-timeout"`
+
+	// IndexSnapshotInterval is the time between snapshots of the series and
+	// fields of changed shards. Shards load their index from the snapshot on
+	// startup if they haven't changed since. Zero disables index snapshots.
+	IndexSnapshotInterval toml.Duration `toml:"index-snapshot-interval"`
+
+	// GroupCommitWindow is the time each shard waits for concurrent writes
+	// to commit in the same transaction, so they are synced to disk once.
+	// Zero commits every write on its own.
+	GroupCommitWindow toml.Duration `toml:"group-commit-window"`
+
+	// HotCacheSize is the size of each shard's cache of recently written
+	// points, in bytes. Queries over recent time ranges read from the cache
+	// instead of the data file. Zero disables the cache.
+	HotCacheSize int `toml:"hot-cache-size"`
+
+	// ShardMmapSize is the smallest size each shard's data file is memory
+	// mapped at, in bytes. Data files are mapped at twice their size once
+	// they're larger. Queries hold a read transaction for as long as they
+	// run, and a write that has to grow the mapping waits for them, so the
+	// headroom lets writes continue during long queries. Zero maps only what
+	// the data file needs.
+	ShardMmapSize int `toml:"shard-mmap-size"`
+
+	// LazyShardOpen loads the index of each shard from its index snapshot on
+	// startup and opens the shard's data file on first access, or in the
+	// background afterwards. Requires index snapshots to be enabled.
+	LazyShardOpen bool `toml:"lazy-shard-open"`
 }
 
 func NewConfig() Config {
 	return Config{
-		RetentionAutoCreate:   DefaultRetentionAutoCreate,
-		RetentionCheckEnabled: DefaultRetentionCheckEnabled,
-		RetentionCheckPeriod:  toml.Duration(DefaultRetentionCheckPeriod),
-		RetentionCreatePeriod: toml.Duration(DefaultRetentionCreatePeriod),
+		RetentionAutoCreate:           DefaultRetentionAutoCreate,
+		RetentionCheckEnabled:         DefaultRetentionCheckEnabled,
+		RetentionCheckPeriod:          toml.Duration(DefaultRetentionCheckPeriod),
+		RetentionCreatePeriod:         toml.Duration(DefaultRetentionCreatePeriod),
+		SeriesCollation:               DefaultSeriesCollation,
+		Precision:                     DefaultPrecision,
+		MaxConcurrentQueries:          DefaultMaxConcurrentQueries,
+		MaxQueryBytes:                 DefaultMaxQueryBytes,
+		MaxSeriesPerDatabase:          DefaultMaxSeriesPerDatabase,
+		MaxValuesPerTag:               DefaultMaxValuesPerTag,
+		MaxPointsPerBatch:             DefaultMaxPointsPerBatch,
+		MaxSeriesKeyLength:            DefaultMaxSeriesKeyLength,
+		MaxTagsPerSeries:              DefaultMaxTagsPerSeries,
+		MaxTagKeyLength:               DefaultMaxTagKeyLength,
+		MaxTagValueLength:             DefaultMaxTagValueLength,
+		MaxWriteQueueBytesPerDatabase: DefaultMaxWriteQueueBytesPerDatabase,
+		ShardPinTimeout:               toml.Duration(DefaultShardPinTimeout),
+		IndexSnapshotInterval:         toml.Duration(DefaultIndexSnapshotInterval),
+		GroupCommitWindow:             toml.Duration(DefaultGroupCommitWindow),
+		HotCacheSize:                  DefaultHotCacheSize,
+		ShardMmapSize:                 DefaultShardMmapSize,
+		LazyShardOpen:                 DefaultLazyShardOpen,
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c *Config) Validate() error {
+	if _, err := influxql.ParseCollation(c.SeriesCollation); err != nil {
+		return err
+	} else if err := ValidatePrecision(c.Precision); err != nil {
+		return err
+	} else if c.MaxConcurrentQueries < 0 {
+		return errors.New("max-concurrent-queries must not be negative")
+	} else if c.MaxQueryBytes < 0 {
+		return errors.New("max-query-bytes must not be negative")
+	} else if c.MaxSeriesPerDatabase < 0 {
+		return errors.New("max-series-per-database must not be negative")
+	} else if c.MaxValuesPerTag < 0 {
+		return errors.New("max-values-per-tag must not be negative")
+	} else if c.MaxPointsPerBatch < 0 {
+		return errors.New("max-points-per-batch must not be negative")
+	} else if c.MaxSeriesKeyLength < 0 || c.MaxSeriesKeyLength > MaxSeriesKeyLength {
+		return fmt.Errorf("max-series-key-length must be between 0 and %d", MaxSeriesKeyLength)
+	} else if c.MaxTagsPerSeries < 0 {
+		return errors.New("max-tags-per-series must not be negative")
+	} else if c.MaxTagKeyLength < 0 {
+		return errors.New("max-tag-key-length must not be negative")
+	} else if c.MaxTagValueLength < 0 {
+		return errors.New("max-tag-value-length must not be negative")
+	} else if c.MaxWriteQueueBytesPerDatabase < 0 {
+		return errors.New("max-write-queue-bytes-per-database must not be negative")
+	} else if c.ShardPinTimeout < 0 {
+		return errors.New("shard-pin-timeout must not be negative")
+	} else if c.IndexSnapshotInterval < 0 {
+		return errors.New("index-snapshot-interval must not be negative")
+	} else if c.GroupCommitWindow < 0 {
+		return errors.New("group-commit-window must not be negative")
+	} else if c.HotCacheSize < 0 {
+		return errors.New("hot-cache-size must not be negative")
+	} else if c.ShardMmapSize < 0 {
+		return errors.New("shard-mmap-size must not be negative")
+	}
+	for _, h := range c.MeasurementHints {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, p := range c.FieldConflictPolicies {
+		if err := p.Validate(); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // ShardGroupPreCreateCheckPeriod returns the check interval to pre-create shard groups.
@@ -0,0 +1,104 @@
+package tsdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// DefaultImportBatchSize is the number of points written to a shard at a
+// time by ImportPoints.
+const DefaultImportBatchSize = 5000
+
+// ExportShardFile writes the points of the shard data file at path between
+// min and max, inclusive, to w as line protocol. The shard must not be open
+// by a running server. Returns the number of points written.
+func ExportShardFile(path string, w io.Writer, min, max int64) (int, error) {
+	sh := NewShard(NewDatabaseIndex(), path)
+	if err := sh.Open(); err != nil {
+		return 0, err
+	}
+	defer sh.Close()
+	return sh.ExportPoints(w, min, max)
+}
+
+// ImportShardFile writes the line protocol points read from r to the shard
+// data file at path, creating it if it doesn't exist. The shard must not be
+// open by a running server. Returns the number of points written.
+func ImportShardFile(path string, r io.Reader) (int, error) {
+	sh := NewShard(NewDatabaseIndex(), path)
+	if err := sh.Open(); err != nil {
+		return 0, err
+	}
+	defer sh.Close()
+	return sh.ImportPoints(r, DefaultImportBatchSize)
+}
+
+// ExportPoints writes the points of the shard between min and max, inclusive,
+// to w as line protocol with nanosecond timestamps. Points are written by
+// series in key order and then in time order. Series are read from the
+// shard's data buckets so every series with points is written. Returns the
+// number of points written.
+func (s *Shard) ExportPoints(w io.Writer, min, max int64) (int, error) {
+	bw := bufio.NewWriter(w)
+
+	var n int
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(k []byte, b *bolt.Bucket) error {
+			if isShardMetaBucket(k) {
+				return nil
+			}
+
+			ss := newSeriesFromKey(k)
+			name := string(unescape([]byte(measurementFromSeriesKey(ss.Key))))
+			codec := s.FieldCodec(name)
+			if codec == nil {
+				return fmt.Errorf("no fields for measurement: %s", name)
+			}
+
+			return forEachInRange(b, min, max, func(tk, v []byte) error {
+				fields, err := codec.DecodeFieldsWithNames(v)
+				if err != nil {
+					return err
+				} else if len(fields) == 0 {
+					return nil
+				}
+
+				p := NewPoint(name, ss.Tags, fields, time.Unix(0, int64(btou64(tk))))
+				if _, err := bw.WriteString(p.String() + "\n"); err != nil {
+					return err
+				}
+				n++
+				return nil
+			})
+		})
+	}); err != nil {
+		return n, err
+	}
+
+	return n, bw.Flush()
+}
+
+// ImportPoints writes the line protocol points read from r to the shard,
+// batchSize points at a time. Timestamps must be in nanoseconds. Blank lines
+// and lines starting with # are skipped. Returns the number of points written.
+func (s *Shard) ImportPoints(r io.Reader, batchSize int) (int, error) {
+	var n int
+	pr := NewPointReader(r, "n")
+	for {
+		points, err := pr.ReadBatch(batchSize)
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+
+		if err := s.WritePoints(points); err != nil {
+			return n, err
+		}
+		n += len(points)
+	}
+}
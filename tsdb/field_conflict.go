@@ -0,0 +1,164 @@
+package tsdb
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// FieldConflictMode describes what happens to a field written with a
+// different type than the existing field in the shard.
+type FieldConflictMode string
+
+const (
+	// FieldConflictReject rejects the whole write with a field type conflict.
+	FieldConflictReject FieldConflictMode = "reject"
+
+	// FieldConflictCoerce converts integers to floats and whole floats to
+	// integers. Other conflicting values are rejected.
+	FieldConflictCoerce FieldConflictMode = "coerce"
+
+	// FieldConflictDropField removes the conflicting fields from the point.
+	// Points left without fields aren't written.
+	FieldConflictDropField FieldConflictMode = "drop-field"
+)
+
+// FieldConflictPolicy sets the field conflict mode of a database. A policy
+// without a database applies to every database.
+type FieldConflictPolicy struct {
+	Database string            `toml:"database"`
+	Mode     FieldConflictMode `toml:"mode"`
+}
+
+// Validate returns an error if the policy is invalid.
+func (p FieldConflictPolicy) Validate() error {
+	switch p.Mode {
+	case FieldConflictReject, FieldConflictCoerce, FieldConflictDropField:
+		return nil
+	default:
+		return fmt.Errorf("invalid field conflict mode: %s", p.Mode)
+	}
+}
+
+// fieldConflictMode returns the field conflict mode of a database. A policy
+// for the database takes precedence over a global policy.
+func fieldConflictMode(policies []FieldConflictPolicy, database string) FieldConflictMode {
+	mode := FieldConflictReject
+	for _, p := range policies {
+		if p.Database == "" {
+			mode = p.Mode
+		}
+	}
+	for _, p := range policies {
+		if p.Database == database {
+			mode = p.Mode
+		}
+	}
+	return mode
+}
+
+// FieldConflictStats counts the conflicting fields written to a shard that
+// were coerced or dropped.
+type FieldConflictStats struct {
+	Coerced int64
+	Dropped int64
+}
+
+// FieldConflictStats returns the number of conflicting fields the shard has
+// coerced or dropped since it was opened.
+func (s *Shard) FieldConflictStats() FieldConflictStats {
+	return FieldConflictStats{
+		Coerced: atomic.LoadInt64(&s.fieldsCoerced),
+		Dropped: atomic.LoadInt64(&s.fieldsDropped),
+	}
+}
+
+// resolveFieldConflicts coerces or drops the fields of points whose type
+// conflicts with an existing field, depending on the database's field
+// conflict mode. Changed points are copied so the caller's points are left
+// as they are. Conflicts that can't be resolved are left for validation.
+func (s *Shard) resolveFieldConflicts(points []Point) []Point {
+	mode := s.index.fieldConflictMode
+	if mode == "" || mode == FieldConflictReject {
+		return points
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var a []Point // set once the first point is changed
+	for i, p := range points {
+		mf := s.measurementFields[p.Name()]
+		if mf == nil {
+			if a != nil {
+				a = append(a, p)
+			}
+			continue
+		}
+
+		fields, changed := p.Fields(), false
+		for name, value := range fields {
+			f := mf.Fields[name]
+			if f == nil || f.Type == influxql.InspectDataType(value) {
+				continue
+			}
+
+			if mode == FieldConflictCoerce {
+				if v, ok := coerceField(value, f.Type); ok {
+					fields[name], changed = v, true
+					atomic.AddInt64(&s.fieldsCoerced, 1)
+				}
+				continue
+			}
+			delete(fields, name)
+			changed = true
+			atomic.AddInt64(&s.fieldsDropped, 1)
+		}
+
+		if !changed {
+			if a != nil {
+				a = append(a, p)
+			}
+			continue
+		}
+
+		if a == nil {
+			a = make([]Point, i, len(points))
+			copy(a, points[:i])
+		}
+		if len(fields) > 0 {
+			a = append(a, NewPoint(p.Name(), p.Tags(), fields, p.Time()))
+		}
+	}
+
+	if a == nil {
+		return points
+	}
+	return a
+}
+
+// coerceField converts a value to an integer or float field type. Floats
+// are only converted to integers if they are whole. Unsigned integers are
+// only converted to floats.
+func coerceField(v interface{}, typ influxql.DataType) (interface{}, bool) {
+	switch typ {
+	case influxql.Float:
+		switch v := v.(type) {
+		case int64:
+			return float64(v), true
+		case int32:
+			return float64(v), true
+		case int:
+			return float64(v), true
+		case uint64:
+			return float64(v), true
+		}
+	case influxql.Integer:
+		if f, ok := v.(float64); ok && f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), true
+		}
+	}
+	return nil, false
+}
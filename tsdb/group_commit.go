@@ -0,0 +1,75 @@
+package tsdb
+
+import (
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// DefaultGroupCommitWindow is the default time a shard waits for concurrent
+// writes to commit with. Zero commits every write on its own.
+const DefaultGroupCommitWindow = 0
+
+// commitGroup is a set of writes to a shard that commit in one transaction.
+type commitGroup struct {
+	fns  []func(*bolt.Tx) error
+	errs []error
+	done chan struct{}
+}
+
+// update runs fn in a read-write transaction like bolt's Update. If the shard
+// has a group commit window, writes made within the window of each other
+// share a transaction so they are synced to disk once. fn may be run more
+// than once if another write in its group fails.
+func (s *Shard) update(fn func(*bolt.Tx) error) error {
+	if s.commitWindow <= 0 {
+		return s.db.Update(fn)
+	}
+
+	// Join the pending group or start a new one that commits once the
+	// window has passed.
+	s.commitMu.Lock()
+	g := s.pendingCommit
+	if g == nil {
+		g = &commitGroup{done: make(chan struct{})}
+		s.pendingCommit = g
+		time.AfterFunc(s.commitWindow, func() { s.commitGroup(g) })
+	}
+	i := len(g.fns)
+	g.fns = append(g.fns, fn)
+	s.commitMu.Unlock()
+
+	<-g.done
+	return g.errs[i]
+}
+
+// commitGroup commits the writes of a group in one transaction. If any of
+// them fails then each write is retried in its own transaction so only the
+// failed writes return an error.
+func (s *Shard) commitGroup(g *commitGroup) {
+	s.commitMu.Lock()
+	if s.pendingCommit == g {
+		s.pendingCommit = nil
+	}
+	s.commitMu.Unlock()
+	defer close(g.done)
+
+	g.errs = make([]error, len(g.fns))
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, fn := range g.fns {
+			if err := fn(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err == nil || len(g.fns) == 1 {
+		for i := range g.errs {
+			g.errs[i] = err
+		}
+		return
+	}
+
+	for i, fn := range g.fns {
+		g.errs[i] = s.db.Update(fn)
+	}
+}
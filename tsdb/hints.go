@@ -0,0 +1,85 @@
+package tsdb
+
+import (
+	"fmt"
+
+	"github.com/boltdb/bolt"
+)
+
+// IngestPattern describes how points are written to a measurement.
+type IngestPattern string
+
+const (
+	// IngestAppendOnly is for measurements whose series are written in time
+	// order. Series pages are filled completely since points are never
+	// inserted before existing ones.
+	IngestAppendOnly IngestPattern = "append-only"
+
+	// IngestHighChurn is for measurements with points that are frequently
+	// overwritten or written out of order. Series pages are left with extra
+	// room so inserts split fewer pages.
+	IngestHighChurn IngestPattern = "high-churn"
+
+	// IngestSparse is for measurements with many series that each have few
+	// points. Their series aren't kept in the hot cache.
+	IngestSparse IngestPattern = "sparse"
+)
+
+const (
+	// appendOnlyFillPercent is the page fill of append-only series.
+	appendOnlyFillPercent = 1.0
+
+	// highChurnFillPercent is the page fill of high-churn series.
+	highChurnFillPercent = 0.25
+)
+
+// MeasurementHint sets the ingest pattern of a measurement. A hint without a
+// database applies to the measurement in every database.
+type MeasurementHint struct {
+	Database    string        `toml:"database"`
+	Measurement string        `toml:"measurement"`
+	Pattern     IngestPattern `toml:"pattern"`
+}
+
+// Validate returns an error if the hint is invalid.
+func (h MeasurementHint) Validate() error {
+	if h.Measurement == "" {
+		return fmt.Errorf("measurement hint must have a measurement")
+	}
+	switch h.Pattern {
+	case IngestAppendOnly, IngestHighChurn, IngestSparse:
+		return nil
+	default:
+		return fmt.Errorf("invalid ingest pattern for measurement %s: %s", h.Measurement, h.Pattern)
+	}
+}
+
+// measurementPatterns returns the ingest pattern of each hinted measurement
+// in a database. Hints for the database take precedence over global hints.
+func measurementPatterns(hints []MeasurementHint, database string) map[string]IngestPattern {
+	var m map[string]IngestPattern
+	for _, global := range []bool{true, false} {
+		for _, h := range hints {
+			if (h.Database == "") != global || (!global && h.Database != database) {
+				continue
+			}
+			if m == nil {
+				m = make(map[string]IngestPattern)
+			}
+			m[h.Measurement] = h.Pattern
+		}
+	}
+	return m
+}
+
+// fillPercent returns the page fill used for the series of a measurement.
+func fillPercent(pattern IngestPattern) float64 {
+	switch pattern {
+	case IngestAppendOnly:
+		return appendOnlyFillPercent
+	case IngestHighChurn:
+		return highChurnFillPercent
+	default:
+		return bolt.DefaultFillPercent
+	}
+}
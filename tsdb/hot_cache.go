@@ -0,0 +1,302 @@
+package tsdb
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	// DefaultHotCacheSize is the default size of each shard's cache of
+	// recently written points, in bytes. Zero disables the cache.
+	DefaultHotCacheSize = 0
+
+	// hotCacheSeriesPoints is the most points cached for each series.
+	hotCacheSeriesPoints = 1000
+
+	// hotPointOverhead approximates the memory used by a cached point
+	// besides its encoded fields.
+	hotPointOverhead = 32
+)
+
+// hotCache holds the most recently written points of the most recently
+// written series of a shard, so queries over recent time ranges can read
+// them without touching the shard's data file. For each series it holds
+// every point from a time on, including points written out of order.
+// Series are evicted in least recently written order once the cache is
+// over its size.
+type hotCache struct {
+	mu      sync.RWMutex
+	maxSize int
+	size    int
+	gen     uint64                   // incremented when series are removed
+	series  map[string]*list.Element // series key to element in lru
+	lru     *list.List               // of *hotSeries, most recently written first
+}
+
+// hotSeries is the cached points of a series.
+type hotSeries struct {
+	key    string
+	min    uint64     // every point of the series from min on is cached
+	points []hotPoint // in key order, never modified in place
+	size   int
+}
+
+// hotPoint is a point as stored in a series' bucket.
+type hotPoint struct {
+	key  uint64
+	data []byte
+}
+
+// newHotCache returns an empty cache of maxSize bytes.
+func newHotCache(maxSize int) *hotCache {
+	return &hotCache{
+		maxSize: maxSize,
+		series:  make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// has returns true if the series is cached.
+func (c *hotCache) has(key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.series[key] != nil
+}
+
+// bytes returns the size of the cached points.
+func (c *hotCache) bytes() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.size
+}
+
+// generation returns a value that changes whenever series are removed.
+func (c *hotCache) generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+// add caches points once they have been written to the shard. mins holds
+// the key after the last point in the data file before the write for each
+// series that wasn't cached, so only those series are added. gen is the
+// generation read in the write's transaction; the points are ignored if
+// series were removed since, as they may have been deleted.
+func (c *hotCache) add(points []Point, mins map[string]uint64, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+
+	for _, p := range points {
+		key := string(p.Key())
+		el := c.series[key]
+		if el == nil {
+			min, ok := mins[key]
+			if !ok {
+				continue
+			}
+			el = c.lru.PushFront(&hotSeries{key: key, min: min})
+			c.series[key] = el
+		}
+		c.lru.MoveToFront(el)
+
+		s := el.Value.(*hotSeries)
+		k := uint64(p.UnixNano())
+		if k < s.min {
+			continue
+		}
+		c.size -= s.size
+		s.insert(hotPoint{key: k, data: append([]byte(nil), p.Data()...)})
+		c.size += s.size
+	}
+
+	// Evict the least recently written series until the cache fits.
+	for c.size > c.maxSize && c.lru.Len() > 0 {
+		s := c.lru.Remove(c.lru.Back()).(*hotSeries)
+		delete(c.series, s.key)
+		c.size -= s.size
+	}
+}
+
+// insert adds a point to the series, replacing a point with the same key
+// like the series' bucket does. The oldest point is dropped if the series
+// holds too many. Cursors share the series' points, so a point is only
+// appended after the end of the points in place; points written out of
+// order or replacing another are inserted into a copy.
+func (s *hotSeries) insert(p hotPoint) {
+	n := len(s.points)
+	i := sort.Search(n, func(i int) bool { return s.points[i].key >= p.key })
+	if i < n && s.points[i].key == p.key {
+		other := make([]hotPoint, n)
+		copy(other, s.points)
+		other[i] = p
+		s.size += len(p.data) - len(s.points[i].data)
+		s.points = other
+		return
+	}
+
+	if i == n {
+		s.points = append(s.points, p)
+	} else {
+		other := make([]hotPoint, n+1)
+		copy(other, s.points[:i])
+		other[i] = p
+		copy(other[i+1:], s.points[i:])
+		s.points = other
+	}
+	s.size += len(p.data) + hotPointOverhead
+
+	if len(s.points) > hotCacheSeriesPoints {
+		s.min = s.points[0].key + 1
+		s.size -= len(s.points[0].data) + hotPointOverhead
+		s.points = s.points[1:]
+	}
+}
+
+// cursor returns a cursor over the cached points of a series, or nil if
+// the cache doesn't hold every point of the series from seek on.
+func (c *hotCache) cursor(key string, seek uint64) *hotCursor {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	el := c.series[key]
+	if el == nil {
+		return nil
+	}
+	s := el.Value.(*hotSeries)
+	if s.min > seek {
+		return nil
+	}
+	return &hotCursor{points: s.points}
+}
+
+// last returns the newest cached point of a series. As every point of a
+// cached series from its min on is held, it's the newest point in the shard.
+func (c *hotCache) last(key string) (hotPoint, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	el := c.series[key]
+	if el == nil {
+		return hotPoint{}, false
+	}
+	s := el.Value.(*hotSeries)
+	if len(s.points) == 0 {
+		return hotPoint{}, false
+	}
+	return s.points[len(s.points)-1], true
+}
+
+// remove removes series from the cache so their points are read from the
+// data file. It must be called when points are deleted from the shard.
+func (c *hotCache) remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for _, key := range keys {
+		if el := c.series[key]; el != nil {
+			s := c.lru.Remove(el).(*hotSeries)
+			delete(c.series, key)
+			c.size -= s.size
+		}
+	}
+}
+
+// reset removes every series from the cache.
+func (c *hotCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.series = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+}
+
+// hotCursor iterates over the cached points of a series like a cursor on
+// the series' bucket. It reads the series' points in place; later writes
+// replace the series' slice rather than modifying it, so the cursor sees
+// the points as they were when it was created.
+type hotCursor struct {
+	points []hotPoint
+	i      int
+}
+
+// Seek moves the cursor to the first point with a key at or after seek.
+func (c *hotCursor) Seek(seek []byte) (key, value []byte) {
+	k := btou64(seek)
+	c.i = sort.Search(len(c.points), func(i int) bool { return c.points[i].key >= k })
+	return c.item()
+}
+
+// Next moves the cursor to the next point.
+func (c *hotCursor) Next() (key, value []byte) {
+	c.i++
+	return c.item()
+}
+
+func (c *hotCursor) item() (key, value []byte) {
+	if c.i >= len(c.points) {
+		return nil, nil
+	}
+	p := c.points[c.i]
+	return u64tob(p.key), p.data
+}
+
+// hotCursor returns a cursor over the cached points of a series if every
+// point from tmin on is cached. Returns nil otherwise.
+func (s *Shard) hotCursor(key string, tmin int64) cursor {
+	if s.hotCache == nil {
+		return nil
+	}
+	if c := s.hotCache.cursor(key, uint64(tmin)); c != nil {
+		return c
+	}
+	return nil
+}
+
+// lastPoints returns the newest point of each series of a measurement in
+// the shard. Points are read from the hot cache, or from the data file for
+// series that aren't cached, using a read transaction from beginRead.
+func (s *Shard) lastPoints(tx *bolt.Tx, measurement string) ([]Point, error) {
+	codec := s.FieldCodec(measurement)
+	m := s.index.Measurement(measurement)
+	if codec == nil || m == nil {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	series := make([]*Series, 0, len(m.seriesByID))
+	for _, ss := range m.seriesByID {
+		series = append(series, ss)
+	}
+	m.mu.RUnlock()
+
+	var points []Point
+	for _, ss := range series {
+		var k, v []byte
+		if s.hotCache != nil {
+			if p, ok := s.hotCache.last(ss.Key); ok {
+				k, v = u64tob(p.key), p.data
+			}
+		}
+		if k == nil {
+			if b := tx.Bucket([]byte(ss.Key)); b != nil {
+				k, v = b.Cursor().Last()
+			}
+		}
+		if k == nil {
+			continue
+		}
+
+		fields, err := codec.DecodeFieldsWithNames(v)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, NewPoint(measurement, ss.Tags, fields, time.Unix(0, int64(btou64(k)))))
+	}
+	return points, nil
+}
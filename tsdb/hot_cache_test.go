@@ -0,0 +1,145 @@
+package tsdb
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+// Ensure the hot cache holds the points written to a shard from the end of
+// its data file on.
+func TestShard_HotCache(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+
+	// Points written before the cache existed are only in the data file.
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(10, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sh.hotCache = newHotCache(1 << 20)
+
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 3.0}, time.Unix(30, 0)),
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 2.0}, time.Unix(20, 0)),
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 0.0}, time.Unix(5, 0)),
+		NewPoint("cpu", Tags{"host": "b"}, Fields{"value": 4.0}, time.Unix(5, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cache holds host=a from just after its last point in the data file.
+	if c := sh.hotCursor("cpu,host=a", time.Unix(10, 0).UnixNano()); c != nil {
+		t.Fatal("cursor returned for range before cached points")
+	}
+	c := sh.hotCursor("cpu,host=a", time.Unix(15, 0).UnixNano())
+	if c == nil {
+		t.Fatal("no cursor for cached range")
+	}
+	codec := sh.FieldCodec("cpu")
+	var values []interface{}
+	for k, v := c.Seek(u64tob(uint64(time.Unix(15, 0).UnixNano()))); k != nil; k, v = c.Next() {
+		value, err := codec.DecodeByID(1, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+	}
+	if len(values) != 2 || values[0] != 2.0 || values[1] != 3.0 {
+		t.Fatalf("unexpected values: %v", values)
+	}
+
+	// Every point of a new series is cached.
+	if c := sh.hotCursor("cpu,host=b", 0); c == nil {
+		t.Fatal("no cursor for new series")
+	}
+
+	// Deleting points removes the series from the cache.
+	if err := sh.deleteRange([]string{"cpu,host=a"}, math.MinInt64, time.Unix(20, 0).UnixNano()); err != nil {
+		t.Fatal(err)
+	} else if c := sh.hotCursor("cpu,host=a", time.Unix(15, 0).UnixNano()); c != nil {
+		t.Fatal("cursor returned after delete")
+	}
+}
+
+// Ensure the least recently written series are evicted once the cache is
+// over its size and only the most recent points of a series are kept.
+func TestHotCache_Evict(t *testing.T) {
+	c := newHotCache(2 * (8 + hotPointOverhead))
+	p := func(host string, sec int64) Point {
+		pt := NewPoint("cpu", Tags{"host": host}, Fields{"value": 1.0}, time.Unix(sec, 0))
+		pt.SetData(make([]byte, 8))
+		return pt
+	}
+
+	mins := map[string]uint64{"cpu,host=a": 0, "cpu,host=b": 0}
+	c.add([]Point{p("a", 1)}, mins, c.generation())
+	c.add([]Point{p("b", 1)}, mins, c.generation())
+	c.add([]Point{p("a", 2)}, mins, c.generation())
+	if c.has("cpu,host=b") {
+		t.Fatal("least recently written series not evicted")
+	} else if !c.has("cpu,host=a") {
+		t.Fatal("most recently written series evicted")
+	}
+
+	// Points added after series were removed may have been deleted.
+	gen := c.generation()
+	c.remove("cpu,host=a")
+	c.add([]Point{p("a", 3)}, mins, gen)
+	if c.has("cpu,host=a") {
+		t.Fatal("points added after remove")
+	}
+
+	s := &hotSeries{}
+	for i := 0; i < hotCacheSeriesPoints+1; i++ {
+		s.insert(hotPoint{key: uint64(i)})
+	}
+	if len(s.points) != hotCacheSeriesPoints || s.min != 1 || s.points[0].key != 1 {
+		t.Fatalf("unexpected series: %d points from %d", len(s.points), s.min)
+	}
+}
+
+// Ensure a cursor isn't affected by points added to its series after it
+// was created.
+func TestHotCache_Cursor_Snapshot(t *testing.T) {
+	c := newHotCache(1 << 20)
+	p := func(sec int64, value float64) Point {
+		pt := NewPoint("cpu", nil, Fields{"value": value}, time.Unix(sec, 0))
+		pt.SetData(u64tob(uint64(sec)*10 + uint64(value)))
+		return pt
+	}
+	mins := map[string]uint64{"cpu": 0}
+	c.add([]Point{p(1, 1), p(3, 1), p(5, 1)}, mins, c.generation())
+
+	cur := c.cursor("cpu", 0)
+	c.add([]Point{p(6, 1), p(2, 1), p(3, 2)}, mins, c.generation())
+
+	var values []uint64
+	for k, v := cur.Seek(u64tob(0)); k != nil; k, v = cur.Next() {
+		values = append(values, btou64(v))
+	}
+	if len(values) != 3 || values[0] != 11 || values[1] != 31 || values[2] != 51 {
+		t.Fatalf("unexpected values: %v", values)
+	}
+
+	// A new cursor sees every point.
+	values = nil
+	cur = c.cursor("cpu", 0)
+	for k, v := cur.Seek(u64tob(0)); k != nil; k, v = cur.Next() {
+		values = append(values, btou64(v))
+	}
+	if len(values) != 5 || values[0] != 11 || values[1] != 21 || values[2] != 32 || values[3] != 51 || values[4] != 61 {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
@@ -0,0 +1,288 @@
+package tsdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// IndexExt is the extension of the file holding a snapshot of a shard's
+// series and fields. A shard whose data hasn't changed since its snapshot was
+// written loads its index from the snapshot instead of scanning its data file.
+const IndexExt = ".index"
+
+// DefaultIndexSnapshotInterval is the default time between index snapshots.
+const DefaultIndexSnapshotInterval = 10 * time.Minute
+
+// indexSnapshotMagic identifies an index snapshot file and its version.
+var indexSnapshotMagic = []byte("IDXSNAP2")
+
+// Record types in an index snapshot.
+const (
+	indexSnapshotEnd byte = iota
+	indexSnapshotFields
+	indexSnapshotSeries
+	indexSnapshotSeriesFields
+)
+
+// maxIndexSnapshotValueSize is the largest key or value accepted when reading
+// a snapshot so a corrupt length doesn't cause a huge allocation.
+const maxIndexSnapshotValueSize = 64 * 1024 * 1024
+
+var (
+	// errIndexSnapshotStale is returned when loading a snapshot that was
+	// written before the shard's last change.
+	errIndexSnapshotStale = errors.New("index snapshot is stale")
+
+	// errIndexSnapshotInvalid is returned when a snapshot can't be decoded.
+	errIndexSnapshotInvalid = errors.New("invalid index snapshot")
+)
+
+// SnapshotIndexes writes an index snapshot for every open shard that has
+// changed since its last snapshot. Returns the number of snapshots written.
+// The store isn't locked while the snapshots are written.
+func (s *Store) SnapshotIndexes() (int, error) {
+	s.mu.RLock()
+	shards := make(map[uint64]*Shard, len(s.shards))
+	for id, sh := range s.shards {
+		shards[id] = sh
+	}
+	s.mu.RUnlock()
+
+	var n int
+	for id, sh := range shards {
+		written, err := sh.writeIndexSnapshot()
+		if err != nil {
+			return n, fmt.Errorf("snapshot index of shard %d: %s", id, err)
+		} else if written {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// snapshotIndexesEvery writes index snapshots every interval until closing is closed.
+func (s *Store) snapshotIndexesEvery(interval time.Duration, closing <-chan struct{}) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closing:
+			return
+		case <-ticker.C:
+			if _, err := s.SnapshotIndexes(); err != nil {
+				s.Logger.Printf("failed to snapshot indexes: %s", err)
+			}
+		}
+	}
+}
+
+// writeIndexSnapshot writes the shard's series and fields to its index
+// snapshot file. Returns false if the shard is closed or hasn't changed since
+// its last snapshot. The shard is only locked to begin the read transaction
+// and to install the snapshot, not while it is serialised.
+func (s *Shard) writeIndexSnapshot() (bool, error) {
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	s.mu.RLock()
+	db, path, indexTxID := s.db, s.path, s.indexTxID
+	if db == nil {
+		s.mu.RUnlock()
+		return false, nil
+	}
+	tx, err := db.Begin(false)
+	s.mu.RUnlock()
+	if err != nil {
+		return false, err
+	}
+	txID := tx.ID()
+	if txID == indexTxID {
+		tx.Rollback()
+		return false, nil
+	}
+
+	// Write to a temporary file first so that a partial snapshot is never read.
+	tmppath := path + IndexExt + ".tmp"
+	err = func() error {
+		defer tx.Rollback()
+
+		f, err := os.Create(tmppath)
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(f)
+		if err := encodeIndexSnapshot(w, tx); err != nil {
+			f.Close()
+			return err
+		} else if err := w.Flush(); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}()
+	if err != nil {
+		os.Remove(tmppath)
+		return false, err
+	}
+
+	// The transaction is closed before locking as closing the shard waits
+	// for it. The snapshot is dropped if the shard was reopened or moved.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db != db || s.path != path {
+		os.Remove(tmppath)
+		return false, nil
+	}
+	if err := os.Rename(tmppath, path+IndexExt); err != nil {
+		return false, err
+	}
+	s.indexTxID = txID
+	return true, nil
+}
+
+// indexSnapshotTxID returns the transaction the index snapshot of the shard
+// at path was written at.
+func indexSnapshotTxID(path string) (int, error) {
+	f, err := os.Open(path + IndexExt)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	hdr := make([]byte, len(indexSnapshotMagic)+8)
+	if _, err := io.ReadFull(f, hdr); err != nil {
+		return 0, errIndexSnapshotInvalid
+	} else if !bytes.Equal(hdr[:len(indexSnapshotMagic)], indexSnapshotMagic) {
+		return 0, errIndexSnapshotInvalid
+	}
+	return int(btou64(hdr[len(indexSnapshotMagic):])), nil
+}
+
+// loadIndexSnapshot loads the shard's series and fields from its index
+// snapshot file and builds its series filter from the series. Returns
+// errIndexSnapshotStale if the snapshot wasn't written at transaction txID.
+// The shard lock must be held.
+func (s *Shard) loadIndexSnapshot(txID int) error {
+	f, err := os.Open(s.path + IndexExt)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	// Check the header before anything is added to the index.
+	hdr := make([]byte, len(indexSnapshotMagic)+8)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return errIndexSnapshotInvalid
+	} else if !bytes.Equal(hdr[:len(indexSnapshotMagic)], indexSnapshotMagic) {
+		return errIndexSnapshotInvalid
+	} else if btou64(hdr[len(indexSnapshotMagic):]) != uint64(txID) {
+		return errIndexSnapshotStale
+	}
+
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+
+	// The fields of each series come before the series.
+	seriesFields := make(map[string][]byte)
+	var keys [][]byte
+	for {
+		typ, err := r.ReadByte()
+		if err != nil {
+			return errIndexSnapshotInvalid
+		} else if typ == indexSnapshotEnd {
+			f := newSeriesFilter(len(keys))
+			for _, k := range keys {
+				f.insert(k)
+			}
+			s.seriesFilter = f
+			return nil
+		}
+
+		k, err := readIndexSnapshotBytes(r)
+		if err != nil {
+			return err
+		}
+		v, err := readIndexSnapshotBytes(r)
+		if err != nil {
+			return err
+		}
+
+		switch typ {
+		case indexSnapshotFields:
+			err = s.indexMeasurementFields(string(k), v)
+		case indexSnapshotSeriesFields:
+			seriesFields[string(k)] = v
+		case indexSnapshotSeries:
+			err = s.indexSeries(string(k), v, seriesFields[string(k)])
+			keys = append(keys, k)
+		default:
+			err = errIndexSnapshotInvalid
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// encodeIndexSnapshot writes the fields, seriesfields and series buckets of
+// tx to w.
+func encodeIndexSnapshot(w io.Writer, tx *bolt.Tx) error {
+	if _, err := w.Write(indexSnapshotMagic); err != nil {
+		return err
+	} else if _, err := w.Write(u64tob(uint64(tx.ID()))); err != nil {
+		return err
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	writeBucket := func(typ byte, name string) error {
+		return tx.Bucket([]byte(name)).ForEach(func(k, v []byte) error {
+			if _, err := w.Write([]byte{typ}); err != nil {
+				return err
+			}
+			for _, b := range [][]byte{k, v} {
+				n := binary.PutUvarint(buf, uint64(len(b)))
+				if _, err := w.Write(buf[:n]); err != nil {
+					return err
+				} else if _, err := w.Write(b); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	// Fields are written first so they are indexed before their series.
+	if err := writeBucket(indexSnapshotFields, "fields"); err != nil {
+		return err
+	} else if err := writeBucket(indexSnapshotSeriesFields, "seriesfields"); err != nil {
+		return err
+	} else if err := writeBucket(indexSnapshotSeries, "series"); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte{indexSnapshotEnd})
+	return err
+}
+
+// readIndexSnapshotBytes reads a length-prefixed key or value from r.
+func readIndexSnapshotBytes(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > maxIndexSnapshotValueSize {
+		return nil, errIndexSnapshotInvalid
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, errIndexSnapshotInvalid
+	}
+	return b, nil
+}
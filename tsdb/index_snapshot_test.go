@@ -0,0 +1,109 @@
+package tsdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// Ensure shards are reopened from their index snapshots after a restart.
+func TestStoreIndexSnapshot_Reopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	} else if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+	pt := NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+	if err := s.WriteToShard(1, []Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	// A snapshot is only written for shards that changed since the last one.
+	if n, err := s.SnapshotIndexes(); err != nil {
+		t.Fatalf("Store.SnapshotIndexes() failed: %v", err)
+	} else if n != 1 {
+		t.Fatalf("unexpected snapshot count: %d", n)
+	}
+	if n, err := s.SnapshotIndexes(); err != nil {
+		t.Fatalf("Store.SnapshotIndexes() failed: %v", err)
+	} else if n != 0 {
+		t.Fatalf("unexpected snapshot count: %d", n)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Store.Close() failed: %v", err)
+	}
+
+	// Reopen the store and ensure the index is the same.
+	s = NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+
+	if n := len(s.shards); n != 1 {
+		t.Fatalf("unexpected shard count: %d", n)
+	} else if m := s.Measurement("mydb", "cpu"); m == nil {
+		t.Fatal("expected measurement")
+	} else if n := len(m.SeriesKeys()); n != 1 {
+		t.Fatalf("unexpected series count: %d", n)
+	} else if s.Shard(1).FieldCodec("cpu") == nil {
+		t.Fatal("expected field codec")
+	}
+}
+
+// Ensure a snapshot is only loaded if the shard hasn't changed since it was written.
+func TestShard_LoadIndexSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shard_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "1")
+
+	sh := NewShard(NewDatabaseIndex(), path)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("Shard.Open() failed: %v", err)
+	}
+	pt := NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+	if err := sh.WritePoints([]Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if _, err := sh.writeIndexSnapshot(); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	var txID int
+	if err := sh.db.View(func(tx *bolt.Tx) error {
+		txID = tx.ID()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sh.Close()
+
+	// A snapshot from another transaction is rejected before the index is changed.
+	other := NewShard(NewDatabaseIndex(), path)
+	if err := other.loadIndexSnapshot(txID + 1); err != errIndexSnapshotStale {
+		t.Fatalf("unexpected error: %v", err)
+	} else if other.index.Measurement("cpu") != nil {
+		t.Fatal("unexpected measurement")
+	}
+
+	// A current snapshot loads the shard's series and fields.
+	if err := other.loadIndexSnapshot(txID); err != nil {
+		t.Fatalf("Shard.loadIndexSnapshot() failed: %v", err)
+	} else if m := other.index.Measurement("cpu"); m == nil || len(m.SeriesKeys()) != 1 {
+		t.Fatalf("unexpected measurement: %v", m)
+	} else if other.FieldCodec("cpu") == nil {
+		t.Fatal("expected field codec")
+	}
+}
@@ -25,10 +25,14 @@ const (
 type DatabaseIndex struct {
 	// in memory metadata index, built on load and updated when new series come in
 	mu           sync.RWMutex
-	measurements map[string]*Measurement // measurement name to object and index
-	series       map[string]*Series      // map series key to the Series object
-	names        []string                // sorted list of the measurement names
-	lastID       uint64                  // last used series ID. They're in memory only for this shard
+	measurements map[string]*Measurement  // measurement name to object and index
+	series       map[string]*Series       // map series key to the Series object
+	names        []string                 // sorted list of the measurement names
+	lastID       uint64                   // last used series ID. They're in memory only for this shard
+	patterns     map[string]IngestPattern // ingest pattern of hinted measurements, set on creation
+	tags         *tagDict                 // interned tag keys and values of the series
+
+	fieldConflictMode FieldConflictMode // how fields with conflicting types are written, set on creation
 }
 
 func NewDatabaseIndex() *DatabaseIndex {
@@ -36,6 +40,7 @@ func NewDatabaseIndex() *DatabaseIndex {
 		measurements: make(map[string]*Measurement),
 		series:       make(map[string]*Series),
 		names:        make([]string, 0),
+		tags:         newTagDict(),
 	}
 }
 
@@ -55,6 +60,42 @@ func (d *DatabaseIndex) MeasurementSeriesCounts() (nMeasurements int, nSeries in
 	return
 }
 
+// SeriesCardinality returns the number of series in the database.
+func (d *DatabaseIndex) SeriesCardinality() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return int64(len(d.series))
+}
+
+// MeasurementCardinality returns the number of measurements in the database.
+func (d *DatabaseIndex) MeasurementCardinality() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return int64(len(d.measurements))
+}
+
+// TagValueCardinality returns the number of distinct values of a tag key
+// across the measurements.
+func TagValueCardinality(measurements Measurements, key string) int64 {
+	// A single measurement's values are already distinct.
+	if len(measurements) == 1 {
+		m := measurements[0]
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return int64(len(m.seriesByTagKeyValue[key]))
+	}
+
+	values := make(map[string]struct{})
+	for _, m := range measurements {
+		m.mu.RLock()
+		for v := range m.seriesByTagKeyValue[key] {
+			values[v] = struct{}{}
+		}
+		m.mu.RUnlock()
+	}
+	return int64(len(values))
+}
+
 // createSeriesIndexIfNotExists adds the series for the given measurement to the index and sets its ID or returns the existing series object
 func (s *DatabaseIndex) createSeriesIndexIfNotExists(measurementName string, series *Series) *Series {
 	// if there is a measurement for this id, it's already been added
@@ -71,6 +112,7 @@ func (s *DatabaseIndex) createSeriesIndexIfNotExists(measurementName string, ser
 	s.lastID += 1
 
 	series.measurement = m
+	series.Tags = s.tags.internTags(series.Tags)
 	s.series[series.Key] = series
 
 	m.AddSeries(series)
@@ -78,6 +120,69 @@ func (s *DatabaseIndex) createSeriesIndexIfNotExists(measurementName string, ser
 	return series
 }
 
+// limitSeries splits points into the points that can be written without the
+// index holding more than maxSeriesN series and the points that would create
+// series over the limit. Points of existing series are always kept.
+func (d *DatabaseIndex) limitSeries(points []Point, maxSeriesN int) (kept, dropped []Point) {
+	created := make(map[string]struct{})
+	for _, p := range points {
+		key := string(p.Key())
+		if d.series[key] == nil {
+			if _, ok := created[key]; !ok {
+				if len(d.series)+len(created) >= maxSeriesN {
+					dropped = append(dropped, p)
+					continue
+				}
+				created[key] = struct{}{}
+			}
+		}
+		kept = append(kept, p)
+	}
+	return kept, dropped
+}
+
+// checkValuesPerTag returns an error if creating the series would give a tag
+// key of a measurement more than maxValuesN values.
+func (d *DatabaseIndex) checkValuesPerTag(seriesToCreate []*seriesCreate, maxValuesN int) error {
+	// New values of each tag key, by measurement name and tag key.
+	created := make(map[string]map[string]map[string]struct{})
+	for _, sc := range seriesToCreate {
+		if d.series[sc.series.Key] != nil {
+			continue
+		}
+
+		keys := created[sc.measurement]
+		if keys == nil {
+			keys = make(map[string]map[string]struct{})
+			created[sc.measurement] = keys
+		}
+
+		m := d.measurements[sc.measurement]
+		for k, v := range sc.series.Tags {
+			var n int
+			if m != nil {
+				var ok bool
+				if n, ok = m.tagValueN(k, v); ok {
+					continue
+				}
+			}
+
+			values := keys[k]
+			if values == nil {
+				values = make(map[string]struct{})
+				keys[k] = values
+			}
+			values[v] = struct{}{}
+
+			if n+len(values) > maxValuesN {
+				return fmt.Errorf("%s: measurement %q, tag %q, value %q, limit %d",
+					ErrMaxValuesPerTagExceeded, sc.measurement, k, v, maxValuesN)
+			}
+		}
+	}
+	return nil
+}
+
 // createMeasurementIndexIfNotExists creates or retrieves an in memory index object for the measurement
 func (s *DatabaseIndex) createMeasurementIndexIfNotExists(name string) *Measurement {
 	m := s.measurements[name]
@@ -241,6 +346,7 @@ func (db *DatabaseIndex) DropMeasurement(name string) {
 	delete(db.measurements, name)
 	for _, s := range m.seriesByID {
 		delete(db.series, s.Key)
+		db.tags.releaseTags(s.Tags)
 	}
 
 	var names []string
@@ -252,7 +358,8 @@ func (db *DatabaseIndex) DropMeasurement(name string) {
 	db.names = names
 }
 
-// DropSeries removes the series keys and their tags from the index
+// DropSeries removes the series keys and their tags from the index. The
+// measurements of the series are kept even if they have no series left.
 func (db *DatabaseIndex) DropSeries(keys []string) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
@@ -261,6 +368,8 @@ func (db *DatabaseIndex) DropSeries(keys []string) {
 		if series == nil {
 			continue
 		}
+		delete(db.series, k)
+		db.tags.releaseTags(series.Tags)
 		series.measurement.DropSeries(series.id)
 	}
 }
@@ -280,6 +389,8 @@ type Measurement struct {
 	measurement         *Measurement
 	seriesByTagKeyValue map[string]map[string]seriesIDs // map from tag key to value to sorted set of series ids
 	seriesIDs           seriesIDs                       // sorted list of series IDs in this measurement
+	seriesByField       map[string]map[uint64]struct{}  // field name to the series that wrote it
+	fieldsKnown         map[uint64]struct{}             // series whose fields are all in seriesByField
 }
 
 // NewMeasurement allocates and initializes a new Measurement.
@@ -293,6 +404,8 @@ func NewMeasurement(name string, idx *DatabaseIndex) *Measurement {
 		seriesByID:          make(map[uint64]*Series),
 		seriesByTagKeyValue: make(map[string]map[string]seriesIDs),
 		seriesIDs:           make(seriesIDs, 0),
+		seriesByField:       make(map[string]map[uint64]struct{}),
+		fieldsKnown:         make(map[uint64]struct{}),
 	}
 }
 
@@ -330,6 +443,16 @@ func (m *Measurement) HasSeries() bool {
 	return len(m.seriesByID) > 0
 }
 
+// tagValueN returns the number of values of a tag key and whether value is
+// one of them.
+func (m *Measurement) tagValueN(key, value string) (n int, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	values := m.seriesByTagKeyValue[key]
+	_, ok = values[value]
+	return len(values), ok
+}
+
 // AddSeries will add a series to the measurementIndex. Returns false if already present
 func (m *Measurement) AddSeries(s *Series) bool {
 	m.mu.Lock()
@@ -383,6 +506,7 @@ func (m *Measurement) DropSeries(seriesID uint64) {
 
 	delete(m.series, tagset)
 	delete(m.seriesByID, seriesID)
+	m.dropSeriesFields(seriesID)
 
 	var ids []uint64
 	for _, id := range m.seriesIDs {
@@ -433,6 +557,11 @@ func (m *Measurement) filters(stmt *influxql.SelectStatement) (map[uint64]influx
 		return seriesIdsToExpr, nil
 	}
 
+	// Match the tags of every series if the query bypasses the tag index.
+	if stmt.Hints.NoIndex {
+		return m.scanFilters(stmt.Condition), nil
+	}
+
 	ids, seriesIdsToExpr, err := m.walkWhereForSeriesIds(stmt.Condition)
 	if err != nil {
 		return nil, err
@@ -449,6 +578,82 @@ func (m *Measurement) filters(stmt *influxql.SelectStatement) (map[uint64]influx
 	return seriesIdsToExpr, nil
 }
 
+// scanFilters evaluates the tag comparisons in expr against the tags of every
+// series in the measurement. It returns the same series and filters as
+// walkWhereForSeriesIds without using the tag index.
+func (m *Measurement) scanFilters(expr influxql.Expr) map[uint64]influxql.Expr {
+	filters := make(map[uint64]influxql.Expr)
+	for _, id := range m.seriesIDs {
+		tags := m.seriesByID[id].Tags
+
+		// Replace tag and time comparisons with their result for the series
+		// and keep the field comparisons as the series filter.
+		filter := influxql.RewriteFunc(influxql.CloneExpr(expr), func(n influxql.Node) influxql.Node {
+			if n, ok := n.(*influxql.BinaryExpr); ok {
+				if match, ok := m.matchTagExpr(n, tags); ok {
+					return &influxql.BooleanLiteral{Val: match}
+				}
+			}
+			return n
+		}).(influxql.Expr)
+		filter = influxql.Reduce(filter, nil)
+
+		if b, ok := filter.(*influxql.BooleanLiteral); ok && !b.Val {
+			continue
+		} else if ok && b.Val {
+			filter = nil
+		}
+		filters[id] = filter
+	}
+	return filters
+}
+
+// matchTagExpr returns whether a series with tags matches a tag or time
+// comparison. Returns false for ok if n doesn't compare a tag or time.
+func (m *Measurement) matchTagExpr(n *influxql.BinaryExpr, tags map[string]string) (match, ok bool) {
+	switch n.Op {
+	case influxql.EQ, influxql.NEQ, influxql.LT, influxql.LTE, influxql.GT, influxql.GTE, influxql.EQREGEX, influxql.NEQREGEX:
+	default:
+		return false, false
+	}
+
+	name, ok := n.LHS.(*influxql.VarRef)
+	value := n.RHS
+	if !ok {
+		if name, ok = n.RHS.(*influxql.VarRef); !ok {
+			return false, false
+		}
+		value = n.LHS
+	}
+
+	// Time is filtered by the mappers and fields by the series filter.
+	if _, ok := value.(*influxql.TimeLiteral); ok || name.Val == "time" {
+		return true, true
+	} else if m.HasField(name.Val) {
+		return false, false
+	} else if _, ok := m.seriesByTagKeyValue[name.Val]; !ok {
+		return false, true
+	}
+
+	// Series without the tag only match negated comparisons, as in the index.
+	v, exists := tags[name.Val]
+	switch value := value.(type) {
+	case *influxql.StringLiteral:
+		if n.Op == influxql.EQ {
+			return exists && v == value.Val, true
+		} else if n.Op == influxql.NEQ {
+			return !exists || v != value.Val, true
+		}
+	case *influxql.RegexLiteral:
+		if n.Op == influxql.EQREGEX {
+			return exists && value.Val.MatchString(v), true
+		} else if n.Op == influxql.NEQREGEX {
+			return !exists || !value.Val.MatchString(v), true
+		}
+	}
+	return false, true
+}
+
 // tagSets returns the unique tag sets that exist for the given tag keys. This is used to determine
 // what composite series will be created by a group by. i.e. "group by region" should return:
 // {"region":"uswest"}, {"region":"useast"}
@@ -515,44 +720,30 @@ func mergeSeriesFilters(op influxql.Token, ids seriesIDs, lfilters, rfilters map
 	// Resulting list of series IDs
 	var series seriesIDs
 
-	// Combining logic:
+	// Combining logic, where a series missing from one side didn't match it
+	// and a nil filter matched without needing a filter:
 	// +==========+==========+==========+=======================+=======================+
 	// | operator |   LHS    |   RHS    |   intermediate expr   |     reduced filter    |
 	// +==========+==========+==========+=======================+=======================+
-	// |          | <nil>    | <r-expr> | true OR <r-expr>      | true                  |
+	// |          | missing  | <r-expr> | false OR <r-expr>     | <r-expr>              |
 	// |          |----------+----------+-----------------------+-----------------------+
-	// | OR       | <l-expr> | <nil>    | <l-expr> OR true      | true                  |
+	// | OR       | <l-expr> | missing  | <l-expr> OR false     | <l-expr>              |
 	// |          |----------+----------+-----------------------+-----------------------+
-	// |          | <nil>    | <nil>    | true OR true          | true                  |
+	// |          | <nil>    | <r-expr> | true OR <r-expr>      | true                  |
 	// |          |----------+----------+-----------------------+-----------------------+
 	// |          | <l-expr> | <r-expr> | <l-expr> OR <r-expr>  | <l-expr> OR <r-expr>  |
 	// +----------+----------+----------+-----------------------+-----------------------+
-	// |          | <nil>    | <r-expr> | false AND <r-expr>    | false*                |
-	// |          |----------+----------+-----------------------+-----------------------+
-	// | AND      | <l-expr> | <nil>    | <l-expr> AND false    | false                 |
+	// |          | missing  | <r-expr> | false AND <r-expr>    | false*                |
 	// |          |----------+----------+-----------------------+-----------------------+
-	// |          | <nil>    | <nil>    | false AND false       | false                 |
+	// | AND      | <nil>    | <r-expr> | true AND <r-expr>     | <r-expr>              |
 	// |          |----------+----------+-----------------------+-----------------------+
 	// |          | <l-expr> | <r-expr> | <l-expr> AND <r-expr> | <l-expr> AND <r-expr> |
 	// +----------+----------+----------+-----------------------+-----------------------+
 	// *literal false filters and series IDs should be excluded from the results
 
-	def := false
-	if op == influxql.OR {
-		def = true
-	}
-
 	for _, id := range ids {
-		// Get LHS and RHS filter expressions for this series ID.
-		lfilter, rfilter := lfilters[id], rfilters[id]
-
-		// Set default filters if either LHS or RHS expressions were nil.
-		if lfilter == nil {
-			lfilter = &influxql.BooleanLiteral{Val: def}
-		}
-		if rfilter == nil {
-			rfilter = &influxql.BooleanLiteral{Val: def}
-		}
+		lfilter := seriesFilter(lfilters, id)
+		rfilter := seriesFilter(rfilters, id)
 
 		// Create the intermediate filter expression for this series ID.
 		be := &influxql.BinaryExpr{
@@ -576,6 +767,19 @@ func mergeSeriesFilters(op influxql.Token, ids seriesIDs, lfilters, rfilters map
 	return series, filters
 }
 
+// seriesFilter returns the filter for a series from one side of a combined
+// expression: false if the series didn't match it, true if it matched
+// without a filter.
+func seriesFilter(filters map[uint64]influxql.Expr, id uint64) influxql.Expr {
+	filter, ok := filters[id]
+	if !ok {
+		return &influxql.BooleanLiteral{Val: false}
+	} else if filter == nil {
+		return &influxql.BooleanLiteral{Val: true}
+	}
+	return filter
+}
+
 // idsForExpr will return a collection of series ids and a filter expression that should
 // be used to filter points from those series.
 func (m *Measurement) idsForExpr(n *influxql.BinaryExpr) (seriesIDs, influxql.Expr, error) {
@@ -643,6 +847,38 @@ func (m *Measurement) idsForExpr(n *influxql.BinaryExpr) (seriesIDs, influxql.Ex
 	return nil, nil, nil
 }
 
+// seriesKeysWhere returns the keys of the series matching a condition on tags.
+// It returns an error if the condition refers to a field.
+func (m *Measurement) seriesKeysWhere(condition influxql.Expr) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := m.seriesIDs
+	if condition != nil {
+		var err error
+		influxql.WalkFunc(condition, func(n influxql.Node) {
+			if ref, ok := n.(*influxql.VarRef); ok {
+				if _, ok := m.fieldNames[ref.Val]; ok {
+					err = fmt.Errorf("fields not supported in WHERE clause during deletion: %s", ref.Val)
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if ids, _, err = m.walkWhereForSeriesIds(condition); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, m.seriesByID[id].Key)
+	}
+	return keys, nil
+}
+
 // walkWhereForSeriesIds recursively walks the WHERE clause and returns an ordered set of series IDs and
 // a map from those series IDs to filter expressions that should be used to limit points returned in
 // the final query result.
@@ -692,7 +928,11 @@ func (m *Measurement) walkWhereForSeriesIds(expr influxql.Expr) (seriesIDs, map[
 		}
 
 		ids, _, err := m.idsForExpr(n)
-		return ids, nil, err
+		filters := map[uint64]influxql.Expr{}
+		for _, id := range ids {
+			filters[id] = nil
+		}
+		return ids, filters, err
 	case *influxql.ParenExpr:
 		// walk down the tree
 		return m.walkWhereForSeriesIds(n.Expr)
@@ -870,6 +1110,20 @@ func (a Measurements) Len() int           { return len(a) }
 func (a Measurements) Less(i, j int) bool { return a[i].Name < a[j].Name }
 func (a Measurements) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
+// sortMeasurements sorts a by name using collation c.
+func sortMeasurements(a Measurements, c influxql.Collation) {
+	sort.Sort(collatedMeasurements{a: a, c: c})
+}
+
+type collatedMeasurements struct {
+	a Measurements
+	c influxql.Collation
+}
+
+func (s collatedMeasurements) Len() int           { return len(s.a) }
+func (s collatedMeasurements) Less(i, j int) bool { return s.c.Less(s.a[i].Name, s.a[j].Name) }
+func (s collatedMeasurements) Swap(i, j int)      { s.a[i], s.a[j] = s.a[j], s.a[i] }
+
 func (a Measurements) intersect(other Measurements) Measurements {
 	l := a
 	r := other
@@ -1237,6 +1491,11 @@ func (s stringSet) intersect(o stringSet) stringSet {
 	return ns
 }
 
+// newSeriesFromKey returns a series with its tags parsed from its key.
+func newSeriesFromKey(key []byte) *Series {
+	return &Series{Key: string(key), Tags: (&point{key: key}).Tags()}
+}
+
 func measurementFromSeriesKey(key string) string {
 	idx := strings.Index(key, ",")
 	if idx == -1 {
@@ -0,0 +1,408 @@
+package tsdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/influxql"
+)
+
+var (
+	// ErrTagExists is returned when converting a field into a tag that a
+	// series of the measurement already has.
+	ErrTagExists = errors.New("tag already exists")
+
+	// ErrLastField is returned when converting the only field of a measurement into a tag.
+	ErrLastField = errors.New("cannot convert the only field of a measurement")
+
+	// ErrShardArchived is returned when migrating a shard whose data has been archived.
+	ErrShardArchived = errors.New("shard is archived")
+)
+
+// MigrationType is the kind of schema change made by a Migration.
+type MigrationType int
+
+const (
+	// TagToField moves the value of a tag into a string field of the same name.
+	TagToField MigrationType = iota + 1
+
+	// FieldToTag moves the value of a field into a tag of the same name.
+	FieldToTag
+)
+
+// String returns the string representation of the migration type.
+func (t MigrationType) String() string {
+	switch t {
+	case TagToField:
+		return "tag-to-field"
+	case FieldToTag:
+		return "field-to-tag"
+	}
+	return fmt.Sprintf("MigrationType(%d)", int(t))
+}
+
+// Migration converts a tag of a measurement into a field, or a field into a tag.
+type Migration struct {
+	Type        MigrationType
+	Measurement string
+	Key         string // name of the tag or field being converted
+
+	// Progress, if set, is called after each series of the measurement is
+	// rewritten with the number of series done and the total.
+	Progress func(done, total int)
+}
+
+// MigrateShardFile runs a migration against the shard data file at path.
+// The shard must not be open by a running server. Returns the number of
+// points rewritten.
+func MigrateShardFile(path string, m *Migration) (int, error) {
+	sh := NewShard(NewDatabaseIndex(), path)
+	if err := sh.Open(); err != nil {
+		return 0, err
+	}
+	defer sh.Close()
+	return sh.Migrate(m)
+}
+
+// MigrateShard runs a migration against an open shard. Writes to the shard
+// block until the migration completes. Returns the number of points rewritten.
+func (s *Store) MigrateShard(shardID uint64, m *Migration) (int, error) {
+	sh := s.Shard(shardID)
+	if sh == nil {
+		return 0, ErrShardNotFound
+	} else if sh.ArchiveKey() != "" {
+		return 0, ErrShardArchived
+	} else if err := sh.openDeferred(); err != nil {
+		return 0, err
+	}
+	return sh.Migrate(m)
+}
+
+// Migrate rewrites every series of the migration's measurement in a single
+// transaction. Series left empty by the migration are removed from the shard
+// but remain in the database index as other shards may still hold them.
+// Returns the number of points rewritten.
+func (s *Shard) Migrate(m *Migration) (int, error) {
+	if m.Type != TagToField && m.Type != FieldToTag {
+		return 0, fmt.Errorf("invalid migration type: %d", m.Type)
+	}
+
+	var n int
+	var mf, other *measurementFields
+	var created []*Series
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		// Determine the fields after the migration. They're copied under the
+		// shard lock within the transaction so fields created by writes
+		// committed before it are kept, and swapped in so writes committed
+		// after it save the migrated fields. Existing field ids never change
+		// so points encoded by racing writes stay readable. A field
+		// converted to a tag keeps its id but no longer has any data.
+		s.mu.Lock()
+		if mf = s.measurementFields[m.Measurement]; mf == nil {
+			// Nothing to do if the measurement was never written to this shard.
+			s.mu.Unlock()
+			return nil
+		}
+		var err error
+		if other, err = mf.migrate(m); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.measurementFields[m.Measurement] = other
+		buf, err := other.MarshalBinary()
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		mig := &shardMigration{
+			Migration: m,
+			tx:        tx,
+			codec:     mf.codec,
+			other:     other.codec,
+			created:   make(map[string]*Series),
+		}
+		if err := mig.run(); err != nil {
+			return err
+		}
+		n, created = mig.n, mig.createdSeries()
+
+		// Save the new fields of the measurement.
+		return tx.Bucket([]byte("fields")).Put([]byte(m.Measurement), buf)
+	}); err != nil {
+		if other != nil {
+			s.revertMigratedFields(m, mf, other)
+		}
+		return 0, err
+	} else if mf == nil {
+		return 0, nil
+	}
+
+	// Cached points of the migrated series have been rewritten.
+	if s.hotCache != nil {
+		s.hotCache.reset()
+	}
+
+	// Add the new series and fields to the index.
+	s.mu.RLock()
+	names := make([]string, 0, len(other.Fields))
+	for name := range other.Fields {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+
+	s.index.mu.Lock()
+	for _, ss := range created {
+		s.index.createSeriesIndexIfNotExists(m.Measurement, ss)
+	}
+	mm := s.index.createMeasurementIndexIfNotExists(m.Measurement)
+	for _, name := range names {
+		mm.fieldNames[name] = struct{}{}
+	}
+	mm.resetSeriesFields()
+	s.index.mu.Unlock()
+
+	// Rebuild the series filter as points may have moved to new series.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadSeriesFilter(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// migrate returns a copy of the fields after a migration.
+func (mf *measurementFields) migrate(m *Migration) (*measurementFields, error) {
+	other := &measurementFields{Fields: make(map[string]*field)}
+	for name, f := range mf.Fields {
+		other.Fields[name] = &field{ID: f.ID, Name: f.Name, Type: f.Type}
+	}
+	other.codec = newFieldCodec(other.Fields)
+
+	switch m.Type {
+	case TagToField:
+		if err := other.createFieldIfNotExists(m.Key, influxql.String); err != nil {
+			return nil, err
+		}
+	case FieldToTag:
+		if other.Fields[m.Key] == nil {
+			return nil, ErrFieldNotFound
+		} else if len(other.Fields) == 1 {
+			return nil, ErrLastField
+		}
+	}
+	return other, nil
+}
+
+// revertMigratedFields restores the fields of a measurement after a failed
+// migration, keeping any fields created by writes since the migration began.
+func (s *Shard) revertMigratedFields(m *Migration, mf, other *measurementFields) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.measurementFields[m.Measurement] != other {
+		return
+	}
+	for name, f := range other.Fields {
+		if mf.Fields[name] == nil && !(m.Type == TagToField && name == m.Key) {
+			mf.Fields[name] = f
+		}
+	}
+	mf.codec = newFieldCodec(mf.Fields)
+	s.measurementFields[m.Measurement] = mf
+}
+
+// shardMigration holds the state of a migration within a shard's transaction.
+type shardMigration struct {
+	*Migration
+	tx *bolt.Tx
+
+	codec *FieldCodec // codec of the existing data
+	other *FieldCodec // codec of the migrated data
+
+	n       int                // points rewritten
+	created map[string]*Series // series created by the migration
+}
+
+// run rewrites each series of the measurement.
+func (m *shardMigration) run() error {
+	// Find the series of the measurement.
+	var a []*Series
+	name := string(escape([]byte(m.Measurement)))
+	c := m.tx.Bucket([]byte("series")).Cursor()
+	for k, v := c.Seek([]byte(name)); k != nil && bytes.HasPrefix(k, []byte(name)); k, v = c.Next() {
+		if measurementFromSeriesKey(string(k)) != name {
+			continue
+		}
+
+		ss := &Series{}
+		if err := ss.UnmarshalBinary(v); err != nil {
+			return err
+		}
+
+		// A field can't be converted to a tag that already exists.
+		if _, ok := ss.Tags[m.Key]; ok && m.Type == FieldToTag {
+			return ErrTagExists
+		}
+		a = append(a, ss)
+	}
+
+	// The fields of the series change, so they're no longer known.
+	sf := m.tx.Bucket([]byte("seriesfields"))
+	for _, ss := range a {
+		if err := sf.Delete([]byte(ss.Key)); err != nil {
+			return err
+		}
+	}
+
+	for i, ss := range a {
+		if err := m.rewriteSeries(ss); err != nil {
+			return fmt.Errorf("rewrite series %s: %s", ss.Key, err)
+		}
+		if m.Progress != nil {
+			m.Progress(i+1, len(a))
+		}
+	}
+	return nil
+}
+
+// rewriteSeries moves the points of a series into the series they belong to
+// after the migration.
+func (m *shardMigration) rewriteSeries(ss *Series) error {
+	// Only series with the tag change when converting a tag to a field.
+	value, ok := ss.Tags[m.Key]
+	if m.Type == TagToField && !ok {
+		return nil
+	}
+
+	// Copy the series' points and remove it. The points are added back to
+	// the new series below, which can be the same series.
+	type entry struct{ k, v []byte }
+	var entries []entry
+	if b := m.tx.Bucket([]byte(ss.Key)); b != nil {
+		if err := b.ForEach(func(k, v []byte) error {
+			entries = append(entries, entry{
+				k: append([]byte(nil), k...),
+				v: append([]byte(nil), v...),
+			})
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := m.tx.DeleteBucket([]byte(ss.Key)); err != nil {
+			return err
+		}
+	}
+	if err := m.tx.Bucket([]byte("series")).Delete([]byte(ss.Key)); err != nil {
+		return err
+	}
+	delete(m.created, ss.Key)
+
+	for _, e := range entries {
+		fields, err := m.codec.DecodeFieldsWithNames(e.v)
+		if err != nil {
+			return err
+		}
+
+		// Move the value between the tags and fields.
+		tags := make(map[string]string, len(ss.Tags)+1)
+		for k, v := range ss.Tags {
+			tags[k] = v
+		}
+		switch m.Type {
+		case TagToField:
+			delete(tags, m.Key)
+			fields[m.Key] = value
+		case FieldToTag:
+			if v, ok := fields[m.Key]; ok {
+				delete(fields, m.Key)
+				if s := formatTagValue(v); s != "" {
+					tags[m.Key] = s
+				}
+			}
+		}
+
+		// Points that only had the converted field are dropped.
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := m.put(tags, e.k, fields); err != nil {
+			return err
+		}
+		m.n++
+	}
+	return nil
+}
+
+// put writes the fields of a point to a series, creating the series if it
+// doesn't exist. A point already in the series at the same time is merged.
+func (m *shardMigration) put(tags map[string]string, timestamp []byte, fields map[string]interface{}) error {
+	key := string(makeKey([]byte(m.Measurement), Tags(tags)))
+
+	// Create the series if necessary.
+	series := m.tx.Bucket([]byte("series"))
+	if series.Get([]byte(key)) == nil {
+		ss := &Series{Key: key, Tags: tags}
+		buf, err := ss.MarshalBinary()
+		if err != nil {
+			return err
+		} else if err := series.Put([]byte(key), buf); err != nil {
+			return err
+		}
+		m.created[key] = ss
+	}
+
+	b, err := m.tx.CreateBucketIfNotExists([]byte(key))
+	if err != nil {
+		return err
+	}
+
+	// Merge with a point already at the same time. The new codec can decode
+	// both the existing and the migrated data as field ids don't change.
+	if v := b.Get(timestamp); v != nil {
+		existing, err := m.other.DecodeFieldsWithNames(v)
+		if err != nil {
+			return err
+		}
+		for k, v := range existing {
+			if _, ok := fields[k]; !ok {
+				fields[k] = v
+			}
+		}
+	}
+
+	buf, err := m.other.EncodeFields(fields)
+	if err != nil {
+		return err
+	}
+	return b.Put(timestamp, buf)
+}
+
+// createdSeries returns the series created by the migration that still exist.
+func (m *shardMigration) createdSeries() []*Series {
+	a := make([]*Series, 0, len(m.created))
+	for _, ss := range m.created {
+		a = append(a, ss)
+	}
+	return a
+}
+
+// formatTagValue returns the tag value of a decoded field value.
+func formatTagValue(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,101 @@
+package tsdb
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// PointReader parses line protocol points from a reader one line at a time
+// so a large payload never has to be held in memory. Blank lines and lines
+// starting with # are skipped.
+type PointReader struct {
+	r         *bufio.Reader
+	precision string
+	line      int
+}
+
+// NewPointReader returns a reader of the points in r. Timestamps are in the
+// given precision; points without one are given the time they're read at.
+func NewPointReader(r io.Reader, precision string) *PointReader {
+	if precision == "" {
+		precision = "n"
+	}
+	return &PointReader{r: bufio.NewReader(r), precision: precision}
+}
+
+// Next returns the next point. Returns io.EOF once every point has been
+// read. Parse errors include the line the point was on.
+func (r *PointReader) Next() (Point, error) {
+	for {
+		buf, err := r.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		} else if err == io.EOF && len(buf) == 0 {
+			return nil, io.EOF
+		}
+		r.line++
+
+		b := bytes.TrimSpace(buf)
+		if len(b) == 0 || b[0] == '#' {
+			continue
+		}
+
+		p, err := parsePoint(b, time.Now().UTC(), r.precision)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: unable to parse '%s': %v", r.line, b, err)
+		}
+		return p, nil
+	}
+}
+
+// ReadBatch returns up to n points. Returns io.EOF once every point has
+// been read.
+func (r *PointReader) ReadBatch(n int) ([]Point, error) {
+	points := make([]Point, 0, n)
+	for len(points) < n {
+		p, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	if len(points) == 0 {
+		return nil, io.EOF
+	}
+	return points, nil
+}
+
+// WriteToShardFrom writes the line protocol points read from r to a shard,
+// batchSize points at a time, so the whole payload isn't parsed up front.
+// Each batch is written like WriteToShard. If a batch fails the earlier
+// batches have already been written. Returns the number of points written.
+func (s *Store) WriteToShardFrom(shardID uint64, r io.Reader, precision string, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+	if s.MaxPointsPerBatch > 0 && batchSize > s.MaxPointsPerBatch {
+		batchSize = s.MaxPointsPerBatch
+	}
+
+	var n int
+	pr := NewPointReader(r, precision)
+	for {
+		points, err := pr.ReadBatch(batchSize)
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+
+		if err := s.WriteToShard(shardID, points); err != nil {
+			return n, err
+		}
+		n += len(points)
+	}
+}
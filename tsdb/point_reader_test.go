@@ -0,0 +1,78 @@
+package tsdb
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Ensure points are read one line at a time, skipping blank lines and comments.
+func TestPointReader(t *testing.T) {
+	r := NewPointReader(strings.NewReader("# comment\ncpu value=1 1\n\ncpu value=2 2\ncpu value=3 3"), "s")
+
+	points, err := r.ReadBatch(2)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(points) != 2 || !points[1].Time().Equal(time.Unix(2, 0)) {
+		t.Fatalf("unexpected points: %v", points)
+	}
+
+	if p, err := r.Next(); err != nil {
+		t.Fatal(err)
+	} else if !p.Time().Equal(time.Unix(3, 0)) {
+		t.Fatalf("unexpected point: %v", p)
+	} else if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := r.ReadBatch(2); err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Parse errors report the line the point was on.
+	r = NewPointReader(strings.NewReader("cpu value=1\n\ncpu value=\n"), "")
+	if _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	} else if _, err := r.Next(); err == nil || !strings.HasPrefix(err.Error(), "line 3: ") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure points are written from a reader in batches with the store's limits.
+func TestStoreWriteToShardFrom(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	s.MaxValuesPerTag = 2
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+
+	n, err := s.WriteToShardFrom(1, strings.NewReader("cpu,host=a value=1 1\ncpu,host=b value=2 2\ncpu,host=a value=3 3\n"), "n", 2)
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Fatalf("unexpected points written: %d", n)
+	}
+
+	// Batches before the one over the limit are written.
+	n, err = s.WriteToShardFrom(1, strings.NewReader("cpu,host=a value=4 4\ncpu,host=c value=5 5\n"), "n", 1)
+	if err == nil || !strings.HasPrefix(err.Error(), ErrMaxValuesPerTagExceeded.Error()) {
+		t.Fatalf("unexpected error: %v", err)
+	} else if n != 1 {
+		t.Fatalf("unexpected points written: %d", n)
+	}
+
+	if _, err := s.WriteToShardFrom(2, strings.NewReader("cpu value=1\n"), "n", 0); err != ErrShardNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -55,6 +55,28 @@ type point struct {
 	data []byte
 }
 
+// MaxSeriesKeyLength is the longest series key, in bytes. Series keys name
+// buckets in a shard's data file so they must fit in a bolt key.
+const MaxSeriesKeyLength = 32768
+
+// SeriesKeyLimits limit the keys of new series. Lengths are of the escaped
+// key. A zero limit is unlimited, although keys are never longer than
+// MaxSeriesKeyLength.
+type SeriesKeyLimits struct {
+	MaxKeyLength      int
+	MaxTags           int
+	MaxTagKeyLength   int
+	MaxTagValueLength int
+}
+
+// DefaultSeriesKeyLimits are the limits of series keys unless configured.
+var DefaultSeriesKeyLimits = SeriesKeyLimits{
+	MaxKeyLength:      DefaultMaxSeriesKeyLength,
+	MaxTags:           DefaultMaxTagsPerSeries,
+	MaxTagKeyLength:   DefaultMaxTagKeyLength,
+	MaxTagValueLength: DefaultMaxTagValueLength,
+}
+
 var escapeCodes = map[byte][]byte{
 	',': []byte(`\,`),
 	'"': []byte(`\"`),
@@ -70,6 +92,63 @@ func init() {
 	}
 }
 
+// Points is a batch of points.
+type Points []Point
+
+// Split divides the batch into batches of at most maxSize bytes. The points
+// are split in time order and a new batch is started at each multiple of
+// shardGroupDuration, the start of a shard group, so each batch is written
+// to a single shard group. Points with the same time keep their order so a
+// point still replaces an earlier one when the batches are written in order.
+// A point larger than maxSize is in a batch of its own. If maxSize is zero
+// the batch isn't split, and if shardGroupDuration is zero batches are split
+// by size only.
+func (a Points) Split(maxSize int, shardGroupDuration time.Duration) []Points {
+	if maxSize <= 0 || writeSize(a) <= maxSize {
+		return []Points{a}
+	}
+
+	other := make(Points, len(a))
+	copy(other, a)
+	sort.Stable(pointsByTime(other))
+
+	var batches []Points
+	var start, size int
+	for i, p := range other {
+		n := pointSize(p)
+		if i > start && (size+n > maxSize || !sameShardGroup(other[start], p, shardGroupDuration)) {
+			batches = append(batches, other[start:i])
+			start, size = i, 0
+		}
+		size += n
+	}
+	return append(batches, other[start:])
+}
+
+// sameShardGroup returns true if a and b fall in the same shard group of a
+// retention policy with shard group duration d.
+func sameShardGroup(a, b Point, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	return a.Time().Truncate(d).Equal(b.Time().Truncate(d))
+}
+
+// pointsByTime sorts points by their time.
+type pointsByTime Points
+
+func (a pointsByTime) Len() int           { return len(a) }
+func (a pointsByTime) Less(i, j int) bool { return a[i].UnixNano() < a[j].UnixNano() }
+func (a pointsByTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// pointSize returns the approximate size of a point in bytes.
+func pointSize(p Point) int {
+	if pt, ok := p.(*point); ok {
+		return len(pt.key) + len(pt.fields) + 8
+	}
+	return len(p.String())
+}
+
 func ParsePointsString(buf string) ([]Point, error) {
 	return ParsePoints([]byte(buf))
 }
@@ -81,6 +160,13 @@ func ParsePoints(buf []byte) ([]Point, error) {
 }
 
 func ParsePointsWithPrecision(buf []byte, defaultTime time.Time, precision string) ([]Point, error) {
+	return ParsePointsWithLimit(buf, defaultTime, precision, 0)
+}
+
+// ParsePointsWithLimit is like ParsePointsWithPrecision but stops with
+// ErrMaxPointsPerBatchExceeded once buf has more than max points, before
+// the rest are parsed. A max of zero is unlimited.
+func ParsePointsWithLimit(buf []byte, defaultTime time.Time, precision string, max int) ([]Point, error) {
 	points := []Point{}
 	var (
 		pos   int
@@ -94,6 +180,10 @@ func ParsePointsWithPrecision(buf []byte, defaultTime time.Time, precision strin
 			break
 		}
 
+		if max > 0 && len(points) == max {
+			return nil, fmt.Errorf("%s: more than %d points", ErrMaxPointsPerBatchExceeded, max)
+		}
+
 		pt, err := parsePoint(block, defaultTime, precision)
 		if err != nil {
 			return nil, fmt.Errorf("unable to parse '%s': %v", string(block), err)
@@ -121,6 +211,12 @@ func parsePoint(buf []byte, defaultTime time.Time, precision string) (Point, err
 		return nil, fmt.Errorf("missing measurement")
 	}
 
+	// Only keys that can't be stored are rejected here. The configured
+	// limits are checked when a series is created.
+	if err := validateKey(key, SeriesKeyLimits{}); err != nil {
+		return nil, err
+	}
+
 	// scan the second block is which is field1=value1[,field2=value2,...]
 	pos, fields, err := scanFields(buf, pos)
 	if err != nil {
@@ -158,6 +254,44 @@ func parsePoint(buf []byte, defaultTime time.Time, precision string) (Point, err
 	return pt, nil
 }
 
+// validateKey returns an error if a series key or its tags are longer than
+// the limits allow or it has too many tags.
+func validateKey(key []byte, l SeriesKeyLimits) error {
+	maxKeyLength := MaxSeriesKeyLength
+	if l.MaxKeyLength > 0 && l.MaxKeyLength < maxKeyLength {
+		maxKeyLength = l.MaxKeyLength
+	}
+	if len(key) > maxKeyLength {
+		return fmt.Errorf("%s: key is %d bytes, limit %d", ErrInvalidSeriesKey, len(key), maxKeyLength)
+	}
+
+	// Only scan the tags if they're limited.
+	if l.MaxTags == 0 && l.MaxTagKeyLength == 0 && l.MaxTagValueLength == 0 {
+		return nil
+	}
+
+	pos, name := scanTo(key, 0, ',')
+	if len(name) == 0 {
+		return nil
+	}
+
+	var n int
+	for i := pos + 1; i < len(key); i++ {
+		var k, v []byte
+		i, k = scanTo(key, i, '=')
+		i, v = scanTagValue(key, i+1)
+
+		if n++; l.MaxTags > 0 && n > l.MaxTags {
+			return fmt.Errorf("%s: more than %d tags", ErrInvalidSeriesKey, l.MaxTags)
+		} else if l.MaxTagKeyLength > 0 && len(k) > l.MaxTagKeyLength {
+			return fmt.Errorf("%s: tag key is %d bytes, limit %d", ErrInvalidSeriesKey, len(k), l.MaxTagKeyLength)
+		} else if l.MaxTagValueLength > 0 && len(v) > l.MaxTagValueLength {
+			return fmt.Errorf("%s: value of tag %s is %d bytes, limit %d", ErrInvalidSeriesKey, unescape(k), len(v), l.MaxTagValueLength)
+		}
+	}
+	return nil
+}
+
 // scanKey scans buf starting at i for the measurement and tag portion of the point.
 // It returns the ending position and the byte slice of key within buf.  If there
 // are tags, they will be sorted if they are not already.
@@ -176,7 +310,7 @@ func scanKey(buf []byte, i int) (int, []byte, error) {
 	indices := make([]int, 100)
 
 	// tracks how many commas we've seen so we know how many values are indices.
-	// Since indices is an arbitraily large slice,
+	// Since indices is grown ahead of use,
 	// we need to know how many values in the buffer are in use.
 	separators := 0
 
@@ -212,6 +346,10 @@ func scanKey(buf []byte, i int) (int, []byte, error) {
 				return i, buf[start:i], fmt.Errorf("missing value")
 			}
 			i += 1
+			// Grow indices, leaving room for the end of the last tag.
+			if separators+1 >= len(indices) {
+				indices = append(indices, make([]int, len(indices))...)
+			}
 			indices[separators] = i
 			separators += 1
 			hasSeparator = false
@@ -300,6 +438,10 @@ func scanFields(buf []byte, i int) (int, []byte, error) {
 	start := skipWhitespace(buf, i)
 	i = start
 	quoted := false
+
+	// tracks how many '=' and ',' we've seen outside quotes, as each field
+	// must have a value
+	var equals, commas int
 	for {
 		// reached the end of buf?
 		if i >= len(buf) {
@@ -319,8 +461,14 @@ func scanFields(buf []byte, i int) (int, []byte, error) {
 			continue
 		}
 
+		if buf[i] == ',' && !quoted {
+			commas++
+		}
+
 		// If we see an =, ensure that there is at least on char after it
 		if buf[i] == '=' && !quoted {
+			equals++
+
 			// check for "... value="
 			if i+1 >= len(buf) {
 				return i, buf[start:i], fmt.Errorf("missing field value")
@@ -360,6 +508,13 @@ func scanFields(buf []byte, i int) (int, []byte, error) {
 	if quoted {
 		return i, buf[start:i], fmt.Errorf("unbalanced quotes")
 	}
+	// a trailing comma doesn't start another field
+	if i > start && buf[i-1] == ',' {
+		commas--
+	}
+	if i > start && equals != commas+1 {
+		return i, buf[start:i], fmt.Errorf("invalid field format")
+	}
 	return i, buf[start:i], nil
 }
 
@@ -376,8 +531,8 @@ func scanTime(buf []byte, i int) (int, []byte, error) {
 		}
 
 		// Timestamps should integers, make sure they are so we don't need to actually
-		// parse the timestamp until needed
-		if buf[i] < '0' || buf[i] > '9' {
+		// parse the timestamp until needed. Times before the epoch are negative.
+		if (buf[i] < '0' || buf[i] > '9') && (buf[i] != '-' || i != start) {
 			return i, buf[start:i], fmt.Errorf("bad timestamp")
 		}
 
@@ -395,7 +550,7 @@ func isNumeric(b byte) bool {
 }
 
 // scanNumber returns the end position within buf, start at i after
-// scanning over buf for an integer, or float.  It returns an
+// scanning over buf for an integer, unsigned integer, or float.  It returns an
 // error if a invalid number is scanned.
 func scanNumber(buf []byte, i int) (int, []byte, error) {
 	start := i
@@ -425,6 +580,15 @@ func scanNumber(buf []byte, i int) (int, []byte, error) {
 			return i, buf[start:i], fmt.Errorf("invalid number")
 		}
 
+		// `u` ends an unsigned integer and must be the last char
+		if i > start && buf[i] == 'u' && (i+1 >= len(buf) || buf[i+1] == ',' || buf[i+1] == ' ') {
+			if _, err := strconv.ParseUint(string(buf[start:i]), 10, 64); err != nil {
+				return i, buf[start:i], fmt.Errorf("invalid unsigned integer")
+			}
+			i += 1
+			continue
+		}
+
 		// `e` is valid for floats but not as the first char
 		if i > start && (buf[i] == 'e') {
 			i += 1
@@ -744,19 +908,71 @@ func (p *point) AddField(name string, value interface{}) {
 
 // SetPrecision will round a time to the specified precision
 func (p *point) SetPrecision(precision string) {
+	if t := TruncateTime(p.Time(), precision); !t.Equal(p.Time()) {
+		p.SetTime(t)
+	}
+}
+
+// ValidatePrecision returns an error if precision isn't one of n, u, ms, s,
+// m or h. An empty precision is nanoseconds.
+func ValidatePrecision(precision string) error {
+	switch precision {
+	case "", "n", "u", "ms", "s", "m", "h":
+		return nil
+	}
+	return fmt.Errorf("invalid precision %q: must be one of n, u, ms, s, m or h", precision)
+}
+
+// TruncateTime truncates a time down to the specified precision.
+func TruncateTime(t time.Time, precision string) time.Time {
 	switch precision {
-	case "n":
 	case "u":
-		p.SetTime(p.Time().Truncate(time.Microsecond))
+		return t.Truncate(time.Microsecond)
 	case "ms":
-		p.SetTime(p.Time().Truncate(time.Millisecond))
+		return t.Truncate(time.Millisecond)
 	case "s":
-		p.SetTime(p.Time().Truncate(time.Second))
+		return t.Truncate(time.Second)
 	case "m":
-		p.SetTime(p.Time().Truncate(time.Minute))
+		return t.Truncate(time.Minute)
 	case "h":
-		p.SetTime(p.Time().Truncate(time.Hour))
+		return t.Truncate(time.Hour)
+	}
+	return t
+}
+
+// TruncatePoints returns the points with their times truncated to the
+// specified precision, so points written at different precisions land on
+// the same time. Points with a time that needs truncating are copied and the
+// passed points are never changed. Returns points if none are copied.
+func TruncatePoints(points []Point, precision string) []Point {
+	if precision == "" || precision == "n" {
+		return points
+	}
+
+	var other []Point
+	for i, p := range points {
+		t := TruncateTime(p.Time(), precision)
+		if t.Equal(p.Time()) {
+			if other != nil {
+				other = append(other, p)
+			}
+			continue
+		}
+
+		if other == nil {
+			other = make([]Point, i, len(points))
+			copy(other, points[:i])
+		}
+		pt := NewPoint(p.Name(), p.Tags(), p.Fields(), t)
+		if data := p.Data(); data != nil {
+			pt.SetData(data)
+		}
+		other = append(other, pt)
 	}
+	if other == nil {
+		return points
+	}
+	return other
 }
 
 // GetPrecisionMultiplier will return a multiplier for the precision specified
@@ -854,6 +1070,9 @@ func parseNumber(val []byte) (interface{}, error) {
 			return string(val), nil
 		}
 	}
+	if len(val) > 0 && val[len(val)-1] == 'u' {
+		return strconv.ParseUint(string(val[:len(val)-1]), 10, 64)
+	}
 	return strconv.ParseInt(string(val), 10, 64)
 }
 
@@ -925,6 +1144,7 @@ func (p Fields) MarshalBinary() []byte {
 			b = append(b, []byte(strconv.FormatInt(int64(t), 10))...)
 		case uint64:
 			b = append(b, []byte(strconv.FormatUint(t, 10))...)
+			b = append(b, 'u')
 		case int64:
 			b = append(b, []byte(strconv.FormatInt(t, 10))...)
 		case float64:
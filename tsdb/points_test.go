@@ -2,6 +2,7 @@ package tsdb
 
 import (
 	"bytes"
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
@@ -174,6 +175,33 @@ func TestParsePointMissingFieldValue(t *testing.T) {
 	if err == nil {
 		t.Errorf(`ParsePoints("%s") mismatch. got nil, exp error`, "cpu")
 	}
+
+	_, err = ParsePointsString(`cpu,host=serverA,region=us-west 1000000000`)
+	if err == nil {
+		t.Errorf(`ParsePoints("%s") mismatch. got nil, exp error`, "cpu")
+	}
+
+	_, err = ParsePointsString(`cpu,host=serverA,region=us-west value=1,value2`)
+	if err == nil {
+		t.Errorf(`ParsePoints("%s") mismatch. got nil, exp error`, "cpu")
+	}
+}
+
+func TestParsePointNegativeTimestamp(t *testing.T) {
+	test(t, `cpu value=1.0 -1000000000`,
+		NewPoint(
+			"cpu",
+			Tags{},
+			Fields{
+				"value": 1.0,
+			},
+			time.Unix(-1, 0)),
+	)
+
+	_, err := ParsePointsString(`cpu value=1 1-1`)
+	if err == nil {
+		t.Errorf(`ParsePoints("%s") mismatch. got nil, exp error`, "cpu")
+	}
 }
 
 func TestParsePointBadNumber(t *testing.T) {
@@ -219,6 +247,26 @@ func TestParsePointNegativeInteger(t *testing.T) {
 	}
 }
 
+func TestParsePointUnsigned(t *testing.T) {
+	_, err := ParsePointsString(`cpu,host=serverA,region=us-west value=18446744073709551615u`)
+	if err != nil {
+		t.Errorf(`ParsePoints("%s") mismatch. got %v, exp nil`, `cpu,host=serverA,region=us-west value=18446744073709551615u`, err)
+	}
+}
+
+func TestParsePointUnsignedInvalid(t *testing.T) {
+	for _, s := range []string{
+		`cpu,host=serverA,region=us-west value=-1u`,
+		`cpu,host=serverA,region=us-west value=1.5u`,
+		`cpu,host=serverA,region=us-west value=18446744073709551616u`,
+		`cpu,host=serverA,region=us-west value=1u1`,
+	} {
+		if _, err := ParsePointsString(s); err == nil {
+			t.Errorf(`ParsePoints("%s") mismatch. got nil, exp error`, s)
+		}
+	}
+}
+
 func TestParsePointNegativeFloat(t *testing.T) {
 	_, err := ParsePointsString(`cpu,host=serverA,region=us-west value=-1.0`)
 	if err != nil {
@@ -391,6 +439,82 @@ func TestParsPointWithDuplicateTags(t *testing.T) {
 	}
 }
 
+func TestPoints_Split(t *testing.T) {
+	var points Points
+	for _, sec := range []int64{3, 1, 2, 1} {
+		points = append(points, NewPoint("cpu", nil, Fields{"value": sec}, time.Unix(sec, 0)))
+	}
+	size := pointSize(points[0])
+
+	if a := points.Split(0, 0); len(a) != 1 || len(a[0]) != 4 {
+		t.Fatalf("unexpected batches: %v", a)
+	}
+
+	// Batches are in time order, and points with the same time keep theirs.
+	a := points.Split(2*size, 0)
+	if len(a) != 2 {
+		t.Fatalf("unexpected batch count: %d", len(a))
+	} else if a[0][0] != points[1] || a[0][1] != points[3] || a[1][0] != points[2] || a[1][1] != points[0] {
+		t.Fatalf("unexpected batches: %v", a)
+	}
+
+	// A point larger than the batch size is in a batch of its own.
+	if a := points.Split(1, 0); len(a) != 4 {
+		t.Fatalf("unexpected batch count: %d", len(a))
+	}
+
+	// Batches don't cross shard group boundaries.
+	a = points.Split(3*size, 2*time.Second)
+	if len(a) != 2 || len(a[0]) != 2 || len(a[1]) != 2 {
+		t.Fatalf("unexpected batches: %v", a)
+	} else if a[0][0] != points[1] || a[0][1] != points[3] || a[1][0] != points[2] || a[1][1] != points[0] {
+		t.Fatalf("unexpected batches: %v", a)
+	}
+}
+
+func TestParsePointInvalidSeriesKey(t *testing.T) {
+	line := "cpu,a=" + strings.Repeat("v", 4096) + ",b=" + strings.Repeat("v", MaxSeriesKeyLength-4096) + " value=1"
+	if _, err := ParsePointsString(line); err == nil || !strings.Contains(err.Error(), ErrInvalidSeriesKey.Error()) || !strings.Contains(err.Error(), "key is 32777 bytes") {
+		t.Errorf("unexpected error: %.100v", err)
+	}
+
+	// The configurable limits are checked when the series is written.
+	if _, err := ParsePointsString("cpu,host=" + strings.Repeat("v", DefaultMaxTagValueLength+1) + " value=1"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	manyTags := make([]string, DefaultMaxTagsPerSeries+1)
+	for i := range manyTags {
+		manyTags[i] = fmt.Sprintf("t%03d=v", i)
+	}
+
+	for _, tt := range []struct {
+		key    string
+		limits SeriesKeyLimits
+		err    string
+	}{
+		{key: "cpu," + strings.Join(manyTags, ","), limits: DefaultSeriesKeyLimits, err: "more than 256 tags"},
+		{key: "cpu," + strings.Repeat("k", DefaultMaxTagKeyLength+1) + "=v", limits: DefaultSeriesKeyLimits, err: "tag key is 257 bytes"},
+		{key: "cpu,host=" + strings.Repeat("v", DefaultMaxTagValueLength+1), limits: DefaultSeriesKeyLimits, err: "value of tag host is 4097 bytes"},
+		{key: "cpu,host=" + strings.Repeat("v", 100), limits: SeriesKeyLimits{MaxKeyLength: 100}, err: "key is 109 bytes, limit 100"},
+		{key: "cpu,host=" + strings.Repeat("v", MaxSeriesKeyLength), limits: SeriesKeyLimits{}, err: "limit 32768"},
+	} {
+		err := validateKey([]byte(tt.key), tt.limits)
+		if err == nil || !strings.Contains(err.Error(), ErrInvalidSeriesKey.Error()) || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("unexpected error: %.100v", err)
+		}
+	}
+
+	// Keys at the limits are valid, and zero limits are unlimited.
+	if err := validateKey([]byte("cpu,"+strings.Join(manyTags[1:], ",")), DefaultSeriesKeyLimits); err != nil {
+		t.Error(err)
+	} else if err := validateKey([]byte("cpu,"+strings.Join(manyTags, ",")), SeriesKeyLimits{}); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestParsePointWithStringField(t *testing.T) {
 	test(t, `cpu,host=serverA,region=us-east value=1.0,str="foo",str2="bar" 1000000000`,
 		NewPoint("cpu",
@@ -595,6 +719,18 @@ func TestParsePointIntsFloats(t *testing.T) {
 
 }
 
+func TestNewPointUnsigned(t *testing.T) {
+	test(t, `cpu value=18446744073709551615u 1000000000`,
+		NewPoint(
+			"cpu",
+			Tags{},
+			Fields{
+				"value": uint64(18446744073709551615),
+			},
+			time.Unix(1, 0)),
+	)
+}
+
 func TestParsePointKeyUnsorted(t *testing.T) {
 	pts, err := ParsePoints([]byte("cpu,last=1,first=2 value=1"))
 	if err != nil {
@@ -704,6 +840,21 @@ func TestParsePointsWithPrecision(t *testing.T) {
 	}
 }
 
+func TestParsePointsWithLimit(t *testing.T) {
+	buf := []byte("cpu value=1 1\ncpu value=2 2\ncpu value=3 3")
+	if pts, err := ParsePointsWithLimit(buf, time.Now(), "n", 3); err != nil {
+		t.Fatal(err)
+	} else if len(pts) != 3 {
+		t.Fatalf("unexpected points: %d", len(pts))
+	}
+
+	// The point past the limit isn't parsed, even if it's invalid.
+	buf = append(buf, []byte("\ncpu invalid")...)
+	if _, err := ParsePointsWithLimit(buf, time.Now(), "n", 3); err == nil || !strings.Contains(err.Error(), ErrMaxPointsPerBatchExceeded.Error()) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestParsePointsWithPrecisionNoTime(t *testing.T) {
 	line := `cpu,host=serverA,region=us-east value=1.0`
 	tm, _ := time.Parse(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z")
@@ -766,6 +917,32 @@ func TestParsePointsWithPrecisionNoTime(t *testing.T) {
 	}
 }
 
+// Ensure points written at a finer precision are truncated to the same time
+// as the same point written at the stored precision.
+func TestTruncatePoints(t *testing.T) {
+	points := []Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 2.0}, time.Unix(1, 250000000)),
+	}
+
+	other := TruncatePoints(points, "s")
+	if len(other) != 2 || other[0] != points[0] {
+		t.Fatalf("unexpected points: %v", other)
+	} else if exp := "cpu,host=a value=2.0 1000000000"; other[1].String() != exp {
+		t.Fatalf("unexpected point:\ngot %v\nexp %v", other[1], exp)
+	} else if !points[1].Time().Equal(time.Unix(1, 250000000)) {
+		t.Fatalf("passed point changed: %v", points[1])
+	}
+
+	if other := TruncatePoints(points, "ms"); &other[0] != &points[0] {
+		t.Fatal("expected points to be returned as is")
+	}
+
+	if err := ValidatePrecision("ns"); err == nil {
+		t.Fatal("expected invalid precision error")
+	}
+}
+
 func TestNewPointEscaped(t *testing.T) {
 	// commas
 	pt := NewPoint("cpu,main", Tags{"tag,bar": "value"}, Fields{"name,bar": 1.0}, time.Unix(0, 0))
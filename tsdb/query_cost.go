@@ -0,0 +1,89 @@
+package tsdb
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ShardCost is the amount of data a query read from a single shard.
+type ShardCost struct {
+	Blocks int64 // stored blocks read
+	Bytes  int64 // key and value bytes read
+}
+
+// QueryCost tracks the data read by a query across every shard it hits.
+// Mappers report their reads to it as they go, so a query that exceeds its
+// byte budget is stopped on whichever shard pushes it over. It is safe for
+// concurrent use so mappers for remote shards can report into it as well.
+type QueryCost struct {
+	mu       sync.Mutex
+	maxBytes int64
+	blocks   int64
+	bytes    int64
+	shards   map[uint64]ShardCost
+}
+
+// NewQueryCost returns a new QueryCost. A maxBytes of zero or less means the
+// query can read any amount of data.
+func NewQueryCost(maxBytes int64) *QueryCost {
+	return &QueryCost{
+		maxBytes: maxBytes,
+		shards:   make(map[uint64]ShardCost),
+	}
+}
+
+// Add records blocks and bytes read from a shard. It returns an
+// ErrQueryBudgetExceeded once the query has read more than its budget.
+func (c *QueryCost) Add(shardID uint64, blocks, bytes int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sc := c.shards[shardID]
+	sc.Blocks += blocks
+	sc.Bytes += bytes
+	c.shards[shardID] = sc
+
+	c.blocks += blocks
+	c.bytes += bytes
+
+	if c.maxBytes > 0 && c.bytes > c.maxBytes {
+		return ErrQueryBudgetExceeded{MaxBytes: c.maxBytes, Bytes: c.bytes}
+	}
+	return nil
+}
+
+// Blocks returns the total number of blocks read by the query.
+func (c *QueryCost) Blocks() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.blocks
+}
+
+// Bytes returns the total number of bytes read by the query.
+func (c *QueryCost) Bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bytes
+}
+
+// Shards returns the cost of the query broken down by shard id.
+func (c *QueryCost) Shards() map[uint64]ShardCost {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	other := make(map[uint64]ShardCost, len(c.shards))
+	for id, sc := range c.shards {
+		other[id] = sc
+	}
+	return other
+}
+
+// ErrQueryBudgetExceeded is returned when a query reads more bytes than the
+// configured per-query budget.
+type ErrQueryBudgetExceeded struct {
+	MaxBytes int64
+	Bytes    int64
+}
+
+func (e ErrQueryBudgetExceeded) Error() string {
+	return fmt.Sprintf("query exceeded byte budget: read %d bytes, max %d", e.Bytes, e.MaxBytes)
+}
@@ -0,0 +1,72 @@
+package tsdb
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Ensure reads are aggregated per query and broken down by shard.
+func TestQueryCost_Add(t *testing.T) {
+	c := NewQueryCost(0)
+	if err := c.Add(1, 2, 100); err != nil {
+		t.Fatal(err)
+	} else if err := c.Add(2, 1, 50); err != nil {
+		t.Fatal(err)
+	} else if err := c.Add(1, 1, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := c.Blocks(); n != 4 {
+		t.Fatalf("unexpected blocks: %d", n)
+	} else if n := c.Bytes(); n != 160 {
+		t.Fatalf("unexpected bytes: %d", n)
+	} else if s := c.Shards(); !reflect.DeepEqual(s, map[uint64]ShardCost{1: {Blocks: 3, Bytes: 110}, 2: {Blocks: 1, Bytes: 50}}) {
+		t.Fatalf("unexpected shards: %#v", s)
+	}
+}
+
+// Ensure an error is returned once the byte budget is exceeded.
+func TestQueryCost_Add_Budget(t *testing.T) {
+	c := NewQueryCost(100)
+	if err := c.Add(1, 1, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Add(2, 1, 1); err != (ErrQueryBudgetExceeded{MaxBytes: 100, Bytes: 101}) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a query that reads more than the executor's byte budget is stopped.
+func TestQueryExecutor_MaxQueryBytes(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+	defer store.Close()
+
+	var pts []Point
+	for i := 0; i < 100; i++ {
+		pts = append(pts, NewPoint(
+			"cpu",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"value": float64(i)},
+			time.Unix(int64(i+1), 0),
+		))
+	}
+	if err := store.WriteToShard(shardID, pts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a budget the query reads everything.
+	if got := executeAndGetJSON("select count(value) from cpu", executor); !strings.Contains(got, `"values":[["1970-01-01T00:00:00Z",100]]`) {
+		t.Fatalf("unexpected result: %s", got)
+	}
+
+	executor.MaxQueryBytes = 100
+	for _, q := range []string{"select count(value) from cpu", "select value from cpu"} {
+		if got := executeAndGetJSON(q, executor); !strings.Contains(got, `"error":"query exceeded byte budget: read `) {
+			t.Fatalf("%s: unexpected result: %s", q, got)
+		}
+	}
+}
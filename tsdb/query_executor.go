@@ -4,9 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
@@ -26,6 +27,8 @@ type QueryExecutor struct {
 		Authenticate(username, password string) (*meta.UserInfo, error)
 		RetentionPolicy(database, name string) (rpi *meta.RetentionPolicyInfo, err error)
 		UserCount() (int, error)
+		ReadOnly() (bool, error)
+		NodeID() uint64
 	}
 
 	// Executes statements relating to meta data.
@@ -35,6 +38,28 @@ type QueryExecutor struct {
 
 	Logger *log.Logger
 
+	// Collation determines the order of measurement names, series keys and tag
+	// values in SHOW results and of GROUP BY series in SELECT results.
+	Collation influxql.Collation
+
+	// QueryLimiter, if set, limits the number of SELECT statements that
+	// execute concurrently.
+	QueryLimiter *QueryLimiter
+
+	// MaxQueryBytes is the most data a query may read across all of the
+	// shards it hits before it is stopped. Zero means unlimited.
+	MaxQueryBytes int64
+
+	// RemoteDeleter, if set, deletes points from the shards owned by other
+	// nodes when a DELETE statement is executed.
+	RemoteDeleter interface {
+		DeleteSeriesRange(shardID, ownerID uint64, measurement string, condition influxql.Expr, min, max int64) error
+	}
+
+	// StatisticsSources report the statistics of services outside the
+	// store. SHOW STATS returns a row from each after the shard statistics.
+	StatisticsSources []StatisticsSource
+
 	// the local data store
 	store *Store
 }
@@ -124,6 +149,9 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 	// track how many of the statements were executed
 	results := make(chan *influxql.Result)
 	go func() {
+		// Every statement in the query is charged against the same budget.
+		cost := NewQueryCost(q.MaxQueryBytes)
+
 		var i int
 		var stmt influxql.Statement
 		for i, stmt = range query.Statements {
@@ -146,10 +174,16 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 			var res *influxql.Result
 			switch stmt := stmt.(type) {
 			case *influxql.SelectStatement:
-				if err := q.executeSelectStatement(i, stmt, results, chunkSize); err != nil {
+				if err := q.executeSelectStatement(i, stmt, results, chunkSize, cost); err != nil {
+					if e, ok := err.(ErrQueryBudgetExceeded); ok {
+						q.Logger.Printf("query exceeded byte budget | query: %q | blocks: %d | bytes: %d | max bytes: %d\n",
+							query.String(), cost.Blocks(), e.Bytes, e.MaxBytes)
+					}
 					results <- &influxql.Result{Err: err}
 					break
 				}
+			case *influxql.ExplainStatement:
+				res = q.executeExplainStatement(stmt)
 			case *influxql.DropSeriesStatement:
 				// TODO: handle this in a cluster
 				res = q.executeDropSeriesStatement(stmt, database)
@@ -166,13 +200,34 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 				res = q.executeShowTagValuesStatement(stmt, database)
 			case *influxql.ShowFieldKeysStatement:
 				res = q.executeShowFieldKeysStatement(stmt, database)
+			case *influxql.ShowSeriesCardinalityStatement:
+				res = q.executeShowCardinalityStatement(database, func(db *DatabaseIndex) (int64, error) {
+					return db.SeriesCardinality(), nil
+				})
+			case *influxql.ShowMeasurementCardinalityStatement:
+				res = q.executeShowCardinalityStatement(database, func(db *DatabaseIndex) (int64, error) {
+					return db.MeasurementCardinality(), nil
+				})
+			case *influxql.ShowTagValuesCardinalityStatement:
+				res = q.executeShowCardinalityStatement(database, func(db *DatabaseIndex) (int64, error) {
+					return q.tagValuesCardinality(db, stmt)
+				})
 			case *influxql.ShowDiagnosticsStatement:
 				res = q.executeShowDiagnosticsStatement(stmt)
+			case *influxql.ShowStatsStatement:
+				res = q.executeShowStatsStatement(stmt)
 			case *influxql.DeleteStatement:
-				res = &influxql.Result{Err: ErrInvalidQuery}
+				res = q.executeDeleteStatement(stmt, database)
 			case *influxql.DropDatabaseStatement:
 				// TODO: handle this in a cluster
 				res = q.executeDropDatabaseStatement(stmt)
+			case *influxql.RenameDatabaseStatement:
+				res = q.executeRenameDatabaseStatement(stmt)
+			case *influxql.ShowSchemaStatement:
+				if stmt.Database == "" {
+					stmt.Database = database
+				}
+				res = q.MetaStatementExecutor.ExecuteStatement(stmt)
 			default:
 				// Delegate all other meta statements to a separate executor. They don't hit tsdb storage.
 				res = q.MetaStatementExecutor.ExecuteStatement(stmt)
@@ -202,7 +257,14 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 }
 
 // executeSelectStatement plans and executes a select statement against a database.
-func (q *QueryExecutor) executeSelectStatement(statementID int, stmt *influxql.SelectStatement, results chan *influxql.Result, chunkSize int) error {
+// The data read by the statement's mappers is charged to cost.
+func (q *QueryExecutor) executeSelectStatement(statementID int, stmt *influxql.SelectStatement, results chan *influxql.Result, chunkSize int, cost *QueryCost) error {
+	// Wait for a free slot if concurrent queries are limited.
+	if q.QueryLimiter != nil {
+		q.QueryLimiter.Acquire()
+		defer q.QueryLimiter.Release()
+	}
+
 	// Perform any necessary query re-writing.
 	stmt, err := q.rewriteSelectStatement(stmt)
 	if err != nil {
@@ -210,7 +272,8 @@ func (q *QueryExecutor) executeSelectStatement(statementID int, stmt *influxql.S
 	}
 
 	// Plan statement execution.
-	p := influxql.NewPlanner(q)
+	p := influxql.NewPlanner(&queryDB{QueryExecutor: q, cost: cost})
+	p.Collation = q.Collation
 	e, err := p.Plan(stmt, chunkSize)
 	if err != nil {
 		return err
@@ -237,6 +300,88 @@ func (q *QueryExecutor) executeSelectStatement(statementID int, stmt *influxql.S
 	return nil
 }
 
+// queryDB begins transactions whose mappers charge their reads to a query's cost.
+type queryDB struct {
+	*QueryExecutor
+	cost *QueryCost
+}
+
+// Begin returns a transaction that charges reads to the query's cost.
+func (db *queryDB) Begin() (influxql.Tx, error) {
+	tx := newTx(db.MetaStore, db.store)
+	tx.cost = db.cost
+	return tx, nil
+}
+
+// executeExplainStatement plans a select statement without executing it and
+// returns a row per measurement describing how its series would be read.
+func (q *QueryExecutor) executeExplainStatement(stmt *influxql.ExplainStatement) *influxql.Result {
+	sel, err := q.rewriteSelectStatement(stmt.Statement)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	// Create the jobs the planner would execute.
+	sel.Condition = influxql.Reduce(sel.Condition, &influxql.NowValuer{Now: time.Now().UTC()})
+	_, tags, err := sel.Dimensions.Normalize()
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+	jobs, err := newTx(q.MetaStore, q.store).CreateMapReduceJobs(sel, tags)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	// Count the tag sets, series and shards read for each measurement.
+	type plan struct {
+		tagSets, series int
+		shards          map[uint64]struct{}
+	}
+	plans := make(map[string]*plan)
+	for _, j := range jobs {
+		p := plans[j.MeasurementName]
+		if p == nil {
+			p = &plan{shards: make(map[uint64]struct{})}
+			plans[j.MeasurementName] = p
+		}
+		p.tagSets++
+		p.series += len(j.TagSet.SeriesKeys)
+		for _, m := range j.Mappers {
+			if m, ok := m.(*LocalMapper); ok {
+				p.shards[m.shardID] = struct{}{}
+			}
+		}
+	}
+
+	strategy := "index"
+	if sel.Hints.NoIndex {
+		strategy = "scan"
+	}
+	parallel := sel.Hints.MaxParallel
+	if parallel == 0 {
+		parallel = 1
+	}
+	var hints string
+	if sel.Hints != (influxql.Hints{}) {
+		hints = sel.Hints.String()
+	}
+
+	row := &influxql.Row{Columns: []string{"measurement", "strategy", "tag_sets", "series", "shards", "max_parallel", "hints"}}
+	for _, src := range sel.Sources {
+		mm, ok := src.(*influxql.Measurement)
+		if !ok {
+			continue
+		}
+		p := plans[mm.Name]
+		if p == nil {
+			p = &plan{}
+		}
+		row.Values = append(row.Values, []interface{}{mm.Name, strategy, p.tagSets, p.series, len(p.shards), parallel, hints})
+	}
+
+	return &influxql.Result{Series: []*influxql.Row{row}}
+}
+
 // rewriteSelectStatement performs any necessary query re-writing.
 func (q *QueryExecutor) rewriteSelectStatement(stmt *influxql.SelectStatement) (*influxql.SelectStatement, error) {
 	var err error
@@ -364,7 +509,7 @@ func (q *QueryExecutor) expandSources(sources influxql.Sources) (influxql.Source
 	}
 
 	// Sort the list of source names.
-	sort.Strings(names)
+	q.Collation.SortStrings(names)
 
 	// Convert set to a list of Sources.
 	expanded := make(influxql.Sources, 0, len(set))
@@ -385,6 +530,13 @@ func (q *QueryExecutor) executeDropDatabaseStatement(stmt *influxql.DropDatabase
 		return &influxql.Result{Err: ErrDatabaseNotFound(stmt.Name)}
 	}
 
+	// Keep the local data if the metastore won't drop the database.
+	if readOnly, err := q.MetaStore.ReadOnly(); err != nil {
+		return &influxql.Result{Err: err}
+	} else if readOnly {
+		return &influxql.Result{Err: meta.ErrReadOnly}
+	}
+
 	var shardIDs []uint64
 	for _, rp := range dbi.RetentionPolicies {
 		for _, sg := range rp.ShardGroups {
@@ -402,6 +554,21 @@ func (q *QueryExecutor) executeDropDatabaseStatement(stmt *influxql.DropDatabase
 	return q.MetaStatementExecutor.ExecuteStatement(stmt)
 }
 
+// executeRenameDatabaseStatement renames a database in the metastore and
+// then its local data. Every other node renames its data once it sees the
+// change in the metadata.
+func (q *QueryExecutor) executeRenameDatabaseStatement(stmt *influxql.RenameDatabaseStatement) *influxql.Result {
+	res := q.MetaStatementExecutor.ExecuteStatement(stmt)
+	if res.Err != nil {
+		return res
+	}
+
+	if err := q.store.RenameDatabase(stmt.OldName, stmt.NewName); err != nil {
+		return &influxql.Result{Err: err}
+	}
+	return res
+}
+
 // executeDropMeasurementStatement removes the measurement and all series data from the local store for the given measurement
 func (q *QueryExecutor) executeDropMeasurementStatement(stmt *influxql.DropMeasurementStatement, database string) *influxql.Result {
 	// Find the database.
@@ -440,7 +607,13 @@ func (q *QueryExecutor) executeDropSeriesStatement(stmt *influxql.DropSeriesStat
 		return &influxql.Result{Err: err}
 	}
 
-	measurements, err := measurementsFromSourcesOrDB(db, sources...)
+	// Nothing to drop if no measurement matches the FROM clause. An empty
+	// list of sources would otherwise match every measurement.
+	if len(stmt.Sources) > 0 && len(sources) == 0 {
+		return &influxql.Result{}
+	}
+
+	measurements, err := measurementsFromSourcesOrDB(db, q.Collation, sources...)
 	if err != nil {
 		return &influxql.Result{Err: err}
 	}
@@ -474,6 +647,93 @@ func (q *QueryExecutor) executeDropSeriesStatement(stmt *influxql.DropSeriesStat
 	return &influxql.Result{}
 }
 
+// executeDeleteStatement removes the points of a measurement within the time
+// range of the WHERE clause from every shard that may hold them, locally and
+// on the other owners of the shards.
+func (q *QueryExecutor) executeDeleteStatement(stmt *influxql.DeleteStatement, database string) *influxql.Result {
+	m, ok := stmt.Source.(*influxql.Measurement)
+	if !ok || m.Regex != nil {
+		return &influxql.Result{Err: errors.New("DELETE requires a measurement name")}
+	}
+	if m.Database != "" {
+		database = m.Database
+	}
+
+	dbi, err := q.MetaStore.Database(database)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	} else if dbi == nil {
+		return &influxql.Result{Err: ErrDatabaseNotFound(database)}
+	}
+
+	// Replace now() with the current time.
+	condition := stmt.Condition
+	if condition != nil {
+		condition = influxql.Reduce(condition, &influxql.NowValuer{Now: time.Now().UTC()})
+		if err := validateDeleteCondition(condition); err != nil {
+			return &influxql.Result{Err: err}
+		}
+	}
+
+	// A missing bound leaves the time range open on that side.
+	tmin, tmax := influxql.TimeRange(condition)
+	if tmin.IsZero() {
+		tmin = time.Unix(0, math.MinInt64).UTC()
+	}
+	if tmax.IsZero() {
+		tmax = time.Unix(0, math.MaxInt64).UTC()
+	}
+	min, max := tmin.UnixNano(), tmax.UnixNano()
+
+	nodeID := q.MetaStore.NodeID()
+	for _, rpi := range dbi.RetentionPolicies {
+		if m.RetentionPolicy != "" && rpi.Name != m.RetentionPolicy {
+			continue
+		}
+		for _, sgi := range rpi.ShardGroups {
+			if sgi.Deleted() || !sgi.Overlaps(tmin, tmax) {
+				continue
+			}
+			for _, sh := range sgi.Shards {
+				if err := q.store.DeleteSeriesRange(sh.ID, m.Name, condition, min, max); err != nil {
+					return &influxql.Result{Err: err}
+				}
+
+				if q.RemoteDeleter == nil {
+					continue
+				}
+				for _, ownerID := range sh.OwnerIDs {
+					if ownerID == nodeID {
+						continue
+					}
+					if err := q.RemoteDeleter.DeleteSeriesRange(sh.ID, ownerID, m.Name, condition, min, max); err != nil {
+						return &influxql.Result{Err: fmt.Errorf("delete from shard %d on node %d: %s", sh.ID, ownerID, err)}
+					}
+				}
+			}
+		}
+	}
+
+	return &influxql.Result{}
+}
+
+// validateDeleteCondition returns an error if time is compared under an OR.
+// The deleted time range applies to every series matching the condition, so
+// it can only be narrowed by time terms ANDed with the rest.
+func validateDeleteCondition(condition influxql.Expr) error {
+	var err error
+	influxql.WalkFunc(condition, func(n influxql.Node) {
+		if n, ok := n.(*influxql.BinaryExpr); ok && n.Op == influxql.OR && err == nil {
+			influxql.WalkFunc(n, func(n influxql.Node) {
+				if ref, ok := n.(*influxql.VarRef); ok && strings.ToLower(ref.Val) == "time" {
+					err = errors.New("DELETE does not support time conditions in an OR")
+				}
+			})
+		}
+	})
+	return err
+}
+
 func (q *QueryExecutor) executeShowSeriesStatement(stmt *influxql.ShowSeriesStatement, database string) *influxql.Result {
 	// Find the database.
 	db := q.store.DatabaseIndex(database)
@@ -488,7 +748,7 @@ func (q *QueryExecutor) executeShowSeriesStatement(stmt *influxql.ShowSeriesStat
 	}
 
 	// Get the list of measurements we're interested in.
-	measurements, err := measurementsFromSourcesOrDB(db, sources...)
+	measurements, err := measurementsFromSourcesOrDB(db, q.Collation, sources...)
 	if err != nil {
 		return &influxql.Result{Err: err}
 	}
@@ -545,6 +805,9 @@ func (q *QueryExecutor) executeShowSeriesStatement(stmt *influxql.ShowSeriesStat
 		// make the id the first column
 		r.Columns = append([]string{"_key"}, r.Columns...)
 
+		// Order the series by key.
+		q.Collation.SortValues(influxql.Rows{r})
+
 		// Append the row to the result.
 		result.Series = append(result.Series, r)
 	}
@@ -605,7 +868,7 @@ func (q *QueryExecutor) executeShowMeasurementsStatement(stmt *influxql.ShowMeas
 		// Otherwise, get all measurements from the database.
 		measurements = db.Measurements()
 	}
-	sort.Sort(measurements)
+	sortMeasurements(measurements, q.Collation)
 
 	offset := stmt.Offset
 	limit := stmt.Limit
@@ -658,7 +921,7 @@ func (q *QueryExecutor) executeShowTagKeysStatement(stmt *influxql.ShowTagKeysSt
 	}
 
 	// Get the list of measurements we're interested in.
-	measurements, err := measurementsFromSourcesOrDB(db, sources...)
+	measurements, err := measurementsFromSourcesOrDB(db, q.Collation, sources...)
 	if err != nil {
 		return &influxql.Result{Err: err}
 	}
@@ -697,6 +960,45 @@ func (q *QueryExecutor) executeShowTagKeysStatement(stmt *influxql.ShowTagKeysSt
 	return result
 }
 
+// executeShowCardinalityStatement returns the cardinality counted by fn
+// from the database's index.
+func (q *QueryExecutor) executeShowCardinalityStatement(database string, fn func(db *DatabaseIndex) (int64, error)) *influxql.Result {
+	// Find the database.
+	db := q.store.DatabaseIndex(database)
+	if db == nil {
+		return &influxql.Result{}
+	}
+
+	n, err := fn(db)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+	return &influxql.Result{
+		Series: influxql.Rows{{
+			Columns: []string{"cardinality"},
+			Values:  [][]interface{}{{n}},
+		}},
+	}
+}
+
+// tagValuesCardinality returns the number of values of the
+// statement's tag key across the measurements in its FROM clause.
+func (q *QueryExecutor) tagValuesCardinality(db *DatabaseIndex, stmt *influxql.ShowTagValuesCardinalityStatement) (int64, error) {
+	// Expand regex expressions in the FROM clause.
+	sources, err := q.expandSources(stmt.Sources)
+	if err != nil {
+		return 0, err
+	} else if len(stmt.Sources) > 0 && len(sources) == 0 {
+		return 0, nil
+	}
+
+	measurements, err := measurementsFromSourcesOrDB(db, q.Collation, sources...)
+	if err != nil {
+		return 0, err
+	}
+	return TagValueCardinality(measurements, stmt.TagKey), nil
+}
+
 func (q *QueryExecutor) executeShowTagValuesStatement(stmt *influxql.ShowTagValuesStatement, database string) *influxql.Result {
 	// Find the database.
 	db := q.store.DatabaseIndex(database)
@@ -711,7 +1013,7 @@ func (q *QueryExecutor) executeShowTagValuesStatement(stmt *influxql.ShowTagValu
 	}
 
 	// Get the list of measurements we're interested in.
-	measurements, err := measurementsFromSourcesOrDB(db, sources...)
+	measurements, err := measurementsFromSourcesOrDB(db, q.Collation, sources...)
 	if err != nil {
 		return &influxql.Result{Err: err}
 	}
@@ -759,7 +1061,7 @@ func (q *QueryExecutor) executeShowTagValuesStatement(stmt *influxql.ShowTagValu
 		}
 
 		vals := v.list()
-		sort.Strings(vals)
+		q.Collation.SortStrings(vals)
 
 		for _, val := range vals {
 			v := interface{}(val)
@@ -769,7 +1071,7 @@ func (q *QueryExecutor) executeShowTagValuesStatement(stmt *influxql.ShowTagValu
 		result.Series = append(result.Series, r)
 	}
 
-	sort.Sort(result.Series)
+	q.Collation.SortRows(result.Series)
 	return result
 }
 
@@ -788,7 +1090,7 @@ func (q *QueryExecutor) executeShowFieldKeysStatement(stmt *influxql.ShowFieldKe
 		return &influxql.Result{Err: err}
 	}
 
-	measurements, err := measurementsFromSourcesOrDB(db, sources...)
+	measurements, err := measurementsFromSourcesOrDB(db, q.Collation, sources...)
 	if err != nil {
 		return &influxql.Result{Err: err}
 	}
@@ -808,7 +1110,7 @@ func (q *QueryExecutor) executeShowFieldKeysStatement(stmt *influxql.ShowFieldKe
 
 		// Get a list of field names from the measurement then sort them.
 		names := m.FieldNames()
-		sort.Strings(names)
+		q.Collation.SortStrings(names)
 
 		// Add the field names to the result row values.
 		for _, n := range names {
@@ -825,8 +1127,8 @@ func (q *QueryExecutor) executeShowFieldKeysStatement(stmt *influxql.ShowFieldKe
 
 // measurementsFromSourcesOrDB returns a list of measurements from the
 // sources passed in or, if sources is empty, a list of all
-// measurement names from the database passed in.
-func measurementsFromSourcesOrDB(db *DatabaseIndex, sources ...influxql.Source) (Measurements, error) {
+// measurement names from the database passed in. The list is sorted using c.
+func measurementsFromSourcesOrDB(db *DatabaseIndex, c influxql.Collation, sources ...influxql.Source) (Measurements, error) {
 	var measurements Measurements
 	if len(sources) > 0 {
 		for _, source := range sources {
@@ -849,7 +1151,7 @@ func measurementsFromSourcesOrDB(db *DatabaseIndex, sources ...influxql.Source)
 			}
 		}
 	}
-	sort.Sort(measurements)
+	sortMeasurements(measurements, c)
 
 	return measurements, nil
 }
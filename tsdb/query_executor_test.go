@@ -1,7 +1,9 @@
 package tsdb
 
 import (
+	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -56,6 +58,91 @@ func TestWritePointsAndExecuteQuery(t *testing.T) {
 	}
 }
 
+// Ensure SHOW and GROUP BY results are ordered by the executor's collation.
+func TestQueryExecutor_Collation(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+	executor.Collation = influxql.NaturalCollation
+
+	var points []Point
+	for i, host := range []string{"server10", "server2", "server1"} {
+		points = append(points, NewPoint(
+			"cpu",
+			map[string]string{"host": host},
+			map[string]interface{}{"value": float64(i)},
+			time.Unix(1, 0),
+		))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	got := executeAndGetJSON("show tag values with key = host", executor)
+	exp := `[{"series":[{"name":"hostTagValues","columns":["host"],"values":[["server1"],["server2"],["server10"]]}]}]`
+	if exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+
+	got = executeAndGetJSON("show series", executor)
+	exp = `[{"series":[{"name":"cpu","columns":["_key","host"],"values":[["cpu,host=server1","server1"],["cpu,host=server2","server2"],["cpu,host=server10","server10"]]}]}]`
+	if exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+
+	got = executeAndGetJSON("select value from cpu group by host slimit 2", executor)
+	exp = `[{"series":[{"name":"cpu","tags":{"host":"server1"},"columns":["time","value"],"values":[["1970-01-01T00:00:01Z",2]]}]},{"series":[{"name":"cpu","tags":{"host":"server2"},"columns":["time","value"],"values":[["1970-01-01T00:00:01Z",1]]}]}]`
+	if exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+}
+
+// Ensure queries with planner hints return the same results as without them.
+func TestQueryExecutor_Hints(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	var points []Point
+	for i, host := range []string{"server1", "server2", "server3"} {
+		points = append(points, NewPoint(
+			"cpu",
+			map[string]string{"host": host, "region": []string{"east", "west", "east"}[i]},
+			map[string]interface{}{"value": float64(i)},
+			time.Unix(1, 0),
+		))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cond := range []string{
+		`host = 'server1'`,
+		`host != 'server1'`,
+		`host =~ /server[12]/ AND region = 'east'`,
+		`region !~ /west/ OR value > 1`,
+		`zone = 'a'`,
+	} {
+		exp := executeAndGetJSON("select value from cpu where "+cond+" group by host", executor)
+		got := executeAndGetJSON("select /*+ NO_INDEX MAX_PARALLEL(2) */ value from cpu where "+cond+" group by host", executor)
+		if exp != got {
+			t.Fatalf("%s:\nexp: %s\ngot: %s", cond, exp, got)
+		}
+	}
+
+	// Series that only match a side of an OR with a field comparison are
+	// filtered by it.
+	got := executeAndGetJSON("select value from cpu where region !~ /west/ OR value > 1 group by host", executor)
+	exp := `[{"series":[{"name":"cpu","tags":{"host":"server1"},"columns":["time","value"],"values":[["1970-01-01T00:00:01Z",0]]}]},{"series":[{"name":"cpu","tags":{"host":"server3"},"columns":["time","value"],"values":[["1970-01-01T00:00:01Z",2]]}]}]`
+	if exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+
+	got = executeAndGetJSON("explain select /*+ NO_INDEX */ value from cpu where region = 'east' group by host", executor)
+	exp = `[{"series":[{"columns":["measurement","strategy","tag_sets","series","shards","max_parallel","hints"],"values":[["cpu","scan",2,2,1,1,"/*+ NO_INDEX */"]]}]}]`
+	if exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+}
+
 func TestDropSeriesStatement(t *testing.T) {
 	store, executor := testStoreAndExecutor()
 	defer os.RemoveAll(store.path)
@@ -110,6 +197,94 @@ func TestDropSeriesStatement(t *testing.T) {
 	}
 }
 
+func TestDropSeriesStatement_Regex(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	pt := NewPoint(
+		"cpu_load",
+		map[string]string{"host": "server"},
+		map[string]interface{}{"value": 1.0},
+		time.Unix(1, 2),
+	)
+	pt2 := NewPoint(
+		"cpu_load",
+		map[string]string{"host": "junk"},
+		map[string]interface{}{"value": 1.0},
+		time.Unix(1, 2),
+	)
+	pt3 := NewPoint(
+		"memory",
+		map[string]string{"host": "junk"},
+		map[string]interface{}{"value": 1.0},
+		time.Unix(1, 2),
+	)
+
+	if err := store.WriteToShard(shardID, []Point{pt, pt2, pt3}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	got := executeAndGetJSON("drop series from /cpu.*/ where host = 'junk'", executor)
+	exepected := `[{}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("show series", executor)
+	exepected = `[{"series":[{"name":"cpu_load","columns":["_key","host"],"values":[["cpu_load,host=server","server"]]},{"name":"memory","columns":["_key","host"],"values":[["memory,host=junk","junk"]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	if _, n := store.DatabaseIndex("foo").MeasurementSeriesCounts(); n != 2 {
+		t.Fatalf("unexpected series count: %d", n)
+	}
+
+	// A regex matching no measurements drops nothing.
+	got = executeAndGetJSON("drop series from /disk.*/", executor)
+	exepected = `[{}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+	if _, n := store.DatabaseIndex("foo").MeasurementSeriesCounts(); n != 2 {
+		t.Fatalf("unexpected series count: %d", n)
+	}
+}
+
+func TestShowCardinalityStatements(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		NewPoint("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		NewPoint("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		NewPoint("mem", map[string]string{"host": "b"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		NewPoint("mem", map[string]string{"host": "c"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{q: "show series cardinality", exp: `[{"series":[{"columns":["cardinality"],"values":[[4]]}]}]`},
+		{q: "show measurement cardinality", exp: `[{"series":[{"columns":["cardinality"],"values":[[2]]}]}]`},
+		{q: "show tag values cardinality with key = host", exp: `[{"series":[{"columns":["cardinality"],"values":[[3]]}]}]`},
+		{q: "show tag values cardinality from cpu with key = host", exp: `[{"series":[{"columns":["cardinality"],"values":[[2]]}]}]`},
+		{q: "show tag values cardinality from /disk/ with key = host", exp: `[{"series":[{"columns":["cardinality"],"values":[[0]]}]}]`},
+
+		// Dropped series are no longer counted.
+		{q: "drop series from mem where host = 'c'", exp: `[{}]`},
+		{q: "show series cardinality", exp: `[{"series":[{"columns":["cardinality"],"values":[[3]]}]}]`},
+		{q: "show tag values cardinality with key = host", exp: `[{"series":[{"columns":["cardinality"],"values":[[2]]}]}]`},
+	} {
+		if got := executeAndGetJSON(tt.q, executor); got != tt.exp {
+			t.Errorf("%s:\nexp: %s\ngot: %s", tt.q, tt.exp, got)
+		}
+	}
+}
+
 func TestDropMeasurementStatement(t *testing.T) {
 	store, executor := testStoreAndExecutor()
 	defer os.RemoveAll(store.path)
@@ -259,6 +434,119 @@ func TestQueryNoData(t *testing.T) {
 	store.Close()
 }
 
+// Ensure DELETE removes the points of matching series within the time range.
+func TestQueryExecutor_Delete(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	var points []Point
+	for _, host := range []string{"serverA", "serverB"} {
+		for i := 3; i > 0; i-- {
+			points = append(points, NewPoint(
+				"cpu",
+				map[string]string{"host": host},
+				map[string]interface{}{"value": float64(i)},
+				now.Add(-time.Duration(i)*10*time.Minute),
+			))
+		}
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	got := executeAndGetJSON(fmt.Sprintf(`delete from cpu where host = 'serverA' and time > '%s' and time < '%s'`,
+		now.Add(-25*time.Minute).Format(time.RFC3339Nano), now.Add(-5*time.Minute).Format(time.RFC3339Nano)), executor)
+	if exp := `[{}]`; exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+
+	// Raw queries return a result for each tag set.
+	ts := func(i int) string { return now.Add(-time.Duration(i) * 10 * time.Minute).Format(time.RFC3339Nano) }
+	got = executeAndGetJSON("select value from cpu group by host", executor)
+	exp := fmt.Sprintf(`[{"series":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["%s",3]]}]},{"series":[{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["%s",3],["%s",2],["%s",1]]}]}]`,
+		ts(3), ts(3), ts(2), ts(1))
+	if exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+
+	// Field conditions aren't supported.
+	got = executeAndGetJSON("delete from cpu where value > 1", executor)
+	if !strings.Contains(got, "fields not supported") {
+		t.Fatalf("unexpected result: %s", got)
+	}
+
+	// Neither is time in an OR, which would delete serverB's points too.
+	got = executeAndGetJSON(fmt.Sprintf(`delete from cpu where host = 'serverA' or time < '%s'`, ts(2)), executor)
+	if !strings.Contains(got, "time conditions in an OR") {
+		t.Fatalf("unexpected result: %s", got)
+	}
+	got = executeAndGetJSON(fmt.Sprintf(`delete from cpu where (host = 'serverA' or host = 'serverB') and time < '%s'`, ts(2)), executor)
+	if exp := `[{}]`; exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+}
+
+// Ensure writes and deletes don't wait for a query that is reading the shard,
+// and that the query reads the shard as it was when it began.
+func TestQueryExecutor_WriteDuringQuery(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	var points []Point
+	for i := 0; i < 10; i++ {
+		points = append(points, NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": float64(i)}, time.Unix(int64(i+1), 0)))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	// Count the points returned, in chunks of one.
+	var n int
+	count := func(r *influxql.Result) {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		for _, row := range r.Series {
+			n += len(row.Values)
+		}
+	}
+	ch, err := executor.ExecuteQuery(mustParseQuery("select value from cpu"), "foo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count(<-ch)
+
+	done := make(chan error)
+	go func() {
+		points = points[:0]
+		for i := 0; i < 10000; i++ {
+			points = append(points, NewPoint("mem", map[string]string{"host": fmt.Sprintf("server%d", i)}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)))
+		}
+		if err := store.WriteToShard(shardID, points); err != nil {
+			done <- err
+			return
+		}
+		done <- store.DeleteSeriesRange(shardID, "cpu", nil, math.MinInt64, math.MaxInt64)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for write and delete")
+	}
+
+	// The query reads the rest of the shard, including the deleted points.
+	for r := range ch {
+		count(r)
+	}
+	if n != 10 {
+		t.Fatalf("unexpected point count: %d", n)
+	}
+}
+
 // ensure that authenticate doesn't return an error if the user count is zero and they're attempting
 // to create a user.
 func TestAuthenticateIfUserCountZeroAndCreateUser(t *testing.T) {
@@ -324,6 +612,7 @@ func executeAndGetJSON(query string, executor *QueryExecutor) string {
 
 type testMetastore struct {
 	userCount int
+	readOnly  bool
 }
 
 func (t *testMetastore) Database(name string) (*meta.DatabaseInfo, error) {
@@ -387,6 +676,10 @@ func (t *testMetastore) UserCount() (int, error) {
 	return t.userCount, nil
 }
 
+func (t *testMetastore) ReadOnly() (bool, error) { return t.readOnly, nil }
+
+func (t *testMetastore) NodeID() uint64 { return 1 }
+
 // MustParseQuery parses an InfluxQL query. Panic on error.
 func mustParseQuery(s string) *influxql.Query {
 	q, err := influxql.NewParser(strings.NewReader(s)).ParseQuery()
@@ -0,0 +1,63 @@
+package tsdb
+
+import (
+	"sync"
+)
+
+// QueryLimiter limits the number of queries that execute concurrently.
+// The limit can be changed while queries are running. Lowering the limit
+// doesn't interrupt running queries, it only delays new ones.
+type QueryLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+// NewQueryLimiter returns a new limiter. A limit of zero or less allows an
+// unlimited number of queries.
+func NewQueryLimiter(limit int) *QueryLimiter {
+	l := &QueryLimiter{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Acquire blocks until a query can execute.
+func (l *QueryLimiter) Acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.limit > 0 && l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+// Release marks a query as finished.
+func (l *QueryLimiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.cond.Signal()
+}
+
+// Limit returns the current limit.
+func (l *QueryLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// SetLimit changes the limit and wakes any queries that can now execute.
+func (l *QueryLimiter) SetLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.cond.Broadcast()
+}
+
+// Active returns the number of executing queries.
+func (l *QueryLimiter) Active() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active
+}
@@ -0,0 +1,58 @@
+package tsdb
+
+import (
+	"testing"
+	"time"
+)
+
+// Ensure the limiter blocks queries over the limit until one is released
+// or the limit is raised.
+func TestQueryLimiter(t *testing.T) {
+	l := NewQueryLimiter(1)
+	l.Acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		l.Acquire()
+		acquired <- struct{}{}
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired over the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	l.Release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for release")
+	}
+
+	go func() {
+		l.Acquire()
+		acquired <- struct{}{}
+	}()
+	l.SetLimit(2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for raised limit")
+	}
+
+	if n := l.Active(); n != 2 {
+		t.Fatalf("unexpected active count: %d", n)
+	}
+}
+
+// Ensure a limit of zero is unlimited.
+func TestQueryLimiter_Unlimited(t *testing.T) {
+	l := NewQueryLimiter(0)
+	for i := 0; i < 100; i++ {
+		l.Acquire()
+	}
+	if n := l.Active(); n != 100 {
+		t.Fatalf("unexpected active count: %d", n)
+	}
+}
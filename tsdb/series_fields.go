@@ -0,0 +1,207 @@
+package tsdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// The fields written to each series of a measurement are indexed so queries
+// of a sparse field can skip the series that never wrote it. Each shard
+// keeps the fields written to its series in its "seriesfields" bucket. A
+// series only has known fields if every shard holding it has an entry for
+// it; series from shards written before fields were tracked, or rewritten
+// by a migration, may have any field.
+
+// errSeriesFieldsInvalid is returned when a series' fields can't be decoded.
+var errSeriesFieldsInvalid = errors.New("invalid series fields")
+
+// addSeriesFields records that a series has written fields.
+func (m *Measurement) addSeriesFields(id uint64, names []string) {
+	m.mu.RLock()
+	missing := false
+	for _, name := range names {
+		if _, ok := m.seriesByField[name][id]; !ok {
+			missing = true
+			break
+		}
+	}
+	m.mu.RUnlock()
+	if !missing {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range names {
+		ids := m.seriesByField[name]
+		if ids == nil {
+			ids = make(map[uint64]struct{})
+			m.seriesByField[name] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// setSeriesFieldsKnown sets whether every field written to a series has
+// been recorded with addSeriesFields.
+func (m *Measurement) setSeriesFieldsKnown(id uint64, known bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if known {
+		m.fieldsKnown[id] = struct{}{}
+	} else {
+		delete(m.fieldsKnown, id)
+	}
+}
+
+// resetSeriesFields forgets the fields of every series of the measurement.
+func (m *Measurement) resetSeriesFields() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seriesByField = make(map[string]map[uint64]struct{})
+	m.fieldsKnown = make(map[uint64]struct{})
+}
+
+// dropSeriesFields removes a series from the field index. The measurement
+// lock must be held.
+func (m *Measurement) dropSeriesFields(id uint64) {
+	delete(m.fieldsKnown, id)
+	for name, ids := range m.seriesByField {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(m.seriesByField, name)
+		}
+	}
+}
+
+// mayHaveFields returns true if a series may have written any of names.
+// The measurement lock must be held.
+func (m *Measurement) mayHaveFields(id uint64, names []string) bool {
+	if _, ok := m.fieldsKnown[id]; !ok {
+		return true
+	}
+	for _, name := range names {
+		if _, ok := m.seriesByField[name][id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterTagSetByFields returns a copy of a tag set without the series that
+// never wrote any of the given fields.
+func (m *Measurement) filterTagSetByFields(t *influxql.TagSet, names []string) *influxql.TagSet {
+	m.index.mu.RLock()
+	defer m.index.mu.RUnlock()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	other := &influxql.TagSet{Tags: t.Tags, Key: t.Key}
+	for i, key := range t.SeriesKeys {
+		if ss := m.index.series[key]; ss != nil && !m.mayHaveFields(ss.id, names) {
+			continue
+		}
+		other.AddFilter(key, t.Filters[i])
+	}
+	return other
+}
+
+// indexSeriesFields records the encoded fields of a series loaded from a
+// shard in the index. A nil buf means the shard doesn't know them. The
+// index lock must be held.
+func (s *Shard) indexSeriesFields(ss *Series, created bool, buf []byte) error {
+	m := ss.measurement
+	if buf == nil {
+		m.setSeriesFieldsKnown(ss.id, false)
+		return nil
+	}
+
+	names, err := decodeSeriesFields(buf)
+	if err != nil {
+		return err
+	}
+	m.addSeriesFields(ss.id, names)
+
+	// A series already in the index stays unknown if another shard didn't
+	// know its fields.
+	if created {
+		m.setSeriesFieldsKnown(ss.id, true)
+	}
+	return nil
+}
+
+// saveSeriesFields adds the fields written to each series to the shard's
+// seriesfields bucket. Series already in the shard without an entry were
+// written before fields were tracked and are left without one. Returns the
+// series that didn't exist in the shard before.
+func saveSeriesFields(tx *bolt.Tx, fields map[string]map[string]struct{}) (map[string]struct{}, error) {
+	b := tx.Bucket([]byte("seriesfields"))
+	created := make(map[string]struct{})
+	for key, names := range fields {
+		var existing []string
+		if buf := b.Get([]byte(key)); buf != nil {
+			a, err := decodeSeriesFields(buf)
+			if err != nil {
+				return nil, err
+			}
+			existing = a
+		} else if tx.Bucket([]byte(key)) != nil {
+			continue
+		} else {
+			created[key] = struct{}{}
+		}
+
+		// Only rewrite the entry if a field is new.
+		other := make(map[string]struct{}, len(existing)+len(names))
+		for _, name := range existing {
+			other[name] = struct{}{}
+		}
+		for name := range names {
+			other[name] = struct{}{}
+		}
+		if len(other) == len(existing) {
+			continue
+		}
+		if err := b.Put([]byte(key), encodeSeriesFields(other)); err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+// encodeSeriesFields encodes field names as a sorted list of length
+// prefixed names.
+func encodeSeriesFields(names map[string]struct{}) []byte {
+	a := make([]string, 0, len(names))
+	for name := range names {
+		a = append(a, name)
+	}
+	sort.Strings(a)
+
+	var buf []byte
+	tmp := make([]byte, binary.MaxVarintLen64)
+	for _, name := range a {
+		n := binary.PutUvarint(tmp, uint64(len(name)))
+		buf = append(buf, tmp[:n]...)
+		buf = append(buf, name...)
+	}
+	return buf
+}
+
+// decodeSeriesFields decodes field names encoded by encodeSeriesFields.
+func decodeSeriesFields(buf []byte) ([]string, error) {
+	var names []string
+	for len(buf) > 0 {
+		n, i := binary.Uvarint(buf)
+		if i <= 0 || uint64(len(buf)-i) < n {
+			return nil, errSeriesFieldsInvalid
+		}
+		names = append(names, string(buf[i:i+int(n)]))
+		buf = buf[i+int(n):]
+	}
+	return names, nil
+}
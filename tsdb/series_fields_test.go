@@ -0,0 +1,169 @@
+package tsdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// Ensure the index knows which fields each series wrote, including after
+// the shard is reopened, so series without a queried field are skipped.
+func TestShard_SeriesFields(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+		NewPoint("cpu", Tags{"host": "b"}, Fields{"idle": 1.0}, time.Unix(1, 0)),
+		NewPoint("cpu", Tags{"host": "c"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "c"}, Fields{"idle": 1.0}, time.Unix(2, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(idx *DatabaseIndex, exp []string) {
+		m := idx.Measurement("cpu")
+		tagSet := &influxql.TagSet{}
+		for _, key := range []string{"cpu,host=a", "cpu,host=b", "cpu,host=c"} {
+			tagSet.AddFilter(key, nil)
+		}
+		if got := m.filterTagSetByFields(tagSet, []string{"idle"}).SeriesKeys; !reflect.DeepEqual(got, exp) {
+			t.Fatalf("unexpected series: %v", got)
+		}
+	}
+	check(sh.index, []string{"cpu,host=b", "cpu,host=c"})
+
+	// The fields are loaded with the series.
+	sh.Close()
+	sh = NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	check(sh.index, []string{"cpu,host=b", "cpu,host=c"})
+
+	// Series written before fields were tracked may have any field, even
+	// when they're written to again.
+	if err := sh.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("seriesfields")).Delete([]byte("cpu,host=a"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sh.Close()
+	sh = NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 2.0}, time.Unix(2, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	check(sh.index, []string{"cpu,host=a", "cpu,host=b", "cpu,host=c"})
+}
+
+// Ensure field names are encoded in order and decoded back.
+func TestSeriesFields_Encode(t *testing.T) {
+	buf := encodeSeriesFields(map[string]struct{}{"value": {}, "idle": {}, "": {}})
+	if names, err := decodeSeriesFields(buf); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(names, []string{"", "idle", "value"}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+
+	if _, err := decodeSeriesFields(buf[:len(buf)-1]); err != errSeriesFieldsInvalid {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the fields a series wrote to a shard are loaded when the series was
+// created in another shard, including shards written before every series
+// was saved in each shard's series bucket.
+func TestStore_SeriesFields_AcrossShards(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "store_test")
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	} else if err := s.CreateShard("db0", "rp0", 1); err != nil {
+		t.Fatal(err)
+	} else if err := s.CreateShard("db0", "rp0", 2); err != nil {
+		t.Fatal(err)
+	} else if err := s.WriteToShard(1, []Point{NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0))}); err != nil {
+		t.Fatal(err)
+	} else if err := s.WriteToShard(2, []Point{NewPoint("cpu", Tags{"host": "a"}, Fields{"other": 1.0}, time.Unix(2, 0))}); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(s *Store) {
+		m := s.Measurement("db0", "cpu")
+		tagSet := &influxql.TagSet{}
+		tagSet.AddFilter("cpu,host=a", nil)
+		if got := m.filterTagSetByFields(tagSet, []string{"other"}).SeriesKeys; !reflect.DeepEqual(got, []string{"cpu,host=a"}) {
+			t.Fatalf("unexpected series: %v", got)
+		}
+
+		// The series is in the index of the shard it wasn't created in.
+		if v, err := s.VerifyShard(2); err != nil {
+			t.Fatal(err)
+		} else if !v.OK() {
+			t.Fatalf("unexpected problems: %v", v.Problems)
+		}
+	}
+
+	s.Close()
+	s = NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	check(s)
+
+	// Remove the series from the second shard's series bucket as a version 1
+	// shard wouldn't have it.
+	path := s.Shard(2).Path()
+	s.Close()
+	db, err := bolt.Open(path, 0666, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket([]byte("series")).Delete([]byte("cpu,host=a")); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(shardFormatBucket)).Put([]byte("format"), []byte(`{"version":1,"capabilities":["series-index","field-codec"]}`))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s = NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	check(s)
+
+	if err := s.Shard(2).db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte("series")).Get([]byte("cpu,host=a")) == nil {
+			t.Fatal("expected series to be added to the series bucket")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
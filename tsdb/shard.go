@@ -6,9 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/influxdb/influxdb/format"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/tsdb/internal"
 
@@ -16,16 +18,83 @@ import (
 	"github.com/gogo/protobuf/proto"
 )
 
+// ShardFormat is the on-disk format of shards written by this release.
+var ShardFormat = format.Spec{
+	Name:         "shard",
+	Version:      2,
+	Capabilities: []string{"series-index", "field-codec"},
+}
+
+// shardFormatBucket is the bucket holding a shard's format header. Series
+// keys never start with a space, so it can't be mistaken for a series.
+const shardFormatBucket = " format"
+
 // Shard represents a self-contained time series database. An inverted index of the measurement and tag data is
 // kept along with the raw time series data. Data can be split across many shards. The query engine in TSDB
 // is responsible for combining the output of many shards into a single query result.
 type Shard struct {
-	db    *bolt.DB // underlying data store
-	index *DatabaseIndex
-	path  string
+	db       *bolt.DB // underlying data store
+	index    *DatabaseIndex
+	path     string
+	database string // name of the database, set by the store
 
 	mu                sync.RWMutex
 	measurementFields map[string]*measurementFields // measurement name to their fields
+
+	// archiveKey is set once the shard's data has been moved to a ShardArchive.
+	// The data file then only exists locally while the shard is cached.
+	archiveKey string
+	lastAccess time.Time
+
+	// fetchMu serialises downloads of the shard from the archive, which
+	// happen without the shard lock held.
+	fetchMu sync.Mutex
+
+	// pins is the number of ShardPins holding the shard's data. A shard
+	// deleted while pinned has its files removed once it is unpinned.
+	pins    int
+	deleted bool
+
+	// indexTxID is the transaction the last index snapshot was written at.
+	snapshotMu sync.Mutex
+	indexTxID  int
+
+	// deferred is set if the index was loaded from its snapshot and the
+	// data file hasn't been opened yet. deferredTxID is the transaction the
+	// snapshot was written at.
+	deferred     bool
+	deferredTxID int
+
+	// Fields with conflicting types that were coerced or dropped.
+	fieldsCoerced int64
+	fieldsDropped int64
+
+	// Writes and queries since the shard was opened and the nanoseconds
+	// spent on them.
+	writeN, writeNs int64
+	queryN, queryNs int64
+
+	// The number of series data buckets at transaction seriesNTxID of the
+	// data file opened as seriesNDB.
+	statsMu      sync.Mutex
+	seriesNDB    *bolt.DB
+	seriesNTxID  int
+	seriesNCount int
+
+	// seriesFilter holds the keys of the series written to the shard so
+	// queries and deletes can skip shards without the series.
+	seriesFilter *bloomFilter
+
+	// Writes within commitWindow of each other commit in one transaction.
+	commitWindow  time.Duration
+	commitMu      sync.Mutex
+	pendingCommit *commitGroup
+
+	// hotCache, if set, holds recently written points for queries.
+	hotCache *hotCache
+
+	// mmapSize is the smallest size the data file is memory mapped at.
+	mmapSize int
 }
 
 // NewShard returns a new initialized Shard
@@ -40,6 +109,35 @@ func NewShard(index *DatabaseIndex, path string) *Shard {
 // Path returns the path set on the shard when it was created.
 func (s *Shard) Path() string { return s.path }
 
+// DefaultShardMmapSize is the default smallest size each shard's data file is
+// memory mapped at.
+const DefaultShardMmapSize = 32 * 1024 * 1024
+
+// openDB opens the data file at path. The file is memory mapped at twice its
+// size, and at least mmapSize, so writes rarely have to grow the mapping.
+// Growing it waits for every open read transaction, and queries hold one for
+// as long as they run so they see a consistent view of the shard.
+func (s *Shard) openDB(path string) (*bolt.DB, error) {
+	size := s.mmapSize
+	if size > 0 {
+		if fi, err := os.Stat(path); err == nil && 2*fi.Size() > int64(size) {
+			size = int(2 * fi.Size())
+		}
+	}
+	return bolt.Open(path, 0666, &bolt.Options{Timeout: 1 * time.Second, InitialMmapSize: size})
+}
+
+// txID returns the ID of the last transaction committed to the shard's data
+// file. Every write increases it. The shard lock must be held.
+func (s *Shard) txID() (int, error) {
+	var id int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id = tx.ID()
+		return nil
+	})
+	return id, err
+}
+
 // open initializes and opens the shard's store.
 func (s *Shard) Open() error {
 	s.mu.Lock()
@@ -51,24 +149,149 @@ func (s *Shard) Open() error {
 	}
 
 	// Open store on shard.
-	store, err := bolt.Open(s.path, 0666, &bolt.Options{Timeout: 1 * time.Second})
+	store, err := s.openDB(s.path)
 	if err != nil {
 		return err
 	}
 	s.db = store
 
+	// Read the last transaction id before the store is initialized so it
+	// can be checked against the index snapshot.
+	var txID int
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		txID = tx.ID()
+		return nil
+	}); err != nil {
+		_ = s.db.Close()
+		s.db = nil
+		return err
+	}
+
 	// Initialize store.
 	if err := s.db.Update(func(tx *bolt.Tx) error {
 		_, _ = tx.CreateBucketIfNotExists([]byte("series"))
 		_, _ = tx.CreateBucketIfNotExists([]byte("fields"))
+		_, _ = tx.CreateBucketIfNotExists([]byte("seriesfields"))
 
-		return nil
+		return upgradeShardFormat(tx)
 	}); err != nil {
-		_ = s.Close()
+		// Close the store directly as the shard lock is already held.
+		_ = s.db.Close()
+		s.db = nil
 		return fmt.Errorf("init: %s", err)
 	}
 
-	return s.loadMetadataIndex()
+	// Load the index and series filter from the snapshot if the data hasn't
+	// changed since it was written. Otherwise fall back to scanning the data
+	// file. A deferred shard already loaded its snapshot and only scans if it
+	// was stale.
+	if s.deferred {
+		s.deferred = false
+		if txID == s.deferredTxID {
+			return nil
+		}
+	} else if err := s.loadIndexSnapshot(txID); err == nil {
+		return nil
+	}
+	if err := s.loadMetadataIndex(); err != nil {
+		return err
+	}
+	return s.loadSeriesFilter()
+}
+
+// upgradeShardFormat checks the format header of the shard and stamps it
+// with the current version. Shards written before headers existed are
+// treated as version 0.
+func upgradeShardFormat(tx *bolt.Tx) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(shardFormatBucket))
+	if err != nil {
+		return err
+	}
+
+	// Headers used to be kept in a "meta" bucket, which is also the bucket
+	// of a measurement named meta without tags. Point keys are 8 bytes so
+	// the header can't be one of its points.
+	if old := tx.Bucket([]byte("meta")); old != nil {
+		if buf := old.Get([]byte("format")); buf != nil {
+			if err := b.Put([]byte("format"), buf); err != nil {
+				return err
+			} else if err := old.Delete([]byte("format")); err != nil {
+				return err
+			}
+			if k, _ := old.Cursor().First(); k == nil {
+				if err := tx.DeleteBucket([]byte("meta")); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	var hdr format.Header
+	if buf := b.Get([]byte("format")); buf != nil {
+		if err := hdr.UnmarshalBinary(buf); err != nil {
+			return fmt.Errorf("read format: %s", err)
+		}
+	}
+
+	// Version 1 shards only listed the series created in them in their
+	// series bucket, not series created in other shards first.
+	if hdr.Version < 2 {
+		if err := indexShardSeries(tx); err != nil {
+			return fmt.Errorf("index series: %s", err)
+		}
+	}
+
+	hdr, changed, err := ShardFormat.Upgrade(hdr)
+	if err != nil {
+		return err
+	} else if !changed {
+		return nil
+	}
+
+	buf, err := hdr.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte("format"), buf)
+}
+
+// shardFormatVersion returns the format version of the shard. Shards
+// written before headers existed are version 0.
+func shardFormatVersion(tx *bolt.Tx) (int, error) {
+	var hdr format.Header
+	if b := tx.Bucket([]byte(shardFormatBucket)); b == nil {
+		return 0, nil
+	} else if buf := b.Get([]byte("format")); buf == nil {
+		return 0, nil
+	} else if err := hdr.UnmarshalBinary(buf); err != nil {
+		return 0, fmt.Errorf("read format: %s", err)
+	}
+	return hdr.Version, nil
+}
+
+// indexShardSeries adds an entry to the series bucket for every data bucket
+// without one.
+func indexShardSeries(tx *bolt.Tx) error {
+	b := tx.Bucket([]byte("series"))
+	var keys [][]byte
+	if err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if !isShardMetaBucket(name) && b.Get(name) == nil {
+			keys = append(keys, append([]byte(nil), name...))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		data, err := newSeriesFromKey(k).MarshalBinary()
+		if err != nil {
+			return err
+		} else if err := b.Put(k, data); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // close shuts down the shard's store.
@@ -76,6 +299,8 @@ func (s *Shard) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// A closed shard is never opened on access.
+	s.deferred = false
 	if s.db != nil {
 		_ = s.db.Close()
 	}
@@ -114,61 +339,175 @@ type seriesCreate struct {
 
 // WritePoints will write the raw data points and any new metadata to the index in the shard
 func (s *Shard) WritePoints(points []Point) error {
-	seriesToCreate, fieldsToCreate, err := s.validateSeriesAndFields(points)
+	return s.writePoints(points, 0, 0, DefaultSeriesKeyLimits)
+}
+
+// writePoints writes points like WritePoints but drops the points that would
+// create series over maxSeriesN in the database. A *PartialWriteError listing
+// the dropped points is returned if any are dropped. The whole write is
+// rejected if it would give a tag key more than maxValuesN values, or create
+// a series over keyLimits. Zero means no limit.
+func (s *Shard) writePoints(points []Point, maxSeriesN, maxValuesN int, keyLimits SeriesKeyLimits) error {
+	defer func(start time.Time) { s.addWrite(time.Since(start)) }(time.Now())
+
+	points = s.resolveFieldConflicts(points)
+
+	seriesToCreate, fieldsToCreate, err := s.validateSeriesAndFields(points, keyLimits)
 	if err != nil {
 		return err
 	}
 
 	// add any new series to the in-memory index
+	var dropped []Point
+	var created []*Series
 	if len(seriesToCreate) > 0 {
 		s.index.mu.Lock()
+
+		// The limits are checked under the index lock so concurrent writes
+		// can't go over them. Series created since validation aren't new.
+		if maxValuesN > 0 {
+			if err := s.index.checkValuesPerTag(seriesToCreate, maxValuesN); err != nil {
+				s.index.mu.Unlock()
+				return err
+			}
+		}
+		if maxSeriesN > 0 {
+			points, dropped = s.index.limitSeries(points, maxSeriesN)
+
+			droppedKeys := make(map[string]struct{}, len(dropped))
+			for _, p := range dropped {
+				droppedKeys[string(p.Key())] = struct{}{}
+			}
+			a := seriesToCreate[:0]
+			for _, ss := range seriesToCreate {
+				if _, ok := droppedKeys[ss.series.Key]; !ok {
+					a = append(a, ss)
+				}
+			}
+			seriesToCreate = a
+		}
+
 		for _, ss := range seriesToCreate {
-			s.index.createSeriesIndexIfNotExists(ss.measurement, ss.series)
+			if s.index.createSeriesIndexIfNotExists(ss.measurement, ss.series) == ss.series {
+				created = append(created, ss.series)
+			}
 		}
 		s.index.mu.Unlock()
 	}
 
+	// Nothing is left to write if every point was dropped.
+	if len(points) == 0 && len(dropped) > 0 {
+		return &PartialWriteError{Reason: ErrMaxSeriesPerDatabaseExceeded, Dropped: dropped}
+	}
+
 	// add any new fields and keep track of what needs to be saved
 	measurementFieldsToSave, err := s.createFieldsAndMeasurements(fieldsToCreate)
 	if err != nil {
 		return err
 	}
 
-	// make sure all data is encoded before attempting to save to bolt
+	// make sure all data is encoded before attempting to save to bolt, and
+	// note the fields written to each series
+	seriesFields := make(map[string]map[string]struct{})
 	for _, p := range points {
+		// this was populated earlier, don't need to validate that it's there.
+		s.mu.RLock()
+		mf := s.measurementFields[p.Name()]
+		s.mu.RUnlock()
+
 		// marshal the raw data if it hasn't been marshaled already
+		var fields Fields
 		if p.Data() == nil {
-			// this was populated earlier, don't need to validate that it's there.
-			s.mu.RLock()
-			mf := s.measurementFields[p.Name()]
-			s.mu.RUnlock()
-			data, err := mf.codec.EncodeFields(p.Fields())
+			fields = p.Fields()
+			data, err := mf.codec.EncodeFields(fields)
 			if err != nil {
 				return err
 			}
 			p.SetData(data)
+		} else if fields, err = mf.codec.DecodeFieldsWithNames(p.Data()); err != nil {
+			return err
+		}
+
+		names := seriesFields[string(p.Key())]
+		if names == nil {
+			names = make(map[string]struct{}, len(fields))
+			seriesFields[string(p.Key())] = names
+		}
+		for name := range fields {
+			names[name] = struct{}{}
 		}
 	}
 
-	// save to the underlying bolt instance
-	if err := s.db.Update(func(tx *bolt.Tx) error {
-		// save any new metadata
-		if len(seriesToCreate) > 0 {
-			b := tx.Bucket([]byte("series"))
+	// Fields are added to the index before they're written so queries
+	// never skip a series with the fields.
+	s.index.mu.RLock()
+	for key, names := range seriesFields {
+		if ss := s.index.series[key]; ss != nil {
+			a := make([]string, 0, len(names))
+			for name := range names {
+				a = append(a, name)
+			}
+			ss.measurement.addSeriesFields(ss.id, a)
+		}
+	}
+	s.index.mu.RUnlock()
+
+	// save to the underlying bolt instance, noting where the data file ends
+	// for each series that isn't in the hot cache yet
+	var mins map[string]uint64
+	var gen uint64
+	var newSeries map[string]struct{}
+	if err := s.update(func(tx *bolt.Tx) error {
+		if s.hotCache != nil {
+			mins = make(map[string]uint64)
+			gen = s.hotCache.generation()
+		}
+
+		// save the fields written to each series before their buckets are
+		// created, noting the series new to the shard
+		var err error
+		if newSeries, err = saveSeriesFields(tx, seriesFields); err != nil {
+			return err
+		}
+
+		// save any new metadata. Series created in another shard are saved
+		// too so the series bucket lists every series in the shard.
+		if len(seriesToCreate) > 0 || len(newSeries) > 0 {
+			series := make(map[string]*Series, len(seriesToCreate)+len(newSeries))
 			for _, sc := range seriesToCreate {
-				data, err := sc.series.MarshalBinary()
+				series[sc.series.Key] = sc.series
+			}
+			for _, p := range points {
+				key := string(p.Key())
+				if _, ok := newSeries[key]; ok && series[key] == nil {
+					series[key] = &Series{Key: key, Tags: p.Tags()}
+				}
+			}
+
+			b := tx.Bucket([]byte("series"))
+			for key, ss := range series {
+				data, err := ss.MarshalBinary()
 				if err != nil {
 					return err
 				}
-				if err := b.Put([]byte(sc.series.Key), data); err != nil {
+				if err := b.Put([]byte(key), data); err != nil {
 					return err
 				}
 			}
 		}
 		if len(measurementFieldsToSave) > 0 {
+			// Save the current fields, which a migration may have replaced
+			// since they were created.
 			b := tx.Bucket([]byte("fields"))
-			for name, m := range measurementFieldsToSave {
-				data, err := m.MarshalBinary()
+			for name := range measurementFieldsToSave {
+				s.mu.RLock()
+				mf := s.measurementFields[name]
+				if mf == nil {
+					s.mu.RUnlock()
+					continue
+				}
+				data, err := mf.MarshalBinary()
+				s.mu.RUnlock()
 				if err != nil {
 					return err
 				}
@@ -184,6 +523,17 @@ func (s *Shard) WritePoints(points []Point) error {
 			if err != nil {
 				return err
 			}
+			bp.FillPercent = fillPercent(s.index.patterns[p.Name()])
+			if mins != nil && s.index.patterns[p.Name()] != IngestSparse {
+				key := string(p.Key())
+				if _, ok := mins[key]; !ok && !s.hotCache.has(key) {
+					if k, _ := bp.Cursor().Last(); k != nil {
+						mins[key] = btou64(k) + 1
+					} else {
+						mins[key] = 0
+					}
+				}
+			}
 			if err := bp.Put(u64tob(uint64(p.UnixNano())), p.Data()); err != nil {
 				return err
 			}
@@ -195,6 +545,24 @@ func (s *Shard) WritePoints(points []Point) error {
 		return err
 	}
 
+	// Every field of a series new to the index and the shard is recorded.
+	for _, ss := range created {
+		if _, ok := newSeries[ss.Key]; ok {
+			ss.measurement.setSeriesFieldsKnown(ss.id, true)
+		}
+	}
+
+	if s.hotCache != nil {
+		s.hotCache.add(points, mins, gen)
+	}
+
+	if err := s.addToSeriesFilter(newSeries); err != nil {
+		return err
+	}
+
+	if len(dropped) > 0 {
+		return &PartialWriteError{Reason: ErrMaxSeriesPerDatabaseExceeded, Dropped: dropped}
+	}
 	return nil
 }
 
@@ -203,7 +571,7 @@ func (s *Shard) ValidateAggregateFieldsInStatement(measurementName string, stmt
 	defer s.mu.RUnlock()
 
 	validateType := func(aname, fname string, t influxql.DataType) error {
-		if t != influxql.Float && t != influxql.Integer {
+		if t != influxql.Float && t != influxql.Integer && t != influxql.Unsigned {
 			return fmt.Errorf("aggregate '%s' requires numerical field values. Field '%s' is of type %s",
 				aname, fname, t)
 		}
@@ -252,22 +620,132 @@ func (s *Shard) ValidateAggregateFieldsInStatement(measurementName string, stmt
 
 // deleteSeries deletes the buckets and the metadata for the given series keys
 func (s *Shard) deleteSeries(keys []string) error {
+	if !s.mayContainSeries(keys) {
+		return nil
+	}
+
 	if err := s.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte("series"))
+		sf := tx.Bucket([]byte("seriesfields"))
 		for _, k := range keys {
 			if err := b.Delete([]byte(k)); err != nil {
 				return err
+			} else if err := sf.Delete([]byte(k)); err != nil {
+				return err
 			}
-			if err := tx.DeleteBucket([]byte(k)); err != nil {
+			// The series may not have been written to this shard.
+			if err := tx.DeleteBucket([]byte(k)); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		_ = s.Close()
+		return err
+	}
+	if s.hotCache != nil {
+		s.hotCache.remove(keys...)
+	}
+
+	return nil
+}
+
+// deleteRange deletes the points of the given series keys between min and max, inclusive.
+func (s *Shard) deleteRange(keys []string, min, max int64) error {
+	if !s.mayContainSeries(keys) {
+		return nil
+	}
+
+	// Only start a write, which syncs the data file, if there are points to
+	// delete.
+	var found bool
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		for _, k := range keys {
+			if b := tx.Bucket([]byte(k)); b != nil {
+				if err := forEachInRange(b, min, max, func(_, _ []byte) error {
+					found = true
+					return errStopIteration
+				}); err != nil && err != errStopIteration {
+					return err
+				}
+			}
+			if found {
+				return nil
+			}
+		}
+		return nil
+	}); err != nil || !found {
+		return err
+	}
+
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, k := range keys {
+			b := tx.Bucket([]byte(k))
+			if b == nil {
+				continue
+			}
+
+			var timestamps [][]byte
+			if err := forEachInRange(b, min, max, func(tk, _ []byte) error {
+				timestamps = append(timestamps, append([]byte(nil), tk...))
+				return nil
+			}); err != nil {
 				return err
 			}
+
+			for _, tk := range timestamps {
+				if err := b.Delete(tk); err != nil {
+					return err
+				}
+			}
 		}
 		return nil
 	}); err != nil {
 		_ = s.Close()
 		return err
 	}
+	if s.hotCache != nil {
+		s.hotCache.remove(keys...)
+	}
+
+	return nil
+}
 
+// errStopIteration is returned by a forEachInRange callback to stop early.
+var errStopIteration = errors.New("stop iteration")
+
+// forEachInRange calls fn for each point of a series bucket between min and
+// max, inclusive, in time order. Timestamps are stored as unsigned integers so
+// negative times sort after positive ones and each range is scanned separately.
+func forEachInRange(b *bolt.Bucket, min, max int64, fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	scan := func(from, to int64) error {
+		for k, v := c.Seek(u64tob(uint64(from))); k != nil; k, v = c.Next() {
+			if t := int64(btou64(k)); t > to || (from >= 0 && t < 0) {
+				break
+			}
+			if err := fn(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if min < 0 {
+		to := int64(-1)
+		if max < to {
+			to = max
+		}
+		if err := scan(min, to); err != nil {
+			return err
+		}
+	}
+	if max >= 0 {
+		from := int64(0)
+		if min > from {
+			from = min
+		}
+		return scan(from, max)
+	}
 	return nil
 }
 
@@ -279,9 +757,12 @@ func (s *Shard) deleteMeasurement(name string, seriesKeys []string) error {
 			return err
 		}
 		b := tx.Bucket([]byte("series"))
+		sf := tx.Bucket([]byte("seriesfields"))
 		for _, k := range seriesKeys {
 			if err := b.Delete([]byte(k)); err != nil {
 				return err
+			} else if err := sf.Delete([]byte(k)); err != nil {
+				return err
 			}
 			if err := tx.DeleteBucket([]byte(k)); err != nil {
 				return err
@@ -293,6 +774,9 @@ func (s *Shard) deleteMeasurement(name string, seriesKeys []string) error {
 		_ = s.Close()
 		return err
 	}
+	if s.hotCache != nil {
+		s.hotCache.remove(seriesKeys...)
+	}
 
 	// Remove entry from shard index.
 	s.mu.Lock()
@@ -340,7 +824,7 @@ func (s *Shard) createFieldsAndMeasurements(fieldsToCreate []*fieldCreate) (map[
 }
 
 // validateSeriesAndFields checks which series and fields are new and whose metadata should be saved and indexed
-func (s *Shard) validateSeriesAndFields(points []Point) ([]*seriesCreate, []*fieldCreate, error) {
+func (s *Shard) validateSeriesAndFields(points []Point, keyLimits SeriesKeyLimits) ([]*seriesCreate, []*fieldCreate, error) {
 	var seriesToCreate []*seriesCreate
 	var fieldsToCreate []*fieldCreate
 
@@ -355,6 +839,9 @@ func (s *Shard) validateSeriesAndFields(points []Point) ([]*seriesCreate, []*fie
 	for _, p := range points {
 		// see if the series should be added to the index
 		if ss := s.index.series[string(p.Key())]; ss == nil {
+			if err := validateKey(p.Key(), keyLimits); err != nil {
+				return nil, nil, err
+			}
 			series := &Series{Key: string(p.Key()), Tags: p.Tags()}
 			seriesToCreate = append(seriesToCreate, &seriesCreate{p.Name(), series})
 		}
@@ -396,32 +883,52 @@ func (s *Shard) loadMetadataIndex() error {
 		meta := tx.Bucket([]byte("fields"))
 		c := meta.Cursor()
 		for k, v := c.First(); k != nil; k, v = c.Next() {
-			m := s.index.createMeasurementIndexIfNotExists(string(k))
-			mf := &measurementFields{}
-			if err := mf.UnmarshalBinary(v); err != nil {
+			if err := s.indexMeasurementFields(string(k), v); err != nil {
 				return err
 			}
-			for name, _ := range mf.Fields {
-				m.fieldNames[name] = struct{}{}
-			}
-			mf.codec = newFieldCodec(mf.Fields)
-			s.measurementFields[string(k)] = mf
 		}
 
-		// load series metadata
+		// load series metadata and the fields written to each series
 		meta = tx.Bucket([]byte("series"))
+		sf := tx.Bucket([]byte("seriesfields"))
 		c = meta.Cursor()
 		for k, v := c.First(); k != nil; k, v = c.Next() {
-			series := &Series{}
-			if err := series.UnmarshalBinary(v); err != nil {
+			if err := s.indexSeries(string(k), v, sf.Get(k)); err != nil {
 				return err
 			}
-			s.index.createSeriesIndexIfNotExists(measurementFromSeriesKey(string(k)), series)
 		}
 		return nil
 	})
 }
 
+// indexMeasurementFields adds the encoded fields of a measurement to the shard
+// and the index. The index lock must be held.
+func (s *Shard) indexMeasurementFields(name string, buf []byte) error {
+	m := s.index.createMeasurementIndexIfNotExists(name)
+	mf := &measurementFields{}
+	if err := mf.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+	for f := range mf.Fields {
+		m.fieldNames[f] = struct{}{}
+	}
+	mf.codec = newFieldCodec(mf.Fields)
+	s.measurementFields[name] = mf
+	return nil
+}
+
+// indexSeries adds an encoded series and its encoded fields to the index. A
+// nil fields means the fields written to the series aren't known. The index
+// lock must be held.
+func (s *Shard) indexSeries(key string, buf, fields []byte) error {
+	series := &Series{}
+	if err := series.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+	ss := s.index.createSeriesIndexIfNotExists(measurementFromSeriesKey(key), series)
+	return s.indexSeriesFields(ss, ss == series, fields)
+}
+
 type measurementFields struct {
 	Fields map[string]*field `json:"fields"`
 	codec  *FieldCodec
@@ -550,6 +1057,9 @@ func (f *FieldCodec) EncodeFields(values map[string]interface{}) ([]byte, error)
 			}
 			buf = make([]byte, 9)
 			binary.BigEndian.PutUint64(buf[1:9], value)
+		case influxql.Unsigned:
+			buf = make([]byte, 9)
+			binary.BigEndian.PutUint64(buf[1:9], v.(uint64))
 		case influxql.Boolean:
 			value := v.(bool)
 
@@ -625,6 +1135,9 @@ func (f *FieldCodec) decodeByID(targetID uint8, b []byte) (interface{}, error) {
 		case influxql.Integer:
 			value = int64(binary.BigEndian.Uint64(b[1:9]))
 			b = b[9:]
+		case influxql.Unsigned:
+			value = binary.BigEndian.Uint64(b[1:9])
+			b = b[9:]
 		case influxql.Boolean:
 			if b[1] == 1 {
 				value = true
@@ -683,6 +1196,10 @@ func (f *FieldCodec) DecodeFields(b []byte) (map[uint8]interface{}, error) {
 			value = int64(binary.BigEndian.Uint64(b[1:9]))
 			// Move bytes forward.
 			b = b[9:]
+		case influxql.Unsigned:
+			value = binary.BigEndian.Uint64(b[1:9])
+			// Move bytes forward.
+			b = b[9:]
 		case influxql.Boolean:
 			if b[1] == 1 {
 				value = true
@@ -756,6 +1273,9 @@ func (f *FieldCodec) DecodeByID(targetID uint8, b []byte) (interface{}, error) {
 		case influxql.Integer:
 			value = int64(binary.BigEndian.Uint64(b[1:9]))
 			b = b[9:]
+		case influxql.Unsigned:
+			value = binary.BigEndian.Uint64(b[1:9])
+			b = b[9:]
 		case influxql.Boolean:
 			if b[1] == 1 {
 				value = true
@@ -826,4 +1346,31 @@ var (
 	// ErrFieldUnmappedID is returned when the system is presented, during decode, with a field ID
 	// there is no mapping for.
 	ErrFieldUnmappedID = errors.New("field ID not mapped")
+
+	// ErrMaxSeriesPerDatabaseExceeded is the reason points are dropped when
+	// they would create more series than the database is allowed.
+	ErrMaxSeriesPerDatabaseExceeded = errors.New("max series per database exceeded")
+
+	// ErrMaxValuesPerTagExceeded is returned when a write would give a tag
+	// key more values than allowed.
+	ErrMaxValuesPerTagExceeded = errors.New("max values per tag exceeded")
+
+	// ErrMaxPointsPerBatchExceeded is returned when a write has more points
+	// than a single batch is allowed.
+	ErrMaxPointsPerBatchExceeded = errors.New("max points per batch exceeded")
+
+	// ErrInvalidSeriesKey is returned when a point's series key or tags are
+	// longer than allowed or it has too many tags.
+	ErrInvalidSeriesKey = errors.New("invalid series key")
 )
+
+// PartialWriteError is returned when some of the points of a write are
+// dropped. The other points are written.
+type PartialWriteError struct {
+	Reason  error
+	Dropped []Point
+}
+
+func (e *PartialWriteError) Error() string {
+	return fmt.Sprintf("partial write: %s: dropped %d points, first %q", e.Reason, len(e.Dropped), e.Dropped[0].Key())
+}
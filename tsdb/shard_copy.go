@@ -0,0 +1,96 @@
+package tsdb
+
+import (
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ExportShard writes a consistent copy of a shard's data file to w. Writes to
+// the shard are allowed while the copy is made. The shard is pinned for the
+// duration of the copy, up to the store's ShardPinTimeout.
+func (s *Store) ExportShard(shardID uint64, w io.Writer) error {
+	p, err := s.PinShard(shardID, 0)
+	if err != nil {
+		return err
+	}
+	defer p.Release()
+
+	_, err = p.WriteTo(w)
+	return err
+}
+
+// ImportShard creates a shard from a data file written by ExportShard and
+// adds its series and fields to the database index. If the shard already
+// exists, the points in the data file are written to it instead, so importing
+// the same data again is harmless and importing a newer copy catches the
+// shard up.
+func (s *Store) ImportShard(database, retentionPolicy string, shardID uint64, r io.Reader) error {
+	dir := filepath.Join(s.path, database, retentionPolicy)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, strconv.FormatUint(shardID, 10))
+
+	// Copy to a temporary file so a partial copy is never opened.
+	tmppath := path + ".import"
+	f, err := os.Create(tmppath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmppath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmppath)
+		return err
+	}
+
+	s.mu.Lock()
+	if _, ok := s.shards[shardID]; ok {
+		s.mu.Unlock()
+		defer os.Remove(tmppath)
+		return s.mergeShardFile(shardID, tmppath)
+	}
+	defer s.mu.Unlock()
+	if err := os.Rename(tmppath, path); err != nil {
+		return err
+	}
+
+	db, ok := s.databaseIndexes[database]
+	if !ok {
+		db = s.newDatabaseIndex(database)
+		s.databaseIndexes[database] = db
+	}
+
+	sh := s.newShard(database, db, path)
+	if err := sh.Open(); err != nil {
+		return err
+	}
+	s.shards[shardID] = sh
+	return nil
+}
+
+// mergeShardFile writes the points of the shard data file at path to an
+// existing shard.
+func (s *Store) mergeShardFile(shardID uint64, path string) error {
+	src := NewShard(NewDatabaseIndex(), path)
+	if err := src.Open(); err != nil {
+		return err
+	}
+	defer src.Close()
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		_, err := src.ExportPoints(pw, math.MinInt64, math.MaxInt64)
+		pw.CloseWithError(err)
+	}()
+
+	_, err := s.WriteToShardFrom(shardID, pr, "n", DefaultImportBatchSize)
+	return err
+}
@@ -0,0 +1,103 @@
+package tsdb
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// openShardDeferred loads the index of a shard from its index snapshot
+// without opening its data file, so a store with many shards can accept
+// writes and queries soon after it opens. The data file is opened on first
+// access. Shards without a readable snapshot, and archived shards, are
+// opened as usual.
+func openShardDeferred(sh *Shard) error {
+	if _, err := os.Stat(sh.path + ArchiveExt); err == nil {
+		return openShard(sh)
+	}
+
+	txID, err := indexSnapshotTxID(sh.path)
+	if err != nil {
+		return openShard(sh)
+	}
+
+	sh.mu.Lock()
+	if err = sh.loadIndexSnapshot(txID); err == nil {
+		sh.deferred = true
+		sh.deferredTxID = txID
+	}
+	sh.mu.Unlock()
+
+	// Anything loaded from a snapshot that can't be read is loaded again
+	// when the data file is scanned.
+	if err != nil {
+		return sh.Open()
+	}
+	return nil
+}
+
+// openDeferred opens the data file of a shard opened by openShardDeferred.
+// It does nothing if the data file is already open.
+func (s *Shard) openDeferred() error {
+	s.mu.RLock()
+	deferred := s.deferred
+	s.mu.RUnlock()
+	if !deferred {
+		return nil
+	}
+	return s.Open()
+}
+
+// warmShards opens the data files of deferred shards in the background,
+// newest first as they're the most likely to be written to, until every
+// shard is open or closing is closed.
+func (s *Store) warmShards(closing <-chan struct{}) {
+	defer s.wg.Done()
+
+	s.mu.RLock()
+	ids := make([]uint64, 0, len(s.shards))
+	for id := range s.shards {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+	sort.Sort(sort.Reverse(uint64Slice(ids)))
+
+	start := time.Now()
+	var n int
+	for _, id := range ids {
+		select {
+		case <-closing:
+			return
+		default:
+		}
+
+		// Hold the store lock so the shard can't be deleted and its data
+		// file recreated by the open.
+		s.mu.RLock()
+		sh := s.shards[id]
+		var err error
+		var opened bool
+		if sh != nil {
+			sh.mu.RLock()
+			opened = sh.deferred
+			sh.mu.RUnlock()
+			err = sh.openDeferred()
+		}
+		s.mu.RUnlock()
+
+		if err != nil {
+			s.Logger.Printf("failed to open shard %d: %s", id, err)
+		} else if opened {
+			n++
+		}
+	}
+	if n > 0 {
+		s.Logger.Printf("opened %d deferred shards in %s", n, time.Since(start))
+	}
+}
+
+type uint64Slice []uint64
+
+func (a uint64Slice) Len() int           { return len(a) }
+func (a uint64Slice) Less(i, j int) bool { return a[i] < a[j] }
+func (a uint64Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
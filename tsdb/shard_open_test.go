@@ -0,0 +1,91 @@
+package tsdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Ensure a deferred shard loads its index from its snapshot and rescans its
+// data file when it's opened if the snapshot was stale.
+func TestShard_OpenDeferred(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shard_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "1")
+
+	sh := NewShard(NewDatabaseIndex(), path)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("Shard.Open() failed: %v", err)
+	}
+	if err := sh.WritePoints([]Point{NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0))}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if _, err := sh.writeIndexSnapshot(); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	} else if err := sh.WritePoints([]Point{NewPoint("cpu", Tags{"host": "b"}, Fields{"value": 1.0}, time.Unix(1, 0))}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+	sh.Close()
+
+	index := NewDatabaseIndex()
+	sh = NewShard(index, path)
+	if err := openShardDeferred(sh); err != nil {
+		t.Fatalf("openShardDeferred() failed: %v", err)
+	} else if !sh.deferred || sh.db != nil {
+		t.Fatal("expected data file not to be opened")
+	} else if index.series["cpu,host=a"] == nil || index.series["cpu,host=b"] != nil {
+		t.Fatal("expected series from snapshot")
+	} else if !sh.mayContainSeries([]string{"cpu,host=a"}) || sh.mayContainSeries([]string{"cpu,host=c"}) {
+		t.Fatal("expected series filter from snapshot")
+	}
+	defer sh.Close()
+
+	if err := sh.openDeferred(); err != nil {
+		t.Fatalf("Shard.openDeferred() failed: %v", err)
+	} else if sh.deferred || sh.db == nil {
+		t.Fatal("expected data file to be opened")
+	} else if index.series["cpu,host=b"] == nil {
+		t.Fatal("expected series written after snapshot")
+	} else if !sh.mayContainSeries([]string{"cpu,host=b"}) {
+		t.Fatal("expected series filter to be rebuilt")
+	}
+}
+
+// Ensure a store opened lazily can be written to before its shards are warm.
+func TestStore_LazyShardOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	} else if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	} else if err := s.WriteToShard(1, []Point{NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0))}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if err := s.Close(); err != nil {
+		t.Fatalf("Store.Close() failed: %v", err)
+	}
+
+	s = NewStore(dir)
+	s.LazyShardOpen = true
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+
+	if m := s.Measurement("mydb", "cpu"); m == nil || len(m.SeriesKeys()) != 1 {
+		t.Fatalf("unexpected measurement: %v", m)
+	} else if err := s.WriteToShard(1, []Point{NewPoint("cpu", Tags{"host": "b"}, Fields{"value": 1.0}, time.Unix(2, 0))}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if n := len(s.Measurement("mydb", "cpu").SeriesKeys()); n != 2 {
+		t.Fatalf("unexpected series count: %d", n)
+	}
+}
@@ -0,0 +1,202 @@
+package tsdb
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// DefaultShardPinTimeout is the default time a shard stays pinned for an
+// export before the pin is released automatically.
+const DefaultShardPinTimeout = 1 * time.Hour
+
+var (
+	// ErrShardPinned is returned when evicting a shard that is pinned.
+	ErrShardPinned = errors.New("shard pinned")
+
+	// ErrShardPinReleased is returned when reading from a released pin.
+	ErrShardPinReleased = errors.New("shard pin released")
+
+	// ErrShardPinExpired is returned when a read outlives its pin's timeout.
+	ErrShardPinExpired = errors.New("shard pin expired")
+)
+
+// ShardPin holds a consistent view of a shard's data for a long-running read,
+// such as an export or a backup. While a shard is pinned its data file isn't
+// removed: deleting the shard takes effect once the last pin is released and
+// archived shards aren't evicted. Writes continue as normal.
+//
+// A pin is released automatically after its timeout so an abandoned read
+// can't hold the shard's files indefinitely. Reads in progress then fail
+// with ErrShardPinExpired.
+type ShardPin struct {
+	mu      sync.Mutex
+	tx      *bolt.Tx
+	timer   *time.Timer
+	expired int32 // atomic
+
+	shard *Shard
+	store *Store
+}
+
+// PinShard pins a shard for reading until the pin is released or timeout
+// passes. A zero timeout uses the store's ShardPinTimeout.
+func (s *Store) PinShard(shardID uint64, timeout time.Duration) (*ShardPin, error) {
+	sh, err := s.FetchShard(shardID)
+	if err != nil {
+		return nil, err
+	} else if sh == nil {
+		return nil, ErrShardNotFound
+	}
+
+	// Hold the store lock so the shard can't be deleted before it's pinned.
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.shards[shardID] != sh {
+		return nil, ErrShardNotFound
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.db == nil {
+		return nil, ErrShardNotFound
+	}
+	tx, err := sh.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	sh.pins++
+
+	if timeout <= 0 {
+		timeout = s.ShardPinTimeout
+	}
+	p := &ShardPin{tx: tx, shard: sh, store: s}
+	p.timer = time.AfterFunc(timeout, p.expire)
+	return p, nil
+}
+
+// Size returns the size of the pinned view of the shard's data file, in bytes.
+func (p *ShardPin) Size() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tx == nil {
+		return 0
+	}
+	return p.tx.Size()
+}
+
+// WriteTo writes the pinned view of the shard's data file to w.
+func (p *ShardPin) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tx == nil {
+		return 0, p.err()
+	}
+
+	n, err := p.tx.WriteTo(&pinWriter{w: w, pin: p})
+	if err == nil && atomic.LoadInt32(&p.expired) == 1 {
+		err = ErrShardPinExpired
+	}
+	return n, err
+}
+
+// Release releases the pin. Deleting the shard completes once it has no pins.
+func (p *ShardPin) Release() error {
+	p.timer.Stop()
+	return p.release()
+}
+
+// Close releases the pin so it can be used as an io.Closer.
+func (p *ShardPin) Close() error { return p.Release() }
+
+// expire releases the pin once its timeout passes. A write in progress is
+// interrupted first so the pin can be released.
+func (p *ShardPin) expire() {
+	atomic.StoreInt32(&p.expired, 1)
+	if err := p.release(); err != nil {
+		p.store.Logger.Printf("failed to release expired pin on shard %s: %s", p.shard.path, err)
+	}
+}
+
+func (p *ShardPin) release() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tx == nil {
+		return nil
+	}
+
+	err := p.tx.Rollback()
+	p.tx = nil
+	p.store.unpinShard(p.shard)
+	return err
+}
+
+// err returns the error for reading from a released pin.
+func (p *ShardPin) err() error {
+	if atomic.LoadInt32(&p.expired) == 1 {
+		return ErrShardPinExpired
+	}
+	return ErrShardPinReleased
+}
+
+// pinWriter stops a write once its pin has expired.
+type pinWriter struct {
+	w   io.Writer
+	pin *ShardPin
+}
+
+func (w *pinWriter) Write(b []byte) (int, error) {
+	if atomic.LoadInt32(&w.pin.expired) == 1 {
+		return 0, ErrShardPinExpired
+	}
+	return w.w.Write(b)
+}
+
+// beginRead starts a read transaction on a shard for a query. The shard is
+// pinned until the transaction is finished with endRead, so its files are
+// not evicted, moved or removed while the query reads them. Writes and
+// deletes don't wait for the query, which sees the shard as it was when the
+// transaction began.
+func (s *Store) beginRead(sh *Shard) (*bolt.Tx, error) {
+	// The shard may have been evicted since the query fetched it.
+	if err := sh.fetch(s.Archive); err != nil {
+		return nil, err
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.db == nil {
+		return nil, ErrShardNotFound
+	}
+	tx, err := sh.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	sh.pins++
+	return tx, nil
+}
+
+// endRead finishes a read transaction started by beginRead and unpins the shard.
+func (s *Store) endRead(sh *Shard, tx *bolt.Tx) {
+	_ = tx.Rollback()
+	s.unpinShard(sh)
+}
+
+// unpinShard removes a pin from a shard. The shard's files are removed if it
+// was deleted while pinned and this was its last pin.
+func (s *Store) unpinShard(sh *Shard) {
+	sh.mu.Lock()
+	sh.pins--
+	deleted := sh.pins == 0 && sh.deleted
+	sh.mu.Unlock()
+
+	if deleted {
+		if err := s.removeShard(sh); err != nil {
+			s.Logger.Printf("failed to remove deleted shard %s: %s", sh.path, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package tsdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Ensure deleting a pinned shard removes its files once the pin is released.
+func TestStorePinShard_DeleteShard(t *testing.T) {
+	s, dir := mustOpenPinStore(t)
+	defer os.RemoveAll(dir)
+	defer s.Close()
+	path := s.Shard(1).Path()
+
+	p, err := s.PinShard(1, time.Minute)
+	if err != nil {
+		t.Fatalf("Store.PinShard() failed: %v", err)
+	} else if _, err := s.PinShard(2, time.Minute); err != ErrShardNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The shard is deleted immediately but its file is kept while pinned.
+	if err := s.DeleteShard(1); err != nil {
+		t.Fatalf("Store.DeleteShard() failed: %v", err)
+	} else if s.Shard(1) != nil {
+		t.Fatal("expected shard to be deleted")
+	} else if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected shard file to remain: %v", err)
+	}
+
+	// The pinned view can still be read.
+	var buf bytes.Buffer
+	if n, err := p.WriteTo(&buf); err != nil {
+		t.Fatalf("ShardPin.WriteTo() failed: %v", err)
+	} else if n == 0 || n != p.Size() {
+		t.Fatalf("unexpected size: %d, exp %d", n, p.Size())
+	}
+
+	// Releasing the last pin removes the file.
+	if err := p.Release(); err != nil {
+		t.Fatalf("ShardPin.Release() failed: %v", err)
+	} else if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected shard file to be removed: %v", err)
+	} else if _, err := p.WriteTo(&buf); err != ErrShardPinReleased {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a pin is released automatically once its timeout passes.
+func TestStorePinShard_Expire(t *testing.T) {
+	s, dir := mustOpenPinStore(t)
+	defer os.RemoveAll(dir)
+	defer s.Close()
+	path := s.Shard(1).Path()
+
+	p, err := s.PinShard(1, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Store.PinShard() failed: %v", err)
+	} else if err := s.DeleteShard(1); err != nil {
+		t.Fatalf("Store.DeleteShard() failed: %v", err)
+	}
+
+	// Wait for the pin to expire and the deleted shard's file to be removed.
+	timeout := time.After(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		select {
+		case <-timeout:
+			t.Fatal("shard file not removed")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if _, err := p.WriteTo(ioutil.Discard); err != ErrShardPinExpired {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := p.Release(); err != nil {
+		t.Fatalf("ShardPin.Release() failed: %v", err)
+	}
+}
+
+// Ensure a pinned shard isn't evicted after it has been archived.
+func TestStorePinShard_ArchiveShard(t *testing.T) {
+	s, dir := mustOpenPinStore(t)
+	defer os.RemoveAll(dir)
+	defer s.Close()
+	s.Archive = &memShardArchive{objects: make(map[string][]byte)}
+
+	p, err := s.PinShard(1, time.Minute)
+	if err != nil {
+		t.Fatalf("Store.PinShard() failed: %v", err)
+	}
+	if err := s.ArchiveShard(1); err != ErrShardPinned {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.Release()
+
+	// The shard can be evicted once it's unpinned.
+	if err := s.ArchiveShard(1); err != nil {
+		t.Fatalf("Store.ArchiveShard() failed: %v", err)
+	} else if _, err := os.Stat(s.Shard(1).Path()); !os.IsNotExist(err) {
+		t.Fatalf("expected shard file to be removed: %v", err)
+	}
+}
+
+// Ensure a query's read transaction sees the shard as it was when it began
+// and holds the shard's file until it ends.
+func TestStore_BeginRead(t *testing.T) {
+	s, dir := mustOpenPinStore(t)
+	defer os.RemoveAll(dir)
+	defer s.Close()
+	s.ColdPath = filepath.Join(dir, "cold")
+	sh := s.Shard(1)
+	path := sh.Path()
+
+	tx, err := s.beginRead(sh)
+	if err != nil {
+		t.Fatalf("Store.beginRead() failed: %v", err)
+	}
+
+	// Deletes don't wait for the read and aren't seen by it.
+	if err := s.DeleteSeriesRange(1, "cpu", nil, math.MinInt64, math.MaxInt64); err != nil {
+		t.Fatalf("Store.DeleteSeriesRange() failed: %v", err)
+	} else if k, _ := tx.Bucket([]byte("cpu,host=server")).Cursor().First(); k == nil {
+		t.Fatal("expected deleted point to remain visible to the read")
+	}
+
+	// The shard's file can't be moved and is kept after the shard is deleted.
+	if err := s.MoveShardToColdTier(1); err != ErrShardPinned {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.DeleteShard(1); err != nil {
+		t.Fatalf("Store.DeleteShard() failed: %v", err)
+	} else if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected shard file to remain: %v", err)
+	}
+
+	// Ending the read removes the file.
+	s.endRead(sh, tx)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected shard file to be removed: %v", err)
+	}
+}
+
+// mustOpenPinStore returns an open store with a single shard with data.
+func mustOpenPinStore(t *testing.T) (*Store, string) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+	pt := NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+	if err := s.WriteToShard(1, []Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+	return s, dir
+}
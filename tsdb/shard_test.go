@@ -1,12 +1,21 @@
 package tsdb
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/format"
 )
 
 func TestShardWriteAndIndex(t *testing.T) {
@@ -75,6 +84,55 @@ func TestShardWriteAndIndex(t *testing.T) {
 	}
 }
 
+func TestShardWriteFieldConflict(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	index := NewDatabaseIndex()
+	sh := NewShard(index, tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("error openeing shard: %s", err.Error())
+	}
+	defer sh.Close()
+
+	newPoint := func(fields map[string]interface{}) Point {
+		return NewPoint("cpu", map[string]string{"host": "server"}, fields, time.Unix(1, 2))
+	}
+	if err := sh.WritePoints([]Point{newPoint(map[string]interface{}{"value": 1.0, "n": int64(1)})}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Conflicting writes are rejected by default.
+	if err := sh.WritePoints([]Point{newPoint(map[string]interface{}{"value": int64(2)})}); err == nil || !strings.Contains(err.Error(), "field type conflict") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Integers and whole floats are coerced.
+	index.fieldConflictMode = FieldConflictCoerce
+	pt := newPoint(map[string]interface{}{"value": int64(2), "n": 3.0})
+	if err := sh.WritePoints([]Point{pt}); err != nil {
+		t.Fatalf(err.Error())
+	} else if _, ok := pt.Fields()["value"].(int64); !ok {
+		t.Fatalf("caller's point changed: %v", pt.Fields())
+	} else if err := sh.WritePoints([]Point{newPoint(map[string]interface{}{"n": 3.5})}); err == nil {
+		t.Fatal("expected error coercing fractional float")
+	}
+
+	// Conflicting fields are dropped, and points without other fields aren't written.
+	index.fieldConflictMode = FieldConflictDropField
+	if err := sh.WritePoints([]Point{
+		newPoint(map[string]interface{}{"value": "x", "n": int64(4)}),
+		newPoint(map[string]interface{}{"value": "y"}),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if stats := sh.FieldConflictStats(); stats != (FieldConflictStats{Coerced: 2, Dropped: 2}) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
 func TestShardWriteAddNewField(t *testing.T) {
 	tmpDir, _ := ioutil.TempDir("", "shard_test")
 	defer os.RemoveAll(tmpDir)
@@ -131,6 +189,101 @@ func TestShardWriteAddNewField(t *testing.T) {
 
 }
 
+// Ensure points created with NewPoint are checked for invalid series keys
+// before they're added to the index.
+func TestShardWriteInvalidSeriesKey(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	index := NewDatabaseIndex()
+	sh := NewShard(index, tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+
+	pt := NewPoint("cpu", Tags{"host": strings.Repeat("a", DefaultMaxTagValueLength+1)}, Fields{"value": 1.0}, time.Unix(1, 0))
+	if err := sh.WritePoints([]Point{pt}); err == nil || !strings.Contains(err.Error(), ErrInvalidSeriesKey.Error()) {
+		t.Fatalf("unexpected error: %v", err)
+	} else if n := len(index.series); n != 0 {
+		t.Fatalf("unexpected series count: %d", n)
+	}
+}
+
+// Ensure concurrent writes within the group commit window share a
+// transaction and a failed write doesn't fail the rest of its group.
+func TestShard_GroupCommit(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+
+	sh := NewShard(NewDatabaseIndex(), path.Join(tmpDir, "shard"))
+	sh.commitWindow = 50 * time.Millisecond
+	if err := sh.Open(); err != nil {
+		t.Fatalf("error opening shard: %s", err.Error())
+	}
+	defer sh.Close()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	txIDs := make([]int, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = sh.update(func(tx *bolt.Tx) error {
+				txIDs[i] = tx.ID()
+				if i == 0 {
+					return errors.New("marker")
+				}
+				b, err := tx.CreateBucketIfNotExists([]byte("cpu"))
+				if err != nil {
+					return err
+				}
+				return b.Put(u64tob(uint64(i)), []byte{byte(i)})
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	if errs[0] == nil || errs[0].Error() != "marker" {
+		t.Fatalf("unexpected error: %v", errs[0])
+	}
+	for i := 1; i < len(errs); i++ {
+		if errs[i] != nil {
+			t.Fatalf("unexpected error: %v", errs[i])
+		}
+	}
+	if err := sh.db.View(func(tx *bolt.Tx) error {
+		if n := tx.Bucket([]byte("cpu")).Stats().KeyN; n != 4 {
+			t.Fatalf("unexpected key count: %d", n)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a failure the writes commit once.
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = sh.update(func(tx *bolt.Tx) error {
+				txIDs[i] = tx.ID()
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	for i := range txIDs {
+		if errs[i] != nil {
+			t.Fatalf("unexpected error: %v", errs[i])
+		} else if txIDs[i] != txIDs[0] {
+			t.Fatalf("writes committed separately: %v", txIDs)
+		}
+	}
+}
+
 func BenchmarkWritePoints_NewSeries_1K(b *testing.B)   { benchmarkWritePoints(b, 38, 3, 3, 1) }
 func BenchmarkWritePoints_NewSeries_100K(b *testing.B) { benchmarkWritePoints(b, 32, 5, 5, 1) }
 func BenchmarkWritePoints_NewSeries_250K(b *testing.B) { benchmarkWritePoints(b, 80, 5, 5, 1) }
@@ -255,3 +408,453 @@ func chunkedWrite(shard *Shard, points []Point) {
 		end += chunkSz
 	}
 }
+
+// Ensure a new shard is stamped with the current format version.
+func TestShard_Open_Format(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("error opening shard: %s", err.Error())
+	}
+	defer sh.Close()
+
+	var hdr format.Header
+	if err := sh.db.View(func(tx *bolt.Tx) error {
+		return hdr.UnmarshalBinary(tx.Bucket([]byte(shardFormatBucket)).Get([]byte("format")))
+	}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(hdr, ShardFormat.Header()) {
+		t.Fatalf("unexpected header: %#v", hdr)
+	}
+}
+
+// Ensure a shard written by a newer release is refused.
+func TestShard_Open_NewerFormat(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	// Write a header with an unknown version.
+	db, err := bolt.Open(tmpShard, 0666, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, _ := tx.CreateBucketIfNotExists([]byte(shardFormatBucket))
+		return b.Put([]byte("format"), []byte(`{"version":100}`))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err == nil || !strings.Contains(err.Error(), "shard format version 100 is newer than supported version 2") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a header in the old "meta" bucket is moved without touching the
+// points of a measurement named meta.
+func TestShard_Open_MetaMeasurement(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("error opening shard: %s", err.Error())
+	} else if err := sh.WritePoints([]Point{NewPoint("meta", nil, Fields{"value": 1.0}, time.Unix(1, 0))}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	// Move the header to where it used to be kept.
+	if err := sh.db.Update(func(tx *bolt.Tx) error {
+		buf := tx.Bucket([]byte(shardFormatBucket)).Get([]byte("format"))
+		if err := tx.DeleteBucket([]byte(shardFormatBucket)); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("meta")).Put([]byte("format"), buf)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sh.Close()
+
+	sh = NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("error opening shard: %s", err.Error())
+	}
+	defer sh.Close()
+
+	if err := sh.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(shardFormatBucket)).Get([]byte("format")) == nil {
+			t.Fatal("expected header to be moved")
+		}
+		b := tx.Bucket([]byte("meta"))
+		if b.Get([]byte("format")) != nil {
+			t.Fatal("expected old header to be removed")
+		} else if n := b.Stats().KeyN; n != 1 {
+			t.Fatalf("unexpected point count: %d", n)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	} else if !sh.mayContainSeries([]string{"meta"}) {
+		t.Fatal("expected meta series in series filter")
+	}
+}
+
+// Ensure a tag can be converted into a field, merging the series it separated.
+func TestShard_Migrate_TagToField(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a", "region": "west"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+		NewPoint("cpu", Tags{"host": "a", "region": "east"}, Fields{"value": 2.0}, time.Unix(2, 0)),
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 3.0}, time.Unix(3, 0)),
+		NewPoint("mem", Tags{"region": "west"}, Fields{"value": 4.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sh.Close()
+
+	var progress []int
+	n, err := MigrateShardFile(tmpShard, &Migration{
+		Type:        TagToField,
+		Measurement: "cpu",
+		Key:         "region",
+		Progress:    func(done, total int) { progress = append(progress, done, total) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("unexpected points rewritten: %d", n)
+	} else if !reflect.DeepEqual(progress, []int{1, 3, 2, 3, 3, 3}) {
+		t.Fatalf("unexpected progress: %v", progress)
+	}
+
+	if m := mustReadShard(t, tmpShard); !reflect.DeepEqual(m, map[string]map[int64]map[string]interface{}{
+		"cpu,host=a": {
+			time.Unix(1, 0).UnixNano(): {"value": 1.0, "region": "west"},
+			time.Unix(2, 0).UnixNano(): {"value": 2.0, "region": "east"},
+			time.Unix(3, 0).UnixNano(): {"value": 3.0},
+		},
+		"mem,region=west": {
+			time.Unix(1, 0).UnixNano(): {"value": 4.0},
+		},
+	}) {
+		t.Fatalf("unexpected points: %v", m)
+	}
+}
+
+// Ensure fields created by writes during and after a migration are saved
+// with the migrated fields.
+func TestShard_Migrate_FieldsKept(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a", "region": "west"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write new fields while migrating.
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 10; i++ {
+			if err := sh.WritePoints([]Point{
+				NewPoint("cpu", Tags{"host": "b"}, Fields{fmt.Sprintf("f%d", i): 1.0}, time.Unix(int64(i+2), 0)),
+			}); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	if _, err := sh.Migrate(&Migration{Type: TagToField, Measurement: "cpu", Key: "region"}); err != nil {
+		t.Fatal(err)
+	} else if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	sh.Close()
+
+	sh = NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	codec := sh.FieldCodec("cpu")
+	for _, name := range []string{"value", "region", "f0", "f9"} {
+		if codec.fieldByName(name) == nil {
+			t.Fatalf("field not saved: %s", name)
+		}
+	}
+}
+
+// Ensure a field can be converted into a tag, splitting a series by its values.
+func TestShard_Migrate_FieldToTag(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	index := NewDatabaseIndex()
+	sh := NewShard(index, tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0, "core": int64(0)}, time.Unix(1, 0)),
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 2.0, "core": int64(1)}, time.Unix(2, 0)),
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 3.0}, time.Unix(3, 0)),
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"core": int64(1)}, time.Unix(4, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only existing fields can be converted.
+	if _, err := sh.Migrate(&Migration{Type: FieldToTag, Measurement: "cpu", Key: "host"}); err != ErrFieldNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	n, err := sh.Migrate(&Migration{Type: FieldToTag, Measurement: "cpu", Key: "core"})
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Fatalf("unexpected points rewritten: %d", n)
+	} else if index.series["cpu,core=1,host=a"] == nil {
+		t.Fatal("expected new series in index")
+	}
+
+	// Writes to the new series use the existing field encoding.
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a", "core": "1"}, Fields{"value": 5.0}, time.Unix(5, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sh.Close()
+
+	if m := mustReadShard(t, tmpShard); !reflect.DeepEqual(m, map[string]map[int64]map[string]interface{}{
+		"cpu,core=0,host=a": {
+			time.Unix(1, 0).UnixNano(): {"value": 1.0},
+		},
+		"cpu,core=1,host=a": {
+			time.Unix(2, 0).UnixNano(): {"value": 2.0},
+			time.Unix(5, 0).UnixNano(): {"value": 5.0},
+		},
+		"cpu,host=a": {
+			time.Unix(3, 0).UnixNano(): {"value": 3.0},
+		},
+	}) {
+		t.Fatalf("unexpected points: %v", m)
+	}
+
+	// The field can't be converted again now that it's a tag.
+	if _, err := MigrateShardFile(tmpShard, &Migration{Type: FieldToTag, Measurement: "cpu", Key: "core"}); err != ErrTagExists {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a shard's points can be exported as line protocol and imported into another shard.
+func TestShard_ExportImport(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(-1, 0)),
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 2.0}, time.Unix(1, 0)),
+		NewPoint("cpu", Tags{"host": "b"}, Fields{"value": 3.0}, time.Unix(2, 0)),
+		NewPoint("mem", Tags{"host": "a"}, Fields{"used": int64(4), "ok": true}, time.Unix(3, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sh.Close()
+
+	// Export everything but the last point.
+	var buf bytes.Buffer
+	if n, err := ExportShardFile(tmpShard, &buf, time.Unix(-1, 0).UnixNano(), time.Unix(2, 0).UnixNano()); err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Fatalf("unexpected points exported: %d", n)
+	}
+	if exp := "cpu,host=a value=1.0 -1000000000\n" +
+		"cpu,host=a value=2.0 1000000000\n" +
+		"cpu,host=b value=3.0 2000000000\n"; buf.String() != exp {
+		t.Fatalf("unexpected export:\n%s", buf.String())
+	}
+
+	other := path.Join(tmpDir, "other")
+	if n, err := ImportShardFile(other, strings.NewReader("# comment\n\n"+buf.String())); err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Fatalf("unexpected points imported: %d", n)
+	}
+
+	if m := mustReadShard(t, other); !reflect.DeepEqual(m, map[string]map[int64]map[string]interface{}{
+		"cpu,host=a": {
+			time.Unix(-1, 0).UnixNano(): {"value": 1.0},
+			time.Unix(1, 0).UnixNano():  {"value": 2.0},
+		},
+		"cpu,host=b": {
+			time.Unix(2, 0).UnixNano(): {"value": 3.0},
+		},
+	}) {
+		t.Fatalf("unexpected points: %v", m)
+	}
+
+	// Lines that can't be parsed are reported.
+	if _, err := ImportShardFile(other, strings.NewReader("cpu value=1.0 1\ncpu 2\n")); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a series is exported from a shard without it in its series bucket,
+// as shards of format version 1 may be.
+func TestShard_ExportPoints_Unindexed(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+
+	sh := NewShard(NewDatabaseIndex(), path.Join(tmpDir, "shard"))
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+	if err := sh.WritePoints([]Point{NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0))}); err != nil {
+		t.Fatal(err)
+	} else if err := sh.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("series")).Delete([]byte("cpu,host=a"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if n, err := sh.ExportPoints(&buf, math.MinInt64, math.MaxInt64); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("unexpected points exported: %d", n)
+	} else if buf.String() != "cpu,host=a value=1.0 1000000000\n" {
+		t.Fatalf("unexpected export:\n%s", buf.String())
+	}
+}
+
+func TestShard_Verify(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := path.Join(tmpDir, "shard")
+
+	sh := NewShard(NewDatabaseIndex(), tmpShard)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sh.WritePoints([]Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+		NewPoint("cpu", Tags{"host": "b"}, Fields{"value": 2.0}, time.Unix(2, 0)),
+		NewPoint("mem", Tags{"host": "a"}, Fields{"used": int64(3)}, time.Unix(3, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sh.Close()
+
+	if v, err := VerifyShardFile(tmpShard); err != nil {
+		t.Fatal(err)
+	} else if !v.OK() || v.Series != 3 || v.Points != 3 {
+		t.Fatalf("unexpected verification: %+v", v)
+	}
+
+	// Truncate a point, drop a series from the index and add data that
+	// isn't in the index.
+	db, err := bolt.Open(tmpShard, 0666, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket([]byte("cpu,host=a")).Put(u64tob(uint64(time.Unix(1, 0).UnixNano())), []byte{1, 0}); err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte("series")).Delete([]byte("mem,host=a")); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket([]byte("disk,host=a"))
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	v, err := VerifyShardFile(tmpShard)
+	if err != nil {
+		t.Fatal(err)
+	} else if v.OK() || len(v.Problems) != 4 || v.Series != 4 {
+		t.Fatalf("unexpected problems: %v", v.Problems)
+	} else if !reflect.DeepEqual(v.CorruptSeries, []string{"cpu,host=a", "disk,host=a", "mem,host=a"}) {
+		t.Fatalf("unexpected corrupt series: %v", v.CorruptSeries)
+	}
+
+	// Version 1 shards don't index series first created in other shards.
+	db, err = bolt.Open(tmpShard, 0666, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(shardFormatBucket)).Put([]byte("format"), []byte(`{"version":1}`))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if v, err := VerifyShardFile(tmpShard); err != nil {
+		t.Fatal(err)
+	} else if len(v.Problems) != 2 {
+		t.Fatalf("unexpected problems: %v", v.Problems)
+	} else if !reflect.DeepEqual(v.CorruptSeries, []string{"cpu,host=a", "disk,host=a"}) {
+		t.Fatalf("unexpected corrupt series: %v", v.CorruptSeries)
+	}
+}
+
+// mustReadShard returns the fields of every point in a shard by series key and time.
+func mustReadShard(t *testing.T, path string) map[string]map[int64]map[string]interface{} {
+	sh := NewShard(NewDatabaseIndex(), path)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+
+	m := make(map[string]map[int64]map[string]interface{})
+	if err := sh.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("series")).ForEach(func(k, _ []byte) error {
+			codec := sh.FieldCodec(measurementFromSeriesKey(string(k)))
+			points := make(map[int64]map[string]interface{})
+			if err := tx.Bucket(k).ForEach(func(k, v []byte) error {
+				fields, err := codec.DecodeFieldsWithNames(v)
+				points[int64(btou64(k))] = fields
+				return err
+			}); err != nil {
+				return err
+			}
+			m[string(k)] = points
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
@@ -8,7 +8,6 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/boltdb/bolt"
 	"github.com/influxdb/influxdb/snapshot"
 )
 
@@ -52,8 +51,10 @@ func appendShardSnapshotFiles(sw *snapshot.Writer, store *Store) error {
 	// Create files for each shard.
 	for _, shardID := range store.ShardIDs() {
 		// Retrieve shard.
-		sh := store.Shard(shardID)
-		if sh == nil {
+		sh, err := store.FetchShard(shardID)
+		if err != nil {
+			return err
+		} else if sh == nil {
 			return fmt.Errorf("shard not found: %d", shardID)
 		}
 
@@ -67,7 +68,7 @@ func appendShardSnapshotFiles(sw *snapshot.Writer, store *Store) error {
 			return fmt.Errorf("shard rel path: %s", err)
 		}
 
-		if err := appendShardSnapshotFile(sw, sh, name); err != nil {
+		if err := appendShardSnapshotFile(sw, store, shardID, name); err != nil {
 			return fmt.Errorf("append shard: name=%s, err=%s", name, err)
 		}
 	}
@@ -75,40 +76,35 @@ func appendShardSnapshotFiles(sw *snapshot.Writer, store *Store) error {
 	return nil
 }
 
-func appendShardSnapshotFile(sw *snapshot.Writer, sh *Shard, name string) error {
-	// Stat the underlying data file to retrieve last modified date.
-	fi, err := os.Stat(sh.Path())
+// appendShardSnapshotFile pins a shard and adds it to the snapshot. The pin
+// is released when the snapshot writer is closed.
+func appendShardSnapshotFile(sw *snapshot.Writer, store *Store, shardID uint64, name string) error {
+	// Pin the shard.
+	p, err := store.PinShard(shardID, 0)
 	if err != nil {
-		return fmt.Errorf("stat shard data file: %s", err)
+		return fmt.Errorf("pin: %s", err)
 	}
 
-	// Begin transaction.
-	tx, err := sh.db.Begin(false)
+	// Stat the underlying data file to retrieve last modified date.
+	fi, err := os.Stat(p.shard.Path())
 	if err != nil {
-		return fmt.Errorf("begin: %s", err)
+		p.Release()
+		return fmt.Errorf("stat shard data file: %s", err)
 	}
 
 	// Create file.
 	f := snapshot.File{
 		Name:    name,
-		Size:    tx.Size(),
+		Size:    p.Size(),
 		ModTime: fi.ModTime(),
 	}
 
 	// Append to snapshot writer.
 	sw.Manifest.Files = append(sw.Manifest.Files, f)
-	sw.FileWriters[f.Name] = &boltTxCloser{tx}
+	sw.FileWriters[f.Name] = p
 	return nil
 }
 
-// boltTxCloser wraps a Bolt transaction to implement io.Closer.
-type boltTxCloser struct {
-	*bolt.Tx
-}
-
-// Close rollsback the transaction.
-func (tx *boltTxCloser) Close() error { return tx.Rollback() }
-
 // NopWriteToCloser returns an io.WriterTo that implements io.Closer.
 func NopWriteToCloser(w io.WriterTo) interface {
 	io.WriterTo
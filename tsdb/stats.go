@@ -0,0 +1,161 @@
+package tsdb
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// StatisticsSource is a service whose statistics are returned by SHOW STATS.
+type StatisticsSource interface {
+	StatisticsRow() *influxql.Row
+}
+
+// ShardStatistics are the statistics of a shard, so capacity problems can be
+// attributed to the shards causing them. Counts and durations are since the
+// shard was opened.
+type ShardStatistics struct {
+	ID       uint64
+	Database string
+	Path     string
+
+	DiskBytes     int64 // size of the data file, zero if it isn't stored locally
+	SeriesN       int   // series with data in the shard
+	HotCacheBytes int   // size of the cache of recently written points
+	PendingWrites int   // writes waiting for the group commit window
+
+	WriteN        int64         // batches of points written
+	WriteDuration time.Duration // total time spent writing
+	QueryN        int64         // mappers that read from the shard
+	QueryDuration time.Duration // total time mappers held the shard open
+
+	FieldsCoerced int64
+	FieldsDropped int64
+}
+
+// Statistics returns the statistics of the shard. The ID isn't set as the
+// shard doesn't know it.
+func (s *Shard) Statistics() ShardStatistics {
+	conflicts := s.FieldConflictStats()
+	st := ShardStatistics{
+		Database:      s.database,
+		Path:          s.path,
+		WriteN:        atomic.LoadInt64(&s.writeN),
+		WriteDuration: time.Duration(atomic.LoadInt64(&s.writeNs)),
+		QueryN:        atomic.LoadInt64(&s.queryN),
+		QueryDuration: time.Duration(atomic.LoadInt64(&s.queryNs)),
+		FieldsCoerced: conflicts.Coerced,
+		FieldsDropped: conflicts.Dropped,
+	}
+
+	if s.hotCache != nil {
+		st.HotCacheBytes = s.hotCache.bytes()
+	}
+
+	s.commitMu.Lock()
+	if s.pendingCommit != nil {
+		st.PendingWrites = len(s.pendingCommit.fns)
+	}
+	s.commitMu.Unlock()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.db == nil {
+		return st
+	}
+	if fi, err := os.Stat(s.path); err == nil {
+		st.DiskBytes = fi.Size()
+	}
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		st.SeriesN = s.seriesN(tx)
+		return nil
+	})
+	return st
+}
+
+// seriesN returns the number of series data buckets in the shard. The count
+// is cached until the next write so repeated statistics don't rescan the
+// shard.
+func (s *Shard) seriesN(tx *bolt.Tx) int {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	if s.seriesNDB == tx.DB() && s.seriesNTxID == tx.ID() {
+		return s.seriesNCount
+	}
+
+	var n int
+	_ = tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if !isShardMetaBucket(name) {
+			n++
+		}
+		return nil
+	})
+	s.seriesNDB, s.seriesNTxID, s.seriesNCount = tx.DB(), tx.ID(), n
+	return n
+}
+
+// Statistics returns the statistics of each shard in the store, by ID.
+func (s *Store) Statistics() []ShardStatistics {
+	// Copy the shards so the store isn't locked while they're read.
+	s.mu.RLock()
+	shards := make(map[uint64]*Shard, len(s.shards))
+	for id, sh := range s.shards {
+		shards[id] = sh
+	}
+	s.mu.RUnlock()
+
+	a := make([]ShardStatistics, 0, len(shards))
+	for id, sh := range shards {
+		st := sh.Statistics()
+		st.ID = id
+		a = append(a, st)
+	}
+	sort.Sort(shardStatisticsByID(a))
+	return a
+}
+
+type shardStatisticsByID []ShardStatistics
+
+func (a shardStatisticsByID) Len() int           { return len(a) }
+func (a shardStatisticsByID) Less(i, j int) bool { return a[i].ID < a[j].ID }
+func (a shardStatisticsByID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// addWrite records a write to the shard that took d.
+func (s *Shard) addWrite(d time.Duration) {
+	atomic.AddInt64(&s.writeN, 1)
+	atomic.AddInt64(&s.writeNs, int64(d))
+}
+
+// addQuery records a mapper that held the shard open for d.
+func (s *Shard) addQuery(d time.Duration) {
+	atomic.AddInt64(&s.queryN, 1)
+	atomic.AddInt64(&s.queryNs, int64(d))
+}
+
+// executeShowStatsStatement returns a row with the statistics of each shard
+// stored on this node, followed by a row from each statistics source.
+func (q *QueryExecutor) executeShowStatsStatement(stmt *influxql.ShowStatsStatement) *influxql.Result {
+	if stmt.Host != "" {
+		return &influxql.Result{Err: fmt.Errorf("SHOW STATS for another host is not supported")}
+	}
+
+	row := &influxql.Row{
+		Name: "shards",
+		Columns: []string{"id", "database", "path", "diskBytes", "seriesN", "hotCacheBytes", "pendingWrites",
+			"writeN", "writeNs", "queryN", "queryNs", "fieldsCoerced", "fieldsDropped"},
+	}
+	for _, st := range q.store.Statistics() {
+		row.Values = append(row.Values, []interface{}{st.ID, st.Database, st.Path, st.DiskBytes, st.SeriesN, st.HotCacheBytes, st.PendingWrites,
+			st.WriteN, int64(st.WriteDuration), st.QueryN, int64(st.QueryDuration), st.FieldsCoerced, st.FieldsDropped})
+	}
+	rows := []*influxql.Row{row}
+	for _, src := range q.StatisticsSources {
+		rows = append(rows, src.StatisticsRow())
+	}
+	return &influxql.Result{Series: rows}
+}
@@ -0,0 +1,76 @@
+package tsdb
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// Ensure the store returns the statistics of each of its shards.
+func TestStoreStatistics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	s.HotCacheSize = 1 << 20
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	for _, id := range []uint64{2, 1} {
+		if err := s.CreateShard("mydb", "myrp", id); err != nil {
+			t.Fatalf("failed to create shard: %v", err)
+		}
+	}
+
+	if err := s.WriteToShard(1, []Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+		NewPoint("cpu", Tags{"host": "b"}, Fields{"value": 2.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := s.Statistics()
+	if len(stats) != 2 {
+		t.Fatalf("unexpected shard count: %d", len(stats))
+	} else if st := stats[0]; st.ID != 1 || st.Database != "mydb" {
+		t.Fatalf("unexpected shard: %d %s", st.ID, st.Database)
+	} else if st.SeriesN != 2 || st.WriteN != 1 || st.WriteDuration <= 0 || st.DiskBytes <= 0 || st.HotCacheBytes <= 0 {
+		t.Fatalf("unexpected statistics: %+v", st)
+	} else if st := stats[1]; st.ID != 2 || st.SeriesN != 0 || st.WriteN != 0 {
+		t.Fatalf("unexpected statistics: %+v", st)
+	}
+
+	// The series count follows writes, including of series first created in
+	// another shard.
+	if err := s.WriteToShard(2, []Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(2, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	} else if stats := s.Statistics(); stats[0].SeriesN != 2 || stats[1].SeriesN != 1 {
+		t.Fatalf("unexpected series counts: %d, %d", stats[0].SeriesN, stats[1].SeriesN)
+	}
+
+	qe := NewQueryExecutor(s)
+	qe.StatisticsSources = []StatisticsSource{statisticsSourceFunc(func() *influxql.Row {
+		return &influxql.Row{Name: "other", Columns: []string{"n"}, Values: [][]interface{}{{1}}}
+	})}
+	res := qe.executeShowStatsStatement(&influxql.ShowStatsStatement{})
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	} else if len(res.Series) != 2 || len(res.Series[0].Values) != 2 || res.Series[0].Values[0][0] != uint64(1) {
+		t.Fatalf("unexpected result: %v", res.Series)
+	} else if res.Series[1].Name != "other" {
+		t.Fatalf("unexpected source row: %v", res.Series[1])
+	}
+}
+
+type statisticsSourceFunc func() *influxql.Row
+
+func (fn statisticsSourceFunc) StatisticsRow() *influxql.Row { return fn() }
@@ -6,17 +6,26 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
 )
 
 func NewStore(path string) *Store {
 	return &Store{
-		path:   path,
-		Logger: log.New(os.Stderr, "[store] ", log.LstdFlags),
+		path:                  path,
+		SeriesKeyLimits:       DefaultSeriesKeyLimits,
+		ShardPinTimeout:       DefaultShardPinTimeout,
+		IndexSnapshotInterval: DefaultIndexSnapshotInterval,
+		GroupCommitWindow:     DefaultGroupCommitWindow,
+		HotCacheSize:          DefaultHotCacheSize,
+		ShardMmapSize:         DefaultShardMmapSize,
+		Logger:                log.New(os.Stderr, "[store] ", log.LstdFlags),
 	}
 }
 
@@ -31,6 +40,83 @@ type Store struct {
 	databaseIndexes map[string]*DatabaseIndex
 	shards          map[uint64]*Shard
 
+	// Archive, if set, holds the data of shards moved to object storage.
+	Archive ShardArchive
+
+	// ColdPath, if set, is a second data directory holding the shards moved
+	// to the cold tier. It must be set before the store is opened.
+	ColdPath string
+
+	// MeasurementHints set the ingest pattern of measurements. They must be
+	// set before the store is opened.
+	MeasurementHints []MeasurementHint
+
+	// FieldConflictPolicies set how fields with conflicting types are written
+	// to each database. They must be set before the store is opened.
+	FieldConflictPolicies []FieldConflictPolicy
+
+	// ShardPinTimeout is the default time a shard stays pinned for an export.
+	ShardPinTimeout time.Duration
+
+	// GroupCommitWindow is the time each shard waits for concurrent writes
+	// to commit in the same transaction. Zero commits every write on its own.
+	GroupCommitWindow time.Duration
+
+	// ShardMmapSize is the smallest size each shard's data file is memory
+	// mapped at. Zero maps only what the data file needs.
+	ShardMmapSize int
+
+	// HotCacheSize is the size of each shard's cache of recently written
+	// points, in bytes. Queries read from the cache instead of the data file
+	// when it holds their whole time range. Zero disables the cache.
+	HotCacheSize int
+
+	// IndexSnapshotInterval is the time between index snapshots of changed
+	// shards. Snapshots are also written when the store is closed. Zero
+	// disables index snapshots.
+	IndexSnapshotInterval time.Duration
+
+	// LazyShardOpen loads the index of shards with an index snapshot when
+	// the store opens and opens their data files on first access or in the
+	// background afterwards.
+	LazyShardOpen bool
+
+	// Precision is the precision timestamps are stored at. Written points
+	// are truncated to it. Empty stores nanoseconds.
+	Precision string
+
+	// MaxPointsPerBatch limits the points written to a shard at once.
+	// Larger writes are rejected. Zero means unlimited.
+	MaxPointsPerBatch int
+
+	// MaxWriteQueueBytesPerDatabase limits the bytes of writes to each
+	// database waiting to be committed. Writes over the limit are rejected
+	// so one database can't stall writes to the others. Zero means unlimited.
+	MaxWriteQueueBytesPerDatabase int
+
+	// MaxSeriesPerDatabase limits the series in each database on this node.
+	// The cluster's max_series_per_database limit applies if it is lower.
+	// Zero means unlimited.
+	MaxSeriesPerDatabase int
+
+	// MaxValuesPerTag limits the values of each tag key in a measurement.
+	// Writes creating more are rejected. Zero means unlimited.
+	MaxValuesPerTag int
+
+	// SeriesKeyLimits limit the keys of new series. Writes creating series
+	// over them are rejected.
+	SeriesKeyLimits SeriesKeyLimits
+
+	// MetaLimits, if set, returns the cluster limits enforced on writes.
+	MetaLimits interface {
+		Limits() (meta.Limits, error)
+	}
+
+	writeQueue *writeQueue
+
+	closing chan struct{}
+	wg      sync.WaitGroup
+
 	Logger *log.Logger
 }
 
@@ -54,12 +140,12 @@ func (s *Store) CreateShard(database, retentionPolicy string, shardID uint64) er
 	// create the database index if it does not exist
 	db, ok := s.databaseIndexes[database]
 	if !ok {
-		db = NewDatabaseIndex()
+		db = s.newDatabaseIndex(database)
 		s.databaseIndexes[database] = db
 	}
 
 	shardPath := filepath.Join(s.path, database, retentionPolicy, strconv.FormatUint(shardID, 10))
-	shard := NewShard(db, shardPath)
+	shard := s.newShard(database, db, shardPath)
 	if err := shard.Open(); err != nil {
 		return err
 	}
@@ -69,7 +155,8 @@ func (s *Store) CreateShard(database, retentionPolicy string, shardID uint64) er
 	return nil
 }
 
-// DeleteShard removes a shard from disk.
+// DeleteShard removes a shard from disk. The shard's files are removed once
+// any pins on the shard are released.
 func (s *Store) DeleteShard(shardID uint64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -80,11 +167,16 @@ func (s *Store) DeleteShard(shardID uint64) error {
 		return nil
 	}
 
-	if err := sh.Close(); err != nil {
-		return err
+	sh.mu.Lock()
+	pinned := sh.pins > 0
+	sh.deleted = pinned
+	sh.mu.Unlock()
+	if pinned {
+		delete(s.shards, shardID)
+		return nil
 	}
 
-	if err := os.Remove(sh.path); err != nil {
+	if err := s.removeShard(sh); err != nil {
 		return err
 	}
 
@@ -93,6 +185,33 @@ func (s *Store) DeleteShard(shardID uint64) error {
 	return nil
 }
 
+// removeShard closes a shard and removes its files, including any archived copy.
+func (s *Store) removeShard(sh *Shard) error {
+	if err := sh.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(sh.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(sh.path + IndexExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Remove the archived copy of the shard, if there is one.
+	if key := sh.ArchiveKey(); key != "" {
+		if s.Archive == nil {
+			return ErrShardArchiveNotSet
+		} else if err := s.Archive.Delete(key); err != nil {
+			return fmt.Errorf("delete archived shard: %s", err)
+		}
+		if err := os.Remove(sh.path + ArchiveExt); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 // DeleteDatabase will close all shards associated with a database and remove the directory and files from disk.
 func (s *Store) DeleteDatabase(name string, shardIDs []uint64) error {
 	s.mu.Lock()
@@ -103,15 +222,121 @@ func (s *Store) DeleteDatabase(name string, shardIDs []uint64) error {
 			shard.Close()
 		}
 	}
+	if s.ColdPath != "" {
+		if err := os.RemoveAll(filepath.Join(s.ColdPath, name)); err != nil {
+			return err
+		}
+	}
 	return os.RemoveAll(s.path)
 }
 
+// RenameDatabase moves the local shards and index of a database to a new
+// name. Measurement hints for the new name apply once the store is reopened.
+func (s *Store) RenameDatabase(oldName, newName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Nothing to do if the database has no shards on this node.
+	db, ok := s.databaseIndexes[oldName]
+	if !ok {
+		return nil
+	} else if _, ok := s.databaseIndexes[newName]; ok {
+		return fmt.Errorf("database already exists: %s", newName)
+	}
+
+	for _, root := range s.roots() {
+		oldPath, newPath := filepath.Join(root, oldName), filepath.Join(root, newName)
+		if err := os.Rename(oldPath, newPath); os.IsNotExist(err) && root == s.ColdPath {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		// Open shards keep their files but their paths follow the directory.
+		for _, sh := range s.shards {
+			if sh.index != db || !isWithin(oldPath, sh.path) {
+				continue
+			}
+			rel, err := filepath.Rel(oldPath, sh.path)
+			if err != nil {
+				return err
+			}
+			sh.mu.Lock()
+			sh.path = filepath.Join(newPath, rel)
+			sh.mu.Unlock()
+		}
+	}
+
+	for _, sh := range s.shards {
+		if sh.index == db {
+			sh.mu.Lock()
+			sh.database = newName
+			sh.mu.Unlock()
+		}
+	}
+
+	delete(s.databaseIndexes, oldName)
+	s.databaseIndexes[newName] = db
+
+	return nil
+}
+
+// SyncDatabaseNames renames the local databases whose shards all belong to a
+// database of another name in the metadata, so renames apply on every node.
+// owner returns the name of the database holding a shard, or a blank string
+// if the shard isn't in the metadata.
+func (s *Store) SyncDatabaseNames(owner func(shardID uint64) string) error {
+	s.mu.RLock()
+	shards := make(map[uint64]string, len(s.shards))
+	for id, sh := range s.shards {
+		shards[id] = sh.database
+	}
+	s.mu.RUnlock()
+
+	// Find the name each local database has in the metadata.
+	names := make(map[string]string)
+	for id, database := range shards {
+		name := owner(id)
+		if name == "" {
+			continue
+		} else if other, ok := names[database]; ok && other != name {
+			return fmt.Errorf("shards of database %s belong to %s and %s", database, other, name)
+		}
+		names[database] = name
+	}
+
+	for database, name := range names {
+		if name == database {
+			continue
+		}
+		if err := s.RenameDatabase(database, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *Store) Shard(shardID uint64) *Shard {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.shards[shardID]
 }
 
+// ShardSize returns the size of a shard's data file in bytes. Returns false
+// if the shard isn't stored locally or its file can't be read.
+func (s *Store) ShardSize(shardID uint64) (int64, bool) {
+	sh := s.Shard(shardID)
+	if sh == nil {
+		return 0, false
+	}
+
+	fi, err := os.Stat(sh.Path())
+	if err != nil {
+		return 0, false
+	}
+	return fi.Size(), true
+}
+
 // ShardIDs returns a slice of all ShardIDs under management.
 func (s *Store) ShardIDs() []uint64 {
 	ids := make([]uint64, 0, len(s.shards))
@@ -137,6 +362,54 @@ func (s *Store) DatabaseIndex(name string) *DatabaseIndex {
 	return s.databaseIndexes[name]
 }
 
+// LastPoints returns the most recent point written to each series of a
+// measurement in a retention policy, sorted by series key.
+func (s *Store) LastPoints(database, retentionPolicy, measurement string) ([]Point, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	last := make(map[string]Point)
+	for _, sh := range s.shards {
+		if sh.database != database || sh.FieldCodec(measurement) == nil {
+			continue
+		}
+
+		// Shards are stored in a directory named after their retention policy.
+		if filepath.Base(filepath.Dir(sh.Path())) != retentionPolicy {
+			continue
+		}
+
+		tx, err := s.beginRead(sh)
+		if err != nil {
+			return nil, err
+		}
+		points, err := sh.lastPoints(tx, measurement)
+		s.endRead(sh, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range points {
+			key := string(p.Key())
+			if prev, ok := last[key]; !ok || p.Time().After(prev.Time()) {
+				last[key] = p
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(last))
+	for k := range last {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	points := make([]Point, len(keys))
+	for i, k := range keys {
+		points[i] = last[k]
+	}
+	return points, nil
+}
+
 func (s *Store) Measurement(database, name string) *Measurement {
 	s.mu.RLock()
 	db := s.databaseIndexes[database]
@@ -152,6 +425,13 @@ func (s *Store) deleteSeries(keys []string) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	for _, sh := range s.shards {
+		// Don't fetch archived shards that don't have the series.
+		if !sh.mayContainSeries(keys) {
+			continue
+		}
+		if err := s.restoreShard(sh); err != nil {
+			return err
+		}
 		if err := sh.deleteSeries(keys); err != nil {
 			return err
 		}
@@ -159,11 +439,46 @@ func (s *Store) deleteSeries(keys []string) error {
 	return nil
 }
 
+// DeleteSeriesRange removes the points of a measurement's series matching
+// condition between min and max, inclusive, from a shard. The condition can
+// only refer to tags and time. Nothing is deleted if the shard isn't local.
+func (s *Store) DeleteSeriesRange(shardID uint64, measurement string, condition influxql.Expr, min, max int64) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sh, ok := s.shards[shardID]
+	if !ok {
+		return nil
+	}
+	m := sh.index.Measurement(measurement)
+	if m == nil {
+		return nil
+	}
+
+	// Find the series matching the condition.
+	keys, err := m.seriesKeysWhere(condition)
+	if err != nil {
+		return err
+	}
+
+	// Don't fetch archived shards that don't have the series.
+	if !sh.mayContainSeries(keys) {
+		return nil
+	}
+	if err := s.restoreShard(sh); err != nil {
+		return err
+	}
+	return sh.deleteRange(keys, min, max)
+}
+
 // deleteMeasurement loops through the local shards and removes the measurement field encodings from each shard
 func (s *Store) deleteMeasurement(name string, seriesKeys []string) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	for _, sh := range s.shards {
+		if err := s.restoreShard(sh); err != nil {
+			return err
+		}
 		if err := sh.deleteMeasurement(name, seriesKeys); err != nil {
 			return err
 		}
@@ -172,25 +487,53 @@ func (s *Store) deleteMeasurement(name string, seriesKeys []string) error {
 }
 
 func (s *Store) loadIndexes() error {
-	dbs, err := ioutil.ReadDir(s.path)
-	if err != nil {
-		return err
-	}
-	for _, db := range dbs {
-		if !db.IsDir() {
-			s.Logger.Printf("Skipping database dir: %s. Not a directory", db.Name())
-			continue
+	for _, root := range s.roots() {
+		dbs, err := ioutil.ReadDir(root)
+		if err != nil {
+			return err
+		}
+		for _, db := range dbs {
+			if !db.IsDir() {
+				s.Logger.Printf("Skipping database dir: %s. Not a directory", db.Name())
+				continue
+			} else if _, ok := s.databaseIndexes[db.Name()]; ok {
+				continue
+			}
+			s.databaseIndexes[db.Name()] = s.newDatabaseIndex(db.Name())
 		}
-		s.databaseIndexes[db.Name()] = NewDatabaseIndex()
 	}
 	return nil
 }
 
+// newDatabaseIndex returns an index for a database with its measurement hints
+// and field conflict mode.
+func (s *Store) newDatabaseIndex(name string) *DatabaseIndex {
+	db := NewDatabaseIndex()
+	db.patterns = measurementPatterns(s.MeasurementHints, name)
+	db.fieldConflictMode = fieldConflictMode(s.FieldConflictPolicies, name)
+	return db
+}
+
 func (s *Store) loadShards() error {
+	// Cold shards are loaded first so a shard left in both tiers by an
+	// interrupted move is opened from the cold tier.
+	roots := s.roots()
+	for i := len(roots) - 1; i >= 0; i-- {
+		if err := s.loadShardsIn(roots[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadShardsIn opens the shards of each database stored under root.
+func (s *Store) loadShardsIn(root string) error {
 	// loop through the current database indexes
 	for db := range s.databaseIndexes {
-		rps, err := ioutil.ReadDir(filepath.Join(s.path, db))
-		if err != nil {
+		rps, err := ioutil.ReadDir(filepath.Join(root, db))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
 			return err
 		}
 
@@ -201,22 +544,59 @@ func (s *Store) loadShards() error {
 				continue
 			}
 
-			shards, err := ioutil.ReadDir(filepath.Join(s.path, db, rp.Name()))
+			shards, err := ioutil.ReadDir(filepath.Join(root, db, rp.Name()))
 			if err != nil {
 				return err
 			}
 			for _, sh := range shards {
-				path := filepath.Join(s.path, db, rp.Name(), sh.Name())
+				name := sh.Name()
+
+				// Index snapshots are loaded along with their shard.
+				if strings.HasSuffix(name, IndexExt) || strings.HasSuffix(name, IndexExt+".tmp") {
+					continue
+				}
+
+				// Archived shards may only have a manifest. Shards that also
+				// have a cached data file are opened from the data file.
+				if strings.HasSuffix(name, ArchiveExt) {
+					name = strings.TrimSuffix(name, ArchiveExt)
+					if _, err := os.Stat(filepath.Join(root, db, rp.Name(), name)); err == nil {
+						continue
+					}
+				}
+				path := filepath.Join(root, db, rp.Name(), name)
 
 				// Shard file names are numeric shardIDs
-				shardID, err := strconv.ParseUint(sh.Name(), 10, 64)
+				shardID, err := strconv.ParseUint(name, 10, 64)
 				if err != nil {
 					s.Logger.Printf("Skipping shard: %s. Not a valid path", rp.Name())
 					continue
 				}
 
-				shard := NewShard(s.databaseIndexes[db], path)
-				shard.Open()
+				// The shard was moved to the cold tier before its hot copy
+				// could be removed.
+				if _, ok := s.shards[shardID]; ok {
+					s.Logger.Printf("Removing shard: %s. Already moved to the cold tier", path)
+					if err := os.Remove(path); err != nil {
+						return err
+					}
+					if err := os.Remove(path + IndexExt); err != nil && !os.IsNotExist(err) {
+						return err
+					}
+					continue
+				}
+
+				open := openShard
+				if s.LazyShardOpen {
+					open = openShardDeferred
+				}
+				// A shard that can't be opened, such as one written by a
+				// newer release, is left on disk for the others to be served.
+				shard := s.newShard(db, s.databaseIndexes[db], path)
+				if err := open(shard); err != nil {
+					s.Logger.Printf("Skipping shard: %s. Failed to open: %s", path, err)
+					continue
+				}
 				s.shards[shardID] = shard
 			}
 		}
@@ -225,16 +605,34 @@ func (s *Store) loadShards() error {
 
 }
 
+// newShard returns a shard of database stored at path with the store's
+// shard settings. The shard isn't opened.
+func (s *Store) newShard(database string, index *DatabaseIndex, path string) *Shard {
+	sh := NewShard(index, path)
+	sh.database = database
+	sh.commitWindow = s.GroupCommitWindow
+	sh.mmapSize = s.ShardMmapSize
+	if s.HotCacheSize > 0 {
+		sh.hotCache = newHotCache(s.HotCacheSize)
+	}
+	return sh
+}
+
 func (s *Store) Open() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.shards = map[uint64]*Shard{}
 	s.databaseIndexes = map[string]*DatabaseIndex{}
+	if s.MaxWriteQueueBytesPerDatabase > 0 {
+		s.writeQueue = newWriteQueue(s.MaxWriteQueueBytesPerDatabase)
+	}
 
-	// Create directory.
-	if err := os.MkdirAll(s.path, 0777); err != nil {
-		return err
+	// Create directories.
+	for _, root := range s.roots() {
+		if err := os.MkdirAll(root, 0777); err != nil {
+			return err
+		}
 	}
 
 	// TODO: Start AE for Node
@@ -246,21 +644,94 @@ func (s *Store) Open() error {
 		return err
 	}
 
+	if s.IndexSnapshotInterval > 0 || s.LazyShardOpen {
+		s.closing = make(chan struct{})
+	}
+	if s.IndexSnapshotInterval > 0 {
+		s.wg.Add(1)
+		go s.snapshotIndexesEvery(s.IndexSnapshotInterval, s.closing)
+	}
+	if s.LazyShardOpen {
+		s.wg.Add(1)
+		go s.warmShards(s.closing)
+	}
+
 	return nil
 }
 
 func (s *Store) WriteToShard(shardID uint64, points []Point) error {
+	// Writes to an archived shard return it to local storage. The shard is
+	// fetched before the store lock is taken so a slow download doesn't block
+	// the rest of the store.
 	s.mu.RLock()
-	defer s.mu.RUnlock()
 	sh, ok := s.shards[shardID]
+	s.mu.RUnlock()
 	if !ok {
 		return ErrShardNotFound
+	} else if err := s.restoreShard(sh); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.shards[shardID] != sh {
+		return ErrShardNotFound
+	}
+
+	if s.MaxPointsPerBatch > 0 && len(points) > s.MaxPointsPerBatch {
+		return fmt.Errorf("%s: %d points, limit %d", ErrMaxPointsPerBatchExceeded, len(points), s.MaxPointsPerBatch)
 	}
 
-	return sh.WritePoints(points)
+	// Store the points at the configured precision, whichever node or
+	// service they were written through.
+	points = TruncatePoints(points, s.Precision)
+
+	// Hold the write's bytes against its database until it's committed.
+	if s.writeQueue != nil {
+		n := writeSize(points)
+		if err := s.writeQueue.acquire(sh.database, n); err != nil {
+			return err
+		}
+		defer s.writeQueue.release(sh.database, n)
+	}
+
+	// The shard may have been archived again since it was restored.
+	if err := s.restoreShard(sh); err != nil {
+		return err
+	}
+
+	// Drop points creating more series than the node or cluster allows.
+	maxSeriesN := s.MaxSeriesPerDatabase
+	if s.MetaLimits != nil {
+		l, err := s.MetaLimits.Limits()
+		if err != nil {
+			return err
+		}
+		if n := l.MaxSeriesPerDatabase; n > 0 && (maxSeriesN == 0 || n < maxSeriesN) {
+			maxSeriesN = n
+		}
+	}
+
+	return sh.writePoints(points, maxSeriesN, s.MaxValuesPerTag, s.SeriesKeyLimits)
 }
 
 func (s *Store) Close() error {
+	// Stop writing index snapshots in the background and write a final
+	// snapshot so the next start doesn't need to scan the shards.
+	s.mu.Lock()
+	closing := s.closing
+	s.closing = nil
+	s.mu.Unlock()
+	if closing != nil {
+		close(closing)
+		s.wg.Wait()
+	}
+	if closing != nil && s.IndexSnapshotInterval > 0 {
+		if _, err := s.SnapshotIndexes(); err != nil {
+			s.Logger.Printf("failed to snapshot indexes: %s", err)
+		}
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -284,5 +755,17 @@ func IsRetryable(err error) bool {
 	if strings.Contains(err.Error(), "field type conflict") {
 		return false
 	}
+	if strings.Contains(err.Error(), "partial write") {
+		return false
+	}
+	if strings.Contains(err.Error(), ErrMaxValuesPerTagExceeded.Error()) {
+		return false
+	}
+	if strings.Contains(err.Error(), ErrMaxPointsPerBatchExceeded.Error()) {
+		return false
+	}
+	if strings.Contains(err.Error(), ErrInvalidSeriesKey.Error()) {
+		return false
+	}
 	return true
 }
@@ -1,11 +1,22 @@
 package tsdb
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
 )
 
 func TestStoreOpen(t *testing.T) {
@@ -115,6 +126,118 @@ func TestStoreOpenShardCreateDelete(t *testing.T) {
 	}
 }
 
+func TestStoreRenameDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+	pt := NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+	if err := s.WriteToShard(1, []Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	if err := s.RenameDatabase("mydb", "newdb"); err != nil {
+		t.Fatalf("failed to rename database: %v", err)
+	} else if s.DatabaseIndex("mydb") != nil || s.DatabaseIndex("newdb") == nil {
+		t.Fatalf("unexpected database indexes: %v", s.databaseIndexes)
+	} else if exp := filepath.Join(dir, "newdb", "myrp", "1"); s.Shard(1).Path() != exp {
+		t.Fatalf("unexpected shard path: got %s, exp %s", s.Shard(1).Path(), exp)
+	}
+
+	// The shard can still be written to and is loaded under the new name.
+	if err := s.WriteToShard(1, []Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+	s.Close()
+
+	s = NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if s.Measurement("newdb", "cpu") == nil {
+		t.Fatal("measurement not found in renamed database")
+	}
+}
+
+// Ensure local databases are renamed to the database owning their shards.
+func TestStoreSyncDatabaseNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	for _, id := range []uint64{1, 2} {
+		if err := s.CreateShard("mydb", "myrp", id); err != nil {
+			t.Fatalf("failed to create shard: %v", err)
+		}
+	}
+	if err := s.CreateShard("otherdb", "myrp", 3); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+
+	// Shard 2 was dropped from the metadata.
+	owners := map[uint64]string{1: "newdb", 3: "otherdb"}
+	if err := s.SyncDatabaseNames(func(id uint64) string { return owners[id] }); err != nil {
+		t.Fatal(err)
+	} else if s.DatabaseIndex("mydb") != nil || s.DatabaseIndex("newdb") == nil || s.DatabaseIndex("otherdb") == nil {
+		t.Fatalf("unexpected database indexes: %v", s.databaseIndexes)
+	} else if sh := s.Shard(2); sh.database != "newdb" || sh.Path() != filepath.Join(dir, "newdb", "myrp", "2") {
+		t.Fatalf("unexpected shard: %s %s", sh.database, sh.Path())
+	}
+
+	// Syncing again changes nothing.
+	if err := s.SyncDatabaseNames(func(id uint64) string { return owners[id] }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreShardSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+
+	fi, err := os.Stat(s.Shard(1).Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := s.ShardSize(1); !ok || n != fi.Size() {
+		t.Fatalf("unexpected shard size: %d, %v", n, ok)
+	}
+
+	// Shards that aren't stored locally have no size.
+	if _, ok := s.ShardSize(2); ok {
+		t.Fatal("expected no size for missing shard")
+	}
+}
+
 func TestStoreOpenNotDatabaseDir(t *testing.T) {
 	dir, err := ioutil.TempDir("", "store_test")
 	if err != nil {
@@ -212,6 +335,298 @@ func TestStoreOpenShardBadShardPath(t *testing.T) {
 
 }
 
+// Ensure a shard moved to the cold tier is still queried and is reopened from
+// the cold path.
+func TestStoreMoveShardToColdTier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	hot, cold := filepath.Join(dir, "hot"), filepath.Join(dir, "cold")
+
+	s := NewStore(hot)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	} else if err := s.MoveShardToColdTier(1); err != ErrColdPathNotSet {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Close()
+
+	s = NewStore(hot)
+	s.ColdPath = cold
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	if err := s.CreateShard("mydb", "myrp", 2); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+	pt := NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+	if err := s.WriteToShard(1, []Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	// Move the shard and ensure only the cold copy remains.
+	if err := s.MoveShardToColdTier(1); err != nil {
+		t.Fatalf("Store.MoveShardToColdTier() failed: %v", err)
+	} else if !s.ShardCold(1) || s.ShardCold(2) {
+		t.Fatal("unexpected shard tiers")
+	} else if _, err := os.Stat(filepath.Join(hot, "mydb", "myrp", "1")); !os.IsNotExist(err) {
+		t.Fatalf("hot copy not removed: %v", err)
+	} else if _, err := os.Stat(filepath.Join(cold, "mydb", "myrp", "1")); err != nil {
+		t.Fatalf("cold copy not written: %v", err)
+	} else if _, err := os.Stat(filepath.Join(hot, "mydb", "myrp", "1") + IndexExt); !os.IsNotExist(err) {
+		t.Fatalf("hot index snapshot not removed: %v", err)
+	}
+
+	// The index snapshot is written with the cold copy at the same transaction.
+	if txID, err := indexSnapshotTxID(filepath.Join(cold, "mydb", "myrp", "1")); err != nil {
+		t.Fatalf("cold index snapshot not written: %v", err)
+	} else if sh := s.Shard(1); txID != sh.indexTxID {
+		t.Fatalf("unexpected index snapshot transaction: %d != %d", txID, sh.indexTxID)
+	}
+
+	// The moved shard is still written to and read from.
+	pt = NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0))
+	if err := s.WriteToShard(1, []Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if n := shardPointN(t, s.Shard(1)); n != 2 {
+		t.Fatalf("unexpected point count: %d", n)
+	}
+
+	// Reopen the store and ensure shards are loaded from both tiers.
+	s.Close()
+	s = NewStore(hot)
+	s.ColdPath = cold
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if s.Shard(1) == nil || s.Shard(2) == nil {
+		t.Fatal("shards not loaded")
+	} else if !s.ShardCold(1) || s.ShardCold(2) {
+		t.Fatal("unexpected shard tiers")
+	} else if m := s.Measurement("mydb", "cpu"); m == nil || len(m.SeriesKeys()) != 1 {
+		t.Fatal("series not loaded from cold shard")
+	} else if n := shardPointN(t, s.Shard(1)); n != 2 {
+		t.Fatalf("unexpected point count: %d", n)
+	}
+}
+
+// shardPointN returns the number of points stored in a shard.
+func shardPointN(t *testing.T, sh *Shard) int {
+	var buf bytes.Buffer
+	n, err := sh.ExportPoints(&buf, math.MinInt64, math.MaxInt64)
+	if err != nil {
+		t.Fatalf("Shard.ExportPoints() failed: %v", err)
+	}
+	return n
+}
+
+func TestStoreArchiveShard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive := &memShardArchive{objects: make(map[string][]byte)}
+	s := NewStore(dir)
+	s.Archive = archive
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+	pt := NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+	if err := s.WriteToShard(1, []Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	// Archive the shard and ensure only the manifest remains locally.
+	shardPath := filepath.Join(dir, "mydb", "myrp", "1")
+	if err := s.ArchiveShard(1); err != nil {
+		t.Fatalf("Store.ArchiveShard() failed: %v", err)
+	} else if _, ok := archive.objects["mydb/myrp/1"]; !ok {
+		t.Fatal("shard not uploaded")
+	} else if _, err := os.Stat(shardPath); !os.IsNotExist(err) {
+		t.Fatalf("shard data file not removed: %v", err)
+	} else if _, err := os.Stat(shardPath + ArchiveExt); err != nil {
+		t.Fatalf("archive manifest not written: %v", err)
+	}
+
+	// Reopen the store and ensure the index is loaded from the manifest.
+	s.Close()
+	s = NewStore(dir)
+	s.Archive = archive
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if m := s.Measurement("mydb", "cpu"); m == nil || len(m.SeriesKeys()) != 1 {
+		t.Fatal("series not loaded from archive manifest")
+	} else if key := s.Shard(1).ArchiveKey(); key != "mydb/myrp/1" {
+		t.Fatalf("unexpected archive key: %s", key)
+	} else if !s.Shard(1).mayContainSeries([]string{"cpu,host=server"}) || s.Shard(1).mayContainSeries([]string{"cpu,host=other"}) {
+		t.Fatal("series filter not loaded from archive manifest")
+	}
+
+	// Fetching the shard copies its data back locally.
+	sh, err := s.FetchShard(1)
+	if err != nil {
+		t.Fatalf("Store.FetchShard() failed: %v", err)
+	} else if sh.FieldCodec("cpu") == nil {
+		t.Fatal("field codec not loaded")
+	} else if _, err := os.Stat(shardPath); err != nil {
+		t.Fatalf("shard data not fetched: %v", err)
+	}
+
+	// Idle cached copies are evicted.
+	if n, err := s.EvictArchivedShards(time.Hour); err != nil || n != 0 {
+		t.Fatalf("unexpected eviction: n=%d, err=%v", n, err)
+	} else if n, err := s.EvictArchivedShards(0); err != nil || n != 1 {
+		t.Fatalf("unexpected eviction: n=%d, err=%v", n, err)
+	} else if _, err := os.Stat(shardPath); !os.IsNotExist(err) {
+		t.Fatalf("cached shard not removed: %v", err)
+	}
+
+	// Writing to the shard returns it to local storage.
+	pt.SetTime(time.Unix(2, 3))
+	if err := s.WriteToShard(1, []Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if key := s.Shard(1).ArchiveKey(); key != "" {
+		t.Fatalf("shard still archived: %s", key)
+	} else if _, ok := archive.objects["mydb/myrp/1"]; ok {
+		t.Fatal("stale archived copy not deleted")
+	} else if _, err := os.Stat(shardPath + ArchiveExt); !os.IsNotExist(err) {
+		t.Fatalf("archive manifest not removed: %v", err)
+	}
+}
+
+func TestStoreDeleteArchivedShard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive := &memShardArchive{objects: make(map[string][]byte)}
+	s := NewStore(dir)
+	s.Archive = archive
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	} else if err := s.ArchiveShard(1); err != nil {
+		t.Fatalf("Store.ArchiveShard() failed: %v", err)
+	} else if err := s.DeleteShard(1); err != nil {
+		t.Fatalf("Store.DeleteShard() failed: %v", err)
+	}
+
+	if len(archive.objects) != 0 {
+		t.Fatal("archived shard not deleted")
+	} else if _, err := os.Stat(filepath.Join(dir, "mydb", "myrp", "1"+ArchiveExt)); !os.IsNotExist(err) {
+		t.Fatalf("archive manifest not removed: %v", err)
+	}
+}
+
+// Ensure a write restoring an archived shard doesn't hold the store lock
+// while the shard is downloaded.
+func TestStoreWriteToShard_RestoreUnlocked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive := &memShardArchive{objects: make(map[string][]byte)}
+	s := NewStore(dir)
+	s.Archive = archive
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	} else if err := s.ArchiveShard(1); err != nil {
+		t.Fatalf("Store.ArchiveShard() failed: %v", err)
+	}
+
+	// Block the download until another shard has been created.
+	archive.getting = make(chan struct{})
+	archive.release = make(chan struct{})
+	errc := make(chan error, 1)
+	go func() {
+		pt := NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+		errc <- s.WriteToShard(1, []Point{pt})
+	}()
+	<-archive.getting
+	if err := s.CreateShard("mydb", "myrp", 2); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+	close(archive.release)
+
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if key := s.Shard(1).ArchiveKey(); key != "" {
+		t.Fatalf("shard still archived: %s", key)
+	} else if n := shardPointN(t, s.Shard(1)); n != 1 {
+		t.Fatalf("unexpected point count: %d", n)
+	}
+}
+
+// memShardArchive is an in-memory implementation of ShardArchive. If getting
+// is set, Get signals it and waits for release to be closed.
+type memShardArchive struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+
+	getting chan struct{}
+	release chan struct{}
+}
+
+func (a *memShardArchive) Put(key string, r io.Reader, size int64) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	} else if int64(len(buf)) != size {
+		return fmt.Errorf("put %s: read %d bytes, expected %d", key, len(buf), size)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.objects[key] = buf
+	return nil
+}
+
+func (a *memShardArchive) Get(key string) (io.ReadCloser, error) {
+	if a.getting != nil {
+		a.getting <- struct{}{}
+		<-a.release
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	buf, ok := a.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("not found: %s", key)
+	}
+	return ioutil.NopCloser(bytes.NewReader(buf)), nil
+}
+
+func (a *memShardArchive) Delete(key string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.objects, key)
+	return nil
+}
+
 func BenchmarkStoreOpen_200KSeries_100Shards(b *testing.B) { benchmarkStoreOpen(b, 64, 5, 5, 1, 100) }
 
 func benchmarkStoreOpen(b *testing.B, mCnt, tkCnt, tvCnt, pntCnt, shardCnt int) {
@@ -279,3 +694,416 @@ func chunkedWriteStoreShard(store *Store, shardID int, points []Point) {
 		end += chunkSz
 	}
 }
+
+func TestStoreExportImportShard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := NewStore(filepath.Join(dir, "src"))
+	if err := src.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer src.Close()
+	if err := src.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+	pt := NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+	if err := src.WriteToShard(1, []Point{pt}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportShard(1, &buf); err != nil {
+		t.Fatalf("Store.ExportShard() failed: %v", err)
+	} else if err := src.ExportShard(2, &buf); err != ErrShardNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dst := NewStore(filepath.Join(dir, "dst"))
+	if err := dst.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer dst.Close()
+	data := buf.Bytes()
+	if err := dst.ImportShard("mydb", "myrp", 1, bytes.NewReader(data)); err != nil {
+		t.Fatalf("Store.ImportShard() failed: %v", err)
+	} else if m := dst.Measurement("mydb", "cpu"); m == nil || len(m.SeriesKeys()) != 1 {
+		t.Fatal("series not indexed")
+	} else if dst.Shard(1).FieldCodec("cpu") == nil {
+		t.Fatal("field codec not loaded")
+	}
+
+	// Importing a newer copy over the shard adds the points written since.
+	pt2 := NewPoint("cpu", map[string]string{"host": "server2"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0))
+	if err := src.WriteToShard(1, []Point{pt2}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+	buf.Reset()
+	if err := src.ExportShard(1, &buf); err != nil {
+		t.Fatalf("Store.ExportShard() failed: %v", err)
+	} else if err := dst.ImportShard("mydb", "myrp", 1, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Store.ImportShard() failed: %v", err)
+	} else if err := dst.ImportShard("mydb", "myrp", 1, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Store.ImportShard() failed again: %v", err)
+	}
+
+	var out bytes.Buffer
+	if n, err := dst.Shard(1).ExportPoints(&out, math.MinInt64, math.MaxInt64); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("unexpected point count: %d\n%s", n, out.String())
+	}
+}
+
+func TestStoreCompactShard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+
+	var points []Point
+	for i := 0; i < 10000; i++ {
+		host := "a"
+		if i%2 == 0 {
+			host = "b"
+		}
+		points = append(points, NewPoint("cpu", map[string]string{"host": host}, map[string]interface{}{"value": float64(i)}, time.Unix(int64(i), 0)))
+	}
+	if err := s.WriteToShard(1, points); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	// Deleting the points of one host leaves free pages in the data file.
+	cond := &influxql.BinaryExpr{Op: influxql.EQ, LHS: &influxql.VarRef{Val: "host"}, RHS: &influxql.StringLiteral{Val: "b"}}
+	if err := s.DeleteSeriesRange(1, "cpu", cond, math.MinInt64, math.MaxInt64); err != nil {
+		t.Fatalf("failed to delete series: %v", err)
+	}
+
+	if n, err := s.CompactShard(1); err != nil {
+		t.Fatalf("Store.CompactShard() failed: %v", err)
+	} else if n <= 0 {
+		t.Fatalf("unexpected bytes reclaimed: %d", n)
+	} else if err := s.Shard(1).DB().Update(func(*bolt.Tx) error { return nil }); err != nil {
+		t.Fatalf("shard not writable: %v", err)
+	} else if n := shardPointN(t, s.Shard(1)); n != 5000 {
+		t.Fatalf("unexpected points after compaction: %d", n)
+	}
+
+	// The buckets are copied in a single batch, followed by the write above.
+	sh := s.Shard(1)
+	sh.mu.RLock()
+	txID, err := sh.txID()
+	sh.mu.RUnlock()
+	if err != nil {
+		t.Fatal(err)
+	} else if txID != 3 {
+		t.Fatalf("unexpected compacted transaction: %d", txID)
+	}
+
+	if _, err := s.CompactShard(2); err != ErrShardNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStoreMeasurementHints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	s.MeasurementHints = []MeasurementHint{
+		{Measurement: "cpu", Pattern: IngestSparse},
+		{Measurement: "mem", Pattern: IngestHighChurn},
+		{Database: "mydb", Measurement: "mem", Pattern: IngestAppendOnly},
+	}
+	s.HotCacheSize = 1 << 20
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+
+	// Database hints take precedence over global hints.
+	if p := s.databaseIndexes["mydb"].patterns; len(p) != 2 || p["cpu"] != IngestSparse || p["mem"] != IngestAppendOnly {
+		t.Fatalf("unexpected patterns: %v", p)
+	} else if fillPercent(p["mem"]) != appendOnlyFillPercent {
+		t.Fatalf("unexpected fill percent: %v", fillPercent(p["mem"]))
+	}
+
+	// Sparse measurements aren't added to the hot cache.
+	if err := s.WriteToShard(1, []Point{
+		NewPoint("cpu", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2)),
+		NewPoint("mem", map[string]string{"host": "server"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2)),
+	}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+	if c := s.shards[1].hotCache; c.has("cpu,host=server") {
+		t.Fatal("unexpected cpu series in hot cache")
+	} else if !c.has("mem,host=server") {
+		t.Fatal("expected mem series in hot cache")
+	}
+}
+
+// Ensure the newest point of each series is returned across shards, from
+// the hot cache or from the data file for series that aren't cached.
+func TestStoreLastPoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	} else if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	} else if err := s.WriteToShard(1, []Point{
+		NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+		NewPoint("cpu", Tags{"host": "b"}, Fields{"value": 2.0}, time.Unix(2, 0)),
+		NewPoint("cpu", Tags{"host": "b"}, Fields{"value": 1.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+	s.Close()
+
+	s = NewStore(dir)
+	s.HotCacheSize = 1 << 20
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.CreateShard("mydb", "myrp", 2); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	} else if err := s.WriteToShard(2, []Point{NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 3.0}, time.Unix(3, 0))}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	exp := []string{
+		"cpu,host=a value=3.0 3000000000",
+		"cpu,host=b value=2.0 2000000000",
+	}
+	if a, err := s.LastPoints("mydb", "myrp", "cpu"); err != nil {
+		t.Fatal(err)
+	} else if got := pointStrings(a); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected points:\n\texp=%v\n\tgot=%v", exp, got)
+	}
+
+	// Points of other retention policies aren't returned.
+	if err := s.CreateShard("mydb", "otherrp", 3); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	} else if err := s.WriteToShard(3, []Point{NewPoint("cpu", Tags{"host": "a"}, Fields{"value": 4.0}, time.Unix(4, 0))}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if a, err := s.LastPoints("mydb", "myrp", "cpu"); err != nil {
+		t.Fatal(err)
+	} else if got := pointStrings(a); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected points:\n\texp=%v\n\tgot=%v", exp, got)
+	} else if a, err := s.LastPoints("mydb", "otherrp", "cpu"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || !a[0].Time().Equal(time.Unix(4, 0)) {
+		t.Fatalf("unexpected points of other policy: %v", a)
+	}
+
+	// Points of a deleted shard are no longer returned.
+	if err := s.DeleteShard(2); err != nil {
+		t.Fatalf("failed to delete shard: %v", err)
+	} else if a, err := s.LastPoints("mydb", "myrp", "cpu"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 2 || !a[0].Time().Equal(time.Unix(1, 0)) {
+		t.Fatalf("unexpected points after delete: %v", a)
+	}
+}
+
+// pointStrings returns the line protocol of each point.
+func pointStrings(points []Point) []string {
+	a := make([]string, len(points))
+	for i, p := range points {
+		a[i] = p.String()
+	}
+	return a
+}
+
+// Ensure a shard that can't be opened is skipped instead of failing the store.
+func TestStoreOpen_SkipBadShard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	} else if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	} else if err := s.CreateShard("mydb", "myrp", 2); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+	s.Close()
+
+	// Stamp shard 2 as written by a newer release.
+	db, err := bolt.Open(filepath.Join(dir, "mydb", "myrp", "2"), 0666, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(shardFormatBucket)).Put([]byte("format"), []byte(`{"version":100}`))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s = NewStore(dir)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if s.Shard(1) == nil {
+		t.Fatal("expected shard 1 to be open")
+	} else if s.Shard(2) != nil {
+		t.Fatal("expected shard 2 to be skipped")
+	}
+}
+
+func TestStoreMaxSeriesPerDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	s.MetaLimits = metaLimitsFunc(func() (meta.Limits, error) {
+		return meta.Limits{MaxSeriesPerDatabase: 2}, nil
+	})
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+
+	// Writing to existing series is allowed at the limit.
+	newPoint := func(host string) Point {
+		return NewPoint("cpu", map[string]string{"host": host}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+	}
+	if err := s.WriteToShard(1, []Point{newPoint("a"), newPoint("b"), newPoint("b")}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if err := s.WriteToShard(1, []Point{newPoint("a")}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	// Points creating series above the limit are dropped and the rest are written.
+	err = s.WriteToShard(1, []Point{newPoint("a"), newPoint("c"), newPoint("d")})
+	if perr, ok := err.(*PartialWriteError); !ok {
+		t.Fatalf("unexpected error: %v", err)
+	} else if perr.Reason != ErrMaxSeriesPerDatabaseExceeded {
+		t.Fatalf("unexpected reason: %v", perr.Reason)
+	} else if len(perr.Dropped) != 2 || string(perr.Dropped[0].Key()) != "cpu,host=c" || string(perr.Dropped[1].Key()) != "cpu,host=d" {
+		t.Fatalf("unexpected dropped points: %v", perr.Dropped)
+	} else if n := len(s.databaseIndexes["mydb"].series); n != 2 {
+		t.Fatalf("unexpected series count: %d", n)
+	}
+
+	// The node's limit applies without a cluster limit.
+	s.MetaLimits = nil
+	s.MaxSeriesPerDatabase = 3
+	if err := s.WriteToShard(1, []Point{newPoint("c")}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	} else if _, ok := s.WriteToShard(1, []Point{newPoint("d")}).(*PartialWriteError); !ok {
+		t.Fatal("expected partial write error")
+	}
+}
+
+func TestStoreMaxValuesPerTag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	s.MaxValuesPerTag = 2
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+
+	newPoint := func(name, host, region string) Point {
+		return NewPoint(name, map[string]string{"host": host, "region": region}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+	}
+	if err := s.WriteToShard(1, []Point{newPoint("cpu", "a", "us"), newPoint("cpu", "b", "us")}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	// New series reusing existing values and values of other measurements are allowed.
+	if err := s.WriteToShard(1, []Point{newPoint("cpu", "a", "eu"), newPoint("mem", "c", "us")}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	// A write giving a tag key a third value is rejected as a whole.
+	err = s.WriteToShard(1, []Point{newPoint("cpu", "a", "us"), newPoint("cpu", "c", "us")})
+	if err == nil || !strings.HasPrefix(err.Error(), ErrMaxValuesPerTagExceeded.Error()) {
+		t.Fatalf("unexpected error: %v", err)
+	} else if IsRetryable(err) {
+		t.Fatal("expected error not to be retryable")
+	} else if n := len(s.databaseIndexes["mydb"].series); n != 4 {
+		t.Fatalf("unexpected series count: %d", n)
+	}
+}
+
+func TestStoreSeriesKeyLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir)
+	s.SeriesKeyLimits = SeriesKeyLimits{MaxTags: 1}
+	if err := s.Open(); err != nil {
+		t.Fatalf("Store.Open() failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.CreateShard("mydb", "myrp", 1); err != nil {
+		t.Fatalf("failed to create shard: %v", err)
+	}
+
+	// Tag values longer than the default limit are allowed when unlimited.
+	long := strings.Repeat("a", DefaultMaxTagValueLength+1)
+	if err := s.WriteToShard(1, []Point{NewPoint("cpu", Tags{"host": long}, Fields{"value": 1.0}, time.Unix(1, 0))}); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+
+	err = s.WriteToShard(1, []Point{NewPoint("cpu", Tags{"host": "a", "region": "us"}, Fields{"value": 1.0}, time.Unix(1, 0))})
+	if err == nil || !strings.HasPrefix(err.Error(), ErrInvalidSeriesKey.Error()) {
+		t.Fatalf("unexpected error: %v", err)
+	} else if IsRetryable(err) {
+		t.Fatal("expected error not to be retryable")
+	}
+}
+
+// metaLimitsFunc is a function that implements Store.MetaLimits.
+type metaLimitsFunc func() (meta.Limits, error)
+
+func (fn metaLimitsFunc) Limits() (meta.Limits, error) { return fn() }
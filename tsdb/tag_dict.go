@@ -0,0 +1,62 @@
+package tsdb
+
+// tagDict interns the tag keys and values of the series in an index so each
+// distinct string is held once, however many series use it. Strings are
+// reference counted and removed once no series uses them.
+type tagDict struct {
+	entries map[string]*tagDictEntry
+}
+
+type tagDictEntry struct {
+	s    string
+	refs int
+}
+
+// newTagDict returns an empty dictionary.
+func newTagDict() *tagDict {
+	return &tagDict{entries: make(map[string]*tagDictEntry)}
+}
+
+// intern returns the dictionary's copy of s, adding a reference to it.
+func (d *tagDict) intern(s string) string {
+	if e := d.entries[s]; e != nil {
+		e.refs++
+		return e.s
+	}
+
+	// Copy the string so it doesn't keep the buffer it was parsed from.
+	e := &tagDictEntry{s: string([]byte(s)), refs: 1}
+	d.entries[e.s] = e
+	return e.s
+}
+
+// release removes a reference to s, removing it once it has none left.
+func (d *tagDict) release(s string) {
+	e := d.entries[s]
+	if e == nil {
+		return
+	}
+	if e.refs--; e.refs == 0 {
+		delete(d.entries, s)
+	}
+}
+
+// internTags returns a copy of tags with interned keys and values.
+func (d *tagDict) internTags(tags map[string]string) map[string]string {
+	other := make(map[string]string, len(tags))
+	for k, v := range tags {
+		other[d.intern(k)] = d.intern(v)
+	}
+	return other
+}
+
+// releaseTags removes a reference to each of the keys and values of tags.
+func (d *tagDict) releaseTags(tags map[string]string) {
+	for k, v := range tags {
+		d.release(k)
+		d.release(v)
+	}
+}
+
+// len returns the number of distinct strings in the dictionary.
+func (d *tagDict) len() int { return len(d.entries) }
@@ -0,0 +1,57 @@
+package tsdb
+
+import (
+	"testing"
+)
+
+// Ensure the dictionary holds each string once until its last reference is released.
+func TestTagDict(t *testing.T) {
+	d := newTagDict()
+	a := d.intern("server01")
+	if b := d.intern(string([]byte("server01"))); b != a {
+		t.Fatalf("unexpected interned string: %s", b)
+	} else if d.len() != 1 {
+		t.Fatalf("unexpected len: %d", d.len())
+	}
+
+	d.release("server01")
+	if d.len() != 1 {
+		t.Fatalf("string removed before last release")
+	}
+	d.release("server01")
+	if d.len() != 0 {
+		t.Fatalf("string not removed after last release")
+	}
+
+	// Releasing a string that isn't in the dictionary is ignored.
+	d.release("server01")
+}
+
+// Ensure the index shares the tags of its series and releases them when
+// they are dropped.
+func TestDatabaseIndex_TagDict(t *testing.T) {
+	idx := NewDatabaseIndex()
+	for _, s := range []*Series{
+		{Key: "cpu,host=a,region=west", Tags: map[string]string{"host": "a", "region": "west"}},
+		{Key: "cpu,host=b,region=west", Tags: map[string]string{"host": "b", "region": "west"}},
+		{Key: "mem,host=a,region=west", Tags: map[string]string{"host": "a", "region": "west"}},
+	} {
+		idx.createSeriesIndexIfNotExists(measurementFromSeriesKey(s.Key), s)
+	}
+
+	// host, region, west, a and b.
+	if n := idx.tags.len(); n != 5 {
+		t.Fatalf("unexpected dictionary size: %d", n)
+	}
+
+	idx.DropSeries([]string{"cpu,host=b,region=west"})
+	if n := idx.tags.len(); n != 4 {
+		t.Fatalf("unexpected dictionary size after dropping series: %d", n)
+	}
+
+	idx.DropMeasurement("cpu")
+	idx.DropMeasurement("mem")
+	if n := idx.tags.len(); n != 0 {
+		t.Fatalf("unexpected dictionary size after dropping measurements: %d", n)
+	}
+}
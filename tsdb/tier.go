@@ -0,0 +1,179 @@
+package tsdb
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boltdb/bolt"
+)
+
+var (
+	// ErrColdPathNotSet is returned when moving a shard to the cold tier
+	// without a cold path set on the store.
+	ErrColdPathNotSet = errors.New("cold path not set")
+)
+
+// MoveShardToColdTier moves a shard's data file and index snapshot from the
+// store's path to the same location under its cold path. The shard stays open
+// and queried as before. Moving a shard that is already cold is a no-op.
+func (s *Store) MoveShardToColdTier(shardID uint64) error {
+	s.mu.RLock()
+	sh, root := s.shards[shardID], s.ColdPath
+	s.mu.RUnlock()
+	if sh == nil {
+		return ErrShardNotFound
+	} else if root == "" {
+		return ErrColdPathNotSet
+	} else if sh.ArchiveKey() != "" {
+		return ErrShardArchived
+	} else if s.ShardCold(shardID) {
+		return nil
+	} else if err := sh.openDeferred(); err != nil {
+		return err
+	}
+
+	rel, err := s.shardRelPath(sh)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// Copy the shard and its index snapshot to the cold tier while writes are
+	// still allowed. The copy may be on another device so it is never renamed
+	// into place.
+	tmppath := path + ".tmp"
+	txID, err := sh.copyTo(tmppath, tmppath+IndexExt)
+	if err != nil {
+		os.Remove(tmppath)
+		os.Remove(tmppath + IndexExt)
+		return err
+	}
+	defer os.Remove(tmppath)
+	defer os.Remove(tmppath + IndexExt)
+
+	// Block writes while the shard is switched over.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shards[shardID] != sh {
+		return ErrShardNotFound
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	// Give up if the shard was written to during the copy so the cold copy
+	// is never stale.
+	if other, err := sh.txID(); err != nil {
+		return err
+	} else if other != txID {
+		return ErrShardModified
+	}
+
+	// Reads holding a pin would block closing the shard's store.
+	if sh.pins > 0 {
+		return ErrShardPinned
+	}
+
+	if err := os.Rename(tmppath, path); err != nil {
+		return err
+	} else if err := os.Rename(tmppath+IndexExt, path+IndexExt); err != nil {
+		return err
+	}
+	sh.indexTxID = txID
+
+	if err := sh.db.Close(); err != nil {
+		return err
+	}
+	db, err := sh.openDB(path)
+	if err != nil {
+		sh.db = nil
+		return err
+	}
+	old := sh.path
+	sh.db, sh.path = db, path
+
+	if err := os.Remove(old + IndexExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(old)
+}
+
+// ShardCold returns true if the shard's files are stored under the cold path.
+func (s *Store) ShardCold(shardID uint64) bool {
+	s.mu.RLock()
+	sh, root := s.shards[shardID], s.ColdPath
+	s.mu.RUnlock()
+	return sh != nil && root != "" && isWithin(root, sh.Path())
+}
+
+// shardRelPath returns the path of a shard's data file relative to the
+// root of the tier it is stored in.
+func (s *Store) shardRelPath(sh *Shard) (string, error) {
+	path := sh.Path()
+	if s.ColdPath != "" && isWithin(s.ColdPath, path) {
+		return filepath.Rel(s.ColdPath, path)
+	}
+	return filepath.Rel(s.path, path)
+}
+
+// roots returns the directories shards are stored in, hot tier first.
+func (s *Store) roots() []string {
+	if s.ColdPath == "" {
+		return []string{s.path}
+	}
+	return []string{s.path, s.ColdPath}
+}
+
+// copyTo writes a consistent copy of the shard's data file to path and an
+// index snapshot of the same transaction to indexPath. Returns the ID of the
+// transaction that was copied.
+func (s *Shard) copyTo(path, indexPath string) (int, error) {
+	var txID int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		txID = tx.ID()
+		if err := writeFileSync(path, func(w io.Writer) error {
+			_, err := tx.WriteTo(w)
+			return err
+		}); err != nil {
+			return err
+		}
+		return writeFileSync(indexPath, func(w io.Writer) error {
+			bw := bufio.NewWriter(w)
+			if err := encodeIndexSnapshot(bw, tx); err != nil {
+				return err
+			}
+			return bw.Flush()
+		})
+	})
+	return txID, err
+}
+
+// writeFileSync creates the file at path, writes it with fn and syncs it.
+func writeFileSync(path string, fn func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// isWithin returns true if path is inside the directory root.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
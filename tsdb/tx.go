@@ -1,6 +1,7 @@
 package tsdb
 
 import (
+	"container/heap"
 	"encoding/binary"
 	"fmt"
 	"math"
@@ -22,6 +23,9 @@ type tx struct {
 
 	meta  metaStore
 	store localStore
+
+	// cost, if set, is charged for the data read by the tx's mappers.
+	cost *QueryCost
 }
 
 type metaStore interface {
@@ -32,6 +36,9 @@ type localStore interface {
 	Measurement(database, name string) *Measurement
 	ValidateAggregateFieldsInStatement(shardID uint64, measurementName string, stmt *influxql.SelectStatement) error
 	Shard(shardID uint64) *Shard
+	FetchShard(shardID uint64) (*Shard, error)
+	beginRead(sh *Shard) (*bolt.Tx, error)
+	endRead(sh *Shard, tx *bolt.Tx)
 }
 
 // newTx return a new initialized Tx.
@@ -145,7 +152,17 @@ func (tx *tx) CreateMapReduceJobs(stmt *influxql.SelectStatement, tagKeys []stri
 			return nil, err
 		}
 
+		// Series that never wrote a selected field can be skipped when only
+		// points with a selected field are returned, which is the case for
+		// aggregates and raw queries of a single field.
+		skipSeries := len(selectFields) > 0 && len(selectTags) == 0 &&
+			(len(stmt.FunctionCalls()) > 0 || len(selectFields) == 1)
+
 		for _, t := range tagSets {
+			if skipSeries {
+				t = m.filterTagSetByFields(t, selectFields)
+			}
+
 			// make a job for each tagset
 			job := &influxql.MapReduceJob{
 				MeasurementName: m.Name,
@@ -163,8 +180,16 @@ func (tx *tx) CreateMapReduceJobs(stmt *influxql.SelectStatement, tagKeys []stri
 				if len(sg.Shards) != 1 {
 					return nil, fmt.Errorf("distributed queries aren't supported yet. You have a replication policy with RF < # of servers in cluster")
 				}
-				shard := tx.store.Shard(sg.Shards[0].ID)
-				if shard == nil {
+				// Skip the shard if none of the tag set's series were written to it,
+				// before an archived shard is fetched or a deferred one opened.
+				if sh := tx.store.Shard(sg.Shards[0].ID); sh != nil && !sh.mayContainSeries(t.SeriesKeys) {
+					continue
+				}
+
+				shard, err := tx.store.FetchShard(sg.Shards[0].ID)
+				if err != nil {
+					return nil, err
+				} else if shard == nil {
 					// the store returned nil which means we haven't written any data into this shard yet, so ignore it
 					continue
 				}
@@ -179,8 +204,11 @@ func (tx *tx) CreateMapReduceJobs(stmt *influxql.SelectStatement, tagKeys []stri
 				var mapper influxql.Mapper
 
 				mapper = &LocalMapper{
+					shardID:      sg.Shards[0].ID,
+					cost:         tx.cost,
 					seriesKeys:   t.SeriesKeys,
-					db:           shard.DB(),
+					store:        tx.store,
+					shard:        shard,
 					job:          job,
 					decoder:      codec,
 					filters:      t.Filters,
@@ -212,19 +240,25 @@ func (tx *tx) CreateMapReduceJobs(stmt *influxql.SelectStatement, tagKeys []stri
 
 // LocalMapper implements the influxql.Mapper interface for running map tasks over a shard that is local to this server
 type LocalMapper struct {
+	shardID          uint64                 // the shard accessed by this mapper
+	cost             *QueryCost             // the query cost charged for reads, if any
+	err              error                  // set if reading stopped because of an error
 	cursorsEmpty     bool                   // boolean that lets us know if the cursors are empty
 	decoder          *FieldCodec            // decoder for the raw data bytes
 	filters          []influxql.Expr        // filters for each series
-	cursors          []*bolt.Cursor         // bolt cursors for each series id
+	cursors          []cursor               // cursors for each series id
 	seriesKeys       []string               // seriesKeys to be read from this shard
-	db               *bolt.DB               // bolt store for the shard accessed by this mapper
+	store            localStore             // the store the shard belongs to
+	shard            *Shard                 // the shard accessed by this mapper
 	txn              *bolt.Tx               // read transactions by shard id
+	opened           time.Time              // when the read transaction began
 	job              *influxql.MapReduceJob // the MRJob this mapper belongs to
 	mapFunc          influxql.MapFunc       // the map func
 	fieldID          uint8                  // the field ID associated with the mapFunc curently being run
 	fieldName        string                 // the field name associated with the mapFunc currently being run
 	keyBuffer        []int64                // the current timestamp key for each cursor
 	valueBuffer      [][]byte               // the current value for each cursor
+	heap             cursorHeap             // the indexes of the non-empty cursors, by current timestamp
 	tmin             int64                  // the min of the current group by interval being iterated over
 	tmax             int64                  // the max of the current group by interval being iterated over
 	additionalNames  []string               // additional field or tag names that might be requested from the map function
@@ -240,17 +274,25 @@ type LocalMapper struct {
 
 // Open opens the LocalMapper.
 func (l *LocalMapper) Open() error {
-	// Open the data store
-	txn, err := l.db.Begin(false)
+	// Begin a read transaction, holding the shard's data file until the
+	// mapper is closed.
+	txn, err := l.store.beginRead(l.shard)
 	if err != nil {
 		return err
 	}
 	l.txn = txn
+	l.opened = time.Now()
 
-	// create a bolt cursor for each unique series id
-	l.cursors = make([]*bolt.Cursor, len(l.seriesKeys))
+	// create a cursor for each unique series id, reading from the shard's
+	// cache of recent points when it holds the whole time range
+	l.cursors = make([]cursor, len(l.seriesKeys))
 
 	for i, key := range l.seriesKeys {
+		if c := l.shard.hotCursor(key, l.job.TMin); c != nil {
+			l.cursors[i] = c
+			continue
+		}
+
 		b := l.txn.Bucket([]byte(key))
 		if b == nil {
 			continue
@@ -264,7 +306,11 @@ func (l *LocalMapper) Open() error {
 
 // Close closes the LocalMapper.
 func (l *LocalMapper) Close() {
-	_ = l.txn.Rollback()
+	if l.txn != nil {
+		l.store.endRead(l.shard, l.txn)
+		l.txn = nil
+		l.shard.addQuery(time.Since(l.opened))
+	}
 }
 
 // Begin will set up the mapper to run the map function for a given aggregate call starting at the passed in time
@@ -333,6 +379,7 @@ func (l *LocalMapper) Begin(c *influxql.Call, startingTime int64, chunkSize int)
 	}
 
 	// seek the bolt cursors and fill the buffers
+	l.heap = cursorHeap{keys: l.keyBuffer, indexes: make([]int, 0, len(l.cursors))}
 	for i, c := range l.cursors {
 		// this series may have never been written in this shard group (time range) so the cursor would be nil
 		if c == nil {
@@ -350,10 +397,25 @@ func (l *LocalMapper) Begin(c *influxql.Call, startingTime int64, chunkSize int)
 		t := int64(btou64(k))
 		l.keyBuffer[i] = t
 		l.valueBuffer[i] = v
+		if t != 0 {
+			l.heap.indexes = append(l.heap.indexes, i)
+		}
+		if err := l.charge(k, v); err != nil {
+			return err
+		}
 	}
+	heap.Init(&l.heap)
 	return nil
 }
 
+// charge records a block read by a cursor against the query's cost.
+func (l *LocalMapper) charge(k, v []byte) error {
+	if l.cost == nil {
+		return nil
+	}
+	return l.cost.Add(l.shardID, 1, int64(len(k)+len(v)))
+}
+
 // NextInterval will get the time ordered next interval of the given interval size from the mapper. This is a
 // forward only operation from the start time passed into Begin. Will return nil when there is no more data to be read.
 // If this is a raw query, interval should be the max time to hit in the query
@@ -381,15 +443,12 @@ func (l *LocalMapper) NextInterval() (interface{}, error) {
 
 	// Execute the map function. This local mapper acts as the iterator
 	val := l.mapFunc(l)
+	if l.err != nil {
+		return nil, l.err
+	}
 
 	// see if all the cursors are empty
-	l.cursorsEmpty = true
-	for _, k := range l.keyBuffer {
-		if k != 0 {
-			l.cursorsEmpty = false
-			break
-		}
-	}
+	l.cursorsEmpty = l.heap.Len() == 0
 
 	// Move the interval forward if it's not a raw query. For raw queries we use the limit to advance intervals.
 	if !l.isRaw {
@@ -408,18 +467,18 @@ func (l *LocalMapper) Next() (seriesKey string, timestamp int64, value interface
 			return "", int64(0), nil
 		}
 
-		// find the minimum timestamp
-		min := -1
-		minKey := int64(math.MaxInt64)
-		for i, k := range l.keyBuffer {
-			if k != 0 && k <= l.tmax && k < minKey && k >= l.tmin {
-				min = i
-				minKey = k
-			}
+		// stop reading if a previous read failed, e.g. went over the query's budget
+		if l.err != nil {
+			return "", int64(0), nil
 		}
 
-		// return if there is no more data in this group by interval
-		if min == -1 {
+		// find the minimum timestamp, returning if there is no more data in
+		// this group by interval
+		if l.heap.Len() == 0 {
+			return "", 0, nil
+		}
+		min := l.heap.indexes[0]
+		if k := l.keyBuffer[min]; k > l.tmax || k < l.tmin {
 			return "", 0, nil
 		}
 
@@ -467,7 +526,15 @@ func (l *LocalMapper) Next() (seriesKey string, timestamp int64, value interface
 		} else {
 			l.keyBuffer[min] = int64(btou64(nextKey))
 		}
+		if l.keyBuffer[min] == 0 {
+			heap.Pop(&l.heap)
+		} else {
+			heap.Fix(&l.heap, 0)
+		}
 		l.valueBuffer[min] = nextVal
+		if nextKey != nil {
+			l.err = l.charge(nextKey, nextVal)
+		}
 
 		// if the value didn't match our filter or if we didn't find the field keep iterating
 		if err != nil || value == nil {
@@ -490,15 +557,40 @@ func (l *LocalMapper) IsEmpty(tmax int64) bool {
 		return true
 	}
 
-	// look at the next time for each cursor
-	for _, t := range l.keyBuffer {
-		// if the time is less than the max, we haven't emptied this mapper yet
-		if t != 0 && t <= tmax {
-			return false
-		}
-	}
+	// if the earliest next time is less than the max, we haven't emptied
+	// this mapper yet
+	return l.heap.Len() == 0 || l.keyBuffer[l.heap.indexes[0]] > tmax
+}
 
-	return true
+// cursor iterates over the points of a series in key order.
+type cursor interface {
+	Seek(seek []byte) (key, value []byte)
+	Next() (key, value []byte)
+}
+
+// cursorHeap is a min-heap of the indexes of a mapper's cursors by their
+// current timestamp, so the next point is found without scanning every
+// cursor. Cursors with the same timestamp are ordered by index. Cursors with
+// a zero timestamp are empty and aren't in the heap.
+type cursorHeap struct {
+	keys    []int64 // the current timestamp for each cursor
+	indexes []int
+}
+
+func (h cursorHeap) Len() int { return len(h.indexes) }
+func (h cursorHeap) Less(i, j int) bool {
+	a, b := h.indexes[i], h.indexes[j]
+	if h.keys[a] != h.keys[b] {
+		return h.keys[a] < h.keys[b]
+	}
+	return a < b
+}
+func (h cursorHeap) Swap(i, j int)       { h.indexes[i], h.indexes[j] = h.indexes[j], h.indexes[i] }
+func (h *cursorHeap) Push(x interface{}) { h.indexes = append(h.indexes, x.(int)) }
+func (h *cursorHeap) Pop() interface{} {
+	i := h.indexes[len(h.indexes)-1]
+	h.indexes = h.indexes[:len(h.indexes)-1]
+	return i
 }
 
 // matchesFilter returns true if the value matches the where clause
@@ -0,0 +1,221 @@
+package tsdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// ShardVerification is the result of checking a shard's data file.
+type ShardVerification struct {
+	Series int // number of series checked
+	Points int // number of points checked
+
+	// CorruptSeries holds the keys of the series whose index entry or data
+	// couldn't be read, in key order.
+	CorruptSeries []string
+
+	// Problems describes each inconsistency found.
+	Problems []string
+}
+
+// OK returns true if no inconsistencies were found.
+func (v *ShardVerification) OK() bool { return len(v.Problems) == 0 }
+
+// VerifyShardFile checks the shard data file at path. The shard must not be
+// open by a running server. The file isn't modified.
+func VerifyShardFile(path string) (*ShardVerification, error) {
+	db, err := bolt.Open(path, 0666, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var v *ShardVerification
+	if err := db.View(func(tx *bolt.Tx) error {
+		v = verifyShard(tx)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// VerifyShard checks the data file of a shard while it is in use. The shard
+// is pinned and checked against a consistent view of its data so writes
+// continue during the check.
+func (s *Store) VerifyShard(shardID uint64) (*ShardVerification, error) {
+	sh := s.Shard(shardID)
+	if sh == nil {
+		return nil, ErrShardNotFound
+	}
+
+	tx, err := s.beginRead(sh)
+	if err != nil {
+		return nil, err
+	}
+	defer s.endRead(sh, tx)
+	return verifyShard(tx), nil
+}
+
+// verifyShard checks the pages of a shard's data file and that its index
+// matches its data. Every data bucket must have readable points for fields of
+// its measurement and be in the series index, and every series in the series
+// index must have a data bucket. Series indexes of shards written before
+// version 2 only list the series created in the shard, so their data buckets
+// don't need an index entry; it is added when the shard is opened.
+func verifyShard(tx *bolt.Tx) *ShardVerification {
+	sv := &shardVerifier{
+		ShardVerification: &ShardVerification{},
+		codecs:            make(map[string]*FieldCodec),
+		corrupt:           make(map[string]struct{}),
+	}
+
+	// Check the structure of the file's pages.
+	for err := range tx.Check() {
+		sv.fail("", "page: %s", err)
+	}
+
+	version, err := shardFormatVersion(tx)
+	if err != nil {
+		sv.fail("", "%s", err)
+	}
+
+	// Read the fields of each measurement.
+	if b := tx.Bucket([]byte("fields")); b == nil {
+		sv.fail("", "fields bucket missing")
+	} else {
+		_ = b.ForEach(func(k, buf []byte) error {
+			mf := &measurementFields{}
+			if err := mf.UnmarshalBinary(buf); err != nil {
+				sv.fail("", "measurement %s: invalid fields: %s", k, err)
+				return nil
+			}
+			sv.codecs[string(k)] = newFieldCodec(mf.Fields)
+			return nil
+		})
+	}
+
+	// Check each data bucket against its index entry.
+	b := tx.Bucket([]byte("series"))
+	if b == nil {
+		sv.fail("", "series bucket missing")
+	}
+	_ = tx.ForEach(func(name []byte, data *bolt.Bucket) error {
+		if isShardMetaBucket(name) {
+			return nil
+		}
+
+		var buf []byte
+		if b != nil {
+			buf = b.Get(name)
+		}
+		if buf == nil && version >= 2 {
+			// Data buckets that aren't in the index can't be queried.
+			sv.fail(string(name), "series %s: not in index", name)
+		}
+		sv.verifySeries(string(name), buf, data)
+		return nil
+	})
+
+	// Every series in the index must have data.
+	if b != nil {
+		_ = b.ForEach(func(k, _ []byte) error {
+			if tx.Bucket(k) == nil {
+				sv.Series++
+				sv.fail(string(k), "series %s: data missing", k)
+			}
+			return nil
+		})
+	}
+
+	for key := range sv.corrupt {
+		sv.CorruptSeries = append(sv.CorruptSeries, key)
+	}
+	sort.Strings(sv.CorruptSeries)
+	return sv.ShardVerification
+}
+
+// shardVerifier holds the state of a shard verification.
+type shardVerifier struct {
+	*ShardVerification
+	codecs  map[string]*FieldCodec // by measurement name
+	corrupt map[string]struct{}    // keys of corrupt series
+}
+
+// fail records a problem, marking the series with key as corrupt if set.
+func (sv *shardVerifier) fail(key, format string, a ...interface{}) {
+	if key != "" {
+		sv.corrupt[key] = struct{}{}
+	}
+	sv.Problems = append(sv.Problems, fmt.Sprintf(format, a...))
+}
+
+// verifySeries checks a series' index entry, if it has one, and the points
+// in its data bucket.
+func (sv *shardVerifier) verifySeries(key string, buf []byte, data *bolt.Bucket) {
+	sv.Series++
+
+	if buf != nil {
+		ss := &Series{}
+		if err := ss.UnmarshalBinary(buf); err != nil {
+			sv.fail(key, "series %s: invalid index entry: %s", key, err)
+		} else if ss.Key != key {
+			sv.fail(key, "series %s: index entry has key %s", key, ss.Key)
+		}
+	}
+
+	name := string(unescape([]byte(measurementFromSeriesKey(key))))
+	codec := sv.codecs[name]
+	if codec == nil {
+		sv.fail(key, "series %s: no fields for measurement %s", key, name)
+		return
+	}
+
+	var bad int
+	_ = data.ForEach(func(k, v []byte) error {
+		sv.Points++
+		if len(k) != 8 || codec.validate(v) != nil {
+			bad++
+		}
+		return nil
+	})
+	if bad > 0 {
+		sv.fail(key, "series %s: %d unreadable points", key, bad)
+	}
+}
+
+// validate returns an error if b isn't a valid encoding of the codec's fields.
+// Unlike DecodeFields it doesn't panic on truncated data.
+func (f *FieldCodec) validate(b []byte) error {
+	for len(b) > 0 {
+		field := f.fieldsByID[b[0]]
+		if field == nil {
+			return ErrFieldUnmappedID
+		}
+
+		var n int
+		switch field.Type {
+		case influxql.Float, influxql.Integer, influxql.Unsigned:
+			n = 9
+		case influxql.Boolean:
+			n = 2
+		case influxql.String:
+			if len(b) < 3 {
+				return fmt.Errorf("field %s truncated", field.Name)
+			}
+			n = 3 + int(binary.BigEndian.Uint16(b[1:3]))
+		default:
+			return fmt.Errorf("field %s has unsupported type %s", field.Name, field.Type)
+		}
+		if len(b) < n {
+			return fmt.Errorf("field %s truncated", field.Name)
+		}
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package tsdb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultMaxWriteQueueBytesPerDatabase is the default limit of bytes of
+// writes to a database waiting to be committed. Zero means unlimited.
+const DefaultMaxWriteQueueBytesPerDatabase = 0
+
+// ErrWriteQueueFull is returned when a database has more bytes of writes
+// waiting to be committed than it is allowed. The write can be retried.
+var ErrWriteQueueFull = errors.New("write queue full")
+
+// writeQueue tracks the bytes of writes to each database that haven't been
+// committed yet, so a database written to faster than its shards commit
+// can't hold up the writes to every other database.
+type writeQueue struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    map[string]int // database name to queued bytes
+}
+
+// newWriteQueue returns a queue allowing maxBytes per database.
+func newWriteQueue(maxBytes int) *writeQueue {
+	return &writeQueue{
+		maxBytes: maxBytes,
+		bytes:    make(map[string]int),
+	}
+}
+
+// acquire adds n bytes to the database's queue. Returns ErrWriteQueueFull
+// if the queue would be over its limit. A write larger than the limit is
+// allowed while nothing else is queued so it isn't rejected forever.
+func (q *writeQueue) acquire(database string, n int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	queued := q.bytes[database]
+	if queued > 0 && queued+n > q.maxBytes {
+		return fmt.Errorf("%s: database %s has %d bytes queued, limit %d", ErrWriteQueueFull, database, queued, q.maxBytes)
+	}
+	q.bytes[database] = queued + n
+	return nil
+}
+
+// release removes n bytes from the database's queue once they're committed.
+func (q *writeQueue) release(database string, n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.bytes[database] -= n; q.bytes[database] <= 0 {
+		delete(q.bytes, database)
+	}
+}
+
+// queued returns the bytes queued for a database.
+func (q *writeQueue) queued(database string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.bytes[database]
+}
+
+// writeSize returns the approximate bytes held in memory by points.
+func writeSize(points []Point) int {
+	var n int
+	for _, p := range points {
+		n += pointSize(p)
+	}
+	return n
+}
@@ -0,0 +1,36 @@
+package tsdb
+
+import (
+	"strings"
+	"testing"
+)
+
+// Ensure each database's queued writes are limited separately.
+func TestWriteQueue(t *testing.T) {
+	q := newWriteQueue(100)
+
+	// A write over the limit is allowed while nothing else is queued.
+	if err := q.acquire("db0", 150); err != nil {
+		t.Fatal(err)
+	} else if err := q.acquire("db0", 1); err == nil || !strings.Contains(err.Error(), ErrWriteQueueFull.Error()) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Other databases aren't held up by db0.
+	if err := q.acquire("db1", 60); err != nil {
+		t.Fatal(err)
+	} else if err := q.acquire("db1", 40); err != nil {
+		t.Fatal(err)
+	} else if err := q.acquire("db1", 1); err == nil {
+		t.Fatal("expected error")
+	}
+
+	q.release("db0", 150)
+	if n := q.queued("db0"); n != 0 {
+		t.Fatalf("unexpected queued bytes: %d", n)
+	} else if err := q.acquire("db0", 1); err != nil {
+		t.Fatal(err)
+	} else if n := q.queued("db1"); n != 100 {
+		t.Fatalf("unexpected queued bytes: %d", n)
+	}
+}